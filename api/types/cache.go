@@ -44,4 +44,57 @@ type Cache interface {
 	// Returns:
 	//   - map[string]interface{}: map of matching key-value pairs
 	GetByPrefix(prefix string) map[string]interface{}
+
+	// Incr atomically adds delta to the integer value of the key
+	// Parameters:
+	//   - key: cache key (string)
+	//   - delta: value to add (int64)
+	//   - ttl: time-to-live used only when the key does not exist or has expired, the expiration of an existing key is kept
+	// Returns:
+	//   - int64: the new value, the key is created with value delta if not exists
+	//   - error: ErrCacheNotInteger if the stored value is not an integer, or if ttl format is invalid
+	Incr(key string, delta int64, ttl string) (int64, error)
+	// Decr atomically subtracts delta from the integer value of the key, same as Incr(key, -delta, ttl)
+	Decr(key string, delta int64, ttl string) (int64, error)
+	// CompareAndSet atomically sets the value of the key if the current value equals expected
+	// Parameters:
+	//   - key: cache key (string)
+	//   - expected: expected current value, nil means the key must not exist or has expired
+	//   - value: new value to store (interface{})
+	//   - ttl: time-to-live duration string of the new value
+	// Returns:
+	//   - bool: true if the value is set
+	//   - error: returns error if ttl format is invalid
+	// Note: values are equal if they are deeply equal or their string forms are equal, e.g. 1 and "1"
+	CompareAndSet(key string, expected, value interface{}, ttl string) (bool, error)
+
+	// ListPush atomically appends values to the tail of the list of the key
+	// Parameters:
+	//   - key: cache key (string)
+	//   - ttl: time-to-live used only when the list does not exist or has expired, the expiration of an existing list is kept
+	//   - values: values to append
+	// Returns:
+	//   - int: the length of the list after the push
+	//   - error: ErrCacheNotList if the stored value is not a list, or if ttl format is invalid
+	ListPush(key string, ttl string, values ...interface{}) (int, error)
+	// ListTrim atomically keeps only the items from start to stop (inclusive) of the list,
+	// negative indexes count from the tail, e.g. ListTrim(key, -10, -1) keeps the last 10 items.
+	// The key is deleted if no items are left.
+	// Returns:
+	//   - error: ErrCacheNotList if the stored value is not a list
+	ListTrim(key string, start, stop int) error
+	// ListRange returns the items from start to stop (inclusive) of the list,
+	// negative indexes count from the tail, e.g. ListRange(key, 0, -1) returns all items.
+	// Returns:
+	//   - []interface{}: the items, empty if the key does not exist
+	//   - error: ErrCacheNotList if the stored value is not a list
+	ListRange(key string, start, stop int) ([]interface{}, error)
+}
+
+// PersistentCache is implemented by caches that keep items across restarts, e.g. cache.BoltCache.
+// The rule engine keeps the rule chain cache items of a persistent cache when the rule engine stops.
+type PersistentCache interface {
+	Cache
+	// Persistent reports whether the items are kept across restarts
+	Persistent() bool
 }
@@ -82,6 +82,14 @@ type Config struct {
 	// 当脚本超过此时间限制时，将被终止并返回错误。
 	//
 	ScriptMaxExecutionTime time.Duration
+	// ScriptLimits are the per-execution resource limits of JavaScript scripts. Zero values mean unlimited.
+	// ScriptLimits 是 JavaScript 脚本每次执行的资源限制。零值表示不限制。
+	//
+	// Exceeding a limit interrupts the script and returns a typed error (see utils/js ErrCallStackLimit,
+	// ErrLoopLimit and ErrAllocLimit), the VM is discarded instead of reused.
+	// 超出限制会中断脚本并返回类型化错误（见 utils/js 的 ErrCallStackLimit、ErrLoopLimit 和 ErrAllocLimit），
+	// 该虚拟机会被丢弃而不是复用。
+	ScriptLimits ScriptLimits
 	// Pool is the interface for a coroutine pool. If not configured, the go func method is used by default.
 	// The default implementation is `pool.WorkerPool`. It is compatible with ants coroutine pool and can be implemented using ants.
 	// Example:
@@ -241,6 +249,32 @@ type Config struct {
 	//	memCache := cache.NewMemoryCache(time.Minute * 10)
 	//	config := NewConfig(WithCache(memCache))
 	Cache Cache
+	// JsStdLibEnabled indicates whether the `$lib` standard library is injected into the JavaScript runtime.
+	// JsStdLibEnabled 表示是否在 JavaScript 运行时中注入 `$lib` 标准库。
+	//
+	// The standard library provides side-effect-free helpers, such as:
+	// 标准库提供无副作用的辅助函数，例如：
+	//   $lib.encoding.base64Encode(str) / $lib.encoding.hexDecode(str)
+	//   $lib.crypto.sha256(str) / $lib.crypto.hmacSha256(key, str)
+	//   $lib.time.format(timestamp, "yyyy-MM-dd HH:mm:ss") / $lib.uuid()
+	//   $lib.json.get(obj, "a.b.c")
+	JsStdLibEnabled bool
+	// JsHttpAllowHosts is the host allow-list of the `$lib.http` helpers. The HTTP helpers are disabled when it is empty.
+	// Supports exact hosts (`api.example.com`, `127.0.0.1:8080`) and wildcard subdomains (`*.example.com`).
+	// JsHttpAllowHosts 是 `$lib.http` 辅助函数的主机白名单。为空时禁用 HTTP 辅助函数。
+	// 支持精确主机（`api.example.com`、`127.0.0.1:8080`）和通配子域名（`*.example.com`）。
+	JsHttpAllowHosts []string
+	// ScriptLibraries is the registry of shared script libraries, which JavaScript scripts reference through `require` or `import`.
+	// ScriptLibraries 是共享脚本库注册表，JavaScript 脚本通过 `require` 或者 `import` 引用。
+	//
+	// Example:
+	// 示例：
+	//   import { round2 } from 'mathUtils';
+	//   var utils = require('mathUtils');
+	ScriptLibraries *ScriptLibraryRegistry
+	// TemplatePartials is the registry of shared template partials, which template nodes reference by name.
+	// TemplatePartials 是共享模板片段注册表，模板节点通过名称引用。
+	TemplatePartials *TemplatePartialRegistry
 }
 
 // RegisterUdf registers a custom function. Function names can be repeated for different script types.
@@ -344,3 +378,28 @@ func DefaultPool() Pool {
 	wp.Start()
 	return wp
 }
+
+// ScriptLimits defines the per-execution resource limits of scripts. Zero values mean unlimited.
+// ScriptLimits 定义脚本每次执行的资源限制。零值表示不限制。
+//
+// Memory is not measured. Allocation is bounded only through MaxArrayLength and MaxStringLength,
+// which are checked by the Array and String builtins. Array literals, index assignment and the +
+// operator are not checked, their growth is bounded by MaxLoopIterations and ScriptMaxExecutionTime.
+// 不统计内存占用。只通过 Array 和 String 内置函数检查 MaxArrayLength 和 MaxStringLength 来限制分配，
+// 数组字面量、下标赋值和 + 运算符不检查，它们的增长由 MaxLoopIterations 和 ScriptMaxExecutionTime 限制。
+type ScriptLimits struct {
+	// MaxCallStackSize is the maximum function call depth.
+	// MaxCallStackSize 最大函数调用深度。
+	MaxCallStackSize int
+	// MaxLoopIterations is the maximum total number of loop iterations of an execution.
+	// The elements visited by Array builtins such as forEach, map and Array.from count as iterations.
+	// MaxLoopIterations 单次执行中所有循环的最大迭代次数。
+	// forEach、map、Array.from 等 Array 内置函数访问的元素也计入迭代次数。
+	MaxLoopIterations int64
+	// MaxArrayLength is the maximum length of an array grown by Array builtins such as push, concat, fill and Array.from.
+	// MaxArrayLength push、concat、fill、Array.from 等 Array 内置函数生成的数组最大长度。
+	MaxArrayLength int64
+	// MaxStringLength is the maximum length of a string created by repeat, padStart, padEnd, concat, replace and join.
+	// MaxStringLength repeat、padStart、padEnd、concat、replace 和 join 生成的字符串最大长度。
+	MaxStringLength int64
+}
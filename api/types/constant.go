@@ -30,6 +30,8 @@ const (
 	Vars = "vars"
 	// Secrets ruleChain dsl additionalInfo secrets key
 	Secrets = "secrets"
+	// ExprTypes ruleChain dsl configuration expr type hints key, e.g. {"msg.temperature": "float"}
+	ExprTypes = "exprTypes"
 )
 
 const (
@@ -54,6 +56,18 @@ var (
 	// ErrConcurrencyLimitReached is the error returned when the concurrency limit has been reached
 	ErrConcurrencyLimitReached = errors.New("concurrency limit reached")
 	ErrCacheNotInitialized     = errors.New("cache not initialized")
+	// ErrCacheNotInteger is the error returned by Cache.Incr and Cache.Decr when the stored value is not an integer
+	ErrCacheNotInteger = errors.New("cache value is not an integer")
+	// ErrCacheNotList is the error returned by the Cache list operations when the stored value is not a list
+	ErrCacheNotList = errors.New("cache value is not a list")
+	// ErrRateLimitExceeded is the error returned when the request rate of a client exceeds the limit
+	ErrRateLimitExceeded = errors.New("rate limit exceeded")
+	// ErrQuotaExceeded is the error returned when the daily quota of a client is used up
+	ErrQuotaExceeded = errors.New("quota exceeded")
+	// ErrRateLimitKeyMissing is the error returned when the rate limit cannot resolve the client identity of a request
+	ErrRateLimitKeyMissing = errors.New("rate limit key missing")
+	// ErrConnectionNotFound is the error returned when the endpoint connection to write to does not exist
+	ErrConnectionNotFound = errors.New("connection not found")
 	// ErrEngineShuttingDown is the error returned when the engine is shutting down and cannot accept new messages
 	ErrEngineShuttingDown = errors.New("engine is shutting down")
 	// ErrEngineNotInitialized is the error returned when the rule engine is not initialized
@@ -0,0 +1,147 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package endpoint
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/yunboom/rulego/api/types"
+)
+
+// Message types and metadata keys of connection lifecycle messages.
+// 连接生命周期消息的消息类型和元数据键。
+const (
+	// MsgTypeConnect is the message type routed to the chain when a client connects.
+	// MsgTypeConnect 客户端连接时路由到规则链的消息类型
+	MsgTypeConnect = "CONNECT"
+	// MsgTypeDisconnect is the message type routed to the chain when a client disconnects.
+	// MsgTypeDisconnect 客户端断开连接时路由到规则链的消息类型
+	MsgTypeDisconnect = "DISCONNECT"
+	// MetadataKeyConnectionId is the metadata key of the id of the connection the message comes from.
+	// MetadataKeyConnectionId 消息来源连接 ID 的元数据键
+	MetadataKeyConnectionId = "connectionId"
+)
+
+// Connection is a live client connection of a long-connection endpoint (net, websocket),
+// which can be written to outside of the request that created it.
+//
+// Connection 长连接端点（net、websocket）中的一个在线客户端连接，
+// 可以在创建它的请求之外向其写入数据。
+type Connection interface {
+	// Id returns the connection id, the remote address or the client id taken from the handshake message
+	// Id 返回连接 ID，即远程地址或者从握手消息中获取的客户端 ID
+	Id() string
+	// RemoteAddr returns the remote address of the client
+	// RemoteAddr 返回客户端远程地址
+	RemoteAddr() string
+	// Send writes the message to the client
+	// Send 把消息写入客户端
+	Send(msg types.RuleMsg) error
+	// Close closes the connection
+	// Close 关闭连接
+	Close() error
+}
+
+// Connections is the global registry of live endpoint connections.
+// Connections 全局在线端点连接注册表。
+var Connections = NewConnectionRegistry()
+
+// ConnectionRegistry keeps the live connections by id.
+// A connection registered with an existing id replaces the old one, e.g. a device reconnects.
+//
+// ConnectionRegistry 按 ID 保存在线连接。
+// 使用已存在的 ID 注册的连接会替换旧连接，例如设备重连。
+type ConnectionRegistry struct {
+	conns map[string]Connection
+	lock  sync.RWMutex
+}
+
+// NewConnectionRegistry creates an empty connection registry.
+// NewConnectionRegistry 创建空的连接注册表。
+func NewConnectionRegistry() *ConnectionRegistry {
+	return &ConnectionRegistry{conns: make(map[string]Connection)}
+}
+
+// Register registers the connection by its id.
+// Register 按连接 ID 注册连接。
+func (r *ConnectionRegistry) Register(conn Connection) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.conns[conn.Id()] = conn
+}
+
+// Unregister removes the connection if it is still the one registered with its id.
+// It returns false if the id has been taken over by another connection.
+//
+// Unregister 如果该 ID 下注册的仍是此连接则删除。
+// 如果该 ID 已被其他连接接管，返回 false。
+func (r *ConnectionRegistry) Unregister(conn Connection) bool {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if old, ok := r.conns[conn.Id()]; ok && old == conn {
+		delete(r.conns, conn.Id())
+		return true
+	}
+	return false
+}
+
+// Get returns the connection with the id.
+// Get 获取指定 ID 的连接。
+func (r *ConnectionRegistry) Get(id string) (Connection, bool) {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	conn, ok := r.conns[id]
+	return conn, ok
+}
+
+// Ids returns the sorted ids of all live connections.
+// Ids 返回所有在线连接的 ID（已排序）。
+func (r *ConnectionRegistry) Ids() []string {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	ids := make([]string, 0, len(r.conns))
+	for id := range r.conns {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// Send writes the message to the connection with the id.
+// Send 把消息写入指定 ID 的连接。
+func (r *ConnectionRegistry) Send(id string, msg types.RuleMsg) error {
+	conn, ok := r.Get(id)
+	if !ok {
+		return fmt.Errorf("%w: %s", types.ErrConnectionNotFound, id)
+	}
+	return conn.Send(msg)
+}
+
+// NewConnectionMsg creates the connect or disconnect message of the connection.
+// NewConnectionMsg 创建连接的连接或断开连接消息。
+func NewConnectionMsg(msgType string, conn Connection) types.RuleMsg {
+	metadata := types.NewMetadata()
+	metadata.PutValue(MetadataKeyConnectionId, conn.Id())
+	data, _ := json.Marshal(map[string]string{
+		MetadataKeyConnectionId: conn.Id(),
+		"remoteAddr":            conn.RemoteAddr(),
+	})
+	return types.NewMsg(0, msgType, types.JSON, metadata, string(data))
+}
@@ -78,9 +78,12 @@ package endpoint
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"net/http"
 	"net/textproto"
 	"sync"
+	"time"
 
 	"github.com/yunboom/rulego/api/types"
 )
@@ -113,6 +116,70 @@ const (
 	// EventCompletedServer 表示服务器完成事件。
 	// 当端点服务器完成其操作时触发。
 	EventCompletedServer = "completedServer"
+
+	// EventRateLimited represents a rejected inbound request event.
+	// Triggered when a request is rejected by a rate limit or quota interceptor,
+	// params are the exchange and the *RateLimitError.
+	// EventRateLimited 表示入站请求被限流拒绝事件。
+	// 当请求被限流或配额拦截器拒绝时触发，参数为 exchange 和 *RateLimitError。
+	EventRateLimited = "RateLimited"
+)
+
+// RateLimitError is the error set to exchange.Out when an inbound request is rejected by a rate limit
+// or quota interceptor. Endpoints map it to protocol-specific responses.
+// RateLimitError 入站请求被限流或配额拦截器拒绝时设置到 exchange.Out 的错误，端点将其转换成协议相关的响应。
+type RateLimitError struct {
+	// Key is the client identity the limit applies to.
+	// Key 限流作用的客户端标识
+	Key string
+	// Err is types.ErrRateLimitExceeded, types.ErrQuotaExceeded or types.ErrRateLimitKeyMissing.
+	// Err 为 types.ErrRateLimitExceeded、types.ErrQuotaExceeded 或者 types.ErrRateLimitKeyMissing
+	Err error
+	// RetryAfter is the duration after which the client may retry.
+	// RetryAfter 客户端可以重试的等待时间
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitError) Error() string {
+	return fmt.Sprintf("%v, key=%s, retry after %v", e.Err, e.Key, e.RetryAfter)
+}
+
+func (e *RateLimitError) Unwrap() error {
+	return e.Err
+}
+
+// GetRateLimitError returns the RateLimitError of the exchange, if the request was rejected by a rate limit.
+// GetRateLimitError 如果请求被限流拒绝，返回 exchange 的 RateLimitError。
+func GetRateLimitError(exchange *Exchange) (*RateLimitError, bool) {
+	if exchange == nil || exchange.Out == nil {
+		return nil, false
+	}
+	var err *RateLimitError
+	if errors.As(exchange.Out.GetError(), &err) {
+		return err, true
+	}
+	return nil, false
+}
+
+// Keys of RouterDsl.AdditionalInfo describing the router contract.
+// They are used to generate API documents and validate requests.
+// RouterDsl.AdditionalInfo 中描述路由契约的键，用于生成 API 文档和校验请求。
+const (
+	// RouterInfoKeySummary is the short summary of the router.
+	// RouterInfoKeySummary 路由的简要说明
+	RouterInfoKeySummary = "summary"
+	// RouterInfoKeyDescription is the detailed description of the router.
+	// RouterInfoKeyDescription 路由的详细描述
+	RouterInfoKeyDescription = "description"
+	// RouterInfoKeyRequestSchema is the JSON Schema of the request body.
+	// RouterInfoKeyRequestSchema 请求体的 JSON Schema
+	RouterInfoKeyRequestSchema = "requestSchema"
+	// RouterInfoKeyRequestSchemaFile is the file path of the JSON Schema of the request body.
+	// RouterInfoKeyRequestSchemaFile 请求体 JSON Schema 的文件路径
+	RouterInfoKeyRequestSchemaFile = "requestSchemaFile"
+	// RouterInfoKeyResponseSchema is the JSON Schema of the response body.
+	// RouterInfoKeyResponseSchema 响应体的 JSON Schema
+	RouterInfoKeyResponseSchema = "responseSchema"
 )
 
 // OnEvent is a callback function type for handling endpoint events.
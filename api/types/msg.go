@@ -312,6 +312,27 @@ func (md *Metadata) PutValue(key, value string) {
 	md.data[key] = value
 }
 
+// Delete removes a key from the metadata.
+func (md *Metadata) Delete(key string) {
+	md.mu.Lock()
+	defer md.mu.Unlock()
+
+	if _, ok := md.data[key]; !ok {
+		return
+	}
+	// Ensure unique copy within the same lock
+	if md.shared {
+		newData := make(map[string]string, len(md.data))
+		for k, v := range md.data {
+			newData[k] = v
+		}
+		md.data = newData
+		md.shared = false
+	}
+
+	delete(md.data, key)
+}
+
 // Values returns all key-value pairs in the metadata.
 func (md *Metadata) Values() map[string]string {
 	md.mu.RLock()
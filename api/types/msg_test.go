@@ -71,6 +71,11 @@ func TestMetadataOperations(t *testing.T) {
 	assert.False(t, copy2.Has("key3"))
 	assert.True(t, copy1.Has("key3"))
 
+	copy2.Delete("key2")
+	copy2.Delete("notfound")
+	assert.False(t, copy2.Has("key2"))
+	assert.True(t, original.Has("key2"))
+
 	newData := map[string]string{"newKey1": "newValue1"}
 	copy1.ReplaceAll(newData)
 	assert.False(t, copy1.Has("key1"))
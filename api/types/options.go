@@ -214,6 +214,72 @@ func WithScriptMaxExecutionTime(scriptMaxExecutionTime time.Duration) Option {
 	}
 }
 
+// WithJsStdLib is an option that enables the `$lib` standard library of the JavaScript runtime.
+// WithJsStdLib 是启用 JavaScript 运行时 `$lib` 标准库的选项。
+//
+// Example:
+// 示例：
+//
+//	config := NewConfig(WithJsStdLib(true))
+//	// jsScript: return {'id': $lib.uuid(), 'sign': $lib.crypto.hmacSha256(vars.key, msg.payload)};
+func WithJsStdLib(enabled bool) Option {
+	return func(c *Config) error {
+		c.JsStdLibEnabled = enabled
+		return nil
+	}
+}
+
+// WithJsHttpAllowHosts is an option that sets the host allow-list of the `$lib.http` helpers.
+// WithJsHttpAllowHosts 是设置 `$lib.http` 辅助函数主机白名单的选项。
+//
+// Example:
+// 示例：
+//
+//	config := NewConfig(WithJsStdLib(true), WithJsHttpAllowHosts("api.example.com", "*.internal.example.com"))
+func WithJsHttpAllowHosts(hosts ...string) Option {
+	return func(c *Config) error {
+		c.JsHttpAllowHosts = hosts
+		return nil
+	}
+}
+
+// WithScriptLibraries is an option that sets the shared script library registry.
+// WithScriptLibraries 是设置共享脚本库注册表的选项。
+func WithScriptLibraries(libraries *ScriptLibraryRegistry) Option {
+	return func(c *Config) error {
+		c.ScriptLibraries = libraries
+		return nil
+	}
+}
+
+// WithTemplatePartials is an option that sets the shared template partial registry.
+// WithTemplatePartials 是设置共享模板片段注册表的选项。
+func WithTemplatePartials(partials *TemplatePartialRegistry) Option {
+	return func(c *Config) error {
+		c.TemplatePartials = partials
+		return nil
+	}
+}
+
+// WithScriptLimits is an option that sets the per-execution resource limits of scripts.
+// WithScriptLimits 是设置脚本每次执行资源限制的选项。
+//
+// Example:
+// 示例：
+//
+//	config := NewConfig(WithScriptLimits(ScriptLimits{
+//	    MaxCallStackSize:  256,
+//	    MaxLoopIterations: 1000000,
+//	    MaxArrayLength:    100000,
+//	    MaxStringLength:   1 << 20,
+//	}))
+func WithScriptLimits(limits ScriptLimits) Option {
+	return func(c *Config) error {
+		c.ScriptLimits = limits
+		return nil
+	}
+}
+
 // WithParser is an option that sets the parser of the Config.
 // WithParser 是设置 Config 解析器的选项。
 //
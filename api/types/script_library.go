@@ -0,0 +1,86 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package types
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// ScriptLibrary is a named script library shared by script nodes.
+// ScriptLibrary 是脚本节点之间共享的命名脚本库。
+type ScriptLibrary struct {
+	// Name library name, referenced by `require('name')` or `import ... from 'name'`
+	// Name 库名称，通过 `require('name')` 或者 `import ... from 'name'` 引用
+	Name string
+	// Type script type, default Js
+	// Type 脚本类型，默认 Js
+	Type string
+	// Content script source code
+	// Content 脚本源代码
+	Content string
+	// Version revision of the library, increased each time it is registered
+	// Version 库的版本号，每次注册时递增
+	Version int64
+}
+
+// ScriptLibraryRegistry is the registry of shared script libraries.
+// Registering a library with an existing name reloads it, script engines that depend on it rebuild their VMs.
+//
+// ScriptLibraryRegistry 共享脚本库注册表。
+// 使用已存在的名称注册库会重新加载该库，依赖它的脚本引擎会重建虚拟机。
+//
+// Usage example:
+// 使用示例：
+//
+//	libraries := types.NewScriptLibraryRegistry()
+//	libraries.Register("mathUtils", types.Js, "export function round2(v) { return Math.round(v * 100) / 100 }")
+//	_ = libraries.LoadDir("./scripts")
+//	config := rulego.NewConfig(types.WithScriptLibraries(libraries))
+//	// jsScript: import { round2 } from 'mathUtils'; msg.value = round2(msg.value); return {'msg':msg,'metadata':metadata,'msgType':msgType};
+type ScriptLibraryRegistry struct {
+	*VersionedRegistry[ScriptLibrary]
+}
+
+// NewScriptLibraryRegistry creates a script library registry.
+// NewScriptLibraryRegistry 创建脚本库注册表。
+func NewScriptLibraryRegistry() *ScriptLibraryRegistry {
+	return &ScriptLibraryRegistry{NewVersionedRegistry("script library", func(lib ScriptLibrary, version int64) ScriptLibrary {
+		lib.Version = version
+		return lib
+	})}
+}
+
+// Register registers or reloads a library.
+// Register 注册或者重新加载库。
+func (r *ScriptLibraryRegistry) Register(name string, scriptType string, content string) {
+	if scriptType == AllScript {
+		scriptType = Js
+	}
+	r.VersionedRegistry.Register(name, ScriptLibrary{Name: name, Type: scriptType, Content: content})
+}
+
+// LoadDir loads the *.js files in the directory, the file name without extension is the library name.
+// Loading the directory again reloads the libraries.
+// LoadDir 加载目录中的 *.js 文件，去掉扩展名的文件名作为库名称。再次加载目录会重新加载这些库。
+func (r *ScriptLibraryRegistry) LoadDir(dir string) error {
+	return r.LoadFiles(dir, func(fileName string) bool {
+		return strings.EqualFold(filepath.Ext(fileName), ".js")
+	}, func(name string, content string) ScriptLibrary {
+		return ScriptLibrary{Name: name, Type: Js, Content: content}
+	})
+}
@@ -0,0 +1,54 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package types
+
+// TemplatePartialRegistry is the registry of named template partials shared by template nodes.
+// Partials are referenced by name in every template engine, and template nodes recompile their templates when a partial changes.
+//
+// TemplatePartialRegistry 模板节点之间共享的命名模板片段注册表。
+// 各模板引擎通过名称引用片段，片段变化时模板节点会重新编译模板。
+//
+// Usage example:
+// 使用示例：
+//
+//	partials := types.NewTemplatePartialRegistry()
+//	partials.Register("footer", "-- {{ metadata.team }}")
+//	_ = partials.LoadDir("./partials")
+//	config := rulego.NewConfig(types.WithTemplatePartials(partials))
+//	// gotemplate: {{ template "footer" . }}
+//	// mustache:   {{> footer}}
+//	// jinja:      {% include "footer" %}
+type TemplatePartialRegistry struct {
+	*VersionedRegistry[string]
+}
+
+// NewTemplatePartialRegistry creates a template partial registry.
+// NewTemplatePartialRegistry 创建模板片段注册表。
+func NewTemplatePartialRegistry() *TemplatePartialRegistry {
+	return &TemplatePartialRegistry{NewVersionedRegistry[string]("template partial", nil)}
+}
+
+// LoadDir loads the files in the directory, the file name without extension is the partial name.
+// Hidden files and subdirectories are skipped. Loading the directory again reloads the partials.
+// LoadDir 加载目录中的文件，去掉扩展名的文件名作为片段名称。跳过隐藏文件和子目录，再次加载目录会重新加载这些片段。
+func (r *TemplatePartialRegistry) LoadDir(dir string) error {
+	return r.LoadFiles(dir, func(string) bool {
+		return true
+	}, func(_ string, content string) string {
+		return content
+	})
+}
@@ -0,0 +1,183 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package types
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// VersionedRegistry is a registry of named values with a version, which is increased each time any value changes.
+// Users of the values compare the version to find out whether to reload them, see ScriptLibraryRegistry and TemplatePartialRegistry.
+//
+// VersionedRegistry 带版本号的命名值注册表，任意值变化时版本号递增。
+// 值的使用者通过比较版本号判断是否需要重新加载，参考 ScriptLibraryRegistry 和 TemplatePartialRegistry。
+type VersionedRegistry[T any] struct {
+	// kind the kind of the values, used in the error messages
+	kind string
+	// withVersion sets the version to the registered value, can be nil
+	withVersion func(value T, version int64) T
+	lock        sync.RWMutex
+	values      map[string]versionedValue[T]
+	// compiled the compiled values by name and compile key, dropped when the value changes
+	compiled map[string]map[interface{}]interface{}
+	// version is increased each time any value changes
+	version int64
+}
+
+type versionedValue[T any] struct {
+	value   T
+	version int64
+}
+
+// NewVersionedRegistry creates a versioned registry, kind names the values in the error messages,
+// withVersion, if not nil, sets the version to each registered value.
+// NewVersionedRegistry 创建带版本号的注册表，kind 是错误信息中值的名称，withVersion 不为空时用于把版本号设置到注册的值。
+func NewVersionedRegistry[T any](kind string, withVersion func(value T, version int64) T) *VersionedRegistry[T] {
+	return &VersionedRegistry[T]{
+		kind:        kind,
+		withVersion: withVersion,
+		values:      make(map[string]versionedValue[T]),
+		compiled:    make(map[string]map[interface{}]interface{}),
+	}
+}
+
+// Register registers or reloads a value.
+// Register 注册或者重新加载值。
+func (r *VersionedRegistry[T]) Register(name string, value T) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.version++
+	if r.withVersion != nil {
+		value = r.withVersion(value, r.version)
+	}
+	delete(r.compiled, name)
+	r.values[name] = versionedValue[T]{value: value, version: r.version}
+}
+
+// Unregister removes a value.
+// Unregister 删除值。
+func (r *VersionedRegistry[T]) Unregister(name string) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if _, ok := r.values[name]; ok {
+		r.version++
+		delete(r.values, name)
+		delete(r.compiled, name)
+	}
+}
+
+// Get returns the value by name.
+// Get 根据名称获取值。
+func (r *VersionedRegistry[T]) Get(name string) (T, bool) {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	v, ok := r.values[name]
+	return v.value, ok
+}
+
+// Names returns the sorted names of the registered values.
+// Names 返回已注册值的名称，按名称排序。
+func (r *VersionedRegistry[T]) Names() []string {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	var names []string
+	for name := range r.values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// All returns a copy of the registered values and the registry version of the copy.
+// All 返回已注册值的副本以及该副本对应的注册表版本号。
+func (r *VersionedRegistry[T]) All() (map[string]T, int64) {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	cp := make(map[string]T, len(r.values))
+	for k, v := range r.values {
+		cp[k] = v.value
+	}
+	return cp, r.version
+}
+
+// Version returns the registry version, which changes each time any value changes.
+// Version 返回注册表版本号，任意值变化时都会改变。
+func (r *VersionedRegistry[T]) Version() int64 {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	return r.version
+}
+
+// Compile returns the value compiled by the compile function and the version of the value.
+// The result is cached by name and key until the value is reloaded or removed, errors are not cached.
+// Compile 返回使用 compile 函数编译的值以及该值的版本号。
+// 结果按名称和 key 缓存，直到值被重新加载或者删除，编译错误不会缓存。
+func (r *VersionedRegistry[T]) Compile(name string, key interface{}, compile func(value T) (interface{}, error)) (interface{}, int64, error) {
+	r.lock.RLock()
+	v, ok := r.values[name]
+	compiled, cached := r.compiled[name][key]
+	r.lock.RUnlock()
+	if !ok {
+		return nil, 0, fmt.Errorf("%s %s not found", r.kind, name)
+	}
+	if cached {
+		return compiled, v.version, nil
+	}
+	compiled, err := compile(v.value)
+	if err != nil {
+		return nil, 0, err
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	// 编译期间值没有变化才缓存
+	if current, ok := r.values[name]; ok && current.version == v.version {
+		if r.compiled[name] == nil {
+			r.compiled[name] = make(map[interface{}]interface{})
+		}
+		r.compiled[name][key] = compiled
+	}
+	return compiled, v.version, nil
+}
+
+// LoadFiles registers the files in the directory which the filter accepts, the file name without extension is the name
+// and value converts the name and the file content to the value. Hidden files and subdirectories are skipped.
+// Loading the directory again reloads the values.
+// LoadFiles 注册目录中 filter 接受的文件，去掉扩展名的文件名作为名称，value 把名称和文件内容转换为值。
+// 跳过隐藏文件和子目录，再次加载目录会重新加载这些值。
+func (r *VersionedRegistry[T]) LoadFiles(dir string, filter func(fileName string) bool, value func(name string, content string) T) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if entry.IsDir() || strings.HasPrefix(entry.Name(), ".") || !filter(entry.Name()) {
+			continue
+		}
+		content, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return err
+		}
+		name := strings.TrimSuffix(entry.Name(), filepath.Ext(entry.Name()))
+		r.Register(name, value(name, string(content)))
+	}
+	return nil
+}
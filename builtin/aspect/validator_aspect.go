@@ -21,6 +21,7 @@ import (
 	"sync"
 
 	"github.com/yunboom/rulego/api/types"
+	"github.com/yunboom/rulego/utils/dsl"
 )
 
 var (
@@ -64,6 +65,10 @@ var (
 //		// Custom validation logic
 //		return nil
 //	})
+//
+//	// Lint scripts and expressions at chain load
+//	// 在加载规则链时检查脚本和表达式
+//	Rules.AddRule(NewLintRule(false))
 type Validator struct {
 }
 
@@ -232,6 +237,35 @@ func (r *rules) Rules() []func(config types.Config, def *types.RuleChain) error
 	return append([]func(config types.Config, def *types.RuleChain) error(nil), r.rules...)
 }
 
+// NewLintRule creates a validation rule that statically checks the scripts and expressions
+// of the rule chain, see dsl.Lint. It is not registered by default.
+// The rule fails if any issue has error severity, or any issue is found when failOnWarning is true.
+// Otherwise the warnings are logged. The returned error is dsl.Issues.
+//
+// NewLintRule 创建静态检查规则链脚本和表达式的验证规则，参考 dsl.Lint。默认不注册。
+// 如果有错误级别的问题，或者 failOnWarning 为 true 时发现任何问题，则验证失败，否则记录警告日志。
+// 返回的错误类型为 dsl.Issues。
+//
+// Usage:
+// 使用方法：
+//
+//	Rules.AddRule(NewLintRule(false))
+func NewLintRule(failOnWarning bool) func(config types.Config, def *types.RuleChain) error {
+	return func(config types.Config, def *types.RuleChain) error {
+		if def == nil {
+			return nil
+		}
+		issues := dsl.Lint(config, *def)
+		if issues.HasError() || (failOnWarning && len(issues) > 0) {
+			return issues
+		}
+		if len(issues) > 0 && config.Logger != nil {
+			config.Logger.Printf("rule chain %s lint warnings:\n%s", def.RuleChain.ID, issues.Error())
+		}
+		return nil
+	}
+}
+
 // CheckCycles performs cycle detection in rule chains using topological sorting algorithm.
 // It builds a directed graph from rule node connections and detects cycles that would
 // cause infinite loops during rule execution.
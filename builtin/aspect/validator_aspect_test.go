@@ -19,6 +19,7 @@ package aspect
 import (
 	"github.com/yunboom/rulego/api/types"
 	"github.com/yunboom/rulego/test/assert"
+	"github.com/yunboom/rulego/utils/dsl"
 	"testing"
 )
 
@@ -62,3 +63,27 @@ func TestCheckCycles(t *testing.T) {
 	assert.NotNil(t, err)
 	//assert.EqualError(t, err, ErrCycleDetected.Error(), "Cycle detection failed for a rule chain with cycles")
 }
+
+func TestLintRule(t *testing.T) {
+	def := &types.RuleChain{
+		Metadata: types.RuleMetadata{
+			Nodes: []*types.RuleNode{
+				{Id: "s1", Type: "exprFilter", Configuration: types.Configuration{"expr": "temperature > 10"}},
+			},
+		},
+	}
+	config := types.NewConfig()
+	assert.Nil(t, NewLintRule(false)(config, def))
+	assert.Nil(t, NewLintRule(false)(config, nil))
+
+	err := NewLintRule(true)(config, def)
+	assert.NotNil(t, err)
+	issues, ok := err.(dsl.Issues)
+	assert.True(t, ok)
+	assert.Equal(t, "s1", issues[0].NodeId)
+
+	def.Metadata.Nodes[0].Configuration["expr"] = "msg.temperature >"
+	err = NewLintRule(false)(config, def)
+	assert.NotNil(t, err)
+	assert.Equal(t, "s1.expr:1:17: error: unexpected token EOF", err.Error())
+}
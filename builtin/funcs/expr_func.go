@@ -0,0 +1,173 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package funcs
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/yunboom/rulego/utils/maps"
+	"github.com/yunboom/rulego/utils/str"
+)
+
+// init 注册内置expr表达式函数，expr自带的函数(upper、trim、split、abs、round、now、date、filter、map等)不重复注册
+// init registers the built-in expr functions, the functions provided by expr itself are not registered again.
+func init() {
+	registerExprStringFunc()
+	registerExprMathFunc()
+	registerExprTimeFunc()
+	registerExprArrayFunc()
+}
+
+// 字符串函数
+func registerExprStringFunc() {
+	// substring 按照字符截取子字符串，end<0 表示截取到末尾，例如：substring('hello', 1, 3) == 'el'
+	ExprFunc.Register("substring", func(s string, start, end int) string {
+		runes := []rune(s)
+		if end < 0 || end > len(runes) {
+			end = len(runes)
+		}
+		if start < 0 {
+			start = 0
+		}
+		if start >= end {
+			return ""
+		}
+		return string(runes[start:end])
+	})
+	// padLeft 左侧填充到指定长度，例如：padLeft('7', 3, '0') == '007'
+	ExprFunc.Register("padLeft", func(s string, length int, pad string) string {
+		return padString(s, length, pad, true)
+	})
+	// padRight 右侧填充到指定长度，例如：padRight('7', 3, '0') == '700'
+	ExprFunc.Register("padRight", func(s string, length int, pad string) string {
+		return padString(s, length, pad, false)
+	})
+	// capitalize 首字母转大写
+	ExprFunc.Register("capitalize", func(s string) string {
+		r, size := utf8.DecodeRuneInString(s)
+		if r == utf8.RuneError {
+			return s
+		}
+		return string(unicode.ToUpper(r)) + s[size:]
+	})
+	// sprintf 格式化字符串，例如：sprintf('%s-%d', 'a', 1) == 'a-1'
+	ExprFunc.Register("sprintf", func(format string, args ...interface{}) string {
+		return fmt.Sprintf(format, args...)
+	})
+	// regexReplace 使用正则表达式替换，例如：regexReplace('a1b2', '[0-9]', '') == 'ab'
+	ExprFunc.Register("regexReplace", func(s, pattern, replacement string) (string, error) {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return "", err
+		}
+		return re.ReplaceAllString(s, replacement), nil
+	})
+}
+
+// 数学函数
+func registerExprMathFunc() {
+	// pow 幂运算
+	ExprFunc.Register("pow", math.Pow)
+	// sqrt 平方根
+	ExprFunc.Register("sqrt", math.Sqrt)
+	// toFixed 保留指定位数的小数，例如：toFixed(3.14159, 2) == 3.14
+	ExprFunc.Register("toFixed", func(v float64, digits int) float64 {
+		p := math.Pow(10, float64(digits))
+		return math.Round(v*p) / p
+	})
+	// clamp 把值限制在[min,max]范围内
+	ExprFunc.Register("clamp", func(v, min, max float64) float64 {
+		return math.Max(min, math.Min(max, v))
+	})
+}
+
+// 时间函数，时间戳单位为毫秒，时间格式例如：yyyy-MM-dd HH:mm:ss.SSS
+func registerExprTimeFunc() {
+	// unixMilli 当前时间戳
+	ExprFunc.Register("unixMilli", func() int64 {
+		return time.Now().UnixMilli()
+	})
+	// formatTime 按照本地时区格式化时间戳，例如：formatTime(ts, 'yyyy-MM-dd')
+	ExprFunc.Register("formatTime", func(timestamp int64, layout string) string {
+		return time.UnixMilli(timestamp).Format(str.ConvertDateLayout(layout))
+	})
+	// parseTime 按照本地时区解析时间，返回时间戳，例如：parseTime('2025-01-02', 'yyyy-MM-dd')
+	ExprFunc.Register("parseTime", func(value, layout string) (int64, error) {
+		t, err := time.ParseInLocation(str.ConvertDateLayout(layout), value, time.Local)
+		if err != nil {
+			return 0, err
+		}
+		return t.UnixMilli(), nil
+	})
+}
+
+// 数组函数
+func registerExprArrayFunc() {
+	// includes 数组是否包含元素，例如：includes(msg.tags, 'alarm')
+	ExprFunc.Register("includes", func(list []interface{}, item interface{}) bool {
+		for _, v := range list {
+			// map、slice 等不可比较的元素不能使用 == 比较
+			if reflect.DeepEqual(v, item) || str.ToString(v) == str.ToString(item) {
+				return true
+			}
+		}
+		return false
+	})
+	// pluck 获取对象数组中每个对象指定字段的值，支持a.b格式，例如：pluck(msg.items, 'price')
+	ExprFunc.Register("pluck", func(list []interface{}, key string) []interface{} {
+		result := make([]interface{}, 0, len(list))
+		for _, v := range list {
+			result = append(result, maps.Get(v, key))
+		}
+		return result
+	})
+	// chunk 把数组按照指定大小分组，例如：chunk([1,2,3], 2) == [[1,2],[3]]
+	ExprFunc.Register("chunk", func(list []interface{}, size int) ([]interface{}, error) {
+		if size <= 0 {
+			return nil, fmt.Errorf("chunk size must be greater than 0")
+		}
+		result := make([]interface{}, 0, (len(list)+size-1)/size)
+		for i := 0; i < len(list); i += size {
+			end := i + size
+			if end > len(list) {
+				end = len(list)
+			}
+			result = append(result, list[i:end])
+		}
+		return result, nil
+	})
+}
+
+func padString(s string, length int, pad string, left bool) string {
+	count := length - utf8.RuneCountInString(s)
+	if count <= 0 || pad == "" {
+		return s
+	}
+	padding := strings.Repeat(pad, count)
+	padding = string([]rune(padding)[:count])
+	if left {
+		return padding + s
+	}
+	return s + padding
+}
@@ -105,6 +105,29 @@ func TestBuiltinFunc(t *testing.T) {
 		assert.False(t, ok)
 	})
 
+	t.Run("TestExprFunc", func(t *testing.T) {
+		for _, name := range []string{"substring", "padLeft", "padRight", "capitalize", "sprintf", "regexReplace",
+			"pow", "sqrt", "toFixed", "clamp", "unixMilli", "formatTime", "parseTime", "includes", "pluck", "chunk"} {
+			_, ok := ExprFunc.Get(name)
+			assert.True(t, ok, name)
+		}
+		substring, _ := ExprFunc.Get("substring")
+		assert.Equal(t, "界", substring.(func(string, int, int) string)("世界", 1, -1))
+		padRight, _ := ExprFunc.Get("padRight")
+		assert.Equal(t, "7ab", padRight.(func(string, int, string) string)("7", 3, "abc"))
+		parseTime, _ := ExprFunc.Get("parseTime")
+		formatTime, _ := ExprFunc.Get("formatTime")
+		ts, err := parseTime.(func(string, string) (int64, error))("2025-01-02 03:04:05.006", "yyyy-MM-dd HH:mm:ss.SSS")
+		assert.Nil(t, err)
+		assert.Equal(t, "2025/01/02 03:04:05.006", formatTime.(func(int64, string) string)(ts, "yyyy/MM/dd HH:mm:ss.SSS"))
+		pluck, _ := ExprFunc.Get("pluck")
+		assert.Equal(t, []interface{}{1, nil}, pluck.(func([]interface{}, string) []interface{})([]interface{}{map[string]interface{}{"a": map[string]interface{}{"b": 1}}, "x"}, "a.b"))
+		chunk, _ := ExprFunc.Get("chunk")
+		out, err := chunk.(func([]interface{}, int) ([]interface{}, error))([]interface{}{1, 2, 3}, 2)
+		assert.Nil(t, err)
+		assert.Equal(t, []interface{}{[]interface{}{1, 2}, []interface{}{3}}, out)
+	})
+
 	t.Run("TestFuncMap", func(t *testing.T) {
 		var testMap = NewFuncMap[string]()
 		testMap.RegisterAll(map[string]string{
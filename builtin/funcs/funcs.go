@@ -25,6 +25,7 @@
 // - FuncMap: A generic map for storing functions
 // - TemplateFunc: A map for storing template functions
 // - ScriptFunc: A map for storing script functions
+// - ExprFunc: A map for storing expr expression functions
 //
 // The package supports features such as:
 // - Registering and accessing functions by name
@@ -44,6 +45,9 @@ var TemplateFunc = NewFuncMap[any]()
 // ScriptFunc 内置Js用户函数
 var ScriptFunc = NewFuncMap[any]()
 
+// ExprFunc 内置expr表达式函数，所有expr表达式编译时可用，函数参数和返回值类型用于编译时类型检查
+var ExprFunc = NewFuncMap[any]()
+
 func init() {
 	TemplateFunc.Register("escape", func(s string) string {
 		var replacer = strings.NewReplacer(
@@ -57,6 +57,12 @@
 //   - toHex: Converts binary data to hexadecimal string representation
 //     toHex：将二进制数据转换为十六进制字符串表示
 //
+//   - validateSchema: Validates the request body against the router JSON Schema, rejects invalid requests with 400
+//     validateSchema：使用路由的 JSON Schema 校验请求体，校验失败以 400 拒绝
+//
+//   - rateLimit: Limits requests per client identity with a token bucket and a daily quota
+//     rateLimit：按客户端标识使用令牌桶和每日配额限制请求
+//
 // Available Output Processors:
 // 可用的输出处理器：
 //
@@ -310,12 +316,25 @@ func init() {
 //   - RegisterAll: Add multiple processors  注册所有：添加多个处理器
 //   - Unregister: Remove processors by name  注销：按名称删除处理器
 //   - Get: Retrieve processor by name  获取：按名称检索处理器
+//   - RegisterFactory: Add per-router processor factory  注册工厂：添加路由级处理器工厂
+//   - GetForRouter: Create or retrieve processor for a router definition  按路由获取：为路由定义创建或检索处理器
 //   - Names: List all registered names  名称：列出所有注册的名称
 type builtins struct {
-	processors map[string]endpoint.Process // Map of processor functions  处理器函数映射
-	lock       sync.RWMutex                // Read/Write mutex for concurrent access  用于并发访问的读写互斥锁
+	processors map[string]endpoint.Process     // Map of processor functions  处理器函数映射
+	factories  map[string]RouterProcessFactory // Map of per-router processor factories  路由级处理器工厂映射
+	lock       sync.RWMutex                    // Read/Write mutex for concurrent access  用于并发访问的读写互斥锁
 }
 
+// RouterProcessFactory creates the processor of a router from its definition.
+// config is the rule engine config of the endpoint and endpointType its type, e.g. endpoint/http,
+// empty if unknown. The returned processor keeps its state, e.g. a parsed schema, for the lifetime of the router,
+// so the state is released together with the router when it is removed or reloaded.
+//
+// RouterProcessFactory 根据路由定义创建该路由的处理器。config 为端点的规则引擎配置，endpointType 为端点类型，
+// 例如：endpoint/http，未知则为空。
+// 返回的处理器在路由生命周期内保存其状态（例如解析后的 Schema），路由被删除或者重新加载时随路由一起释放。
+type RouterProcessFactory func(config types.Config, endpointType string, def *types.RouterDsl) (endpoint.Process, error)
+
 // Register adds a single processor function to the registry with the specified name.
 // If a processor with the same name already exists, it will be replaced.
 //
@@ -390,6 +409,74 @@ func (b *builtins) Unregister(names ...string) {
 	defer b.lock.Unlock()
 	for _, name := range names {
 		delete(b.processors, name)
+		delete(b.factories, name)
+	}
+}
+
+// RegisterFactory registers the per-router factory of the processor with the specified name.
+// Routers created from the DSL get their own processor from the factory, see GetForRouter.
+// The processor registered by Register with the same name is still used by Get.
+//
+// RegisterFactory 注册指定名称处理器的路由级工厂。通过 DSL 创建的路由从工厂获取各自的处理器，参考 GetForRouter。
+// Get 仍然返回通过 Register 注册的同名处理器。
+func (b *builtins) RegisterFactory(name string, factory RouterProcessFactory) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if b.factories == nil {
+		b.factories = make(map[string]RouterProcessFactory)
+	}
+	b.factories[name] = factory
+}
+
+// GetForRouter returns the processor of the router created from def on the endpoint of endpointType.
+// If a factory is registered with the name, a new processor is created from config and def,
+// otherwise it is the same as Get.
+//
+// GetForRouter 返回在 endpointType 类型端点上根据 def 创建的路由使用的处理器。
+// 如果该名称注册了工厂，则使用 config 和 def 创建新的处理器，否则与 Get 相同。
+func (b *builtins) GetForRouter(name string, config types.Config, endpointType string, def *types.RouterDsl) (endpoint.Process, bool, error) {
+	b.lock.RLock()
+	factory, ok := b.factories[name]
+	b.lock.RUnlock()
+	if !ok || def == nil {
+		p, ok := b.Get(name)
+		return p, ok, nil
+	}
+	p, err := factory(config, endpointType, def)
+	return p, true, err
+}
+
+// routerProcessKey is the key of the processes created for routers not created from the DSL
+type routerProcessKey struct {
+	name string
+	def  *types.RouterDsl
+}
+
+// routerProcesses caches the processes created for routers not created from the DSL, keyed by the router definition
+var routerProcesses sync.Map
+
+// definitionProcess returns the process that creates the processor of the router definition by factory
+// on the first request and reuses it for later requests of the same definition, like the routers created from the DSL.
+// onError handles the requests whose processor cannot be created.
+func definitionProcess(name string, factory RouterProcessFactory, onError func(exchange *endpoint.Exchange, err error) bool) endpoint.Process {
+	return func(router endpoint.Router, exchange *endpoint.Exchange) bool {
+		key := routerProcessKey{name: name, def: router.Definition()}
+		if p, ok := routerProcesses.Load(key); ok {
+			return p.(endpoint.Process)(router, exchange)
+		}
+		var config types.Config
+		if r, ok := router.(interface{ GetConfig() types.Config }); ok {
+			config = r.GetConfig()
+		}
+		p, err := factory(config, "", key.def)
+		if err != nil {
+			return onError(exchange, err)
+		}
+		if key.def != nil {
+			actual, _ := routerProcesses.LoadOrStore(key, p)
+			p = actual.(endpoint.Process)
+		}
+		return p(router, exchange)
 	}
 }
 
@@ -0,0 +1,376 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package processor
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/yunboom/rulego/api/types"
+	"github.com/yunboom/rulego/api/types/endpoint"
+	"github.com/yunboom/rulego/utils/cache"
+	"github.com/yunboom/rulego/utils/maps"
+	"github.com/yunboom/rulego/utils/str"
+)
+
+// ProcessorNameRateLimit is the name of the built-in rate limit processor.
+// Its configuration is read from the router AdditionalInfo by RouterInfoKeyRateLimit.
+//
+// ProcessorNameRateLimit 内置限流处理器名称，配置从路由 AdditionalInfo 的 RouterInfoKeyRateLimit 读取。
+//
+// Usage in Endpoint DSL / 在端点 DSL 中的使用：
+//
+//	{
+//	  "from": {
+//	    "path": "/api/device",
+//	    "processors": ["rateLimit"]
+//	  },
+//	  "additionalInfo": {
+//	    "rateLimit": {"keyType": "apiKey", "rate": 10, "burst": 20, "dailyQuota": 10000}
+//	  }
+//	}
+const ProcessorNameRateLimit = "rateLimit"
+
+// RouterInfoKeyRateLimit is the RouterDsl.AdditionalInfo key of the RateLimitConfig.
+// RouterInfoKeyRateLimit RouterDsl.AdditionalInfo 中限流配置的键。
+const RouterInfoKeyRateLimit = "rateLimit"
+
+// Client identity types of the rate limit key.
+// 限流键的客户端标识类型。
+const (
+	// RateLimitKeyIp uses the client IP of http and websocket requests.
+	// RateLimitKeyIp 使用 http 和 websocket 请求的客户端 IP
+	RateLimitKeyIp = "ip"
+	// RateLimitKeyApiKey uses the API key header, falling back to the apiKey query parameter.
+	// RateLimitKeyApiKey 使用 API key 请求头，不存在则使用 apiKey 查询参数
+	RateLimitKeyApiKey = "apiKey"
+	// RateLimitKeyClientId uses the MQTT client id carried by the topic level ClientIdTopicLevel.
+	// MQTT 3 messages do not carry the publisher client id, so devices publish to a topic containing it.
+	// RateLimitKeyClientId 使用 ClientIdTopicLevel 指定的主题层级携带的 MQTT 客户端 ID。
+	// MQTT 3 消息不携带发布者客户端 ID，所以设备需要发布到包含客户端 ID 的主题。
+	RateLimitKeyClientId = "clientId"
+	// RateLimitKeyTemplate uses the metadata template KeyTemplate, e.g. ${deviceId}
+	// RateLimitKeyTemplate 使用元数据模板 KeyTemplate，例如：${deviceId}
+	RateLimitKeyTemplate = "template"
+)
+
+// rateLimitKeyEndpoints are the endpoint types able to resolve the key types, key types not listed resolve on all endpoints
+var rateLimitKeyEndpoints = map[string][]string{
+	RateLimitKeyIp:       {types.EndpointTypePrefix + "http", types.EndpointTypePrefix + "ws"},
+	RateLimitKeyApiKey:   {types.EndpointTypePrefix + "http", types.EndpointTypePrefix + "ws"},
+	RateLimitKeyClientId: {types.EndpointTypePrefix + "mqtt"},
+}
+
+const (
+	// DefaultApiKeyHeader is the default header of the API key.
+	// DefaultApiKeyHeader 默认的 API key 请求头
+	DefaultApiKeyHeader = "X-API-Key"
+	// defaultRateLimitKeyPrefix is the default cache key prefix of the counters
+	defaultRateLimitKeyPrefix = "$rateLimit:"
+)
+
+// RateLimitConfig is the configuration of the endpoint rate limit interceptor.
+// Token bucket limit and daily quota can be used separately or together.
+// RateLimitConfig 端点限流拦截器配置。令牌桶限流和每日配额可以单独或者同时使用。
+type RateLimitConfig struct {
+	// KeyType is the client identity type: ip, apiKey, clientId or template. Default ip.
+	// Requests whose key is empty are limited by the client IP, and rejected with types.ErrRateLimitKeyMissing
+	// if the IP is not available either.
+	// KeyType 客户端标识类型：ip、apiKey、clientId 或 template，默认 ip。
+	// 键为空的请求使用客户端 IP 限流，如果也无法获取 IP，则使用 types.ErrRateLimitKeyMissing 拒绝请求
+	KeyType string `json:"keyType"`
+	// KeyTemplate is the metadata template of the key when KeyType is template, e.g. ${deviceId}
+	// KeyTemplate KeyType 为 template 时的元数据模板，例如：${deviceId}
+	KeyTemplate string `json:"keyTemplate"`
+	// ApiKeyHeader is the header of the API key. Default X-API-Key.
+	// ApiKeyHeader API key 请求头，默认 X-API-Key
+	ApiKeyHeader string `json:"apiKeyHeader"`
+	// ClientIdTopicLevel is the topic level (starting from 0) holding the MQTT client id, -1 uses the whole topic.
+	// ClientIdTopicLevel 携带 MQTT 客户端 ID 的主题层级（从 0 开始），-1 使用整个主题
+	ClientIdTopicLevel int `json:"clientIdTopicLevel"`
+	// Rate is the number of tokens added to the bucket per second, 0 disables the token bucket limit.
+	// Rate 每秒向令牌桶添加的令牌数，0 表示不启用令牌桶限流
+	Rate float64 `json:"rate"`
+	// Burst is the bucket capacity. Default max(1, Rate).
+	// Burst 令牌桶容量，默认 max(1, Rate)
+	Burst int `json:"burst"`
+	// DailyQuota is the maximum number of requests per client per day, 0 disables the quota.
+	// DailyQuota 每个客户端每天最大请求数，0 表示不启用配额
+	DailyQuota int64 `json:"dailyQuota"`
+	// KeyPrefix is the cache key prefix of the counters. Default $rateLimit:
+	// KeyPrefix 计数器的缓存键前缀，默认 $rateLimit:
+	KeyPrefix string `json:"keyPrefix"`
+}
+
+// maxBucketRetries is the maximum number of compare-and-set attempts to update a token bucket
+const maxBucketRetries = 16
+
+// RateLimiter limits inbound requests per client identity with a token bucket and a daily quota.
+// Counters are kept in types.Cache and updated by its atomic Incr and CompareAndSet operations,
+// so limiters and processes sharing the same cache share the same limits.
+// RateLimiter 按客户端标识使用令牌桶和每日配额限制入站请求。计数器保存在 types.Cache 中，
+// 使用 Incr 和 CompareAndSet 原子操作更新，所以共享同一个缓存的限流器和进程共享相同的限制。
+type RateLimiter struct {
+	Config RateLimitConfig
+	cache  types.Cache
+	// now returns the current time, replaceable in tests
+	now func() time.Time
+}
+
+// NewRateLimiter creates the rate limiter, nil cache uses cache.DefaultCache.
+// NewRateLimiter 创建限流器，cache 为空使用 cache.DefaultCache。
+func NewRateLimiter(config RateLimitConfig, c types.Cache) *RateLimiter {
+	if config.KeyType == "" {
+		config.KeyType = RateLimitKeyIp
+	}
+	if config.ApiKeyHeader == "" {
+		config.ApiKeyHeader = DefaultApiKeyHeader
+	}
+	if config.Burst <= 0 {
+		config.Burst = int(math.Max(1, math.Ceil(config.Rate)))
+	}
+	if config.KeyPrefix == "" {
+		config.KeyPrefix = defaultRateLimitKeyPrefix
+	}
+	if c == nil {
+		c = cache.DefaultCache
+	}
+	return &RateLimiter{Config: config, cache: c, now: time.Now}
+}
+
+// NewRateLimitInterceptor creates the interceptor that rejects requests exceeding the limits,
+// it can be set by DynamicEndpointOptions.WithInterceptors or From.Process.
+// Rejected requests get *endpoint.RateLimitError on exchange.Out, which the endpoints map to
+// HTTP 429 on rest, a close code on websocket and a dropped message on mqtt.
+//
+// NewRateLimitInterceptor 创建拒绝超出限制请求的拦截器，可以通过 DynamicEndpointOptions.WithInterceptors 或者 From.Process 设置。
+// 被拒绝的请求在 exchange.Out 设置 *endpoint.RateLimitError，端点将其转换成：
+// rest 返回 HTTP 429，websocket 使用关闭码关闭连接，mqtt 丢弃消息。
+func NewRateLimitInterceptor(config RateLimitConfig, c types.Cache) endpoint.Process {
+	limiter := NewRateLimiter(config, c)
+	return limiter.Process
+}
+
+// Process is the endpoint.Process of the rate limiter
+func (l *RateLimiter) Process(router endpoint.Router, exchange *endpoint.Exchange) bool {
+	key := l.Key(exchange)
+	if key == "" && l.Config.KeyType != RateLimitKeyIp {
+		key = ipKey(exchange)
+	}
+	if key == "" {
+		exchange.Out.SetError(&endpoint.RateLimitError{Err: types.ErrRateLimitKeyMissing})
+		return false
+	}
+	if err := l.Allow(key); err != nil {
+		exchange.Out.SetError(err)
+		return false
+	}
+	return true
+}
+
+// Key returns the client identity of the exchange, empty if it cannot be resolved.
+// Key 返回 exchange 的客户端标识，无法解析则返回空。
+func (l *RateLimiter) Key(exchange *endpoint.Exchange) string {
+	switch l.Config.KeyType {
+	case RateLimitKeyApiKey:
+		if headers := exchange.In.Headers(); headers != nil {
+			if v := headers.Get(l.Config.ApiKeyHeader); v != "" {
+				return v
+			}
+		}
+		return exchange.In.GetParam(RateLimitKeyApiKey)
+	case RateLimitKeyClientId:
+		topic := exchange.In.From()
+		if l.Config.ClientIdTopicLevel < 0 {
+			return topic
+		}
+		levels := strings.Split(topic, "/")
+		if l.Config.ClientIdTopicLevel < len(levels) {
+			return levels[l.Config.ClientIdTopicLevel]
+		}
+		return ""
+	case RateLimitKeyTemplate:
+		msg := exchange.In.GetMsg()
+		if msg == nil {
+			return ""
+		}
+		key := str.SprintfDict(l.Config.KeyTemplate, msg.Metadata.Values())
+		if strings.Contains(key, "${") {
+			return ""
+		}
+		return key
+	default:
+		return ipKey(exchange)
+	}
+}
+
+// CheckKeyType returns an error if the key type is unknown or cannot be resolved on the endpoint of endpointType.
+// Empty endpointType only checks the key type is known.
+//
+// CheckKeyType 如果键类型未知或者无法在 endpointType 类型的端点上解析则返回错误。endpointType 为空只检查键类型是否已知。
+func (l *RateLimiter) CheckKeyType(endpointType string) error {
+	switch l.Config.KeyType {
+	case RateLimitKeyIp, RateLimitKeyApiKey, RateLimitKeyClientId:
+	case RateLimitKeyTemplate:
+		if l.Config.KeyTemplate == "" {
+			return errors.New("rate limit keyTemplate is required when keyType is template")
+		}
+	default:
+		return fmt.Errorf("unknown rate limit keyType: %s", l.Config.KeyType)
+	}
+	endpoints, ok := rateLimitKeyEndpoints[l.Config.KeyType]
+	if !ok || endpointType == "" {
+		return nil
+	}
+	for _, item := range endpoints {
+		if item == endpointType {
+			return nil
+		}
+	}
+	return fmt.Errorf("rate limit keyType %s is not supported by endpoint %s", l.Config.KeyType, endpointType)
+}
+
+// Allow consumes one request of the key, returns *endpoint.RateLimitError if the limit is exceeded.
+// Allow 消耗 key 的一次请求，超出限制返回 *endpoint.RateLimitError。
+func (l *RateLimiter) Allow(key string) error {
+	now := l.now()
+	var quotaKey string
+	if l.Config.DailyQuota > 0 {
+		quotaKey = l.Config.KeyPrefix + "quota:" + now.Format("20060102") + ":" + key
+		count, err := l.cache.Incr(quotaKey, 1, "25h")
+		if err != nil {
+			return err
+		}
+		if count > l.Config.DailyQuota {
+			year, month, day := now.Date()
+			tomorrow := time.Date(year, month, day+1, 0, 0, 0, 0, now.Location())
+			return &endpoint.RateLimitError{Key: key, Err: types.ErrQuotaExceeded, RetryAfter: tomorrow.Sub(now)}
+		}
+	}
+	if l.Config.Rate > 0 {
+		if err := l.takeToken(key, now); err != nil {
+			// 被令牌桶拒绝的请求不计入配额
+			if quotaKey != "" {
+				_, _ = l.cache.Decr(quotaKey, 1, "25h")
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// takeToken takes one token from the bucket of the key.
+// The bucket is stored as "tokens:lastUnixNano" and updated by compare-and-set.
+func (l *RateLimiter) takeToken(key string, now time.Time) error {
+	bucketKey := l.Config.KeyPrefix + "bucket:" + key
+	burst := float64(l.Config.Burst)
+	// 令牌桶填满后即可过期
+	ttl := (time.Duration(burst/l.Config.Rate*float64(time.Second)) + time.Second).String()
+	for i := 0; i < maxBucketRetries; i++ {
+		current := l.cache.Get(bucketKey)
+		tokens := burst
+		// 无法解析的值视为满桶并被覆盖
+		if last, lastTokens, ok := parseTokenBucket(current); ok {
+			elapsed := time.Duration(now.UnixNano() - last).Seconds()
+			tokens = math.Min(burst, lastTokens+math.Max(0, elapsed)*l.Config.Rate)
+		}
+		if tokens < 1 {
+			retryAfter := time.Duration((1 - tokens) / l.Config.Rate * float64(time.Second))
+			return &endpoint.RateLimitError{Key: key, Err: types.ErrRateLimitExceeded, RetryAfter: retryAfter}
+		}
+		value := strconv.FormatFloat(tokens-1, 'f', -1, 64) + ":" + strconv.FormatInt(now.UnixNano(), 10)
+		if ok, err := l.cache.CompareAndSet(bucketKey, current, value, ttl); err != nil {
+			return err
+		} else if ok {
+			return nil
+		}
+	}
+	return &endpoint.RateLimitError{Key: key, Err: types.ErrRateLimitExceeded}
+}
+
+// parseTokenBucket parses the token bucket value "tokens:lastUnixNano"
+func parseTokenBucket(v interface{}) (last int64, tokens float64, ok bool) {
+	s, isStr := v.(string)
+	if !isStr {
+		return 0, 0, false
+	}
+	i := strings.IndexByte(s, ':')
+	if i < 0 {
+		return 0, 0, false
+	}
+	tokens, err := strconv.ParseFloat(s[:i], 64)
+	if err != nil {
+		return 0, 0, false
+	}
+	last, err = strconv.ParseInt(s[i+1:], 10, 64)
+	if err != nil {
+		return 0, 0, false
+	}
+	return last, tokens, true
+}
+
+// ipKey returns the client IP of http and websocket requests, empty for other endpoints
+func ipKey(exchange *endpoint.Exchange) string {
+	if r, ok := exchange.In.(interface{ Request() *http.Request }); ok && r.Request() != nil {
+		return clientIp(r.Request())
+	}
+	return ""
+}
+
+// clientIp returns the host of the request remote address
+func clientIp(r *http.Request) string {
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		return host
+	}
+	return r.RemoteAddr
+}
+
+func init() {
+	// 未通过 DSL 创建的路由，首次请求读取路由定义中的限流配置创建限流器，计数器保存在缓存中
+	InBuiltins.Register(ProcessorNameRateLimit, definitionProcess(ProcessorNameRateLimit, newRouterRateLimiter,
+		func(exchange *endpoint.Exchange, err error) bool {
+			exchange.Out.SetError(err)
+			exchange.Out.SetStatusCode(http.StatusInternalServerError)
+			exchange.Out.SetBody([]byte(err.Error()))
+			return false
+		}))
+	// 通过 DSL 创建的路由，限流器随路由创建和释放
+	InBuiltins.RegisterFactory(ProcessorNameRateLimit, newRouterRateLimiter)
+}
+
+// newRouterRateLimiter creates the rate limit process declared in the router definition,
+// the counters are kept in the config cache
+func newRouterRateLimiter(config types.Config, endpointType string, def *types.RouterDsl) (endpoint.Process, error) {
+	if def == nil || def.AdditionalInfo == nil || def.AdditionalInfo[RouterInfoKeyRateLimit] == nil {
+		return nil, errors.New("rate limit is not declared in router additionalInfo")
+	}
+	var limitConfig RateLimitConfig
+	if err := maps.Map2Struct(def.AdditionalInfo[RouterInfoKeyRateLimit], &limitConfig); err != nil {
+		return nil, err
+	}
+	limiter := NewRateLimiter(limitConfig, config.Cache)
+	if err := limiter.CheckKeyType(endpointType); err != nil {
+		return nil, err
+	}
+	return limiter.Process, nil
+}
@@ -0,0 +1,235 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package processor
+
+import (
+	"errors"
+	"net/http"
+	"net/textproto"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/yunboom/rulego/api/types"
+	"github.com/yunboom/rulego/api/types/endpoint"
+	"github.com/yunboom/rulego/endpoint/impl"
+	"github.com/yunboom/rulego/endpoint/rest"
+	"github.com/yunboom/rulego/engine"
+	"github.com/yunboom/rulego/test/assert"
+	"github.com/yunboom/rulego/utils/cache"
+)
+
+func TestRateLimiterAllow(t *testing.T) {
+	now := time.Date(2025, 1, 1, 23, 59, 0, 0, time.Local)
+	limiter := NewRateLimiter(RateLimitConfig{Rate: 2, Burst: 2, DailyQuota: 5}, cache.NewMemoryCache(time.Minute))
+	limiter.now = func() time.Time { return now }
+
+	assert.Nil(t, limiter.Allow("a"))
+	assert.Nil(t, limiter.Allow("a"))
+	err := limiter.Allow("a")
+	assert.True(t, errors.Is(err, types.ErrRateLimitExceeded))
+	var limitErr *endpoint.RateLimitError
+	assert.True(t, errors.As(err, &limitErr))
+	assert.Equal(t, "a", limitErr.Key)
+	assert.Equal(t, 500*time.Millisecond, limitErr.RetryAfter)
+	//其他 key 不受影响
+	assert.Nil(t, limiter.Allow("b"))
+
+	//补充令牌
+	now = now.Add(time.Second)
+	assert.Nil(t, limiter.Allow("a"))
+	assert.Nil(t, limiter.Allow("a"))
+	now = now.Add(time.Second)
+	assert.Nil(t, limiter.Allow("a"))
+	//每日配额用完
+	now = now.Add(time.Second)
+	err = limiter.Allow("a")
+	assert.True(t, errors.Is(err, types.ErrQuotaExceeded))
+	assert.True(t, errors.As(err, &limitErr))
+	assert.Equal(t, 57*time.Second, limitErr.RetryAfter)
+
+	//第二天重置配额
+	now = now.Add(time.Minute)
+	assert.Nil(t, limiter.Allow("a"))
+}
+
+func TestRateLimiterSharedCache(t *testing.T) {
+	//共享同一个缓存的多个限流器并发请求，不会超出配额和令牌桶容量
+	concurrentAllow := func(config RateLimitConfig) int32 {
+		c := cache.NewMemoryCache(time.Minute)
+		limiters := []*RateLimiter{NewRateLimiter(config, c), NewRateLimiter(config, c)}
+		var allowed int32
+		var wg sync.WaitGroup
+		for i := 0; i < 50; i++ {
+			wg.Add(1)
+			go func(limiter *RateLimiter) {
+				defer wg.Done()
+				if limiter.Allow("a") == nil {
+					atomic.AddInt32(&allowed, 1)
+				}
+			}(limiters[i%2])
+		}
+		wg.Wait()
+		return atomic.LoadInt32(&allowed)
+	}
+	assert.Equal(t, int32(10), concurrentAllow(RateLimitConfig{DailyQuota: 10}))
+	assert.Equal(t, int32(10), concurrentAllow(RateLimitConfig{Rate: 0.001, Burst: 10}))
+}
+
+func TestRateLimiterKey(t *testing.T) {
+	req, _ := http.NewRequest(http.MethodGet, "http://127.0.0.1/api?apiKey=k2", nil)
+	req.RemoteAddr = "192.168.1.10:52000"
+	req.Header.Set(DefaultApiKeyHeader, "k1")
+	msg := types.NewMsg(0, "devices/dev01/telemetry", types.JSON, types.NewMetadata(), "{}")
+	msg.Metadata.PutValue("deviceId", "dev02")
+	exchange := &endpoint.Exchange{
+		In:  &requestTestMessage{testMessage: testMessage{msg: &msg, headers: textproto.MIMEHeader(req.Header)}, request: req},
+		Out: &testMessage{headers: textproto.MIMEHeader{}},
+	}
+	assert.Equal(t, "192.168.1.10", NewRateLimiter(RateLimitConfig{}, nil).Key(exchange))
+	assert.Equal(t, "k1", NewRateLimiter(RateLimitConfig{KeyType: RateLimitKeyApiKey}, nil).Key(exchange))
+	req.Header.Del(DefaultApiKeyHeader)
+	assert.Equal(t, "k2", NewRateLimiter(RateLimitConfig{KeyType: RateLimitKeyApiKey}, nil).Key(exchange))
+	assert.Equal(t, "dev01", NewRateLimiter(RateLimitConfig{KeyType: RateLimitKeyClientId, ClientIdTopicLevel: 1}, nil).Key(exchange))
+	assert.Equal(t, "devices/dev01/telemetry", NewRateLimiter(RateLimitConfig{KeyType: RateLimitKeyClientId, ClientIdTopicLevel: -1}, nil).Key(exchange))
+	assert.Equal(t, "dev02", NewRateLimiter(RateLimitConfig{KeyType: RateLimitKeyTemplate, KeyTemplate: "${deviceId}"}, nil).Key(exchange))
+	assert.Equal(t, "", NewRateLimiter(RateLimitConfig{KeyType: RateLimitKeyTemplate, KeyTemplate: "${productId}"}, nil).Key(exchange))
+}
+
+func TestRateLimiterEmptyKey(t *testing.T) {
+	req, _ := http.NewRequest(http.MethodGet, "http://127.0.0.1/api", nil)
+	req.RemoteAddr = "192.168.1.10:52000"
+	msg := types.NewMsg(0, "devices", types.JSON, types.NewMetadata(), "{}")
+	c := cache.NewMemoryCache(time.Minute)
+	limiter := NewRateLimiter(RateLimitConfig{KeyType: RateLimitKeyApiKey, DailyQuota: 1}, c)
+	// 没有 API key 的请求使用客户端 IP 限流
+	httpExchange := func() *endpoint.Exchange {
+		return &endpoint.Exchange{
+			In:  &requestTestMessage{testMessage: testMessage{msg: &msg, headers: textproto.MIMEHeader{}}, request: req},
+			Out: &testMessage{headers: textproto.MIMEHeader{}},
+		}
+	}
+	assert.True(t, limiter.Process(nil, httpExchange()))
+	exchange := httpExchange()
+	assert.False(t, limiter.Process(nil, exchange))
+	limitErr, ok := endpoint.GetRateLimitError(exchange)
+	assert.True(t, ok)
+	assert.Equal(t, "192.168.1.10", limitErr.Key)
+
+	// 无法解析客户端标识也无法获取 IP 的请求被拒绝
+	limiter = NewRateLimiter(RateLimitConfig{KeyType: RateLimitKeyClientId, ClientIdTopicLevel: 1, DailyQuota: 1}, c)
+	exchange = &endpoint.Exchange{
+		In:  &testMessage{msg: &msg, headers: textproto.MIMEHeader{}},
+		Out: &testMessage{headers: textproto.MIMEHeader{}},
+	}
+	assert.False(t, limiter.Process(nil, exchange))
+	assert.True(t, errors.Is(exchange.Out.GetError(), types.ErrRateLimitKeyMissing))
+}
+
+func TestRateLimiterCheckKeyType(t *testing.T) {
+	httpType := types.EndpointTypePrefix + "http"
+	mqttType := types.EndpointTypePrefix + "mqtt"
+	assert.Nil(t, NewRateLimiter(RateLimitConfig{}, nil).CheckKeyType(httpType))
+	assert.Nil(t, NewRateLimiter(RateLimitConfig{KeyType: RateLimitKeyApiKey}, nil).CheckKeyType(""))
+	assert.Nil(t, NewRateLimiter(RateLimitConfig{KeyType: RateLimitKeyClientId}, nil).CheckKeyType(mqttType))
+	assert.Nil(t, NewRateLimiter(RateLimitConfig{KeyType: RateLimitKeyTemplate, KeyTemplate: "${deviceId}"}, nil).CheckKeyType(mqttType))
+	assert.NotNil(t, NewRateLimiter(RateLimitConfig{}, nil).CheckKeyType(mqttType))
+	assert.NotNil(t, NewRateLimiter(RateLimitConfig{KeyType: RateLimitKeyApiKey}, nil).CheckKeyType(mqttType))
+	assert.NotNil(t, NewRateLimiter(RateLimitConfig{KeyType: RateLimitKeyClientId}, nil).CheckKeyType(httpType))
+	assert.NotNil(t, NewRateLimiter(RateLimitConfig{KeyType: RateLimitKeyTemplate}, nil).CheckKeyType(mqttType))
+	assert.NotNil(t, NewRateLimiter(RateLimitConfig{KeyType: "token"}, nil).CheckKeyType(""))
+
+	def := &types.RouterDsl{AdditionalInfo: map[string]interface{}{
+		RouterInfoKeyRateLimit: map[string]interface{}{"dailyQuota": 1},
+	}}
+	_, _, err := InBuiltins.GetForRouter(ProcessorNameRateLimit, types.Config{}, mqttType, def)
+	assert.NotNil(t, err)
+}
+
+func TestRouterRateLimiterConfigCache(t *testing.T) {
+	c := cache.NewMemoryCache(time.Minute)
+	config := engine.NewConfig(types.WithCache(c))
+	def := &types.RouterDsl{AdditionalInfo: map[string]interface{}{
+		RouterInfoKeyRateLimit: map[string]interface{}{"keyType": RateLimitKeyTemplate, "keyTemplate": "${deviceId}", "dailyQuota": 1, "keyPrefix": "test:"},
+	}}
+	process, ok, err := InBuiltins.GetForRouter(ProcessorNameRateLimit, config, types.EndpointTypePrefix+"mqtt", def)
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	msg := types.NewMsg(0, "devices", types.JSON, types.NewMetadata(), "{}")
+	msg.Metadata.PutValue("deviceId", "dev01")
+	exchange := &endpoint.Exchange{
+		In:  &testMessage{msg: &msg, headers: textproto.MIMEHeader{}},
+		Out: &testMessage{headers: textproto.MIMEHeader{}},
+	}
+	assert.True(t, process(nil, exchange))
+	// 计数器保存在规则引擎配置的缓存中
+	assert.Equal(t, 1, len(c.GetByPrefix("test:quota:")))
+}
+
+func TestRateLimitRestEndpoint(t *testing.T) {
+	var server = ":9098"
+	config := engine.NewConfig(types.WithDefaultPool())
+	ep := &rest.Rest{}
+	assert.Nil(t, ep.Init(config, types.Configuration{"server": server}))
+	defer ep.Destroy()
+	var limitedCount int32
+	ep.OnEvent = func(eventName string, params ...interface{}) {
+		if eventName == endpoint.EventRateLimited {
+			atomic.AddInt32(&limitedCount, 1)
+		}
+	}
+	ep.AddInterceptors(NewRateLimitInterceptor(RateLimitConfig{Rate: 0.001, Burst: 1}, cache.NewMemoryCache(time.Minute)))
+	router := impl.NewRouter().From("/api/ping").Process(func(router endpoint.Router, exchange *endpoint.Exchange) bool {
+		exchange.Out.SetBody([]byte("pong"))
+		return false
+	}).End()
+	_, err := ep.AddRouter(router, "GET")
+	assert.Nil(t, err)
+	assert.Nil(t, ep.Start())
+	time.Sleep(time.Millisecond * 200)
+
+	resp, err := http.Get("http://127.0.0.1" + server + "/api/ping")
+	assert.Nil(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	resp, err = http.Get("http://127.0.0.1" + server + "/api/ping")
+	assert.Nil(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+	assert.NotEqual(t, "", resp.Header.Get(rest.HeaderKeyRetryAfter))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&limitedCount))
+}
+
+// requestTestMessage 带 http 请求的测试消息
+type requestTestMessage struct {
+	testMessage
+	request *http.Request
+}
+
+func (m *requestTestMessage) GetParam(key string) string {
+	return m.request.FormValue(key)
+}
+
+func (m *requestTestMessage) Request() *http.Request {
+	return m.request
+}
+
+func (m *requestTestMessage) From() string {
+	return m.msg.Type
+}
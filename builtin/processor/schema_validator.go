@@ -0,0 +1,189 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package processor
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/yunboom/rulego/api/types"
+	"github.com/yunboom/rulego/api/types/endpoint"
+	"github.com/yunboom/rulego/utils/schema"
+)
+
+// ProcessorNameValidateSchema is the name of the built-in request schema validation processor.
+// It validates the request body against the JSON Schema declared in the router AdditionalInfo
+// by endpoint.RouterInfoKeyRequestSchema (inline) or endpoint.RouterInfoKeyRequestSchemaFile (file path).
+//
+// ProcessorNameValidateSchema 内置请求 Schema 校验处理器名称。
+// 使用路由 AdditionalInfo 中 endpoint.RouterInfoKeyRequestSchema（内联）
+// 或者 endpoint.RouterInfoKeyRequestSchemaFile（文件路径）声明的 JSON Schema 校验请求体。
+//
+// Usage in Endpoint DSL / 在端点 DSL 中的使用：
+//
+//	{
+//	  "from": {
+//	    "path": "/api/device",
+//	    "processors": ["validateSchema"]
+//	  },
+//	  "additionalInfo": {
+//	    "requestSchema": {
+//	      "type": "object",
+//	      "properties": {"temperature": {"type": "number", "minimum": -40, "maximum": 125}},
+//	      "required": ["temperature"]
+//	    }
+//	  }
+//	}
+const ProcessorNameValidateSchema = "validateSchema"
+
+// ValidationFailedMessage is the message of the validation failure response.
+// ValidationFailedMessage 校验失败响应的提示信息。
+const ValidationFailedMessage = "request validation failed"
+
+// ValidationResponse is the structured response body of a rejected request.
+// ValidationResponse 请求被拒绝时的结构化响应体。
+type ValidationResponse struct {
+	Message string               `json:"message"`
+	Errors  []*schema.FieldError `json:"errors"`
+}
+
+func init() {
+	// 未通过 DSL 创建的路由，首次请求解析路由定义中的 Schema，之后按路由定义复用
+	InBuiltins.Register(ProcessorNameValidateSchema, definitionProcess(ProcessorNameValidateSchema, newRouterSchemaValidator, rejectInvalidSchema))
+	// 通过 DSL 创建的路由，Schema 只解析一次并随路由释放
+	InBuiltins.RegisterFactory(ProcessorNameValidateSchema, newRouterSchemaValidator)
+}
+
+// NewSchemaValidator creates the process that validates the request body against the schema.
+// Invalid requests are rejected with a 400 response listing the violated fields.
+//
+// NewSchemaValidator 创建使用指定 Schema 校验请求体的处理器。
+// 校验失败的请求以 400 响应拒绝，并列出违反规则的字段。
+func NewSchemaValidator(s schema.JSONSchema) endpoint.Process {
+	return func(router endpoint.Router, exchange *endpoint.Exchange) bool {
+		return validateRequest(&s, exchange)
+	}
+}
+
+// NewSchemaValidatorFromFile creates the schema validation process from a JSON Schema file.
+// The file is parsed again when its modification time changes.
+//
+// NewSchemaValidatorFromFile 从 JSON Schema 文件创建 Schema 校验处理器，文件修改时间变化时重新解析。
+func NewSchemaValidatorFromFile(path string) (endpoint.Process, error) {
+	f := &schemaFile{path: path}
+	if _, err := f.load(); err != nil {
+		return nil, err
+	}
+	return func(router endpoint.Router, exchange *endpoint.Exchange) bool {
+		s, err := f.load()
+		if err != nil {
+			return rejectInvalidSchema(exchange, err)
+		}
+		return validateRequest(s, exchange)
+	}, nil
+}
+
+// newRouterSchemaValidator creates the validation process of the schema declared in the router definition
+func newRouterSchemaValidator(_ types.Config, _ string, def *types.RouterDsl) (endpoint.Process, error) {
+	if def == nil || def.AdditionalInfo == nil {
+		return nil, errors.New("request schema is not declared in router additionalInfo")
+	}
+	if v, ok := def.AdditionalInfo[endpoint.RouterInfoKeyRequestSchema]; ok && v != nil {
+		s, err := schema.Parse(v)
+		if err != nil {
+			return nil, err
+		}
+		return NewSchemaValidator(s), nil
+	}
+	if v, ok := def.AdditionalInfo[endpoint.RouterInfoKeyRequestSchemaFile].(string); ok && v != "" {
+		return NewSchemaValidatorFromFile(v)
+	}
+	return nil, errors.New("request schema is not declared in router additionalInfo")
+}
+
+// schemaFile is the schema parsed from a file, reloaded when the file modification time changes
+type schemaFile struct {
+	path    string
+	lock    sync.Mutex
+	modTime time.Time
+	schema  *schema.JSONSchema
+}
+
+func (f *schemaFile) load() (*schema.JSONSchema, error) {
+	info, err := os.Stat(f.path)
+	if err != nil {
+		return nil, err
+	}
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if f.schema != nil && info.ModTime().Equal(f.modTime) {
+		return f.schema, nil
+	}
+	s, err := schema.ParseFile(f.path)
+	if err != nil {
+		return nil, err
+	}
+	f.schema, f.modTime = &s, info.ModTime()
+	return f.schema, nil
+}
+
+// rejectInvalidSchema writes the 500 response of a missing or invalid schema
+func rejectInvalidSchema(exchange *endpoint.Exchange, err error) bool {
+	exchange.Out.SetError(err)
+	exchange.Out.SetStatusCode(http.StatusInternalServerError)
+	exchange.Out.SetBody([]byte(err.Error()))
+	return false
+}
+
+// validateRequest validates exchange.In and writes the 400 response if it is invalid
+func validateRequest(s *schema.JSONSchema, exchange *endpoint.Exchange) bool {
+	var data interface{}
+	var err error
+	if msg := exchange.In.GetMsg(); msg != nil && msg.GetData() != "" {
+		if jsonErr := json.Unmarshal([]byte(msg.GetData()), &data); jsonErr != nil {
+			err = schema.ValidationErrors{{Rule: schema.RuleType, Message: "request body is not valid JSON"}}
+		}
+	}
+	if err == nil {
+		if data == nil && s.Type == "object" {
+			data = map[string]interface{}{}
+		}
+		err = s.ValidateValue(data)
+	}
+	if err == nil {
+		return true
+	}
+	var fieldErrors schema.ValidationErrors
+	if !errors.As(err, &fieldErrors) {
+		fieldErrors = schema.ValidationErrors{{Message: err.Error()}}
+	}
+	body, _ := json.Marshal(ValidationResponse{
+		Message: ValidationFailedMessage,
+		Errors:  fieldErrors,
+	})
+	exchange.Out.SetError(err)
+	if headers := exchange.Out.Headers(); headers != nil {
+		headers.Set(HeaderKeyContentType, HeaderValueApplicationJson)
+	}
+	exchange.Out.SetStatusCode(http.StatusBadRequest)
+	exchange.Out.SetBody(body)
+	return false
+}
@@ -0,0 +1,142 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package processor
+
+import (
+	"encoding/json"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/yunboom/rulego/api/types"
+	"github.com/yunboom/rulego/api/types/endpoint"
+	"github.com/yunboom/rulego/endpoint/impl"
+	"github.com/yunboom/rulego/test/assert"
+)
+
+// testMessage 测试用的请求、响应消息
+type testMessage struct {
+	body       []byte
+	headers    textproto.MIMEHeader
+	msg        *types.RuleMsg
+	statusCode int
+	err        error
+}
+
+func (m *testMessage) Body() []byte                  { return m.body }
+func (m *testMessage) Headers() textproto.MIMEHeader { return m.headers }
+func (m *testMessage) From() string                  { return "" }
+func (m *testMessage) GetParam(key string) string    { return "" }
+func (m *testMessage) SetMsg(msg *types.RuleMsg)     { m.msg = msg }
+func (m *testMessage) GetMsg() *types.RuleMsg        { return m.msg }
+func (m *testMessage) SetStatusCode(statusCode int)  { m.statusCode = statusCode }
+func (m *testMessage) SetBody(body []byte)           { m.body = body }
+func (m *testMessage) SetError(err error)            { m.err = err }
+func (m *testMessage) GetError() error               { return m.err }
+
+func newTestExchange(data string) *endpoint.Exchange {
+	msg := types.NewMsg(0, "", types.JSON, types.NewMetadata(), data)
+	return &endpoint.Exchange{
+		In:  &testMessage{msg: &msg},
+		Out: &testMessage{headers: textproto.MIMEHeader{}},
+	}
+}
+
+const testSchema = `{
+	"type": "object",
+	"properties": {
+		"deviceId": {"type": "string", "pattern": "^dev-[0-9]+$"},
+		"temperature": {"type": "number", "minimum": -40, "maximum": 125}
+	},
+	"required": ["deviceId", "temperature"]
+}`
+
+func TestValidateSchemaProcessor(t *testing.T) {
+	var inline map[string]interface{}
+	assert.Nil(t, json.Unmarshal([]byte(testSchema), &inline))
+	router := impl.NewRouter(endpoint.RouterOptions.WithDefinition(&types.RouterDsl{
+		AdditionalInfo: map[string]interface{}{endpoint.RouterInfoKeyRequestSchema: inline},
+	}))
+	process, ok := InBuiltins.Get(ProcessorNameValidateSchema)
+	assert.True(t, ok)
+
+	exchange := newTestExchange(`{"deviceId":"dev-1","temperature":20}`)
+	assert.True(t, process(router, exchange))
+	assert.Nil(t, exchange.Out.GetError())
+
+	exchange = newTestExchange(`{"deviceId":"aa","temperature":200}`)
+	assert.False(t, process(router, exchange))
+	out := exchange.Out.(*testMessage)
+	assert.Equal(t, 400, out.statusCode)
+	assert.Equal(t, HeaderValueApplicationJson, out.headers.Get(HeaderKeyContentType))
+	var resp ValidationResponse
+	assert.Nil(t, json.Unmarshal(out.body, &resp))
+	assert.Equal(t, ValidationFailedMessage, resp.Message)
+	assert.Equal(t, 2, len(resp.Errors))
+	assert.Equal(t, "deviceId", resp.Errors[0].Field)
+	assert.Equal(t, "pattern", resp.Errors[0].Rule)
+	assert.Equal(t, "temperature", resp.Errors[1].Field)
+	assert.Equal(t, "maximum", resp.Errors[1].Rule)
+
+	exchange = newTestExchange(``)
+	assert.False(t, process(router, exchange))
+	assert.Nil(t, json.Unmarshal(exchange.Out.(*testMessage).body, &resp))
+	assert.Equal(t, 2, len(resp.Errors))
+	assert.Equal(t, "required", resp.Errors[0].Rule)
+
+	exchange = newTestExchange(`{aa`)
+	assert.False(t, process(router, exchange))
+	assert.Equal(t, 400, exchange.Out.(*testMessage).statusCode)
+
+	//从文件加载
+	file := filepath.Join(t.TempDir(), "schema.json")
+	assert.Nil(t, os.WriteFile(file, []byte(testSchema), 0644))
+	fileRouter := impl.NewRouter(endpoint.RouterOptions.WithDefinition(&types.RouterDsl{
+		AdditionalInfo: map[string]interface{}{endpoint.RouterInfoKeyRequestSchemaFile: file},
+	}))
+	assert.True(t, process(fileRouter, newTestExchange(`{"deviceId":"dev-2","temperature":-10}`)))
+	assert.False(t, process(fileRouter, newTestExchange(`{"deviceId":"dev-2"}`)))
+	//按路由定义复用解析后的 Schema
+	_, ok = routerProcesses.Load(routerProcessKey{name: ProcessorNameValidateSchema, def: fileRouter.Definition()})
+	assert.True(t, ok)
+
+	//路由级处理器，文件修改后重新加载
+	routerProcess, ok, err := InBuiltins.GetForRouter(ProcessorNameValidateSchema, types.Config{}, "", fileRouter.Definition())
+	assert.True(t, ok)
+	assert.Nil(t, err)
+	assert.True(t, routerProcess(fileRouter, newTestExchange(`{"deviceId":"dev-2","temperature":-10}`)))
+	assert.Nil(t, os.WriteFile(file, []byte(`{"type":"object","required":["humidity"]}`), 0644))
+	modTime := time.Now().Add(time.Minute)
+	assert.Nil(t, os.Chtimes(file, modTime, modTime))
+	assert.False(t, routerProcess(fileRouter, newTestExchange(`{"deviceId":"dev-2","temperature":-10}`)))
+	assert.True(t, routerProcess(fileRouter, newTestExchange(`{"humidity":50}`)))
+	assert.Nil(t, os.WriteFile(file, []byte(testSchema), 0644))
+
+	_, _, err = InBuiltins.GetForRouter(ProcessorNameValidateSchema, types.Config{}, "", &types.RouterDsl{})
+	assert.NotNil(t, err)
+
+	fileProcess, err := NewSchemaValidatorFromFile(file)
+	assert.Nil(t, err)
+	assert.True(t, fileProcess(impl.NewRouter(), newTestExchange(`{"deviceId":"dev-3","temperature":0}`)))
+
+	//未声明 Schema
+	exchange = newTestExchange(`{}`)
+	assert.False(t, process(impl.NewRouter(), exchange))
+	assert.Equal(t, 500, exchange.Out.(*testMessage).statusCode)
+}
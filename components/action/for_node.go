@@ -36,10 +36,11 @@ import (
 	"strings"
 	"sync"
 
-	"github.com/expr-lang/expr"
 	"github.com/expr-lang/expr/vm"
+	"github.com/jmespath/go-jmespath"
 	"github.com/yunboom/rulego/api/types"
 	"github.com/yunboom/rulego/components/base"
+	"github.com/yunboom/rulego/utils/el"
 	"github.com/yunboom/rulego/utils/json"
 	"github.com/yunboom/rulego/utils/maps"
 	"github.com/yunboom/rulego/utils/str"
@@ -73,6 +74,7 @@ func init() {
 type ForNodeConfiguration struct {
 	// Range is the target expression to iterate over, supporting arrays, slices, and structs.
 	// Expr expressions are allowed, e.g., msg.items, 1..5 to iterate over []int{1,2,3,4,5}.
+	// JMESPath queries with the jq: prefix are allowed, e.g., jq: msg.items[?status=='error'].
 	// If empty, it iterates over the msg payload.
 	Range string
 	// Do specifies the node or sub-rule chain to process the iterated elements,
@@ -111,6 +113,7 @@ type ForNodeConfiguration struct {
 //   - Metadata: "metadata.list"  元数据
 //   - Numeric ranges: "1..5" creates [1,2,3,4,5]  数值范围
 //   - Complex expressions: "msg.data.products"  复杂表达式
+//   - JMESPath queries: "jq: msg.items[?status=='error']"  JMESPath 查询
 //   - Empty: Iterates over entire message payload  空值：遍历整个消息负荷
 //
 // Processing Modes:
@@ -209,6 +212,8 @@ type ForNode struct {
 	Config ForNodeConfiguration
 	// range variable
 	program *vm.Program
+	// range JMESPath query
+	query *jmespath.JMESPath
 	// do variable nodeId or chainId
 	ruleNodeId types.RuleNodeId
 }
@@ -226,7 +231,7 @@ func (x *ForNode) New() types.Node {
 }
 
 // Init 初始化
-func (x *ForNode) Init(_ types.Config, configuration types.Configuration) error {
+func (x *ForNode) Init(ruleConfig types.Config, configuration types.Configuration) error {
 	// Map the configuration to the ForNodeConfiguration struct.
 	if err := maps.Map2Struct(configuration, &x.Config); err != nil {
 		return err
@@ -234,8 +239,14 @@ func (x *ForNode) Init(_ types.Config, configuration types.Configuration) error
 	// Trim whitespace from the Range configuration.
 	x.Config.Range = strings.TrimSpace(x.Config.Range)
 	// Compile the Range expression if it's not empty.
-	if x.Config.Range != "" {
-		if program, err := expr.Compile(x.Config.Range, expr.AllowUndefinedVariables()); err != nil {
+	if el.IsQuery(x.Config.Range) {
+		if query, err := el.CompileQuery(x.Config.Range); err != nil {
+			return err
+		} else {
+			x.query = query
+		}
+	} else if x.Config.Range != "" {
+		if program, err := el.CompileExpr(x.Config.Range, ruleConfig.Udf, base.NodeUtils.GetExprTypes(configuration)); err != nil {
 			return err
 		} else {
 			x.program = program
@@ -284,6 +295,13 @@ func (x *ForNode) OnMsg(ctx types.RuleContext, msg types.RuleMsg) {
 		} else {
 			data = out
 		}
+	} else if x.query != nil {
+		if out, err := el.Search(x.query, evn); err != nil {
+			ctx.TellFailure(msg, err)
+			return
+		} else {
+			data = out
+		}
 	} else {
 		data = x.toMap(inData)
 	}
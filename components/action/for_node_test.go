@@ -328,6 +328,34 @@ func TestForNode(t *testing.T) {
 		time.Sleep(time.Millisecond * 20)
 
 	})
+
+	t.Run("QueryRange", func(t *testing.T) {
+		_, err := test.CreateAndInitNode(targetNodeType, types.Configuration{
+			"range": "jq: msg.items[?",
+			"do":    "node1",
+		}, Registry)
+		assert.NotNil(t, err)
+
+		node, err := test.CreateAndInitNode(targetNodeType, types.Configuration{
+			"range": "jq: msg.items[?status=='error'].name",
+			"do":    "node1",
+			"mode":  MergeValues,
+		}, Registry)
+		assert.Nil(t, err)
+		childrenNode, _ := test.CreateAndInitNode("comment", types.Configuration{}, Registry)
+		msgList := []test.Msg{
+			{
+				MetaData:   types.NewMetadata(),
+				MsgType:    "ACTIVITY_EVENT",
+				Data:       `{"items":[{"name":"a","status":"ok"},{"name":"b","status":"error"},{"name":"c","status":"error"}]}`,
+				AfterSleep: time.Millisecond * 20,
+			},
+		}
+		test.NodeOnMsgWithChildren(t, node, msgList, map[string]types.Node{"node1": childrenNode}, func(msg types.RuleMsg, relationType string, err error) {
+			assert.Equal(t, types.Success, relationType)
+			assert.Equal(t, `["b","c"]`, msg.GetData())
+		})
+	})
 }
 
 // TestForNodeMetadataAccuracy 测试for节点metadata的准确性
@@ -31,6 +31,7 @@ import (
 	"fmt"
 
 	"github.com/yunboom/rulego/utils/js"
+	"github.com/yunboom/rulego/utils/lua"
 
 	"github.com/yunboom/rulego/api/types"
 	"github.com/yunboom/rulego/components/base"
@@ -43,6 +44,9 @@ const (
 
 	// JsLogFuncTemplate JavaScript函数模板
 	JsLogFuncTemplate = "function ToString(msg, metadata, msgType, dataType) { %s }"
+
+	// LuaLogFuncTemplate Lua函数模板
+	LuaLogFuncTemplate = "function ToString(msg, metadata, msgType, dataType) %s end"
 )
 
 // JsLogReturnFormatErr JavaScript脚本必须返回字符串
@@ -67,6 +71,9 @@ type LogNodeConfiguration struct {
 	//
 	// 示例: "return '[' + msgType + '] ' + JSON.stringify(msg);"
 	JsScript string `json:"jsScript"`
+	// ScriptType 脚本类型：Js 或 Lua，默认 Js
+	// Lua 脚本中上下文对象为 ctx，示例: "return '[' .. msgType .. '] ' .. tostring(msg.temperature)"
+	ScriptType string `json:"scriptType"`
 }
 
 // LogNode 使用JavaScript格式化并记录消息的日志节点
@@ -97,8 +104,16 @@ func (x *LogNode) New() types.Node {
 func (x *LogNode) Init(ruleConfig types.Config, configuration types.Configuration) error {
 	err := maps.Map2Struct(configuration, &x.Config)
 	if err == nil {
-		jsScript := fmt.Sprintf(JsLogFuncTemplate, x.Config.JsScript)
-		x.jsEngine, err = js.NewGojaJsEngine(ruleConfig, jsScript, base.NodeUtils.GetVars(configuration))
+		switch x.Config.ScriptType {
+		case types.Lua:
+			luaScript := fmt.Sprintf(LuaLogFuncTemplate, x.Config.JsScript)
+			x.jsEngine, err = lua.NewLuaEngine(ruleConfig, luaScript, base.NodeUtils.GetVars(configuration))
+		case types.Js, types.AllScript:
+			jsScript := fmt.Sprintf(JsLogFuncTemplate, x.Config.JsScript)
+			x.jsEngine, err = js.NewGojaJsEngine(ruleConfig, jsScript, base.NodeUtils.GetVars(configuration))
+		default:
+			err = fmt.Errorf("unsupported script type: %s", x.Config.ScriptType)
+		}
 	}
 	x.logger = ruleConfig.Logger
 	return err
@@ -95,3 +95,24 @@ func TestJsLogNode(t *testing.T) {
 		}
 	})
 }
+
+// TestJsLogNodeLua 测试使用Lua脚本格式化日志
+func TestJsLogNodeLua(t *testing.T) {
+	config := types.NewConfig()
+	node := &LogNode{}
+	err := node.Init(config, types.Configuration{
+		"scriptType": types.Lua,
+		"jsScript":   "return '[' .. msgType .. '] ' .. tostring(msg.temperature)",
+	})
+	assert.Nil(t, err)
+	defer node.Destroy()
+
+	ctx := test.NewRuleContext(config, func(msg types.RuleMsg, relationType string, err error) {
+		assert.Nil(t, err)
+		assert.Equal(t, types.Success, relationType)
+	})
+	out, err := node.jsEngine.Execute(ctx, JsLogFuncName, map[string]interface{}{"temperature": 41}, map[string]string{}, "TEST", "JSON")
+	assert.Nil(t, err)
+	assert.Equal(t, "[TEST] 41", out)
+	node.OnMsg(ctx, types.NewMsg(0, "TEST", types.JSON, types.NewMetadata(), `{"temperature":41}`))
+}
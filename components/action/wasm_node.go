@@ -0,0 +1,489 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package action
+
+//规则链节点配置示例：
+//{
+//	"id": "s1",
+//	"type": "wasm",
+//	"name": "WebAssembly组件",
+//	"configuration": {
+//		"path": "./plugins/filter.wasm",
+//		"function": "onMsg",
+//		"poolSize": 4,
+//		"timeoutMs": 1000
+//	}
+//}
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/imports/wasi_snapshot_preview1"
+	"github.com/yunboom/rulego/api/types"
+	"github.com/yunboom/rulego/utils/json"
+	"github.com/yunboom/rulego/utils/maps"
+	"github.com/yunboom/rulego/utils/str"
+)
+
+const (
+	// WasmHostModule WebAssembly模块导入宿主函数使用的模块名
+	// WasmHostModule is the module name of the host functions imported by the WebAssembly modules.
+	WasmHostModule = "rulego"
+	// WasmMallocFunc 模块必须导出的内存分配函数 malloc(size i32) i32
+	// WasmMallocFunc is the allocation function malloc(size i32) i32 the module must export.
+	WasmMallocFunc = "malloc"
+	// WasmFreeFunc 模块可选导出的内存释放函数 free(ptr i32, size i32)
+	// WasmFreeFunc is the optional free function free(ptr i32, size i32) of the module.
+	WasmFreeFunc = "free"
+	// WasmCacheLevelChain 宿主缓存函数的规则链缓存级别
+	// WasmCacheLevelChain is the chain cache level of the host cache functions.
+	WasmCacheLevelChain = 0
+	// WasmCacheLevelGlobal 宿主缓存函数的全局缓存级别
+	// WasmCacheLevelGlobal is the global cache level of the host cache functions.
+	WasmCacheLevelGlobal = 1
+)
+
+// wasmMagic WebAssembly二进制文件的魔数
+var wasmMagic = []byte{0x00, 0x61, 0x73, 0x6d}
+
+// wasmRuleCtxKey 调用宿主函数时传递规则上下文的key
+type wasmRuleCtxKey struct{}
+
+// init 注册WasmNode组件
+// init registers the WasmNode component with the default registry.
+func init() {
+	Registry.Add(&WasmNode{})
+}
+
+// WasmNodeConfiguration WasmNode配置结构
+// WasmNodeConfiguration defines the configuration structure for the WasmNode component.
+type WasmNodeConfiguration struct {
+	// Path WebAssembly模块文件路径
+	// Path is the file path of the WebAssembly module.
+	Path string
+	// Module base64编码的WebAssembly模块，Path为空时使用。也可以直接传入模块的二进制内容
+	// Module is the base64 encoded WebAssembly module, used if Path is empty. The raw binary is also accepted.
+	Module string
+	// Function 处理消息的导出函数 fn(ptr i32, len i32) i64
+	// Function is the exported function fn(ptr i32, len i32) i64 that processes the message.
+	Function string
+	// PoolSize 保留的模块实例数量，每个实例同一时间只处理一条消息
+	// PoolSize is the number of the retained module instances, each instance processes one message at a time.
+	PoolSize int
+	// TimeoutMs 执行超时时间，单位毫秒，超时后中断执行。0表示不限制
+	// TimeoutMs is the execution timeout in milliseconds, the execution is interrupted after timeout. 0 means no limit.
+	TimeoutMs int
+}
+
+// wasmInput 传入模块的消息
+type wasmInput struct {
+	Id       string            `json:"id"`
+	Ts       int64             `json:"ts"`
+	Data     string            `json:"data"`
+	DataType string            `json:"dataType"`
+	MsgType  string            `json:"msgType"`
+	Metadata map[string]string `json:"metadata"`
+}
+
+// wasmOutput 模块返回的结果，为空的字段保持不变
+type wasmOutput struct {
+	Data         *string           `json:"data"`
+	DataType     string            `json:"dataType"`
+	MsgType      string            `json:"msgType"`
+	Metadata     map[string]string `json:"metadata"`
+	RelationType string            `json:"relationType"`
+	Error        string            `json:"error"`
+}
+
+// WasmNode 在WebAssembly沙箱中执行自定义逻辑的组件，使用纯Go实现的wazero运行时，不依赖cgo和Go plugin
+// WasmNode executes custom logic in the WebAssembly sandbox using the pure Go wazero runtime, without cgo or Go plugins.
+//
+// 模块可以使用任何支持WebAssembly的语言编写，例如Rust、TinyGo、AssemblyScript，支持WASI(wasi_snapshot_preview1)。
+// 如果模块导出 _initialize 函数，实例化时会调用该函数。
+// The module can be written in any language targeting WebAssembly, e.g. Rust, TinyGo, AssemblyScript. WASI
+// (wasi_snapshot_preview1) is supported. The _initialize function is called on instantiation if exported.
+//
+// 调用约定 - Calling convention:
+//   - 模块导出 memory 和 malloc(size i32) i32，可选导出 free(ptr i32, size i32)
+//     The module exports memory and malloc(size i32) i32, optionally free(ptr i32, size i32)
+//   - 宿主把消息序列化成JSON写入模块内存，然后调用 Function(ptr i32, len i32) i64
+//     The host writes the message as JSON into the module memory, then calls Function(ptr i32, len i32) i64
+//   - 输入JSON - Input JSON: {"id":"","ts":0,"data":"","dataType":"JSON","msgType":"","metadata":{}}
+//   - 返回值高32位为结果指针，低32位为结果长度，0表示消息不变
+//     The high 32 bits of the result are the pointer of the output, the low 32 bits are the length, 0 keeps the message
+//   - 输出JSON - Output JSON: {"data":"","dataType":"","msgType":"","metadata":{},"relationType":"Success","error":""}
+//     省略的字段保持不变，metadata替换全部元数据，relationType默认为Success，error不为空时路由到Failure链
+//     The omitted fields are kept, metadata replaces all metadata, relationType defaults to Success,
+//     the message is routed to Failure if error is not empty
+//
+// 宿主函数 - Host functions (module "rulego"):
+//   - log(ptr i32, len i32): 输出日志 - Logs the message
+//   - cache_get(level i32, keyPtr i32, keyLen i32) i64: 读取缓存，返回值格式同上，不存在返回0
+//     Gets the cache value, the result has the same format as above, 0 if not found
+//   - cache_set(level i32, keyPtr i32, keyLen i32, valPtr i32, valLen i32, ttlPtr i32, ttlLen i32) i32: 设置缓存，成功返回0
+//     Sets the cache value, returns 0 on success
+//   - cache_delete(level i32, keyPtr i32, keyLen i32) i32: 删除缓存，成功返回0 - Deletes the cache value, returns 0 on success
+//
+// level: 0 规则链缓存 - chain cache，1 全局缓存 - global cache
+type WasmNode struct {
+	// Config 节点配置
+	// Config holds the node configuration
+	Config WasmNodeConfiguration
+	// ruleConfig 规则引擎配置
+	ruleConfig types.Config
+	// runtime WebAssembly运行时
+	runtime wazero.Runtime
+	// compiled 编译后的模块
+	compiled wazero.CompiledModule
+	// pool 空闲的模块实例
+	pool chan api.Module
+}
+
+// Type 返回组件类型
+// Type returns the component type identifier.
+func (x *WasmNode) Type() string {
+	return "wasm"
+}
+
+// New 创建新实例
+// New creates a new instance.
+func (x *WasmNode) New() types.Node {
+	return &WasmNode{Config: WasmNodeConfiguration{
+		Function: "onMsg",
+		PoolSize: 1,
+	}}
+}
+
+// Init 初始化组件，编译模块并注册宿主函数
+// Init initializes the component, compiles the module and registers the host functions.
+func (x *WasmNode) Init(ruleConfig types.Config, configuration types.Configuration) error {
+	if err := maps.Map2Struct(configuration, &x.Config); err != nil {
+		return err
+	}
+	x.ruleConfig = ruleConfig
+	if strings.TrimSpace(x.Config.Function) == "" {
+		return errors.New("function can not be empty")
+	}
+	if x.Config.PoolSize <= 0 {
+		x.Config.PoolSize = 1
+	}
+	binary, err := x.loadModule()
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+	x.runtime = wazero.NewRuntimeWithConfig(ctx, wazero.NewRuntimeConfig().WithCloseOnContextDone(x.Config.TimeoutMs > 0))
+	if err = x.init(ctx, binary); err != nil {
+		_ = x.runtime.Close(ctx)
+		x.runtime = nil
+		return err
+	}
+	x.pool = make(chan api.Module, x.Config.PoolSize)
+	// 预先实例化一个模块，提前发现实例化错误
+	mod, err := x.instantiate(ctx)
+	if err != nil {
+		x.Destroy()
+		return err
+	}
+	x.release(mod)
+	return nil
+}
+
+// OnMsg 处理消息，调用模块的导出函数
+// OnMsg processes incoming messages by calling the exported function of the module.
+func (x *WasmNode) OnMsg(ctx types.RuleContext, msg types.RuleMsg) {
+	parent := ctx.GetContext()
+	if parent == nil {
+		parent = context.Background()
+	}
+	callCtx := context.WithValue(parent, wasmRuleCtxKey{}, ctx)
+	if x.Config.TimeoutMs > 0 {
+		var cancel context.CancelFunc
+		callCtx, cancel = context.WithTimeout(callCtx, time.Duration(x.Config.TimeoutMs)*time.Millisecond)
+		defer cancel()
+	}
+	mod, err := x.acquire(callCtx)
+	if err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	out, err := x.call(callCtx, mod, msg)
+	if err != nil {
+		// 调用失败的实例状态不可信，直接关闭不归还
+		_ = mod.Close(context.Background())
+		ctx.TellFailure(msg, err)
+		return
+	}
+	x.release(mod)
+	if out == nil {
+		ctx.TellSuccess(msg)
+		return
+	}
+	if out.Error != "" {
+		ctx.TellFailure(msg, errors.New(out.Error))
+		return
+	}
+	if out.Data != nil {
+		msg.SetData(*out.Data)
+	}
+	if out.DataType != "" {
+		msg.DataType = types.DataType(out.DataType)
+	}
+	if out.MsgType != "" {
+		msg.Type = out.MsgType
+	}
+	if out.Metadata != nil {
+		msg.Metadata.ReplaceAll(out.Metadata)
+	}
+	if out.RelationType == "" || out.RelationType == types.Success {
+		ctx.TellSuccess(msg)
+	} else {
+		ctx.TellNext(msg, out.RelationType)
+	}
+}
+
+// Destroy 关闭运行时和所有模块实例
+// Destroy closes the runtime and all module instances.
+func (x *WasmNode) Destroy() {
+	if x.runtime != nil {
+		_ = x.runtime.Close(context.Background())
+		x.runtime = nil
+	}
+}
+
+// loadModule 读取模块文件或者解码模块内容
+func (x *WasmNode) loadModule() ([]byte, error) {
+	if path := strings.TrimSpace(x.Config.Path); path != "" {
+		return os.ReadFile(path)
+	}
+	if x.Config.Module == "" {
+		return nil, errors.New("path and module can not be empty")
+	}
+	if binary := []byte(x.Config.Module); bytes.HasPrefix(binary, wasmMagic) {
+		return binary, nil
+	}
+	return base64.StdEncoding.DecodeString(strings.TrimSpace(x.Config.Module))
+}
+
+// init 注册WASI和宿主函数，编译模块并检查导出函数
+func (x *WasmNode) init(ctx context.Context, binary []byte) error {
+	if _, err := wasi_snapshot_preview1.Instantiate(ctx, x.runtime); err != nil {
+		return err
+	}
+	if _, err := x.runtime.NewHostModuleBuilder(WasmHostModule).
+		NewFunctionBuilder().WithFunc(x.hostLog).Export("log").
+		NewFunctionBuilder().WithFunc(x.hostCacheGet).Export("cache_get").
+		NewFunctionBuilder().WithFunc(x.hostCacheSet).Export("cache_set").
+		NewFunctionBuilder().WithFunc(x.hostCacheDelete).Export("cache_delete").
+		Instantiate(ctx); err != nil {
+		return err
+	}
+	compiled, err := x.runtime.CompileModule(ctx, binary)
+	if err != nil {
+		return err
+	}
+	exports := compiled.ExportedFunctions()
+	if _, ok := exports[WasmMallocFunc]; !ok {
+		return fmt.Errorf("function %s is not exported", WasmMallocFunc)
+	}
+	if _, ok := exports[x.Config.Function]; !ok {
+		return fmt.Errorf("function %s is not exported", x.Config.Function)
+	}
+	x.compiled = compiled
+	return nil
+}
+
+// instantiate 创建新的匿名模块实例
+func (x *WasmNode) instantiate(ctx context.Context) (api.Module, error) {
+	return x.runtime.InstantiateModule(ctx, x.compiled, wazero.NewModuleConfig().
+		WithName("").
+		WithStartFunctions("_initialize").
+		WithSysWalltime().
+		WithSysNanotime())
+}
+
+// acquire 获取空闲的模块实例，没有空闲实例时创建新实例
+func (x *WasmNode) acquire(ctx context.Context) (api.Module, error) {
+	select {
+	case mod := <-x.pool:
+		return mod, nil
+	default:
+		return x.instantiate(ctx)
+	}
+}
+
+// release 归还模块实例，实例已关闭或者超过PoolSize时关闭
+func (x *WasmNode) release(mod api.Module) {
+	if mod.IsClosed() {
+		return
+	}
+	select {
+	case x.pool <- mod:
+	default:
+		_ = mod.Close(context.Background())
+	}
+}
+
+// call 把消息写入模块内存，调用导出函数并读取结果
+func (x *WasmNode) call(ctx context.Context, mod api.Module, msg types.RuleMsg) (*wasmOutput, error) {
+	input, err := json.Marshal(wasmInput{
+		Id:       msg.Id,
+		Ts:       msg.Ts,
+		Data:     msg.GetData(),
+		DataType: string(msg.DataType),
+		MsgType:  msg.Type,
+		Metadata: msg.Metadata.Values(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	ptr, err := writeWasmBytes(ctx, mod, input)
+	if err != nil {
+		return nil, err
+	}
+	results, err := mod.ExportedFunction(x.Config.Function).Call(ctx, uint64(ptr), uint64(len(input)))
+	freeWasmBytes(ctx, mod, ptr, uint32(len(input)))
+	if err != nil {
+		return nil, err
+	}
+	if len(results) == 0 || results[0] == 0 {
+		return nil, nil
+	}
+	outPtr, outLen := uint32(results[0]>>32), uint32(results[0])
+	outBytes, ok := mod.Memory().Read(outPtr, outLen)
+	if !ok {
+		return nil, fmt.Errorf("output out of memory range: ptr=%d len=%d", outPtr, outLen)
+	}
+	var out wasmOutput
+	err = json.Unmarshal(outBytes, &out)
+	freeWasmBytes(ctx, mod, outPtr, outLen)
+	if err != nil {
+		return nil, fmt.Errorf("invalid output: %w", err)
+	}
+	return &out, nil
+}
+
+// hostLog 宿主函数 log(ptr i32, len i32)
+func (x *WasmNode) hostLog(ctx context.Context, mod api.Module, ptr, length uint32) {
+	if b, ok := mod.Memory().Read(ptr, length); ok && x.ruleConfig.Logger != nil {
+		if ruleCtx, ok := ctx.Value(wasmRuleCtxKey{}).(types.RuleContext); ok {
+			x.ruleConfig.Logger.Printf("wasm node %s: %s", ruleCtx.GetSelfId(), string(b))
+		} else {
+			x.ruleConfig.Logger.Printf("wasm node: %s", string(b))
+		}
+	}
+}
+
+// hostCacheGet 宿主函数 cache_get(level i32, keyPtr i32, keyLen i32) i64
+func (x *WasmNode) hostCacheGet(ctx context.Context, mod api.Module, level, keyPtr, keyLen uint32) uint64 {
+	cache, key, ok := x.hostCache(ctx, mod, level, keyPtr, keyLen)
+	if !ok {
+		return 0
+	}
+	value := cache.Get(key)
+	if value == nil {
+		return 0
+	}
+	b := []byte(str.ToString(value))
+	ptr, err := writeWasmBytes(ctx, mod, b)
+	if err != nil {
+		return 0
+	}
+	return uint64(ptr)<<32 | uint64(len(b))
+}
+
+// hostCacheSet 宿主函数 cache_set(level i32, keyPtr i32, keyLen i32, valPtr i32, valLen i32, ttlPtr i32, ttlLen i32) i32
+func (x *WasmNode) hostCacheSet(ctx context.Context, mod api.Module, level, keyPtr, keyLen, valPtr, valLen, ttlPtr, ttlLen uint32) uint32 {
+	cache, key, ok := x.hostCache(ctx, mod, level, keyPtr, keyLen)
+	if !ok {
+		return 1
+	}
+	value, ok := mod.Memory().Read(valPtr, valLen)
+	if !ok {
+		return 1
+	}
+	ttl, ok := mod.Memory().Read(ttlPtr, ttlLen)
+	if !ok {
+		return 1
+	}
+	if err := cache.Set(key, string(value), string(ttl)); err != nil {
+		return 1
+	}
+	return 0
+}
+
+// hostCacheDelete 宿主函数 cache_delete(level i32, keyPtr i32, keyLen i32) i32
+func (x *WasmNode) hostCacheDelete(ctx context.Context, mod api.Module, level, keyPtr, keyLen uint32) uint32 {
+	cache, key, ok := x.hostCache(ctx, mod, level, keyPtr, keyLen)
+	if !ok {
+		return 1
+	}
+	if err := cache.Delete(key); err != nil {
+		return 1
+	}
+	return 0
+}
+
+// hostCache 获取指定级别的缓存和key
+func (x *WasmNode) hostCache(ctx context.Context, mod api.Module, level, keyPtr, keyLen uint32) (types.Cache, string, bool) {
+	ruleCtx, ok := ctx.Value(wasmRuleCtxKey{}).(types.RuleContext)
+	if !ok {
+		return nil, "", false
+	}
+	key, ok := mod.Memory().Read(keyPtr, keyLen)
+	if !ok {
+		return nil, "", false
+	}
+	var cache types.Cache
+	if level == WasmCacheLevelGlobal {
+		cache = ruleCtx.GlobalCache()
+	} else {
+		cache = ruleCtx.ChainCache()
+	}
+	if cache == nil {
+		return nil, "", false
+	}
+	return cache, string(key), true
+}
+
+// writeWasmBytes 使用模块的malloc分配内存并写入数据
+func writeWasmBytes(ctx context.Context, mod api.Module, b []byte) (uint32, error) {
+	results, err := mod.ExportedFunction(WasmMallocFunc).Call(ctx, uint64(len(b)))
+	if err != nil {
+		return 0, err
+	}
+	ptr := uint32(results[0])
+	if !mod.Memory().Write(ptr, b) {
+		return 0, fmt.Errorf("malloc returned out of memory range: ptr=%d len=%d", ptr, len(b))
+	}
+	return ptr, nil
+}
+
+// freeWasmBytes 如果模块导出了free函数，释放内存
+func freeWasmBytes(ctx context.Context, mod api.Module, ptr, length uint32) {
+	if free := mod.ExportedFunction(WasmFreeFunc); free != nil {
+		_, _ = free.Call(ctx, uint64(ptr), uint64(length))
+	}
+}
@@ -0,0 +1,328 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package action
+
+import (
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/yunboom/rulego/api/types"
+	"github.com/yunboom/rulego/test"
+	"github.com/yunboom/rulego/test/assert"
+)
+
+const (
+	wasmTestRouteOutput = `{"relationType":"True","data":"ok","msgType":"T2","metadata":{"a":"b"}}`
+	wasmTestFailOutput  = `{"error":"boom"}`
+	wasmTestRouteOffset = 16
+	wasmTestFailOffset  = 128
+)
+
+// wasmTestModule 手工编码的测试模块，等价于：
+//
+//	(module
+//	  (import "rulego" "log" (func $log (param i32 i32)))
+//	  (import "rulego" "cache_get" (func $cache_get (param i32 i32 i32) (result i64)))
+//	  (import "rulego" "cache_set" (func $cache_set (param i32 i32 i32 i32 i32 i32 i32) (result i32)))
+//	  (memory (export "memory") 1)
+//	  (global $heap (mut i32) (i32.const 1024))
+//	  (func (export "malloc") (param i32) (result i32)
+//	    global.get $heap (global.set $heap (i32.add (global.get $heap) (local.get 0))))
+//	  ;; 输出日志，把输入写入缓存key "k"，返回缓存的值
+//	  (func (export "echo") (param i32 i32) (result i64)
+//	    (call $log (local.get 0) (local.get 1))
+//	    (drop (call $cache_set (i32.const 0) (i32.const 0) (i32.const 1) (local.get 0) (local.get 1) (i32.const 0) (i32.const 0)))
+//	    (call $cache_get (i32.const 0) (i32.const 0) (i32.const 1)))
+//	  (func (export "route") (param i32 i32) (result i64) (i64.const <routeOutput>))
+//	  (func (export "fail") (param i32 i32) (result i64) (i64.const <failOutput>))
+//	  (func (export "keep") (param i32 i32) (result i64) (i64.const 0))
+//	  (func (export "loop") (param i32 i32) (result i64) (loop (br 0)) (i64.const 0))
+//	  ;; 输入超过512字节时陷入异常
+//	  (func (export "trap") (param i32 i32) (result i64)
+//	    (if (i32.gt_u (local.get 1) (i32.const 512)) (then unreachable)) (i64.const 0))
+//	  (data (i32.const 0) "k")
+//	  (data (i32.const 16) "<routeOutput>")
+//	  (data (i32.const 128) "<failOutput>"))
+func wasmTestModule() []byte {
+	i32, i64 := byte(0x7f), byte(0x7e)
+	funcType := func(params []byte, results ...byte) []byte {
+		return append(append(append([]byte{0x60}, wasmVec(len(params), params)...), byte(len(results))), results...)
+	}
+	packed := func(offset int, output string) []byte {
+		return append([]byte{0x42}, wasmSleb(int64(offset)<<32|int64(len(output)))...)
+	}
+	importFunc := func(name string, typeIdx byte) []byte {
+		return append(append(wasmName(WasmHostModule), wasmName(name)...), 0x00, typeIdx)
+	}
+	exportItem := func(name string, kind, idx byte) []byte {
+		return append(wasmName(name), kind, idx)
+	}
+	body := func(code ...byte) []byte {
+		code = append(append([]byte{0x00}, code...), 0x0b)
+		return append(wasmUleb(uint64(len(code))), code...)
+	}
+	data := func(offset int, content string) []byte {
+		b := append([]byte{0x00, 0x41}, wasmSleb(int64(offset))...)
+		return append(append(b, 0x0b), wasmName(content)...)
+	}
+	types := [][]byte{
+		funcType([]byte{i32, i32}),
+		funcType([]byte{i32, i32, i32}, i64),
+		funcType([]byte{i32, i32, i32, i32, i32, i32, i32}, i32),
+		funcType([]byte{i32}, i32),
+		funcType([]byte{i32, i32}, i64),
+	}
+	imports := [][]byte{importFunc("log", 0), importFunc("cache_get", 1), importFunc("cache_set", 2)}
+	exports := [][]byte{
+		exportItem("memory", 0x02, 0),
+		exportItem("malloc", 0x00, 3),
+		exportItem("echo", 0x00, 4),
+		exportItem("route", 0x00, 5),
+		exportItem("fail", 0x00, 6),
+		exportItem("keep", 0x00, 7),
+		exportItem("loop", 0x00, 8),
+		exportItem("trap", 0x00, 9),
+	}
+	codes := [][]byte{
+		body(0x23, 0x00, 0x23, 0x00, 0x20, 0x00, 0x6a, 0x24, 0x00),
+		body(0x20, 0x00, 0x20, 0x01, 0x10, 0x00,
+			0x41, 0x00, 0x41, 0x00, 0x41, 0x01, 0x20, 0x00, 0x20, 0x01, 0x41, 0x00, 0x41, 0x00, 0x10, 0x02, 0x1a,
+			0x41, 0x00, 0x41, 0x00, 0x41, 0x01, 0x10, 0x01),
+		body(packed(wasmTestRouteOffset, wasmTestRouteOutput)...),
+		body(packed(wasmTestFailOffset, wasmTestFailOutput)...),
+		body(0x42, 0x00),
+		body(0x03, 0x40, 0x0c, 0x00, 0x0b, 0x42, 0x00),
+		body(0x20, 0x01, 0x41, 0x80, 0x04, 0x4b, 0x04, 0x40, 0x00, 0x0b, 0x42, 0x00),
+	}
+	datas := [][]byte{data(0, "k"), data(wasmTestRouteOffset, wasmTestRouteOutput), data(wasmTestFailOffset, wasmTestFailOutput)}
+
+	module := []byte{0x00, 0x61, 0x73, 0x6d, 0x01, 0x00, 0x00, 0x00}
+	module = append(module, wasmSection(1, wasmVecOf(types))...)
+	module = append(module, wasmSection(2, wasmVecOf(imports))...)
+	module = append(module, wasmSection(3, wasmVec(7, []byte{3, 4, 4, 4, 4, 4, 4}))...)
+	module = append(module, wasmSection(5, []byte{0x01, 0x00, 0x01})...)
+	module = append(module, wasmSection(6, []byte{0x01, i32, 0x01, 0x41, 0x80, 0x08, 0x0b})...)
+	module = append(module, wasmSection(7, wasmVecOf(exports))...)
+	module = append(module, wasmSection(10, wasmVecOf(codes))...)
+	module = append(module, wasmSection(11, wasmVecOf(datas))...)
+	return module
+}
+
+func wasmUleb(v uint64) []byte {
+	var b []byte
+	for {
+		c := byte(v & 0x7f)
+		v >>= 7
+		if v != 0 {
+			b = append(b, c|0x80)
+		} else {
+			return append(b, c)
+		}
+	}
+}
+
+func wasmSleb(v int64) []byte {
+	var b []byte
+	for {
+		c := byte(v & 0x7f)
+		v >>= 7
+		if (v == 0 && c&0x40 == 0) || (v == -1 && c&0x40 != 0) {
+			return append(b, c)
+		}
+		b = append(b, c|0x80)
+	}
+}
+
+func wasmName(s string) []byte {
+	return append(wasmUleb(uint64(len(s))), s...)
+}
+
+func wasmVec(n int, content []byte) []byte {
+	return append(wasmUleb(uint64(n)), content...)
+}
+
+func wasmVecOf(items [][]byte) []byte {
+	var content []byte
+	for _, item := range items {
+		content = append(content, item...)
+	}
+	return wasmVec(len(items), content)
+}
+
+func wasmSection(id byte, content []byte) []byte {
+	return append(append([]byte{id}, wasmUleb(uint64(len(content)))...), content...)
+}
+
+type wasmTestLogger struct {
+	sync.Mutex
+	logs []string
+}
+
+func (l *wasmTestLogger) Printf(format string, v ...interface{}) {
+	l.Lock()
+	defer l.Unlock()
+	l.logs = append(l.logs, fmt.Sprintf(format, v...))
+}
+
+func TestWasmNode(t *testing.T) {
+	var targetNodeType = "wasm"
+	module := base64.StdEncoding.EncodeToString(wasmTestModule())
+
+	t.Run("NewNode", func(t *testing.T) {
+		test.NodeNew(t, targetNodeType, &WasmNode{}, types.Configuration{
+			"function": "onMsg",
+			"poolSize": 1,
+		}, Registry)
+	})
+
+	t.Run("InitNode", func(t *testing.T) {
+		_, err := test.CreateAndInitNode(targetNodeType, types.Configuration{}, Registry)
+		assert.Equal(t, "path and module can not be empty", err.Error())
+		_, err = test.CreateAndInitNode(targetNodeType, types.Configuration{
+			"module": module,
+		}, Registry)
+		assert.Equal(t, "function onMsg is not exported", err.Error())
+		_, err = test.CreateAndInitNode(targetNodeType, types.Configuration{
+			"module": "bm90IHdhc20=",
+		}, Registry)
+		assert.NotNil(t, err)
+		_, err = test.CreateAndInitNode(targetNodeType, types.Configuration{
+			"path": filepath.Join(t.TempDir(), "notfound.wasm"),
+		}, Registry)
+		assert.NotNil(t, err)
+
+		path := filepath.Join(t.TempDir(), "test.wasm")
+		assert.Nil(t, os.WriteFile(path, wasmTestModule(), 0644))
+		node, err := test.CreateAndInitNode(targetNodeType, types.Configuration{
+			"path":     path,
+			"function": "echo",
+		}, Registry)
+		assert.Nil(t, err)
+		node.Destroy()
+		//二进制内容
+		node, err = test.CreateAndInitNode(targetNodeType, types.Configuration{
+			"module":   string(wasmTestModule()),
+			"function": "echo",
+		}, Registry)
+		assert.Nil(t, err)
+		node.Destroy()
+	})
+
+	t.Run("OnMsg", func(t *testing.T) {
+		logger := &wasmTestLogger{}
+		config := types.NewConfig(types.WithLogger(logger))
+		newNode := func(function string, timeoutMs int) *WasmNode {
+			node := &WasmNode{}
+			err := node.Init(config, types.Configuration{
+				"module":    module,
+				"function":  function,
+				"poolSize":  2,
+				"timeoutMs": timeoutMs,
+			})
+			assert.Nil(t, err)
+			return node
+		}
+		run := func(node *WasmNode, msg types.RuleMsg) (types.RuleContext, types.RuleMsg, string, error) {
+			var outMsg types.RuleMsg
+			var relationType string
+			var outErr error
+			ctx := test.NewRuleContext(config, func(msg types.RuleMsg, rt string, err error) {
+				outMsg, relationType, outErr = msg, rt, err
+			})
+			node.OnMsg(ctx, msg)
+			return ctx, outMsg, relationType, outErr
+		}
+		metadata := types.NewMetadata()
+		metadata.PutValue("productType", "test")
+		newMsg := func() types.RuleMsg {
+			return types.NewMsg(0, "TEST_MSG_TYPE", types.JSON, metadata.Copy(), `{"temperature":41}`)
+		}
+
+		echoNode := newNode("echo", 0)
+		defer echoNode.Destroy()
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				ctx, outMsg, relationType, err := run(echoNode, newMsg())
+				assert.Nil(t, err)
+				assert.Equal(t, types.Success, relationType)
+				assert.Equal(t, `{"temperature":41}`, outMsg.GetData())
+				assert.Equal(t, "test", outMsg.Metadata.GetValue("productType"))
+				assert.True(t, strings.Contains(ctx.ChainCache().Get("k").(string), `"data":"{\"temperature\":41}"`))
+			}()
+		}
+		wg.Wait()
+		assert.True(t, len(echoNode.pool) <= 2)
+		logger.Lock()
+		assert.Equal(t, 10, len(logger.logs))
+		assert.True(t, strings.Contains(logger.logs[0], `"msgType":"TEST_MSG_TYPE"`))
+		logger.Unlock()
+
+		routeNode := newNode("route", 0)
+		defer routeNode.Destroy()
+		_, outMsg, relationType, err := run(routeNode, newMsg())
+		assert.Nil(t, err)
+		assert.Equal(t, types.True, relationType)
+		assert.Equal(t, "ok", outMsg.GetData())
+		assert.Equal(t, "T2", outMsg.Type)
+		assert.Equal(t, map[string]string{"a": "b"}, outMsg.Metadata.Values())
+
+		failNode := newNode("fail", 0)
+		defer failNode.Destroy()
+		_, _, relationType, err = run(failNode, newMsg())
+		assert.Equal(t, types.Failure, relationType)
+		assert.Equal(t, "boom", err.Error())
+
+		keepNode := newNode("keep", 0)
+		defer keepNode.Destroy()
+		_, outMsg, relationType, _ = run(keepNode, newMsg())
+		assert.Equal(t, types.Success, relationType)
+		assert.Equal(t, `{"temperature":41}`, outMsg.GetData())
+
+		//超时中断
+		loopNode := newNode("loop", 100)
+		defer loopNode.Destroy()
+		_, _, relationType, err = run(loopNode, newMsg())
+		assert.Equal(t, types.Failure, relationType)
+		assert.NotNil(t, err)
+		//中断的实例被丢弃，可以继续处理消息
+		_, _, relationType, _ = run(loopNode, newMsg())
+		assert.Equal(t, types.Failure, relationType)
+
+		//陷入异常的实例被关闭，不归还实例池
+		trapNode := newNode("trap", 0)
+		defer trapNode.Destroy()
+		trapMsg := types.NewMsg(0, "TEST_MSG_TYPE", types.TEXT, types.NewMetadata(), strings.Repeat("a", 600))
+		_, _, relationType, err = run(trapNode, trapMsg)
+		assert.Equal(t, types.Failure, relationType)
+		assert.NotNil(t, err)
+		assert.Equal(t, 0, len(trapNode.pool))
+		//下一条消息使用新实例，处理成功
+		_, outMsg, relationType, err = run(trapNode, newMsg())
+		assert.Nil(t, err)
+		assert.Equal(t, types.Success, relationType)
+		assert.Equal(t, `{"temperature":41}`, outMsg.GetData())
+		assert.Equal(t, 1, len(trapNode.pool))
+	})
+}
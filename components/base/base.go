@@ -24,6 +24,7 @@ import (
 	"sync"
 
 	"github.com/yunboom/rulego/api/types"
+	"github.com/yunboom/rulego/utils/str"
 )
 
 var (
@@ -63,6 +64,14 @@ func (n *nodeUtils) GetVars(configuration types.Configuration) map[string]interf
 	}
 }
 
+// GetExprTypes 获取规则链配置的expr表达式类型提示
+func (n *nodeUtils) GetExprTypes(configuration types.Configuration) map[string]string {
+	if v, ok := configuration[types.ExprTypes]; ok {
+		return str.ToStringMapString(v)
+	}
+	return nil
+}
+
 func (n *nodeUtils) GetEvn(ctx types.RuleContext, msg types.RuleMsg) map[string]interface{} {
 	return n.getEvnAndMetadata(ctx, msg, false)
 }
@@ -18,6 +18,9 @@ package external
 
 import (
 	"errors"
+	"fmt"
+	"math"
+	"strconv"
 	"strings"
 
 	"github.com/yunboom/rulego/utils/json"
@@ -44,8 +47,20 @@ const (
 	CacheOutputModeMergeToMsg      = 1   //合并到当前消息负荷
 	CacheOutputModeNewMsg          = 2   //覆盖原消息负荷输出
 	KeyMatchAll                    = "*" //通配符
+
+	CacheOpSet           = "set"           // 设置值，默认操作
+	CacheOpIncr          = "incr"          // 原子增加整数值
+	CacheOpDecr          = "decr"          // 原子减少整数值
+	CacheOpCompareAndSet = "compareAndSet" // 当前值等于期望值时设置值
+	CacheOpListPush      = "listPush"      // 追加元素到列表末尾
+	CacheOpListTrim      = "listTrim"      // 只保留列表指定范围的元素
+	CacheOpGet           = "get"           // 获取值，默认操作
+	CacheOpListRange     = "listRange"     // 获取列表指定范围的元素
 )
 
+// errCompareAndSetFailed 当前值不等于期望值
+var errCompareAndSetFailed = errors.New("compare and set failed, the current value is not equal to the expected value")
+
 // LevelKey 缓存key
 type LevelKey struct {
 	// Level 缓存级别，chain或global
@@ -55,12 +70,21 @@ type LevelKey struct {
 	// 可以使用 ${metadata.key} 读取元数据中的变量或者使用 ${msg.key} 读取消息负荷中的变量进行替换
 	// 支持 * 通配符查找，例如：test:* 表示以test: 开头的所有key
 	Key string `json:"key"`
+	// Op 获取操作，仅cacheGet使用，get:获取值(默认)，listRange:获取列表从Start到Stop(包含)的元素
+	Op string `json:"op,omitempty"`
+	// Start listRange起始位置，负数表示从末尾开始计算
+	Start int `json:"start,omitempty"`
+	// Stop listRange结束位置(包含)，负数表示从末尾开始计算，-1表示最后一个元素
+	Stop int `json:"stop,omitempty"`
 }
 
 // LevelKeyTemplate 缓存key模板
 type LevelKeyTemplate struct {
 	level       string            // 缓存级别
 	keyTemplate *el.MixedTemplate // 缓存key模板
+	op          string            // 获取操作
+	start       int               // listRange起始位置
+	stop        int               // listRange结束位置
 }
 
 // CacheGetNodeConfiguration 缓存获取节点配置
@@ -92,6 +116,13 @@ type CacheGetNodeConfiguration struct {
 //			{
 //				"level": "global",
 //				"key": "config_*"        // Wildcard pattern for multiple keys  多键通配符模式
+//			},
+//			{
+//				"level": "chain",
+//				"key": "readings_${metadata.deviceId}",
+//				"op": "listRange",       // Get list items from start to stop  获取列表指定范围的元素
+//				"start": -10,            // Negative index counts from the tail  负数从末尾开始计算
+//				"stop": -1
 //			}
 //		],
 //		"outputMode": 0                  // Output mode: 0=metadata, 1=merge to msg, 2=replace msg  输出模式
@@ -112,6 +143,13 @@ type CacheGetNodeConfiguration struct {
 //   - Wildcard: "user:*" retrieves all keys with prefix "user:"  通配符：检索所有前缀为 "user:" 的键
 //   - Variable substitution: "data_${metadata.id}" uses runtime values  变量替换：使用运行时值
 //
+// Operations:
+// 操作：
+//
+//   - "get" (default): Get the value  获取值（默认）
+//   - "listRange": Get the list items from start to stop (inclusive), e.g. start=-10, stop=-1 gets the last 10 items
+//     获取列表从 start 到 stop（包含）的元素，例如 start=-10, stop=-1 获取最后10个元素
+//
 // Output Modes:
 // 输出模式：
 //
@@ -167,13 +205,19 @@ func (x *CacheGetNode) Init(ruleConfig types.Config, configuration types.Configu
 
 	//初始化keys模板
 	for _, item := range x.Config.Keys {
-		template, err := el.NewMixedTemplate(item.Key)
+		if item.Op != "" && item.Op != CacheOpGet && item.Op != CacheOpListRange {
+			return fmt.Errorf("unsupported cache operation: %s", item.Op)
+		}
+		template, err := el.NewMixedTemplate(item.Key, el.WithUdf(ruleConfig.Udf))
 		if err != nil {
 			return err
 		}
 		x.keysTemplate = append(x.keysTemplate, LevelKeyTemplate{
 			level:       item.Level,
 			keyTemplate: template,
+			op:          item.Op,
+			start:       item.Start,
+			stop:        item.Stop,
 		})
 	}
 
@@ -185,7 +229,7 @@ func (x *CacheGetNode) OnMsg(ctx types.RuleContext, msg types.RuleMsg) {
 	//处理keys模板
 	var keys []LevelKey
 	for _, item := range x.keysTemplate {
-		keys = append(keys, LevelKey{Level: item.level, Key: item.keyTemplate.ExecuteAsString(env)})
+		keys = append(keys, LevelKey{Level: item.level, Key: item.keyTemplate.ExecuteAsString(env), Op: item.op, Start: item.start, Stop: item.stop})
 	}
 	x.handleGet(ctx, msg, keys)
 }
@@ -203,7 +247,14 @@ func (x *CacheGetNode) handleGet(ctx types.RuleContext, msg types.RuleMsg, keys
 		} else {
 			c = ctx.ChainCache()
 		}
-		if strings.HasSuffix(item.Key, KeyMatchAll) {
+		if item.Op == CacheOpListRange {
+			items, err := c.ListRange(item.Key, item.Start, item.Stop)
+			if err != nil {
+				ctx.TellFailure(msg, err)
+				return
+			}
+			values[item.Key] = items
+		} else if strings.HasSuffix(item.Key, KeyMatchAll) {
 			matchValues := c.GetByPrefix(item.Key[:len(item.Key)-1])
 			for k, v := range matchValues {
 				values[k] = v
@@ -263,14 +314,39 @@ type CacheItem struct {
 	Value interface{} `json:"value"`
 	// Ttl 过期时间
 	// 示例：1h(1小时) 1h30m(1小时30分钟) 10m(10分钟) 10s(10秒)，如果为空或者0，则表示永不过期
+	// incr、decr和listPush只在key不存在时使用该过期时间，已存在的key保持原过期时间
 	Ttl string `json:"ttl"`
+	// Op 操作，默认set
+	// set:设置值
+	// incr/decr:原子增加/减少整数值，Value为步长，默认1
+	// compareAndSet:当前值等于Expect时设置为Value，否则发送到Failure链
+	// listPush:追加Value到列表末尾，MaxLen大于0时只保留最后MaxLen个元素
+	// listTrim:只保留列表从Start到Stop(包含)的元素
+	Op string `json:"op,omitempty"`
+	// Expect compareAndSet的期望值，为空表示key不存在
+	// 可以使用 ${metadata.key} 读取元数据中的变量或者使用 ${msg.key} 读取消息负荷中的变量进行替换
+	Expect interface{} `json:"expect,omitempty"`
+	// MaxLen listPush后列表保留的最大元素个数，0表示不限制
+	MaxLen int `json:"maxLen,omitempty"`
+	// Start listTrim起始位置，负数表示从末尾开始计算
+	Start int `json:"start,omitempty"`
+	// Stop listTrim结束位置(包含)，负数表示从末尾开始计算，-1表示最后一个元素
+	Stop int `json:"stop,omitempty"`
+	// OutputKey incr、decr的结果和listPush后的列表长度保存到元数据的key，为空则不保存
+	OutputKey string `json:"outputKey,omitempty"`
 }
 
 type CacheItemTemplate struct {
-	level         string
-	keyTemplate   *el.MixedTemplate
-	valueTemplate el.Template
-	ttl           string
+	level          string
+	keyTemplate    *el.MixedTemplate
+	valueTemplate  el.Template
+	expectTemplate el.Template
+	ttl            string
+	op             string
+	maxLen         int
+	start          int
+	stop           int
+	outputKey      string
 }
 
 // CacheSetNode stores data in cache storage at different levels with TTL support.
@@ -302,6 +378,22 @@ type CacheItemTemplate struct {
 //   - "1h30m": 1 hour 30 minutes  1小时30分钟
 //   - "": No expiration (permanent storage)  无过期时间（永久存储）
 //
+// Operations:
+// 操作：
+//
+//   - "set" (default): Set the value  设置值（默认）
+//   - "incr"/"decr": Atomically add/subtract the integer value, value is the delta, default 1
+//     原子增加/减少整数值，value为步长，默认1
+//   - "compareAndSet": Set the value if the current value equals expect, otherwise route to Failure, empty expect means the key does not exist
+//     当前值等于 expect 时设置值，否则发送到 Failure 链，expect 为空表示 key 不存在
+//   - "listPush": Append the value to the list, keep the last maxLen items if maxLen > 0
+//     追加 value 到列表末尾，maxLen 大于0时只保留最后 maxLen 个元素
+//   - "listTrim": Keep the list items from start to stop (inclusive), negative index counts from the tail
+//     只保留列表从 start 到 stop（包含）的元素，负数从末尾开始计算
+//
+// The result of incr/decr and the list length after listPush are saved to metadata outputKey if set.
+// incr/decr 的结果和 listPush 后的列表长度保存到元数据 outputKey 中（如果设置）。
+//
 // Variable Substitution:
 // 变量替换：
 //
@@ -331,6 +423,20 @@ type CacheItemTemplate struct {
 //					"key": "last_activity_${metadata.userId}",
 //					"value": "${metadata.timestamp}",
 //					"ttl": "24h"
+//				},
+//				{
+//					"level": "chain",
+//					"key": "count_${metadata.deviceId}",
+//					"op": "incr",
+//					"value": 1,
+//					"outputKey": "count"
+//				},
+//				{
+//					"level": "chain",
+//					"key": "readings_${metadata.deviceId}",
+//					"op": "listPush",
+//					"value": "${msg.temperature}",
+//					"maxLen": 10
 //				}
 //			]
 //		}
@@ -366,7 +472,12 @@ func (x *CacheSetNode) Init(ruleConfig types.Config, configuration types.Configu
 	var hasVar = false
 	//初始化缓存项列表模板
 	for _, item := range x.Config.Items {
-		keyTemplate, err := el.NewMixedTemplate(item.Key)
+		switch item.Op {
+		case "", CacheOpSet, CacheOpIncr, CacheOpDecr, CacheOpCompareAndSet, CacheOpListPush, CacheOpListTrim:
+		default:
+			return fmt.Errorf("unsupported cache operation: %s", item.Op)
+		}
+		keyTemplate, err := el.NewMixedTemplate(item.Key, el.WithUdf(ruleConfig.Udf))
 		if err != nil {
 			return err
 		}
@@ -375,18 +486,31 @@ func (x *CacheSetNode) Init(ruleConfig types.Config, configuration types.Configu
 			hasVar = true
 		}
 
-		valueTemplate, err := el.NewTemplate(item.Value)
+		valueTemplate, err := el.NewTemplate(item.Value, el.WithUdf(ruleConfig.Udf))
 		if err != nil {
 			return err
 		}
 		if valueTemplate.HasVar() {
 			hasVar = true
 		}
+		expectTemplate, err := el.NewTemplate(item.Expect, el.WithUdf(ruleConfig.Udf))
+		if err != nil {
+			return err
+		}
+		if expectTemplate.HasVar() {
+			hasVar = true
+		}
 		x.itemsTemplate = append(x.itemsTemplate, CacheItemTemplate{
-			level:         item.Level,
-			keyTemplate:   keyTemplate,
-			valueTemplate: valueTemplate,
-			ttl:           item.Ttl,
+			level:          item.Level,
+			keyTemplate:    keyTemplate,
+			valueTemplate:  valueTemplate,
+			expectTemplate: expectTemplate,
+			ttl:            item.Ttl,
+			op:             item.Op,
+			maxLen:         item.MaxLen,
+			start:          item.Start,
+			stop:           item.Stop,
+			outputKey:      item.OutputKey,
 		})
 	}
 	x.hasVar = hasVar
@@ -399,23 +523,8 @@ func (x *CacheSetNode) OnMsg(ctx types.RuleContext, msg types.RuleMsg) {
 		evn = base.NodeUtils.GetEvnAndMetadata(ctx, msg)
 	}
 	var err error
-	var value interface{}
 	for _, item := range x.itemsTemplate {
-		key := item.keyTemplate.ExecuteAsString(evn)
-		if key == "" {
-			err = errors.New("key is empty")
-			break
-		}
-		value, err = item.valueTemplate.Execute(evn)
-		if err == nil {
-			var c = ctx.GlobalCache()
-			if item.level == CacheLevelGlobal {
-				c = ctx.GlobalCache()
-			} else {
-				c = ctx.ChainCache()
-			}
-			err = c.Set(key, value, item.ttl)
-		} else {
+		if err = x.execItem(ctx, msg, evn, item); err != nil {
 			break
 		}
 	}
@@ -427,10 +536,94 @@ func (x *CacheSetNode) OnMsg(ctx types.RuleContext, msg types.RuleMsg) {
 	}
 }
 
+// execItem 执行缓存项操作
+func (x *CacheSetNode) execItem(ctx types.RuleContext, msg types.RuleMsg, evn map[string]interface{}, item CacheItemTemplate) error {
+	key := item.keyTemplate.ExecuteAsString(evn)
+	if key == "" {
+		return errors.New("key is empty")
+	}
+	value, err := item.valueTemplate.Execute(evn)
+	if err != nil {
+		return err
+	}
+	var c types.Cache
+	if item.level == CacheLevelGlobal {
+		c = ctx.GlobalCache()
+	} else {
+		c = ctx.ChainCache()
+	}
+	var result interface{}
+	switch item.op {
+	case CacheOpIncr, CacheOpDecr:
+		delta, err := cacheDelta(value)
+		if err != nil {
+			return err
+		}
+		if item.op == CacheOpDecr {
+			result, err = c.Decr(key, delta, item.ttl)
+		} else {
+			result, err = c.Incr(key, delta, item.ttl)
+		}
+		if err != nil {
+			return err
+		}
+	case CacheOpCompareAndSet:
+		expect, err := item.expectTemplate.Execute(evn)
+		if err != nil {
+			return err
+		}
+		if expect == "" {
+			expect = nil
+		}
+		ok, err := c.CompareAndSet(key, expect, value, item.ttl)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return errCompareAndSetFailed
+		}
+	case CacheOpListPush:
+		length, err := c.ListPush(key, item.ttl, value)
+		if err != nil {
+			return err
+		}
+		if item.maxLen > 0 && length > item.maxLen {
+			if err = c.ListTrim(key, -item.maxLen, -1); err != nil {
+				return err
+			}
+			length = item.maxLen
+		}
+		result = length
+	case CacheOpListTrim:
+		return c.ListTrim(key, item.start, item.stop)
+	default:
+		return c.Set(key, value, item.ttl)
+	}
+	if item.outputKey != "" && result != nil {
+		msg.Metadata.PutValue(item.outputKey, str.ToString(result))
+	}
+	return nil
+}
+
 // Destroy 销毁组件
 func (x *CacheSetNode) Destroy() {
 }
 
+// cacheDelta 转换incr、decr的步长，为空时默认1
+func cacheDelta(value interface{}) (int64, error) {
+	s := strings.TrimSpace(str.ToString(value))
+	if s == "" {
+		return 1, nil
+	}
+	if v, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return v, nil
+	}
+	if v, err := strconv.ParseFloat(s, 64); err == nil && v == math.Trunc(v) {
+		return int64(v), nil
+	}
+	return 0, fmt.Errorf("invalid delta: %s", s)
+}
+
 // CacheDeleteNodeConfiguration 缓存删除节点配置
 type CacheDeleteNodeConfiguration struct {
 	// Keys 删除的键列表
@@ -509,7 +702,7 @@ func (x *CacheDeleteNode) Init(ruleConfig types.Config, configuration types.Conf
 
 	//初始化keys模板
 	for _, item := range x.Config.Keys {
-		template, err := el.NewMixedTemplate(item.Key)
+		template, err := el.NewMixedTemplate(item.Key, el.WithUdf(ruleConfig.Udf))
 		if err != nil {
 			return err
 		}
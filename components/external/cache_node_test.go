@@ -0,0 +1,140 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package external
+
+import (
+	"testing"
+
+	"github.com/yunboom/rulego/api/types"
+	"github.com/yunboom/rulego/test"
+	"github.com/yunboom/rulego/test/assert"
+	"github.com/yunboom/rulego/utils/str"
+)
+
+func TestCacheNodeOps(t *testing.T) {
+	config := types.NewConfig()
+	var outMsg types.RuleMsg
+	var relationType string
+	var outErr error
+	ctx := test.NewRuleContext(config, func(msg types.RuleMsg, rt string, err error) {
+		outMsg, relationType, outErr = msg, rt, err
+	})
+	onMsg := func(node types.Node, data string) {
+		metadata := types.NewMetadata()
+		metadata.PutValue("deviceId", "d1")
+		node.OnMsg(ctx, types.NewMsg(0, "TELEMETRY", types.JSON, metadata, data))
+	}
+
+	t.Run("InitError", func(t *testing.T) {
+		err := (&CacheSetNode{}).Init(config, types.Configuration{
+			"items": []interface{}{map[string]interface{}{"key": "k", "op": "unknown"}},
+		})
+		assert.NotNil(t, err)
+		err = (&CacheGetNode{}).Init(config, types.Configuration{
+			"keys": []interface{}{map[string]interface{}{"key": "k", "op": "incr"}},
+		})
+		assert.NotNil(t, err)
+	})
+
+	t.Run("Incr", func(t *testing.T) {
+		node := &CacheSetNode{}
+		err := node.Init(config, types.Configuration{
+			"items": []interface{}{
+				map[string]interface{}{"level": "chain", "key": "count:${metadata.deviceId}", "op": "incr", "outputKey": "count"},
+				map[string]interface{}{"level": "global", "key": "total", "op": "incr", "value": "${msg.n}", "outputKey": "total"},
+				map[string]interface{}{"level": "global", "key": "remain", "op": "decr", "value": 2, "outputKey": "remain"},
+			},
+		})
+		assert.Nil(t, err)
+		for i := 0; i < 3; i++ {
+			onMsg(node, `{"n":5}`)
+		}
+		assert.Equal(t, types.Success, relationType)
+		assert.Equal(t, "3", outMsg.Metadata.GetValue("count"))
+		assert.Equal(t, "15", outMsg.Metadata.GetValue("total"))
+		assert.Equal(t, "-6", outMsg.Metadata.GetValue("remain"))
+		assert.Equal(t, int64(3), ctx.ChainCache().Get("count:d1"))
+
+		onMsg(node, `{"n":"abc"}`)
+		assert.Equal(t, types.Failure, relationType)
+		assert.NotNil(t, outErr)
+	})
+
+	t.Run("CompareAndSet", func(t *testing.T) {
+		node := &CacheSetNode{}
+		err := node.Init(config, types.Configuration{
+			"items": []interface{}{
+				map[string]interface{}{"level": "chain", "key": "state:${metadata.deviceId}", "op": "compareAndSet", "expect": "${msg.from}", "value": "${msg.to}"},
+			},
+		})
+		assert.Nil(t, err)
+		onMsg(node, `{"from":"","to":"on"}`)
+		assert.Equal(t, types.Success, relationType)
+		onMsg(node, `{"from":"off","to":"on"}`)
+		assert.Equal(t, types.Failure, relationType)
+		assert.Equal(t, errCompareAndSetFailed, outErr)
+		onMsg(node, `{"from":"on","to":"off"}`)
+		assert.Equal(t, types.Success, relationType)
+		assert.Equal(t, "off", ctx.ChainCache().Get("state:d1"))
+	})
+
+	t.Run("List", func(t *testing.T) {
+		node := &CacheSetNode{}
+		err := node.Init(config, types.Configuration{
+			"items": []interface{}{
+				map[string]interface{}{"level": "chain", "key": "readings:${metadata.deviceId}", "op": "listPush", "value": "${msg.temperature}", "maxLen": 3, "outputKey": "length"},
+			},
+		})
+		assert.Nil(t, err)
+		for i := 1; i <= 5; i++ {
+			onMsg(node, `{"temperature":`+str.ToString(i)+`}`)
+			assert.Equal(t, types.Success, relationType)
+		}
+		assert.Equal(t, "3", outMsg.Metadata.GetValue("length"))
+
+		getNode := &CacheGetNode{}
+		err = getNode.Init(config, types.Configuration{
+			"keys": []interface{}{
+				map[string]interface{}{"level": "chain", "key": "readings:${metadata.deviceId}", "op": "listRange", "start": -2, "stop": -1},
+			},
+			"outputMode": CacheOutputModeNewMsg,
+		})
+		assert.Nil(t, err)
+		onMsg(getNode, `{}`)
+		assert.Equal(t, types.Success, relationType)
+		assert.Equal(t, `{"readings:d1":[4,5]}`, outMsg.GetData())
+
+		trimNode := &CacheSetNode{}
+		err = trimNode.Init(config, types.Configuration{
+			"items": []interface{}{
+				map[string]interface{}{"level": "chain", "key": "readings:${metadata.deviceId}", "op": "listTrim", "start": 0, "stop": 0},
+			},
+		})
+		assert.Nil(t, err)
+		onMsg(trimNode, `{}`)
+		assert.Equal(t, types.Success, relationType)
+		items, err := ctx.ChainCache().ListRange("readings:d1", 0, -1)
+		assert.Nil(t, err)
+		assert.Equal(t, []interface{}{float64(3)}, items)
+
+		// 非列表的值
+		assert.Nil(t, ctx.ChainCache().Set("readings:d1", "abc", ""))
+		onMsg(getNode, `{}`)
+		assert.Equal(t, types.Failure, relationType)
+		assert.Equal(t, types.ErrCacheNotList, outErr)
+	})
+}
@@ -0,0 +1,299 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package external
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+
+	"github.com/yunboom/rulego/api/types"
+	"github.com/yunboom/rulego/utils/json"
+	"github.com/yunboom/rulego/utils/maps"
+	"github.com/yunboom/rulego/utils/str"
+)
+
+const (
+	// defaultBulkChunkSize 默认每块写入的行数
+	defaultBulkChunkSize = 100
+	// maxBulkParams 单条语句最多的参数个数，Postgres的限制
+	maxBulkParams = 65535
+	// chunkRowsAffectedKey 批量写入每块影响行数
+	chunkRowsAffectedKey = "chunkRowsAffected"
+	// failedChunkKey 批量写入失败的块序号，从1开始
+	failedChunkKey = "failedChunk"
+)
+
+// identifierRegexp 合法的表名和列名，防止SQL注入
+var identifierRegexp = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)?$`)
+
+// DbBulkConfiguration 批量写入配置
+type DbBulkConfiguration struct {
+	// Table 表名
+	Table string
+	// Columns 列映射，按顺序写入
+	Columns []DbBulkColumn
+	// ConflictKeys 冲突检测列，不为空时执行upsert，MySQL使用表的主键或者唯一索引，忽略该值
+	ConflictKeys []string
+	// UpdateColumns 冲突时更新的列，默认更新除ConflictKeys之外的所有列
+	UpdateColumns []string
+	// ChunkSize 每块写入的行数，默认100
+	ChunkSize int
+}
+
+// DbBulkColumn 列映射
+type DbBulkColumn struct {
+	// Column 列名
+	Column string
+	// Field JSON对象的字段，支持 a.b 嵌套字段，默认和列名相同
+	Field string
+}
+
+// dbBulk 编译后的批量写入配置
+type dbBulk struct {
+	table     string
+	columns   []string
+	fields    []string
+	chunkSize int
+	// conflictClause upsert子句
+	conflictClause string
+	// chunkSql 完整块的SQL语句
+	chunkSql string
+}
+
+// compileBulk 编译批量写入配置
+func (x *DbClientNode) compileBulk(config DbBulkConfiguration) (*dbBulk, error) {
+	if !identifierRegexp.MatchString(config.Table) {
+		return nil, fmt.Errorf("invalid identifier: %s", config.Table)
+	}
+	if len(config.Columns) == 0 {
+		return nil, errors.New("bulk columns can not be empty")
+	}
+	bulk := &dbBulk{table: config.Table, chunkSize: config.ChunkSize}
+	if bulk.chunkSize <= 0 {
+		bulk.chunkSize = defaultBulkChunkSize
+	}
+	mapped := make(map[string]bool)
+	for _, item := range config.Columns {
+		if !identifierRegexp.MatchString(item.Column) {
+			return nil, fmt.Errorf("invalid identifier: %s", item.Column)
+		}
+		field := item.Field
+		if field == "" {
+			field = item.Column
+		}
+		mapped[item.Column] = true
+		bulk.columns = append(bulk.columns, item.Column)
+		bulk.fields = append(bulk.fields, field)
+	}
+	if bulk.chunkSize*len(bulk.columns) > maxBulkParams {
+		return nil, fmt.Errorf("too many parameters in a chunk, reduce chunk size to %d", maxBulkParams/len(bulk.columns))
+	}
+	if len(config.ConflictKeys) > 0 {
+		clause, err := x.bulkConflictClause(config, mapped)
+		if err != nil {
+			return nil, err
+		}
+		bulk.conflictClause = clause
+	}
+	bulk.chunkSql = x.bulkSql(bulk, bulk.chunkSize)
+	return bulk, nil
+}
+
+// bulkConflictClause 根据数据库方言生成upsert子句
+func (x *DbClientNode) bulkConflictClause(config DbBulkConfiguration, mapped map[string]bool) (string, error) {
+	keys := make(map[string]bool)
+	for _, key := range config.ConflictKeys {
+		if !identifierRegexp.MatchString(key) {
+			return "", fmt.Errorf("invalid identifier: %s", key)
+		}
+		keys[key] = true
+	}
+	updateColumns := config.UpdateColumns
+	if len(updateColumns) == 0 {
+		for _, item := range config.Columns {
+			if !keys[item.Column] {
+				updateColumns = append(updateColumns, item.Column)
+			}
+		}
+	}
+	for _, column := range updateColumns {
+		if !mapped[column] {
+			return "", fmt.Errorf("update column %s is not mapped", column)
+		}
+	}
+	var sets []string
+	switch strings.ToLower(x.Config.DriverName) {
+	case "postgres", "pgx", "sqlite", "sqlite3":
+		for _, column := range updateColumns {
+			sets = append(sets, column+" = excluded."+column)
+		}
+		if len(sets) == 0 {
+			return fmt.Sprintf(" ON CONFLICT (%s) DO NOTHING", strings.Join(config.ConflictKeys, ", ")), nil
+		}
+		return fmt.Sprintf(" ON CONFLICT (%s) DO UPDATE SET %s", strings.Join(config.ConflictKeys, ", "), strings.Join(sets, ", ")), nil
+	case "mysql":
+		for _, column := range updateColumns {
+			sets = append(sets, column+" = VALUES("+column+")")
+		}
+		if len(sets) == 0 {
+			//没有需要更新的列时忽略冲突的行
+			sets = append(sets, config.ConflictKeys[0]+" = "+config.ConflictKeys[0])
+		}
+		return " ON DUPLICATE KEY UPDATE " + strings.Join(sets, ", "), nil
+	default:
+		return "", fmt.Errorf("upsert is not supported by driver: %s", x.Config.DriverName)
+	}
+}
+
+// bulkSql 生成写入rows行的SQL语句
+func (x *DbClientNode) bulkSql(bulk *dbBulk, rows int) string {
+	row := "(" + strings.TrimSuffix(strings.Repeat("?, ", len(bulk.columns)), ", ") + ")"
+	var sb strings.Builder
+	sb.WriteString("INSERT INTO ")
+	sb.WriteString(bulk.table)
+	sb.WriteString(" (")
+	sb.WriteString(strings.Join(bulk.columns, ", "))
+	sb.WriteString(") VALUES ")
+	for i := 0; i < rows; i++ {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		sb.WriteString(row)
+	}
+	sb.WriteString(bulk.conflictClause)
+	return str.ConvertDollarPlaceholder(sb.String(), x.Config.DriverName)
+}
+
+// execBulk 把消息负荷中的JSON数组分块在同一个事务中写入
+func (x *DbClientNode) execBulk(ctx types.RuleContext, msg types.RuleMsg) {
+	rows, err := x.bulkRows(msg.GetData())
+	if err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	client, err := x.SharedNode.GetSafely()
+	if err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	c := dbContext(ctx)
+	tx, err := client.BeginTx(c, nil)
+	if err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	bulk := x.bulk
+	//完整的分块使用相同的语句，只预编译一次，事务提交或者回滚时关闭
+	var chunkStmt *sql.Stmt
+	var total int64
+	chunkRowsAffected := make([]int64, 0, (len(rows)+bulk.chunkSize-1)/bulk.chunkSize)
+	for start := 0; start < len(rows); start += bulk.chunkSize {
+		end := start + bulk.chunkSize
+		if end > len(rows) {
+			end = len(rows)
+		}
+		params := make([]interface{}, 0, (end-start)*len(bulk.columns))
+		for _, row := range rows[start:end] {
+			for _, field := range bulk.fields {
+				params = append(params, bulkValue(maps.Get(row, field)))
+			}
+		}
+		var rowsAffected int64
+		if end-start == bulk.chunkSize {
+			if chunkStmt == nil {
+				chunkStmt, err = tx.PrepareContext(c, bulk.chunkSql)
+			}
+			if err == nil {
+				rowsAffected, err = execStmt(c, chunkStmt, params)
+			}
+		} else {
+			rowsAffected, err = x.update(c, tx, x.bulkSql(bulk, end-start), params)
+		}
+		if err != nil {
+			chunk := len(chunkRowsAffected) + 1
+			err = fmt.Errorf("chunk %d failed: %w", chunk, err)
+			if rollbackErr := tx.Rollback(); rollbackErr != nil {
+				err = fmt.Errorf("%w, rollback failed: %v", err, rollbackErr)
+			}
+			msg.Metadata.PutValue(failedChunkKey, str.ToString(chunk))
+			ctx.TellFailure(msg, err)
+			return
+		}
+		total += rowsAffected
+		chunkRowsAffected = append(chunkRowsAffected, rowsAffected)
+	}
+	if err = tx.Commit(); err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	msg.Metadata.PutValue(rowsAffectedKey, str.ToString(total))
+	msg.Metadata.PutValue(chunkRowsAffectedKey, str.ToString(chunkRowsAffected))
+	ctx.TellSuccess(msg)
+}
+
+// execStmt 执行预编译语句并返回影响行数
+func execStmt(c context.Context, stmt *sql.Stmt, params []interface{}) (int64, error) {
+	result, err := stmt.ExecContext(c, params...)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// bulkRows 解析消息负荷，JSON对象作为一行处理
+func (x *DbClientNode) bulkRows(data string) ([]map[string]interface{}, error) {
+	var value interface{}
+	if err := json.Unmarshal([]byte(data), &value); err != nil {
+		return nil, err
+	}
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return []map[string]interface{}{v}, nil
+	case []interface{}:
+		rows := make([]map[string]interface{}, 0, len(v))
+		for i, item := range v {
+			row, ok := item.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("row %d is not a JSON object", i)
+			}
+			rows = append(rows, row)
+		}
+		return rows, nil
+	default:
+		return nil, errors.New("bulk data must be a JSON array of objects")
+	}
+}
+
+// bulkValue 转换成数据库驱动支持的参数值，整数转换成int64，对象和数组转换成JSON字符串
+func bulkValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case float64:
+		if v == math.Trunc(v) && math.Abs(v) < 1<<53 {
+			return int64(v)
+		}
+		return v
+	case map[string]interface{}, []interface{}:
+		return str.ToString(v)
+	default:
+		return v
+	}
+}
@@ -0,0 +1,54 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package external
+
+import (
+	"testing"
+
+	"github.com/yunboom/rulego/test/assert"
+)
+
+// 不同数据库驱动生成的批量写入语句
+func TestDbClientNodeBulkDialect(t *testing.T) {
+	bulkConfig := DbBulkConfiguration{
+		Table:        "telemetry",
+		Columns:      []DbBulkColumn{{Column: "device_id"}, {Column: "ts"}, {Column: "temp"}},
+		ConflictKeys: []string{"device_id", "ts"},
+		ChunkSize:    2,
+	}
+	node := &DbClientNode{Config: DbClientNodeConfiguration{DriverName: "postgres"}}
+	bulk, err := node.compileBulk(bulkConfig)
+	assert.Nil(t, err)
+	assert.Equal(t, "INSERT INTO telemetry (device_id, ts, temp) VALUES ($1, $2, $3), ($4, $5, $6) ON CONFLICT (device_id, ts) DO UPDATE SET temp = excluded.temp", bulk.chunkSql)
+
+	node = &DbClientNode{Config: DbClientNodeConfiguration{DriverName: "mysql"}}
+	bulk, err = node.compileBulk(bulkConfig)
+	assert.Nil(t, err)
+	assert.Equal(t, "INSERT INTO telemetry (device_id, ts, temp) VALUES (?, ?, ?), (?, ?, ?) ON DUPLICATE KEY UPDATE temp = VALUES(temp)", bulk.chunkSql)
+
+	bulkConfig.Columns = bulkConfig.Columns[:2]
+	bulk, err = node.compileBulk(bulkConfig)
+	assert.Nil(t, err)
+	assert.Equal(t, "INSERT INTO telemetry (device_id, ts) VALUES (?, ?), (?, ?) ON DUPLICATE KEY UPDATE device_id = device_id", bulk.chunkSql)
+
+	node = &DbClientNode{Config: DbClientNodeConfiguration{DriverName: "sqlserver"}}
+	_, err = node.compileBulk(bulkConfig)
+	assert.Equal(t, "upsert is not supported by driver: sqlserver", err.Error())
+	bulkConfig.Table = "telemetry;drop table telemetry"
+	_, err = node.compileBulk(bulkConfig)
+	assert.Equal(t, "invalid identifier: telemetry;drop table telemetry", err.Error())
+}
@@ -17,6 +17,7 @@
 package external
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"fmt"
@@ -45,8 +46,40 @@ const (
 const (
 	rowsAffectedKey = "rowsAffected"
 	lastInsertIdKey = "lastInsertId"
+	// failedStatementKey 事务模式下执行失败的语句名称
+	failedStatementKey = "failedStatement"
+	// resultsKey 事务模式下后续语句通过 ${results.语句名称.xx} 引用前面语句的执行结果
+	resultsKey = "results"
 )
 
+// DbStatement 事务模式的SQL语句
+type DbStatement struct {
+	// Name 语句名称，后续语句通过 ${results.name.xx} 引用执行结果，为空时使用 s+序号，例如：s1
+	Name string
+	// Sql SQL语句，可以使用 ${results.name.xx} 引用前面语句的执行结果
+	Sql string
+	// Params SQL语句参数列表，除了 ${metadata.key}、${msg.key} 还可以使用 ${results.name.xx} 引用前面语句的执行结果
+	Params []interface{}
+	// GetOne 是否只返回一条记录，SELECT语句有效
+	GetOne bool
+}
+
+// dbStatement 编译后的事务语句
+type dbStatement struct {
+	name           string
+	sql            string
+	sqlHasVar      bool
+	opType         string
+	paramsTemplate []el.Template
+	getOne         bool
+}
+
+// dbExecutor *sql.DB 和 *sql.Tx 的公共方法
+type dbExecutor interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
 // DbClientNodeConfiguration 节点配置
 type DbClientNodeConfiguration struct {
 	// DriverName 数据库驱动名称，mysql或postgres
@@ -61,6 +94,13 @@ type DbClientNodeConfiguration struct {
 	Params []interface{}
 	// GetOne 是否只返回一条记录，true:返回结构不是数组结构，false：返回数据是数组结构
 	GetOne bool
+	// Statements 事务语句列表，不为空时在同一个事务中按顺序执行这些语句，忽略Sql和Params
+	// 全部语句执行成功则提交事务，否则回滚事务
+	Statements []DbStatement
+	// Bulk 批量写入配置，Bulk.Table不为空时把消息负荷中的JSON数组批量写入或者更新到表，忽略Sql和Params
+	Bulk DbBulkConfiguration
+	// Stream 流式查询配置，Stream.Mode不为空时以游标方式读取SELECT语句的结果，每行或者每页发送一条消息
+	Stream DbStreamConfiguration
 }
 
 // DbClientNode 为RuleGo规则引擎提供通用数据库连接和SQL执行能力的外部组件
@@ -93,6 +133,29 @@ type DbClientNodeConfiguration struct {
 //   - INSERT: 设置rowsAffected和lastInsertId到元数据 - Sets rowsAffected and lastInsertId in metadata
 //   - UPDATE/DELETE: 设置rowsAffected到元数据 - Sets rowsAffected in metadata
 //
+// 事务模式 - Transaction mode:
+//   - 配置Statements后，在同一个事务中按顺序执行语句 - With Statements configured, statements run in order within one transaction
+//   - 后续语句通过${results.name.xx}引用前面语句的结果 - Later statements reference earlier results via ${results.name.xx}
+//   - INSERT结果：{"rowsAffected":1,"lastInsertId":1}，UPDATE/DELETE结果：{"rowsAffected":1}，SELECT结果：查询记录
+//     INSERT result: {"rowsAffected":1,"lastInsertId":1}, UPDATE/DELETE result: {"rowsAffected":1}, SELECT result: the selected rows
+//   - 全部成功则提交，并把所有语句的结果(JSON对象)设置到消息数据 - Commits on success and sets all results (JSON object) to message data
+//   - 任意语句失败则回滚，转到Failure链，失败语句名称设置到元数据failedStatement
+//     Rolls back on any failure, routes to Failure with the failed statement name in metadata failedStatement
+//
+// 批量写入模式 - Bulk mode:
+//   - 配置Bulk.Table后，把消息负荷中的JSON数组按列映射批量写入表 - With Bulk.Table configured, writes the JSON array payload into the table by column mapping
+//   - 配置Bulk.ConflictKeys时执行upsert：Postgres/SQLite使用ON CONFLICT，MySQL使用ON DUPLICATE KEY UPDATE
+//     Upserts when Bulk.ConflictKeys is configured: ON CONFLICT for Postgres/SQLite, ON DUPLICATE KEY UPDATE for MySQL
+//   - 按Bulk.ChunkSize分块在同一个事务中执行，元数据rowsAffected为总影响行数，chunkRowsAffected为每块影响行数(JSON数组)
+//     Executes chunks of Bulk.ChunkSize rows in one transaction, metadata rowsAffected is the total and chunkRowsAffected the per-chunk counts (JSON array)
+//
+// 流式查询模式 - Stream mode:
+//   - 配置Stream.Mode后，SELECT语句以游标方式读取，row模式每行发送一条消息，page模式每Stream.PageSize行发送一条消息
+//     With Stream.Mode configured, SELECT results are cursor-read and sent as one message per row (row) or per Stream.PageSize rows (page)
+//   - 消息通过Success链发送，元数据rowIndex或者pageIndex标识序号 - Messages go to Success with rowIndex or pageIndex metadata
+//   - 读取完成后发送完成消息，元数据streamCompleted=true，rowCount为总行数 - A completion message with metadata streamCompleted=true and rowCount follows the last row
+//   - 协程池已满时等待后重试，不再继续读取，取消ctx.GetContext()时停止读取 - Waits while the pool is full and stops reading when ctx.GetContext() is cancelled
+//
 // 连接管理 - Connection management:
 //   - 使用连接池和SharedNode模式共享连接 - Uses connection pooling and SharedNode pattern for sharing connections
 //   - 可配置的池大小和自动连接生命周期管理 - Configurable pool size and automatic connection lifecycle management
@@ -109,6 +172,10 @@ type DbClientNode struct {
 	//参数是否有变量
 	paramsHasVar   bool
 	paramsTemplate []el.Template
+	//事务语句列表
+	statements []dbStatement
+	//批量写入
+	bulk *dbBulk
 }
 
 // Type 返回组件类型
@@ -135,33 +202,48 @@ func (x *DbClientNode) Init(ruleConfig types.Config, configuration types.Configu
 	}
 
 	if !base.NodeUtils.IsInitNetResource(ruleConfig, configuration) {
-		if x.Config.Sql == "" {
-			return errors.New("sql can not empty")
-		}
-		//检查是否需要转换成$1风格占位符
-		x.Config.Sql = str.ConvertDollarPlaceholder(x.Config.Sql, x.Config.DriverName)
-		if str.CheckHasVar(x.Config.Sql) {
-			x.sqlHasVar = true
-		}
-		if !x.sqlHasVar {
-			x.opType = x.getOpType(x.Config.Sql)
-			if err = x.checkOpType(x.opType, x.Config.Sql); err != nil {
+		if len(x.Config.Statements) > 0 {
+			if x.statements, err = x.compileStatements(x.Config.Statements, el.WithUdf(ruleConfig.Udf)); err != nil {
 				return err
 			}
-		}
-		//检查是参数否有变量
-		for _, item := range x.Config.Params {
-
-			if temp, err := el.NewTemplate(item); err != nil {
+		} else if x.Config.Bulk.Table != "" {
+			if x.bulk, err = x.compileBulk(x.Config.Bulk); err != nil {
+				return err
+			}
+		} else {
+			if x.Config.Sql == "" {
+				return errors.New("sql can not empty")
+			}
+			if err = x.checkStream(); err != nil {
 				return err
-			} else {
-				x.paramsTemplate = append(x.paramsTemplate, temp)
-				if !temp.IsNotVar() {
-					x.paramsHasVar = true
+			}
+			//检查是否需要转换成$1风格占位符
+			x.Config.Sql = str.ConvertDollarPlaceholder(x.Config.Sql, x.Config.DriverName)
+			if str.CheckHasVar(x.Config.Sql) {
+				x.sqlHasVar = true
+			}
+			if !x.sqlHasVar {
+				x.opType = x.getOpType(x.Config.Sql)
+				if err = x.checkOpType(x.opType, x.Config.Sql); err != nil {
+					return err
+				}
+				if x.Config.Stream.Mode != "" && x.opType != SELECT {
+					return errStreamNotSelect
 				}
 			}
-		}
+			//检查是参数否有变量
+			for _, item := range x.Config.Params {
 
+				if temp, err := el.NewTemplate(item, el.WithUdf(ruleConfig.Udf)); err != nil {
+					return err
+				} else {
+					x.paramsTemplate = append(x.paramsTemplate, temp)
+					if !temp.IsNotVar() {
+						x.paramsHasVar = true
+					}
+				}
+			}
+		}
 	}
 	//初始化客户端
 	return x.SharedNode.InitWithClose(ruleConfig, x.Type(), x.Config.Dsn, ruleConfig.NodeClientInitNow, func() (*sql.DB, error) {
@@ -175,6 +257,14 @@ func (x *DbClientNode) Init(ruleConfig types.Config, configuration types.Configu
 // OnMsg 处理消息，执行SQL操作并处理结果
 // OnMsg processes messages by executing SQL operations and handling results.
 func (x *DbClientNode) OnMsg(ctx types.RuleContext, msg types.RuleMsg) {
+	if len(x.statements) > 0 {
+		x.execTransaction(ctx, msg)
+		return
+	}
+	if x.bulk != nil {
+		x.execBulk(ctx, msg)
+		return
+	}
 	var data interface{}
 	var err error
 	var rowsAffected int64
@@ -212,16 +302,25 @@ func (x *DbClientNode) OnMsg(ctx types.RuleContext, msg types.RuleMsg) {
 		ctx.TellFailure(msg, err)
 		return
 	}
+	if x.Config.Stream.Mode != "" {
+		if opType != SELECT {
+			ctx.TellFailure(msg, errStreamNotSelect)
+		} else {
+			x.streamQuery(ctx, msg, client, sqlStr, params)
+		}
+		return
+	}
 
+	c := dbContext(ctx)
 	switch opType {
 	case SELECT:
-		data, err = x.query(client, sqlStr, params, x.Config.GetOne)
+		data, err = x.query(c, client, sqlStr, params, x.Config.GetOne)
 	case UPDATE:
-		rowsAffected, err = x.update(client, sqlStr, params)
+		rowsAffected, err = x.update(c, client, sqlStr, params)
 	case INSERT:
-		rowsAffected, lastInsertId, err = x.insert(client, sqlStr, params)
+		rowsAffected, lastInsertId, err = x.insert(c, client, sqlStr, params)
 	case DELETE:
-		rowsAffected, err = x.delete(client, sqlStr, params)
+		rowsAffected, err = x.delete(c, client, sqlStr, params)
 	default:
 		err = fmt.Errorf("unsupported sql statement: %s", sqlStr)
 	}
@@ -243,8 +342,8 @@ func (x *DbClientNode) OnMsg(ctx types.RuleContext, msg types.RuleMsg) {
 }
 
 // query 查询数据并返回map或slice类型
-func (x *DbClientNode) query(client *sql.DB, sqlStr string, params []interface{}, getOne bool) (interface{}, error) {
-	rows, err := client.Query(sqlStr, params...)
+func (x *DbClientNode) query(c context.Context, client dbExecutor, sqlStr string, params []interface{}, getOne bool) (interface{}, error) {
+	rows, err := client.QueryContext(c, sqlStr, params...)
 	if err != nil {
 		return nil, err
 	}
@@ -255,42 +354,15 @@ func (x *DbClientNode) query(client *sql.DB, sqlStr string, params []interface{}
 		return nil, err
 	}
 
-	// 创建一个固定大小的 map 和切片，用于存储每一行的数据
-	row := make(map[string]interface{}, len(columns))
-	values := make([]interface{}, len(columns))
-
-	// 遍历每一列，初始化 interface{} 切片中的值
-	for i := range columns {
-		var v interface{}
-		values[i] = &v
-		row[columns[i]] = &v
-	}
-
 	// 创建一个空的 map 切片，用于存储最终结果
 	result := make([]map[string]interface{}, 0)
 
 	// 遍历结果集中的每一行数据
 	for rows.Next() {
-		// 调用 rows.Scan 方法，将结果存储在指针切片中
-		err = rows.Scan(values...)
+		m, err := scanRow(rows, columns)
 		if err != nil {
 			return nil, err
 		}
-
-		// 将当前行的 map 深拷贝到一个新的 map 中，避免后续循环覆盖数据
-		m := make(map[string]interface{}, len(row))
-		for k, v := range row {
-			var temp = v
-			// 如果值是 []byte 类型，转换成 string 类型
-			if b1, ok := v.(*interface{}); ok {
-				if b, ok := (*b1).([]byte); ok {
-					temp = string(b)
-				} else {
-					temp = *b1
-				}
-			}
-			m[k] = temp
-		}
 		// 将新的 map 追加到结果切片中
 		result = append(result, m)
 	}
@@ -312,9 +384,38 @@ func (x *DbClientNode) query(client *sql.DB, sqlStr string, params []interface{}
 
 }
 
+// dbContext 数据库操作使用的上下文，规则链取消或者超时后中断执行和回滚事务
+func dbContext(ctx types.RuleContext) context.Context {
+	if c := ctx.GetContext(); c != nil {
+		return c
+	}
+	return context.Background()
+}
+
+// scanRow 读取当前行的数据，如果值是 []byte 类型，转换成 string 类型
+func scanRow(rows *sql.Rows, columns []string) (map[string]interface{}, error) {
+	values := make([]interface{}, len(columns))
+	for i := range values {
+		var v interface{}
+		values[i] = &v
+	}
+	if err := rows.Scan(values...); err != nil {
+		return nil, err
+	}
+	row := make(map[string]interface{}, len(columns))
+	for i, column := range columns {
+		v := *(values[i].(*interface{}))
+		if b, ok := v.([]byte); ok {
+			v = string(b)
+		}
+		row[column] = v
+	}
+	return row, nil
+}
+
 // update 修改数据并返回影响行数
-func (x *DbClientNode) update(client *sql.DB, sqlStr string, params []interface{}) (int64, error) {
-	result, err := client.Exec(sqlStr, params...)
+func (x *DbClientNode) update(c context.Context, client dbExecutor, sqlStr string, params []interface{}) (int64, error) {
+	result, err := client.ExecContext(c, sqlStr, params...)
 	if err != nil {
 		return 0, err
 	}
@@ -326,8 +427,8 @@ func (x *DbClientNode) update(client *sql.DB, sqlStr string, params []interface{
 }
 
 // insert 插入数据并返回自增ID
-func (x *DbClientNode) insert(client *sql.DB, sqlStr string, params []interface{}) (int64, int64, error) {
-	result, err := client.Exec(sqlStr, params...)
+func (x *DbClientNode) insert(c context.Context, client dbExecutor, sqlStr string, params []interface{}) (int64, int64, error) {
+	result, err := client.ExecContext(c, sqlStr, params...)
 	if err != nil {
 		return 0, 0, err
 	} else {
@@ -342,8 +443,8 @@ func (x *DbClientNode) insert(client *sql.DB, sqlStr string, params []interface{
 }
 
 // delete 删除数据并返回影响行数
-func (x *DbClientNode) delete(client *sql.DB, sqlStr string, params []interface{}) (int64, error) {
-	result, err := client.Exec(sqlStr, params...)
+func (x *DbClientNode) delete(c context.Context, client dbExecutor, sqlStr string, params []interface{}) (int64, error) {
+	result, err := client.ExecContext(c, sqlStr, params...)
 	if err != nil {
 		return 0, err
 	}
@@ -354,6 +455,120 @@ func (x *DbClientNode) delete(client *sql.DB, sqlStr string, params []interface{
 	return rowsAffected, nil
 }
 
+// compileStatements 编译事务语句
+func (x *DbClientNode) compileStatements(statements []DbStatement, opt el.TemplateOption) ([]dbStatement, error) {
+	var result []dbStatement
+	names := make(map[string]struct{})
+	for i, item := range statements {
+		statement := dbStatement{
+			name:   strings.TrimSpace(item.Name),
+			sql:    str.ConvertDollarPlaceholder(item.Sql, x.Config.DriverName),
+			getOne: item.GetOne,
+		}
+		if statement.name == "" {
+			statement.name = fmt.Sprintf("s%d", i+1)
+		}
+		if _, ok := names[statement.name]; ok {
+			return nil, fmt.Errorf("duplicate statement name: %s", statement.name)
+		}
+		names[statement.name] = struct{}{}
+		if statement.sql == "" {
+			return nil, fmt.Errorf("statement %s: sql can not empty", statement.name)
+		}
+		statement.sqlHasVar = str.CheckHasVar(statement.sql)
+		if !statement.sqlHasVar {
+			statement.opType = x.getOpType(statement.sql)
+			if err := x.checkOpType(statement.opType, statement.sql); err != nil {
+				return nil, fmt.Errorf("statement %s: %w", statement.name, err)
+			}
+		}
+		for _, param := range item.Params {
+			temp, err := el.NewTemplate(param, opt)
+			if err != nil {
+				return nil, fmt.Errorf("statement %s: %w", statement.name, err)
+			}
+			statement.paramsTemplate = append(statement.paramsTemplate, temp)
+		}
+		result = append(result, statement)
+	}
+	return result, nil
+}
+
+// execTransaction 在同一个事务中按顺序执行语句，全部成功则提交，否则回滚
+func (x *DbClientNode) execTransaction(ctx types.RuleContext, msg types.RuleMsg) {
+	client, err := x.SharedNode.GetSafely()
+	if err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	c := dbContext(ctx)
+	tx, err := client.BeginTx(c, nil)
+	if err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	results := make(map[string]interface{}, len(x.statements))
+	evn := base.NodeUtils.GetEvnAndMetadata(ctx, msg)
+	evn[resultsKey] = results
+	for _, statement := range x.statements {
+		result, err := x.execStatement(c, tx, statement, evn)
+		if err != nil {
+			err = fmt.Errorf("statement %s failed: %w", statement.name, err)
+			if rollbackErr := tx.Rollback(); rollbackErr != nil {
+				err = fmt.Errorf("%w, rollback failed: %v", err, rollbackErr)
+			}
+			msg.Metadata.PutValue(failedStatementKey, statement.name)
+			ctx.TellFailure(msg, err)
+			return
+		}
+		results[statement.name] = result
+	}
+	if err = tx.Commit(); err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	msg.DataType = types.JSON
+	msg.SetData(str.ToString(results))
+	ctx.TellSuccess(msg)
+}
+
+// execStatement 执行事务中的一条语句，返回执行结果
+func (x *DbClientNode) execStatement(c context.Context, tx *sql.Tx, statement dbStatement, evn map[string]interface{}) (interface{}, error) {
+	sqlStr := statement.sql
+	opType := statement.opType
+	if statement.sqlHasVar {
+		sqlStr = str.ConvertDollarPlaceholder(str.ExecuteTemplate(sqlStr, evn), x.Config.DriverName)
+		opType = x.getOpType(sqlStr)
+		if err := x.checkOpType(opType, sqlStr); err != nil {
+			return nil, err
+		}
+	}
+	var params []interface{}
+	for _, item := range statement.paramsTemplate {
+		param, err := item.Execute(evn)
+		if err != nil {
+			return nil, err
+		}
+		params = append(params, param)
+	}
+	switch opType {
+	case SELECT:
+		return x.query(c, tx, sqlStr, params, statement.getOne)
+	case INSERT:
+		rowsAffected, lastInsertId, err := x.insert(c, tx, sqlStr, params)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{rowsAffectedKey: rowsAffected, lastInsertIdKey: lastInsertId}, nil
+	default:
+		rowsAffected, err := x.update(c, tx, sqlStr, params)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{rowsAffectedKey: rowsAffected}, nil
+	}
+}
+
 // Destroy 销毁组件
 func (x *DbClientNode) Destroy() {
 	_ = x.SharedNode.Close()
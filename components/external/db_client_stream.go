@@ -0,0 +1,202 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package external
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/yunboom/rulego/api/types"
+	"github.com/yunboom/rulego/utils/str"
+)
+
+const (
+	// StreamModeRow 每行发送一条消息
+	StreamModeRow = "row"
+	// StreamModePage 每页发送一条消息
+	StreamModePage = "page"
+	// defaultStreamPageSize 默认每页的行数
+	defaultStreamPageSize = 100
+	// maxSubmitRetryDelay 协程池已满时重试的最大等待时间
+	maxSubmitRetryDelay = 100 * time.Millisecond
+
+	// rowIndexKey row模式行序号，从0开始
+	rowIndexKey = "rowIndex"
+	// pageIndexKey page模式页序号，从0开始
+	pageIndexKey = "pageIndex"
+	// rowCountKey 当前页的行数，完成消息中为总行数
+	rowCountKey = "rowCount"
+	// pageCountKey 完成消息中的总页数
+	pageCountKey = "pageCount"
+	// streamCompletedKey 完成消息标识
+	streamCompletedKey = "streamCompleted"
+)
+
+var errStreamNotSelect = errors.New("stream mode only supports select statement")
+
+// DbStreamConfiguration 流式查询配置
+type DbStreamConfiguration struct {
+	// Mode 流式模式，row:每行发送一条消息，page:每页发送一条消息，为空则不使用流式查询
+	Mode string
+	// PageSize page模式每页的行数，默认100
+	PageSize int
+}
+
+// checkStream 检查流式查询配置
+func (x *DbClientNode) checkStream() error {
+	switch x.Config.Stream.Mode {
+	case "", StreamModeRow:
+		return nil
+	case StreamModePage:
+		if x.Config.Stream.PageSize <= 0 {
+			x.Config.Stream.PageSize = defaultStreamPageSize
+		}
+		return nil
+	default:
+		return fmt.Errorf("unsupported stream mode: %s", x.Config.Stream.Mode)
+	}
+}
+
+// streamQuery 以游标方式读取查询结果，每行或者每页发送一条消息，最后发送完成消息
+func (x *DbClientNode) streamQuery(ctx types.RuleContext, msg types.RuleMsg, client *sql.DB, sqlStr string, params []interface{}) {
+	c := dbContext(ctx)
+	rows, err := client.QueryContext(c, sqlStr, params...)
+	if err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	defer rows.Close()
+	columns, err := rows.Columns()
+	if err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+
+	emitter := &streamEmitter{ctx: ctx, c: c}
+	pageSize := 1
+	if x.Config.Stream.Mode == StreamModePage {
+		pageSize = x.Config.Stream.PageSize
+	}
+	var rowCount, pageCount int
+	page := make([]map[string]interface{}, 0, pageSize)
+	flush := func() error {
+		var data interface{}
+		outMsg := msg.Copy()
+		if x.Config.Stream.Mode == StreamModePage {
+			data = page
+			outMsg.Metadata.PutValue(pageIndexKey, str.ToString(pageCount))
+			outMsg.Metadata.PutValue(rowCountKey, str.ToString(len(page)))
+		} else {
+			data = page[0]
+			outMsg.Metadata.PutValue(rowIndexKey, str.ToString(rowCount-1))
+		}
+		outMsg.DataType = types.JSON
+		outMsg.SetData(str.ToString(data))
+		pageCount++
+		page = make([]map[string]interface{}, 0, pageSize)
+		return emitter.emit(outMsg)
+	}
+	for rows.Next() {
+		row, err := scanRow(rows, columns)
+		if err != nil {
+			emitter.tell(msg, err)
+			return
+		}
+		rowCount++
+		page = append(page, row)
+		if len(page) >= pageSize {
+			if err = flush(); err != nil {
+				emitter.tell(msg, err)
+				return
+			}
+		}
+	}
+	if err = rows.Err(); err != nil {
+		emitter.tell(msg, err)
+		return
+	}
+	if len(page) > 0 {
+		if err = flush(); err != nil {
+			emitter.tell(msg, err)
+			return
+		}
+	}
+	//完成消息
+	msg.Metadata.PutValue(streamCompletedKey, "true")
+	msg.Metadata.PutValue(rowCountKey, str.ToString(rowCount))
+	if x.Config.Stream.Mode == StreamModePage {
+		msg.Metadata.PutValue(pageCountKey, str.ToString(pageCount))
+	}
+	emitter.tell(msg, nil)
+}
+
+// streamEmitter 通过协程池发送流式查询的消息
+// 协程池已满时等待后重试，从而暂停读取结果集，没有配置协程池时直接发送
+type streamEmitter struct {
+	ctx types.RuleContext
+	c   context.Context
+	// lock 串行调用ctx.TellSuccess
+	lock sync.Mutex
+	wg   sync.WaitGroup
+}
+
+// emit 发送行或者页消息
+func (e *streamEmitter) emit(msg types.RuleMsg) error {
+	task := func() {
+		e.lock.Lock()
+		defer e.lock.Unlock()
+		e.ctx.TellSuccess(msg)
+	}
+	pool := e.ctx.Config().Pool
+	if pool == nil {
+		task()
+		return nil
+	}
+	delay := time.Millisecond
+	e.wg.Add(1)
+	for {
+		if err := pool.Submit(func() {
+			defer e.wg.Done()
+			task()
+		}); err == nil {
+			return nil
+		}
+		select {
+		case <-e.c.Done():
+			e.wg.Done()
+			return e.c.Err()
+		case <-time.After(delay):
+		}
+		if delay < maxSubmitRetryDelay {
+			delay *= 2
+		}
+	}
+}
+
+// tell 等待已提交的消息发送完成后，发送完成消息或者失败消息
+func (e *streamEmitter) tell(msg types.RuleMsg, err error) {
+	e.wg.Wait()
+	if err != nil {
+		e.ctx.TellFailure(msg, err)
+	} else {
+		e.ctx.TellSuccess(msg)
+	}
+}
@@ -31,8 +31,11 @@
 //
 // Message Broker Components:
 // 消息代理组件：
-//   - MqttClientNode: MQTT broker connectivity for IoT and messaging
-//     MQTT 代理连接，用于物联网和消息传递
+//   - MqttClientNode: MQTT 3.1.1/5 publishing with retained messages, user properties and request/response
+//     MQTT 3.1.1/5 消息发布，支持保留消息、用户属性和请求/响应
+//   - KafkaProducerNode: Publish messages to Kafka-compatible brokers with key, partition and header templates,
+//     registered by importing components/external/kafka
+//     向 Kafka 兼容的消息队列发布消息，支持 key、分区和消息头模板，导入 components/external/kafka 后注册
 //
 // Network Components:
 // 网络组件：
@@ -40,21 +43,36 @@
 //     TCP/UDP/Unix 套接字通信，支持各种协议
 //   - RestApiCallNode: HTTP/REST API client for web service integration
 //     HTTP/REST API 客户端，用于 Web 服务集成
+//   - EndpointSendNode: Push messages to live net/websocket endpoint connections by connection id
+//     根据连接ID向 net/websocket 端点的在线连接推送消息
+//   - SendEmailNode: SMTP email with HTML/plain-text bodies, attachments, inline images and STARTTLS/TLS
+//     通过 SMTP 发送邮件，支持 HTML/纯文本正文、附件、内嵌图片和 STARTTLS/TLS
 //
 // Remote Execution Components:
 // 远程执行组件：
-//   - SshNode: SSH-based remote command execution
-//     基于 SSH 的远程命令执行
+//   - SshNode: SSH remote command execution and SFTP transfer with key/agent auth and host key verification
+//     基于 SSH 的远程命令执行和 SFTP 文件传输，支持密钥/agent 认证和服务器公钥校验
 //
 // Cache Management Components:
 // 缓存管理组件：
 //   - CacheGetNode: Retrieve data from chain-level or global cache
 //     从链级或全局缓存中检索数据
-//   - CacheSetNode: Store data in cache with TTL support
-//     在缓存中存储数据，支持 TTL
+//   - CacheSetNode: Store data in cache with TTL support, atomic counters, compare-and-set and list operations
+//     在缓存中存储数据，支持 TTL、原子计数器、比较设置和列表操作
 //   - CacheDeleteNode: Remove data from cache with pattern matching
 //     从缓存中删除数据，支持模式匹配
 //
+// Object Storage Components:
+// 对象存储组件：
+//   - ObjectPutNode: Upload message data as an object to local directory or S3-compatible storage
+//     将消息数据作为对象上传到本地目录或 S3 兼容存储
+//   - ObjectGetNode: Download an object into the message
+//     下载对象到消息中
+//   - ObjectListNode: List objects by key prefix
+//     按 key 前缀列出对象
+//   - ObjectDeleteNode: Delete an object
+//     删除对象
+//
 // Component Categories:
 // 组件分类：
 //
@@ -64,11 +82,15 @@
 //     支持 SQL 的数据库操作
 //   - Cache management for data persistence
 //     数据持久化的缓存管理
+//   - Object storage for files and large payloads
+//     文件和大数据的对象存储
 //
 // Communication:
 // 通信：
 //   - MQTT messaging for IoT scenarios
 //     物联网场景的 MQTT 消息传递
+//   - Kafka publishing for event streaming
+//     事件流场景的 Kafka 消息发布
 //   - HTTP/REST API calls for web integration
 //     Web 集成的 HTTP/REST API 调用
 //   - Raw network protocols for custom communication
@@ -76,8 +98,8 @@
 //
 // Remote Operations:
 // 远程操作：
-//   - SSH command execution for system administration
-//     系统管理的 SSH 命令执行
+//   - SSH command execution and SFTP file transfer for system administration
+//     系统管理的 SSH 命令执行和 SFTP 文件传输
 //
 // Registration:
 // 注册：
@@ -0,0 +1,144 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package external
+
+import (
+	"errors"
+
+	"github.com/yunboom/rulego/api/types"
+	"github.com/yunboom/rulego/api/types/endpoint"
+	"github.com/yunboom/rulego/components/base"
+	"github.com/yunboom/rulego/utils/el"
+	"github.com/yunboom/rulego/utils/maps"
+)
+
+// 注册节点
+func init() {
+	Registry.Add(&EndpointSendNode{})
+}
+
+// EndpointSendNodeConfiguration 节点配置
+type EndpointSendNodeConfiguration struct {
+	// ConnectionId 连接ID，远程地址或者握手消息中的客户端ID
+	// 可以使用 ${metadata.key} 读取元数据中的变量或者使用 ${msg.key} 读取消息负荷中的变量进行替换
+	// 默认 ${metadata.connectionId}，即回复到消息来源的连接
+	ConnectionId string `json:"connectionId"`
+}
+
+// EndpointSendNode pushes the message to a live connection of the net or websocket endpoint by connection id.
+// It allows a chain to send commands to any connected device, not only reply to the current request.
+//
+// EndpointSendNode 根据连接ID把消息推送到 net 或 websocket 端点的在线连接。
+// 使规则链可以向任意已连接的设备下发指令，而不仅仅是回复当前请求。
+//
+// Configuration:
+// 配置说明：
+//
+//	{
+//		"connectionId": "${metadata.deviceId}"  // Connection id with variable substitution  支持变量替换的连接ID
+//	}
+//
+// Connection Id:
+// 连接ID：
+//
+// Connections are registered to endpoint.Connections by remote address, or by the client id
+// taken from the handshake message when clientIdField of the endpoint is configured.
+// Messages from the endpoint carry the id in metadata connectionId.
+//
+// 连接以远程地址注册到 endpoint.Connections，如果端点配置了 clientIdField，
+// 则以握手消息中的客户端ID注册。端点的消息在元数据 connectionId 中携带连接ID。
+//
+// Data Handling:
+// 数据处理：
+//
+//   - net: the data is written as is, or encoded into a frame by the codec of the endpoint
+//     net：数据原样写入，或者由端点的编解码器编码成帧
+//   - websocket: BINARY messages are sent as binary frames, others as text frames
+//     websocket：BINARY 类型消息以二进制帧发送，其他以文本帧发送
+//
+// Output Relations:
+// 输出关系：
+//
+//   - Success: Message sent successfully  消息发送成功
+//   - Failure: Connection not found or write error  连接不存在或写入失败
+//
+// Usage Example:
+// 使用示例：
+//
+//	// Send a downlink command to the device
+//	// 向设备下发指令
+//	{
+//		"id": "sendCommand",
+//		"type": "endpointSend",
+//		"configuration": {
+//			"connectionId": "${metadata.deviceId}"
+//		}
+//	}
+type EndpointSendNode struct {
+	// 节点配置
+	Config EndpointSendNodeConfiguration
+	// 连接ID模板
+	connectionIdTemplate *el.MixedTemplate
+}
+
+// Type 组件类型
+func (x *EndpointSendNode) Type() string {
+	return "endpointSend"
+}
+
+func (x *EndpointSendNode) New() types.Node {
+	return &EndpointSendNode{Config: EndpointSendNodeConfiguration{
+		ConnectionId: "${metadata." + endpoint.MetadataKeyConnectionId + "}",
+	}}
+}
+
+// Init 初始化
+func (x *EndpointSendNode) Init(ruleConfig types.Config, configuration types.Configuration) error {
+	err := maps.Map2Struct(configuration, &x.Config)
+	if err != nil {
+		return err
+	}
+	if x.Config.ConnectionId == "" {
+		x.Config.ConnectionId = "${metadata." + endpoint.MetadataKeyConnectionId + "}"
+	}
+	x.connectionIdTemplate, err = el.NewMixedTemplate(x.Config.ConnectionId, el.WithUdf(ruleConfig.Udf))
+	return err
+}
+
+// OnMsg 处理消息
+func (x *EndpointSendNode) OnMsg(ctx types.RuleContext, msg types.RuleMsg) {
+	var connectionId string
+	if x.connectionIdTemplate.HasVar() {
+		env := base.NodeUtils.GetEvnAndMetadata(ctx, msg)
+		connectionId = x.connectionIdTemplate.ExecuteAsString(env)
+	} else {
+		connectionId = x.Config.ConnectionId
+	}
+	if connectionId == "" {
+		ctx.TellFailure(msg, errors.New("connectionId can not be empty"))
+		return
+	}
+	if err := endpoint.Connections.Send(connectionId, msg); err != nil {
+		ctx.TellFailure(msg, err)
+	} else {
+		ctx.TellSuccess(msg)
+	}
+}
+
+// Destroy 销毁
+func (x *EndpointSendNode) Destroy() {
+}
@@ -0,0 +1,336 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package kafka provides the kafkaProducer component that publishes messages to Kafka-compatible brokers.
+// The component is not registered by default, so the Kafka client is only linked when it is used.
+// Import the package to register it with the default component registry:
+//
+// Package kafka 提供向 Kafka 兼容消息队列发布消息的 kafkaProducer 组件。
+// 该组件默认不注册，只有使用时才会链接 Kafka 客户端。导入该包后自动注册到默认组件注册表：
+//
+//	import _ "github.com/yunboom/rulego/components/external/kafka"
+package kafka
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/yunboom/rulego/api/types"
+	"github.com/yunboom/rulego/components/base"
+	"github.com/yunboom/rulego/engine"
+	"github.com/yunboom/rulego/utils/el"
+	"github.com/yunboom/rulego/utils/kafka"
+	"github.com/yunboom/rulego/utils/maps"
+)
+
+// KafkaProducerNode 把消息发布到Kafka兼容消息队列的外部组件
+// KafkaProducerNode publishes messages to Kafka-compatible brokers.
+//
+// 核心算法：
+// Core Algorithm:
+// 1. 使用变量替换解析主题、key、分区和消息头模板 - Parse topic, key, partition and header templates with variable substitution
+// 2. 通过共享的同步生产者发送消息，并发的消息按批量配置合并发送 - Send through the shared sync producer, concurrent messages are batched
+// 3. 等待broker按acks级别确认 - Wait for the broker acknowledgement according to acks
+// 4. 把写入的分区和位移放入元数据 - Put the written partition and offset into metadata
+//
+// 配置说明 - Configuration:
+//
+//	{
+//		"brokers": ["127.0.0.1:9092"],          // Broker addresses  broker 地址列表
+//		"topic": "device.${metadata.type}",     // Topic with variable substitution  主题，支持变量替换
+//		"key": "${metadata.deviceId}",          // Message key, empty for round-robin  消息key，为空则轮询分区
+//		"partition": "",                        // Explicit partition, empty to hash by key  指定分区，为空按key哈希
+//		"headers": {"traceId": "${metadata.traceId}"}, // Message headers  消息头
+//		"acks": 1,                              // 0:none, 1:leader, -1:all replicas  确认级别
+//		"batchSize": 100,                       // Flush after N messages  批量条数阈值
+//		"batchBytes": 0,                        // Flush after N bytes  批量字节数阈值
+//		"lingerMs": 10,                         // Max batching delay in milliseconds  批量最大等待时间（毫秒）
+//		"compression": "none",                  // none, gzip, snappy, lz4, zstd  压缩算法
+//		"timeout": 10                           // Acknowledgement timeout in seconds  确认超时（秒）
+//	}
+//
+// 输出元数据 - Output metadata:
+//   - partition: 消息写入的分区 - Partition the message was written to
+//   - offset: 消息写入的位移 - Offset of the written message
+//
+// 批量发送 - Batching:
+//
+// 同步生产者逐条等待确认，规则链并发处理的消息会在 lingerMs 时间窗口内合并成一个批次发送，
+// batchSize、batchBytes 任一达到阈值立即发送。
+// The sync producer waits for each acknowledgement; messages sent concurrently by the rule chain
+// are merged into one batch within lingerMs, and a batch is flushed as soon as batchSize or batchBytes is reached.
+//
+// 连接管理 - Connection management:
+//   - SharedNode模式，相同brokers的节点可以共享生产者 - SharedNode pattern, nodes with the same brokers can share the producer
+func init() {
+	_ = engine.Registry.Register(&KafkaProducerNode{})
+}
+
+const (
+	// KafkaPartitionMetadataKey 消息写入分区的元数据key
+	KafkaPartitionMetadataKey = "partition"
+	// KafkaOffsetMetadataKey 消息写入位移的元数据key
+	KafkaOffsetMetadataKey = "offset"
+)
+
+// KafkaProducerNodeConfiguration 节点配置
+type KafkaProducerNodeConfiguration struct {
+	// Brokers kafka broker 地址列表
+	Brokers []string
+	// Topic 发布主题，可以使用 ${metadata.key} 读取元数据中的变量或者使用 ${msg.key} 读取消息负荷中的变量进行替换
+	Topic string
+	// Key 消息key，可以使用变量，为空则轮询分区
+	Key string
+	// Partition 指定写入的分区，可以使用变量，为空则按key哈希选择分区
+	Partition string
+	// Headers 消息头，key和value都可以使用变量
+	Headers map[string]string
+	// Acks 确认级别，0:不等待确认，1:leader确认，-1:所有同步副本确认
+	Acks int
+	// BatchSize 批量发送的消息条数阈值，0不限制
+	BatchSize int
+	// BatchBytes 批量发送的字节数阈值，0不限制
+	BatchBytes int
+	// LingerMs 批量发送的最大等待时间，单位毫秒，0表示立即发送
+	LingerMs int
+	// Compression 压缩算法，none、gzip、snappy、lz4、zstd，默认none
+	Compression string
+	// Timeout 等待broker确认的超时时间，单位秒
+	Timeout int
+	// ClientID 客户端ID，为空则随机生成
+	ClientID string
+	// Version kafka协议版本，例如：2.8.0，为空使用默认版本
+	Version string
+	// Username SASL认证用户名，为空不认证
+	Username string
+	// Password SASL认证密码
+	Password string
+	// SaslMechanism SASL认证机制，目前支持PLAIN
+	SaslMechanism string
+	// TLS 是否使用TLS连接
+	TLS         bool
+	CAFile      string
+	CertFile    string
+	CertKeyFile string
+}
+
+func (x *KafkaProducerNodeConfiguration) ToKafkaConfig() kafka.Config {
+	return kafka.Config{
+		Brokers:       x.Brokers,
+		ClientID:      x.ClientID,
+		Version:       x.Version,
+		Username:      x.Username,
+		Password:      x.Password,
+		SaslMechanism: x.SaslMechanism,
+		TLS:           x.TLS,
+		CAFile:        x.CAFile,
+		CertFile:      x.CertFile,
+		CertKeyFile:   x.CertKeyFile,
+	}
+}
+
+type KafkaProducerNode struct {
+	base.SharedNode[sarama.SyncProducer]
+	//节点配置
+	Config            KafkaProducerNodeConfiguration
+	topicTemplate     *el.MixedTemplate
+	keyTemplate       *el.MixedTemplate
+	partitionTemplate *el.MixedTemplate
+	headersTemplate   map[*el.MixedTemplate]*el.MixedTemplate
+	hasVar            bool
+}
+
+// Type 组件类型
+func (x *KafkaProducerNode) Type() string {
+	return "kafkaProducer"
+}
+
+func (x *KafkaProducerNode) New() types.Node {
+	return &KafkaProducerNode{Config: KafkaProducerNodeConfiguration{
+		Brokers: []string{"127.0.0.1:9092"},
+		Topic:   "device.msg",
+		Acks:    1,
+		Timeout: 10,
+	}}
+}
+
+// Init 初始化
+func (x *KafkaProducerNode) Init(ruleConfig types.Config, configuration types.Configuration) error {
+	err := maps.Map2Struct(configuration, &x.Config)
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(x.Config.Topic) == "" {
+		return fmt.Errorf("topic can not be empty")
+	}
+	if _, err = x.producerConfig(); err != nil {
+		return err
+	}
+	if x.topicTemplate, err = x.newTemplate(x.Config.Topic, el.WithUdf(ruleConfig.Udf)); err != nil {
+		return err
+	}
+	if x.keyTemplate, err = x.newTemplate(x.Config.Key, el.WithUdf(ruleConfig.Udf)); err != nil {
+		return err
+	}
+	if x.partitionTemplate, err = x.newTemplate(x.Config.Partition, el.WithUdf(ruleConfig.Udf)); err != nil {
+		return err
+	}
+	x.headersTemplate = make(map[*el.MixedTemplate]*el.MixedTemplate)
+	for key, value := range x.Config.Headers {
+		keyTmpl, err := el.NewMixedTemplate(key, el.WithUdf(ruleConfig.Udf))
+		if err != nil {
+			return err
+		}
+		valueTmpl, err := el.NewMixedTemplate(value, el.WithUdf(ruleConfig.Udf))
+		if err != nil {
+			return err
+		}
+		if keyTmpl.HasVar() || valueTmpl.HasVar() {
+			x.hasVar = true
+		}
+		x.headersTemplate[keyTmpl] = valueTmpl
+	}
+	return x.SharedNode.InitWithClose(ruleConfig, x.Type(), x.Config.ToKafkaConfig().ResourcePath(), ruleConfig.NodeClientInitNow, func() (sarama.SyncProducer, error) {
+		return x.initClient()
+	}, func(producer sarama.SyncProducer) error {
+		// 清理回调函数
+		return producer.Close()
+	})
+}
+
+// OnMsg 处理消息，解析主题、key、分区和消息头后同步发送到kafka
+// OnMsg resolves the topic, key, partition and headers, then sends the message to kafka synchronously.
+func (x *KafkaProducerNode) OnMsg(ctx types.RuleContext, msg types.RuleMsg) {
+	producer, err := x.SharedNode.GetSafely()
+	if err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	var evn map[string]interface{}
+	if x.hasVar {
+		evn = base.NodeUtils.GetEvnAndMetadata(ctx, msg)
+	}
+	producerMsg, err := x.producerMessage(evn, msg)
+	if err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	partition, offset, err := producer.SendMessage(producerMsg)
+	if err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	msg.Metadata.PutValue(KafkaPartitionMetadataKey, strconv.FormatInt(int64(partition), 10))
+	msg.Metadata.PutValue(KafkaOffsetMetadataKey, strconv.FormatInt(offset, 10))
+	ctx.TellSuccess(msg)
+}
+
+// producerMessage 根据模板生成待发送的消息
+func (x *KafkaProducerNode) producerMessage(evn map[string]interface{}, msg types.RuleMsg) (*sarama.ProducerMessage, error) {
+	producerMsg := &sarama.ProducerMessage{
+		Topic: x.topicTemplate.ExecuteAsString(evn),
+		Value: sarama.StringEncoder(msg.GetData()),
+	}
+	if x.keyTemplate != nil {
+		if key := x.keyTemplate.ExecuteAsString(evn); key != "" {
+			producerMsg.Key = sarama.StringEncoder(key)
+		}
+	}
+	if x.partitionTemplate != nil {
+		partition, err := strconv.ParseInt(x.partitionTemplate.ExecuteAsString(evn), 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid partition: %w", err)
+		}
+		producerMsg.Partition = int32(partition)
+	}
+	for key, value := range x.headersTemplate {
+		producerMsg.Headers = append(producerMsg.Headers, sarama.RecordHeader{
+			Key:   []byte(key.ExecuteAsString(evn)),
+			Value: []byte(value.ExecuteAsString(evn)),
+		})
+	}
+	return producerMsg, nil
+}
+
+// Destroy 销毁
+func (x *KafkaProducerNode) Destroy() {
+	_ = x.SharedNode.Close()
+}
+
+// newTemplate 创建模板，空字符串返回nil
+func (x *KafkaProducerNode) newTemplate(tmpl string, opts ...el.TemplateOption) (*el.MixedTemplate, error) {
+	if tmpl == "" {
+		return nil, nil
+	}
+	t, err := el.NewMixedTemplate(tmpl, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if t.HasVar() {
+		x.hasVar = true
+	}
+	return t, nil
+}
+
+// producerConfig 生成生产者配置
+func (x *KafkaProducerNode) producerConfig() (*sarama.Config, error) {
+	config, err := kafka.NewSaramaConfig(x.Config.ToKafkaConfig())
+	if err != nil {
+		return nil, err
+	}
+	switch x.Config.Acks {
+	case 0:
+		config.Producer.RequiredAcks = sarama.NoResponse
+	case 1:
+		config.Producer.RequiredAcks = sarama.WaitForLocal
+	case -1:
+		config.Producer.RequiredAcks = sarama.WaitForAll
+	default:
+		return nil, fmt.Errorf("invalid acks: %d, must be 0, 1 or -1", x.Config.Acks)
+	}
+	if x.Config.Compression != "" {
+		if err = config.Producer.Compression.UnmarshalText([]byte(strings.ToLower(x.Config.Compression))); err != nil {
+			return nil, err
+		}
+	}
+	config.Producer.Flush.Messages = x.Config.BatchSize
+	config.Producer.Flush.Bytes = x.Config.BatchBytes
+	config.Producer.Flush.Frequency = time.Duration(x.Config.LingerMs) * time.Millisecond
+	if x.Config.Timeout > 0 {
+		config.Producer.Timeout = time.Duration(x.Config.Timeout) * time.Second
+	}
+	if x.Config.Partition != "" {
+		config.Producer.Partitioner = sarama.NewManualPartitioner
+	} else {
+		config.Producer.Partitioner = sarama.NewHashPartitioner
+	}
+	//同步生产者需要返回成功和失败结果
+	config.Producer.Return.Successes = true
+	config.Producer.Return.Errors = true
+	return config, config.Validate()
+}
+
+// initClient 初始化客户端
+func (x *KafkaProducerNode) initClient() (sarama.SyncProducer, error) {
+	config, err := x.producerConfig()
+	if err != nil {
+		return nil, err
+	}
+	return sarama.NewSyncProducer(x.Config.Brokers, config)
+}
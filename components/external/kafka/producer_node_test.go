@@ -0,0 +1,167 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package kafka
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/IBM/sarama"
+	"github.com/yunboom/rulego/api/types"
+	"github.com/yunboom/rulego/engine"
+	"github.com/yunboom/rulego/test"
+	"github.com/yunboom/rulego/test/assert"
+)
+
+// newKafkaMockBroker 进程内的kafka broker替身，topic有两个分区
+func newKafkaMockBroker(t *testing.T, topic string, produce *sarama.MockProduceResponse) *sarama.MockBroker {
+	broker := sarama.NewMockBroker(t, 1)
+	broker.SetHandlerByMap(map[string]sarama.MockResponse{
+		"MetadataRequest": sarama.NewMockMetadataResponse(t).
+			SetBroker(broker.Addr(), broker.BrokerID()).
+			SetLeader(topic, 0, broker.BrokerID()).
+			SetLeader(topic, 1, broker.BrokerID()),
+		"ProduceRequest": produce,
+	})
+	return broker
+}
+
+// 导入包后注册到默认组件注册表
+func TestRegister(t *testing.T) {
+	node, err := engine.Registry.NewNode("kafkaProducer")
+	assert.Nil(t, err)
+	assert.Equal(t, "kafkaProducer", node.Type())
+}
+
+func TestKafkaProducerNode(t *testing.T) {
+	broker := newKafkaMockBroker(t, "device.t1", sarama.NewMockProduceResponse(t).SetError("device.t1", 1, sarama.ErrNotEnoughReplicas))
+	defer broker.Close()
+
+	config := types.NewConfig()
+	var lock sync.Mutex
+	var outMsg types.RuleMsg
+	var relationType string
+	ctx := test.NewRuleContext(config, func(msg types.RuleMsg, rt string, err error) {
+		lock.Lock()
+		defer lock.Unlock()
+		outMsg, relationType = msg, rt
+	})
+	newMsg := func(partition string) types.RuleMsg {
+		metadata := types.NewMetadata()
+		metadata.PutValue("type", "t1")
+		metadata.PutValue("deviceId", "d1")
+		metadata.PutValue("partition", partition)
+		return types.NewMsg(0, "TELEMETRY", types.JSON, metadata, `{"temperature":41}`)
+	}
+
+	t.Run("InitError", func(t *testing.T) {
+		assert.NotNil(t, (&KafkaProducerNode{}).Init(config, types.Configuration{"brokers": []string{broker.Addr()}}))
+		assert.NotNil(t, (&KafkaProducerNode{}).Init(config, types.Configuration{"brokers": []string{broker.Addr()}, "topic": "t", "acks": 2}))
+		assert.NotNil(t, (&KafkaProducerNode{}).Init(config, types.Configuration{"brokers": []string{broker.Addr()}, "topic": "t", "compression": "rar"}))
+		assert.NotNil(t, (&KafkaProducerNode{}).Init(config, types.Configuration{"topic": "t", "brokers": []string{}}))
+	})
+
+	t.Run("ProducerMessage", func(t *testing.T) {
+		node := &KafkaProducerNode{}
+		err := node.Init(config, types.Configuration{
+			"brokers":   []string{broker.Addr()},
+			"topic":     "device.${metadata.type}",
+			"key":       "${metadata.deviceId}",
+			"partition": "${metadata.partition}",
+			"headers":   map[string]string{"deviceId": "${metadata.deviceId}", "source": "rulego"},
+		})
+		assert.Nil(t, err)
+		defer node.Destroy()
+		msg := newMsg("1")
+		producerMsg, err := node.producerMessage(map[string]interface{}{"metadata": msg.Metadata.Values()}, msg)
+		assert.Nil(t, err)
+		assert.Equal(t, "device.t1", producerMsg.Topic)
+		assert.Equal(t, sarama.StringEncoder("d1"), producerMsg.Key)
+		assert.Equal(t, sarama.StringEncoder(`{"temperature":41}`), producerMsg.Value)
+		assert.Equal(t, int32(1), producerMsg.Partition)
+		headers := map[string]string{}
+		for _, header := range producerMsg.Headers {
+			headers[string(header.Key)] = string(header.Value)
+		}
+		assert.Equal(t, map[string]string{"deviceId": "d1", "source": "rulego"}, headers)
+
+		_, err = node.producerMessage(map[string]interface{}{"metadata": map[string]string{"partition": "x"}}, msg)
+		assert.NotNil(t, err)
+	})
+
+	t.Run("Send", func(t *testing.T) {
+		node := &KafkaProducerNode{}
+		err := node.Init(config, types.Configuration{
+			"brokers":   []string{broker.Addr()},
+			"topic":     "device.${metadata.type}",
+			"key":       "${metadata.deviceId}",
+			"partition": "${metadata.partition}",
+			"acks":      -1,
+			"lingerMs":  5,
+			"batchSize": 10,
+		})
+		assert.Nil(t, err)
+		defer node.Destroy()
+
+		node.OnMsg(ctx, newMsg("0"))
+		assert.Equal(t, types.Success, relationType)
+		assert.Equal(t, "0", outMsg.Metadata.GetValue(KafkaPartitionMetadataKey))
+		assert.Equal(t, "0", outMsg.Metadata.GetValue(KafkaOffsetMetadataKey))
+
+		//broker返回错误
+		node.OnMsg(ctx, newMsg("1"))
+		assert.Equal(t, types.Failure, relationType)
+
+		//分区不合法
+		node.OnMsg(ctx, newMsg("x"))
+		assert.Equal(t, types.Failure, relationType)
+
+		//并发发送合并成批次
+		var wg sync.WaitGroup
+		var success sync.Map
+		for i := 0; i < 5; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				msgCtx := test.NewRuleContext(config, func(msg types.RuleMsg, rt string, err error) {
+					success.Store(msg.Id, rt == types.Success)
+				})
+				node.OnMsg(msgCtx, newMsg("0"))
+			}()
+		}
+		wg.Wait()
+		count := 0
+		success.Range(func(key, value any) bool {
+			if value.(bool) {
+				count++
+			}
+			return true
+		})
+		assert.Equal(t, 5, count)
+	})
+
+	t.Run("ConnectError", func(t *testing.T) {
+		node := &KafkaProducerNode{}
+		err := node.Init(config, types.Configuration{
+			"brokers": []string{"127.0.0.1:1"},
+			"topic":   "device.msg",
+		})
+		assert.Nil(t, err)
+		node.OnMsg(ctx, newMsg("0"))
+		assert.Equal(t, types.Failure, relationType)
+	})
+}
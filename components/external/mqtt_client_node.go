@@ -18,6 +18,9 @@ package external
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"strconv"
 	"time"
 
 	"github.com/yunboom/rulego/utils/mqtt"
@@ -33,10 +36,11 @@ import (
 //
 // 核心算法：
 // Core Algorithm:
-// 1. 使用变量替换解析主题模板 - Parse topic template with variable substitution
+// 1. 使用变量替换解析主题、QoS、保留标志和用户属性 - Resolve topic, QoS, retain flag and user properties with variable substitution
 // 2. 建立MQTT连接并配置QoS参数 - Establish MQTT connection and configure QoS parameters
 // 3. 发布消息到指定主题 - Publish message to specified topic
-// 4. 支持自动重连和连接池管理 - Support automatic reconnection and connection pool management
+// 4. 配置响应主题时等待响应并把响应发送到下一个节点 - Wait for the reply when a response topic is configured and emit it downstream
+// 5. 支持自动重连和连接池管理 - Support automatic reconnection and connection pool management
 //
 // 配置说明 - Configuration:
 //
@@ -45,7 +49,15 @@ import (
 //		"username": "user",                   // Authentication username  认证用户名
 //		"password": "pass",                   // Authentication password  认证密码
 //		"topic": "/device/${metadata.deviceId}",  // Publish topic with variable substitution  发布主题支持变量替换
-//		"qos": 1,                            // QoS level (0, 1, 2)  QoS 级别
+//		"qos": 1,                            // Default QoS level (0, 1, 2)  默认 QoS 级别
+//		"qosTemplate": "${metadata.qos}",    // Per-message QoS, empty uses qos  每条消息的 QoS，为空使用 qos
+//		"retain": "${metadata.retain}",      // Per-message retain flag ("true"/"false")  每条消息的保留标志
+//		"protocolVersion": 5,                // MQTT protocol version (3, 4, 5), default 4  MQTT 协议版本，默认4
+//		"userProperties": {"deviceId": "${metadata.deviceId}"}, // MQTT 5 user properties  MQTT 5 用户属性
+//		"responseTopic": "/reply/${metadata.deviceId}", // MQTT 5 response topic, enables request/response  MQTT 5 响应主题，开启请求/响应
+//		"correlationData": "${id}",          // MQTT 5 correlation data, random if empty  MQTT 5 对比数据，为空随机生成
+//		"responseTimeout": 10,               // Seconds to wait for the reply  等待响应超时（秒）
+//		"publishTimeout": 5,                 // Seconds to wait for the publish to complete  等待发布完成超时（秒）
 //		"maxReconnectInterval": 60,          // Reconnection interval in seconds  重连间隔（秒）
 //		"cleanSession": true,                // Clean session flag  清理会话标志
 //		"clientID": "rulegoClient",          // MQTT client identifier  MQTT 客户端标识符
@@ -63,6 +75,15 @@ import (
 //   - 1: 至少一次投递（确认投递）- At least once delivery (acknowledged delivery)
 //   - 2: 恰好一次投递（保证投递）- Exactly once delivery (assured delivery)
 //
+// 请求/响应 - Request/response (MQTT 5):
+//   - 配置了 responseTopic 时，节点订阅响应主题，发布请求后等待携带相同对比数据的响应
+//     When responseTopic is set, the node subscribes to it and waits for a reply carrying the same correlation data
+//   - 响应负荷替换消息负荷，响应的用户属性合并到元数据，通过 Success 链发送
+//     The reply payload replaces the message data, reply user properties are merged into metadata, sent via Success
+//   - 超时通过 Failure 链发送 - Timeouts are sent via Failure
+//   - 不含变量的响应主题在客户端初始化时订阅并保持，含变量的响应主题在每次请求时订阅和取消订阅
+//     Static response topics are subscribed once, templated ones are subscribed per request
+//
 // 连接管理 - Connection management:
 //   - 指数退避自动重连直到最大间隔 - Automatic reconnection with exponential backoff up to maximum interval
 //   - SharedNode模式高效资源利用 - SharedNode pattern for efficient resource utilization
@@ -91,6 +112,17 @@ func init() {
 	Registry.Add(&MqttClientNode{})
 }
 
+const (
+	// MqttTopicMetadataKey 响应消息的主题
+	MqttTopicMetadataKey = "topic"
+	// MqttCorrelationDataMetadataKey 响应消息的对比数据
+	MqttCorrelationDataMetadataKey = "correlationData"
+	// defaultMqttResponseTimeout 默认等待响应超时，单位秒
+	defaultMqttResponseTimeout = 10
+	// defaultMqttPublishTimeout 默认等待发布完成超时，单位秒
+	defaultMqttPublishTimeout = 5
+)
+
 type MqttClientNodeConfiguration struct {
 	Server   string
 	Username string
@@ -100,11 +132,27 @@ type MqttClientNodeConfiguration struct {
 	//MaxReconnectInterval 重连间隔 单位秒
 	MaxReconnectInterval int
 	QOS                  uint8
-	CleanSession         bool
-	ClientID             string
-	CAFile               string
-	CertFile             string
-	CertKeyFile          string
+	// QosTemplate 每条消息的QoS，可以使用变量，结果必须是0、1或2，为空使用 QOS
+	QosTemplate string
+	// Retain 是否保留消息，可以使用变量，结果为 true 时保留
+	Retain       string
+	CleanSession bool
+	ClientID     string
+	// ProtocolVersion 协议版本 3:MQTT 3.1 4:MQTT 3.1.1 5:MQTT 5.0，默认4
+	ProtocolVersion int
+	// UserProperties MQTT 5 用户属性，值可以使用变量，例如：{"deviceId": "${metadata.deviceId}"}
+	UserProperties map[string]string
+	// ResponseTopic MQTT 5 响应主题，可以使用变量，不为空时等待响应并把响应发送到下一个节点
+	ResponseTopic string
+	// CorrelationData MQTT 5 对比数据，可以使用变量，为空随机生成
+	CorrelationData string
+	// ResponseTimeout 等待响应超时，单位秒，默认10
+	ResponseTimeout int
+	// PublishTimeout 等待发布完成(QoS>0时等待broker确认)超时，单位秒，默认5
+	PublishTimeout int
+	CAFile         string
+	CertFile       string
+	CertKeyFile    string
 }
 
 func (x *MqttClientNodeConfiguration) ToMqttConfig() mqtt.Config {
@@ -119,6 +167,7 @@ func (x *MqttClientNodeConfiguration) ToMqttConfig() mqtt.Config {
 		MaxReconnectInterval: time.Duration(x.MaxReconnectInterval) * time.Second,
 		CleanSession:         x.CleanSession,
 		ClientID:             x.ClientID,
+		ProtocolVersion:      uint(x.ProtocolVersion),
 		CAFile:               x.CAFile,
 		CertFile:             x.CertFile,
 		CertKeyFile:          x.CertKeyFile,
@@ -126,11 +175,21 @@ func (x *MqttClientNodeConfiguration) ToMqttConfig() mqtt.Config {
 }
 
 type MqttClientNode struct {
-	base.SharedNode[*mqtt.Client]
+	base.SharedNode[mqtt.Publisher]
 	//节点配置
 	Config MqttClientNodeConfiguration
 	//topic 模板
 	topicTemplate str.Template
+	//qos 模板
+	qosTemplate str.Template
+	//retain 模板
+	retainTemplate str.Template
+	//用户属性模板
+	userPropertiesTemplate map[string]str.Template
+	//响应主题模板
+	responseTopicTemplate str.Template
+	//对比数据模板
+	correlationDataTemplate str.Template
 }
 
 // Type 组件类型
@@ -150,34 +209,106 @@ func (x *MqttClientNode) New() types.Node {
 // Init 初始化
 func (x *MqttClientNode) Init(ruleConfig types.Config, configuration types.Configuration) error {
 	err := maps.Map2Struct(configuration, &x.Config)
-	if err == nil {
-		_ = x.SharedNode.InitWithClose(ruleConfig, x.Type(), x.Config.Server, ruleConfig.NodeClientInitNow, func() (*mqtt.Client, error) {
-			return x.initClient()
-		}, func(client *mqtt.Client) error {
-			// 清理回调函数
-			return client.Close()
-		})
-		x.topicTemplate = str.NewTemplate(x.Config.Topic)
-	}
-	return err
+	if err != nil {
+		return err
+	}
+	if x.Config.QOS > 2 {
+		return fmt.Errorf("invalid qos: %d", x.Config.QOS)
+	}
+	switch x.Config.ProtocolVersion {
+	case 0, mqtt.ProtocolVersion31, mqtt.ProtocolVersion311:
+		if len(x.Config.UserProperties) > 0 || x.Config.ResponseTopic != "" || x.Config.CorrelationData != "" {
+			return errors.New("userProperties, responseTopic and correlationData require protocolVersion 5")
+		}
+	case mqtt.ProtocolVersion5:
+	default:
+		return fmt.Errorf("unsupported protocol version: %d", x.Config.ProtocolVersion)
+	}
+	if x.Config.ResponseTimeout <= 0 {
+		x.Config.ResponseTimeout = defaultMqttResponseTimeout
+	}
+	if x.Config.PublishTimeout <= 0 {
+		x.Config.PublishTimeout = defaultMqttPublishTimeout
+	}
+	x.topicTemplate = str.NewTemplate(x.Config.Topic)
+	x.qosTemplate = str.NewTemplate(x.Config.QosTemplate)
+	x.retainTemplate = str.NewTemplate(x.Config.Retain)
+	x.responseTopicTemplate = str.NewTemplate(x.Config.ResponseTopic)
+	x.correlationDataTemplate = str.NewTemplate(x.Config.CorrelationData)
+	x.userPropertiesTemplate = make(map[string]str.Template, len(x.Config.UserProperties))
+	for k, v := range x.Config.UserProperties {
+		x.userPropertiesTemplate[k] = str.NewTemplate(v)
+	}
+	_ = x.SharedNode.InitWithClose(ruleConfig, x.Type(), x.Config.Server, ruleConfig.NodeClientInitNow, func() (mqtt.Publisher, error) {
+		return x.initClient()
+	}, func(client mqtt.Publisher) error {
+		// 清理回调函数
+		return client.Close()
+	})
+	return nil
 }
 
-// OnMsg 处理消息，使用变量替换解析主题并发布MQTT消息
-// OnMsg processes messages by parsing topic with variable substitution and publishing MQTT messages.
+// OnMsg 处理消息，使用变量替换解析主题并发布MQTT消息，配置了响应主题时等待响应
+// OnMsg processes messages by parsing topic with variable substitution and publishing MQTT messages,
+// waiting for the reply when a response topic is configured.
 func (x *MqttClientNode) OnMsg(ctx types.RuleContext, msg types.RuleMsg) {
-	topic := x.topicTemplate.ExecuteFn(func() map[string]any {
-		return base.NodeUtils.GetEvnAndMetadata(ctx, msg)
-	})
-
-	if client, err := x.SharedNode.GetSafely(); err != nil {
+	evn := base.NodeUtils.GetEvnAndMetadata(ctx, msg)
+	mqttMsg, err := x.mqttMessage(evn, msg)
+	if err != nil {
 		ctx.TellFailure(msg, err)
-	} else {
-		if err := client.Publish(topic, x.Config.QOS, []byte(msg.GetData())); err != nil {
+		return
+	}
+	client, err := x.getClient()
+	if err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	if mqttMsg.ResponseTopic == "" {
+		pubCtx, cancel := context.WithTimeout(ctx.GetContext(), time.Duration(x.Config.PublishTimeout)*time.Second)
+		defer cancel()
+		if err := client.PublishMessage(pubCtx, mqttMsg); err != nil {
 			ctx.TellFailure(msg, err)
 		} else {
 			ctx.TellSuccess(msg)
 		}
+		return
+	}
+	requester, ok := client.(*mqtt.ClientV5)
+	if !ok {
+		ctx.TellFailure(msg, errors.New("request/response requires an mqtt 5 client"))
+		return
+	}
+	reqCtx, cancel := context.WithTimeout(ctx.GetContext(), time.Duration(x.Config.ResponseTimeout)*time.Second)
+	defer cancel()
+	reply, err := requester.Request(reqCtx, mqttMsg)
+	if err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	msg.SetData(string(reply.Payload))
+	for k, v := range reply.UserProperties {
+		msg.Metadata.PutValue(k, v)
 	}
+	msg.Metadata.PutValue(MqttTopicMetadataKey, reply.Topic)
+	msg.Metadata.PutValue(MqttCorrelationDataMetadataKey, string(reply.CorrelationData))
+	ctx.TellSuccess(msg)
+}
+
+// getClient 获取客户端，引用共享客户端(ref://)时，共享客户端以资源ID共享，
+// 所以检查共享客户端的协议版本和客户端ID与节点配置的一致(配置了的情况下)
+func (x *MqttClientNode) getClient() (mqtt.Publisher, error) {
+	client, err := x.SharedNode.GetSafely()
+	if err != nil || x.SharedNode.InstanceId == "" {
+		return client, err
+	}
+	_, isV5 := client.(*mqtt.ClientV5)
+	if x.Config.ProtocolVersion != 0 && (x.Config.ProtocolVersion == mqtt.ProtocolVersion5) != isV5 {
+		return nil, fmt.Errorf("shared mqtt client %s does not use protocol version %d", x.Config.Server, x.Config.ProtocolVersion)
+	}
+	if c, ok := client.(interface{ ClientID() string }); ok && x.Config.ClientID != "" && c.ClientID() != x.Config.ClientID {
+		return nil, fmt.Errorf("shared mqtt client %s does not use client id %s", x.Config.Server, x.Config.ClientID)
+	}
+	return client, nil
 }
 
 // Destroy 销毁
@@ -185,11 +316,60 @@ func (x *MqttClientNode) Destroy() {
 	_ = x.SharedNode.Close()
 }
 
-// initClient 初始化客户端
-func (x *MqttClientNode) initClient() (*mqtt.Client, error) {
+// mqttMessage 根据消息和配置模板构建发布的消息
+func (x *MqttClientNode) mqttMessage(evn map[string]interface{}, msg types.RuleMsg) (mqtt.Message, error) {
+	mqttMsg := mqtt.Message{
+		Topic:   x.topicTemplate.Execute(evn),
+		Qos:     x.Config.QOS,
+		Payload: []byte(msg.GetData()),
+	}
+	if qosStr := x.qosTemplate.Execute(evn); qosStr != "" {
+		qos, err := strconv.ParseUint(qosStr, 10, 8)
+		if err != nil || qos > 2 {
+			return mqttMsg, fmt.Errorf("invalid qos: %s", qosStr)
+		}
+		mqttMsg.Qos = byte(qos)
+	}
+	if retainStr := x.retainTemplate.Execute(evn); retainStr != "" {
+		retain, err := strconv.ParseBool(retainStr)
+		if err != nil {
+			return mqttMsg, fmt.Errorf("invalid retain: %s", retainStr)
+		}
+		mqttMsg.Retain = retain
+	}
+	if len(x.userPropertiesTemplate) > 0 {
+		mqttMsg.UserProperties = make(map[string]string, len(x.userPropertiesTemplate))
+		for k, tmpl := range x.userPropertiesTemplate {
+			mqttMsg.UserProperties[k] = tmpl.Execute(evn)
+		}
+	}
+	mqttMsg.ResponseTopic = x.responseTopicTemplate.Execute(evn)
+	if correlationData := x.correlationDataTemplate.Execute(evn); correlationData != "" {
+		mqttMsg.CorrelationData = []byte(correlationData)
+	}
+	return mqttMsg, nil
+}
+
+// initClient 初始化客户端，MQTT 5 使用 ClientV5，不含变量的响应主题在初始化时订阅
+func (x *MqttClientNode) initClient() (mqtt.Publisher, error) {
 	ctx, cancel := context.WithTimeout(context.TODO(), 10*time.Second)
 	defer cancel()
-
-	client, err := mqtt.NewClient(ctx, x.Config.ToMqttConfig())
-	return client, err
+	if x.Config.ProtocolVersion != mqtt.ProtocolVersion5 {
+		client, err := mqtt.NewClient(ctx, x.Config.ToMqttConfig())
+		if err != nil {
+			return nil, err
+		}
+		return client, nil
+	}
+	client, err := mqtt.NewClientV5(ctx, x.Config.ToMqttConfig())
+	if err != nil {
+		return nil, err
+	}
+	if x.Config.ResponseTopic != "" && x.responseTopicTemplate.IsNotVar() {
+		if err := client.SubscribeResponseTopic(ctx, x.Config.ResponseTopic); err != nil {
+			_ = client.Close()
+			return nil, err
+		}
+	}
+	return client, nil
 }
@@ -0,0 +1,236 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package external
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/eclipse/paho.golang/packets"
+	"github.com/yunboom/rulego/api/types"
+	"github.com/yunboom/rulego/test"
+	"github.com/yunboom/rulego/test/assert"
+	"github.com/yunboom/rulego/utils/mqtt"
+)
+
+func TestMqttClientNode(t *testing.T) {
+	broker := test.NewMqtt5Broker(t)
+	defer broker.Close()
+	//设备回复请求，延迟回复用于测试超时
+	broker.SetResponder(func(p *packets.Publish) *packets.Publish {
+		if p.Properties == nil || p.Properties.ResponseTopic == "" {
+			return nil
+		}
+		if string(p.Payload) == "slow" {
+			time.Sleep(1500 * time.Millisecond)
+		}
+		return &packets.Publish{Topic: p.Properties.ResponseTopic, Payload: []byte(`{"result":"ok"}`), Properties: &packets.Properties{
+			CorrelationData: p.Properties.CorrelationData,
+			User:            []packets.User{{Key: "status", Value: "200"}},
+		}}
+	})
+
+	config := types.NewConfig()
+	var lock sync.Mutex
+	var outMsg types.RuleMsg
+	var relationType string
+	ctx := test.NewRuleContext(config, func(msg types.RuleMsg, rt string, err error) {
+		lock.Lock()
+		defer lock.Unlock()
+		outMsg, relationType = msg, rt
+	})
+	newMsg := func(data string) types.RuleMsg {
+		metadata := types.NewMetadata()
+		metadata.PutValue("deviceId", "d1")
+		metadata.PutValue("qos", "2")
+		metadata.PutValue("retain", "true")
+		return types.NewMsg(0, "TELEMETRY", types.JSON, metadata, data)
+	}
+
+	t.Run("InitError", func(t *testing.T) {
+		assert.NotNil(t, (&MqttClientNode{}).Init(config, types.Configuration{"qos": 3}))
+		assert.NotNil(t, (&MqttClientNode{}).Init(config, types.Configuration{"protocolVersion": 6}))
+		assert.NotNil(t, (&MqttClientNode{}).Init(config, types.Configuration{"responseTopic": "/reply"}))
+		assert.NotNil(t, (&MqttClientNode{}).Init(config, types.Configuration{"protocolVersion": 4, "userProperties": map[string]string{"a": "b"}}))
+	})
+
+	t.Run("MqttMessage", func(t *testing.T) {
+		node := &MqttClientNode{}
+		err := node.Init(config, types.Configuration{
+			"server":          broker.Addr(),
+			"protocolVersion": 5,
+			"topic":           "/device/${metadata.deviceId}",
+			"qosTemplate":     "${metadata.qos}",
+			"retain":          "${metadata.retain}",
+			"userProperties":  map[string]string{"deviceId": "${metadata.deviceId}"},
+			"responseTopic":   "/reply/${metadata.deviceId}",
+			"correlationData": "${metadata.deviceId}-1",
+		})
+		assert.Nil(t, err)
+		msg := newMsg("ping")
+		mqttMsg, err := node.mqttMessage(map[string]interface{}{"metadata": msg.Metadata.Values()}, msg)
+		assert.Nil(t, err)
+		assert.Equal(t, "/device/d1", mqttMsg.Topic)
+		assert.Equal(t, byte(2), mqttMsg.Qos)
+		assert.True(t, mqttMsg.Retain)
+		assert.Equal(t, map[string]string{"deviceId": "d1"}, mqttMsg.UserProperties)
+		assert.Equal(t, "/reply/d1", mqttMsg.ResponseTopic)
+		assert.Equal(t, "d1-1", string(mqttMsg.CorrelationData))
+
+		_, err = node.mqttMessage(map[string]interface{}{"metadata": map[string]string{"qos": "3"}}, msg)
+		assert.NotNil(t, err)
+		_, err = node.mqttMessage(map[string]interface{}{"metadata": map[string]string{"retain": "x"}}, msg)
+		assert.NotNil(t, err)
+	})
+
+	t.Run("Publish", func(t *testing.T) {
+		node := &MqttClientNode{}
+		err := node.Init(config, types.Configuration{
+			"server":          broker.Addr(),
+			"protocolVersion": 5,
+			"topic":           "/device/${metadata.deviceId}",
+			"qosTemplate":     "${metadata.qos}",
+			"retain":          "${metadata.retain}",
+			"userProperties":  map[string]string{"deviceId": "${metadata.deviceId}"},
+		})
+		assert.Nil(t, err)
+		defer node.Destroy()
+		assert.Equal(t, defaultMqttPublishTimeout, node.Config.PublishTimeout)
+		node.OnMsg(ctx, newMsg(`{"temperature":41}`))
+		assert.Equal(t, types.Success, relationType)
+		published := broker.Published()
+		last := published[len(published)-1]
+		assert.Equal(t, "/device/d1", last.Topic)
+		assert.Equal(t, byte(2), last.QoS)
+		assert.True(t, last.Retain)
+		assert.Equal(t, "d1", last.Properties.User[0].Value)
+	})
+
+	t.Run("PublishTimeout", func(t *testing.T) {
+		node := &MqttClientNode{}
+		err := node.Init(config, types.Configuration{
+			"server":          broker.Addr(),
+			"protocolVersion": 5,
+			"topic":           "/device/${metadata.deviceId}",
+			"qos":             1,
+			"publishTimeout":  1,
+		})
+		assert.Nil(t, err)
+		defer node.Destroy()
+		_, err = node.SharedNode.GetSafely()
+		assert.Nil(t, err)
+		//broker 不回复确认，超时后通过 Failure 链发送
+		broker.SetNoAck(true)
+		defer broker.SetNoAck(false)
+		start := time.Now()
+		node.OnMsg(ctx, types.NewMsg(0, "TELEMETRY", types.JSON, types.NewMetadata(), "{}"))
+		assert.Equal(t, types.Failure, relationType)
+		assert.True(t, time.Since(start) < 3*time.Second)
+	})
+
+	t.Run("Request", func(t *testing.T) {
+		node := &MqttClientNode{}
+		err := node.Init(config, types.Configuration{
+			"server":          broker.Addr(),
+			"protocolVersion": 5,
+			"topic":           "/device/${metadata.deviceId}/cmd",
+			"qos":             1,
+			"responseTopic":   "/reply/rulego",
+			"responseTimeout": 1,
+		})
+		assert.Nil(t, err)
+		defer node.Destroy()
+		_, err = node.SharedNode.GetSafely()
+		assert.Nil(t, err)
+		//不含变量的响应主题在初始化时订阅
+		assert.True(t, broker.Subscribed("/reply/rulego"))
+
+		node.OnMsg(ctx, newMsg("ping"))
+		assert.Equal(t, types.Success, relationType)
+		assert.Equal(t, `{"result":"ok"}`, outMsg.GetData())
+		assert.Equal(t, "200", outMsg.Metadata.GetValue("status"))
+		assert.Equal(t, "/reply/rulego", outMsg.Metadata.GetValue(MqttTopicMetadataKey))
+		assert.Equal(t, 16, len(outMsg.Metadata.GetValue(MqttCorrelationDataMetadataKey)))
+		assert.True(t, broker.Subscribed("/reply/rulego"))
+
+		//响应超时
+		node.OnMsg(ctx, newMsg("slow"))
+		assert.Equal(t, types.Failure, relationType)
+		assert.Equal(t, "slow", outMsg.GetData())
+	})
+
+	t.Run("ResponseTopicRefs", func(t *testing.T) {
+		client, err := mqtt.NewClientV5(context.Background(), mqtt.Config{Server: broker.Addr(), ClientID: "refs"})
+		assert.Nil(t, err)
+		defer client.Close()
+		//并发订阅同一响应主题只向broker订阅一次，全部释放后取消订阅
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				assert.Nil(t, client.SubscribeResponseTopic(context.Background(), "/reply/refs"))
+			}()
+		}
+		wg.Wait()
+		assert.True(t, broker.Subscribed("/reply/refs"))
+		for i := 0; i < 9; i++ {
+			client.UnsubscribeResponseTopic("/reply/refs")
+		}
+		assert.True(t, broker.Subscribed("/reply/refs"))
+		client.UnsubscribeResponseTopic("/reply/refs")
+		assert.False(t, broker.Subscribed("/reply/refs"))
+		//取消订阅后可以重新订阅
+		assert.Nil(t, client.SubscribeResponseTopic(context.Background(), "/reply/refs"))
+		assert.True(t, broker.Subscribed("/reply/refs"))
+		client.UnsubscribeResponseTopic("/reply/refs")
+	})
+
+	t.Run("SharedClient", func(t *testing.T) {
+		client, err := mqtt.NewClientV5(context.Background(), mqtt.Config{Server: broker.Addr(), ClientID: "shared"})
+		assert.Nil(t, err)
+		defer client.Close()
+		poolConfig := types.NewConfig(types.WithNodePool(&testNodePool{instance: client}))
+		newNode := func(configuration types.Configuration) *MqttClientNode {
+			node := &MqttClientNode{}
+			configuration["server"] = "ref://mqtt01"
+			assert.Nil(t, node.Init(poolConfig, configuration))
+			return node
+		}
+		_, err = newNode(types.Configuration{}).getClient()
+		assert.Nil(t, err)
+		_, err = newNode(types.Configuration{"protocolVersion": 5, "clientId": "shared"}).getClient()
+		assert.Nil(t, err)
+		//共享客户端的协议版本或者客户端ID与节点配置不一致
+		_, err = newNode(types.Configuration{"protocolVersion": 4}).getClient()
+		assert.NotNil(t, err)
+		_, err = newNode(types.Configuration{"clientId": "other"}).getClient()
+		assert.NotNil(t, err)
+	})
+}
+
+// testNodePool 只支持 GetInstance 的节点池
+type testNodePool struct {
+	types.NodePool
+	instance interface{}
+}
+
+func (p *testNodePool) GetInstance(id string) (interface{}, error) {
+	return p.instance, nil
+}
@@ -17,12 +17,18 @@
 package external
 
 import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"net"
+	"sync"
 	"sync/atomic"
 	"time"
 
 	"github.com/yunboom/rulego/api/types"
 	"github.com/yunboom/rulego/components/base"
+	"github.com/yunboom/rulego/utils/codec"
 	"github.com/yunboom/rulego/utils/maps"
 )
 
@@ -32,6 +38,9 @@ const EndSign = '\n'
 // PingData ping内容
 var PingData = []byte("ping\n")
 
+// ResponseBufferSize 编解码器不能切分帧时，读取响应的缓冲区大小
+const ResponseBufferSize = 4096
+
 // 注册节点
 func init() {
 	Registry.Add(&NetNode{})
@@ -47,6 +56,14 @@ type NetNodeConfiguration struct {
 	ConnectTimeout int
 	// 心跳间隔，用于定期发送心跳消息，单位为秒，如果=0，则不发心跳包。默认60
 	HeartbeatInterval int
+	// 协议编解码器名称，例如 modbusTcp、binaryStruct。配置后消息(JSON)被编码成帧发送，并且不发送心跳包
+	Codec string
+	// 协议编解码器配置，见 codec 包中各编解码器的配置
+	CodecConfig types.Configuration
+	// 是否读取服务器的响应，需要配置 Codec。响应帧被解码成 JSON 作为新的消息内容
+	ReadResponse bool
+	// 读取响应超时，单位为秒，如果<=0 则默认10
+	ReadTimeout int
 }
 
 // NetNode provides network protocol communication capabilities for sending messages over various protocols.
@@ -64,7 +81,11 @@ type NetNodeConfiguration struct {
 //		"protocol": "tcp",              // Network protocol  网络协议
 //		"server": "192.168.1.100:8080", // Server address  服务器地址
 //		"connectTimeout": 30,           // Connection timeout in seconds  连接超时（秒）
-//		"heartbeatInterval": 60         // Heartbeat interval in seconds (0=disabled)  心跳间隔（秒，0=禁用）
+//		"heartbeatInterval": 60,        // Heartbeat interval in seconds (0=disabled)  心跳间隔（秒，0=禁用）
+//		"codec": "modbusTcp",           // Protocol codec name (optional)  协议编解码器名称（可选）
+//		"codecConfig": {"role": "client"}, // Protocol codec configuration  协议编解码器配置
+//		"readResponse": true,           // Read and decode the response frame  读取并解码响应帧
+//		"readTimeout": 10               // Response read timeout in seconds  读取响应超时（秒）
 //	}
 //
 // Supported Protocols:
@@ -91,6 +112,16 @@ type NetNodeConfiguration struct {
 //   - JSON/TEXT: Uses GetData(), appends newline terminator ('\n')
 //     JSON/文本：使用 GetData()，追加换行符终止符（'\n'）
 //
+// Protocol Codecs:
+// 协议编解码器：
+//
+// When codec is configured, the JSON message is encoded into a frame by the codec registered
+// in the codec package and no heartbeat is sent. With readResponse, the response frame is
+// decoded into JSON and becomes the data of the outgoing message.
+//
+// 配置 codec 后，JSON 消息由 codec 包中注册的编解码器编码成帧发送，并且不发送心跳包。
+// 开启 readResponse 后，响应帧被解码成 JSON 作为输出消息的内容。
+//
 // Message Format:
 // 消息格式：
 //
@@ -187,6 +218,16 @@ type NetNode struct {
 	disconnected int32
 	//断开连接次数
 	disconnectedCount int32
+	// 协议编解码器
+	codec codec.Codec
+}
+
+// sharedConn 共享的连接，读取响应的缓冲读取器和请求锁随连接一起共享，
+// 保证共享同一个连接的节点请求和响应一一对应
+type sharedConn struct {
+	net.Conn
+	reader      *bufio.Reader
+	requestLock sync.Mutex
 }
 
 // Type 组件类型
@@ -210,6 +251,18 @@ func (x *NetNode) Init(ruleConfig types.Config, configuration types.Configuratio
 	}
 	// 设置默认值
 	x.setDefaultConfig()
+	if x.Config.ReadResponse && x.Config.Codec == "" {
+		return errors.New("readResponse requires codec")
+	}
+	if x.Config.Codec != "" {
+		c, err := codec.Registry.New(x.Config.Codec, x.Config.CodecConfig)
+		if err != nil {
+			return err
+		}
+		x.codec = c
+		// 二进制协议不能插入心跳数据
+		x.Config.HeartbeatInterval = 0
+	}
 	x.heartbeatDuration = time.Duration(x.Config.HeartbeatInterval) * time.Second
 	return x.SharedNode.InitWithClose(ruleConfig, x.Type(), x.Config.Server, ruleConfig.NodeClientInitNow, x.initConnect, func(conn net.Conn) error {
 		// 清理回调函数：关闭连接并清理相关状态
@@ -220,6 +273,10 @@ func (x *NetNode) Init(ruleConfig types.Config, configuration types.Configuratio
 
 // OnMsg 处理消息
 func (x *NetNode) OnMsg(ctx types.RuleContext, msg types.RuleMsg) {
+	if x.codec != nil {
+		x.onCodecMsg(ctx, msg)
+		return
+	}
 	var data []byte
 
 	// 根据数据类型智能处理
@@ -263,7 +320,7 @@ func (x *NetNode) initConnect() (net.Conn, error) {
 			x.heartbeatTimer.Reset(x.heartbeatDuration)
 		}
 	}
-	return conn, nil
+	return &sharedConn{Conn: conn, reader: bufio.NewReader(conn)}, nil
 }
 
 // 重连
@@ -321,6 +378,91 @@ func (x *NetNode) onWrite(ctx types.RuleContext, msg types.RuleMsg, data []byte)
 	}
 }
 
+// onCodecMsg 使用协议编解码器把 JSON 消息编码成帧发送
+func (x *NetNode) onCodecMsg(ctx types.RuleContext, msg types.RuleMsg) {
+	var data map[string]interface{}
+	if err := json.Unmarshal([]byte(msg.GetData()), &data); err != nil {
+		ctx.TellFailure(msg, fmt.Errorf("codec encode err: %w", err))
+		return
+	}
+	frame, err := x.codec.Encode(data)
+	if err != nil {
+		ctx.TellFailure(msg, fmt.Errorf("codec encode err: %w", err))
+		return
+	}
+	if x.Config.ReadResponse {
+		x.onRequest(ctx, msg, frame)
+		return
+	}
+	x.onWrite(ctx, msg, frame)
+}
+
+// onRequest 发送请求帧并读取一帧响应，解码成 JSON 后发送到下一个节点
+func (x *NetNode) onRequest(ctx types.RuleContext, msg types.RuleMsg, frame []byte) {
+	conn, err := x.SharedNode.GetSafely()
+	if err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	c, ok := conn.(*sharedConn)
+	if !ok {
+		ctx.TellFailure(msg, errors.New("connection does not support reading response"))
+		return
+	}
+	c.requestLock.Lock()
+	if _, err = c.Write(frame); err != nil {
+		c.requestLock.Unlock()
+		retry := atomic.LoadInt32(&x.disconnectedCount) == 0
+		x.setDisconnected(true)
+		if retry {
+			//重试一次
+			x.onRequest(ctx, msg, frame)
+		} else {
+			ctx.TellFailure(msg, err)
+		}
+		return
+	}
+	data, err := x.readResponse(c)
+	c.requestLock.Unlock()
+	if err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	msg.DataType = types.JSON
+	msg.SetData(data)
+	ctx.TellSuccess(msg)
+}
+
+// readResponse 读取一帧响应并解码成 JSON，调用方需持有连接的请求锁
+func (x *NetNode) readResponse(c *sharedConn) (string, error) {
+	_ = c.SetReadDeadline(time.Now().Add(time.Duration(x.Config.ReadTimeout) * time.Second))
+	defer func() {
+		_ = c.SetReadDeadline(time.Time{})
+	}()
+	var frame []byte
+	var err error
+	if splitter, ok := x.codec.(codec.Splitter); ok {
+		frame, err = splitter.ReadPacket(c.reader)
+	} else {
+		buf := make([]byte, ResponseBufferSize)
+		var n int
+		n, err = c.reader.Read(buf)
+		frame = buf[:n]
+	}
+	if err != nil {
+		return "", err
+	}
+	data, err := x.codec.Decode(frame)
+	if err != nil {
+		return "", fmt.Errorf("codec decode err: %w", err)
+	}
+	body, err := json.Marshal(data)
+	if err != nil {
+		return "", err
+	}
+	return string(body), nil
+}
+
 func (x *NetNode) onDisconnect() {
 	// 停止心跳定时器
 	if x.heartbeatTimer != nil {
@@ -354,4 +496,7 @@ func (x *NetNode) setDefaultConfig() {
 	if x.Config.HeartbeatInterval < 0 {
 		x.Config.HeartbeatInterval = 60
 	}
+	if x.Config.ReadTimeout <= 0 {
+		x.Config.ReadTimeout = 10
+	}
 }
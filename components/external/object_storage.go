@@ -0,0 +1,316 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package external
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// ObjectStorageDriverLocal 本地目录存储驱动
+	ObjectStorageDriverLocal = "local"
+	// ObjectStorageDriverS3 S3兼容API存储驱动
+	ObjectStorageDriverS3 = "s3"
+	// defaultContentType 默认的内容类型
+	defaultContentType = "application/octet-stream"
+	// localTempPrefix 本地驱动写入时的临时文件前缀，列出对象时跳过
+	localTempPrefix = ".rulego-tmp-"
+)
+
+var (
+	// ErrObjectNotFound 对象不存在
+	ErrObjectNotFound = errors.New("object not found")
+	// ErrInvalidObjectKey 对象key不合法
+	ErrInvalidObjectKey = errors.New("invalid object key")
+)
+
+// objectStorageDrivers 已注册的存储驱动
+var objectStorageDrivers = map[string]func(config ObjectStorageConfiguration) (ObjectStorageDriver, error){
+	ObjectStorageDriverLocal: newLocalObjectStorage,
+	ObjectStorageDriverS3:    newS3ObjectStorage,
+}
+var objectStorageDriversLock sync.RWMutex
+
+// RegisterObjectStorageDriver 注册存储驱动，已存在则覆盖，在对象存储节点配置中通过Storage.Driver选择
+func RegisterObjectStorageDriver(name string, newDriver func(config ObjectStorageConfiguration) (ObjectStorageDriver, error)) {
+	objectStorageDriversLock.Lock()
+	defer objectStorageDriversLock.Unlock()
+	objectStorageDrivers[name] = newDriver
+}
+
+// newObjectStorageDriver 根据配置创建存储驱动
+func newObjectStorageDriver(config ObjectStorageConfiguration) (ObjectStorageDriver, error) {
+	objectStorageDriversLock.RLock()
+	newDriver, ok := objectStorageDrivers[config.Driver]
+	objectStorageDriversLock.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unsupported object storage driver: %s", config.Driver)
+	}
+	return newDriver(config)
+}
+
+// ObjectStorageDriver 对象存储驱动
+type ObjectStorageDriver interface {
+	// Put 写入对象，从body读取size字节，已存在则覆盖
+	Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error
+	// Get 读取对象，调用方负责关闭返回的io.ReadCloser，对象不存在返回ErrObjectNotFound
+	Get(ctx context.Context, key string) (io.ReadCloser, ObjectInfo, error)
+	// List 按key顺序列出前缀匹配的对象，limit<=0表示不限制
+	List(ctx context.Context, prefix string, limit int) ([]ObjectInfo, error)
+	// Delete 删除对象，对象不存在不返回错误
+	Delete(ctx context.Context, key string) error
+	// Close 释放资源
+	Close() error
+}
+
+// ObjectInfo 对象信息
+type ObjectInfo struct {
+	// Key 对象key
+	Key string `json:"key"`
+	// Size 对象大小，单位字节
+	Size int64 `json:"size"`
+	// ContentType 内容类型
+	ContentType string `json:"contentType,omitempty"`
+	// ETag 对象版本标识
+	ETag string `json:"etag,omitempty"`
+	// LastModified 最后修改时间
+	LastModified time.Time `json:"lastModified"`
+}
+
+// ObjectStorageConfiguration 对象存储配置
+type ObjectStorageConfiguration struct {
+	// Driver 存储驱动，local:本地目录，s3:S3兼容API，默认local
+	Driver string
+	// Dir local驱动的根目录
+	Dir string
+	// Endpoint s3驱动的服务地址，例如：https://s3.us-east-1.amazonaws.com 或者 http://127.0.0.1:9000
+	Endpoint string
+	// Region s3驱动的区域，默认us-east-1
+	Region string
+	// Bucket s3驱动的存储桶
+	Bucket string
+	// AccessKeyId s3驱动的访问密钥ID
+	AccessKeyId string
+	// SecretAccessKey s3驱动的访问密钥
+	SecretAccessKey string
+	// SessionToken s3驱动的临时会话令牌，可选
+	SessionToken string
+	// VirtualHostStyle s3驱动是否使用虚拟主机风格(bucket.endpoint)访问，默认使用路径风格(endpoint/bucket)
+	VirtualHostStyle bool
+	// PartSize s3驱动分片上传的分片大小，单位字节，超过该大小的对象使用分片上传，默认16MB，最小5MB
+	PartSize int64
+	// Timeout s3驱动的请求超时，单位秒，默认0不限制，大对象传输请使用ctx控制超时
+	Timeout int
+}
+
+// resourcePath 共享客户端的资源路径
+func (c ObjectStorageConfiguration) resourcePath() string {
+	if c.Driver == ObjectStorageDriverS3 {
+		return c.Endpoint
+	}
+	return c.Dir
+}
+
+// localObjectStorage 本地目录存储驱动，对象key中的 / 映射为子目录
+// 本地文件系统不保存内容类型，读取时根据扩展名推断
+type localObjectStorage struct {
+	root string
+}
+
+func newLocalObjectStorage(config ObjectStorageConfiguration) (ObjectStorageDriver, error) {
+	if config.Dir == "" {
+		return nil, errors.New("dir can not be empty")
+	}
+	root, err := filepath.Abs(config.Dir)
+	if err != nil {
+		return nil, err
+	}
+	if err = os.MkdirAll(root, 0755); err != nil {
+		return nil, err
+	}
+	return &localObjectStorage{root: root}, nil
+}
+
+// path 转换成本地文件路径，不允许访问根目录之外的文件
+func (s *localObjectStorage) path(key string) (string, error) {
+	if key == "" || strings.HasSuffix(key, "/") || strings.Contains(key, "\\") {
+		return "", ErrInvalidObjectKey
+	}
+	cleaned := path.Clean("/" + key)
+	if cleaned != "/"+strings.TrimPrefix(key, "/") {
+		return "", ErrInvalidObjectKey
+	}
+	if strings.HasPrefix(path.Base(cleaned), localTempPrefix) {
+		return "", ErrInvalidObjectKey
+	}
+	return filepath.Join(s.root, filepath.FromSlash(cleaned)), nil
+}
+
+// Put 先写入同目录的临时文件再重命名，读取方不会读到不完整的对象
+func (s *localObjectStorage) Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error {
+	name, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(name), localTempPrefix+"*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	n, err := io.Copy(tmp, &contextReader{ctx: ctx, r: body})
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	if size >= 0 && n != size {
+		return fmt.Errorf("object size mismatch, expected %d, written %d", size, n)
+	}
+	return os.Rename(tmp.Name(), name)
+}
+
+func (s *localObjectStorage) Get(ctx context.Context, key string) (io.ReadCloser, ObjectInfo, error) {
+	name, err := s.path(key)
+	if err != nil {
+		return nil, ObjectInfo{}, err
+	}
+	f, err := os.Open(name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ObjectInfo{}, ErrObjectNotFound
+	} else if err != nil {
+		return nil, ObjectInfo{}, err
+	}
+	stat, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return nil, ObjectInfo{}, err
+	}
+	if stat.IsDir() {
+		_ = f.Close()
+		return nil, ObjectInfo{}, ErrObjectNotFound
+	}
+	return f, s.objectInfo(key, stat), nil
+}
+
+// List 从前缀所在的最深目录开始遍历
+func (s *localObjectStorage) List(ctx context.Context, prefix string, limit int) ([]ObjectInfo, error) {
+	dir := s.root
+	if i := strings.LastIndex(prefix, "/"); i >= 0 {
+		if name, err := s.path(prefix[:i] + "/_"); err == nil {
+			dir = filepath.Dir(name)
+		} else {
+			return nil, err
+		}
+	}
+	var result []ObjectInfo
+	err := filepath.WalkDir(dir, func(name string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+		if entry.IsDir() || strings.HasPrefix(entry.Name(), localTempPrefix) {
+			return nil
+		}
+		rel, err := filepath.Rel(s.root, name)
+		if err != nil {
+			return err
+		}
+		key := filepath.ToSlash(rel)
+		if !strings.HasPrefix(key, prefix) {
+			return nil
+		}
+		stat, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		result = append(result, s.objectInfo(key, stat))
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	//WalkDir按文件名顺序遍历，子目录中的key和同级文件交叉时需要重新排序
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Key < result[j].Key
+	})
+	if limit > 0 && len(result) > limit {
+		result = result[:limit]
+	}
+	return result, nil
+}
+
+func (s *localObjectStorage) Delete(ctx context.Context, key string) error {
+	name, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err = os.Remove(name); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+func (s *localObjectStorage) Close() error {
+	return nil
+}
+
+func (s *localObjectStorage) objectInfo(key string, stat fs.FileInfo) ObjectInfo {
+	contentType := mime.TypeByExtension(path.Ext(key))
+	if contentType == "" {
+		contentType = defaultContentType
+	}
+	return ObjectInfo{
+		Key:          key,
+		Size:         stat.Size(),
+		ContentType:  contentType,
+		LastModified: stat.ModTime(),
+	}
+}
+
+// contextReader ctx取消后停止读取
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (r *contextReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return r.r.Read(p)
+}
@@ -0,0 +1,474 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package external
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"mime"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/yunboom/rulego/api/types"
+	"github.com/yunboom/rulego/components/base"
+	"github.com/yunboom/rulego/utils/el"
+	"github.com/yunboom/rulego/utils/maps"
+	"github.com/yunboom/rulego/utils/str"
+)
+
+func init() {
+	Registry.Add(&ObjectPutNode{})
+	Registry.Add(&ObjectGetNode{})
+	Registry.Add(&ObjectListNode{})
+	Registry.Add(&ObjectDeleteNode{})
+}
+
+const (
+	// defaultObjectMaxSize objectGet默认读取的最大对象大小
+	defaultObjectMaxSize = 64 * 1024 * 1024
+	// defaultObjectListLimit objectList默认列出的最大对象个数
+	defaultObjectListLimit = 1000
+
+	// objectKeyKey 对象key
+	objectKeyKey = "objectKey"
+	// objectSizeKey 对象大小
+	objectSizeKey = "objectSize"
+	// objectContentTypeKey 对象内容类型
+	objectContentTypeKey = "objectContentType"
+	// objectETagKey 对象版本标识
+	objectETagKey = "objectETag"
+	// objectLastModifiedKey 对象最后修改时间，RFC3339格式
+	objectLastModifiedKey = "objectLastModified"
+	// objectCountKey objectList列出的对象个数
+	objectCountKey = "objectCount"
+)
+
+// ErrObjectTooLarge 对象超过objectGet配置的最大大小
+var ErrObjectTooLarge = errors.New("object is too large")
+
+// objectStorageNode 对象存储节点的公共部分，同一个存储配置的节点共享存储驱动
+type objectStorageNode struct {
+	base.SharedNode[ObjectStorageDriver]
+}
+
+// initStorage 初始化存储驱动
+func (x *objectStorageNode) initStorage(ruleConfig types.Config, nodeType string, config *ObjectStorageConfiguration) error {
+	if config.Driver == "" {
+		config.Driver = ObjectStorageDriverLocal
+	}
+	storageConfig := *config
+	return x.SharedNode.InitWithClose(ruleConfig, nodeType, config.resourcePath(), ruleConfig.NodeClientInitNow, func() (ObjectStorageDriver, error) {
+		return newObjectStorageDriver(storageConfig)
+	}, func(driver ObjectStorageDriver) error {
+		return driver.Close()
+	})
+}
+
+// objectContext 获取规则上下文的ctx，取消后停止传输
+func objectContext(ctx types.RuleContext) context.Context {
+	if c := ctx.GetContext(); c != nil {
+		return c
+	}
+	return context.Background()
+}
+
+// newKeyTemplate 创建对象key模板
+func newKeyTemplate(key string, opts ...el.TemplateOption) (*el.MixedTemplate, error) {
+	if key == "" {
+		return nil, errors.New("key can not be empty")
+	}
+	return el.NewMixedTemplate(key, opts...)
+}
+
+// ObjectPutNodeConfiguration objectPut节点配置
+type ObjectPutNodeConfiguration struct {
+	// Storage 存储配置
+	Storage ObjectStorageConfiguration
+	// Key 对象key，可以使用 ${metadata.key}、${msg.key}、${ts}、${id} 等变量，例如：${metadata.deviceId}/${ts}.json
+	Key string
+	// ContentType 内容类型，可以使用变量，为空则根据key的扩展名推断，无法推断时根据消息数据类型推断
+	ContentType string
+}
+
+// ObjectPutNode 把消息负荷写入对象存储
+// ObjectPutNode writes the message payload to the object storage.
+//
+// 消息负荷的字节直接作为对象内容，不做编码转换，大于分片大小的对象使用s3分片上传，每次只读取一个分片。
+// The payload bytes are written as is, objects larger than the part size are uploaded in parts by the s3 driver, one part at a time.
+//
+// 内容类型 - Content type:
+//   - 配置ContentType时使用配置的值 - ContentType when configured
+//   - 否则根据key的扩展名推断，例如.json为application/json - Otherwise inferred from the key extension, e.g. application/json for .json
+//   - 否则根据消息数据类型推断：JSON为application/json，TEXT为text/plain，BINARY为application/octet-stream
+//     Otherwise inferred from the message data type: application/json for JSON, text/plain for TEXT, application/octet-stream for BINARY
+//
+// 成功后元数据objectKey为对象key，objectSize为对象大小，objectContentType为内容类型，转到Success链，否则转到Failure链。
+// On success metadata objectKey, objectSize and objectContentType are set and the message goes to Success, otherwise to Failure.
+//
+// 配置示例 - Configuration example:
+//
+//	{
+//		"storage": {"driver": "s3", "endpoint": "http://127.0.0.1:9000", "bucket": "archive", "accessKeyId": "minio", "secretAccessKey": "minio123"},
+//		"key": "${metadata.deviceId}/${ts}.json"
+//	}
+type ObjectPutNode struct {
+	objectStorageNode
+	// 节点配置
+	Config              ObjectPutNodeConfiguration
+	keyTemplate         *el.MixedTemplate
+	contentTypeTemplate *el.MixedTemplate
+}
+
+// Type 组件类型
+func (x *ObjectPutNode) Type() string {
+	return "objectPut"
+}
+
+func (x *ObjectPutNode) New() types.Node {
+	return &ObjectPutNode{Config: ObjectPutNodeConfiguration{
+		Storage: ObjectStorageConfiguration{Driver: ObjectStorageDriverLocal, Dir: "./data/objects"},
+		Key:     "${metadata.deviceId}/${ts}.json",
+	}}
+}
+
+// Init 初始化
+func (x *ObjectPutNode) Init(ruleConfig types.Config, configuration types.Configuration) error {
+	err := maps.Map2Struct(configuration, &x.Config)
+	if err != nil {
+		return err
+	}
+	if x.keyTemplate, err = newKeyTemplate(x.Config.Key, el.WithUdf(ruleConfig.Udf)); err != nil {
+		return err
+	}
+	if x.Config.ContentType != "" {
+		if x.contentTypeTemplate, err = el.NewMixedTemplate(x.Config.ContentType, el.WithUdf(ruleConfig.Udf)); err != nil {
+			return err
+		}
+	}
+	return x.initStorage(ruleConfig, x.Type(), &x.Config.Storage)
+}
+
+// OnMsg 处理消息
+func (x *ObjectPutNode) OnMsg(ctx types.RuleContext, msg types.RuleMsg) {
+	evn := base.NodeUtils.GetEvnAndMetadata(ctx, msg)
+	key := x.keyTemplate.ExecuteAsString(evn)
+	var contentType string
+	if x.contentTypeTemplate != nil {
+		contentType = x.contentTypeTemplate.ExecuteAsString(evn)
+	}
+	if contentType == "" {
+		contentType = inferContentType(key, msg.DataType)
+	}
+	driver, err := x.SharedNode.GetSafely()
+	if err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	data := msg.GetBytes()
+	if err = driver.Put(objectContext(ctx), key, bytes.NewReader(data), int64(len(data)), contentType); err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	msg.Metadata.PutValue(objectKeyKey, key)
+	msg.Metadata.PutValue(objectSizeKey, str.ToString(len(data)))
+	msg.Metadata.PutValue(objectContentTypeKey, contentType)
+	ctx.TellSuccess(msg)
+}
+
+// Destroy 销毁
+func (x *ObjectPutNode) Destroy() {
+	_ = x.SharedNode.Close()
+}
+
+// ObjectGetNodeConfiguration objectGet节点配置
+type ObjectGetNodeConfiguration struct {
+	// Storage 存储配置
+	Storage ObjectStorageConfiguration
+	// Key 对象key，可以使用 ${metadata.key} 读取元数据中的变量或者使用 ${msg.key} 读取消息负荷中的变量进行替换
+	Key string
+	// MaxSize 读取的最大对象大小，单位字节，超过则转到Failure链，默认64MB
+	MaxSize int64
+}
+
+// ObjectGetNode 读取对象，把对象内容作为消息负荷
+// ObjectGetNode reads the object and replaces the message payload with the object content.
+//
+// 消息数据类型根据内容类型设置：application/json为JSON，text/*为TEXT，其他为BINARY。
+// The message data type is set by the content type: JSON for application/json, TEXT for text/*, BINARY for others.
+//
+// 成功后元数据objectKey、objectSize、objectContentType、objectETag、objectLastModified为对象信息，转到Success链，
+// 对象不存在或者超过MaxSize时转到Failure链。
+// On success the object info is set to metadata objectKey, objectSize, objectContentType, objectETag and objectLastModified and
+// the message goes to Success. Missing objects and objects larger than MaxSize go to Failure.
+type ObjectGetNode struct {
+	objectStorageNode
+	// 节点配置
+	Config      ObjectGetNodeConfiguration
+	keyTemplate *el.MixedTemplate
+}
+
+// Type 组件类型
+func (x *ObjectGetNode) Type() string {
+	return "objectGet"
+}
+
+func (x *ObjectGetNode) New() types.Node {
+	return &ObjectGetNode{Config: ObjectGetNodeConfiguration{
+		Storage: ObjectStorageConfiguration{Driver: ObjectStorageDriverLocal, Dir: "./data/objects"},
+		Key:     "${metadata.objectKey}",
+		MaxSize: defaultObjectMaxSize,
+	}}
+}
+
+// Init 初始化
+func (x *ObjectGetNode) Init(ruleConfig types.Config, configuration types.Configuration) error {
+	err := maps.Map2Struct(configuration, &x.Config)
+	if err != nil {
+		return err
+	}
+	if x.Config.MaxSize <= 0 {
+		x.Config.MaxSize = defaultObjectMaxSize
+	}
+	if x.keyTemplate, err = newKeyTemplate(x.Config.Key, el.WithUdf(ruleConfig.Udf)); err != nil {
+		return err
+	}
+	return x.initStorage(ruleConfig, x.Type(), &x.Config.Storage)
+}
+
+// OnMsg 处理消息
+func (x *ObjectGetNode) OnMsg(ctx types.RuleContext, msg types.RuleMsg) {
+	key := x.keyTemplate.ExecuteAsString(base.NodeUtils.GetEvnAndMetadata(ctx, msg))
+	driver, err := x.SharedNode.GetSafely()
+	if err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	body, info, err := driver.Get(objectContext(ctx), key)
+	if err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	defer body.Close()
+	if info.Size > x.Config.MaxSize {
+		ctx.TellFailure(msg, ErrObjectTooLarge)
+		return
+	}
+	var buf bytes.Buffer
+	if info.Size > 0 {
+		buf.Grow(int(info.Size))
+	}
+	//多读取一个字节，判断是否超过最大大小
+	n, err := io.Copy(&buf, io.LimitReader(body, x.Config.MaxSize+1))
+	if err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	if n > x.Config.MaxSize {
+		ctx.TellFailure(msg, ErrObjectTooLarge)
+		return
+	}
+	msg.DataType = dataTypeOfContentType(info.ContentType)
+	msg.SetBytes(buf.Bytes())
+	msg.Metadata.PutValue(objectKeyKey, key)
+	msg.Metadata.PutValue(objectSizeKey, str.ToString(n))
+	msg.Metadata.PutValue(objectContentTypeKey, info.ContentType)
+	msg.Metadata.PutValue(objectETagKey, info.ETag)
+	if !info.LastModified.IsZero() {
+		msg.Metadata.PutValue(objectLastModifiedKey, info.LastModified.Format(time.RFC3339))
+	}
+	ctx.TellSuccess(msg)
+}
+
+// Destroy 销毁
+func (x *ObjectGetNode) Destroy() {
+	_ = x.SharedNode.Close()
+}
+
+// ObjectListNodeConfiguration objectList节点配置
+type ObjectListNodeConfiguration struct {
+	// Storage 存储配置
+	Storage ObjectStorageConfiguration
+	// Prefix key前缀，可以使用 ${metadata.key} 读取元数据中的变量或者使用 ${msg.key} 读取消息负荷中的变量进行替换，为空列出所有对象
+	Prefix string
+	// Limit 列出的最大对象个数，默认1000
+	Limit int
+}
+
+// ObjectListNode 按key顺序列出前缀匹配的对象
+// ObjectListNode lists the objects with the prefix in key order.
+//
+// 消息负荷为对象信息的JSON数组：[{"key":"d1/1.json","size":12,"contentType":"application/json","etag":"...","lastModified":"..."}]，
+// 元数据objectCount为对象个数。s3驱动列出对象时不返回内容类型。
+// The payload is replaced with the JSON array of the object info, metadata objectCount is the number of objects.
+// The s3 driver does not return the content type when listing.
+type ObjectListNode struct {
+	objectStorageNode
+	// 节点配置
+	Config         ObjectListNodeConfiguration
+	prefixTemplate *el.MixedTemplate
+}
+
+// Type 组件类型
+func (x *ObjectListNode) Type() string {
+	return "objectList"
+}
+
+func (x *ObjectListNode) New() types.Node {
+	return &ObjectListNode{Config: ObjectListNodeConfiguration{
+		Storage: ObjectStorageConfiguration{Driver: ObjectStorageDriverLocal, Dir: "./data/objects"},
+		Prefix:  "${metadata.deviceId}/",
+		Limit:   defaultObjectListLimit,
+	}}
+}
+
+// Init 初始化
+func (x *ObjectListNode) Init(ruleConfig types.Config, configuration types.Configuration) error {
+	err := maps.Map2Struct(configuration, &x.Config)
+	if err != nil {
+		return err
+	}
+	if x.Config.Limit <= 0 {
+		x.Config.Limit = defaultObjectListLimit
+	}
+	if x.prefixTemplate, err = el.NewMixedTemplate(x.Config.Prefix, el.WithUdf(ruleConfig.Udf)); err != nil {
+		return err
+	}
+	return x.initStorage(ruleConfig, x.Type(), &x.Config.Storage)
+}
+
+// OnMsg 处理消息
+func (x *ObjectListNode) OnMsg(ctx types.RuleContext, msg types.RuleMsg) {
+	prefix := x.prefixTemplate.ExecuteAsString(base.NodeUtils.GetEvnAndMetadata(ctx, msg))
+	driver, err := x.SharedNode.GetSafely()
+	if err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	objects, err := driver.List(objectContext(ctx), prefix, x.Config.Limit)
+	if err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	if objects == nil {
+		objects = []ObjectInfo{}
+	}
+	msg.DataType = types.JSON
+	msg.SetData(str.ToString(objects))
+	msg.Metadata.PutValue(objectCountKey, str.ToString(len(objects)))
+	ctx.TellSuccess(msg)
+}
+
+// Destroy 销毁
+func (x *ObjectListNode) Destroy() {
+	_ = x.SharedNode.Close()
+}
+
+// ObjectDeleteNodeConfiguration objectDelete节点配置
+type ObjectDeleteNodeConfiguration struct {
+	// Storage 存储配置
+	Storage ObjectStorageConfiguration
+	// Key 对象key，可以使用 ${metadata.key} 读取元数据中的变量或者使用 ${msg.key} 读取消息负荷中的变量进行替换
+	Key string
+}
+
+// ObjectDeleteNode 删除对象，对象不存在也转到Success链
+// ObjectDeleteNode deletes the object, the message goes to Success even if the object does not exist.
+type ObjectDeleteNode struct {
+	objectStorageNode
+	// 节点配置
+	Config      ObjectDeleteNodeConfiguration
+	keyTemplate *el.MixedTemplate
+}
+
+// Type 组件类型
+func (x *ObjectDeleteNode) Type() string {
+	return "objectDelete"
+}
+
+func (x *ObjectDeleteNode) New() types.Node {
+	return &ObjectDeleteNode{Config: ObjectDeleteNodeConfiguration{
+		Storage: ObjectStorageConfiguration{Driver: ObjectStorageDriverLocal, Dir: "./data/objects"},
+		Key:     "${metadata.objectKey}",
+	}}
+}
+
+// Init 初始化
+func (x *ObjectDeleteNode) Init(ruleConfig types.Config, configuration types.Configuration) error {
+	err := maps.Map2Struct(configuration, &x.Config)
+	if err != nil {
+		return err
+	}
+	if x.keyTemplate, err = newKeyTemplate(x.Config.Key, el.WithUdf(ruleConfig.Udf)); err != nil {
+		return err
+	}
+	return x.initStorage(ruleConfig, x.Type(), &x.Config.Storage)
+}
+
+// OnMsg 处理消息
+func (x *ObjectDeleteNode) OnMsg(ctx types.RuleContext, msg types.RuleMsg) {
+	key := x.keyTemplate.ExecuteAsString(base.NodeUtils.GetEvnAndMetadata(ctx, msg))
+	driver, err := x.SharedNode.GetSafely()
+	if err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	if err = driver.Delete(objectContext(ctx), key); err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	msg.Metadata.PutValue(objectKeyKey, key)
+	ctx.TellSuccess(msg)
+}
+
+// Destroy 销毁
+func (x *ObjectDeleteNode) Destroy() {
+	_ = x.SharedNode.Close()
+}
+
+// inferContentType 根据key的扩展名或者消息数据类型推断内容类型
+func inferContentType(key string, dataType types.DataType) string {
+	if contentType := mime.TypeByExtension(path.Ext(key)); contentType != "" {
+		return contentType
+	}
+	switch dataType {
+	case types.JSON:
+		return "application/json"
+	case types.TEXT:
+		return "text/plain; charset=utf-8"
+	default:
+		return defaultContentType
+	}
+}
+
+// dataTypeOfContentType 根据内容类型获取消息数据类型
+func dataTypeOfContentType(contentType string) types.DataType {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediaType = strings.ToLower(contentType)
+	}
+	switch {
+	case mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"):
+		return types.JSON
+	case strings.HasPrefix(mediaType, "text/"):
+		return types.TEXT
+	default:
+		return types.BINARY
+	}
+}
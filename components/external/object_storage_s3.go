@@ -0,0 +1,425 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package external
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// defaultS3Region 默认区域
+	defaultS3Region = "us-east-1"
+	// defaultS3PartSize 默认分片大小
+	defaultS3PartSize = 16 * 1024 * 1024
+	// minS3PartSize S3要求除最后一个分片外，每个分片不小于5MB
+	minS3PartSize = 5 * 1024 * 1024
+	// s3ListPageSize 每次列出对象的最大个数
+	s3ListPageSize = 1000
+	// s3UnsignedPayload 对象内容不参与签名，流式上传时无需预先计算哈希
+	s3UnsignedPayload = "UNSIGNED-PAYLOAD"
+	// s3TimeFormat 签名时间格式
+	s3TimeFormat = "20060102T150405Z"
+)
+
+// s3ObjectStorage S3兼容API存储驱动，使用AWS Signature Version 4签名
+type s3ObjectStorage struct {
+	config   ObjectStorageConfiguration
+	endpoint *url.URL
+	client   *http.Client
+	// now 签名时间，测试时替换
+	now func() time.Time
+}
+
+func newS3ObjectStorage(config ObjectStorageConfiguration) (ObjectStorageDriver, error) {
+	if config.Bucket == "" {
+		return nil, errors.New("bucket can not be empty")
+	}
+	endpoint, err := url.Parse(config.Endpoint)
+	if err != nil {
+		return nil, err
+	}
+	if endpoint.Scheme == "" || endpoint.Host == "" {
+		return nil, fmt.Errorf("invalid endpoint: %s", config.Endpoint)
+	}
+	if config.Region == "" {
+		config.Region = defaultS3Region
+	}
+	if config.PartSize <= 0 {
+		config.PartSize = defaultS3PartSize
+	} else if config.PartSize < minS3PartSize {
+		config.PartSize = minS3PartSize
+	}
+	return &s3ObjectStorage{
+		config:   config,
+		endpoint: endpoint,
+		client:   &http.Client{Timeout: time.Duration(config.Timeout) * time.Second},
+		now:      time.Now,
+	}, nil
+}
+
+// Put 不超过分片大小的对象直接上传，否则使用分片上传，每次只读取一个分片
+func (s *s3ObjectStorage) Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error {
+	if key == "" {
+		return ErrInvalidObjectKey
+	}
+	if size >= 0 && size <= s.config.PartSize {
+		header := http.Header{"Content-Type": []string{contentType}}
+		resp, err := s.do(ctx, http.MethodPut, key, nil, header, body, size, s3UnsignedPayload)
+		if err != nil {
+			return err
+		}
+		return resp.Body.Close()
+	}
+	return s.multipartPut(ctx, key, body, contentType)
+}
+
+// multipartPut 分片上传，失败时取消上传，释放已上传的分片
+func (s *s3ObjectStorage) multipartPut(ctx context.Context, key string, body io.Reader, contentType string) error {
+	header := http.Header{"Content-Type": []string{contentType}}
+	var initResult struct {
+		UploadId string `xml:"UploadId"`
+	}
+	if err := s.doXml(ctx, http.MethodPost, key, url.Values{"uploads": {""}}, header, nil, &initResult); err != nil {
+		return err
+	}
+	uploadId := initResult.UploadId
+	complete := s3CompleteMultipartUpload{}
+	buf := make([]byte, s.config.PartSize)
+	err := func() error {
+		for partNumber := 1; ; partNumber++ {
+			n, readErr := io.ReadFull(body, buf)
+			if readErr != nil && readErr != io.ErrUnexpectedEOF && readErr != io.EOF {
+				return readErr
+			}
+			if n == 0 && partNumber > 1 {
+				return nil
+			}
+			query := url.Values{"partNumber": {strconv.Itoa(partNumber)}, "uploadId": {uploadId}}
+			resp, err := s.do(ctx, http.MethodPut, key, query, nil, bytes.NewReader(buf[:n]), int64(n), s3UnsignedPayload)
+			if err != nil {
+				return err
+			}
+			_ = resp.Body.Close()
+			complete.Parts = append(complete.Parts, s3CompletedPart{PartNumber: partNumber, ETag: resp.Header.Get("ETag")})
+			if readErr != nil {
+				return nil
+			}
+		}
+	}()
+	if err == nil {
+		var payload []byte
+		if payload, err = xml.Marshal(complete); err == nil {
+			err = s.doXml(ctx, http.MethodPost, key, url.Values{"uploadId": {uploadId}}, nil, payload, nil)
+		}
+	}
+	if err != nil {
+		//使用新的ctx取消上传，原ctx可能已经取消
+		if resp, abortErr := s.do(context.Background(), http.MethodDelete, key, url.Values{"uploadId": {uploadId}}, nil, nil, 0, ""); abortErr == nil {
+			_ = resp.Body.Close()
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *s3ObjectStorage) Get(ctx context.Context, key string) (io.ReadCloser, ObjectInfo, error) {
+	if key == "" {
+		return nil, ObjectInfo{}, ErrInvalidObjectKey
+	}
+	resp, err := s.do(ctx, http.MethodGet, key, nil, nil, nil, 0, "")
+	if err != nil {
+		return nil, ObjectInfo{}, err
+	}
+	info := ObjectInfo{
+		Key:         key,
+		Size:        resp.ContentLength,
+		ContentType: resp.Header.Get("Content-Type"),
+		ETag:        strings.Trim(resp.Header.Get("ETag"), `"`),
+	}
+	if info.ContentType == "" {
+		info.ContentType = defaultContentType
+	}
+	if lastModified, err := http.ParseTime(resp.Header.Get("Last-Modified")); err == nil {
+		info.LastModified = lastModified
+	}
+	return resp.Body, info, nil
+}
+
+// List 使用ListObjectsV2分页列出对象
+func (s *s3ObjectStorage) List(ctx context.Context, prefix string, limit int) ([]ObjectInfo, error) {
+	var result []ObjectInfo
+	var token string
+	for {
+		pageSize := s3ListPageSize
+		if limit > 0 && limit-len(result) < pageSize {
+			pageSize = limit - len(result)
+		}
+		query := url.Values{"list-type": {"2"}, "prefix": {prefix}, "max-keys": {strconv.Itoa(pageSize)}}
+		if token != "" {
+			query.Set("continuation-token", token)
+		}
+		var page struct {
+			Contents []struct {
+				Key          string    `xml:"Key"`
+				Size         int64     `xml:"Size"`
+				ETag         string    `xml:"ETag"`
+				LastModified time.Time `xml:"LastModified"`
+			} `xml:"Contents"`
+			IsTruncated           bool   `xml:"IsTruncated"`
+			NextContinuationToken string `xml:"NextContinuationToken"`
+		}
+		if err := s.doXml(ctx, http.MethodGet, "", query, nil, nil, &page); err != nil {
+			return nil, err
+		}
+		for _, item := range page.Contents {
+			result = append(result, ObjectInfo{
+				Key:          item.Key,
+				Size:         item.Size,
+				ETag:         strings.Trim(item.ETag, `"`),
+				LastModified: item.LastModified,
+			})
+		}
+		if !page.IsTruncated || page.NextContinuationToken == "" || (limit > 0 && len(result) >= limit) {
+			return result, nil
+		}
+		token = page.NextContinuationToken
+	}
+}
+
+func (s *s3ObjectStorage) Delete(ctx context.Context, key string) error {
+	if key == "" {
+		return ErrInvalidObjectKey
+	}
+	resp, err := s.do(ctx, http.MethodDelete, key, nil, nil, nil, 0, "")
+	if errors.Is(err, ErrObjectNotFound) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+func (s *s3ObjectStorage) Close() error {
+	s.client.CloseIdleConnections()
+	return nil
+}
+
+// doXml 发送请求并解析XML响应，payload参与签名
+func (s *s3ObjectStorage) doXml(ctx context.Context, method, key string, query url.Values, header http.Header, payload []byte, result interface{}) error {
+	resp, err := s.do(ctx, method, key, query, header, bytes.NewReader(payload), int64(len(payload)), sha256Hex(payload))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	//CompleteMultipartUpload 可能返回200，但响应体是错误
+	if bytes.Contains(data, []byte("<Error>")) {
+		return s3ResponseError(resp.StatusCode, data)
+	}
+	if result == nil {
+		return nil
+	}
+	return xml.Unmarshal(data, result)
+}
+
+// do 签名并发送请求，非2xx响应转换成错误，404转换成ErrObjectNotFound
+// payloadHash 为空时使用空内容的哈希
+func (s *s3ObjectStorage) do(ctx context.Context, method, key string, query url.Values, header http.Header, body io.Reader, size int64, payloadHash string) (*http.Response, error) {
+	u := *s.endpoint
+	host := u.Host
+	escapedPath := strings.TrimSuffix(u.EscapedPath(), "/")
+	if s.config.VirtualHostStyle {
+		host = s.config.Bucket + "." + host
+		escapedPath += "/" + s3EscapePath(key)
+	} else {
+		escapedPath += "/" + s3EscapePath(s.config.Bucket)
+		if key != "" {
+			escapedPath += "/" + s3EscapePath(key)
+		}
+	}
+	u.Host = host
+	u.RawPath = escapedPath
+	u.Path, _ = url.PathUnescape(escapedPath)
+	u.RawQuery = s3CanonicalQuery(query)
+
+	if body == nil {
+		body = http.NoBody
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), body)
+	if err != nil {
+		return nil, err
+	}
+	req.ContentLength = size
+	if size == 0 {
+		req.Body = http.NoBody
+	}
+	for k, v := range header {
+		if len(v) > 0 && v[0] != "" {
+			req.Header[k] = v
+		}
+	}
+	if payloadHash == "" {
+		payloadHash = sha256Hex(nil)
+	}
+	s.sign(req, escapedPath, payloadHash)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return resp, nil
+	}
+	defer resp.Body.Close()
+	//只有对象请求的404表示对象不存在，存储桶或者分片上传不存在时返回S3错误
+	if resp.StatusCode == http.StatusNotFound && key != "" && len(query) == 0 {
+		return nil, ErrObjectNotFound
+	}
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	return nil, s3ResponseError(resp.StatusCode, data)
+}
+
+// sign 使用AWS Signature Version 4签名请求
+func (s *s3ObjectStorage) sign(req *http.Request, escapedPath, payloadHash string) {
+	now := s.now().UTC()
+	amzDate := now.Format(s3TimeFormat)
+	date := amzDate[:8]
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	if s.config.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", s.config.SessionToken)
+	}
+	signedHeaders := []string{"host", "x-amz-content-sha256", "x-amz-date"}
+	if s.config.SessionToken != "" {
+		signedHeaders = append(signedHeaders, "x-amz-security-token")
+	}
+	var canonicalHeaders strings.Builder
+	for _, name := range signedHeaders {
+		value := req.Header.Get(name)
+		if name == "host" {
+			value = req.URL.Host
+		}
+		canonicalHeaders.WriteString(name + ":" + strings.TrimSpace(value) + "\n")
+	}
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		escapedPath,
+		req.URL.RawQuery,
+		canonicalHeaders.String(),
+		strings.Join(signedHeaders, ";"),
+		payloadHash,
+	}, "\n")
+	scope := date + "/" + s.config.Region + "/s3/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + sha256Hex([]byte(canonicalRequest))
+	key := hmacSha256([]byte("AWS4"+s.config.SecretAccessKey), date)
+	key = hmacSha256(key, s.config.Region)
+	key = hmacSha256(key, "s3")
+	key = hmacSha256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSha256(key, stringToSign))
+	req.Header.Set("Authorization", "AWS4-HMAC-SHA256 Credential="+s.config.AccessKeyId+"/"+scope+
+		", SignedHeaders="+strings.Join(signedHeaders, ";")+", Signature="+signature)
+}
+
+// s3CompleteMultipartUpload 完成分片上传请求体
+type s3CompleteMultipartUpload struct {
+	XMLName xml.Name          `xml:"CompleteMultipartUpload"`
+	Parts   []s3CompletedPart `xml:"Part"`
+}
+
+type s3CompletedPart struct {
+	PartNumber int    `xml:"PartNumber"`
+	ETag       string `xml:"ETag"`
+}
+
+// s3ResponseError 解析S3错误响应
+func s3ResponseError(statusCode int, data []byte) error {
+	var s3Err struct {
+		Code    string `xml:"Code"`
+		Message string `xml:"Message"`
+	}
+	if err := xml.Unmarshal(data, &s3Err); err == nil && s3Err.Code != "" {
+		return fmt.Errorf("s3 error, status: %d, code: %s, message: %s", statusCode, s3Err.Code, s3Err.Message)
+	}
+	return fmt.Errorf("s3 error, status: %d", statusCode)
+}
+
+// s3EscapePath 按照RFC 3986转义路径，保留 /
+func s3EscapePath(p string) string {
+	segments := strings.Split(p, "/")
+	for i, segment := range segments {
+		segments[i] = s3Escape(segment)
+	}
+	return strings.Join(segments, "/")
+}
+
+// s3Escape 按照RFC 3986转义，只保留非保留字符
+func s3Escape(s string) string {
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9') || c == '-' || c == '_' || c == '.' || c == '~' {
+			sb.WriteByte(c)
+		} else {
+			sb.WriteString(fmt.Sprintf("%%%02X", c))
+		}
+	}
+	return sb.String()
+}
+
+// s3CanonicalQuery 按key排序的查询字符串，同时用于请求和签名
+func s3CanonicalQuery(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var parts []string
+	for _, k := range keys {
+		for _, v := range query[k] {
+			parts = append(parts, s3Escape(k)+"="+s3Escape(v))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSha256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
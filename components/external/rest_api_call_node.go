@@ -251,7 +251,7 @@ func (x *RestApiCallNode) Init(ruleConfig types.Config, configuration types.Conf
 	if err == nil {
 		x.Config.RequestMethod = strings.ToUpper(x.Config.RequestMethod)
 		x.httpClient = NewHttpClient(x.Config)
-		if tmp, err := HttpUtils.BuildRequestTemplate(&x.Config); err != nil {
+		if tmp, err := HttpUtils.BuildRequestTemplate(&x.Config, el.WithUdf(ruleConfig.Udf)); err != nil {
 			return err
 		} else {
 			x.template = tmp
@@ -502,14 +502,18 @@ func (h *httpUtils) ReadFromStream(ctx types.RuleContext, msg types.RuleMsg, res
 	}
 }
 
-func (h *httpUtils) BuildRequestTemplate(config *RestApiCallNodeConfiguration) (*HTTPRequestTemplate, error) {
+func (h *httpUtils) BuildRequestTemplate(config *RestApiCallNodeConfiguration, opts ...el.TemplateOption) (*HTTPRequestTemplate, error) {
 	reqTemplate := &HTTPRequestTemplate{}
 	//Server-Send Events 流式响应
 	if strings.HasPrefix(config.Headers[AcceptKey], EventStreamMime) ||
 		strings.HasPrefix(config.Headers[ContentTypeKey], EventStreamMime) {
 		reqTemplate.IsStream = true
 	}
-	if tmpl, err := el.NewTemplate(config.RestEndpointUrlPattern); err != nil {
+	var params []any
+	for _, opt := range opts {
+		params = append(params, opt)
+	}
+	if tmpl, err := el.NewTemplate(config.RestEndpointUrlPattern, params...); err != nil {
 		return nil, err
 	} else {
 		reqTemplate.UrlTemplate = tmpl
@@ -520,8 +524,8 @@ func (h *httpUtils) BuildRequestTemplate(config *RestApiCallNodeConfiguration) (
 
 	var headerTemplates = make(map[*el.MixedTemplate]*el.MixedTemplate)
 	for key, value := range config.Headers {
-		keyTmpl, _ := el.NewMixedTemplate(key)
-		valueTmpl, _ := el.NewMixedTemplate(value)
+		keyTmpl, _ := el.NewMixedTemplate(key, opts...)
+		valueTmpl, _ := el.NewMixedTemplate(value, opts...)
 		headerTemplates[keyTmpl] = valueTmpl
 		if keyTmpl.HasVar() || valueTmpl.HasVar() {
 			reqTemplate.HasVar = true
@@ -531,7 +535,7 @@ func (h *httpUtils) BuildRequestTemplate(config *RestApiCallNodeConfiguration) (
 
 	config.Body = strings.TrimSpace(config.Body)
 	if config.Body != "" {
-		if bodyTemplate, err := el.NewTemplate(config.Body); err != nil {
+		if bodyTemplate, err := el.NewTemplate(config.Body, params...); err != nil {
 			return nil, err
 		} else {
 			reqTemplate.BodyTemplate = bodyTemplate
@@ -17,15 +17,23 @@
 package external
 
 import (
+	"bytes"
 	"crypto/tls"
+	"encoding/base64"
 	"errors"
 	"fmt"
 	"github.com/yunboom/rulego/api/types"
 	"github.com/yunboom/rulego/components/base"
 	"github.com/yunboom/rulego/utils/maps"
 	string2 "github.com/yunboom/rulego/utils/str"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
 	"net"
 	"net/smtp"
+	"net/textproto"
+	"os"
+	"path/filepath"
 	"strings"
 	"time"
 )
@@ -33,121 +41,361 @@ import (
 // 分隔符
 const splitUserSep = ","
 
+// 附件来源
+const (
+	// AttachmentSourceMsg 使用消息负荷作为附件内容
+	AttachmentSourceMsg = "msg"
+	// AttachmentSourceBase64 Content 为base64编码的附件内容
+	AttachmentSourceBase64 = "base64"
+	// AttachmentSourceFile Content 为附件文件路径
+	AttachmentSourceFile = "file"
+)
+
+// 加密方式
+const (
+	// TlsModeNone 不加密
+	TlsModeNone = "none"
+	// TlsModeStartTls 使用STARTTLS把明文连接升级为加密连接，服务器不支持时发送失败
+	TlsModeStartTls = "starttls"
+	// TlsModeTls 隐式TLS，连接建立时即加密，通常使用465端口
+	TlsModeTls = "tls"
+)
+
+// recipientVarKey 逐个收件人发送时，当前收件人的变量名
+const recipientVarKey = "recipient"
+
 func init() {
 	Registry.Add(&SendEmailNode{})
 }
 
+// Attachment 邮件附件
+type Attachment struct {
+	//Filename 附件文件名，可以使用 ${metadata.key} 读取元数据中的变量或者使用 ${msg.key} 读取消息负荷中的变量进行替换
+	Filename string `json:"filename"`
+	//Source 附件来源：msg 消息负荷，base64 Content为base64编码的内容，file Content为文件路径。默认file
+	Source string `json:"source"`
+	//Content base64编码的内容或者文件路径，可以使用变量，例如：${msg.image}
+	Content string `json:"content"`
+	//ContentType 附件类型，为空根据文件名后缀推断
+	ContentType string `json:"contentType"`
+	//Cid 内嵌资源的Content-ID，不为空时作为内嵌图片，HTML正文中通过 <img src="cid:xxx"> 引用
+	Cid string `json:"cid"`
+}
+
 // Email 邮件消息体
 type Email struct {
 	//From 发件人邮箱
 	From string `json:"from"`
-	//To 收件人邮箱，多个与`,`隔开
+	//To 收件人邮箱，多个与`,`隔开，可以使用变量
 	To string `json:"to"`
-	//Cc 抄送人邮箱，多个与`,`隔开
+	//Cc 抄送人邮箱，多个与`,`隔开，可以使用变量
 	Cc string `json:"cc"`
-	//Bcc 密送人邮箱，多个与`,`隔开
+	//Bcc 密送人邮箱，多个与`,`隔开，可以使用变量
 	Bcc string `json:"bcc"`
 	//Subject 邮件主题，可以使用 ${metadata.key} 读取元数据中的变量或者使用 ${msg.key} 读取消息负荷中的变量进行替换
 	Subject string `json:"subject"`
-	//Body 邮件模板，可以使用 ${metadata.key} 读取元数据中的变量或者使用 ${msg.key} 读取消息负荷中的变量进行替换
+	//Body 邮件模板，HTML格式，可以使用 ${metadata.key} 读取元数据中的变量或者使用 ${msg.key} 读取消息负荷中的变量进行替换
 	Body string `json:"body"`
+	//TextBody 纯文本邮件模板，和Body同时配置时发送HTML和纯文本两种格式的邮件，由邮件客户端选择展示
+	TextBody string `json:"textBody"`
+	//Attachments 附件和内嵌图片
+	Attachments []Attachment `json:"attachments"`
+	//PerRecipient 是否为To中的每个收件人单独渲染和发送邮件，模板中可以使用 ${recipient} 读取当前收件人。抄送和密送人只接收第一个收件人的邮件
+	PerRecipient bool `json:"perRecipient"`
+}
+
+// emailMessage 渲染后的邮件
+type emailMessage struct {
+	data  []byte
+	rcpts []string
+}
+
+// mimePart 邮件的MIME结构
+type mimePart struct {
+	header textproto.MIMEHeader
+	//叶子节点内容，已经编码
+	body []byte
+	//不为空是multipart节点，例如：mixed、related、alternative
+	multipart string
+	children  []*mimePart
 }
 
-func (e *Email) createEmailMsg(ctx types.RuleContext, ruleMsg types.RuleMsg) ([]byte, []string) {
+func (e *Email) createEmailMsg(ctx types.RuleContext, ruleMsg types.RuleMsg) ([]emailMessage, error) {
 	evn := base.NodeUtils.GetEvnAndMetadata(ctx, ruleMsg)
+	attachments, inlines, err := e.createAttachments(evn, ruleMsg)
+	if err != nil {
+		return nil, err
+	}
+	to := splitAddress(string2.ExecuteTemplate(e.To, evn))
+	cc := splitAddress(string2.ExecuteTemplate(e.Cc, evn))
+	bcc := splitAddress(string2.ExecuteTemplate(e.Bcc, evn))
+	if len(to) == 0 {
+		return nil, errors.New("to address can not empty")
+	}
+	if !e.PerRecipient {
+		// 将所有的收件人、抄送和密送合并为一个切片
+		sendTo := append(append(append([]string{}, to...), cc...), bcc...)
+		return []emailMessage{{data: e.render(evn, to, cc, attachments, inlines), rcpts: sendTo}}, nil
+	}
+	var msgs []emailMessage
+	for i, recipient := range to {
+		recipientEvn := make(map[string]interface{}, len(evn)+1)
+		for k, v := range evn {
+			recipientEvn[k] = v
+		}
+		recipientEvn[recipientVarKey] = recipient
+		sendTo := []string{recipient}
+		var recipientCc []string
+		// 抄送和密送人只发送一次，和第一封邮件一起发送
+		if i == 0 {
+			sendTo = append(append(sendTo, cc...), bcc...)
+			recipientCc = cc
+		}
+		msgs = append(msgs, emailMessage{data: e.render(recipientEvn, []string{recipient}, recipientCc, attachments, inlines), rcpts: sendTo})
+	}
+	return msgs, nil
+}
+
+// render 渲染邮件，创建一个符合RFC 5322标准的邮件消息，密送人不写入邮件头
+func (e *Email) render(evn map[string]interface{}, to, cc []string, attachments, inlines []*mimePart) []byte {
 	// 设置邮件主题
 	subject := string2.ExecuteTemplate(e.Subject, evn)
-	// 设置邮件正文，使用HTML格式
-	body := string2.ExecuteTemplate(e.Body, evn)
+	// 设置邮件正文
+	var content *mimePart
+	html := newTextPart("text/html", string2.ExecuteTemplate(e.Body, evn))
+	if e.TextBody == "" {
+		content = html
+	} else {
+		text := newTextPart("text/plain", string2.ExecuteTemplate(e.TextBody, evn))
+		if e.Body == "" {
+			content = text
+		} else {
+			content = &mimePart{multipart: "alternative", children: []*mimePart{text, html}}
+		}
+	}
+	if len(inlines) > 0 {
+		content = &mimePart{multipart: "related", children: append([]*mimePart{content}, inlines...)}
+	}
+	if len(attachments) > 0 {
+		content = &mimePart{multipart: "mixed", children: append([]*mimePart{content}, attachments...)}
+	}
+	header, body := content.encode()
+
+	var buf bytes.Buffer
+	buf.WriteString("From: " + e.From + "\r\n")
+	buf.WriteString("To: " + strings.Join(to, ", ") + "\r\n")
+	if len(cc) > 0 {
+		buf.WriteString("Cc: " + strings.Join(cc, ", ") + "\r\n")
+	}
+	buf.WriteString("Subject: " + mime.QEncoding.Encode("UTF-8", subject) + "\r\n")
+	buf.WriteString("Date: " + time.Now().Format(time.RFC1123Z) + "\r\n")
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	writeMimeHeader(&buf, header)
+	buf.WriteString("\r\n")
+	buf.Write(body)
+	return buf.Bytes()
+}
 
-	to := strings.Split(e.To, splitUserSep)
-	// 将所有的收件人、抄送和密送合并为一个切片
-	sendTo := to
+// createAttachments 读取附件，返回普通附件和内嵌资源
+func (e *Email) createAttachments(evn map[string]interface{}, ruleMsg types.RuleMsg) ([]*mimePart, []*mimePart, error) {
+	var attachments, inlines []*mimePart
+	for _, item := range e.Attachments {
+		filename := string2.ExecuteTemplate(item.Filename, evn)
+		content := string2.ExecuteTemplate(item.Content, evn)
+		var data []byte
+		var err error
+		switch item.Source {
+		case AttachmentSourceMsg:
+			data = ruleMsg.GetBytes()
+		case AttachmentSourceBase64:
+			data, err = base64.StdEncoding.DecodeString(content)
+		case AttachmentSourceFile, "":
+			data, err = os.ReadFile(content)
+			if filename == "" {
+				filename = filepath.Base(content)
+			}
+		default:
+			err = fmt.Errorf("unsupported attachment source: %s", item.Source)
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("read attachment %s error: %w", filename, err)
+		}
+		contentType := item.ContentType
+		if contentType == "" {
+			contentType = mime.TypeByExtension(filepath.Ext(filename))
+		}
+		mediaType, params, err := mime.ParseMediaType(contentType)
+		if err != nil {
+			mediaType, params = "application/octet-stream", map[string]string{}
+		}
+		params["name"] = filename
+		disposition := "attachment"
+		if item.Cid != "" {
+			disposition = "inline"
+		}
+		header := textproto.MIMEHeader{}
+		header.Set("Content-Type", mime.FormatMediaType(mediaType, params))
+		header.Set("Content-Disposition", mime.FormatMediaType(disposition, map[string]string{"filename": filename}))
+		header.Set("Content-Transfer-Encoding", "base64")
+		part := &mimePart{header: header, body: encodeBase64Lines(data)}
+		if item.Cid != "" {
+			header.Set("Content-ID", "<"+item.Cid+">")
+			inlines = append(inlines, part)
+		} else {
+			attachments = append(attachments, part)
+		}
+	}
+	return attachments, inlines, nil
+}
 
-	var cc, bcc []string
-	if e.Cc != "" {
-		cc = strings.Split(e.Cc, splitUserSep)
-		sendTo = append(sendTo, cc...)
+// encode 返回MIME头和编码后的内容
+func (p *mimePart) encode() (textproto.MIMEHeader, []byte) {
+	if p.multipart == "" {
+		return p.header, p.body
 	}
-	if e.Bcc != "" {
-		bcc = strings.Split(e.Bcc, splitUserSep)
-		sendTo = append(sendTo, bcc...)
+	var buf bytes.Buffer
+	w := multipart.NewWriter(&buf)
+	for _, child := range p.children {
+		header, body := child.encode()
+		pw, _ := w.CreatePart(header)
+		_, _ = pw.Write(body)
 	}
+	_ = w.Close()
+	header := textproto.MIMEHeader{}
+	header.Set("Content-Type", "multipart/"+p.multipart+"; boundary="+w.Boundary())
+	return header, buf.Bytes()
+}
 
-	// 创建一个邮件消息，符合RFC 822标准
-	msg := []byte("To: " + e.To + "\r\n" +
-		"From: " + e.From + "\r\n" +
-		"Subject: " + subject + "\r\n" +
-		"Cc: " + e.Cc + "\r\n" +
-		"Bcc: " + e.Bcc + "\r\n" +
-		"Content-Type: text/html; charset=UTF-8\r\n" +
-		"\r\n" +
-		body)
-	return msg, sendTo
+func newTextPart(contentType, text string) *mimePart {
+	var buf bytes.Buffer
+	w := quotedprintable.NewWriter(&buf)
+	_, _ = w.Write([]byte(text))
+	_ = w.Close()
+	header := textproto.MIMEHeader{}
+	header.Set("Content-Type", contentType+"; charset=UTF-8")
+	header.Set("Content-Transfer-Encoding", "quoted-printable")
+	return &mimePart{header: header, body: buf.Bytes()}
 }
 
-func (e *Email) SendEmail(ctx types.RuleContext, ruleMsg types.RuleMsg, addr string, auth smtp.Auth, connectTimeout time.Duration) error {
-	msg, sendTo := e.createEmailMsg(ctx, ruleMsg)
-	// 调用SendMail函数发送邮件
-	return smtp.SendMail(addr, auth, e.From, sendTo, msg)
+// encodeBase64Lines base64编码，每行76个字符
+func encodeBase64Lines(data []byte) []byte {
+	encoded := base64.StdEncoding.EncodeToString(data)
+	var buf bytes.Buffer
+	for len(encoded) > 76 {
+		buf.WriteString(encoded[:76] + "\r\n")
+		encoded = encoded[76:]
+	}
+	buf.WriteString(encoded)
+	return buf.Bytes()
 }
 
-func (e *Email) SendEmailWithTls(ctx types.RuleContext, ruleMsg types.RuleMsg, addr string, auth smtp.Auth, connectTimeout time.Duration) error {
+func writeMimeHeader(buf *bytes.Buffer, header textproto.MIMEHeader) {
+	for k, values := range header {
+		for _, v := range values {
+			buf.WriteString(k + ": " + v + "\r\n")
+		}
+	}
+}
 
-	msg, sendTo := e.createEmailMsg(ctx, ruleMsg)
+func splitAddress(addresses string) []string {
+	var result []string
+	for _, item := range strings.Split(addresses, splitUserSep) {
+		if item = strings.TrimSpace(item); item != "" {
+			result = append(result, item)
+		}
+	}
+	return result
+}
 
+// SendEmail 发送邮件，服务器支持时自动使用STARTTLS
+func (e *Email) SendEmail(ctx types.RuleContext, ruleMsg types.RuleMsg, addr string, auth smtp.Auth, connectTimeout time.Duration) error {
+	return e.send(ctx, ruleMsg, addr, auth, connectTimeout, "", nil)
+}
+
+// SendEmailWithTls 使用隐式TLS连接发送邮件
+func (e *Email) SendEmailWithTls(ctx types.RuleContext, ruleMsg types.RuleMsg, addr string, auth smtp.Auth, connectTimeout time.Duration) error {
 	host, _, _ := net.SplitHostPort(addr)
+	return e.send(ctx, ruleMsg, addr, auth, connectTimeout, TlsModeTls, &tls.Config{
+		InsecureSkipVerify: true,
+		ServerName:         host,
+	})
+}
 
-	conn, err := net.DialTimeout("tcp", addr, connectTimeout)
+// send 发送邮件，tlsMode为空时，服务器支持则自动使用STARTTLS
+func (e *Email) send(ctx types.RuleContext, ruleMsg types.RuleMsg, addr string, auth smtp.Auth, connectTimeout time.Duration, tlsMode string, tlsConfig *tls.Config) error {
+	msgs, err := e.createEmailMsg(ctx, ruleMsg)
 	if err != nil {
 		return err
 	}
-	// TLS
-	tlsConfig := &tls.Config{
-		InsecureSkipVerify: true,
-		ServerName:         host,
+	host, _, _ := net.SplitHostPort(addr)
+	if tlsConfig == nil {
+		tlsConfig = &tls.Config{ServerName: host}
+	}
+	var conn net.Conn
+	if tlsMode == TlsModeTls {
+		conn, err = tls.DialWithDialer(&net.Dialer{Timeout: connectTimeout}, "tcp", addr, tlsConfig)
+	} else {
+		conn, err = net.DialTimeout("tcp", addr, connectTimeout)
 	}
-	conn = tls.Client(conn, tlsConfig)
 	if err != nil {
 		return err
 	}
-
 	c, err := smtp.NewClient(conn, host)
 	if err != nil {
+		_ = conn.Close()
 		return err
 	}
 	defer c.Close()
+
+	if tlsMode == "" || tlsMode == TlsModeStartTls {
+		if ok, _ := c.Extension("STARTTLS"); ok {
+			if err = c.StartTLS(tlsConfig); err != nil {
+				return err
+			}
+		} else if tlsMode == TlsModeStartTls {
+			return errors.New("smtp server does not support STARTTLS")
+		}
+	}
 	// Auth
-	if err = c.Auth(auth); err != nil {
-		return err
+	if auth != nil {
+		if ok, _ := c.Extension("AUTH"); ok {
+			if err = c.Auth(auth); err != nil {
+				return err
+			}
+		}
+	}
+	for i, msg := range msgs {
+		if i > 0 {
+			if err = c.Reset(); err != nil {
+				return err
+			}
+		}
+		if err = sendData(c, e.From, msg); err != nil {
+			return err
+		}
 	}
+	return c.Quit()
+}
 
+func sendData(c *smtp.Client, from string, msg emailMessage) error {
 	// To && From
-	if err = c.Mail(e.From); err != nil {
+	if err := c.Mail(from); err != nil {
 		return err
 	}
-
-	for _, item := range sendTo {
-		if err = c.Rcpt(item); err != nil {
+	for _, item := range msg.rcpts {
+		if err := c.Rcpt(item); err != nil {
 			return err
 		}
 	}
-
 	// Data
 	w, err := c.Data()
 	if err != nil {
 		return err
 	}
-
-	if _, err = w.Write(msg); err != nil {
+	if _, err = w.Write(msg.data); err != nil {
 		return err
 	}
-
-	if err = w.Close(); err != nil {
-		return err
-	}
-
-	return c.Quit()
+	return w.Close()
 }
 
 // SendEmailConfiguration 配置
@@ -156,12 +404,17 @@ type SendEmailConfiguration struct {
 	SmtpHost string `json:"smtpHost"`
 	//SmtpPort Smtp端口
 	SmtpPort int `json:"smtpPort"`
-	//Username 用户名
+	//Username 用户名，为空不认证
 	Username string `json:"username"`
 	//Password 授权码
 	Password string `json:"password"`
-	//EnableTls 是否是使用tls方式
+	//EnableTls 是否是使用tls方式，等同于 TlsMode=tls 并且不校验服务器证书
 	EnableTls bool `json:"enableTls"`
+	//TlsMode 加密方式：none 不加密，starttls 使用STARTTLS，tls 隐式TLS。
+	//为空时根据 EnableTls 选择，未开启 EnableTls 则在服务器支持时自动使用STARTTLS
+	TlsMode string `json:"tlsMode"`
+	//InsecureSkipVerify 配置TlsMode时，是否跳过服务器证书校验
+	InsecureSkipVerify bool `json:"insecureSkipVerify"`
 	//Email 邮件内容配置
 	Email Email `json:"email"`
 	//ConnectTimeout 连接超时，单位秒
@@ -169,6 +422,7 @@ type SendEmailConfiguration struct {
 }
 
 // SendEmailNode 通过SMTP服务器发送邮消息
+// 支持HTML和纯文本邮件、附件、内嵌图片以及为每个收件人单独渲染邮件
 // 如果请求成功，发送消息到`Success`链, 否则发到`Failure`链，
 type SendEmailNode struct {
 	//节点配置
@@ -198,9 +452,23 @@ func (x *SendEmailNode) Init(ruleConfig types.Config, configuration types.Config
 		if x.Config.Email.To == "" {
 			return errors.New("to address can not empty")
 		}
+		switch x.Config.TlsMode {
+		case "", TlsModeNone, TlsModeStartTls, TlsModeTls:
+		default:
+			return fmt.Errorf("unsupported tls mode: %s", x.Config.TlsMode)
+		}
+		for _, item := range x.Config.Email.Attachments {
+			switch item.Source {
+			case "", AttachmentSourceMsg, AttachmentSourceBase64, AttachmentSourceFile:
+			default:
+				return fmt.Errorf("unsupported attachment source: %s", item.Source)
+			}
+		}
 		x.smtpAddr = fmt.Sprintf("%s:%d", x.Config.SmtpHost, x.Config.SmtpPort)
 		// 创建一个PLAIN认证
-		x.smtpAuth = smtp.PlainAuth("", x.Config.Username, x.Config.Password, x.Config.SmtpHost)
+		if x.Config.Username != "" {
+			x.smtpAuth = smtp.PlainAuth("", x.Config.Username, x.Config.Password, x.Config.SmtpHost)
+		}
 		if x.Config.ConnectTimeout <= 0 {
 			x.Config.ConnectTimeout = 10
 		}
@@ -213,11 +481,15 @@ func (x *SendEmailNode) Init(ruleConfig types.Config, configuration types.Config
 func (x *SendEmailNode) OnMsg(ctx types.RuleContext, msg types.RuleMsg) {
 	emailPojo := x.Config.Email
 	var err error
-	if x.Config.EnableTls {
+	if x.Config.TlsMode != "" {
+		err = emailPojo.send(ctx, msg, x.smtpAddr, x.smtpAuth, x.ConnectTimeoutDuration, x.Config.TlsMode, &tls.Config{
+			InsecureSkipVerify: x.Config.InsecureSkipVerify,
+			ServerName:         x.Config.SmtpHost,
+		})
+	} else if x.Config.EnableTls {
 		err = emailPojo.SendEmailWithTls(ctx, msg, x.smtpAddr, x.smtpAuth, x.ConnectTimeoutDuration)
 	} else {
 		err = emailPojo.SendEmail(ctx, msg, x.smtpAddr, x.smtpAuth, x.ConnectTimeoutDuration)
-
 	}
 	if err != nil {
 		ctx.TellFailure(msg, err)
@@ -0,0 +1,436 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package external
+
+import (
+	"bufio"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"io"
+	"math/big"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/yunboom/rulego/api/types"
+	"github.com/yunboom/rulego/test"
+	"github.com/yunboom/rulego/test/assert"
+)
+
+// smtpMail smtp替身收到的邮件
+type smtpMail struct {
+	from  string
+	rcpts []string
+	data  string
+	//是否通过加密连接接收
+	tls bool
+	//是否认证
+	auth bool
+}
+
+// smtpStub 进程内的smtp服务替身
+type smtpStub struct {
+	listener  net.Listener
+	tlsConfig *tls.Config
+	//是否支持STARTTLS
+	startTls bool
+	lock     sync.Mutex
+	mails    []smtpMail
+	//收到的RCPT命令数
+	rcptCount int
+}
+
+// newSmtpStub 创建smtp服务替身，implicitTls=true 时使用隐式TLS监听
+func newSmtpStub(t *testing.T, implicitTls, startTls bool) *smtpStub {
+	tlsConfig := newStubTlsConfig(t)
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	if implicitTls {
+		listener = tls.NewListener(listener, tlsConfig)
+	}
+	s := &smtpStub{listener: listener, tlsConfig: tlsConfig, startTls: startTls}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go s.handle(conn, implicitTls)
+		}
+	}()
+	return s
+}
+
+func (s *smtpStub) port() int {
+	return s.listener.Addr().(*net.TCPAddr).Port
+}
+
+func (s *smtpStub) received() []smtpMail {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return append([]smtpMail(nil), s.mails...)
+}
+
+func (s *smtpStub) handle(conn net.Conn, isTls bool) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	reply := func(line string) {
+		_, _ = io.WriteString(conn, line+"\r\n")
+	}
+	reply("220 stub ESMTP")
+	var current smtpMail
+	current.tls = isTls
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		cmd := strings.ToUpper(strings.TrimSpace(line))
+		switch {
+		case strings.HasPrefix(cmd, "EHLO"):
+			reply("250-stub")
+			if s.startTls && !current.tls {
+				reply("250-STARTTLS")
+			}
+			reply("250 AUTH PLAIN")
+		case cmd == "STARTTLS":
+			reply("220 ready")
+			tlsConn := tls.Server(conn, s.tlsConfig)
+			if tlsConn.Handshake() != nil {
+				return
+			}
+			conn, r = tlsConn, bufio.NewReader(tlsConn)
+			current.tls = true
+		case strings.HasPrefix(cmd, "AUTH"):
+			current.auth = true
+			reply("235 ok")
+		case strings.HasPrefix(cmd, "MAIL FROM:"):
+			current.from = strings.Trim(strings.TrimSpace(line)[10:], "<>")
+			reply("250 ok")
+		case strings.HasPrefix(cmd, "RCPT TO:"):
+			current.rcpts = append(current.rcpts, strings.Trim(strings.TrimSpace(line)[8:], "<>"))
+			s.lock.Lock()
+			s.rcptCount++
+			s.lock.Unlock()
+			reply("250 ok")
+		case cmd == "DATA":
+			reply("354 go ahead")
+			var data strings.Builder
+			for {
+				dataLine, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if dataLine == ".\r\n" {
+					break
+				}
+				data.WriteString(strings.TrimPrefix(dataLine, "."))
+			}
+			current.data = data.String()
+			s.lock.Lock()
+			s.mails = append(s.mails, current)
+			s.lock.Unlock()
+			current = smtpMail{tls: current.tls, auth: current.auth}
+			reply("250 queued")
+		case cmd == "RSET":
+			reply("250 ok")
+		case cmd == "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("250 ok")
+		}
+	}
+}
+
+// newStubTlsConfig 生成自签名证书
+func newStubTlsConfig(t *testing.T) *tls.Config {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.Nil(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "127.0.0.1"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	assert.Nil(t, err)
+	return &tls.Config{Certificates: []tls.Certificate{{Certificate: [][]byte{der}, PrivateKey: key}}}
+}
+
+// parseMailParts 解析multipart邮件，返回各部分的Content-Type和解码后的内容
+func parseMailParts(t *testing.T, data string) (map[string]string, *mail.Message) {
+	msg, err := mail.ReadMessage(strings.NewReader(data))
+	assert.Nil(t, err)
+	parts := map[string]string{}
+	var walk func(contentType string, body io.Reader)
+	walk = func(contentType string, body io.Reader) {
+		mediaType, params, err := mime.ParseMediaType(contentType)
+		assert.Nil(t, err)
+		if !strings.HasPrefix(mediaType, "multipart/") {
+			content, _ := io.ReadAll(body)
+			parts[mediaType] = string(content)
+			return
+		}
+		mr := multipart.NewReader(body, params["boundary"])
+		for {
+			p, err := mr.NextRawPart()
+			if err != nil {
+				return
+			}
+			var partBody io.Reader = p
+			if p.Header.Get("Content-Transfer-Encoding") == "base64" {
+				partBody = base64.NewDecoder(base64.StdEncoding, p)
+			} else if p.Header.Get("Content-Transfer-Encoding") == "quoted-printable" {
+				partBody = quotedprintable.NewReader(p)
+			}
+			key := p.Header.Get("Content-Type")
+			if cid := p.Header.Get("Content-ID"); cid != "" {
+				key = "cid:" + cid
+			}
+			if strings.HasPrefix(key, "multipart/") {
+				walk(key, partBody)
+			} else if p.Header.Get("Content-Disposition") == "" {
+				mediaType, _, _ := mime.ParseMediaType(key)
+				content, _ := io.ReadAll(partBody)
+				parts[mediaType] = string(content)
+			} else {
+				content, _ := io.ReadAll(partBody)
+				parts[key] = string(content)
+			}
+		}
+	}
+	walk(msg.Header.Get("Content-Type"), msg.Body)
+	return parts, msg
+}
+
+func TestSendEmailNode(t *testing.T) {
+	config := types.NewConfig()
+	var lock sync.Mutex
+	var relationType string
+	var lastErr error
+	ctx := test.NewRuleContext(config, func(msg types.RuleMsg, rt string, err error) {
+		lock.Lock()
+		defer lock.Unlock()
+		relationType, lastErr = rt, err
+	})
+	newMsg := func() types.RuleMsg {
+		metadata := types.NewMetadata()
+		metadata.PutValue("deviceId", "d1")
+		metadata.PutValue("users", "a@test.com,b@test.com")
+		return types.NewMsg(0, "ALARM", types.JSON, metadata, `{"temperature":41,"image":"`+base64.StdEncoding.EncodeToString([]byte("png-data"))+`"}`)
+	}
+	dir := t.TempDir()
+	reportFile := filepath.Join(dir, "report.csv")
+	assert.Nil(t, os.WriteFile(reportFile, []byte("id,temperature\nd1,41"), 0644))
+
+	t.Run("InitError", func(t *testing.T) {
+		assert.NotNil(t, (&SendEmailNode{}).Init(config, types.Configuration{"email": map[string]interface{}{}}))
+		assert.NotNil(t, (&SendEmailNode{}).Init(config, types.Configuration{"tlsMode": "ssl", "email": map[string]interface{}{"to": "a@test.com"}}))
+		assert.NotNil(t, (&SendEmailNode{}).Init(config, types.Configuration{"email": map[string]interface{}{"to": "a@test.com",
+			"attachments": []map[string]interface{}{{"source": "url"}}}}))
+	})
+
+	t.Run("HtmlWithAttachments", func(t *testing.T) {
+		stub := newSmtpStub(t, false, false)
+		defer stub.listener.Close()
+		node := &SendEmailNode{}
+		err := node.Init(config, types.Configuration{
+			"smtpHost": "127.0.0.1",
+			"smtpPort": stub.port(),
+			"username": "user",
+			"password": "pass",
+			"email": map[string]interface{}{
+				"from":     "rulego@test.com",
+				"to":       "${metadata.users}",
+				"cc":       "cc@test.com",
+				"bcc":      "bcc@test.com",
+				"subject":  "设备 ${metadata.deviceId} 告警",
+				"body":     `<p>温度：${msg.temperature}</p><img src="cid:chart">`,
+				"textBody": "温度：${msg.temperature}",
+				"attachments": []map[string]interface{}{
+					{"source": "base64", "content": "${msg.image}", "filename": "chart.png", "cid": "chart"},
+					{"source": "file", "content": reportFile},
+					{"source": "msg", "filename": "${metadata.deviceId}.json"},
+				},
+			},
+		})
+		assert.Nil(t, err)
+		node.OnMsg(ctx, newMsg())
+		assert.Equal(t, types.Success, relationType)
+
+		mails := stub.received()
+		assert.Equal(t, 1, len(mails))
+		assert.True(t, mails[0].auth)
+		assert.False(t, mails[0].tls)
+		assert.Equal(t, "rulego@test.com", mails[0].from)
+		assert.Equal(t, []string{"a@test.com", "b@test.com", "cc@test.com", "bcc@test.com"}, mails[0].rcpts)
+
+		parts, msg := parseMailParts(t, mails[0].data)
+		subject, _ := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+		assert.Equal(t, "设备 d1 告警", subject)
+		assert.Equal(t, "a@test.com, b@test.com", msg.Header.Get("To"))
+		assert.Equal(t, "", msg.Header.Get("Bcc"))
+		assert.True(t, strings.HasPrefix(msg.Header.Get("Content-Type"), "multipart/mixed"))
+		assert.Equal(t, "温度：41", parts["text/plain"])
+		assert.Equal(t, `<p>温度：41</p><img src="cid:chart">`, parts["text/html"])
+		assert.Equal(t, "png-data", parts["cid:<chart>"])
+		assert.Equal(t, "id,temperature\nd1,41", parts[`text/csv; charset=utf-8; name=report.csv`])
+		assert.Equal(t, `{"temperature":41,"image":"`+base64.StdEncoding.EncodeToString([]byte("png-data"))+`"}`, parts[`application/json; name=d1.json`])
+	})
+
+	t.Run("PerRecipient", func(t *testing.T) {
+		stub := newSmtpStub(t, false, false)
+		defer stub.listener.Close()
+		node := &SendEmailNode{}
+		err := node.Init(config, types.Configuration{
+			"smtpHost": "127.0.0.1",
+			"smtpPort": stub.port(),
+			"email": map[string]interface{}{
+				"from":         "rulego@test.com",
+				"to":           "${metadata.users}",
+				"cc":           "cc@test.com",
+				"bcc":          "bcc@test.com",
+				"subject":      "Hi ${recipient}",
+				"body":         "<p>Dear ${recipient}</p>",
+				"perRecipient": true,
+			},
+		})
+		assert.Nil(t, err)
+		node.OnMsg(ctx, newMsg())
+		assert.Equal(t, types.Success, relationType)
+
+		mails := stub.received()
+		assert.Equal(t, 2, len(mails))
+		assert.False(t, mails[0].auth)
+		//2个收件人各一次，抄送和密送人只发送一次
+		stub.lock.Lock()
+		assert.Equal(t, 4, stub.rcptCount)
+		stub.lock.Unlock()
+		assert.Equal(t, []string{"a@test.com", "cc@test.com", "bcc@test.com"}, mails[0].rcpts)
+		assert.Equal(t, []string{"b@test.com"}, mails[1].rcpts)
+		for i, recipient := range []string{"a@test.com", "b@test.com"} {
+			msg, err := mail.ReadMessage(strings.NewReader(mails[i].data))
+			assert.Nil(t, err)
+			assert.Equal(t, "Hi "+recipient, msg.Header.Get("Subject"))
+			assert.Equal(t, recipient, msg.Header.Get("To"))
+			assert.True(t, strings.HasPrefix(msg.Header.Get("Content-Type"), "text/html"))
+		}
+		msg, _ := mail.ReadMessage(strings.NewReader(mails[1].data))
+		assert.Equal(t, "", msg.Header.Get("Cc"))
+	})
+
+	t.Run("TlsMode", func(t *testing.T) {
+		tests := []struct {
+			tlsMode     string
+			implicitTls bool
+			startTls    bool
+			success     bool
+		}{
+			{tlsMode: TlsModeStartTls, startTls: true, success: true},
+			{tlsMode: TlsModeStartTls, startTls: false, success: false},
+			{tlsMode: TlsModeTls, implicitTls: true, success: true},
+			{tlsMode: TlsModeNone, startTls: true, success: true},
+		}
+		for _, item := range tests {
+			stub := newSmtpStub(t, item.implicitTls, item.startTls)
+			node := &SendEmailNode{}
+			err := node.Init(config, types.Configuration{
+				"smtpHost":           "127.0.0.1",
+				"smtpPort":           stub.port(),
+				"tlsMode":            item.tlsMode,
+				"insecureSkipVerify": true,
+				"email": map[string]interface{}{
+					"from": "rulego@test.com",
+					"to":   "a@test.com",
+					"body": "hello",
+				},
+			})
+			assert.Nil(t, err)
+			node.OnMsg(ctx, newMsg())
+			if item.success {
+				assert.Equal(t, types.Success, relationType)
+				mails := stub.received()
+				assert.Equal(t, 1, len(mails))
+				assert.Equal(t, item.tlsMode != TlsModeNone, mails[0].tls)
+			} else {
+				assert.Equal(t, types.Failure, relationType)
+				assert.NotNil(t, lastErr)
+			}
+			_ = stub.listener.Close()
+		}
+
+		//证书校验失败
+		stub := newSmtpStub(t, true, false)
+		defer stub.listener.Close()
+		node := &SendEmailNode{}
+		err := node.Init(config, types.Configuration{
+			"smtpHost": "127.0.0.1",
+			"smtpPort": stub.port(),
+			"tlsMode":  TlsModeTls,
+			"email":    map[string]interface{}{"from": "rulego@test.com", "to": "a@test.com"},
+		})
+		assert.Nil(t, err)
+		node.OnMsg(ctx, newMsg())
+		assert.Equal(t, types.Failure, relationType)
+
+		//兼容 enableTls
+		node = &SendEmailNode{}
+		err = node.Init(config, types.Configuration{
+			"smtpHost":  "127.0.0.1",
+			"smtpPort":  stub.port(),
+			"enableTls": true,
+			"email":     map[string]interface{}{"from": "rulego@test.com", "to": "a@test.com"},
+		})
+		assert.Nil(t, err)
+		node.OnMsg(ctx, newMsg())
+		assert.Equal(t, types.Success, relationType)
+	})
+
+	t.Run("AttachmentError", func(t *testing.T) {
+		stub := newSmtpStub(t, false, false)
+		defer stub.listener.Close()
+		node := &SendEmailNode{}
+		err := node.Init(config, types.Configuration{
+			"smtpHost": "127.0.0.1",
+			"smtpPort": stub.port(),
+			"email": map[string]interface{}{
+				"from":        "rulego@test.com",
+				"to":          "a@test.com",
+				"attachments": []map[string]interface{}{{"source": "file", "content": filepath.Join(dir, "none.txt")}},
+			},
+		})
+		assert.Nil(t, err)
+		node.OnMsg(ctx, newMsg())
+		assert.Equal(t, types.Failure, relationType)
+		assert.Equal(t, 0, len(stub.received()))
+	})
+}
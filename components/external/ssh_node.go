@@ -28,15 +28,25 @@ package external
 //}
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
 	"sync"
+	"time"
 
+	"github.com/pkg/sftp"
 	"github.com/yunboom/rulego/api/types"
 	"github.com/yunboom/rulego/components/base"
 	"github.com/yunboom/rulego/utils/maps"
 	"github.com/yunboom/rulego/utils/str"
 	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+	"golang.org/x/crypto/ssh/knownhosts"
 )
 
 var (
@@ -45,6 +55,26 @@ var (
 	SshCmdEmptyErr      = errors.New("cmd can not empty")
 )
 
+// SSH 节点操作类型
+const (
+	// SshOperationExec 执行shell命令
+	SshOperationExec = "exec"
+	// SshOperationUpload 通过SFTP上传文件
+	SshOperationUpload = "upload"
+	// SshOperationDownload 通过SFTP下载文件
+	SshOperationDownload = "download"
+)
+
+// SSH 节点输出的元数据键
+const (
+	// SshStdoutMetadataKey 命令标准输出
+	SshStdoutMetadataKey = "stdout"
+	// SshStderrMetadataKey 命令标准错误输出
+	SshStderrMetadataKey = "stderr"
+	// SshExitCodeMetadataKey 命令退出码
+	SshExitCodeMetadataKey = "exitCode"
+)
+
 func init() {
 	Registry.Add(&SshNode{})
 }
@@ -52,7 +82,7 @@ func init() {
 // SshConfiguration SSH节点配置
 // SshConfiguration defines SSH node configuration.
 type SshConfiguration struct {
-	//Host ssh 主机地址
+	//Host ssh 主机地址，也可以使用 ref://{resourceId} 引用节点池中的共享连接
 	Host string
 	//Port ssh 主机端口
 	Port int
@@ -60,20 +90,44 @@ type SshConfiguration struct {
 	Username string
 	//Password ssh登录密码
 	Password string
+	//PrivateKey PEM格式的私钥内容
+	PrivateKey string
+	//PrivateKeyFile 私钥文件路径
+	PrivateKeyFile string
+	//Passphrase 私钥密码
+	Passphrase string
+	//UseAgent 是否使用ssh-agent中的密钥认证
+	UseAgent bool
+	//AgentSocket ssh-agent 的unix socket地址，为空使用环境变量 SSH_AUTH_SOCK
+	AgentSocket string
+	//KnownHostsFile known_hosts 文件路径，用于校验服务器公钥。为空使用 ~/.ssh/known_hosts
+	KnownHostsFile string
+	//InsecureIgnoreHostKey 是否跳过服务器公钥校验，存在中间人攻击风险，只用于测试环境
+	InsecureIgnoreHostKey bool
+	//ConnectTimeout 连接超时，单位秒，默认10
+	ConnectTimeout int
+	//Operation 操作类型：exec 执行命令，upload 上传文件，download 下载文件。默认exec
+	Operation string
 	//Cmd shell命令,可以使用 ${metadata.key} 读取元数据中的变量或者使用 ${msg.key} 读取消息负荷中的变量进行替换
 	Cmd string
+	//RemotePath 上传或下载的远程文件路径，可以使用变量
+	RemotePath string
+	//LocalPath 上传或下载的本地文件路径，可以使用变量。
+	//为空时上传消息负荷，或者把下载的文件内容作为消息负荷
+	LocalPath string
 }
 
-// SshNode SSH远程命令执行组件，建立SSH连接到远程主机并执行shell命令
-// SshNode provides SSH-based remote command execution capabilities.
+// SshNode SSH远程命令执行和SFTP文件传输组件，通过共享的SSH连接执行shell命令或者上传、下载文件
+// SshNode provides SSH-based remote command execution and SFTP file transfer over a shared connection.
 //
 // 核心算法：
 // Core Algorithm:
-// 1. 初始化时建立SSH连接 - Establish SSH connection during initialization
-// 2. 解析命令模板，支持变量替换 - Parse command template with variable substitution
-// 3. 创建SSH会话执行命令 - Create SSH session to execute command
-// 4. 捕获命令输出（stdout+stderr）- Capture command output (stdout+stderr)
-// 5. 关闭会话并返回结果 - Close session and return results
+// 1. 通过SharedNode复用SSH连接，首次使用时建立连接 - Reuse the SSH connection through SharedNode, dialing on first use
+// 2. 使用密码、私钥或ssh-agent认证，通过known_hosts校验服务器公钥 - Authenticate by password, private key or ssh-agent, verifying the host key against known_hosts
+// 3. 解析命令或文件路径模板，支持变量替换 - Parse command or file path templates with variable substitution
+// 4. exec：创建SSH会话执行命令，分别捕获stdout、stderr和退出码 - exec: run the command in a session capturing stdout, stderr and the exit code
+// 5. upload/download：通过SFTP传输文件 - upload/download: transfer files over SFTP
+// 6. 连接断开时释放连接，下一条消息重新连接 - Drop a broken connection so the next message reconnects
 //
 // 变量替换 - Variable substitution:
 //   - ${metadata.key}: 访问消息元数据变量 - Access message metadata variables
@@ -86,9 +140,22 @@ type SshConfiguration struct {
 //		"port": 22,                     // SSH端口 - SSH port
 //		"username": "admin",            // 用户名 - Username
 //		"password": "secret123",        // 密码 - Password
+//		"privateKeyFile": "/home/rulego/.ssh/id_ed25519", // 私钥文件 - Private key file
+//		"useAgent": false,              // 使用ssh-agent认证 - Authenticate with ssh-agent
+//		"knownHostsFile": "/home/rulego/.ssh/known_hosts", // 校验服务器公钥，默认~/.ssh/known_hosts - Verify host key, default ~/.ssh/known_hosts
+//		"insecureIgnoreHostKey": false, // 跳过服务器公钥校验，只用于测试 - Skip host key verification, for testing only
+//		"operation": "exec",            // exec、upload 或 download - exec, upload or download
 //		"cmd": "ls -la /tmp/${metadata.path}"  // 支持变量替换的命令 - Command with variables
 //	}
 //
+// 输出 - Output:
+//   - exec：消息负荷为stdout和stderr的合并输出，元数据 stdout、stderr、exitCode 分别保存标准输出、错误输出和退出码，
+//     退出码不为0时发送到Failure链
+//     exec: data is the combined output, metadata stdout, stderr and exitCode hold each stream and the exit code,
+//     a non-zero exit code goes to Failure
+//   - upload：消息不变 - upload: message unchanged
+//   - download：LocalPath为空时，文件内容作为消息负荷 - download: file content becomes the data when localPath is empty
+//
 // 使用示例 - Usage examples:
 //
 //	// 执行系统监控命令 - Execute system monitoring command
@@ -104,16 +171,18 @@ type SshConfiguration struct {
 //		}
 //	}
 //
-//	// 执行带动态参数的命令 - Execute command with dynamic parameters
+//	// 上传消息负荷到远程文件 - Upload the message data to a remote file
 //	{
-//		"id": "sshDynamic",
+//		"id": "sshUpload",
 //		"type": "ssh",
 //		"configuration": {
-//			"host": "${metadata.targetHost}",
+//			"host": "server.example.com",
 //			"port": 22,
 //			"username": "admin",
-//			"password": "pass",
-//			"cmd": "cat /var/log/${msg.logFile} | tail -${metadata.lines}"
+//			"privateKeyFile": "/home/rulego/.ssh/id_ed25519",
+//			"knownHostsFile": "/home/rulego/.ssh/known_hosts",
+//			"operation": "upload",
+//			"remotePath": "/data/${metadata.deviceId}.json"
 //		}
 //	}
 //
@@ -121,14 +190,14 @@ type SshConfiguration struct {
 //   - 远程系统监控和维护 - Remote system monitoring and maintenance
 //   - 批量服务器管理操作 - Batch server management operations
 //   - 自动化运维脚本执行 - Automated operations script execution
+//   - 文件分发和日志收集 - File distribution and log collection
 type SshNode struct {
+	base.SharedNode[*ssh.Client]
 	//节点配置
-	Config SshConfiguration
-	// client 是一个 ssh.Client 类型的字段，用来保存 ssh 客户端对象
-	client      *ssh.Client
-	cmdTemplate str.Template
-	// 保护client字段的并发访问
-	clientMutex sync.RWMutex
+	Config             SshConfiguration
+	cmdTemplate        str.Template
+	remotePathTemplate str.Template
+	localPathTemplate  str.Template
 }
 
 // Type 方法用来返回组件的类型
@@ -149,78 +218,286 @@ func (x *SshNode) New() types.Node {
 // Init 方法用来初始化组件，一般做一些组件参数配置或者客户端初始化操作
 func (x *SshNode) Init(ruleConfig types.Config, configuration types.Configuration) error {
 	err := maps.Map2Struct(configuration, &x.Config)
-	if err == nil {
-		// 从配置中获取 ssh 连接的参数
-		sshConfig := x.Config
-		// 如果参数不为空，则创建一个 ssh 客户端对象
-		if sshConfig.Host != "" && sshConfig.Port != 0 && sshConfig.Username != "" && sshConfig.Password != "" {
-			config := &ssh.ClientConfig{
-				User: sshConfig.Username,
-				Auth: []ssh.AuthMethod{
-					ssh.Password(sshConfig.Password),
-				},
-				HostKeyCallback: ssh.InsecureIgnoreHostKey(),
-			}
-			x.client, err = ssh.Dial("tcp", fmt.Sprintf("%s:%d", sshConfig.Host, sshConfig.Port), config)
-		} else {
-			return SshConfigEmptyErr
+	if err != nil {
+		return err
+	}
+	sshConfig := x.Config
+	hasAuth := sshConfig.Password != "" || sshConfig.PrivateKey != "" || sshConfig.PrivateKeyFile != "" || sshConfig.UseAgent
+	if sshConfig.Host == "" || (!base.NodeUtils.IsNodePool(ruleConfig, sshConfig.Host) && (sshConfig.Port == 0 || sshConfig.Username == "" || !hasAuth)) {
+		return SshConfigEmptyErr
+	}
+	switch sshConfig.Operation {
+	case "", SshOperationExec:
+		x.Config.Operation = SshOperationExec
+	case SshOperationUpload, SshOperationDownload:
+		if sshConfig.RemotePath == "" {
+			return errors.New("remotePath can not empty")
 		}
-		x.cmdTemplate = str.NewTemplate(x.Config.Cmd)
+	default:
+		return fmt.Errorf("unsupported operation: %s", sshConfig.Operation)
 	}
-	return err
+	if x.Config.ConnectTimeout <= 0 {
+		x.Config.ConnectTimeout = 10
+	}
+	x.cmdTemplate = str.NewTemplate(x.Config.Cmd)
+	x.remotePathTemplate = str.NewTemplate(x.Config.RemotePath)
+	x.localPathTemplate = str.NewTemplate(x.Config.LocalPath)
+	return x.SharedNode.InitWithClose(ruleConfig, x.Type(), x.Config.Host, ruleConfig.NodeClientInitNow, func() (*ssh.Client, error) {
+		return x.initClient()
+	}, func(client *ssh.Client) error {
+		return client.Close()
+	})
 }
 
 // OnMsg 方法用来处理消息，每条流入组件的数据会经过该函数处理
 func (x *SshNode) OnMsg(ctx types.RuleContext, msg types.RuleMsg) {
-	var err error
+	if x.Config.Operation == SshOperationExec && x.Config.Cmd == "" {
+		ctx.TellFailure(msg, SshCmdEmptyErr)
+		return
+	}
+	client, err := x.SharedNode.GetSafely()
+	if err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	evn := base.NodeUtils.GetEvnAndMetadata(ctx, msg)
+	switch x.Config.Operation {
+	case SshOperationUpload:
+		err = x.upload(client, evn, msg)
+	case SshOperationDownload:
+		err = x.download(client, evn, &msg)
+	default:
+		err = x.exec(client, evn, &msg)
+	}
+	if err != nil {
+		ctx.TellFailure(msg, err)
+	} else {
+		// 将输出结果作为新的消息发送到下一个组件
+		ctx.TellSuccess(msg)
+	}
+}
 
-	// 安全获取client引用
-	x.clientMutex.RLock()
-	client := x.client
-	x.clientMutex.RUnlock()
+// Destroy 方法用来销毁组件，做一些资源释放操作
+func (x *SshNode) Destroy() {
+	_ = x.SharedNode.Close()
+}
 
-	if client == nil {
-		ctx.TellFailure(msg, SshClientNotInitErr)
-		return
+// exec 执行命令，stdout和stderr分别写入元数据，合并输出作为消息负荷
+func (x *SshNode) exec(client *ssh.Client, evn map[string]interface{}, msg *types.RuleMsg) error {
+	session, err := x.newSession(client)
+	if err != nil {
+		return err
 	}
+	defer session.Close()
 
-	// 获取shell 命令
-	cmd := x.Config.Cmd
-	if cmd == "" {
-		ctx.TellFailure(msg, SshCmdEmptyErr)
-		return
+	var stdout, stderr bytes.Buffer
+	combined := &syncBuffer{}
+	session.Stdout = io.MultiWriter(&stdout, combined)
+	session.Stderr = io.MultiWriter(&stderr, combined)
+	err = session.Run(x.cmdTemplate.Execute(evn))
+
+	exitCode := 0
+	var exitErr *ssh.ExitError
+	if errors.As(err, &exitErr) {
+		exitCode = exitErr.ExitStatus()
+	} else if err != nil {
+		return err
 	}
-	cmd = x.cmdTemplate.ExecuteFn(func() map[string]any {
-		return base.NodeUtils.GetEvnAndMetadata(ctx, msg)
-	})
-	var output []byte
-	var session *ssh.Session
-	// 如果有 ssh 客户端对象，则创建一个 ssh 会话，并执行远程 shell 命令，并获取其输出或错误信息
-	if session, err = client.NewSession(); err == nil {
-		defer session.Close()
-		output, err = session.CombinedOutput(cmd)
+	msg.SetData(combined.String())
+	msg.DataType = types.TEXT
+	msg.Metadata.PutValue(SshStdoutMetadataKey, stdout.String())
+	msg.Metadata.PutValue(SshStderrMetadataKey, stderr.String())
+	msg.Metadata.PutValue(SshExitCodeMetadataKey, strconv.Itoa(exitCode))
+	return err
+}
 
-		msg.SetData(string(output))
-		msg.DataType = types.TEXT
+// upload 上传本地文件或者消息负荷到远程文件
+func (x *SshNode) upload(client *ssh.Client, evn map[string]interface{}, msg types.RuleMsg) error {
+	sftpClient, err := x.newSftpClient(client)
+	if err != nil {
+		return err
+	}
+	defer sftpClient.Close()
 
+	var src io.Reader
+	if localPath := x.localPathTemplate.Execute(evn); localPath != "" {
+		file, err := os.Open(localPath)
 		if err != nil {
-			ctx.TellFailure(msg, err)
-		} else {
-			// 将输出结果作为新的消息发送到下一个组件
-			ctx.TellSuccess(msg)
+			return err
 		}
+		defer file.Close()
+		src = file
 	} else {
-		ctx.TellFailure(msg, err)
+		src = bytes.NewReader(msg.GetBytes())
+	}
+	dst, err := sftpClient.Create(x.remotePathTemplate.Execute(evn))
+	if err != nil {
+		return err
+	}
+	if _, err = io.Copy(dst, src); err != nil {
+		_ = dst.Close()
+		return err
 	}
+	return dst.Close()
 }
 
-// Destroy 方法用来销毁组件，做一些资源释放操作
-func (x *SshNode) Destroy() {
-	x.clientMutex.Lock()
-	defer x.clientMutex.Unlock()
+// download 下载远程文件到本地文件，本地路径为空时文件内容作为消息负荷
+func (x *SshNode) download(client *ssh.Client, evn map[string]interface{}, msg *types.RuleMsg) error {
+	sftpClient, err := x.newSftpClient(client)
+	if err != nil {
+		return err
+	}
+	defer sftpClient.Close()
+
+	src, err := sftpClient.Open(x.remotePathTemplate.Execute(evn))
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	localPath := x.localPathTemplate.Execute(evn)
+	if localPath == "" {
+		var buf bytes.Buffer
+		if _, err = src.WriteTo(&buf); err != nil {
+			return err
+		}
+		msg.SetData(buf.String())
+		return nil
+	}
+	dst, err := os.Create(localPath)
+	if err != nil {
+		return err
+	}
+	if _, err = src.WriteTo(dst); err != nil {
+		_ = dst.Close()
+		return err
+	}
+	return dst.Close()
+}
+
+// newSession 创建会话，连接已经断开时释放连接，下一条消息重新连接
+func (x *SshNode) newSession(client *ssh.Client) (*ssh.Session, error) {
+	session, err := client.NewSession()
+	if err != nil {
+		x.releaseBrokenClient(client)
+	}
+	return session, err
+}
+
+func (x *SshNode) newSftpClient(client *ssh.Client) (*sftp.Client, error) {
+	sftpClient, err := sftp.NewClient(client)
+	if err != nil {
+		x.releaseBrokenClient(client)
+	}
+	return sftpClient, err
+}
+
+// releaseBrokenClient 连接已经断开时释放连接
+func (x *SshNode) releaseBrokenClient(client *ssh.Client) {
+	if _, _, err := client.SendRequest("keepalive@openssh.com", true, nil); err != nil {
+		_ = x.SharedNode.Close()
+	}
+}
+
+// initClient 建立SSH连接
+func (x *SshNode) initClient() (*ssh.Client, error) {
+	sshConfig := x.Config
+	auth, agentConn, err := x.authMethods()
+	if err != nil {
+		return nil, err
+	}
+	if agentConn != nil {
+		defer agentConn.Close()
+	}
+	hostKeyCallback, err := x.hostKeyCallback()
+	if err != nil {
+		return nil, err
+	}
+	config := &ssh.ClientConfig{
+		User:            sshConfig.Username,
+		Auth:            auth,
+		HostKeyCallback: hostKeyCallback,
+		Timeout:         time.Duration(sshConfig.ConnectTimeout) * time.Second,
+	}
+	return ssh.Dial("tcp", net.JoinHostPort(sshConfig.Host, strconv.Itoa(sshConfig.Port)), config)
+}
+
+// hostKeyCallback 服务器公钥校验，KnownHostsFile 为空使用 ~/.ssh/known_hosts，显式开启 InsecureIgnoreHostKey 时不校验
+func (x *SshNode) hostKeyCallback() (ssh.HostKeyCallback, error) {
+	if x.Config.InsecureIgnoreHostKey {
+		return ssh.InsecureIgnoreHostKey(), nil
+	}
+	knownHostsFile := x.Config.KnownHostsFile
+	if knownHostsFile == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, err
+		}
+		knownHostsFile = filepath.Join(home, ".ssh", "known_hosts")
+	}
+	return knownhosts.New(knownHostsFile)
+}
 
-	if x.client != nil {
-		_ = x.client.Close()
-		x.client = nil
+// authMethods 认证方式，按照私钥、ssh-agent、密码的顺序尝试，返回的closer用于关闭ssh-agent连接
+func (x *SshNode) authMethods() ([]ssh.AuthMethod, io.Closer, error) {
+	sshConfig := x.Config
+	var methods []ssh.AuthMethod
+	var closer io.Closer
+	if sshConfig.PrivateKey != "" || sshConfig.PrivateKeyFile != "" {
+		key := []byte(sshConfig.PrivateKey)
+		if len(key) == 0 {
+			var err error
+			if key, err = os.ReadFile(sshConfig.PrivateKeyFile); err != nil {
+				return nil, nil, err
+			}
+		}
+		var signer ssh.Signer
+		var err error
+		if sshConfig.Passphrase != "" {
+			signer, err = ssh.ParsePrivateKeyWithPassphrase(key, []byte(sshConfig.Passphrase))
+		} else {
+			signer, err = ssh.ParsePrivateKey(key)
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		methods = append(methods, ssh.PublicKeys(signer))
+	}
+	if sshConfig.UseAgent {
+		socket := sshConfig.AgentSocket
+		if socket == "" {
+			socket = os.Getenv("SSH_AUTH_SOCK")
+		}
+		if socket == "" {
+			return nil, nil, errors.New("ssh agent socket not found")
+		}
+		// 签名时需要访问ssh-agent，连接在握手完成后关闭
+		conn, err := net.Dial("unix", socket)
+		if err != nil {
+			return nil, nil, err
+		}
+		closer = conn
+		methods = append(methods, ssh.PublicKeysCallback(agent.NewClient(conn).Signers))
+	}
+	if sshConfig.Password != "" {
+		methods = append(methods, ssh.Password(sshConfig.Password))
 	}
+	return methods, closer, nil
+}
+
+// syncBuffer 并发安全的缓冲区，stdout和stderr在不同的协程中写入
+type syncBuffer struct {
+	lock sync.Mutex
+	buf  bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.buf.String()
 }
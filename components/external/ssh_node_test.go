@@ -0,0 +1,370 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package external
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/pem"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/pkg/sftp"
+	"github.com/yunboom/rulego/api/types"
+	"github.com/yunboom/rulego/test"
+	"github.com/yunboom/rulego/test/assert"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// sshStub 进程内SSH服务器，支持密码和公钥认证、exec和sftp子系统
+type sshStub struct {
+	listener  net.Listener
+	hostKey   ssh.Signer
+	clientKey ssh.PublicKey
+	conns     int32
+	wg        sync.WaitGroup
+}
+
+func newSshStub(t *testing.T, clientKey ssh.PublicKey) *sshStub {
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	assert.Nil(t, err)
+	hostKey, err := ssh.NewSignerFromKey(priv)
+	assert.Nil(t, err)
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	s := &sshStub{listener: listener, hostKey: hostKey, clientKey: clientKey}
+	s.wg.Add(1)
+	go s.serve()
+	return s
+}
+
+func (s *sshStub) serve() {
+	defer s.wg.Done()
+	config := &ssh.ServerConfig{
+		PasswordCallback: func(conn ssh.ConnMetadata, password []byte) (*ssh.Permissions, error) {
+			if conn.User() == "rulego" && string(password) == "secret" {
+				return nil, nil
+			}
+			return nil, errors.New("password rejected")
+		},
+		PublicKeyCallback: func(conn ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+			if s.clientKey != nil && bytes.Equal(key.Marshal(), s.clientKey.Marshal()) {
+				return nil, nil
+			}
+			return nil, errors.New("public key rejected")
+		},
+	}
+	config.AddHostKey(s.hostKey)
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go s.handleConn(conn, config)
+	}
+}
+
+func (s *sshStub) handleConn(conn net.Conn, config *ssh.ServerConfig) {
+	serverConn, chans, reqs, err := ssh.NewServerConn(conn, config)
+	if err != nil {
+		_ = conn.Close()
+		return
+	}
+	defer serverConn.Close()
+	atomic.AddInt32(&s.conns, 1)
+	go ssh.DiscardRequests(reqs)
+	for newChannel := range chans {
+		if newChannel.ChannelType() != "session" {
+			_ = newChannel.Reject(ssh.UnknownChannelType, "unknown channel type")
+			continue
+		}
+		channel, requests, err := newChannel.Accept()
+		if err != nil {
+			continue
+		}
+		go s.handleSession(channel, requests)
+	}
+}
+
+func (s *sshStub) handleSession(channel ssh.Channel, requests <-chan *ssh.Request) {
+	defer channel.Close()
+	for req := range requests {
+		switch req.Type {
+		case "exec":
+			_ = req.Reply(true, nil)
+			//模拟命令：echo 输出到stdout，fail 输出到stderr并返回退出码3
+			cmd := string(req.Payload[4:])
+			exitCode := 0
+			if strings.HasPrefix(cmd, "echo ") {
+				_, _ = channel.Write([]byte(strings.TrimPrefix(cmd, "echo ") + "\n"))
+			} else {
+				_, _ = channel.Stderr().Write([]byte("command failed\n"))
+				exitCode = 3
+			}
+			status := make([]byte, 4)
+			binary.BigEndian.PutUint32(status, uint32(exitCode))
+			_, _ = channel.SendRequest("exit-status", false, status)
+			return
+		case "subsystem":
+			if string(req.Payload[4:]) != "sftp" {
+				_ = req.Reply(false, nil)
+				continue
+			}
+			_ = req.Reply(true, nil)
+			server, err := sftp.NewServer(channel)
+			if err != nil {
+				return
+			}
+			_ = server.Serve()
+			return
+		default:
+			_ = req.Reply(false, nil)
+		}
+	}
+}
+
+func (s *sshStub) port() int {
+	return s.listener.Addr().(*net.TCPAddr).Port
+}
+
+func (s *sshStub) connCount() int {
+	return int(atomic.LoadInt32(&s.conns))
+}
+
+func (s *sshStub) Close() {
+	_ = s.listener.Close()
+	s.wg.Wait()
+}
+
+func TestSshNode(t *testing.T) {
+	_, clientPriv, err := ed25519.GenerateKey(rand.Reader)
+	assert.Nil(t, err)
+	clientSigner, err := ssh.NewSignerFromKey(clientPriv)
+	assert.Nil(t, err)
+	block, err := ssh.MarshalPrivateKey(clientPriv, "")
+	assert.Nil(t, err)
+	privateKeyPem := string(pem.EncodeToMemory(block))
+
+	stub := newSshStub(t, clientSigner.PublicKey())
+	defer stub.Close()
+
+	dir := t.TempDir()
+	config := types.NewConfig()
+	var outMsg types.RuleMsg
+	var relationType string
+	var outErr error
+	ctx := test.NewRuleContext(config, func(msg types.RuleMsg, rt string, err error) {
+		outMsg, relationType, outErr = msg, rt, err
+	})
+	newMsg := func(data string) types.RuleMsg {
+		metadata := types.NewMetadata()
+		metadata.PutValue("name", "rulego")
+		metadata.PutValue("file", "data.txt")
+		return types.NewMsg(0, "TEST", types.TEXT, metadata, data)
+	}
+	baseConfig := func(configuration types.Configuration) types.Configuration {
+		configuration["host"] = "127.0.0.1"
+		configuration["port"] = stub.port()
+		configuration["username"] = "rulego"
+		//替身服务器的公钥不在 known_hosts 中，没有指定 known_hosts 时跳过校验
+		_, hasKnownHosts := configuration["knownHostsFile"]
+		if _, ok := configuration["insecureIgnoreHostKey"]; !ok && !hasKnownHosts {
+			configuration["insecureIgnoreHostKey"] = true
+		}
+		return configuration
+	}
+
+	t.Run("InitError", func(t *testing.T) {
+		assert.Equal(t, SshConfigEmptyErr, (&SshNode{}).Init(config, types.Configuration{"host": "127.0.0.1", "port": 22, "username": "root"}))
+		assert.NotNil(t, (&SshNode{}).Init(config, baseConfig(types.Configuration{"password": "secret", "operation": "upload"})))
+		assert.NotNil(t, (&SshNode{}).Init(config, baseConfig(types.Configuration{"password": "secret", "operation": "rsync"})))
+	})
+
+	t.Run("ExecWithPassword", func(t *testing.T) {
+		node := &SshNode{}
+		err := node.Init(config, baseConfig(types.Configuration{"password": "secret", "cmd": "echo hello ${metadata.name}"}))
+		assert.Nil(t, err)
+		defer node.Destroy()
+
+		node.OnMsg(ctx, newMsg(""))
+		assert.Equal(t, types.Success, relationType)
+		assert.Equal(t, "hello rulego\n", outMsg.GetData())
+		assert.Equal(t, "hello rulego\n", outMsg.Metadata.GetValue(SshStdoutMetadataKey))
+		assert.Equal(t, "", outMsg.Metadata.GetValue(SshStderrMetadataKey))
+		assert.Equal(t, "0", outMsg.Metadata.GetValue(SshExitCodeMetadataKey))
+
+		//复用同一个连接
+		count := stub.connCount()
+		node.OnMsg(ctx, newMsg(""))
+		assert.Equal(t, types.Success, relationType)
+		assert.Equal(t, count, stub.connCount())
+	})
+
+	t.Run("ExecExitCode", func(t *testing.T) {
+		node := &SshNode{}
+		err := node.Init(config, baseConfig(types.Configuration{"privateKey": privateKeyPem, "cmd": "fail"}))
+		assert.Nil(t, err)
+		defer node.Destroy()
+
+		node.OnMsg(ctx, newMsg(""))
+		assert.Equal(t, types.Failure, relationType)
+		assert.Equal(t, "command failed\n", outMsg.Metadata.GetValue(SshStderrMetadataKey))
+		assert.Equal(t, "3", outMsg.Metadata.GetValue(SshExitCodeMetadataKey))
+	})
+
+	t.Run("KnownHosts", func(t *testing.T) {
+		keyFile := filepath.Join(dir, "id_ed25519")
+		assert.Nil(t, os.WriteFile(keyFile, []byte(privateKeyPem), 0600))
+		addr := net.JoinHostPort("127.0.0.1", strconv.Itoa(stub.port()))
+		knownHostsFile := filepath.Join(dir, "known_hosts")
+		line := knownhosts.Line([]string{knownhosts.Normalize(addr)}, stub.hostKey.PublicKey())
+		assert.Nil(t, os.WriteFile(knownHostsFile, []byte(line+"\n"), 0600))
+
+		node := &SshNode{}
+		err := node.Init(config, baseConfig(types.Configuration{"privateKeyFile": keyFile, "knownHostsFile": knownHostsFile, "cmd": "echo ok"}))
+		assert.Nil(t, err)
+		node.OnMsg(ctx, newMsg(""))
+		node.Destroy()
+		assert.Equal(t, types.Success, relationType)
+
+		//服务器公钥不匹配
+		_, otherPriv, _ := ed25519.GenerateKey(rand.Reader)
+		otherSigner, _ := ssh.NewSignerFromKey(otherPriv)
+		line = knownhosts.Line([]string{knownhosts.Normalize(addr)}, otherSigner.PublicKey())
+		assert.Nil(t, os.WriteFile(knownHostsFile, []byte(line+"\n"), 0600))
+		node = &SshNode{}
+		err = node.Init(config, baseConfig(types.Configuration{"privateKeyFile": keyFile, "knownHostsFile": knownHostsFile, "cmd": "echo ok"}))
+		assert.Nil(t, err)
+		node.OnMsg(ctx, newMsg(""))
+		node.Destroy()
+		assert.Equal(t, types.Failure, relationType)
+		var keyErr *knownhosts.KeyError
+		assert.True(t, errors.As(outErr, &keyErr))
+
+		//没有指定 known_hosts 时默认使用 ~/.ssh/known_hosts，不能跳过校验
+		home := filepath.Join(dir, "home")
+		t.Setenv("HOME", home)
+		defaultConfig := types.Configuration{"privateKeyFile": keyFile, "insecureIgnoreHostKey": false, "cmd": "echo ok"}
+		node = &SshNode{}
+		err = node.Init(config, baseConfig(defaultConfig))
+		assert.Nil(t, err)
+		node.OnMsg(ctx, newMsg(""))
+		node.Destroy()
+		assert.Equal(t, types.Failure, relationType)
+
+		assert.Nil(t, os.MkdirAll(filepath.Join(home, ".ssh"), 0700))
+		line = knownhosts.Line([]string{knownhosts.Normalize(addr)}, stub.hostKey.PublicKey())
+		assert.Nil(t, os.WriteFile(filepath.Join(home, ".ssh", "known_hosts"), []byte(line+"\n"), 0600))
+		node = &SshNode{}
+		err = node.Init(config, baseConfig(defaultConfig))
+		assert.Nil(t, err)
+		node.OnMsg(ctx, newMsg(""))
+		node.Destroy()
+		assert.Equal(t, types.Success, relationType)
+	})
+
+	t.Run("Agent", func(t *testing.T) {
+		keyring := agent.NewKeyring()
+		assert.Nil(t, keyring.Add(agent.AddedKey{PrivateKey: clientPriv}))
+		socket := filepath.Join(dir, "agent.sock")
+		listener, err := net.Listen("unix", socket)
+		assert.Nil(t, err)
+		defer listener.Close()
+		go func() {
+			for {
+				conn, err := listener.Accept()
+				if err != nil {
+					return
+				}
+				go func() {
+					defer conn.Close()
+					_ = agent.ServeAgent(keyring, conn)
+				}()
+			}
+		}()
+
+		node := &SshNode{}
+		err = node.Init(config, baseConfig(types.Configuration{"useAgent": true, "agentSocket": socket, "cmd": "echo agent"}))
+		assert.Nil(t, err)
+		defer node.Destroy()
+		node.OnMsg(ctx, newMsg(""))
+		assert.Equal(t, types.Success, relationType)
+		assert.Equal(t, "agent\n", outMsg.GetData())
+	})
+
+	t.Run("Sftp", func(t *testing.T) {
+		remoteFile := filepath.Join(dir, "remote_${metadata.file}")
+		upload := &SshNode{}
+		err := upload.Init(config, baseConfig(types.Configuration{"password": "secret", "operation": "upload", "remotePath": remoteFile}))
+		assert.Nil(t, err)
+		defer upload.Destroy()
+		upload.OnMsg(ctx, newMsg("uploaded content"))
+		assert.Equal(t, types.Success, relationType)
+		content, err := os.ReadFile(filepath.Join(dir, "remote_data.txt"))
+		assert.Nil(t, err)
+		assert.Equal(t, "uploaded content", string(content))
+
+		download := &SshNode{}
+		err = download.Init(config, baseConfig(types.Configuration{"password": "secret", "operation": "download", "remotePath": remoteFile}))
+		assert.Nil(t, err)
+		defer download.Destroy()
+		download.OnMsg(ctx, newMsg(""))
+		assert.Equal(t, types.Success, relationType)
+		assert.Equal(t, "uploaded content", outMsg.GetData())
+
+		//下载到本地文件
+		localFile := filepath.Join(dir, "local.txt")
+		toFile := &SshNode{}
+		err = toFile.Init(config, baseConfig(types.Configuration{"password": "secret", "operation": "download", "remotePath": remoteFile, "localPath": localFile}))
+		assert.Nil(t, err)
+		defer toFile.Destroy()
+		toFile.OnMsg(ctx, newMsg("unchanged"))
+		assert.Equal(t, types.Success, relationType)
+		assert.Equal(t, "unchanged", outMsg.GetData())
+		content, err = os.ReadFile(localFile)
+		assert.Nil(t, err)
+		assert.Equal(t, "uploaded content", string(content))
+
+		//远程文件不存在
+		missing := &SshNode{}
+		err = missing.Init(config, baseConfig(types.Configuration{"password": "secret", "operation": "download", "remotePath": filepath.Join(dir, "missing")}))
+		assert.Nil(t, err)
+		defer missing.Destroy()
+		missing.OnMsg(ctx, newMsg(""))
+		assert.Equal(t, types.Failure, relationType)
+	})
+
+	t.Run("AuthFailed", func(t *testing.T) {
+		node := &SshNode{}
+		err := node.Init(config, baseConfig(types.Configuration{"password": "wrong", "cmd": "echo x"}))
+		assert.Nil(t, err)
+		defer node.Destroy()
+		node.OnMsg(ctx, newMsg(""))
+		assert.Equal(t, types.Failure, relationType)
+	})
+}
@@ -36,6 +36,8 @@
 //
 //   - ExprFilterNode: Evaluates complex expressions using expression language
 //     使用表达式语言评估复杂表达式
+//   - JsonPathFilterNode: Evaluates JMESPath queries on nested JSON
+//     在嵌套 JSON 上评估 JMESPath 查询
 //   - JsFilterNode: Executes JavaScript-based filter logic
 //     执行基于 JavaScript 的过滤逻辑
 //   - JsSwitchNode: JavaScript-based conditional routing
@@ -60,6 +62,8 @@
 //     高级表达式语言支持
 //   - JsFilterNode: JavaScript-based conditions
 //     基于 JavaScript 的条件
+//   - JsonPathFilterNode: JMESPath query conditions
+//     JMESPath 查询条件
 //
 // Message Routing:
 // 消息路由：
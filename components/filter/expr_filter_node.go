@@ -30,10 +30,10 @@ import (
 	"fmt"
 	"strings"
 
-	"github.com/expr-lang/expr"
 	"github.com/expr-lang/expr/vm"
 	"github.com/yunboom/rulego/api/types"
 	"github.com/yunboom/rulego/components/base"
+	"github.com/yunboom/rulego/utils/el"
 	"github.com/yunboom/rulego/utils/maps"
 )
 
@@ -118,7 +118,7 @@ func (x *ExprFilterNode) Init(ruleConfig types.Config, configuration types.Confi
 		return fmt.Errorf("expr can not be empty")
 	}
 	if err == nil {
-		x.program, err = expr.Compile(x.Config.Expr, expr.AllowUndefinedVariables())
+		x.program, err = el.CompileExpr(x.Config.Expr, ruleConfig.Udf, base.NodeUtils.GetExprTypes(configuration))
 	}
 	return err
 }
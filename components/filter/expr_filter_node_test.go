@@ -160,4 +160,31 @@ func TestExprFilterNode(t *testing.T) {
 		}
 		time.Sleep(time.Millisecond * 20)
 	})
+	t.Run("UdfAndTypeHints", func(t *testing.T) {
+		config := types.NewConfig()
+		config.RegisterUdf("isHot", func(temperature float64) bool {
+			return temperature > 50
+		})
+		node := &ExprFilterNode{}
+		err := node.Init(config, types.Configuration{
+			"expr": "isHot(msg.temperature) && substring(msg.name, 0, 1) == 'a'",
+		})
+		assert.Nil(t, err)
+		//类型提示，编译时检查类型
+		err = (&ExprFilterNode{}).Init(config, types.Configuration{
+			"expr":          "isHot(msg.name)",
+			types.ExprTypes: map[string]string{"msg.name": "string"},
+		})
+		assert.NotNil(t, err)
+
+		msg := test.Msg{
+			MetaData:   types.NewMetadata(),
+			MsgType:    "ACTIVITY_EVENT",
+			Data:       "{\"name\":\"aa\",\"temperature\":60}",
+			AfterSleep: time.Millisecond * 20,
+		}
+		test.NodeOnMsg(t, node, []test.Msg{msg}, func(msg types.RuleMsg, relationType string, err error) {
+			assert.Equal(t, types.True, relationType)
+		})
+	})
 }
@@ -30,6 +30,7 @@ import (
 	"fmt"
 
 	"github.com/yunboom/rulego/utils/js"
+	"github.com/yunboom/rulego/utils/lua"
 
 	"github.com/yunboom/rulego/api/types"
 	"github.com/yunboom/rulego/components/base"
@@ -43,6 +44,8 @@ const (
 	JsFilterType = "jsFilter"
 	// JsFilterFuncTemplate JS函数模板
 	JsFilterFuncTemplate = "function Filter(msg, metadata, msgType, dataType) { %s }"
+	// LuaFilterFuncTemplate Lua函数模板
+	LuaFilterFuncTemplate = "function Filter(msg, metadata, msgType, dataType) %s end"
 )
 
 // init 注册JsFilterNode组件
@@ -64,6 +67,9 @@ type JsFilterNodeConfiguration struct {
 	//
 	// 示例: "return msg.temperature > 25.0;"
 	JsScript string `json:"jsScript"`
+	// ScriptType 脚本类型：Js 或 Lua，默认 Js
+	// Lua 脚本中上下文对象为 ctx，示例: "return msg.temperature > 25.0"
+	ScriptType string `json:"scriptType"`
 }
 
 // JsFilterNode 使用JavaScript评估布尔条件的过滤器节点
@@ -91,8 +97,16 @@ func (x *JsFilterNode) New() types.Node {
 func (x *JsFilterNode) Init(ruleConfig types.Config, configuration types.Configuration) error {
 	err := maps.Map2Struct(configuration, &x.Config)
 	if err == nil {
-		jsScript := fmt.Sprintf(JsFilterFuncTemplate, x.Config.JsScript)
-		x.jsEngine, err = js.NewGojaJsEngine(ruleConfig, jsScript, base.NodeUtils.GetVars(configuration))
+		switch x.Config.ScriptType {
+		case types.Lua:
+			luaScript := fmt.Sprintf(LuaFilterFuncTemplate, x.Config.JsScript)
+			x.jsEngine, err = lua.NewLuaEngine(ruleConfig, luaScript, base.NodeUtils.GetVars(configuration))
+		case types.Js, types.AllScript:
+			jsScript := fmt.Sprintf(JsFilterFuncTemplate, x.Config.JsScript)
+			x.jsEngine, err = js.NewGojaJsEngine(ruleConfig, jsScript, base.NodeUtils.GetVars(configuration))
+		default:
+			err = fmt.Errorf("unsupported script type: %s", x.Config.ScriptType)
+		}
 	}
 	return err
 }
@@ -669,3 +669,37 @@ func TestJsFilterNodeJSONArraySupport(t *testing.T) {
 		assert.Equal(t, types.False, result2)
 	})
 }
+
+// TestJsFilterNodeLua 测试使用Lua脚本过滤
+func TestJsFilterNodeLua(t *testing.T) {
+	config := types.NewConfig()
+	node := &JsFilterNode{}
+	err := node.Init(config, types.Configuration{
+		"scriptType": types.Lua,
+		"jsScript":   "return msg.temperature > 50 and metadata.productType == 'test'",
+	})
+	assert.Nil(t, err)
+	defer node.Destroy()
+
+	metadata := types.BuildMetadata(map[string]string{"productType": "test"})
+	for data, expected := range map[string]string{`{"temperature":60}`: types.True, `{"temperature":40}`: types.False} {
+		var resultRelationType string
+		ctx := test.NewRuleContext(config, func(msg types.RuleMsg, relationType string, err error) {
+			assert.Nil(t, err)
+			resultRelationType = relationType
+		})
+		node.OnMsg(ctx, types.NewMsg(0, "TEST", types.JSON, metadata, data))
+		assert.Equal(t, expected, resultRelationType)
+	}
+
+	err = (&JsFilterNode{}).Init(config, types.Configuration{
+		"scriptType": types.Lua,
+		"jsScript":   "return msg.temperature > 50;}",
+	})
+	assert.NotNil(t, err)
+	err = (&JsFilterNode{}).Init(config, types.Configuration{
+		"scriptType": "Ruby",
+		"jsScript":   "return true",
+	})
+	assert.NotNil(t, err)
+}
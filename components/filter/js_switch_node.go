@@ -31,6 +31,7 @@ import (
 	"fmt"
 
 	"github.com/yunboom/rulego/utils/js"
+	"github.com/yunboom/rulego/utils/lua"
 
 	"github.com/yunboom/rulego/api/types"
 	"github.com/yunboom/rulego/components/base"
@@ -60,6 +61,9 @@ type JsSwitchNodeConfiguration struct {
 	//
 	// 示例: "return ['route1', 'route2'];"
 	JsScript string
+	// ScriptType 脚本类型：Js 或 Lua，默认 Js
+	// Lua 脚本中上下文对象为 ctx，示例: "return {'route1', 'route2'}"
+	ScriptType string `json:"scriptType"`
 }
 
 // JsSwitchNode 使用JavaScript确定消息路由路径的开关节点
@@ -90,8 +94,16 @@ func (x *JsSwitchNode) New() types.Node {
 func (x *JsSwitchNode) Init(ruleConfig types.Config, configuration types.Configuration) error {
 	err := maps.Map2Struct(configuration, &x.Config)
 	if err == nil {
-		jsScript := fmt.Sprintf("function Switch(msg, metadata, msgType, dataType) { %s }", x.Config.JsScript)
-		x.jsEngine, err = js.NewGojaJsEngine(ruleConfig, jsScript, base.NodeUtils.GetVars(configuration))
+		switch x.Config.ScriptType {
+		case types.Lua:
+			luaScript := fmt.Sprintf("function Switch(msg, metadata, msgType, dataType) %s end", x.Config.JsScript)
+			x.jsEngine, err = lua.NewLuaEngine(ruleConfig, luaScript, base.NodeUtils.GetVars(configuration))
+		case types.Js, types.AllScript:
+			jsScript := fmt.Sprintf("function Switch(msg, metadata, msgType, dataType) { %s }", x.Config.JsScript)
+			x.jsEngine, err = js.NewGojaJsEngine(ruleConfig, jsScript, base.NodeUtils.GetVars(configuration))
+		default:
+			err = fmt.Errorf("unsupported script type: %s", x.Config.ScriptType)
+		}
 		if v := ruleConfig.Properties.GetValue(KeyOtherRelationTypeName); v != "" {
 			x.defaultRelationType = v
 		} else {
@@ -564,3 +564,24 @@ func TestJsSwitchNodeJSONArraySupport(t *testing.T) {
 		}
 	})
 }
+
+// TestJsSwitchNodeLua 测试使用Lua脚本路由
+func TestJsSwitchNodeLua(t *testing.T) {
+	config := types.NewConfig()
+	node := &JsSwitchNode{}
+	err := node.Init(config, types.Configuration{
+		"scriptType": types.Lua,
+		"jsScript":   "if msg.temperature > 50 then return {'high', 'alarm'} end return {'normal'}",
+	})
+	assert.Nil(t, err)
+	defer node.Destroy()
+
+	var relationTypes []string
+	ctx := test.NewRuleContext(config, func(msg types.RuleMsg, relationType string, err error) {
+		assert.Nil(t, err)
+		relationTypes = append(relationTypes, relationType)
+	})
+	node.OnMsg(ctx, types.NewMsg(0, "TEST", types.JSON, types.NewMetadata(), `{"temperature":60}`))
+	time.Sleep(time.Millisecond * 100)
+	assert.Equal(t, 2, len(relationTypes))
+}
@@ -0,0 +1,125 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package filter
+
+//规则链节点配置示例：
+//{
+//        "id": "s1",
+//        "type": "jsonPathFilter",
+//        "name": "JMESPath过滤器",
+//        "debugMode": false,
+//        "configuration": {
+//          "query": "msg.items[?status=='error']"
+//        }
+//      }
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jmespath/go-jmespath"
+	"github.com/yunboom/rulego/api/types"
+	"github.com/yunboom/rulego/components/base"
+	"github.com/yunboom/rulego/utils/el"
+	"github.com/yunboom/rulego/utils/maps"
+)
+
+// init 注册JsonPathFilterNode组件
+// init registers the JsonPathFilterNode component with the default registry.
+func init() {
+	Registry.Add(&JsonPathFilterNode{})
+}
+
+// JsonPathFilterNodeConfiguration JsonPathFilterNode配置结构
+// JsonPathFilterNodeConfiguration defines the configuration structure for the JsonPathFilterNode component.
+type JsonPathFilterNodeConfiguration struct {
+	// Query 用于过滤评估的JMESPath查询，可以带 jq: 前缀
+	// Query contains the JMESPath query to evaluate for filtering, the jq: prefix is optional.
+	// The query has access to the same variables as exprFilter: id, ts, data, msg, metadata, msgType, dataType.
+	//
+	// The result is evaluated by the JMESPath truthiness:
+	//   - false, null, empty string, empty array, empty object: routed to "False" relation
+	//   - other values: routed to "True" relation
+	//
+	// Example queries:
+	// 查询示例：
+	//   - "msg.items[?status=='error']"
+	//   - "msg.temperature > `50`"
+	//   - "length(msg.items[?price > `100`]) > `2`"
+	//   - "metadata.deviceType == 'sensor'"
+	Query string
+}
+
+// JsonPathFilterNode 使用JMESPath查询过滤消息，查询结果为真路由到True链，否则路由到False链
+// JsonPathFilterNode filters messages using JMESPath queries, the message is routed to True
+// relation if the query result is truthy, otherwise to False relation.
+//
+// JMESPath适合查询嵌套的JSON数组，例如过滤、切片、投影和聚合函数。
+// JMESPath is suitable for querying nested JSON arrays, e.g. filters, slices, projections and functions.
+type JsonPathFilterNode struct {
+	// Config JMESPath过滤器配置
+	// Config holds the JMESPath filter configuration
+	Config JsonPathFilterNodeConfiguration
+
+	// query 编译后的查询
+	// query is the compiled query
+	query *jmespath.JMESPath
+}
+
+// Type 返回组件类型
+// Type returns the component type identifier.
+func (x *JsonPathFilterNode) Type() string {
+	return "jsonPathFilter"
+}
+
+// New 创建新实例
+// New creates a new instance.
+func (x *JsonPathFilterNode) New() types.Node {
+	return &JsonPathFilterNode{Config: JsonPathFilterNodeConfiguration{
+		Query: "",
+	}}
+}
+
+// Init 初始化组件，编译查询
+// Init initializes the component.
+func (x *JsonPathFilterNode) Init(ruleConfig types.Config, configuration types.Configuration) error {
+	err := maps.Map2Struct(configuration, &x.Config)
+	if strings.TrimSpace(x.Config.Query) == "" {
+		return fmt.Errorf("query can not be empty")
+	}
+	if err == nil {
+		x.query, err = el.CompileQuery(x.Config.Query)
+	}
+	return err
+}
+
+// OnMsg 处理消息，执行查询并根据结果路由消息
+// OnMsg processes incoming messages by evaluating the compiled query.
+func (x *JsonPathFilterNode) OnMsg(ctx types.RuleContext, msg types.RuleMsg) {
+	evn := base.NodeUtils.GetEvn(ctx, msg)
+	if out, err := el.Search(x.query, evn); err != nil {
+		ctx.TellFailure(msg, err)
+	} else if el.Truthy(out) {
+		ctx.TellNext(msg, types.True)
+	} else {
+		ctx.TellNext(msg, types.False)
+	}
+}
+
+// Destroy 清理资源
+// Destroy cleans up resources.
+func (x *JsonPathFilterNode) Destroy() {
+}
@@ -0,0 +1,122 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package filter
+
+import (
+	"testing"
+	"time"
+
+	"github.com/yunboom/rulego/api/types"
+	"github.com/yunboom/rulego/test"
+	"github.com/yunboom/rulego/test/assert"
+)
+
+func TestJsonPathFilterNode(t *testing.T) {
+	var targetNodeType = "jsonPathFilter"
+
+	t.Run("NewNode", func(t *testing.T) {
+		test.NodeNew(t, targetNodeType, &JsonPathFilterNode{}, types.Configuration{
+			"query": "",
+		}, Registry)
+	})
+
+	t.Run("InitNode", func(t *testing.T) {
+		test.NodeInit(t, targetNodeType, types.Configuration{
+			"query": "msg.items[?status=='error']",
+		}, types.Configuration{
+			"query": "msg.items[?status=='error']",
+		}, Registry)
+		_, err := test.CreateAndInitNode(targetNodeType, types.Configuration{
+			"query": "",
+		}, Registry)
+		assert.Equal(t, "query can not be empty", err.Error())
+		_, err = test.CreateAndInitNode(targetNodeType, types.Configuration{
+			"query": "msg.items[?",
+		}, Registry)
+		assert.NotNil(t, err)
+	})
+
+	t.Run("OnMsg", func(t *testing.T) {
+		node1, err := test.CreateAndInitNode(targetNodeType, types.Configuration{
+			"query": "msg.items[?status=='error']",
+		}, Registry)
+		assert.Nil(t, err)
+		node2, err := test.CreateAndInitNode(targetNodeType, types.Configuration{
+			"query": "jq: length(msg.items[?price > `10`]) > `1` && metadata.productType == 'test'",
+		}, Registry)
+		assert.Nil(t, err)
+		node3, err := test.CreateAndInitNode(targetNodeType, types.Configuration{
+			"query": "abs(msg)",
+		}, Registry)
+		assert.Nil(t, err)
+
+		metaData := types.BuildMetadata(make(map[string]string))
+		metaData.PutValue("productType", "test")
+		msg1 := test.Msg{
+			MetaData:   metaData,
+			MsgType:    "ACTIVITY_EVENT",
+			Data:       `{"items":[{"status":"ok","price":5},{"status":"error","price":20},{"status":"ok","price":30}]}`,
+			AfterSleep: time.Millisecond * 20,
+		}
+		msg2 := test.Msg{
+			MetaData:   metaData,
+			MsgType:    "ACTIVITY_EVENT",
+			Data:       `{"items":[{"status":"ok","price":5}]}`,
+			AfterSleep: time.Millisecond * 20,
+		}
+		var nodeList = []test.NodeAndCallback{
+			{
+				Node:    node1,
+				MsgList: []test.Msg{msg1},
+				Callback: func(msg types.RuleMsg, relationType string, err error) {
+					assert.Equal(t, types.True, relationType)
+				},
+			},
+			{
+				Node:    node1,
+				MsgList: []test.Msg{msg2},
+				Callback: func(msg types.RuleMsg, relationType string, err error) {
+					assert.Equal(t, types.False, relationType)
+				},
+			},
+			{
+				Node:    node2,
+				MsgList: []test.Msg{msg1},
+				Callback: func(msg types.RuleMsg, relationType string, err error) {
+					assert.Equal(t, types.True, relationType)
+				},
+			},
+			{
+				Node:    node2,
+				MsgList: []test.Msg{msg2},
+				Callback: func(msg types.RuleMsg, relationType string, err error) {
+					assert.Equal(t, types.False, relationType)
+				},
+			},
+			{
+				Node:    node3,
+				MsgList: []test.Msg{msg2},
+				Callback: func(msg types.RuleMsg, relationType string, err error) {
+					assert.Equal(t, types.Failure, relationType)
+				},
+			},
+		}
+		for _, item := range nodeList {
+			test.NodeOnMsgWithChildren(t, item.Node, item.MsgList, item.ChildrenNodes, item.Callback)
+		}
+	})
+}
@@ -33,6 +33,7 @@ import (
 	"github.com/expr-lang/expr/vm"
 	"github.com/yunboom/rulego/api/types"
 	"github.com/yunboom/rulego/components/base"
+	"github.com/yunboom/rulego/utils/el"
 	"github.com/yunboom/rulego/utils/maps"
 )
 
@@ -159,7 +160,7 @@ func (x *SwitchNode) Init(ruleConfig types.Config, configuration types.Configura
 	if err == nil {
 		x.Cases = nil
 		for _, item := range x.Config.Cases {
-			if program, err := expr.Compile(item.Case, expr.AllowUndefinedVariables(), expr.AsBool()); err == nil {
+			if program, err := el.CompileExpr(item.Case, ruleConfig.Udf, base.NodeUtils.GetExprTypes(configuration), expr.AsBool()); err == nil {
 				x.Cases = append(x.Cases, &caseProgram{
 					relationType: item.Then,
 					program:      program,
@@ -37,10 +37,14 @@
 //     基于 JavaScript 的数据转换，具有完整的脚本功能
 //   - ExprTransformNode: Expression-based field transformation using expression language
 //     使用表达式语言进行基于表达式的字段转换
+//   - JsonPathTransformNode: JMESPath query-based field extraction and reshaping
+//     使用 JMESPath 查询提取和重构字段
 //   - TemplateNode: Template-based message formatting and data restructuring
 //     基于模板的消息格式化和数据重构
 //   - MetadataTransformNode: Message metadata modification and manipulation
 //     消息元数据修改和操作
+//   - FieldMappingNode: Declarative field rename, move, cast, default and drop without scripting
+//     声明式的字段重命名、移动、类型转换、默认值和删除，不需要编写脚本
 //
 // Component Categories by Function:
 // 按功能分类的组件：
@@ -51,17 +55,24 @@
 //     完整的 JavaScript 转换，可访问内置函数
 //   - ExprTransformNode: Expression language for simple field transformations
 //     表达式语言，用于简单的字段转换
+//   - JsonPathTransformNode: JMESPath queries for nested JSON arrays
+//     JMESPath 查询，用于嵌套的 JSON 数组
 //
 // Template and Formatting:
 // 模板和格式化：
-//   - TemplateNode: Apply templates for structured data formatting
-//     应用模板进行结构化数据格式化
+//   - TemplateNode: Apply Go template, Mustache or Jinja templates with shared partials for structured data formatting
+//     使用 Go template、Mustache 或者 Jinja 模板以及共享片段进行结构化数据格式化
 //
 // Metadata Processing:
 // 元数据处理：
 //   - MetadataTransformNode: Transform and manipulate message metadata
 //     转换和操作消息元数据
 //
+// Declarative Mapping:
+// 声明式映射：
+//   - FieldMappingNode: Mapping rules editable in the visual editor
+//     可在可视化编辑器中编辑的映射规则
+//
 // Transform Output Relations:
 // 转换输出关系：
 //
@@ -79,6 +90,8 @@
 //     内置变量：msg、metadata、msgType、dataType
 //   - Built-in functions: $ctx.ChainCache(), $ctx.GlobalCache(), global.*, vars.*
 //     内置函数：$ctx.ChainCache()、$ctx.GlobalCache()、global.*、vars.*
+//   - Atomic cache operations: Incr, Decr, CompareAndSet, ListPush, ListTrim, ListRange
+//     缓存原子操作：Incr、Decr、CompareAndSet、ListPush、ListTrim、ListRange
 //   - Modern syntax: async/await, Promise, let/const, arrow functions
 //     现代语法：async/await、Promise、let/const、箭头函数
 //
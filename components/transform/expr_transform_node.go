@@ -32,10 +32,10 @@ package transform
 //	}
 //}
 import (
-	"github.com/expr-lang/expr"
 	"github.com/expr-lang/expr/vm"
 	"github.com/yunboom/rulego/api/types"
 	"github.com/yunboom/rulego/components/base"
+	"github.com/yunboom/rulego/utils/el"
 	"github.com/yunboom/rulego/utils/maps"
 	"github.com/yunboom/rulego/utils/str"
 	"strings"
@@ -102,7 +102,7 @@ func (x *ExprTransformNode) Init(ruleConfig types.Config, configuration types.Co
 	err := maps.Map2Struct(configuration, &x.Config)
 	if err == nil {
 		if exprV := strings.TrimSpace(x.Config.Expr); exprV != "" {
-			if program, err := expr.Compile(exprV, expr.AllowUndefinedVariables()); err != nil {
+			if program, err := el.CompileExpr(exprV, ruleConfig.Udf, base.NodeUtils.GetExprTypes(configuration)); err != nil {
 				return err
 			} else {
 				x.program = program
@@ -110,7 +110,7 @@ func (x *ExprTransformNode) Init(ruleConfig types.Config, configuration types.Co
 		} else {
 			x.programMapping = make(map[string]*vm.Program)
 			for k, v := range x.Config.Mapping {
-				if program, err := expr.Compile(v, expr.AllowUndefinedVariables()); err != nil {
+				if program, err := el.CompileExpr(v, ruleConfig.Udf, base.NodeUtils.GetExprTypes(configuration)); err != nil {
 					return err
 				} else {
 					x.programMapping[k] = program
@@ -0,0 +1,367 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package transform
+
+//规则链节点配置示例：
+//{
+//	"id": "s1",
+//	"type": "fieldMapping",
+//	"name": "字段映射",
+//	"debugMode": false,
+//	"configuration": {
+//		"rules": [
+//			{"source": "msg.temp", "target": "msg.temperature", "type": "float", "drop": true},
+//			{"source": "msg.status", "target": "metadata.status", "lookup": {"0": "offline", "1": "online"}},
+//			{"source": "msg.unit", "default": "C"},
+//			{"source": "msg.debug", "drop": true},
+//			{"target": "msg.alarm", "default": true, "condition": "msg.temp > 50"}
+//		]
+//	}
+//}
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/expr-lang/expr/vm"
+	"github.com/yunboom/rulego/api/types"
+	"github.com/yunboom/rulego/components/base"
+	"github.com/yunboom/rulego/utils/cast"
+	"github.com/yunboom/rulego/utils/el"
+	"github.com/yunboom/rulego/utils/json"
+	"github.com/yunboom/rulego/utils/maps"
+	"github.com/yunboom/rulego/utils/str"
+)
+
+// 字段映射的类型转换
+const (
+	CastString = "string"
+	CastInt    = "int"
+	CastFloat  = "float"
+	CastBool   = "bool"
+	CastJson   = "json"
+)
+
+const (
+	msgPathPrefix      = types.MsgKey + "."
+	metadataPathPrefix = types.MetadataKey + "."
+)
+
+func init() {
+	Registry.Add(&FieldMappingNode{})
+}
+
+// FieldMappingRule 字段映射规则
+type FieldMappingRule struct {
+	//源字段路径，msg.xx 或者 metadata.xx，不带前缀表示msg字段，msg字段支持嵌套路径，例如：msg.location.x
+	//为空表示把Default值写入目标字段
+	Source string
+	//目标字段路径，格式和Source相同，为空表示源字段
+	Target string
+	//类型转换：string、int、float、bool、json，为空不转换
+	Type string
+	//源字段不存在或者为null时使用的默认值
+	Default interface{}
+	//值映射表，key为源值的字符串形式，没有匹配的值保持不变
+	Lookup map[string]interface{}
+	//是否删除源字段。Target为空只删除源字段，否则把源字段移动到目标字段
+	Drop bool
+	//条件表达式，表达式结果为true才执行该规则，为空总是执行
+	Condition string
+}
+
+// FieldMappingNodeConfiguration 节点配置
+type FieldMappingNodeConfiguration struct {
+	//映射规则，按照顺序执行
+	Rules []FieldMappingRule
+}
+
+// FieldMappingNode 使用声明式的映射规则重命名、移动、转换类型、设置默认值和删除msg或者metadata字段，不需要编写脚本
+// 每条规则按照以下步骤执行：
+//  1. 如果配置了condition，表达式结果为false跳过该规则
+//  2. 读取源字段的值，如果不存在或者为null使用default值
+//  3. 如果配置了lookup，使用映射表转换值
+//  4. 如果配置了type，转换值的类型
+//  5. 把值写入目标字段，如果drop=true，删除源字段
+//
+// 规则修改msg字段时，msg必须是JSON对象，处理后消息的dataType为JSON
+// 条件表达式可以访问和 exprFilter 相同的变量，使用的是处理前的消息
+type FieldMappingNode struct {
+	//节点配置
+	Config FieldMappingNodeConfiguration
+	rules  []fieldMappingRule
+	// 是否有规则读取或者写入msg字段
+	useMsg bool
+	// 是否有条件表达式
+	hasCondition bool
+}
+
+// fieldMappingRule 解析后的映射规则
+type fieldMappingRule struct {
+	FieldMappingRule
+	source    fieldPath
+	target    fieldPath
+	condition *vm.Program
+}
+
+// fieldPath 字段路径
+type fieldPath struct {
+	isMetadata bool
+	key        string
+}
+
+func (p fieldPath) isEmpty() bool {
+	return p.key == ""
+}
+
+// Type 组件类型
+func (x *FieldMappingNode) Type() string {
+	return "fieldMapping"
+}
+
+func (x *FieldMappingNode) New() types.Node {
+	return &FieldMappingNode{Config: FieldMappingNodeConfiguration{
+		Rules: []FieldMappingRule{
+			{Source: "msg.temp", Target: "msg.temperature", Type: CastFloat},
+		},
+	}}
+}
+
+// Def 可视化编辑的表单定义
+func (x *FieldMappingNode) Def() types.ComponentForm {
+	relationTypes := []string{types.Success, types.Failure}
+	var typeOptions []map[string]interface{}
+	for _, item := range []string{CastString, CastInt, CastFloat, CastBool, CastJson} {
+		typeOptions = append(typeOptions, map[string]interface{}{"label": item, "value": item})
+	}
+	return types.ComponentForm{
+		Label:         "Field Mapping",
+		Desc:          "Rename, move, cast, default and drop the fields of msg and metadata by mapping rules",
+		RelationTypes: &relationTypes,
+		Fields: types.ComponentFormFieldList{
+			{
+				Name:         "rules",
+				Type:         "array",
+				Label:        "Rules",
+				Desc:         "Mapping rules, executed in order",
+				DefaultValue: x.New().(*FieldMappingNode).Config.Rules,
+				Required:     true,
+				Rules:        []map[string]interface{}{{"required": true, "message": "This field is required"}},
+				Fields: types.ComponentFormFieldList{
+					{Name: "source", Type: "string", Label: "Source", Desc: "Source path, e.g. msg.temp, metadata.deviceId. Empty to write the default value"},
+					{Name: "target", Type: "string", Label: "Target", Desc: "Target path, empty for the source path"},
+					{Name: "type", Type: "string", Label: "Type", Desc: "Cast the value to the type",
+						Component: map[string]interface{}{"type": "select", "options": typeOptions}},
+					{Name: "default", Type: "string", Label: "Default", Desc: "Default value if the source field does not exist or is null"},
+					{Name: "lookup", Type: "map", Label: "Lookup", Desc: "Value lookup table, the key is the source value"},
+					{Name: "drop", Type: "bool", Label: "Drop", Desc: "Drop the source field, move it if the target is set"},
+					{Name: "condition", Type: "string", Label: "Condition", Desc: "Apply the rule only if the expression is true, e.g. msg.temp > 50",
+						Component: map[string]interface{}{"type": "codeEditor", "language": "expr"}},
+				},
+			},
+		},
+	}
+}
+
+// Init 初始化
+func (x *FieldMappingNode) Init(ruleConfig types.Config, configuration types.Configuration) error {
+	//删除默认配置
+	x.Config.Rules = nil
+	if err := maps.Map2Struct(configuration, &x.Config); err != nil {
+		return err
+	}
+	if len(x.Config.Rules) == 0 {
+		return errors.New("rules can not be empty")
+	}
+	x.rules, x.useMsg, x.hasCondition = nil, false, false
+	for i, item := range x.Config.Rules {
+		rule := fieldMappingRule{FieldMappingRule: item}
+		if strings.TrimSpace(item.Source) == "" && strings.TrimSpace(item.Target) == "" {
+			return fmt.Errorf("rule %d: source and target can not be empty", i)
+		}
+		var err error
+		if rule.source, err = parseFieldPath(item.Source); err != nil {
+			return fmt.Errorf("rule %d: %w", i, err)
+		}
+		if rule.target, err = parseFieldPath(item.Target); err != nil {
+			return fmt.Errorf("rule %d: %w", i, err)
+		}
+		if rule.target.isEmpty() {
+			rule.target = rule.source
+		}
+		switch item.Type {
+		case "", CastString, CastInt, CastFloat, CastBool, CastJson:
+		default:
+			return fmt.Errorf("rule %d: unsupported type: %s", i, item.Type)
+		}
+		if condition := strings.TrimSpace(item.Condition); condition != "" {
+			if rule.condition, err = el.CompileExpr(condition, ruleConfig.Udf, base.NodeUtils.GetExprTypes(configuration)); err != nil {
+				return fmt.Errorf("rule %d: %w", i, err)
+			}
+			x.hasCondition = true
+		}
+		if (!rule.source.isEmpty() && !rule.source.isMetadata) || !rule.target.isMetadata {
+			x.useMsg = true
+		}
+		x.rules = append(x.rules, rule)
+	}
+	return nil
+}
+
+// OnMsg 处理消息
+func (x *FieldMappingNode) OnMsg(ctx types.RuleContext, msg types.RuleMsg) {
+	var evn map[string]interface{}
+	if x.hasCondition {
+		evn = base.NodeUtils.GetEvn(ctx, msg)
+	}
+	var data map[string]interface{}
+	if x.useMsg {
+		data = make(map[string]interface{})
+		if msgData := msg.GetData(); strings.TrimSpace(msgData) != "" {
+			if err := json.Unmarshal([]byte(msgData), &data); err != nil {
+				ctx.TellFailure(msg, fmt.Errorf("msg data is not a JSON object: %w", err))
+				return
+			}
+		}
+	}
+	var exprVm = vm.VM{}
+	for i, rule := range x.rules {
+		if rule.condition != nil {
+			if out, err := exprVm.Run(rule.condition, evn); err != nil {
+				ctx.TellFailure(msg, fmt.Errorf("rule %d: %w", i, err))
+				return
+			} else if ok, _ := out.(bool); !ok {
+				continue
+			}
+		}
+		var value interface{}
+		if !rule.source.isEmpty() {
+			value = x.get(rule.source, msg, data)
+		}
+		if value == nil {
+			value = rule.Default
+		}
+		if rule.Drop && !rule.source.isEmpty() {
+			x.delete(rule.source, msg, data)
+			if rule.target == rule.source {
+				continue
+			}
+		}
+		if value == nil {
+			continue
+		}
+		if rule.Lookup != nil {
+			if v, ok := rule.Lookup[str.ToString(value)]; ok {
+				value = v
+			}
+		}
+		value, err := castValue(rule.Type, value)
+		if err != nil {
+			ctx.TellFailure(msg, fmt.Errorf("rule %d: %w", i, err))
+			return
+		}
+		x.set(rule.target, msg, data, value)
+	}
+	if x.useMsg {
+		if b, err := json.Marshal(data); err != nil {
+			ctx.TellFailure(msg, err)
+			return
+		} else {
+			msg.DataType = types.JSON
+			msg.SetBytes(b)
+		}
+	}
+	ctx.TellSuccess(msg)
+}
+
+// Destroy 销毁
+func (x *FieldMappingNode) Destroy() {
+}
+
+func (x *FieldMappingNode) get(path fieldPath, msg types.RuleMsg, data map[string]interface{}) interface{} {
+	if path.isMetadata {
+		if msg.Metadata.Has(path.key) {
+			return msg.Metadata.GetValue(path.key)
+		}
+		return nil
+	}
+	return maps.Get(data, path.key)
+}
+
+func (x *FieldMappingNode) set(path fieldPath, msg types.RuleMsg, data map[string]interface{}, value interface{}) {
+	if path.isMetadata {
+		msg.Metadata.PutValue(path.key, str.ToString(value))
+	} else {
+		maps.Set(data, path.key, value)
+	}
+}
+
+func (x *FieldMappingNode) delete(path fieldPath, msg types.RuleMsg, data map[string]interface{}) {
+	if path.isMetadata {
+		msg.Metadata.Delete(path.key)
+	} else {
+		maps.Delete(data, path.key)
+	}
+}
+
+// parseFieldPath 解析字段路径，metadata.xx 表示元数据字段，msg.xx 或者不带前缀表示msg字段
+func parseFieldPath(path string) (fieldPath, error) {
+	path = strings.TrimSpace(path)
+	if path == "" {
+		return fieldPath{}, nil
+	}
+	var result fieldPath
+	if strings.HasPrefix(path, metadataPathPrefix) {
+		result = fieldPath{isMetadata: true, key: strings.TrimPrefix(path, metadataPathPrefix)}
+	} else {
+		result = fieldPath{key: strings.TrimPrefix(path, msgPathPrefix)}
+	}
+	if result.key == "" || path == types.MsgKey || path == types.MetadataKey {
+		return result, fmt.Errorf("invalid path: %s", path)
+	}
+	return result, nil
+}
+
+// castValue 转换值的类型
+func castValue(typeName string, value interface{}) (interface{}, error) {
+	switch typeName {
+	case CastString:
+		return str.ToString(value), nil
+	case CastInt:
+		if v, err := cast.ToInt64E(value); err == nil {
+			return v, nil
+		} else if f, fErr := cast.ToFloat64E(value); fErr == nil {
+			return int64(f), nil
+		} else {
+			return nil, err
+		}
+	case CastFloat:
+		return cast.ToFloat64E(value)
+	case CastBool:
+		return cast.ToBoolE(value)
+	case CastJson:
+		if s, ok := value.(string); ok {
+			var v interface{}
+			if err := json.Unmarshal([]byte(s), &v); err != nil {
+				return nil, err
+			}
+			return v, nil
+		}
+		return value, nil
+	}
+	return value, nil
+}
@@ -0,0 +1,175 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package transform
+
+import (
+	"testing"
+	"time"
+
+	"github.com/yunboom/rulego/api/types"
+	"github.com/yunboom/rulego/test"
+	"github.com/yunboom/rulego/test/assert"
+	"github.com/yunboom/rulego/utils/json"
+	"github.com/yunboom/rulego/utils/reflect"
+)
+
+func TestFieldMappingNode(t *testing.T) {
+	var targetNodeType = "fieldMapping"
+
+	t.Run("NewNode", func(t *testing.T) {
+		test.NodeNew(t, targetNodeType, &FieldMappingNode{}, types.Configuration{
+			"rules": []FieldMappingRule{
+				{Source: "msg.temp", Target: "msg.temperature", Type: CastFloat},
+			},
+		}, Registry)
+	})
+
+	t.Run("InitNode", func(t *testing.T) {
+		_, err := test.CreateAndInitNode(targetNodeType, types.Configuration{}, Registry)
+		assert.Equal(t, "rules can not be empty", err.Error())
+		_, err = test.CreateAndInitNode(targetNodeType, types.Configuration{
+			"rules": []interface{}{map[string]interface{}{"type": "int"}},
+		}, Registry)
+		assert.Equal(t, "rule 0: source and target can not be empty", err.Error())
+		_, err = test.CreateAndInitNode(targetNodeType, types.Configuration{
+			"rules": []interface{}{map[string]interface{}{"source": "msg.a", "type": "date"}},
+		}, Registry)
+		assert.Equal(t, "rule 0: unsupported type: date", err.Error())
+		_, err = test.CreateAndInitNode(targetNodeType, types.Configuration{
+			"rules": []interface{}{map[string]interface{}{"source": "metadata.", "target": "msg.a"}},
+		}, Registry)
+		assert.Equal(t, "rule 0: invalid path: metadata.", err.Error())
+		_, err = test.CreateAndInitNode(targetNodeType, types.Configuration{
+			"rules": []interface{}{map[string]interface{}{"source": "msg.a", "condition": "msg.a >"}},
+		}, Registry)
+		assert.NotNil(t, err)
+	})
+
+	t.Run("Def", func(t *testing.T) {
+		form := reflect.GetComponentForm(&FieldMappingNode{})
+		assert.Equal(t, "fieldMapping", form.Type)
+		assert.Equal(t, "transform", form.Category)
+		field, ok := form.Fields.GetField("rules")
+		assert.True(t, ok)
+		assert.Equal(t, "array", field.Type)
+		assert.Equal(t, 7, len(field.Fields))
+		typeField, _ := field.Fields.GetField("type")
+		assert.Equal(t, "select", typeField.Component["type"])
+	})
+
+	t.Run("OnMsg", func(t *testing.T) {
+		node1, err := test.CreateAndInitNode(targetNodeType, types.Configuration{
+			"rules": []interface{}{
+				map[string]interface{}{"source": "msg.temp", "target": "msg.temperature", "type": "float", "drop": true},
+				map[string]interface{}{"source": "msg.status", "target": "metadata.status", "lookup": map[string]interface{}{"0": "offline", "1": "online"}},
+				map[string]interface{}{"source": "msg.unit", "default": "C"},
+				map[string]interface{}{"source": "msg.debug", "drop": true},
+				map[string]interface{}{"source": "metadata.deviceId", "target": "msg.device.id"},
+				map[string]interface{}{"source": "msg.count", "type": "int"},
+				map[string]interface{}{"source": "metadata.productType", "drop": true},
+				map[string]interface{}{"target": "msg.alarm", "default": true, "condition": "float(msg.temp) > 50"},
+				map[string]interface{}{"target": "msg.normal", "default": true, "condition": "float(msg.temp) <= 50"},
+			},
+		}, Registry)
+		assert.Nil(t, err)
+		node2, err := test.CreateAndInitNode(targetNodeType, types.Configuration{
+			"rules": []interface{}{
+				map[string]interface{}{"source": "msg.temp", "type": "int"},
+			},
+		}, Registry)
+		assert.Nil(t, err)
+		node3, err := test.CreateAndInitNode(targetNodeType, types.Configuration{
+			"rules": []interface{}{
+				map[string]interface{}{"source": "metadata.a", "target": "metadata.b", "type": "int"},
+			},
+		}, Registry)
+		assert.Nil(t, err)
+
+		metaData := types.BuildMetadata(map[string]string{"deviceId": "d1", "productType": "test", "a": "12"})
+		msg := test.Msg{
+			MetaData:   metaData,
+			MsgType:    "ACTIVITY_EVENT",
+			Data:       `{"temp":"56.5","status":1,"debug":"x","count":"3"}`,
+			AfterSleep: time.Millisecond * 20,
+		}
+		var nodeList = []test.NodeAndCallback{
+			{
+				Node:    node1,
+				MsgList: []test.Msg{msg},
+				Callback: func(msg types.RuleMsg, relationType string, err error) {
+					assert.Equal(t, types.Success, relationType)
+					assert.Equal(t, types.JSON, msg.DataType)
+					var result map[string]interface{}
+					assert.Nil(t, json.Unmarshal([]byte(msg.GetData()), &result))
+					assert.Equal(t, map[string]interface{}{
+						"temperature": 56.5,
+						"status":      float64(1),
+						"unit":        "C",
+						"device":      map[string]interface{}{"id": "d1"},
+						"count":       float64(3),
+						"alarm":       true,
+					}, result)
+					assert.Equal(t, "online", msg.Metadata.GetValue("status"))
+					assert.False(t, msg.Metadata.Has("productType"))
+				},
+			},
+			{
+				Node: node2,
+				MsgList: []test.Msg{{
+					MetaData:   types.NewMetadata(),
+					MsgType:    "ACTIVITY_EVENT",
+					Data:       `{"temp":"abc"}`,
+					AfterSleep: time.Millisecond * 20,
+				}},
+				Callback: func(msg types.RuleMsg, relationType string, err error) {
+					assert.Equal(t, types.Failure, relationType)
+				},
+			},
+			{
+				Node: node2,
+				MsgList: []test.Msg{{
+					MetaData:   types.NewMetadata(),
+					MsgType:    "ACTIVITY_EVENT",
+					DataType:   types.TEXT,
+					Data:       `abc`,
+					AfterSleep: time.Millisecond * 20,
+				}},
+				Callback: func(msg types.RuleMsg, relationType string, err error) {
+					assert.Equal(t, types.Failure, relationType)
+				},
+			},
+			{
+				Node: node3,
+				MsgList: []test.Msg{{
+					MetaData:   types.BuildMetadata(map[string]string{"a": "12"}),
+					MsgType:    "ACTIVITY_EVENT",
+					DataType:   types.TEXT,
+					Data:       `abc`,
+					AfterSleep: time.Millisecond * 20,
+				}},
+				Callback: func(msg types.RuleMsg, relationType string, err error) {
+					assert.Equal(t, types.Success, relationType)
+					assert.Equal(t, "abc", msg.GetData())
+					assert.Equal(t, "12", msg.Metadata.GetValue("b"))
+				},
+			},
+		}
+		for _, item := range nodeList {
+			test.NodeOnMsgWithChildren(t, item.Node, item.MsgList, item.ChildrenNodes, item.Callback)
+		}
+	})
+}
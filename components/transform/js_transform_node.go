@@ -32,6 +32,7 @@ import (
 	"strings"
 
 	"github.com/yunboom/rulego/utils/js"
+	"github.com/yunboom/rulego/utils/lua"
 
 	"github.com/yunboom/rulego/api/types"
 	"github.com/yunboom/rulego/components/base"
@@ -46,6 +47,8 @@ const (
 	JsTransformType = "jsTransform"
 	// JsTransformFuncTemplate JS函数模板
 	JsTransformFuncTemplate = "function Transform(msg, metadata, msgType, dataType) { %s }"
+	// LuaTransformFuncTemplate Lua函数模板
+	LuaTransformFuncTemplate = "function Transform(msg, metadata, msgType, dataType) %s end"
 	// JsTransformFuncName JS函数名
 	JsTransformFuncName = "Transform"
 )
@@ -69,6 +72,10 @@ type JsTransformNodeConfiguration struct {
 	// 支持修改消息的任意字段，dataType字段可选
 	// Supports modifying any message fields, dataType field is optional
 	JsScript string
+	// ScriptType 脚本类型：Js 或 Lua，默认 Js。Lua 脚本中上下文对象为 ctx
+	// ScriptType script type: Js or Lua, default Js. The context object is ctx in Lua scripts
+	// Lua 示例 - Lua example: "msg.aa = 66; return {msg = msg, metadata = metadata, msgType = msgType}"
+	ScriptType string `json:"scriptType"`
 }
 
 // JsTransformNode JavaScript消息转换节点，使用JavaScript脚本对消息进行转换处理
@@ -100,6 +107,12 @@ type JsTransformNodeConfiguration struct {
 //	cache.Delete("key"); // 删除缓存 - Delete cache
 //	let values = cache.GetByPrefix("prefix_"); // 获取前缀匹配的缓存 - Get caches by prefix
 //	cache.DeleteByPrefix("prefix_"); // 删除前缀匹配的缓存 - Delete caches by prefix
+//	let count = cache.Incr("count", 1, "1h"); // 原子增加计数，返回新值 - Atomically increment, returns the new value
+//	cache.Decr("count", 1, ""); // 原子减少计数 - Atomically decrement
+//	let ok = cache.CompareAndSet("state", "off", "on", ""); // 当前值等于期望值时设置，null表示key不存在 - Set if the current value equals expected, null means not exists
+//	cache.ListPush("readings", "1h", msg.temperature); // 追加到列表末尾，返回列表长度 - Append to the list, returns the length
+//	cache.ListTrim("readings", -10, -1); // 只保留最后10个元素 - Keep the last 10 items
+//	let readings = cache.ListRange("readings", 0, -1); // 获取列表所有元素 - Get all list items
 //
 // 配置示例 - Configuration example:
 //
@@ -153,9 +166,17 @@ func (x *JsTransformNode) Init(ruleConfig types.Config, configuration types.Conf
 		return nil
 	}
 
-	// 初始化JavaScript执行引擎
-	jsScript := fmt.Sprintf(JsTransformFuncTemplate, x.Config.JsScript)
-	x.jsEngine, err = js.NewGojaJsEngine(ruleConfig, jsScript, base.NodeUtils.GetVars(configuration))
+	// 初始化脚本执行引擎
+	switch x.Config.ScriptType {
+	case types.Lua:
+		luaScript := fmt.Sprintf(LuaTransformFuncTemplate, x.Config.JsScript)
+		x.jsEngine, err = lua.NewLuaEngine(ruleConfig, luaScript, base.NodeUtils.GetVars(configuration))
+	case types.Js, types.AllScript:
+		jsScript := fmt.Sprintf(JsTransformFuncTemplate, x.Config.JsScript)
+		x.jsEngine, err = js.NewGojaJsEngine(ruleConfig, jsScript, base.NodeUtils.GetVars(configuration))
+	default:
+		err = fmt.Errorf("unsupported script type: %s", x.Config.ScriptType)
+	}
 	return err
 }
 
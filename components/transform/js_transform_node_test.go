@@ -566,3 +566,81 @@ func TestJsTransformNodeDataTypeFixComprehensive(t *testing.T) {
 		assert.Equal(t, types.BINARY, resultMsg.DataType)
 	})
 }
+
+// TestJsTransformNodeLua 测试使用Lua脚本转换消息
+func TestJsTransformNodeLua(t *testing.T) {
+	config := types.NewConfig()
+	node := &JsTransformNode{}
+	err := node.Init(config, types.Configuration{
+		"scriptType": types.Lua,
+		"jsScript": `
+			ctx:ChainCache():Set("lastTemperature", msg.temperature, "")
+			msg.temperature = msg.temperature * 9 / 5 + 32
+			metadata.unit = "F"
+			return {msg = msg, metadata = metadata, msgType = "CONVERTED"}`,
+	})
+	assert.Nil(t, err)
+	defer node.Destroy()
+
+	var result types.RuleMsg
+	ctx := test.NewRuleContext(config, func(msg types.RuleMsg, relationType string, err error) {
+		assert.Nil(t, err)
+		assert.Equal(t, types.Success, relationType)
+		result = msg
+	})
+	node.OnMsg(ctx, types.NewMsg(0, "TEST", types.JSON, types.NewMetadata(), `{"temperature":20}`))
+	assert.Equal(t, `{"temperature":68}`, result.GetData())
+	assert.Equal(t, "F", result.Metadata.GetValue("unit"))
+	assert.Equal(t, "CONVERTED", result.Type)
+	assert.Equal(t, float64(20), ctx.ChainCache().Get("lastTemperature"))
+
+	// 二进制数据
+	node2 := &JsTransformNode{}
+	err = node2.Init(config, types.Configuration{
+		"scriptType": types.Lua,
+		"jsScript":   "msg[1] = msg[1] + 1; return {msg = msg, dataType = dataType}",
+	})
+	assert.Nil(t, err)
+	node2.OnMsg(ctx, types.NewMsgFromBytes(0, "TEST", types.BINARY, types.NewMetadata(), []byte{1, 2}))
+	assert.Equal(t, []byte{2, 2}, result.GetBytes())
+}
+
+// TestJsTransformNodeCacheOps 测试在脚本中使用缓存原子操作
+func TestJsTransformNodeCacheOps(t *testing.T) {
+	config := types.NewConfig()
+	node := &JsTransformNode{}
+	err := node.Init(config, types.Configuration{
+		"jsScript": `
+			let cache = $ctx.ChainCache();
+			metadata.count = String(cache.Incr("count:" + metadata.deviceId, 1, ""));
+			cache.ListPush("readings:" + metadata.deviceId, "1h", msg.temperature);
+			cache.ListTrim("readings:" + metadata.deviceId, -2, -1);
+			msg.readings = cache.ListRange("readings:" + metadata.deviceId, 0, -1);
+			metadata.first = String(cache.CompareAndSet("first:" + metadata.deviceId, null, msg.temperature, ""));
+			try {
+				cache.Incr("readings:" + metadata.deviceId, 1, "");
+			} catch (e) {
+				metadata.error = e.message;
+			}
+			return {'msg':msg,'metadata':metadata,'msgType':msgType};`,
+	})
+	assert.Nil(t, err)
+	defer node.Destroy()
+
+	var result types.RuleMsg
+	ctx := test.NewRuleContext(config, func(msg types.RuleMsg, relationType string, err error) {
+		assert.Nil(t, err)
+		assert.Equal(t, types.Success, relationType)
+		result = msg
+	})
+	for i, temperature := range []string{"20", "21", "22"} {
+		metadata := types.NewMetadata()
+		metadata.PutValue("deviceId", "d1")
+		node.OnMsg(ctx, types.NewMsg(0, "TEST", types.JSON, metadata, `{"temperature":`+temperature+`}`))
+		assert.Equal(t, str.ToString(i+1), result.Metadata.GetValue("count"))
+		assert.Equal(t, str.ToString(i == 0), result.Metadata.GetValue("first"))
+	}
+	assert.Equal(t, `{"readings":[21,22],"temperature":22}`, result.GetData())
+	assert.Equal(t, types.ErrCacheNotInteger.Error(), result.Metadata.GetValue("error"))
+	assert.Equal(t, int64(3), ctx.ChainCache().Get("count:d1"))
+}
@@ -0,0 +1,146 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package transform
+
+//规则链节点配置示例：
+//{
+//	"id": "s1",
+//	"type": "jsonPathTransform",
+//	"name": "JMESPath转换",
+//	"debugMode": false,
+//		"configuration": {
+//			"mapping": {
+//			"errors":   "msg.items[?status=='error'].name",
+//			"top2":     "msg.items[0:2]",
+//			"total":    "sum(msg.items[*].price)",
+//			"deviceId": "metadata.deviceId"
+//		}
+//	}
+//}
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jmespath/go-jmespath"
+	"github.com/yunboom/rulego/api/types"
+	"github.com/yunboom/rulego/components/base"
+	"github.com/yunboom/rulego/utils/el"
+	"github.com/yunboom/rulego/utils/maps"
+	"github.com/yunboom/rulego/utils/str"
+)
+
+func init() {
+	Registry.Add(&JsonPathTransformNode{})
+}
+
+// JsonPathTransformNodeConfiguration 节点配置
+type JsonPathTransformNodeConfiguration struct {
+	//JMESPath查询，查询结果替换到msg 转到下一个节点。
+	Query string
+	//多个字段查询，格式(字段:查询)，多个查询结果转换成json字符串转到下一个节点。如果Mapping和Query同时存在，优先使用Query
+	Mapping map[string]string
+}
+
+// JsonPathTransformNode 使用JMESPath查询转换或者创建新的msg，用法和 exprTransform 相同
+// 如果config.Query有值，则把查询结果替换到msg 转到下一个节点
+// 如果config.Mapping有值，则把多个字段查询结果转换成json替换到msg 转到下一个节点
+// 如果Mapping和Query同时存在，优先使用config.Query
+//
+// 查询可以访问 id、ts、data、msg、metadata、msgType、dataType 变量，查询可以带 jq: 前缀，例如:
+//
+//	"configuration": {
+//		"mapping": {
+//		"errors":   "msg.items[?status=='error'].name",
+//		"top2":     "msg.items[0:2]",
+//		"deviceId": "metadata.deviceId",
+//	}
+type JsonPathTransformNode struct {
+	//节点配置
+	Config       JsonPathTransformNodeConfiguration
+	query        *jmespath.JMESPath
+	queryMapping map[string]*jmespath.JMESPath
+}
+
+// Type 组件类型
+func (x *JsonPathTransformNode) Type() string {
+	return "jsonPathTransform"
+}
+
+func (x *JsonPathTransformNode) New() types.Node {
+	return &JsonPathTransformNode{Config: JsonPathTransformNodeConfiguration{}}
+}
+
+// Init 初始化
+func (x *JsonPathTransformNode) Init(ruleConfig types.Config, configuration types.Configuration) error {
+	err := maps.Map2Struct(configuration, &x.Config)
+	if err != nil {
+		return err
+	}
+	if query := strings.TrimSpace(x.Config.Query); query != "" {
+		x.query, err = el.CompileQuery(query)
+		return err
+	}
+	if len(x.Config.Mapping) == 0 {
+		return fmt.Errorf("query and mapping can not be empty")
+	}
+	x.queryMapping = make(map[string]*jmespath.JMESPath)
+	for k, v := range x.Config.Mapping {
+		if query, err := el.CompileQuery(v); err != nil {
+			return err
+		} else {
+			x.queryMapping[k] = query
+		}
+	}
+	return nil
+}
+
+// OnMsg 处理消息
+func (x *JsonPathTransformNode) OnMsg(ctx types.RuleContext, msg types.RuleMsg) {
+	evn := base.NodeUtils.GetEvn(ctx, msg)
+	var result interface{}
+	if x.query != nil {
+		if out, err := el.Search(x.query, evn); err != nil {
+			ctx.TellFailure(msg, err)
+			return
+		} else {
+			result = out
+		}
+	} else {
+		mapResult := make(map[string]interface{})
+		for fieldName, query := range x.queryMapping {
+			if out, err := el.Search(query, evn); err != nil {
+				ctx.TellFailure(msg, err)
+				return
+			} else {
+				mapResult[fieldName] = out
+			}
+		}
+		result = mapResult
+		msg.DataType = types.JSON
+	}
+
+	if newValue, err := str.ToStringMaybeErr(result); err == nil {
+		msg.SetData(newValue)
+		ctx.TellSuccess(msg)
+	} else {
+		ctx.TellFailure(msg, err)
+	}
+}
+
+// Destroy 销毁
+func (x *JsonPathTransformNode) Destroy() {
+}
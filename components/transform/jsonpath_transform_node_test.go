@@ -0,0 +1,123 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package transform
+
+import (
+	"testing"
+	"time"
+
+	"github.com/yunboom/rulego/api/types"
+	"github.com/yunboom/rulego/test"
+	"github.com/yunboom/rulego/test/assert"
+	"github.com/yunboom/rulego/utils/json"
+)
+
+func TestJsonPathTransformNode(t *testing.T) {
+	var targetNodeType = "jsonPathTransform"
+
+	t.Run("NewNode", func(t *testing.T) {
+		test.NodeNew(t, targetNodeType, &JsonPathTransformNode{}, types.Configuration{}, Registry)
+	})
+
+	t.Run("InitNode", func(t *testing.T) {
+		test.NodeInit(t, targetNodeType, types.Configuration{
+			"mapping": map[string]string{
+				"names": "msg.items[*].name",
+			},
+		}, types.Configuration{
+			"mapping": map[string]string{
+				"names": "msg.items[*].name",
+			},
+		}, Registry)
+		_, err := test.CreateAndInitNode(targetNodeType, types.Configuration{}, Registry)
+		assert.Equal(t, "query and mapping can not be empty", err.Error())
+		_, err = test.CreateAndInitNode(targetNodeType, types.Configuration{
+			"mapping": map[string]string{
+				"names": "msg.items[",
+			},
+		}, Registry)
+		assert.NotNil(t, err)
+	})
+
+	t.Run("OnMsg", func(t *testing.T) {
+		node1, err := test.CreateAndInitNode(targetNodeType, types.Configuration{
+			"mapping": map[string]string{
+				"errors":   "msg.items[?status=='error'].name",
+				"top2":     "jq: msg.items[0:2].name",
+				"total":    "sum(msg.items[*].price)",
+				"deviceId": "metadata.deviceId",
+				"type":     "msgType",
+			},
+		}, Registry)
+		assert.Nil(t, err)
+		node2, err := test.CreateAndInitNode(targetNodeType, types.Configuration{
+			"query": "msg.items[?price > `10`] | [0]",
+		}, Registry)
+		assert.Nil(t, err)
+		node3, err := test.CreateAndInitNode(targetNodeType, types.Configuration{
+			"query": "msg.items[0].name",
+		}, Registry)
+		assert.Nil(t, err)
+
+		metaData := types.BuildMetadata(make(map[string]string))
+		metaData.PutValue("deviceId", "d1")
+		msgList := []test.Msg{
+			{
+				MetaData:   metaData,
+				MsgType:    "ACTIVITY_EVENT",
+				Data:       `{"items":[{"name":"a","status":"ok","price":5},{"name":"b","status":"error","price":20},{"name":"c","status":"error","price":30}]}`,
+				AfterSleep: time.Millisecond * 20,
+			},
+		}
+		var nodeList = []test.NodeAndCallback{
+			{
+				Node:    node1,
+				MsgList: msgList,
+				Callback: func(msg types.RuleMsg, relationType string, err error) {
+					assert.Equal(t, types.Success, relationType)
+					assert.Equal(t, types.JSON, msg.DataType)
+					var result map[string]interface{}
+					assert.Nil(t, json.Unmarshal([]byte(msg.GetData()), &result))
+					assert.Equal(t, []interface{}{"b", "c"}, result["errors"])
+					assert.Equal(t, []interface{}{"a", "b"}, result["top2"])
+					assert.Equal(t, float64(55), result["total"])
+					assert.Equal(t, "d1", result["deviceId"])
+					assert.Equal(t, "ACTIVITY_EVENT", result["type"])
+				},
+			},
+			{
+				Node:    node2,
+				MsgList: msgList,
+				Callback: func(msg types.RuleMsg, relationType string, err error) {
+					assert.Equal(t, types.Success, relationType)
+					assert.Equal(t, `{"name":"b","price":20,"status":"error"}`, msg.GetData())
+				},
+			},
+			{
+				Node:    node3,
+				MsgList: msgList,
+				Callback: func(msg types.RuleMsg, relationType string, err error) {
+					assert.Equal(t, types.Success, relationType)
+					assert.Equal(t, "a", msg.GetData())
+				},
+			},
+		}
+		for _, item := range nodeList {
+			test.NodeOnMsgWithChildren(t, item.Node, item.MsgList, item.ChildrenNodes, item.Callback)
+		}
+	})
+}
@@ -32,10 +32,10 @@ package transform
 //	}
 //}
 import (
-	"github.com/expr-lang/expr"
 	"github.com/expr-lang/expr/vm"
 	"github.com/yunboom/rulego/api/types"
 	"github.com/yunboom/rulego/components/base"
+	"github.com/yunboom/rulego/utils/el"
 	"github.com/yunboom/rulego/utils/maps"
 	"github.com/yunboom/rulego/utils/str"
 )
@@ -99,14 +99,14 @@ func (x *MetadataTransformNode) New() types.Node {
 }
 
 // Init 初始化
-func (x *MetadataTransformNode) Init(_ types.Config, configuration types.Configuration) error {
+func (x *MetadataTransformNode) Init(ruleConfig types.Config, configuration types.Configuration) error {
 	//删除默认配置
 	x.Config.Mapping = map[string]string{}
 	err := maps.Map2Struct(configuration, &x.Config)
 	if err == nil {
 		x.programMapping = make(map[string]*vm.Program)
 		for k, v := range x.Config.Mapping {
-			if program, err := expr.Compile(v, expr.AllowUndefinedVariables()); err != nil {
+			if program, err := el.CompileExpr(v, ruleConfig.Udf, base.NodeUtils.GetExprTypes(configuration)); err != nil {
 				return err
 			} else {
 				x.programMapping[k] = program
@@ -0,0 +1,260 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package transform
+
+import (
+	"bytes"
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"regexp"
+	"strings"
+	"text/template"
+	"text/template/parse"
+
+	"github.com/cbroglie/mustache"
+	"github.com/flosch/pongo2/v6"
+	"github.com/yunboom/rulego/builtin/funcs"
+)
+
+const (
+	// TemplateEngineGo Go text/template 模板引擎，开启HTML转义时使用 html/template
+	TemplateEngineGo = "gotemplate"
+	// TemplateEngineMustache Mustache 模板引擎
+	TemplateEngineMustache = "mustache"
+	// TemplateEngineJinja Jinja 兼容模板引擎
+	TemplateEngineJinja = "jinja"
+)
+
+// mustacheVarRegexp 匹配 mustache 转义变量标签
+var mustacheVarRegexp = regexp.MustCompile(`\{\{\s*([^#^/>!=&{\s][^}]*)\}\}`)
+
+// jinjaExtendsRegexp 匹配以 extends 标签开头的 jinja 模板
+var jinjaExtendsRegexp = regexp.MustCompile(`^\s*\{%-?\s*extends\s`)
+
+// templateRenderer 模板渲染器
+type templateRenderer interface {
+	Render(evn map[string]interface{}) (string, error)
+}
+
+// newTemplateRenderer 根据模板引擎创建模板渲染器
+func newTemplateRenderer(engine, name, content string, partials map[string]string, escapeHtml bool) (templateRenderer, error) {
+	switch strings.ToLower(engine) {
+	case "", TemplateEngineGo:
+		return newGoTemplateRenderer(name, content, partials, escapeHtml)
+	case TemplateEngineMustache:
+		return newMustacheRenderer(content, partials, escapeHtml)
+	case TemplateEngineJinja:
+		return newJinjaRenderer(name, content, partials, escapeHtml)
+	default:
+		return nil, fmt.Errorf("unsupported template engine: %s", engine)
+	}
+}
+
+// goTemplateExecutor text/template 和 html/template 的公共方法
+type goTemplateExecutor interface {
+	ExecuteTemplate(wr io.Writer, name string, data any) error
+}
+
+type goTemplateRenderer struct {
+	name string
+	tmpl goTemplateExecutor
+}
+
+// newGoTemplateRenderer 片段作为关联模板，通过 {{ template "name" . }} 引用，只解析模板引用到的片段
+func newGoTemplateRenderer(name, content string, partials map[string]string, escapeHtml bool) (templateRenderer, error) {
+	if escapeHtml {
+		tmpl, err := htmltemplate.New(name).Funcs(funcs.TemplateFunc.GetAll()).Parse(content)
+		if err != nil {
+			return nil, err
+		}
+		err = parseGoTemplatePartials(partials, func() []*parse.Tree {
+			var trees []*parse.Tree
+			for _, item := range tmpl.Templates() {
+				trees = append(trees, item.Tree)
+			}
+			return trees
+		}, func(name string) bool {
+			return tmpl.Lookup(name) != nil
+		}, func(name, partial string) error {
+			_, err := tmpl.New(name).Parse(partial)
+			return err
+		})
+		return &goTemplateRenderer{name: name, tmpl: tmpl}, err
+	}
+	tmpl, err := template.New(name).Funcs(funcs.TemplateFunc.GetAll()).Parse(content)
+	if err != nil {
+		return nil, err
+	}
+	err = parseGoTemplatePartials(partials, func() []*parse.Tree {
+		var trees []*parse.Tree
+		for _, item := range tmpl.Templates() {
+			trees = append(trees, item.Tree)
+		}
+		return trees
+	}, func(name string) bool {
+		return tmpl.Lookup(name) != nil
+	}, func(name, partial string) error {
+		_, err := tmpl.New(name).Parse(partial)
+		return err
+	})
+	return &goTemplateRenderer{name: name, tmpl: tmpl}, err
+}
+
+// parseGoTemplatePartials 解析模板引用到的片段，直到没有新的引用
+// 注册表中的片段可能属于其他模板引擎，所以不能全部解析
+func parseGoTemplatePartials(partials map[string]string, trees func() []*parse.Tree,
+	defined func(name string) bool, parsePartial func(name, partial string) error) error {
+	for {
+		var refs []string
+		for _, tree := range trees() {
+			if tree != nil {
+				refs = goTemplateRefs(tree.Root, refs)
+			}
+		}
+		added := false
+		for _, ref := range refs {
+			partial, ok := partials[ref]
+			if !ok || defined(ref) {
+				continue
+			}
+			if err := parsePartial(ref, partial); err != nil {
+				return fmt.Errorf("partial %s: %w", ref, err)
+			}
+			added = true
+		}
+		if !added {
+			return nil
+		}
+	}
+}
+
+// goTemplateRefs 查找 {{ template "name" }} 引用的模板名称
+func goTemplateRefs(node parse.Node, refs []string) []string {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n != nil {
+			for _, item := range n.Nodes {
+				refs = goTemplateRefs(item, refs)
+			}
+		}
+	case *parse.TemplateNode:
+		refs = append(refs, n.Name)
+	case *parse.IfNode:
+		refs = goTemplateRefs(n.List, goTemplateRefs(n.ElseList, refs))
+	case *parse.RangeNode:
+		refs = goTemplateRefs(n.List, goTemplateRefs(n.ElseList, refs))
+	case *parse.WithNode:
+		refs = goTemplateRefs(n.List, goTemplateRefs(n.ElseList, refs))
+	}
+	return refs
+}
+
+func (r *goTemplateRenderer) Render(evn map[string]interface{}) (string, error) {
+	var buf bytes.Buffer
+	if err := r.tmpl.ExecuteTemplate(&buf, r.name, evn); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+type mustacheRenderer struct {
+	tmpl *mustache.Template
+}
+
+// newMustacheRenderer 片段通过 {{> name}} 引用
+func newMustacheRenderer(content string, partials map[string]string, escapeHtml bool) (templateRenderer, error) {
+	tmpl, err := mustache.ParseStringPartialsRaw(content, &mustachePartialProvider{partials: partials, raw: !escapeHtml}, !escapeHtml)
+	if err != nil {
+		return nil, err
+	}
+	return &mustacheRenderer{tmpl: tmpl}, nil
+}
+
+func (r *mustacheRenderer) Render(evn map[string]interface{}) (string, error) {
+	return r.tmpl.Render(evn)
+}
+
+// mustachePartialProvider 提供模板片段，不存在的片段渲染为空
+type mustachePartialProvider struct {
+	partials map[string]string
+	raw      bool
+}
+
+func (p *mustachePartialProvider) Get(name string) (string, error) {
+	partial := p.partials[name]
+	if p.raw {
+		//mustache 使用默认转义解析片段，关闭转义时把变量标签改成不转义的 {{& name}}
+		partial = mustacheVarRegexp.ReplaceAllString(partial, "{{&$1}}")
+	}
+	return partial, nil
+}
+
+type jinjaRenderer struct {
+	tmpl *pongo2.Template
+}
+
+// newJinjaRenderer 片段通过 {% include "name" %}、{% extends "name" %} 或者 {% import "name" x %} 引用
+// 这些标签只能从模板片段加载，禁用直接读取本地文件的 ssi 标签
+func newJinjaRenderer(name, content string, partials map[string]string, escapeHtml bool) (templateRenderer, error) {
+	set := pongo2.NewSet(name, &jinjaPartialLoader{partials: partials, escapeHtml: escapeHtml})
+	if err := set.BanTag("ssi"); err != nil {
+		return nil, err
+	}
+	for k, v := range funcs.TemplateFunc.GetAll() {
+		set.Globals[k] = v
+	}
+	tmpl, err := set.FromString(jinjaAutoescape(content, escapeHtml))
+	if err != nil {
+		return nil, err
+	}
+	return &jinjaRenderer{tmpl: tmpl}, nil
+}
+
+func (r *jinjaRenderer) Render(evn map[string]interface{}) (string, error) {
+	return r.tmpl.Execute(evn)
+}
+
+// jinjaPartialLoader 从模板片段加载被引用的模板
+type jinjaPartialLoader struct {
+	partials   map[string]string
+	escapeHtml bool
+}
+
+func (l *jinjaPartialLoader) Abs(base, name string) string {
+	return name
+}
+
+func (l *jinjaPartialLoader) Get(path string) (io.Reader, error) {
+	if partial, ok := l.partials[path]; ok {
+		return strings.NewReader(jinjaAutoescape(partial, l.escapeHtml)), nil
+	}
+	return nil, fmt.Errorf("template partial not found: %s", path)
+}
+
+// jinjaAutoescape pongo2 的转义开关是全局的，使用 autoescape 标签包裹模板控制当前模板是否转义
+// extends 必须是模板的第一个标签，继承的模板由父模板控制是否转义
+func jinjaAutoescape(content string, escapeHtml bool) string {
+	if jinjaExtendsRegexp.MatchString(content) {
+		return content
+	}
+	mode := "off"
+	if escapeHtml {
+		mode = "on"
+	}
+	return "{% autoescape " + mode + " %}" + content + "{% endautoescape %}"
+}
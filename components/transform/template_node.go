@@ -27,14 +27,14 @@ package transform
 //}
 
 import (
-	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
 	"github.com/yunboom/rulego/api/types"
-	"github.com/yunboom/rulego/builtin/funcs"
 	"github.com/yunboom/rulego/components/base"
 	"github.com/yunboom/rulego/utils/maps"
-	"path/filepath"
-	"strings"
-	"text/template"
 )
 
 // TemplateName 默认模板名称
@@ -48,9 +48,13 @@ func init() {
 type TemplateNodeConfiguration struct {
 	// Template 模板内容或文件路径
 	Template string
+	// Engine 模板引擎：gotemplate、mustache 或者 jinja，默认 gotemplate
+	Engine string
+	// EscapeHtml 是否对输出的变量进行HTML转义，默认不转义
+	EscapeHtml bool
 }
 
-// TemplateNode 使用 text/template 解析模板
+// TemplateNode 使用模板引擎解析模板，支持 gotemplate(text/template)、mustache 和 jinja 兼容语法
 // 通过`.id`变量访问消息id
 // 通过`.ts`变量访问消息时间戳
 // 通过`.data`变量访问消息原始数据
@@ -58,10 +62,24 @@ type TemplateNodeConfiguration struct {
 // 通过`.metadata`变量访问消息元数据。例如 `metadata.customerName`
 // 通过`.type`变量访问消息类型
 // 通过`.dataType`变量访问数据类型
+// mustache 和 jinja 引擎访问变量不需要`.`前缀，例如：`{{ msg.name }}`
+//
+// 模板可以引用 types.Config.TemplatePartials 注册的共享片段，片段变化时自动重新编译模板：
+//   - gotemplate: {{ template "footer" . }}
+//   - mustache: {{> footer}}
+//   - jinja: {% include "footer" %}、{% extends "layout" %}
 type TemplateNode struct {
-	Config         TemplateNodeConfiguration
-	templateEngine *template.Template
-	templateName   string
+	Config TemplateNodeConfiguration
+	// partials 共享模板片段
+	partials     *types.TemplatePartialRegistry
+	templateName string
+	content      string
+	renderer     templateRenderer
+	// version 编译模板时片段注册表的版本号
+	version int64
+	// err 该版本的编译错误，片段再次变化前不重新编译
+	err  error
+	lock sync.RWMutex
 }
 
 // Type 组件类型
@@ -79,6 +97,7 @@ func (x *TemplateNode) New() types.Node {
 "data": "{{ .data | escape}}"
 "dataType": "{{ .dataType}}"
 `,
+			Engine: TemplateEngineGo,
 		},
 	}
 }
@@ -86,36 +105,81 @@ func (x *TemplateNode) New() types.Node {
 // Init 初始化
 func (x *TemplateNode) Init(ruleConfig types.Config, configuration types.Configuration) error {
 	err := maps.Map2Struct(configuration, &x.Config)
-	if err == nil {
-		if strings.HasPrefix(x.Config.Template, "file:") {
-			// 从文件路径加载模板
-			filePath := strings.TrimPrefix(x.Config.Template, "file:")
-			x.templateName = filepath.Base(filePath)
-			x.templateEngine, err = template.New(x.templateName).Funcs(funcs.TemplateFunc.GetAll()).ParseFiles(filePath)
-		} else {
-			x.templateName = TemplateName
-			// 使用模板内容
-			x.templateEngine, err = template.New(x.templateName).Funcs(funcs.TemplateFunc.GetAll()).Parse(x.Config.Template)
+	if err != nil {
+		return err
+	}
+	if strings.HasPrefix(x.Config.Template, "file:") {
+		// 从文件路径加载模板
+		filePath := strings.TrimPrefix(x.Config.Template, "file:")
+		x.templateName = filepath.Base(filePath)
+		content, err := os.ReadFile(filePath)
+		if err != nil {
+			return err
 		}
+		x.content = string(content)
+	} else {
+		x.templateName = TemplateName
+		// 使用模板内容
+		x.content = x.Config.Template
 	}
+	x.partials = ruleConfig.TemplatePartials
+	x.renderer, x.version, err = x.compile()
 	return err
 }
 
 // OnMsg 处理消息
 func (x *TemplateNode) OnMsg(ctx types.RuleContext, msg types.RuleMsg) {
-	var err error
+	renderer, err := x.getRenderer()
+	if err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
 	evn := base.NodeUtils.GetEvn(ctx, msg)
-
-	var buf bytes.Buffer
-	err = x.templateEngine.ExecuteTemplate(&buf, x.templateName, evn)
+	result, err := renderer.Render(evn)
 	if err != nil {
 		ctx.TellFailure(msg, err)
 		return
 	}
-	msg.SetData(buf.String())
+	msg.SetData(result)
 	ctx.TellSuccess(msg)
 }
 
 // Destroy 销毁
 func (x *TemplateNode) Destroy() {
 }
+
+// compile 使用当前的模板片段编译模板
+func (x *TemplateNode) compile() (templateRenderer, int64, error) {
+	var partials map[string]string
+	var version int64
+	if x.partials != nil {
+		partials, version = x.partials.All()
+	}
+	renderer, err := newTemplateRenderer(x.Config.Engine, x.templateName, x.content, partials, x.Config.EscapeHtml)
+	return renderer, version, err
+}
+
+// getRenderer 获取模板渲染器，模板片段变化时重新编译模板
+func (x *TemplateNode) getRenderer() (templateRenderer, error) {
+	if x.partials == nil {
+		return x.renderer, nil
+	}
+	version := x.partials.Version()
+	x.lock.RLock()
+	renderer, err := x.renderer, x.err
+	changed := x.version != version
+	x.lock.RUnlock()
+	if !changed {
+		return renderer, err
+	}
+	x.lock.Lock()
+	defer x.lock.Unlock()
+	if x.version != version {
+		renderer, newVersion, err := x.compile()
+		if err == nil {
+			x.renderer = renderer
+		}
+		x.version, x.err = newVersion, err
+	}
+	return x.renderer, x.err
+}
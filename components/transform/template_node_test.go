@@ -17,6 +17,10 @@
 package transform
 
 import (
+	"os"
+	"path/filepath"
+	"strings"
+
 	"github.com/yunboom/rulego/api/types"
 	"github.com/yunboom/rulego/test"
 	"github.com/yunboom/rulego/test/assert"
@@ -125,3 +129,174 @@ func TestTemplateNode(t *testing.T) {
 		}
 	})
 }
+
+func TestTemplateNodeEngines(t *testing.T) {
+	partials := types.NewTemplatePartialRegistry()
+	partials.Register("title", "{{ .msg.name }}")
+	partials.Register("mustacheTitle", "{{ msg.name }}")
+	partials.Register("jinjaTitle", "{{ msg.name|upper }}")
+	partials.Register("layout", "<p>{% block body %}{% endblock %}</p>")
+	config := types.NewConfig(types.WithTemplatePartials(partials))
+
+	render := func(configuration types.Configuration) (string, string, error) {
+		node := &TemplateNode{}
+		if err := node.Init(config, configuration); err != nil {
+			return "", "", err
+		}
+		var data, relationType string
+		var outErr error
+		ctx := test.NewRuleContext(config, func(msg types.RuleMsg, rt string, err error) {
+			data, relationType, outErr = msg.GetData(), rt, err
+		})
+		metadata := types.NewMetadata()
+		metadata.PutValue("team", "ops")
+		node.OnMsg(ctx, types.NewMsg(0, "TEST_MSG_TYPE", types.JSON, metadata, `{"name":"<b>aa</b>","items":["x","y"]}`))
+		return data, relationType, outErr
+	}
+
+	t.Run("GoTemplate", func(t *testing.T) {
+		data, relationType, err := render(types.Configuration{
+			"template": `{{ template "title" . }}-{{ .metadata.team }}`,
+		})
+		assert.Nil(t, err)
+		assert.Equal(t, types.Success, relationType)
+		assert.Equal(t, "<b>aa</b>-ops", data)
+
+		data, _, _ = render(types.Configuration{
+			"engine":     TemplateEngineGo,
+			"template":   `<div>{{ template "title" . }}</div>`,
+			"escapeHtml": true,
+		})
+		assert.Equal(t, "<div>&lt;b&gt;aa&lt;/b&gt;</div>", data)
+	})
+
+	t.Run("Mustache", func(t *testing.T) {
+		data, relationType, err := render(types.Configuration{
+			"engine":   TemplateEngineMustache,
+			"template": "{{> mustacheTitle}}:{{#msg.items}}{{.}},{{/msg.items}}{{ metadata.team }}",
+		})
+		assert.Nil(t, err)
+		assert.Equal(t, types.Success, relationType)
+		assert.Equal(t, "<b>aa</b>:x,y,ops", data)
+
+		data, _, _ = render(types.Configuration{
+			"engine":     TemplateEngineMustache,
+			"template":   "{{> mustacheTitle}}|{{{ msg.name }}}",
+			"escapeHtml": true,
+		})
+		assert.Equal(t, "&lt;b&gt;aa&lt;/b&gt;|<b>aa</b>", data)
+	})
+
+	t.Run("Jinja", func(t *testing.T) {
+		data, relationType, err := render(types.Configuration{
+			"engine":   TemplateEngineJinja,
+			"template": `{% include "jinjaTitle" %}:{% for item in msg.items %}{{ item }}{% if not forloop.Last %},{% endif %}{% endfor %} {{ escape(metadata.team) }}`,
+		})
+		assert.Nil(t, err)
+		assert.Equal(t, types.Success, relationType)
+		assert.Equal(t, "<B>AA</B>:x,y ops", data)
+
+		data, _, _ = render(types.Configuration{
+			"engine":     TemplateEngineJinja,
+			"template":   `{% include "jinjaTitle" %}|{{ msg.name|safe }}`,
+			"escapeHtml": true,
+		})
+		assert.Equal(t, "&lt;B&gt;AA&lt;/B&gt;|<b>aa</b>", data)
+
+		data, _, _ = render(types.Configuration{
+			"engine":   TemplateEngineJinja,
+			"template": `{% extends "layout" %}{% block body %}{{ msg.name }}{% endblock %}`,
+		})
+		assert.Equal(t, "<p><b>aa</b></p>", data)
+
+		_, relationType, err = render(types.Configuration{
+			"engine":   TemplateEngineJinja,
+			"template": `{{ msg.name }}{% include name %}`,
+		})
+		assert.Equal(t, types.Failure, relationType)
+		assert.NotNil(t, err)
+	})
+
+	t.Run("InitError", func(t *testing.T) {
+		_, _, err := render(types.Configuration{
+			"engine":   "velocity",
+			"template": "x",
+		})
+		assert.Equal(t, "unsupported template engine: velocity", err.Error())
+		_, _, err = render(types.Configuration{
+			"engine":   TemplateEngineJinja,
+			"template": `{% include "notFound" %}`,
+		})
+		assert.NotNil(t, err)
+		//不能读取本地文件
+		file := filepath.Join(t.TempDir(), "secret.txt")
+		assert.Nil(t, os.WriteFile(file, []byte("secret"), 0644))
+		_, _, err = render(types.Configuration{
+			"engine":   TemplateEngineJinja,
+			"template": `{% ssi "` + file + `" %}`,
+		})
+		assert.NotNil(t, err)
+		assert.True(t, strings.Contains(err.Error(), "ssi"))
+		//include、import、extends 只能引用模板片段
+		for _, tmpl := range []string{`{% include "` + file + `" %}`, `{% import "` + file + `" m %}`, `{% extends "` + file + `" %}`} {
+			_, _, err = render(types.Configuration{
+				"engine":   TemplateEngineJinja,
+				"template": tmpl,
+			})
+			assert.True(t, strings.Contains(err.Error(), "unable to resolve template"))
+		}
+	})
+
+	t.Run("ReloadPartials", func(t *testing.T) {
+		node := &TemplateNode{}
+		err := node.Init(config, types.Configuration{
+			"engine":   TemplateEngineJinja,
+			"template": `{% include "greeting" %}`,
+		})
+		assert.NotNil(t, err)
+
+		partials.Register("greeting", "hello {{ msg.name }}")
+		err = node.Init(config, types.Configuration{
+			"engine":   TemplateEngineJinja,
+			"template": `{% include "greeting" %}`,
+		})
+		assert.Nil(t, err)
+		var data string
+		ctx := test.NewRuleContext(config, func(msg types.RuleMsg, relationType string, err error) {
+			data = msg.GetData()
+		})
+		node.OnMsg(ctx, types.NewMsg(0, "TEST_MSG_TYPE", types.JSON, types.NewMetadata(), `{"name":"aa"}`))
+		assert.Equal(t, "hello aa", data)
+
+		partials.Register("greeting", "hi {{ msg.name }}")
+		node.OnMsg(ctx, types.NewMsg(0, "TEST_MSG_TYPE", types.JSON, types.NewMetadata(), `{"name":"aa"}`))
+		assert.Equal(t, "hi aa", data)
+
+		// 编译错误和版本号一起缓存，片段再次变化前不重新编译
+		partials.Register("greeting", "hi {% if %}")
+		_, err = node.getRenderer()
+		assert.NotNil(t, err)
+		assert.Equal(t, partials.Version(), node.version)
+		_, cachedErr := node.getRenderer()
+		assert.True(t, err == cachedErr)
+
+		partials.Register("greeting", "hey {{ msg.name }}")
+		node.OnMsg(ctx, types.NewMsg(0, "TEST_MSG_TYPE", types.JSON, types.NewMetadata(), `{"name":"aa"}`))
+		assert.Equal(t, "hey aa", data)
+	})
+
+	t.Run("LoadDir", func(t *testing.T) {
+		dir := t.TempDir()
+		assert.Nil(t, os.WriteFile(filepath.Join(dir, "footer.mustache"), []byte("-- {{ metadata.team }}"), 0644))
+		assert.Nil(t, os.WriteFile(filepath.Join(dir, ".hidden"), []byte("x"), 0644))
+		registry := types.NewTemplatePartialRegistry()
+		assert.Nil(t, registry.LoadDir(dir))
+		assert.Equal(t, []string{"footer"}, registry.Names())
+		content, ok := registry.Get("footer")
+		assert.True(t, ok)
+		assert.Equal(t, "-- {{ metadata.team }}", content)
+		registry.Unregister("footer")
+		assert.Equal(t, 0, len(registry.Names()))
+		assert.Equal(t, int64(2), registry.Version())
+	})
+}
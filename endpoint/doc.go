@@ -42,6 +42,7 @@
 // • WebsocketEndpoint: WebSocket server (endpoint/websocket)  WebSocket 服务器
 // • NetEndpoint: TCP/UDP network server (endpoint/net)  TCP/UDP 网络服务器
 // • ScheduleEndpoint: Timer-based message generation (endpoint/schedule)  基于定时器的消息生成
+// • KafkaEndpoint: Kafka consumer group (endpoint/kafka), registered by importing the package  Kafka 消费者组，导入该包后注册
 //
 // Extended Endpoint Components:
 // 扩展端点组件：
@@ -55,7 +56,7 @@
 //   - rulego-components: Additional general-purpose endpoint and processing components
 //     (https://github.com/yunboom/rulego-components)
 //     rulego-components：额外的通用端点和处理组件
-//     包含 Kafka、Redis、RabbitMQ、NATS、gRPC、FastHTTP 等端点组件
+//     包含 Redis、RabbitMQ、NATS、gRPC、FastHTTP 等端点组件
 //
 // Specialized Extension Libraries / 专用扩展库：
 //
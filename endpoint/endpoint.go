@@ -332,7 +332,9 @@ func (e *DynamicEndpoint) AddRouterFromDef(routerDsl *types.RouterDsl) (string,
 	defer e.locker.Unlock()
 	from := NewRouter(opts...).SetId(routerDsl.Id).From(routerDsl.From.Path, routerDsl.From.Configuration)
 	for _, item := range routerDsl.From.Processors {
-		if p, ok := processor.InBuiltins.Get(item); ok {
+		if p, ok, err := processor.InBuiltins.GetForRouter(item, e.ruleConfig, e.Endpoint.Type(), routerDsl); err != nil {
+			return "", err
+		} else if ok {
 			from.Process(p)
 		} else {
 			return "", errors.New("processor not found: " + item)
@@ -341,7 +343,9 @@ func (e *DynamicEndpoint) AddRouterFromDef(routerDsl *types.RouterDsl) (string,
 	if routerDsl.To.Path != "" {
 		to := from.To(routerDsl.To.Path, routerDsl.To.Configuration)
 		for _, item := range routerDsl.To.Processors {
-			if p, ok := processor.OutBuiltins.Get(item); ok {
+			if p, ok, err := processor.OutBuiltins.GetForRouter(item, e.ruleConfig, e.Endpoint.Type(), routerDsl); err != nil {
+				return "", err
+			} else if ok {
 				to.Process(p)
 			} else {
 				return "", errors.New("processor not found: " + item)
@@ -539,6 +539,11 @@ func (r *Router) SetConfig(config types.Config) {
 	r.Config = config
 }
 
+// GetConfig returns the rule engine config of the router
+func (r *Router) GetConfig() types.Config {
+	return r.Config
+}
+
 func (r *Router) SetRuleEnginePool(pool types.RuleEnginePool) {
 	r.RuleGo = pool
 }
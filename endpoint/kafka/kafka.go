@@ -0,0 +1,900 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package kafka provides a Kafka consumer endpoint implementation for the RuleGo framework.
+// It joins a consumer group, subscribes to the topics of its routers and routes every record
+// to the rule chain or component of the matching router. It works with any broker that speaks
+// the Kafka protocol.
+//
+// Package kafka 为 RuleGo 框架提供 Kafka 消费者端点实现。
+// 它加入消费者组，订阅路由器的主题，并把每条记录路由到匹配路由器的规则链或组件。
+// 支持任何兼容 Kafka 协议的消息队列。
+//
+// Key Features / 主要特性：
+//
+// • Consumer Groups: Partitions are balanced across all endpoints with the same groupId  消费者组：分区在相同 groupId 的端点之间均衡
+// • Commit After Success: With To().Wait(), an offset is committed only after the To has completed without error  成功后提交：To 调用 Wait() 时，只有 To 执行完成且没有错误时才提交位移
+// • Ordered Retries: A failed record is retried in place, later records of the partition wait for it  有序重试：失败的记录原地重试，同分区后续记录等待
+// • Dead Letters: Records still failing after maxRetries are skipped or published to deadLetterTopic  死信：重试 maxRetries 次仍失败的记录被跳过或者发布到 deadLetterTopic
+// • Back-pressure: Fetching pauses while a record is rate limited, and can be paused and resumed manually  背压：记录被限流时暂停拉取，也可以手动暂停和恢复
+// • Dynamic Routers: Adding or removing routers re-subscribes the consumer group  动态路由：添加或删除路由会重新订阅消费者组
+//
+// Delivery Semantics / 投递语义：
+//
+// The endpoint knows whether the rule chain succeeded only if the To of the router waits for it,
+// so routers should call To().Wait() ("wait": true in the DSL). Records of one partition are processed
+// one by one, partitions are processed in parallel. If processing fails, the record is retried
+// every retryInterval. After maxRetries failed retries (default 3) the record is published to
+// deadLetterTopic if it is set, then skipped and committed. maxRetries = -1 retries until it succeeds
+// or the partition is revoked, which blocks the partition on a record that never succeeds.
+// This gives at-least-once delivery: after a restart or rebalance, records that were not
+// committed are delivered again. The To of a router without Wait() runs asynchronously, its record is
+// committed once it has been handed to the rule chain and chain failures are not retried.
+//
+// 只有路由器的 To 等待规则链执行结束，端点才能知道规则链是否成功，所以路由器应该调用 To().Wait()（DSL 中 "wait": true）。
+// 同一分区的记录逐条处理，不同分区并行处理。处理失败时每隔 retryInterval 重试该记录。
+// 重试 maxRetries 次（默认3）仍失败时，如果设置了 deadLetterTopic 则把该记录发布到该主题，然后跳过并提交该记录。
+// maxRetries = -1 表示一直重试直到成功或者分区被回收，一直失败的记录会阻塞该分区。
+// 这提供了至少一次投递：重启或者重平衡后，未提交的记录会被再次投递。
+// 没有调用 Wait() 的 To 异步执行，记录交给规则链后即提交，规则链失败不会重试。
+//
+// Usage Example / 使用示例：
+//
+//	dslConfig := `{
+//	  "id": "kafka-endpoint",
+//	  "type": "endpoint/kafka",
+//	  "name": "Kafka Consumer",
+//	  "configuration": {
+//	    "brokers": ["127.0.0.1:9092"],
+//	    "groupId": "rulego",
+//	    "initialOffset": "oldest",
+//	    "maxRetries": 3,
+//	    "deadLetterTopic": "orders.dlq"
+//	  },
+//	  "routers": [
+//	    {
+//	      "id": "r1",
+//	      "from": {
+//	        "path": "orders"
+//	      },
+//	      "to": {
+//	        "path": "chain:orderProcessing",
+//	        "wait": true
+//	      }
+//	    }
+//	  ]
+//	}`
+//
+// Message Metadata / 消息元数据：
+//
+// • topic, partition, offset: Position of the record  记录的位置
+// • key: Record key  记录的 key
+// • Record headers are copied into metadata  记录的消息头复制到元数据
+//
+// The endpoint is not registered by default, import the package to register it:
+// 该端点默认不注册，导入该包后自动注册：
+//
+//	import _ "github.com/yunboom/rulego/endpoint/kafka"
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/yunboom/rulego/api/types"
+	"github.com/yunboom/rulego/api/types/endpoint"
+	"github.com/yunboom/rulego/components/base"
+	endpointApi "github.com/yunboom/rulego/endpoint"
+	"github.com/yunboom/rulego/endpoint/impl"
+	"github.com/yunboom/rulego/utils/kafka"
+	"github.com/yunboom/rulego/utils/maps"
+	"github.com/yunboom/rulego/utils/runtime"
+)
+
+// Type defines the component type identifier for the Kafka endpoint.
+// Type 定义 Kafka 端点的组件类型标识符。
+const Type = types.EndpointTypePrefix + "kafka"
+
+// Metadata keys used for Kafka-specific information in RuleMsg metadata.
+// 用于 RuleMsg 元数据中 Kafka 特定信息的元数据键。
+const (
+	// KeyRequestTopic stores the topic of the record
+	// KeyRequestTopic 存储记录的主题
+	KeyRequestTopic = "topic"
+	// KeyPartition stores the partition of the record
+	// KeyPartition 存储记录的分区
+	KeyPartition = "partition"
+	// KeyOffset stores the offset of the record
+	// KeyOffset 存储记录的位移
+	KeyOffset = "offset"
+	// KeyMessageKey stores the key of the record
+	// KeyMessageKey 存储记录的 key
+	KeyMessageKey = "key"
+	// KeyError is the dead letter header storing the processing error of the record
+	// KeyError 死信消息头，存储记录的处理错误
+	KeyError = "error"
+)
+
+// DefaultMaxRetries is the default number of retries of a failed record before it is skipped.
+// DefaultMaxRetries 失败记录被跳过前的默认重试次数。
+const DefaultMaxRetries = 3
+
+const (
+	// InitialOffsetNewest starts a new consumer group from the newest record
+	// InitialOffsetNewest 新的消费者组从最新的记录开始消费
+	InitialOffsetNewest = "newest"
+	// InitialOffsetOldest starts a new consumer group from the oldest record
+	// InitialOffsetOldest 新的消费者组从最早的记录开始消费
+	InitialOffsetOldest = "oldest"
+)
+
+// init 向默认端点注册表注册 Kafka 端点
+// init registers the Kafka endpoint with the default endpoint registry.
+func init() {
+	_ = endpointApi.Registry.Register(&Endpoint{})
+}
+
+// Endpoint is an alias for Kafka to provide consistent naming with other endpoints.
+// Endpoint 是 Kafka 的别名，提供与其他端点一致的命名。
+type Endpoint = Kafka
+
+// Config Kafka 端点配置
+type Config struct {
+	// Brokers kafka broker 地址列表
+	Brokers []string
+	// GroupId 消费者组ID
+	GroupId string
+	// InitialOffset 消费者组没有已提交位移时从哪里开始消费，newest 或者 oldest，默认 newest
+	InitialOffset string
+	// BalanceStrategy 分区分配策略，range、roundrobin 或者 sticky，默认 range
+	BalanceStrategy string
+	// CommitInterval 已处理成功的位移提交间隔，单位毫秒，默认1000
+	CommitInterval int
+	// RetryInterval 处理失败后重试的间隔，单位毫秒，默认1000
+	RetryInterval int
+	// MaxRetries 处理失败后的最大重试次数，超过后跳过并提交该记录，默认3，-1表示一直重试直到成功
+	MaxRetries int
+	// DeadLetterTopic 死信主题，不为空时把重试 MaxRetries 次仍失败的记录发布到该主题后再跳过，
+	// 消息头包含原记录的消息头以及 topic、partition、offset 和 error
+	DeadLetterTopic string
+	// ClientID 客户端ID，为空则随机生成
+	ClientID string
+	// Version kafka协议版本，例如：2.8.0，为空使用默认版本
+	Version string
+	// Username SASL认证用户名，为空不认证
+	Username string
+	// Password SASL认证密码
+	Password string
+	// SaslMechanism SASL认证机制，目前支持PLAIN
+	SaslMechanism string
+	// TLS 是否使用TLS连接
+	TLS         bool
+	CAFile      string
+	CertFile    string
+	CertKeyFile string
+}
+
+func (c Config) ToKafkaConfig() kafka.Config {
+	return kafka.Config{
+		Brokers:       c.Brokers,
+		ClientID:      c.ClientID,
+		Version:       c.Version,
+		Username:      c.Username,
+		Password:      c.Password,
+		SaslMechanism: c.SaslMechanism,
+		TLS:           c.TLS,
+		CAFile:        c.CAFile,
+		CertFile:      c.CertFile,
+		CertKeyFile:   c.CertKeyFile,
+	}
+}
+
+// RequestMessage represents an incoming Kafka record in the RuleGo processing pipeline.
+// RequestMessage 表示 RuleGo 处理管道中的传入 Kafka 记录。
+type RequestMessage struct {
+	//记录的主题、分区、位移、key和消息头  Topic, partition, offset, key and headers of the record  头部映射
+	headers textproto.MIMEHeader
+	//原始 Kafka 记录  Original Kafka record  原始记录
+	message *sarama.ConsumerMessage
+	//记录的值  Value of the record  消息体
+	body []byte
+	//转换后的规则消息  Converted rule message  转换后的规则消息
+	msg *types.RuleMsg
+	//处理过程中的错误信息  Error during processing  处理错误信息
+	err error
+}
+
+func (r *RequestMessage) Body() []byte {
+	if r.body == nil && r.message != nil {
+		r.body = r.message.Value
+	}
+	return r.body
+}
+
+// Headers 返回记录的消息头，以及主题、分区、位移和key
+func (r *RequestMessage) Headers() textproto.MIMEHeader {
+	if r.headers == nil {
+		r.headers = make(map[string][]string)
+		if r.message != nil {
+			for _, header := range r.message.Headers {
+				if header != nil {
+					r.headers.Add(string(header.Key), string(header.Value))
+				}
+			}
+			r.headers.Set(KeyRequestTopic, r.message.Topic)
+			r.headers.Set(KeyPartition, strconv.FormatInt(int64(r.message.Partition), 10))
+			r.headers.Set(KeyOffset, strconv.FormatInt(r.message.Offset, 10))
+			r.headers.Set(KeyMessageKey, string(r.message.Key))
+		}
+	}
+	return r.headers
+}
+
+func (r *RequestMessage) From() string {
+	if r.message == nil {
+		return ""
+	}
+	return r.message.Topic
+}
+
+// GetParam 不提供获取参数
+func (r *RequestMessage) GetParam(key string) string {
+	return ""
+}
+
+func (r *RequestMessage) SetMsg(msg *types.RuleMsg) {
+	r.msg = msg
+}
+
+// GetMsg 把记录转换成 RuleMsg，记录的消息头原样放入元数据，主题、分区、位移和key使用固定的元数据键
+func (r *RequestMessage) GetMsg() *types.RuleMsg {
+	if r.msg == nil {
+		metadata := types.NewMetadata()
+		if r.message != nil {
+			for _, header := range r.message.Headers {
+				if header != nil {
+					metadata.PutValue(string(header.Key), string(header.Value))
+				}
+			}
+			metadata.PutValue(KeyRequestTopic, r.message.Topic)
+			metadata.PutValue(KeyPartition, strconv.FormatInt(int64(r.message.Partition), 10))
+			metadata.PutValue(KeyOffset, strconv.FormatInt(r.message.Offset, 10))
+			metadata.PutValue(KeyMessageKey, string(r.message.Key))
+		}
+		ruleMsg := types.NewMsg(0, r.From(), types.JSON, metadata, string(r.Body()))
+		r.msg = &ruleMsg
+	}
+	return r.msg
+}
+
+func (r *RequestMessage) SetStatusCode(statusCode int) {
+}
+
+func (r *RequestMessage) SetBody(body []byte) {
+	r.body = body
+}
+
+func (r *RequestMessage) SetError(err error) {
+	r.err = err
+}
+
+func (r *RequestMessage) GetError() error {
+	return r.err
+}
+
+// Message 返回原始 Kafka 记录
+func (r *RequestMessage) Message() *sarama.ConsumerMessage {
+	return r.message
+}
+
+// ResponseMessage represents the processing result of a Kafka record.
+// Its error decides whether the offset of the record is committed.
+//
+// ResponseMessage 表示 Kafka 记录的处理结果。
+// 它的错误决定是否提交该记录的位移。
+type ResponseMessage struct {
+	//响应头  Response headers  头部映射
+	headers textproto.MIMEHeader
+	//原始 Kafka 记录  Original Kafka record  原始记录
+	message *sarama.ConsumerMessage
+	//响应消息体  Response body  响应消息体
+	body []byte
+	//处理结果的规则消息  Rule message with processing results  处理结果的规则消息
+	msg *types.RuleMsg
+	//处理过程中的错误，规则链有多个结束分支时保留最后一个错误  Error during processing, the last error is kept when the chain has several ends  处理错误
+	err error
+}
+
+func (r *ResponseMessage) Body() []byte {
+	return r.body
+}
+
+func (r *ResponseMessage) Headers() textproto.MIMEHeader {
+	if r.headers == nil {
+		r.headers = make(map[string][]string)
+	}
+	return r.headers
+}
+
+func (r *ResponseMessage) From() string {
+	if r.message == nil {
+		return ""
+	}
+	return r.message.Topic
+}
+
+// GetParam 不提供获取参数
+func (r *ResponseMessage) GetParam(key string) string {
+	return ""
+}
+
+func (r *ResponseMessage) SetMsg(msg *types.RuleMsg) {
+	r.msg = msg
+}
+
+func (r *ResponseMessage) GetMsg() *types.RuleMsg {
+	return r.msg
+}
+
+func (r *ResponseMessage) SetStatusCode(statusCode int) {
+}
+
+func (r *ResponseMessage) SetBody(body []byte) {
+	r.body = body
+}
+
+// SetError 记录处理错误，成功的结束分支不会清除其他分支的错误
+func (r *ResponseMessage) SetError(err error) {
+	if err != nil {
+		r.err = err
+	}
+}
+
+func (r *ResponseMessage) GetError() error {
+	return r.err
+}
+
+// Kafka represents a Kafka consumer group endpoint for the RuleGo framework.
+// Kafka 表示 RuleGo 框架的 Kafka 消费者组端点。
+type Kafka struct {
+	// BaseEndpoint provides common endpoint functionality
+	// BaseEndpoint 提供通用端点功能
+	impl.BaseEndpoint
+
+	// GracefulShutdown provides graceful shutdown capabilities
+	// GracefulShutdown 提供优雅停机功能
+	base.GracefulShutdown
+
+	// RuleConfig provides access to the rule engine configuration
+	// RuleConfig 提供对规则引擎配置的访问
+	RuleConfig types.Config
+
+	// Config contains the Kafka consumer configuration settings
+	// Config 包含 Kafka 消费者配置设置
+	Config Config
+
+	// group is the consumer group, created on Start
+	// group 消费者组，启动时创建
+	group sarama.ConsumerGroup
+	// cancel stops the consume loop
+	// cancel 停止消费循环
+	cancel context.CancelFunc
+	// sessionCancel ends the current session so that the consume loop re-subscribes
+	// sessionCancel 结束当前会话，消费循环重新订阅
+	sessionCancel context.CancelFunc
+	// done is closed when the consume loop has exited
+	// done 消费循环退出后关闭
+	done chan struct{}
+	// paused indicates whether fetching is paused manually
+	// paused 是否手动暂停了拉取
+	paused bool
+	// throttled counts the partitions waiting for a rate limit
+	// throttled 等待限流的分区数量
+	throttled int32
+	// started indicates whether the consumer group has been started
+	// started 消费者组是否已经启动
+	started bool
+	// producer publishes dead letters, created on the first dead letter
+	// producer 发布死信的生产者，第一条死信时创建
+	producer sarama.SyncProducer
+}
+
+// Type 组件类型
+func (x *Kafka) Type() string {
+	return Type
+}
+
+func (x *Kafka) New() types.Node {
+	return &Kafka{Config: Config{
+		Brokers:        []string{"127.0.0.1:9092"},
+		GroupId:        "rulego",
+		InitialOffset:  InitialOffsetNewest,
+		CommitInterval: 1000,
+		RetryInterval:  1000,
+		MaxRetries:     DefaultMaxRetries,
+	}}
+}
+
+// Init 初始化
+func (x *Kafka) Init(ruleConfig types.Config, configuration types.Configuration) error {
+	err := maps.Map2Struct(configuration, &x.Config)
+	x.RuleConfig = ruleConfig
+	// 初始化优雅停机功能 - 使用合理的默认超时(10秒)
+	x.GracefulShutdown.InitGracefulShutdown(x.RuleConfig.Logger, 10*time.Second)
+	if err != nil {
+		return err
+	}
+	if x.Config.GroupId == "" {
+		return errors.New("groupId can not be empty")
+	}
+	if x.Config.MaxRetries == 0 {
+		x.Config.MaxRetries = DefaultMaxRetries
+	}
+	_, err = x.consumerConfig()
+	return err
+}
+
+// Destroy 销毁
+func (x *Kafka) Destroy() {
+	x.GracefulShutdown.GracefulStop(func() {
+		_ = x.Close()
+	})
+}
+
+// GracefulStop provides graceful shutdown for the Kafka endpoint
+// GracefulStop 为 Kafka 端点提供优雅停机
+func (x *Kafka) GracefulStop() {
+	x.GracefulShutdown.GracefulStop(func() {
+		_ = x.Close()
+	})
+}
+
+// Close 离开消费者组，已处理成功的位移在离开前提交
+func (x *Kafka) Close() error {
+	x.Lock()
+	group, cancel, done, producer := x.group, x.cancel, x.done, x.producer
+	x.group, x.cancel, x.sessionCancel, x.done, x.producer = nil, nil, nil, nil, nil
+	x.started = false
+	x.Unlock()
+	if cancel != nil {
+		cancel()
+	}
+	var err error
+	if group != nil {
+		err = group.Close()
+	}
+	if done != nil {
+		<-done
+	}
+	if producer != nil {
+		_ = producer.Close()
+	}
+	return err
+}
+
+func (x *Kafka) Id() string {
+	return x.Config.ToKafkaConfig().ResourcePath() + "/" + x.Config.GroupId
+}
+
+func (x *Kafka) AddRouter(router endpoint.Router, params ...interface{}) (string, error) {
+	if router == nil {
+		return "", errors.New("router can not nil")
+	}
+	if router.GetFrom() == nil || router.FromToString() == "" {
+		return "", errors.New("from topic can not be empty")
+	}
+	x.CheckAndSetRouterId(router)
+	//遵循路由的同步设置，没有调用 Wait() 的 To 异步执行，记录交给规则链后即提交
+	x.Lock()
+	if x.RouterStorage == nil {
+		x.RouterStorage = make(map[string]endpoint.Router)
+	}
+	x.RouterStorage[router.GetId()] = router
+	x.Unlock()
+	x.resubscribe()
+	return router.GetId(), nil
+}
+
+func (x *Kafka) RemoveRouter(routerId string, params ...interface{}) error {
+	x.Lock()
+	router, ok := x.RouterStorage[routerId]
+	if ok {
+		delete(x.RouterStorage, routerId)
+	}
+	x.Unlock()
+	if !ok {
+		return fmt.Errorf("router: %s not found", routerId)
+	}
+	if x.routerOf(router.FromToString()) == nil {
+		x.resubscribe()
+	}
+	return nil
+}
+
+func (x *Kafka) Start() error {
+	x.Lock()
+	defer x.Unlock()
+	if x.started {
+		return nil
+	}
+	config, err := x.consumerConfig()
+	if err != nil {
+		return err
+	}
+	group, err := sarama.NewConsumerGroup(x.Config.Brokers, x.Config.GroupId, config)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	x.group, x.cancel, x.done = group, cancel, make(chan struct{})
+	x.started = true
+	go x.logErrors(group)
+	go x.consume(ctx, group, x.done)
+	return nil
+}
+
+// Pause 暂停拉取已分配分区的记录，已拉取的记录继续处理，消费者组会话保持
+func (x *Kafka) Pause() {
+	x.Lock()
+	x.paused = true
+	group := x.group
+	x.Unlock()
+	if group != nil {
+		group.PauseAll()
+	}
+}
+
+// Resume 恢复拉取
+func (x *Kafka) Resume() {
+	x.Lock()
+	x.paused = false
+	group := x.group
+	x.Unlock()
+	if group != nil && atomic.LoadInt32(&x.throttled) == 0 {
+		group.ResumeAll()
+	}
+}
+
+// Paused 是否手动暂停了拉取
+func (x *Kafka) Paused() bool {
+	x.RLock()
+	defer x.RUnlock()
+	return x.paused
+}
+
+func (x *Kafka) Printf(format string, v ...interface{}) {
+	if x.RuleConfig.Logger != nil {
+		x.RuleConfig.Logger.Printf(format, v...)
+	}
+}
+
+// Setup 实现 sarama.ConsumerGroupHandler
+func (x *Kafka) Setup(session sarama.ConsumerGroupSession) error {
+	return nil
+}
+
+// Cleanup 实现 sarama.ConsumerGroupHandler
+func (x *Kafka) Cleanup(session sarama.ConsumerGroupSession) error {
+	return nil
+}
+
+// ConsumeClaim 实现 sarama.ConsumerGroupHandler，逐条处理分区的记录，处理成功后标记位移
+func (x *Kafka) ConsumeClaim(session sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
+	if x.Paused() || atomic.LoadInt32(&x.throttled) > 0 {
+		if group := x.consumerGroup(); group != nil {
+			group.Pause(map[string][]int32{claim.Topic(): {claim.Partition()}})
+		}
+	}
+	for {
+		select {
+		case message, ok := <-claim.Messages():
+			if !ok {
+				return nil
+			}
+			if !x.process(session.Context(), message) {
+				return nil
+			}
+			session.MarkMessage(message, "")
+		case <-session.Context().Done():
+			return nil
+		}
+	}
+}
+
+// process 处理记录直到成功，返回false表示会话结束前没有处理成功，不能提交位移
+func (x *Kafka) process(ctx context.Context, message *sarama.ConsumerMessage) bool {
+	for failures := 0; ; {
+		if err := x.GracefulShutdown.CheckShutdownSignal(); err != nil {
+			return false
+		}
+		exchange := x.handle(message)
+		if exchange == nil || exchange.Out.GetError() == nil {
+			return true
+		}
+		wait := time.Duration(x.Config.RetryInterval) * time.Millisecond
+		if rateLimitErr, ok := endpoint.GetRateLimitError(exchange); ok {
+			if x.OnEvent != nil {
+				x.OnEvent(endpoint.EventRateLimited, exchange, rateLimitErr)
+			}
+			if !x.throttle(ctx, rateLimitErr.RetryAfter) {
+				return false
+			}
+			continue
+		}
+		failures++
+		if x.Config.MaxRetries >= 0 && failures > x.Config.MaxRetries {
+			return x.skip(ctx, message, exchange.Out.GetError())
+		}
+		x.Printf("kafka message failed, retry in %s, topic=%s partition=%d offset=%d: %v",
+			wait, message.Topic, message.Partition, message.Offset, exchange.Out.GetError())
+		select {
+		case <-ctx.Done():
+			return false
+		case <-time.After(wait):
+		}
+	}
+}
+
+// skip 跳过重试 MaxRetries 次仍失败的记录，设置了死信主题时先发布到死信主题，发布失败每隔 RetryInterval 重试
+// 返回false表示会话结束前没有发布成功，不能提交位移
+func (x *Kafka) skip(ctx context.Context, message *sarama.ConsumerMessage, cause error) bool {
+	if x.Config.DeadLetterTopic == "" {
+		x.Printf("kafka message skipped after %d retries, topic=%s partition=%d offset=%d: %v",
+			x.Config.MaxRetries, message.Topic, message.Partition, message.Offset, cause)
+		return true
+	}
+	for {
+		err := x.sendDeadLetter(message, cause)
+		if err == nil {
+			x.Printf("kafka message sent to dead letter topic %s after %d retries, topic=%s partition=%d offset=%d: %v",
+				x.Config.DeadLetterTopic, x.Config.MaxRetries, message.Topic, message.Partition, message.Offset, cause)
+			return true
+		}
+		x.Printf("kafka dead letter failed, topic=%s partition=%d offset=%d: %v",
+			message.Topic, message.Partition, message.Offset, err)
+		select {
+		case <-ctx.Done():
+			return false
+		case <-time.After(time.Duration(x.Config.RetryInterval) * time.Millisecond):
+		}
+	}
+}
+
+// sendDeadLetter 把记录发布到死信主题
+func (x *Kafka) sendDeadLetter(message *sarama.ConsumerMessage, cause error) error {
+	producer, err := x.deadLetterProducer()
+	if err != nil {
+		return err
+	}
+	headers := make([]sarama.RecordHeader, 0, len(message.Headers)+4)
+	for _, h := range message.Headers {
+		if h != nil {
+			headers = append(headers, *h)
+		}
+	}
+	headers = append(headers,
+		sarama.RecordHeader{Key: []byte(KeyRequestTopic), Value: []byte(message.Topic)},
+		sarama.RecordHeader{Key: []byte(KeyPartition), Value: []byte(strconv.FormatInt(int64(message.Partition), 10))},
+		sarama.RecordHeader{Key: []byte(KeyOffset), Value: []byte(strconv.FormatInt(message.Offset, 10))},
+	)
+	if cause != nil {
+		headers = append(headers, sarama.RecordHeader{Key: []byte(KeyError), Value: []byte(cause.Error())})
+	}
+	dlq := &sarama.ProducerMessage{
+		Topic:   x.Config.DeadLetterTopic,
+		Value:   sarama.ByteEncoder(message.Value),
+		Headers: headers,
+	}
+	if message.Key != nil {
+		dlq.Key = sarama.ByteEncoder(message.Key)
+	}
+	_, _, err = producer.SendMessage(dlq)
+	return err
+}
+
+// deadLetterProducer 获取死信生产者，没有则创建
+func (x *Kafka) deadLetterProducer() (sarama.SyncProducer, error) {
+	x.Lock()
+	defer x.Unlock()
+	if x.producer != nil {
+		return x.producer, nil
+	}
+	if !x.started {
+		return nil, errors.New("kafka endpoint is closed")
+	}
+	config, err := kafka.NewSaramaConfig(x.Config.ToKafkaConfig())
+	if err != nil {
+		return nil, err
+	}
+	config.Producer.RequiredAcks = sarama.WaitForAll
+	config.Producer.Return.Successes = true
+	producer, err := sarama.NewSyncProducer(x.Config.Brokers, config)
+	if err != nil {
+		return nil, err
+	}
+	x.producer = producer
+	return producer, nil
+}
+
+// handle 把记录交给路由器处理，没有匹配的路由器返回nil
+func (x *Kafka) handle(message *sarama.ConsumerMessage) (exchange *endpoint.Exchange) {
+	router := x.routerOf(message.Topic)
+	if router == nil {
+		return nil
+	}
+	exchange = &endpoint.Exchange{
+		In: &RequestMessage{
+			message: message,
+		},
+		Out: &ResponseMessage{
+			message: message,
+		},
+	}
+	defer func() {
+		//捕捉异常
+		if e := recover(); e != nil {
+			x.Printf("kafka endpoint handler err :\n%v", runtime.Stack())
+			exchange.Out.SetError(fmt.Errorf("%v", e))
+		}
+	}()
+	// 增加活跃操作计数
+	x.GracefulShutdown.IncrementActiveOperations()
+	defer x.GracefulShutdown.DecrementActiveOperations()
+
+	x.DoProcess(x.GracefulShutdown.GetShutdownContext(), router, exchange)
+	return exchange
+}
+
+// throttle 限流时暂停拉取所有分区，等待后恢复，手动暂停时不恢复
+func (x *Kafka) throttle(ctx context.Context, wait time.Duration) bool {
+	group := x.consumerGroup()
+	if group == nil {
+		return false
+	}
+	atomic.AddInt32(&x.throttled, 1)
+	group.PauseAll()
+	defer func() {
+		if atomic.AddInt32(&x.throttled, -1) == 0 && !x.Paused() {
+			group.ResumeAll()
+		}
+	}()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-time.After(wait):
+		return true
+	}
+}
+
+// consume 消费循环，会话因重平衡或者路由变化结束后重新加入消费者组
+func (x *Kafka) consume(ctx context.Context, group sarama.ConsumerGroup, done chan struct{}) {
+	defer close(done)
+	for ctx.Err() == nil {
+		x.Lock()
+		topics := x.topics()
+		sessionCtx, sessionCancel := context.WithCancel(ctx)
+		x.sessionCancel = sessionCancel
+		x.Unlock()
+		if len(topics) == 0 {
+			//没有路由，等待添加路由
+			<-sessionCtx.Done()
+		} else if err := group.Consume(sessionCtx, topics, x); err != nil {
+			if errors.Is(err, sarama.ErrClosedConsumerGroup) {
+				sessionCancel()
+				return
+			}
+			x.Printf("kafka consume topics=%v error: %v", topics, err)
+			select {
+			case <-ctx.Done():
+			case <-time.After(time.Duration(x.Config.RetryInterval) * time.Millisecond):
+			}
+		}
+		sessionCancel()
+	}
+}
+
+// logErrors 输出消费者组的后台错误，消费者组关闭后退出
+func (x *Kafka) logErrors(group sarama.ConsumerGroup) {
+	for err := range group.Errors() {
+		x.Printf("kafka consumer group error: %v", err)
+	}
+}
+
+// resubscribe 结束当前会话，消费循环使用最新的主题重新订阅
+func (x *Kafka) resubscribe() {
+	x.RLock()
+	cancel := x.sessionCancel
+	x.RUnlock()
+	if cancel != nil {
+		cancel()
+	}
+}
+
+// topics 所有路由订阅的主题，调用方需要持有锁
+func (x *Kafka) topics() []string {
+	var topics []string
+	seen := make(map[string]bool)
+	for _, router := range x.RouterStorage {
+		topic := router.FromToString()
+		if !seen[topic] {
+			seen[topic] = true
+			topics = append(topics, topic)
+		}
+	}
+	return topics
+}
+
+// routerOf 查找订阅主题的路由
+func (x *Kafka) routerOf(topic string) endpoint.Router {
+	x.RLock()
+	defer x.RUnlock()
+	for _, router := range x.RouterStorage {
+		if router.FromToString() == topic {
+			return router
+		}
+	}
+	return nil
+}
+
+func (x *Kafka) consumerGroup() sarama.ConsumerGroup {
+	x.RLock()
+	defer x.RUnlock()
+	return x.group
+}
+
+// consumerConfig 生成消费者组配置
+func (x *Kafka) consumerConfig() (*sarama.Config, error) {
+	config, err := kafka.NewSaramaConfig(x.Config.ToKafkaConfig())
+	if err != nil {
+		return nil, err
+	}
+	switch strings.ToLower(x.Config.InitialOffset) {
+	case "", InitialOffsetNewest:
+		config.Consumer.Offsets.Initial = sarama.OffsetNewest
+	case InitialOffsetOldest:
+		config.Consumer.Offsets.Initial = sarama.OffsetOldest
+	default:
+		return nil, fmt.Errorf("invalid initialOffset: %s", x.Config.InitialOffset)
+	}
+	switch strings.ToLower(x.Config.BalanceStrategy) {
+	case "", sarama.RangeBalanceStrategyName:
+		config.Consumer.Group.Rebalance.GroupStrategies = []sarama.BalanceStrategy{sarama.NewBalanceStrategyRange()}
+	case sarama.RoundRobinBalanceStrategyName:
+		config.Consumer.Group.Rebalance.GroupStrategies = []sarama.BalanceStrategy{sarama.NewBalanceStrategyRoundRobin()}
+	case sarama.StickyBalanceStrategyName:
+		config.Consumer.Group.Rebalance.GroupStrategies = []sarama.BalanceStrategy{sarama.NewBalanceStrategySticky()}
+	default:
+		return nil, fmt.Errorf("invalid balanceStrategy: %s", x.Config.BalanceStrategy)
+	}
+	if x.Config.CommitInterval <= 0 {
+		x.Config.CommitInterval = 1000
+	}
+	if x.Config.RetryInterval <= 0 {
+		x.Config.RetryInterval = 1000
+	}
+	//只提交标记过的位移，标记在处理成功后进行
+	config.Consumer.Offsets.AutoCommit.Enable = true
+	config.Consumer.Offsets.AutoCommit.Interval = time.Duration(x.Config.CommitInterval) * time.Millisecond
+	config.Consumer.Return.Errors = true
+	return config, config.Validate()
+}
@@ -0,0 +1,387 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package kafka
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/yunboom/rulego/api/types"
+	"github.com/yunboom/rulego/api/types/endpoint"
+	endpointApi "github.com/yunboom/rulego/endpoint"
+	"github.com/yunboom/rulego/endpoint/impl"
+	"github.com/yunboom/rulego/engine"
+	"github.com/yunboom/rulego/test"
+	"github.com/yunboom/rulego/test/assert"
+)
+
+const (
+	testTopic           = "orders"
+	testDeadLetterTopic = "orders.dlq"
+	testGroup           = "rulego-test"
+)
+
+var testChain = `{
+  "ruleChain": {"id": "kafkaTest", "name": "kafka test"},
+  "metadata": {
+    "nodes": [
+      {"id": "s1", "type": "jsFilter", "configuration": {"jsScript": "if (msg.fail) { throw new Error('fail'); } return true;"}}
+    ]
+  }
+}`
+
+// 测试请求/响应消息
+// 导入包后注册到默认端点注册表
+func TestRegister(t *testing.T) {
+	_, ok := endpointApi.Registry.GetComponents()[Type]
+	assert.True(t, ok)
+	ep, err := endpointApi.Registry.New(Type, engine.NewConfig(), types.Configuration{
+		"brokers": []string{"127.0.0.1:9092"},
+		"groupId": testGroup,
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, Type, ep.Type())
+}
+
+func TestKafkaMessage(t *testing.T) {
+	t.Run("Request", func(t *testing.T) {
+		var request = &RequestMessage{}
+		test.EndpointMessage(t, request)
+	})
+	t.Run("Response", func(t *testing.T) {
+		var response = &ResponseMessage{}
+		test.EndpointMessage(t, response)
+	})
+	t.Run("Record", func(t *testing.T) {
+		request := &RequestMessage{message: &sarama.ConsumerMessage{
+			Topic:     testTopic,
+			Partition: 2,
+			Offset:    15,
+			Key:       []byte("k1"),
+			Value:     []byte(`{"n":1}`),
+			Headers:   []*sarama.RecordHeader{{Key: []byte("traceId"), Value: []byte("t1")}},
+		}}
+		assert.Equal(t, testTopic, request.From())
+		assert.Equal(t, "t1", request.Headers().Get("traceId"))
+		assert.Equal(t, "15", request.Headers().Get(KeyOffset))
+		msg := request.GetMsg()
+		assert.Equal(t, `{"n":1}`, msg.GetData())
+		assert.Equal(t, testTopic, msg.Metadata.GetValue(KeyRequestTopic))
+		assert.Equal(t, "2", msg.Metadata.GetValue(KeyPartition))
+		assert.Equal(t, "15", msg.Metadata.GetValue(KeyOffset))
+		assert.Equal(t, "k1", msg.Metadata.GetValue(KeyMessageKey))
+		assert.Equal(t, "t1", msg.Metadata.GetValue("traceId"))
+	})
+}
+
+func TestRouterId(t *testing.T) {
+	var ep = &Endpoint{}
+	err := ep.Init(types.NewConfig(), types.Configuration{"brokers": []string{"127.0.0.1:9092"}, "groupId": testGroup})
+	assert.Nil(t, err)
+	assert.Equal(t, "127.0.0.1:9092/"+testGroup, ep.Id())
+
+	router := impl.NewRouter().SetId("r1").From(testTopic).End()
+	routerId, _ := ep.AddRouter(router)
+	assert.Equal(t, "r1", routerId)
+	router = impl.NewRouter().From("payments").End()
+	routerId, _ = ep.AddRouter(router)
+	assert.Equal(t, "payments", routerId)
+	_, err = ep.AddRouter(nil)
+	assert.NotNil(t, err)
+	_, err = ep.AddRouter(impl.NewRouter())
+	assert.NotNil(t, err)
+
+	err = ep.RemoveRouter("r1")
+	assert.Nil(t, err)
+	err = ep.RemoveRouter("r1")
+	assert.Equal(t, "router: r1 not found", err.Error())
+}
+
+func TestKafkaConfig(t *testing.T) {
+	config := types.NewConfig()
+	assert.NotNil(t, (&Endpoint{}).Init(config, types.Configuration{"brokers": []string{"127.0.0.1:9092"}}))
+	assert.NotNil(t, (&Endpoint{}).Init(config, types.Configuration{"brokers": []string{}, "groupId": testGroup}))
+	assert.NotNil(t, (&Endpoint{}).Init(config, types.Configuration{"brokers": []string{"127.0.0.1:9092"}, "groupId": testGroup, "initialOffset": "latest"}))
+	assert.NotNil(t, (&Endpoint{}).Init(config, types.Configuration{"brokers": []string{"127.0.0.1:9092"}, "groupId": testGroup, "balanceStrategy": "random"}))
+	assert.NotNil(t, (&Endpoint{}).Init(config, types.Configuration{"brokers": []string{"127.0.0.1:9092"}, "groupId": testGroup, "version": "x"}))
+	assert.NotNil(t, (&Endpoint{}).Init(config, types.Configuration{"brokers": []string{"127.0.0.1:9092"}, "groupId": testGroup, "username": "u", "saslMechanism": "GSSAPI"}))
+
+	ep := (&Endpoint{}).New().(*Endpoint)
+	assert.Nil(t, ep.Init(config, types.Configuration{"balanceStrategy": "sticky", "initialOffset": "oldest"}))
+	assert.Equal(t, Type, ep.Type())
+	assert.Equal(t, "rulego", ep.Config.GroupId)
+}
+
+// newMockBroker 进程内的kafka broker替身，负责消费者组协调并返回fetch中的记录
+func newMockBroker(t *testing.T, fetch *sarama.MockFetchResponse) *sarama.MockBroker {
+	broker := sarama.NewMockBroker(t, 1)
+	broker.SetHandlerByMap(map[string]sarama.MockResponse{
+		"MetadataRequest": sarama.NewMockMetadataResponse(t).
+			SetBroker(broker.Addr(), broker.BrokerID()).
+			SetLeader(testTopic, 0, broker.BrokerID()).
+			SetLeader(testDeadLetterTopic, 0, broker.BrokerID()),
+		"OffsetRequest": sarama.NewMockOffsetResponse(t).
+			SetOffset(testTopic, 0, sarama.OffsetOldest, 0).
+			SetOffset(testTopic, 0, sarama.OffsetNewest, 3),
+		"FindCoordinatorRequest": sarama.NewMockFindCoordinatorResponse(t).
+			SetCoordinator(sarama.CoordinatorGroup, testGroup, broker),
+		"HeartbeatRequest": sarama.NewMockHeartbeatResponse(t),
+		"JoinGroupRequest": sarama.NewMockJoinGroupResponse(t).SetGroupProtocol(sarama.RangeBalanceStrategyName),
+		"SyncGroupRequest": sarama.NewMockSyncGroupResponse(t).SetMemberAssignment(
+			&sarama.ConsumerGroupMemberAssignment{
+				Topics: map[string][]int32{testTopic: {0}},
+			}),
+		"OffsetFetchRequest": sarama.NewMockOffsetFetchResponse(t).
+			SetOffset(testGroup, testTopic, 0, -1, "", sarama.ErrNoError).
+			SetError(sarama.ErrNoError),
+		"FetchRequest":        fetch,
+		"OffsetCommitRequest": sarama.NewMockOffsetCommitResponse(t),
+		"ProduceRequest":      sarama.NewMockProduceResponse(t),
+		"LeaveGroupRequest":   sarama.NewMockLeaveGroupResponse(t),
+	})
+	return broker
+}
+
+// deadLetters broker收到的死信发布请求
+func deadLetters(broker *sarama.MockBroker) []*sarama.ProduceRequest {
+	var requests []*sarama.ProduceRequest
+	for _, item := range broker.History() {
+		if req, ok := item.Request.(*sarama.ProduceRequest); ok {
+			requests = append(requests, req)
+		}
+	}
+	return requests
+}
+
+// committedOffset broker收到的最大提交位移，没有提交返回-1
+func committedOffset(broker *sarama.MockBroker) int64 {
+	var committed int64 = -1
+	for _, item := range broker.History() {
+		if req, ok := item.Request.(*sarama.OffsetCommitRequest); ok {
+			if offset, _, err := req.Offset(testTopic, 0); err == nil && offset > committed {
+				committed = offset
+			}
+		}
+	}
+	return committed
+}
+
+func waitFor(t *testing.T, timeout time.Duration, condition func() bool) {
+	deadline := time.Now().Add(timeout)
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatal("timeout")
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+// 记录to执行结果
+type recorder struct {
+	sync.Mutex
+	offsets []string
+	errs    []error
+}
+
+func (r *recorder) process(router endpoint.Router, exchange *endpoint.Exchange) bool {
+	r.Lock()
+	defer r.Unlock()
+	r.offsets = append(r.offsets, exchange.In.GetMsg().Metadata.GetValue(KeyOffset))
+	r.errs = append(r.errs, exchange.Out.GetError())
+	return true
+}
+
+func (r *recorder) get() []string {
+	r.Lock()
+	defer r.Unlock()
+	return append([]string(nil), r.offsets...)
+}
+
+func newEndpoint(t *testing.T, broker *sarama.MockBroker, configuration types.Configuration) (*Endpoint, types.Config) {
+	config := engine.NewConfig(types.WithDefaultPool())
+	_, err := engine.New("kafkaTest", []byte(testChain), engine.WithConfig(config))
+	assert.Nil(t, err)
+	configuration["brokers"] = []string{broker.Addr()}
+	configuration["groupId"] = testGroup
+	configuration["initialOffset"] = InitialOffsetOldest
+	configuration["commitInterval"] = 20
+	configuration["retryInterval"] = 20
+	var ep = &Endpoint{}
+	assert.Nil(t, ep.Init(config, configuration))
+	return ep, config
+}
+
+func TestKafkaEndpoint(t *testing.T) {
+	t.Run("CommitAfterSuccess", func(t *testing.T) {
+		fetch := sarama.NewMockFetchResponse(t, 3).
+			SetMessageWithKey(testTopic, 0, 0, sarama.StringEncoder("k0"), sarama.StringEncoder(`{"n":0}`)).
+			SetMessageWithKey(testTopic, 0, 1, sarama.StringEncoder("k1"), sarama.StringEncoder(`{"n":1,"fail":true}`)).
+			SetMessageWithKey(testTopic, 0, 2, sarama.StringEncoder("k2"), sarama.StringEncoder(`{"n":2}`)).
+			SetHighWaterMark(testTopic, 0, 3)
+		broker := newMockBroker(t, fetch)
+		defer broker.Close()
+
+		ep, _ := newEndpoint(t, broker, types.Configuration{"maxRetries": -1})
+		rec := &recorder{}
+		var keys sync.Map
+		_, err := ep.AddRouter(impl.NewRouter().From(testTopic).Process(func(router endpoint.Router, exchange *endpoint.Exchange) bool {
+			msg := exchange.In.GetMsg()
+			keys.Store(msg.Metadata.GetValue(KeyOffset), msg.Metadata.GetValue(KeyMessageKey))
+			return true
+		}).To("chain:kafkaTest").Process(rec.process).Wait().End())
+		assert.Nil(t, err)
+		assert.Nil(t, ep.Start())
+		assert.Nil(t, ep.Start())
+
+		//第二条记录一直失败，后续记录等待，位移只提交到第一条之后
+		waitFor(t, 5*time.Second, func() bool {
+			return len(rec.get()) >= 4
+		})
+		time.Sleep(100 * time.Millisecond)
+		offsets := rec.get()
+		assert.Equal(t, "0", offsets[0])
+		for _, offset := range offsets[1:] {
+			assert.Equal(t, "1", offset)
+		}
+		assert.Equal(t, int64(1), committedOffset(broker))
+		key, _ := keys.Load("0")
+		assert.Equal(t, "k0", key)
+		rec.Lock()
+		assert.Nil(t, rec.errs[0])
+		assert.NotNil(t, rec.errs[1])
+		rec.Unlock()
+
+		assert.Nil(t, ep.Close())
+		assert.Equal(t, int64(1), committedOffset(broker))
+	})
+
+	t.Run("MaxRetries", func(t *testing.T) {
+		fetch := sarama.NewMockFetchResponse(t, 3).
+			SetMessage(testTopic, 0, 0, sarama.StringEncoder(`{"n":0}`)).
+			SetMessage(testTopic, 0, 1, sarama.StringEncoder(`{"n":1,"fail":true}`)).
+			SetMessage(testTopic, 0, 2, sarama.StringEncoder(`{"n":2}`)).
+			SetHighWaterMark(testTopic, 0, 3)
+		broker := newMockBroker(t, fetch)
+		defer broker.Close()
+
+		ep, _ := newEndpoint(t, broker, types.Configuration{"maxRetries": 2})
+		rec := &recorder{}
+		_, _ = ep.AddRouter(impl.NewRouter().From(testTopic).To("chain:kafkaTest").Process(rec.process).Wait().End())
+		assert.Nil(t, ep.Start())
+
+		//失败记录重试2次后跳过
+		waitFor(t, 5*time.Second, func() bool {
+			return committedOffset(broker) == 3
+		})
+		assert.Equal(t, []string{"0", "1", "1", "1", "2"}, rec.get())
+		assert.Equal(t, 0, len(deadLetters(broker)))
+		ep.Destroy()
+	})
+
+	t.Run("DeadLetter", func(t *testing.T) {
+		fetch := sarama.NewMockFetchResponse(t, 3).
+			SetMessage(testTopic, 0, 0, sarama.StringEncoder(`{"n":0}`)).
+			SetMessage(testTopic, 0, 1, sarama.StringEncoder(`{"n":1,"fail":true}`)).
+			SetMessage(testTopic, 0, 2, sarama.StringEncoder(`{"n":2}`)).
+			SetHighWaterMark(testTopic, 0, 3)
+		broker := newMockBroker(t, fetch)
+		defer broker.Close()
+
+		//默认重试3次后发布到死信主题
+		ep, _ := newEndpoint(t, broker, types.Configuration{"deadLetterTopic": testDeadLetterTopic})
+		assert.Equal(t, DefaultMaxRetries, ep.Config.MaxRetries)
+		rec := &recorder{}
+		_, _ = ep.AddRouter(impl.NewRouter().From(testTopic).To("chain:kafkaTest").Process(rec.process).Wait().End())
+		assert.Nil(t, ep.Start())
+
+		waitFor(t, 5*time.Second, func() bool {
+			return committedOffset(broker) == 3
+		})
+		assert.Equal(t, []string{"0", "1", "1", "1", "1", "2"}, rec.get())
+		assert.Equal(t, 1, len(deadLetters(broker)))
+		ep.Destroy()
+	})
+
+	t.Run("AsyncTo", func(t *testing.T) {
+		fetch := sarama.NewMockFetchResponse(t, 3).
+			SetMessage(testTopic, 0, 0, sarama.StringEncoder(`{"n":0}`)).
+			SetMessage(testTopic, 0, 1, sarama.StringEncoder(`{"n":1,"fail":true}`)).
+			SetMessage(testTopic, 0, 2, sarama.StringEncoder(`{"n":2}`)).
+			SetHighWaterMark(testTopic, 0, 3)
+		broker := newMockBroker(t, fetch)
+		defer broker.Close()
+
+		ep, _ := newEndpoint(t, broker, types.Configuration{})
+		rec := &recorder{}
+		_, _ = ep.AddRouter(impl.NewRouter().From(testTopic).To("chain:kafkaTest").Process(rec.process).End())
+		assert.Nil(t, ep.Start())
+
+		//没有调用 Wait() 的 To 异步执行，交给规则链后即提交，不重试
+		waitFor(t, 5*time.Second, func() bool {
+			return committedOffset(broker) == 3 && len(rec.get()) == 3
+		})
+		time.Sleep(100 * time.Millisecond)
+		assert.Equal(t, 3, len(rec.get()))
+		ep.Destroy()
+	})
+
+	t.Run("PauseAndRateLimit", func(t *testing.T) {
+		fetch := sarama.NewMockFetchResponse(t, 3).
+			SetMessage(testTopic, 0, 0, sarama.StringEncoder(`{"n":0}`)).
+			SetMessage(testTopic, 0, 1, sarama.StringEncoder(`{"n":1}`)).
+			SetHighWaterMark(testTopic, 0, 2)
+		broker := newMockBroker(t, fetch)
+		defer broker.Close()
+
+		ep, _ := newEndpoint(t, broker, types.Configuration{})
+		var rateLimited int32
+		ep.SetOnEvent(func(eventName string, params ...interface{}) {
+			if eventName == endpoint.EventRateLimited {
+				atomic.AddInt32(&rateLimited, 1)
+			}
+		})
+		var limited int32
+		rec := &recorder{}
+		//第一次处理时被限流
+		_, _ = ep.AddRouter(impl.NewRouter().From(testTopic).Process(func(router endpoint.Router, exchange *endpoint.Exchange) bool {
+			if atomic.CompareAndSwapInt32(&limited, 0, 1) {
+				exchange.Out.SetError(&endpoint.RateLimitError{Key: testTopic, Err: types.ErrRateLimitExceeded, RetryAfter: 50 * time.Millisecond})
+				return false
+			}
+			return true
+		}).To("chain:kafkaTest").Process(rec.process).Wait().End())
+
+		//启动前暂停，不处理任何记录
+		ep.Pause()
+		assert.True(t, ep.Paused())
+		assert.Nil(t, ep.Start())
+		time.Sleep(300 * time.Millisecond)
+		assert.Equal(t, 0, len(rec.get()))
+
+		ep.Resume()
+		assert.False(t, ep.Paused())
+		waitFor(t, 5*time.Second, func() bool {
+			return committedOffset(broker) == 2
+		})
+		assert.Equal(t, []string{"0", "1"}, rec.get())
+		assert.Equal(t, int32(1), atomic.LoadInt32(&rateLimited))
+		ep.Destroy()
+	})
+}
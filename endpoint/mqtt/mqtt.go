@@ -770,6 +770,13 @@ func (x *Mqtt) handler(router endpoint.Router) func(c paho.Client, data paho.Mes
 
 		// 使用停机上下文处理消息
 		x.DoProcess(x.GracefulShutdown.GetShutdownContext(), router, exchange)
+		//限流拒绝，丢弃消息并触发事件
+		if err, ok := endpoint.GetRateLimitError(exchange); ok {
+			x.Printf("mqtt message dropped, topic=%s: %v", data.Topic(), err)
+			if x.OnEvent != nil {
+				x.OnEvent(endpoint.EventRateLimited, exchange, err)
+			}
+		}
 	}
 }
 
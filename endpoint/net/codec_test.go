@@ -0,0 +1,93 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package net
+
+import (
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/yunboom/rulego/api/types"
+	"github.com/yunboom/rulego/api/types/endpoint"
+	"github.com/yunboom/rulego/components/external"
+	"github.com/yunboom/rulego/endpoint/impl"
+	"github.com/yunboom/rulego/engine"
+	"github.com/yunboom/rulego/test"
+	"github.com/yunboom/rulego/test/assert"
+)
+
+// 测试 Modbus TCP 编解码器：endpoint 作为从站，net 节点作为主站
+func TestModbusCodecEndpoint(t *testing.T) {
+	config := engine.NewConfig(types.WithDefaultPool())
+	ep := &Net{}
+	err := ep.Init(config, types.Configuration{
+		"server":      ":8901",
+		"readTimeout": 5,
+		"codec":       "modbusTcp",
+	})
+	assert.Nil(t, err)
+	defer ep.Destroy()
+
+	router := impl.NewRouter().From("").Process(func(router endpoint.Router, exchange *endpoint.Exchange) bool {
+		msg := exchange.In.GetMsg()
+		assert.Equal(t, types.JSON, msg.GetDataType())
+		var request map[string]interface{}
+		assert.Nil(t, json.Unmarshal([]byte(msg.GetData()), &request))
+		assert.Equal(t, float64(3), request["functionCode"])
+		assert.Equal(t, float64(2), request["quantity"])
+		request["values"] = []int{215, 1013}
+		reply, _ := json.Marshal(request)
+		exchange.Out.SetBody(reply)
+		return false
+	}).End()
+	_, err = ep.AddRouter(router)
+	assert.Nil(t, err)
+	assert.Nil(t, ep.Start())
+	time.Sleep(time.Millisecond * 200)
+
+	node := &external.NetNode{}
+	err = node.Init(types.NewConfig(), types.Configuration{
+		"server":       "127.0.0.1:8901",
+		"codec":        "modbusTcp",
+		"codecConfig":  types.Configuration{"role": "client"},
+		"readResponse": true,
+	})
+	assert.Nil(t, err)
+	defer node.Destroy()
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	var result string
+	ctx := test.NewRuleContext(types.NewConfig(), func(msg types.RuleMsg, relationType string, err error) {
+		defer wg.Done()
+		assert.Nil(t, err)
+		assert.Equal(t, types.Success, relationType)
+		result = msg.GetData()
+	})
+	node.OnMsg(ctx, types.NewMsg(0, "READ", types.JSON, types.NewMetadata(),
+		`{"transactionId":7,"unitId":1,"functionCode":3,"address":0,"quantity":2}`))
+	wg.Wait()
+
+	var response map[string]interface{}
+	assert.Nil(t, json.Unmarshal([]byte(result), &response))
+	assert.Equal(t, float64(7), response["transactionId"])
+	assert.Equal(t, []interface{}{float64(215), float64(1013)}, response["values"])
+
+	// 非法的编解码器
+	assert.NotNil(t, (&Net{}).Init(config, types.Configuration{"codec": "notFound"}))
+}
@@ -0,0 +1,110 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package net
+
+import (
+	"bufio"
+	"errors"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/yunboom/rulego/api/types"
+	"github.com/yunboom/rulego/api/types/endpoint"
+	"github.com/yunboom/rulego/components/external"
+	"github.com/yunboom/rulego/endpoint/impl"
+	"github.com/yunboom/rulego/engine"
+	"github.com/yunboom/rulego/test"
+	"github.com/yunboom/rulego/test/assert"
+)
+
+// 测试连接注册表和服务端推送
+func TestConnectionRegistry(t *testing.T) {
+	config := engine.NewConfig(types.WithDefaultPool())
+	ep := &Net{}
+	err := ep.Init(config, types.Configuration{
+		"server":           ":8902",
+		"readTimeout":      5,
+		"clientIdField":    "device.id",
+		"connectionEvents": true,
+	})
+	assert.Nil(t, err)
+	defer ep.Destroy()
+
+	var lock sync.Mutex
+	var msgTypes []string
+	var connectionIds []string
+	router := impl.NewRouter().From("").Process(func(router endpoint.Router, exchange *endpoint.Exchange) bool {
+		msg := exchange.In.GetMsg()
+		lock.Lock()
+		defer lock.Unlock()
+		msgTypes = append(msgTypes, msg.Type)
+		connectionIds = append(connectionIds, msg.Metadata.GetValue(endpoint.MetadataKeyConnectionId))
+		return false
+	}).End()
+	_, err = ep.AddRouter(router)
+	assert.Nil(t, err)
+	assert.Nil(t, ep.Start())
+	time.Sleep(time.Millisecond * 200)
+
+	conn, err := net.Dial("tcp", "127.0.0.1:8902")
+	assert.Nil(t, err)
+	remoteAddr := conn.LocalAddr().String()
+	time.Sleep(time.Millisecond * 100)
+	//握手前以远程地址注册
+	_, ok := endpoint.Connections.Get(remoteAddr)
+	assert.True(t, ok)
+
+	_, err = conn.Write([]byte(`{"device":{"id":"dev01"}}` + "\n"))
+	assert.Nil(t, err)
+	time.Sleep(time.Millisecond * 100)
+	_, ok = endpoint.Connections.Get(remoteAddr)
+	assert.False(t, ok)
+	connection, ok := endpoint.Connections.Get("dev01")
+	assert.True(t, ok)
+	assert.Equal(t, remoteAddr, connection.RemoteAddr())
+
+	//通过 endpointSend 组件推送
+	node := (&external.EndpointSendNode{}).New()
+	assert.Nil(t, node.Init(types.NewConfig(), types.Configuration{"connectionId": "${metadata.deviceId}"}))
+	metadata := types.NewMetadata()
+	metadata.PutValue("deviceId", "dev01")
+	var sendErr error
+	ctx := test.NewRuleContext(types.NewConfig(), func(msg types.RuleMsg, relationType string, err error) {
+		sendErr = err
+	})
+	node.OnMsg(ctx, types.NewMsg(0, "COMMAND", types.TEXT, metadata, "reboot\n"))
+	assert.Nil(t, sendErr)
+	line, err := bufio.NewReader(conn).ReadString('\n')
+	assert.Nil(t, err)
+	assert.Equal(t, "reboot\n", line)
+
+	metadata.PutValue("deviceId", "dev02")
+	node.OnMsg(ctx, types.NewMsg(0, "COMMAND", types.TEXT, metadata, "reboot\n"))
+	assert.True(t, errors.Is(sendErr, types.ErrConnectionNotFound))
+
+	_ = conn.Close()
+	time.Sleep(time.Millisecond * 200)
+	_, ok = endpoint.Connections.Get("dev01")
+	assert.False(t, ok)
+
+	lock.Lock()
+	defer lock.Unlock()
+	assert.Equal(t, []string{endpoint.MsgTypeConnect, remoteAddr, endpoint.MsgTypeDisconnect}, msgTypes)
+	assert.Equal(t, []string{"dev01", "dev01", "dev01"}, connectionIds)
+}
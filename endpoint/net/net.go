@@ -36,6 +36,7 @@ import (
 	"context"
 	"encoding/base64"
 	"encoding/hex"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"net"
@@ -50,8 +51,10 @@ import (
 	"github.com/yunboom/rulego/api/types"
 	"github.com/yunboom/rulego/api/types/endpoint"
 	"github.com/yunboom/rulego/endpoint/impl"
+	"github.com/yunboom/rulego/utils/codec"
 	"github.com/yunboom/rulego/utils/maps"
 	"github.com/yunboom/rulego/utils/runtime"
+	"github.com/yunboom/rulego/utils/str"
 )
 
 const (
@@ -238,7 +241,9 @@ type ResponseMessage struct {
 	err     error
 	udpAddr *net.UDPAddr
 	from    string
-	mu      sync.RWMutex
+	// codec 协议编解码器，不为空时把 JSON 回复编码成帧
+	codec codec.Codec
+	mu    sync.RWMutex
 }
 
 func (r *ResponseMessage) Body() []byte {
@@ -286,7 +291,21 @@ func (r *ResponseMessage) SetStatusCode(statusCode int) {
 func (r *ResponseMessage) SetBody(body []byte) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
-	if r.msg != nil && r.msg.GetDataType() == types.JSON {
+	if r.codec != nil {
+		// 使用编解码器把 JSON 回复编码成帧
+		var data map[string]interface{}
+		if err := json.Unmarshal(body, &data); err != nil {
+			r.err = fmt.Errorf("codec encode err: %w", err)
+			return
+		}
+		frame, err := r.codec.Encode(data)
+		if err != nil {
+			r.err = fmt.Errorf("codec encode err: %w", err)
+			return
+		}
+		body = frame
+		r.body = body
+	} else if r.msg != nil && r.msg.GetDataType() == types.JSON {
 		// 检查JSON数据是否以换行符结尾，如果没有则添加
 		if len(body) > 0 && !strings.HasSuffix(string(body), LineBreak) {
 			body = append(body, LineBreak...)
@@ -364,6 +383,19 @@ func (r *ResponseMessage) GetError() error {
 //   - "length_prefix_*": Split by length prefix with various endianness options
 //     按长度前缀分割，支持各种字节序选项
 //
+// Protocol Codecs:
+// 协议编解码器：
+//
+// A codec registered in the codec package can be configured to decode each frame into
+// a JSON message and encode the JSON reply of the chain back into a frame:
+// 可以配置 codec 包中注册的编解码器，把每一帧解码成 JSON 消息，并把规则链的 JSON 回复编码成帧：
+//
+//	{
+//	  "server": ":502",
+//	  "codec": "modbusTcp",
+//	  "codecConfig": {"role": "server"}
+//	}
+//
 // Router Configuration Best Practices:
 // 路由配置最佳实践：
 //
@@ -425,6 +457,73 @@ type Config struct {
 	// 最大数据包大小，防止恶意数据包，默认64KB
 	// Maximum packet size to prevent malicious packets, default 64KB
 	MaxPacketSize int `json:"maxPacketSize"`
+
+	// 协议编解码器名称，例如 modbusTcp、binaryStruct，为空则不使用编解码器
+	// 配置后每一帧数据被解码成 JSON 消息，规则链的回复(JSON)被编码成帧
+	// 如果编解码器能够自行切分帧(如 modbusTcp)，则忽略 PacketMode
+	// Protocol codec name, such as modbusTcp or binaryStruct. Empty means no codec
+	// When configured, each frame is decoded into a JSON message and the JSON reply of the chain is encoded into a frame
+	// If the codec splits frames itself (such as modbusTcp), PacketMode is ignored
+	Codec string `json:"codec"`
+
+	// 协议编解码器配置，见 codec 包中各编解码器的配置
+	// Protocol codec configuration, see the configuration of each codec in the codec package
+	CodecConfig types.Configuration `json:"codecConfig"`
+
+	// 握手消息中客户端ID的字段，支持嵌套字段如 device.id。为空则使用远程地址作为连接ID
+	// TCP连接的第一条包含该字段的JSON消息被视为握手消息，之后连接以客户端ID注册到 endpoint.Connections
+	// Client id field of the handshake message, nested fields such as device.id are supported. Empty means the remote address is used as the connection id
+	// The first JSON message of a TCP connection containing the field is the handshake message, then the connection is registered to endpoint.Connections by the client id
+	ClientIdField string `json:"clientIdField"`
+
+	// 是否把TCP连接建立和断开作为 CONNECT/DISCONNECT 类型的消息路由到规则链
+	// Whether to route TCP connect and disconnect as CONNECT/DISCONNECT messages to the rule chain
+	ConnectionEvents bool `json:"connectionEvents"`
+}
+
+// Connection 在线的 TCP 客户端连接，注册到 endpoint.Connections 后可以通过连接ID向其推送消息
+type Connection struct {
+	id    string
+	conn  net.Conn
+	codec codec.Codec
+	lock  sync.RWMutex
+}
+
+// Id 连接ID，远程地址或者握手消息中的客户端ID
+func (c *Connection) Id() string {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.id
+}
+
+// RemoteAddr 客户端远程地址
+func (c *Connection) RemoteAddr() string {
+	if c.conn.RemoteAddr() == nil {
+		return ""
+	}
+	return c.conn.RemoteAddr().String()
+}
+
+// Send 向客户端写入消息，配置了编解码器时把 JSON 消息编码成帧
+func (c *Connection) Send(msg types.RuleMsg) error {
+	r := &ResponseMessage{conn: c.conn, codec: c.codec, msg: &msg}
+	if msg.GetDataType() == types.BINARY {
+		r.SetBody(msg.GetBytes())
+	} else {
+		r.SetBody([]byte(msg.GetData()))
+	}
+	return r.GetError()
+}
+
+// Close 关闭连接
+func (c *Connection) Close() error {
+	return c.conn.Close()
+}
+
+func (c *Connection) setId(id string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.id = id
 }
 
 // RegexpRouter 正则表达式路由
@@ -482,7 +581,9 @@ type Net struct {
 	udpConn *net.UDPConn
 	// 路由映射表
 	routers map[string]*RegexpRouter
-	closed  int32 // 使用int32类型支持原子操作，0表示未关闭，1表示已关闭
+	// 协议编解码器
+	codec  codec.Codec
+	closed int32 // 使用int32类型支持原子操作，0表示未关闭，1表示已关闭
 }
 
 // Type 组件类型
@@ -508,6 +609,9 @@ func (ep *Net) New() types.Node {
 func (ep *Net) Init(ruleConfig types.Config, configuration types.Configuration) error {
 	// 将配置转换为EndpointConfiguration结构体
 	err := maps.Map2Struct(configuration, &ep.Config)
+	if err != nil {
+		return err
+	}
 	if ep.Config.Protocol == "" {
 		ep.Config.Protocol = ProtocolTCP
 	}
@@ -517,8 +621,13 @@ func (ep *Net) Init(ruleConfig types.Config, configuration types.Configuration)
 	if ep.Config.MaxPacketSize <= 0 {
 		ep.Config.MaxPacketSize = DefaultMaxPacketSize
 	}
+	if ep.Config.Codec != "" {
+		if ep.codec, err = codec.Registry.New(ep.Config.Codec, ep.Config.CodecConfig); err != nil {
+			return err
+		}
+	}
 	ep.RuleConfig = ruleConfig
-	return err
+	return nil
 }
 
 // Destroy 销毁
@@ -745,6 +854,20 @@ func (ep *Net) encode(src []byte) ([]byte, types.DataType) {
 	return encodedMessage, dataType
 }
 
+// decode 使用协议编解码器把帧解码成 JSON 消息，未配置编解码器时使用 encode 处理
+func (ep *Net) decode(src []byte) ([]byte, types.DataType, error) {
+	if ep.codec == nil {
+		encodedMessage, dataType := ep.encode(src)
+		return encodedMessage, dataType, nil
+	}
+	data, err := ep.codec.Decode(src)
+	if err != nil {
+		return nil, types.JSON, err
+	}
+	encodedMessage, err := json.Marshal(data)
+	return encodedMessage, types.JSON, err
+}
+
 func (ep *Net) handler(conn net.Conn) {
 	h := TcpHandler{
 		endpoint: ep,
@@ -763,24 +886,47 @@ type TcpHandler struct {
 	config Config
 	// 数据包分割器
 	splitter PacketSplitter
+	// 注册到 endpoint.Connections 的连接
+	connection *Connection
+	// 是否已经通过握手消息获取客户端ID
+	identified bool
+	// 是否已经发送连接消息
+	connected bool
 }
 
 func (x *TcpHandler) handler() {
+	from := ""
+	if x.conn.RemoteAddr() != nil {
+		from = x.conn.RemoteAddr().String()
+	}
+	x.connection = &Connection{id: from, conn: x.conn, codec: x.endpoint.codec}
+	endpoint.Connections.Register(x.connection)
 	defer func() {
 		_ = x.conn.Close()
 		//捕捉异常
 		if e := recover(); e != nil {
 			x.endpoint.Printf("net endpoint handler err :\n%v", runtime.Stack())
 		}
+		endpoint.Connections.Unregister(x.connection)
+		if x.connected {
+			x.onConnectionMsg(endpoint.MsgTypeDisconnect)
+		}
 	}()
+	if x.endpoint.Config.ClientIdField == "" {
+		x.onConnectionMsg(endpoint.MsgTypeConnect)
+	}
 
-	// 创建数据包分割器
-	splitter, err := CreatePacketSplitter(x.endpoint.Config)
-	if err != nil {
-		x.endpoint.Printf("failed to create packet splitter: %v", err)
-		return
+	// 创建数据包分割器，编解码器能够自行切分帧时优先使用编解码器
+	if s, ok := x.endpoint.codec.(codec.Splitter); ok {
+		x.splitter = s
+	} else {
+		splitter, err := CreatePacketSplitter(x.endpoint.Config)
+		if err != nil {
+			x.endpoint.Printf("failed to create packet splitter: %v", err)
+			return
+		}
+		x.splitter = splitter
 	}
-	x.splitter = splitter
 
 	readTimeoutDuration := time.Duration(x.endpoint.Config.ReadTimeout+5) * time.Second
 	//读超时，断开连接
@@ -826,12 +972,16 @@ func (x *TcpHandler) handler() {
 			continue
 		}
 		// 编码处理
-		encodedMessage, dataType := x.endpoint.encode(data)
-
-		from := ""
-		if x.conn.RemoteAddr() != nil {
-			from = x.conn.RemoteAddr().String()
+		encodedMessage, dataType, err := x.endpoint.decode(data)
+		if err != nil {
+			x.endpoint.Printf("net endpoint decode err: %v", err)
+			continue
+		}
+		// 从握手消息中获取客户端ID
+		if x.endpoint.Config.ClientIdField != "" && !x.identified {
+			x.identify(encodedMessage)
 		}
+
 		// 创建一个交换对象，用于存储输入和输出的消息
 		exchange := &endpoint.Exchange{
 			In: &RequestMessage{
@@ -844,13 +994,15 @@ func (x *TcpHandler) handler() {
 				log: func(format string, v ...interface{}) {
 					x.endpoint.Printf(format, v...)
 				},
-				conn: x.conn,
-				from: from,
+				conn:  x.conn,
+				from:  from,
+				codec: x.endpoint.codec,
 			}}
 
 		msg := exchange.In.GetMsg()
 		// 把客户端连接的地址放到msg元数据中
 		msg.Metadata.PutValue(RemoteAddrKey, from)
+		msg.Metadata.PutValue(endpoint.MetadataKeyConnectionId, x.connection.Id())
 
 		// 匹配符合的路由，处理消息
 		for _, v := range x.endpoint.routers {
@@ -862,6 +1014,58 @@ func (x *TcpHandler) handler() {
 
 }
 
+// identify 从握手消息中获取客户端ID，并使用客户端ID重新注册连接
+func (x *TcpHandler) identify(message []byte) {
+	var data map[string]interface{}
+	if err := json.Unmarshal(message, &data); err != nil {
+		return
+	}
+	clientId := str.ToString(maps.Get(data, x.endpoint.Config.ClientIdField))
+	if clientId == "" {
+		return
+	}
+	endpoint.Connections.Unregister(x.connection)
+	x.connection.setId(clientId)
+	endpoint.Connections.Register(x.connection)
+	x.identified = true
+	x.onConnectionMsg(endpoint.MsgTypeConnect)
+}
+
+// onConnectionMsg 把连接建立或者断开作为消息路由到规则链
+func (x *TcpHandler) onConnectionMsg(msgType string) {
+	if msgType == endpoint.MsgTypeConnect {
+		x.connected = true
+	}
+	if !x.endpoint.Config.ConnectionEvents {
+		return
+	}
+	msg := endpoint.NewConnectionMsg(msgType, x.connection)
+	from := x.connection.RemoteAddr()
+	msg.Metadata.PutValue(RemoteAddrKey, from)
+	body := []byte(msg.GetData())
+	exchange := &endpoint.Exchange{
+		In: &RequestMessage{
+			conn:     x.conn,
+			body:     body,
+			msg:      &msg,
+			from:     from,
+			dataType: types.JSON,
+		},
+		Out: &ResponseMessage{
+			log: func(format string, v ...interface{}) {
+				x.endpoint.Printf(format, v...)
+			},
+			conn:  x.conn,
+			from:  from,
+			codec: x.endpoint.codec,
+		}}
+	for _, v := range x.endpoint.routers {
+		if x.matchesRouter(v, body, body, exchange) {
+			x.endpoint.DoProcess(context.Background(), v.router, exchange)
+		}
+	}
+}
+
 // matchesRouter 检查数据是否匹配指定的路由
 func (x *TcpHandler) matchesRouter(router *RegexpRouter, rawData, encodedData []byte, exchange *endpoint.Exchange) bool {
 	// 获取匹配选项
@@ -966,7 +1170,11 @@ func (x *UDPHandler) handler() {
 			from = addr.String()
 		}
 		// 编码处理
-		encodedMessage, dataType := x.endpoint.encode(msgBuffer)
+		encodedMessage, dataType, err := x.endpoint.decode(msgBuffer)
+		if err != nil {
+			x.endpoint.Printf("net endpoint decode err: %v", err)
+			continue
+		}
 
 		// 创建一个交换对象，用于存储输入和输出的消息
 		exchange := &endpoint.Exchange{
@@ -983,6 +1191,7 @@ func (x *UDPHandler) handler() {
 				conn:    x.endpoint.udpConn,
 				udpAddr: addr,
 				from:    from,
+				codec:   x.endpoint.codec,
 			}}
 
 		msg := exchange.In.GetMsg()
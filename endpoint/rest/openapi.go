@@ -0,0 +1,229 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package rest
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/yunboom/rulego/api/types/endpoint"
+	"github.com/yunboom/rulego/endpoint/impl"
+	"github.com/yunboom/rulego/utils/str"
+)
+
+const (
+	// OpenApiVersion is the OpenAPI specification version of the generated document.
+	// OpenApiVersion 生成文档使用的 OpenAPI 规范版本。
+	OpenApiVersion = "3.0.3"
+	// DefaultOpenApiTitle is the default title of the generated document.
+	// DefaultOpenApiTitle 生成文档的默认标题。
+	DefaultOpenApiTitle = "RuleGo REST API"
+	// DefaultOpenApiApiVersion is the default API version of the generated document.
+	// DefaultOpenApiApiVersion 生成文档的默认 API 版本。
+	DefaultOpenApiApiVersion = "1.0.0"
+)
+
+// pathParamRegex matches {name}, :name and *name path parameters.
+// pathParamRegex 匹配 {name}、:name 和 *name 形式的路径参数。
+var pathParamRegex = regexp.MustCompile(`\{([a-zA-Z_][a-zA-Z0-9_]*)\}|[:*]([a-zA-Z_][a-zA-Z0-9_]*)`)
+
+// OpenApiDocument is the OpenAPI 3 document generated from the registered routers.
+// OpenApiDocument 根据已注册路由生成的 OpenAPI 3 文档。
+type OpenApiDocument struct {
+	OpenApi string                                  `json:"openapi"`
+	Info    OpenApiInfo                             `json:"info"`
+	Paths   map[string]map[string]*OpenApiOperation `json:"paths"`
+}
+
+// OpenApiInfo is the metadata of the API.
+// OpenApiInfo API 元数据。
+type OpenApiInfo struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+// OpenApiOperation describes a single API operation on a path.
+// OpenApiOperation 描述路径上的单个 API 操作。
+type OpenApiOperation struct {
+	OperationId string                     `json:"operationId,omitempty"`
+	Summary     string                     `json:"summary,omitempty"`
+	Description string                     `json:"description,omitempty"`
+	Parameters  []OpenApiParameter         `json:"parameters,omitempty"`
+	RequestBody *OpenApiRequestBody        `json:"requestBody,omitempty"`
+	Responses   map[string]OpenApiResponse `json:"responses"`
+	// To is the router target, e.g. chain:default
+	// To 路由目标，例如 chain:default
+	To string `json:"x-rulego-to,omitempty"`
+}
+
+// OpenApiParameter describes a single operation parameter.
+// OpenApiParameter 描述单个操作参数。
+type OpenApiParameter struct {
+	Name     string                 `json:"name"`
+	In       string                 `json:"in"`
+	Required bool                   `json:"required"`
+	Schema   map[string]interface{} `json:"schema,omitempty"`
+}
+
+// OpenApiRequestBody describes the request body of an operation.
+// OpenApiRequestBody 描述操作的请求体。
+type OpenApiRequestBody struct {
+	Required bool                        `json:"required"`
+	Content  map[string]OpenApiMediaType `json:"content"`
+}
+
+// OpenApiResponse describes a single response of an operation.
+// OpenApiResponse 描述操作的单个响应。
+type OpenApiResponse struct {
+	Description string                      `json:"description"`
+	Content     map[string]OpenApiMediaType `json:"content,omitempty"`
+}
+
+// OpenApiMediaType holds the schema of a content type.
+// OpenApiMediaType 保存内容类型的 Schema。
+type OpenApiMediaType struct {
+	Schema interface{} `json:"schema,omitempty"`
+}
+
+// OpenApi generates the OpenAPI 3 document from the enabled routers of this endpoint.
+// Paths, path parameters, methods and targets come from the routers, request and response
+// schemas come from the RouterDsl.AdditionalInfo keys endpoint.RouterInfoKeyRequestSchema
+// and endpoint.RouterInfoKeyResponseSchema.
+//
+// OpenApi 根据该端点已启用的路由生成 OpenAPI 3 文档。
+// 路径、路径参数、方法和目标来自路由，请求和响应的 Schema 来自
+// RouterDsl.AdditionalInfo 中的 endpoint.RouterInfoKeyRequestSchema 和 endpoint.RouterInfoKeyResponseSchema。
+func (rest *Rest) OpenApi() *OpenApiDocument {
+	doc := &OpenApiDocument{
+		OpenApi: OpenApiVersion,
+		Info: OpenApiInfo{
+			Title:   rest.Config.OpenApiTitle,
+			Version: rest.Config.OpenApiVersion,
+		},
+		Paths: make(map[string]map[string]*OpenApiOperation),
+	}
+	if doc.Info.Title == "" {
+		doc.Info.Title = DefaultOpenApiTitle
+	}
+	if doc.Info.Version == "" {
+		doc.Info.Version = DefaultOpenApiApiVersion
+	}
+	rest.RLock()
+	defer rest.RUnlock()
+	for _, router := range rest.RouterStorage {
+		if router.IsDisable() || len(router.GetParams()) == 0 {
+			continue
+		}
+		method := strings.ToLower(str.ToString(router.GetParams()[0]))
+		path, op := newOpenApiOperation(method, router)
+		if _, ok := doc.Paths[path]; !ok {
+			doc.Paths[path] = make(map[string]*OpenApiOperation)
+		}
+		doc.Paths[path][method] = op
+	}
+	return doc
+}
+
+// openApiHandler serves the OpenAPI document
+func (rest *Rest) openApiHandler(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	b, err := json.Marshal(rest.OpenApi())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set(ContentTypeKey, JsonContextType)
+	if rest.Config.AllowCors {
+		w.Header().Set(HeaderKeyAccessControlAllowOrigin, HeaderValueAll)
+	}
+	_, _ = w.Write(b)
+}
+
+// newOpenApiOperation converts the router to the OpenAPI path and operation
+func newOpenApiOperation(method string, router endpoint.Router) (string, *OpenApiOperation) {
+	op := &OpenApiOperation{
+		OperationId: router.GetId(),
+		Responses:   map[string]OpenApiResponse{},
+	}
+	path := pathParamRegex.ReplaceAllStringFunc(strings.TrimSpace(router.FromToString()), func(s string) string {
+		name := pathParamName(s)
+		op.Parameters = append(op.Parameters, OpenApiParameter{
+			Name:     name,
+			In:       "path",
+			Required: true,
+			Schema:   map[string]interface{}{"type": "string"},
+		})
+		return "{" + name + "}"
+	})
+	if from := router.GetFrom(); from != nil && from.GetTo() != nil {
+		if to, ok := from.GetTo().(*impl.To); ok {
+			op.To = to.To
+		} else {
+			op.To = from.GetTo().ToString()
+		}
+	}
+	okResponse := OpenApiResponse{Description: http.StatusText(http.StatusOK)}
+	if def := router.Definition(); def != nil && def.AdditionalInfo != nil {
+		op.Summary = str.ToString(def.AdditionalInfo[endpoint.RouterInfoKeySummary])
+		op.Description = str.ToString(def.AdditionalInfo[endpoint.RouterInfoKeyDescription])
+		requestSchema := openApiSchema(def.AdditionalInfo[endpoint.RouterInfoKeyRequestSchema])
+		if file, ok := def.AdditionalInfo[endpoint.RouterInfoKeyRequestSchemaFile].(string); requestSchema == nil && ok && file != "" {
+			if b, err := os.ReadFile(file); err == nil {
+				requestSchema = openApiSchema(b)
+			}
+		}
+		if v := requestSchema; v != nil &&
+			method != strings.ToLower(http.MethodGet) && method != strings.ToLower(http.MethodHead) {
+			op.RequestBody = &OpenApiRequestBody{
+				Required: true,
+				Content:  map[string]OpenApiMediaType{JsonContextType: {Schema: v}},
+			}
+		}
+		if v := openApiSchema(def.AdditionalInfo[endpoint.RouterInfoKeyResponseSchema]); v != nil {
+			okResponse.Content = map[string]OpenApiMediaType{JsonContextType: {Schema: v}}
+		}
+	}
+	op.Responses["200"] = okResponse
+	return path, op
+}
+
+// pathParamName returns the parameter name of {name}, :name or *name
+func pathParamName(s string) string {
+	if strings.HasPrefix(s, "{") {
+		return s[1 : len(s)-1]
+	}
+	return s[1:]
+}
+
+// openApiSchema returns the schema object, JSON string schema is decoded
+func openApiSchema(v interface{}) interface{} {
+	switch value := v.(type) {
+	case string:
+		var schema map[string]interface{}
+		if err := json.Unmarshal([]byte(value), &schema); err != nil {
+			return nil
+		}
+		return schema
+	case []byte:
+		return openApiSchema(string(value))
+	default:
+		return v
+	}
+}
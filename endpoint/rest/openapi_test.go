@@ -0,0 +1,114 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package rest
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/yunboom/rulego/api/types"
+	"github.com/yunboom/rulego/api/types/endpoint"
+	"github.com/yunboom/rulego/endpoint/impl"
+	"github.com/yunboom/rulego/engine"
+	"github.com/yunboom/rulego/test/assert"
+	"github.com/yunboom/rulego/utils/schema"
+)
+
+var testOpenApiServer = ":9097"
+
+func TestOpenApi(t *testing.T) {
+	config := engine.NewConfig(types.WithDefaultPool())
+	ep := &Rest{}
+	err := ep.Init(config, types.Configuration{
+		"server":       testOpenApiServer,
+		"openApiPath":  "/openapi.json",
+		"openApiTitle": "device api",
+	})
+	assert.Nil(t, err)
+	defer ep.Destroy()
+
+	requestSchema := map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"temperature": map[string]interface{}{"type": "integer"},
+		},
+		"required": []interface{}{"temperature"},
+	}
+	def := &types.RouterDsl{
+		AdditionalInfo: map[string]interface{}{
+			endpoint.RouterInfoKeySummary:        "report telemetry",
+			endpoint.RouterInfoKeyRequestSchema:  requestSchema,
+			endpoint.RouterInfoKeyResponseSchema: `{"type":"object"}`,
+		},
+	}
+	router := impl.NewRouter(endpoint.RouterOptions.WithDefinition(def)).SetId("report").
+		From("/api/v1/device/{deviceId}/telemetry").To("chain:telemetry").End()
+	_, err = ep.AddRouter(router, "POST")
+	assert.Nil(t, err)
+	_, err = ep.AddRouter(impl.NewRouter().From("/api/v1/device/:deviceId").To("chain:device").End(), "GET")
+	assert.Nil(t, err)
+	disabled := impl.NewRouter().SetId("disabled").From("/api/v1/disabled").End()
+	_, err = ep.AddRouter(disabled, "GET")
+	assert.Nil(t, err)
+	assert.Nil(t, ep.RemoveRouter("disabled"))
+
+	doc := ep.OpenApi()
+	assert.Equal(t, OpenApiVersion, doc.OpenApi)
+	assert.Equal(t, "device api", doc.Info.Title)
+	assert.Equal(t, DefaultOpenApiApiVersion, doc.Info.Version)
+	assert.Equal(t, 2, len(doc.Paths))
+
+	post := doc.Paths["/api/v1/device/{deviceId}/telemetry"]["post"]
+	assert.NotNil(t, post)
+	assert.Equal(t, "report", post.OperationId)
+	assert.Equal(t, "report telemetry", post.Summary)
+	assert.Equal(t, "chain:telemetry", post.To)
+	assert.Equal(t, 1, len(post.Parameters))
+	assert.Equal(t, "deviceId", post.Parameters[0].Name)
+	assert.Equal(t, "path", post.Parameters[0].In)
+	assert.NotNil(t, post.RequestBody)
+	assert.Equal(t, "object", post.Responses["200"].Content[JsonContextType].Schema.(map[string]interface{})["type"])
+
+	//请求体 Schema 可以用于校验
+	s, err := schema.Parse(post.RequestBody.Content[JsonContextType].Schema)
+	assert.Nil(t, err)
+	assert.Nil(t, s.Validate(map[string]interface{}{"temperature": float64(12)}))
+	assert.NotNil(t, s.Validate(map[string]interface{}{}))
+
+	get := doc.Paths["/api/v1/device/{deviceId}"]["get"]
+	assert.NotNil(t, get)
+	assert.Equal(t, "chain:device", get.To)
+	assert.Equal(t, "deviceId", get.Parameters[0].Name)
+	assert.Nil(t, get.RequestBody)
+
+	assert.Nil(t, ep.Start())
+	time.Sleep(time.Millisecond * 200)
+
+	resp, err := http.Get("http://127.0.0.1" + testOpenApiServer + "/openapi.json")
+	assert.Nil(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, JsonContextType, resp.Header.Get(ContentTypeKey))
+	b, _ := io.ReadAll(resp.Body)
+	var served OpenApiDocument
+	assert.Nil(t, json.Unmarshal(b, &served))
+	assert.Equal(t, 2, len(served.Paths))
+	assert.Equal(t, "chain:telemetry", served.Paths["/api/v1/device/{deviceId}/telemetry"]["post"].To)
+}
@@ -32,6 +32,7 @@
 // • SSL/TLS Support: HTTPS server with certificate configuration  SSL/TLS 支持：带证书配置的 HTTPS 服务器
 // • Static File Serving: Built-in static file serving capabilities  静态文件服务：内置静态文件服务功能
 // • Shared Server: Multiple endpoint instances can share the same server  共享服务器：多个端点实例可以共享同一服务器
+// • OpenAPI Document: OpenAPI 3 document generated from the routers, served at Config.OpenApiPath  OpenAPI 文档：根据路由生成 OpenAPI 3 文档，通过 Config.OpenApiPath 提供
 //
 // Architecture / 架构：
 //
@@ -149,10 +150,12 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"math"
 	"net"
 	"net/http"
 	"net/textproto"
 	"regexp"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -179,6 +182,7 @@ const (
 	HeaderKeyAccessControlAllowHeaders  = "Access-Control-Allow-Headers"
 	HeaderKeyAccessControlAllowOrigin   = "Access-Control-Allow-Origin"
 	HeaderValueAll                      = "*"
+	HeaderKeyRetryAfter                 = "Retry-After"
 )
 
 // Type defines the component type identifier for the REST endpoint.
@@ -707,6 +711,20 @@ type Config struct {
 	// 当为 true 时，每个请求使用新连接，这可能影响性能，
 	// 但对于某些部署场景或调试可能有用。
 	DisableKeepalive bool `json:"disableKeepalive"`
+
+	// OpenApiPath specifies the path serving the OpenAPI 3 document generated from the registered routers.
+	// Empty disables the document. Example: "/openapi.json"
+	// OpenApiPath 指定提供 OpenAPI 3 文档的路径，文档根据已注册的路由生成。
+	// 为空则不提供文档。示例："/openapi.json"
+	OpenApiPath string `json:"openApiPath"`
+
+	// OpenApiTitle specifies the title of the OpenAPI document.
+	// OpenApiTitle 指定 OpenAPI 文档的标题。
+	OpenApiTitle string `json:"openApiTitle"`
+
+	// OpenApiVersion specifies the API version of the OpenAPI document.
+	// OpenApiVersion 指定 OpenAPI 文档的 API 版本。
+	OpenApiVersion string `json:"openApiVersion"`
 }
 
 // Rest represents an HTTP/REST endpoint implementation for the RuleGo framework.
@@ -1214,6 +1232,18 @@ func (rest *Rest) handler(router endpoint.Router, isWait bool) httprouter.Handle
 			ctx = context.Background()
 		}
 		rest.DoProcess(ctx, router, exchange)
+		if err, ok := endpoint.GetRateLimitError(exchange); ok {
+			rest.writeRateLimited(w, exchange, err)
+		}
+	}
+}
+
+// writeRateLimited 把限流拒绝转换成 HTTP 429 响应  Map the rate limit rejection to HTTP 429 response
+func (rest *Rest) writeRateLimited(w http.ResponseWriter, exchange *endpoint.Exchange, err *endpoint.RateLimitError) {
+	w.Header().Set(HeaderKeyRetryAfter, strconv.Itoa(int(math.Ceil(err.RetryAfter.Seconds()))))
+	http.Error(w, err.Error(), http.StatusTooManyRequests)
+	if rest.OnEvent != nil {
+		rest.OnEvent(endpoint.EventRateLimited, exchange, err)
 	}
 }
 
@@ -1269,6 +1299,9 @@ func (rest *Rest) newRouter() *httprouter.Router {
 		}
 		rest.Interceptors = append(rest.Interceptors, corsInterceptor)
 	}
+	if rest.Config.OpenApiPath != "" {
+		rest.router.GET(rest.Config.OpenApiPath, rest.openApiHandler)
+	}
 	return rest.router
 }
 
@@ -0,0 +1,122 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package websocket
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/yunboom/rulego/api/types"
+	"github.com/yunboom/rulego/api/types/endpoint"
+	"github.com/yunboom/rulego/endpoint/impl"
+	"github.com/yunboom/rulego/engine"
+	"github.com/yunboom/rulego/test/assert"
+)
+
+// 测试连接注册表和服务端推送
+func TestConnectionRegistry(t *testing.T) {
+	config := engine.NewConfig(types.WithDefaultPool())
+	ep := &Websocket{}
+	err := ep.Init(config, types.Configuration{
+		"server":           ":9099",
+		"allowCors":        true,
+		"connectionEvents": true,
+	})
+	assert.Nil(t, err)
+	defer ep.Destroy()
+
+	var lock sync.Mutex
+	var msgTypes []string
+	router := impl.NewRouter().From("/ws/:deviceId").Process(func(router endpoint.Router, exchange *endpoint.Exchange) bool {
+		msg := exchange.In.GetMsg()
+		lock.Lock()
+		msgTypes = append(msgTypes, msg.Type)
+		lock.Unlock()
+		if msg.Type == endpoint.MsgTypeConnect {
+			assert.Equal(t, "dev01", msg.Metadata.GetValue("deviceId"))
+			exchange.Out.SetBody([]byte("welcome"))
+		}
+		return false
+	}).End()
+	_, err = ep.AddRouter(router)
+	assert.Nil(t, err)
+	assert.Nil(t, ep.Start())
+	time.Sleep(time.Millisecond * 200)
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws://127.0.0.1:9099/ws/dev01", nil)
+	assert.Nil(t, err)
+	remoteAddr := conn.LocalAddr().String()
+	_, data, err := conn.ReadMessage()
+	assert.Nil(t, err)
+	assert.Equal(t, "welcome", string(data))
+
+	//服务端推送
+	msg := types.NewMsg(0, "COMMAND", types.BINARY, types.NewMetadata(), "")
+	msg.SetBytes([]byte{0x01, 0x02})
+	assert.Nil(t, endpoint.Connections.Send(remoteAddr, msg))
+	mt, data, err := conn.ReadMessage()
+	assert.Nil(t, err)
+	assert.Equal(t, websocket.BinaryMessage, mt)
+	assert.Equal(t, []byte{0x01, 0x02}, data)
+
+	_ = conn.Close()
+	time.Sleep(time.Millisecond * 200)
+	_, ok := endpoint.Connections.Get(remoteAddr)
+	assert.False(t, ok)
+	lock.Lock()
+	defer lock.Unlock()
+	assert.Equal(t, []string{endpoint.MsgTypeConnect, endpoint.MsgTypeDisconnect}, msgTypes)
+}
+
+// 测试通过握手消息获取客户端ID
+func TestConnectionHandshake(t *testing.T) {
+	config := engine.NewConfig(types.WithDefaultPool())
+	ep := &Websocket{}
+	err := ep.Init(config, types.Configuration{
+		"server":        ":9099",
+		"allowCors":     true,
+		"clientIdField": "clientId",
+	})
+	assert.Nil(t, err)
+	defer ep.Destroy()
+
+	router := impl.NewRouter().From("/ws").Process(func(router endpoint.Router, exchange *endpoint.Exchange) bool {
+		msg := exchange.In.GetMsg()
+		exchange.Out.SetBody([]byte(msg.Metadata.GetValue(endpoint.MetadataKeyConnectionId)))
+		return false
+	}).End()
+	_, err = ep.AddRouter(router)
+	assert.Nil(t, err)
+	assert.Nil(t, ep.Start())
+	time.Sleep(time.Millisecond * 200)
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws://127.0.0.1:9099/ws", nil)
+	assert.Nil(t, err)
+	defer conn.Close()
+	assert.Nil(t, conn.WriteMessage(websocket.TextMessage, []byte(`{"clientId":"c1"}`)))
+	_, data, err := conn.ReadMessage()
+	assert.Nil(t, err)
+	assert.Equal(t, "c1", string(data))
+
+	assert.Nil(t, endpoint.Connections.Send("c1", types.NewMsg(0, "COMMAND", types.JSON, types.NewMetadata(), `{"cmd":"on"}`)))
+	mt, data, err := conn.ReadMessage()
+	assert.Nil(t, err)
+	assert.Equal(t, websocket.TextMessage, mt)
+	assert.Equal(t, `{"cmd":"on"}`, string(data))
+}
@@ -32,6 +32,7 @@
 package websocket
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
@@ -39,6 +40,7 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/gorilla/websocket"
 	"github.com/julienschmidt/httprouter"
@@ -53,6 +55,9 @@ import (
 // Type 组件类型
 const Type = types.EndpointTypePrefix + "ws"
 
+// RateLimitCloseCode 请求被限流拒绝时关闭连接使用的关闭码  Close code used when a request is rejected by rate limit
+const RateLimitCloseCode = websocket.CloseTryAgainLater
+
 // Endpoint 别名
 type Endpoint = Websocket
 
@@ -141,11 +146,13 @@ type ResponseMessage struct {
 	log         func(format string, v ...interface{})
 	request     *http.Request
 	conn        *websocket.Conn
-	body        []byte
-	to          string
-	msg         *types.RuleMsg
-	err         error
-	locker      sync.RWMutex
+	// connection 注册的连接，与推送消息共用写锁
+	connection *Connection
+	body       []byte
+	to         string
+	msg        *types.RuleMsg
+	err        error
+	locker     sync.RWMutex
 }
 
 func (r *ResponseMessage) Body() []byte {
@@ -202,8 +209,14 @@ func (r *ResponseMessage) SetBody(body []byte) {
 			r.messageType = websocket.TextMessage
 		}
 
-		if err := r.conn.WriteMessage(r.messageType, body); err != nil {
-			r.SetError(err)
+		var err error
+		if r.connection != nil {
+			err = r.connection.write(r.messageType, body)
+		} else {
+			err = r.conn.WriteMessage(r.messageType, body)
+		}
+		if err != nil {
+			r.err = err
 		}
 	}
 }
@@ -220,15 +233,73 @@ func (r *ResponseMessage) GetError() error {
 	return r.err
 }
 
+// Connection 在线的 websocket 客户端连接，注册到 endpoint.Connections 后可以通过连接ID向其推送消息
+type Connection struct {
+	id         string
+	remoteAddr string
+	conn       *websocket.Conn
+	// 写锁，websocket 连接不支持并发写
+	writeLock sync.Mutex
+	lock      sync.RWMutex
+}
+
+// Id 连接ID，远程地址或者握手消息中的客户端ID
+func (c *Connection) Id() string {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.id
+}
+
+// RemoteAddr 客户端远程地址
+func (c *Connection) RemoteAddr() string {
+	return c.remoteAddr
+}
+
+// Send 向客户端写入消息，BINARY 类型的消息以二进制帧发送，其他类型以文本帧发送
+func (c *Connection) Send(msg types.RuleMsg) error {
+	if msg.GetDataType() == types.BINARY {
+		return c.write(websocket.BinaryMessage, msg.GetBytes())
+	}
+	return c.write(websocket.TextMessage, []byte(msg.GetData()))
+}
+
+// Close 关闭连接
+func (c *Connection) Close() error {
+	return c.conn.Close()
+}
+
+func (c *Connection) write(messageType int, data []byte) error {
+	c.writeLock.Lock()
+	defer c.writeLock.Unlock()
+	return c.conn.WriteMessage(messageType, data)
+}
+
+func (c *Connection) setId(id string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.id = id
+}
+
 // Config Websocket 服务配置
 type Config = rest.Config
 
+// Options websocket 连接相关配置，和 Config 从同一个 configuration 中解析
+type Options struct {
+	// ClientIdField 握手消息中客户端ID的字段，支持嵌套字段如 device.id。
+	// 连接的第一条包含该字段的 JSON 消息以客户端ID把连接注册到 endpoint.Connections
+	ClientIdField string `json:"clientIdField"`
+	// ConnectionEvents 把连接建立和断开作为 CONNECT/DISCONNECT 消息路由到连接所在的路由
+	ConnectionEvents bool `json:"connectionEvents"`
+}
+
 // Websocket 接收端端点
 type Websocket struct {
 	*rest.Rest
 	//配置
 	Config   Config
 	Upgrader websocket.Upgrader
+	//连接相关配置
+	Options Options
 }
 
 // Type 组件类型
@@ -251,6 +322,9 @@ func (ws *Websocket) Init(ruleConfig types.Config, configuration types.Configura
 	if err != nil {
 		return err
 	}
+	if err = maps.Map2Struct(configuration, &ws.Options); err != nil {
+		return err
+	}
 	ws.Upgrader.CheckOrigin = func(r *http.Request) bool {
 		return ws.Config.AllowCors // 允许所有跨域请求
 	}
@@ -346,6 +420,8 @@ func (ws *Websocket) handler(router endpoint.Router) httprouter.Handle {
 			ws.Printf("Websocket handler upgrade:", err)
 			return
 		}
+		connection := &Connection{id: r.RemoteAddr, remoteAddr: r.RemoteAddr, conn: c}
+		endpoint.Connections.Register(connection)
 		connectExchange := &endpoint.Exchange{
 			In: &RequestMessage{
 				request: r,
@@ -356,12 +432,15 @@ func (ws *Websocket) handler(router endpoint.Router) httprouter.Handle {
 				log: func(format string, v ...interface{}) {
 					ws.Printf(format, v...)
 				},
-				request: r,
-				conn:    c,
+				request:    r,
+				conn:       c,
+				connection: connection,
 			}}
 		if ws.OnEvent != nil {
 			ws.OnEvent(endpoint.EventConnect, connectExchange)
 		}
+		//是否已经发送连接消息
+		var connected, identified bool
 		defer func() {
 			_ = c.Close()
 			//捕捉异常
@@ -371,7 +450,15 @@ func (ws *Websocket) handler(router endpoint.Router) httprouter.Handle {
 				}
 				ws.Printf("ws endpoint handler err :\n%v", runtime.Stack())
 			}
+			endpoint.Connections.Unregister(connection)
+			if connected {
+				ws.onConnectionMsg(router, r, params, connection, endpoint.MsgTypeDisconnect)
+			}
 		}()
+		if ws.Options.ClientIdField == "" {
+			connected = true
+			ws.onConnectionMsg(router, r, params, connection, endpoint.MsgTypeConnect)
+		}
 
 		for {
 			mt, message, err := c.ReadMessage()
@@ -406,10 +493,24 @@ func (ws *Websocket) handler(router endpoint.Router) httprouter.Handle {
 					},
 					request:     r,
 					conn:        c,
+					connection:  connection,
 					messageType: mt,
 				}}
 
+			//从握手消息中获取客户端ID
+			if ws.Options.ClientIdField != "" && !identified {
+				if clientId := handshakeClientId(message, ws.Options.ClientIdField); clientId != "" {
+					endpoint.Connections.Unregister(connection)
+					connection.setId(clientId)
+					endpoint.Connections.Register(connection)
+					identified = true
+					connected = true
+					ws.onConnectionMsg(router, r, params, connection, endpoint.MsgTypeConnect)
+				}
+			}
+
 			msg := exchange.In.GetMsg()
+			msg.Metadata.PutValue(endpoint.MetadataKeyConnectionId, connection.Id())
 			//把路径参数放到msg元数据中
 			for _, param := range params {
 				msg.Metadata.PutValue(param.Key, param.Value)
@@ -427,6 +528,55 @@ func (ws *Websocket) handler(router endpoint.Router) httprouter.Handle {
 
 			}
 			ws.DoProcess(r.Context(), router, exchange)
+			//限流拒绝，使用关闭码关闭连接
+			if err, ok := endpoint.GetRateLimitError(exchange); ok {
+				if ws.OnEvent != nil {
+					ws.OnEvent(endpoint.EventRateLimited, exchange, err)
+				}
+				_ = c.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(RateLimitCloseCode, err.Err.Error()), time.Now().Add(time.Second))
+				if ws.OnEvent != nil {
+					ws.OnEvent(endpoint.EventDisconnect, connectExchange, w, r, params)
+				}
+				break
+			}
 		}
 	}
 }
+
+// onConnectionMsg 把连接建立或者断开作为消息路由到连接所在的路由
+func (ws *Websocket) onConnectionMsg(router endpoint.Router, r *http.Request, params httprouter.Params, connection *Connection, msgType string) {
+	if !ws.Options.ConnectionEvents {
+		return
+	}
+	msg := endpoint.NewConnectionMsg(msgType, connection)
+	for _, param := range params {
+		msg.Metadata.PutValue(param.Key, param.Value)
+	}
+	exchange := &endpoint.Exchange{
+		In: &RequestMessage{
+			request:     r,
+			Params:      params,
+			body:        []byte(msg.GetData()),
+			messageType: websocket.TextMessage,
+			msg:         &msg,
+		},
+		Out: &ResponseMessage{
+			log: func(format string, v ...interface{}) {
+				ws.Printf(format, v...)
+			},
+			request:     r,
+			conn:        connection.conn,
+			connection:  connection,
+			messageType: websocket.TextMessage,
+		}}
+	ws.DoProcess(r.Context(), router, exchange)
+}
+
+// handshakeClientId 从握手消息中获取客户端ID
+func handshakeClientId(message []byte, field string) string {
+	var data map[string]interface{}
+	if err := json.Unmarshal(message, &data); err != nil {
+		return ""
+	}
+	return str.ToString(maps.Get(data, field))
+}
@@ -303,3 +303,19 @@ func newWebsocketServe(t *testing.T, restEndpoint *rest.Rest) endpoint.Endpoint
 	assert.NotNil(t, wsEndpoint.Router())
 	return wsEndpoint
 }
+
+// 测试从同一个 configuration 中解析 http 服务配置和连接相关配置
+func TestConfig(t *testing.T) {
+	ws := &Websocket{}
+	err := ws.Init(types.NewConfig(), types.Configuration{
+		"server":           testServer,
+		"allowCors":        true,
+		"clientIdField":    "device.id",
+		"connectionEvents": true,
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, testServer, ws.Config.Server)
+	assert.True(t, ws.Config.AllowCors)
+	assert.Equal(t, "device.id", ws.Options.ClientIdField)
+	assert.True(t, ws.Options.ConnectionEvents)
+}
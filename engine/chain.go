@@ -169,6 +169,10 @@ type RuleChainCtx struct {
 	// decryptSecrets 包含节点可访问的解密秘密值，为敏感配置数据提供安全访问
 	decryptSecrets map[string]string
 
+	// exprTypes contains the type hints of the expr expressions of the nodes
+	// exprTypes 包含节点expr表达式的类型提示
+	exprTypes map[string]string
+
 	// isEmpty indicates whether the rule chain has no nodes,
 	// used for optimization and error handling in empty chains
 	// isEmpty 指示规则链是否没有节点，用于空链的优化和错误处理
@@ -253,6 +257,9 @@ func InitRuleChainCtx(config types.Config, aspects types.AspectList, ruleChainDe
 		envConfig := ruleChainDef.RuleChain.Configuration[types.Secrets]
 		secrets := str.ToStringMapString(envConfig)
 		ruleChainCtx.decryptSecrets = decryptSecret(secrets, []byte(config.SecretKey))
+		if exprTypes, ok := ruleChainDef.RuleChain.Configuration[types.ExprTypes]; ok {
+			ruleChainCtx.exprTypes = str.ToStringMapString(exprTypes)
+		}
 	}
 	nodeLen := len(ruleChainDef.Metadata.Nodes)
 	ruleChainCtx.nodeIds = make([]types.RuleNodeId, nodeLen)
@@ -733,6 +740,7 @@ func (rc *RuleChainCtx) copyUnsafe(newCtx *RuleChainCtx) {
 	rc.destroyAspects = newCtx.destroyAspects
 	rc.vars = newCtx.vars
 	rc.decryptSecrets = newCtx.decryptSecrets
+	rc.exprTypes = newCtx.exprTypes
 	// Clear cache
 	rc.relationCache = make(map[RelationCache][]types.NodeCtx)
 }
@@ -786,6 +794,7 @@ func (rc *RuleChainCtx) Copy(newCtx *RuleChainCtx) {
 	rc.destroyAspects = newCtx.destroyAspects
 	rc.vars = newCtx.vars
 	rc.decryptSecrets = newCtx.decryptSecrets
+	rc.exprTypes = newCtx.exprTypes
 	// Clear cache
 	rc.relationCache = make(map[RelationCache][]types.NodeCtx)
 }
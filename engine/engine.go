@@ -939,8 +939,9 @@ func (e *RuleEngine) forceStop() {
 		}()
 	}
 
-	// 清理实例缓存
-	if e.Config.Cache != nil && e.rootRuleChainCtx != nil {
+	// 清理实例缓存，持久化缓存保留规则链缓存，重启后继续使用
+	persistent, ok := e.Config.Cache.(types.PersistentCache)
+	if e.Config.Cache != nil && e.rootRuleChainCtx != nil && !(ok && persistent.Persistent()) {
 		func() {
 			defer func() {
 				if r := recover(); r != nil {
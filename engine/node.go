@@ -378,11 +378,14 @@ func processVariables(config types.Config, chainCtx *RuleChainCtx, configuration
 		globalEnv = config.Properties.Values()
 	}
 
-	var varsEnv, decryptSecrets map[string]string
+	var varsEnv, decryptSecrets, exprTypes map[string]string
 
 	if chainCtx != nil {
 		varsEnv = copyMap(chainCtx.vars)
 		decryptSecrets = copyMap(chainCtx.decryptSecrets)
+		if chainCtx.exprTypes != nil {
+			exprTypes = copyMap(chainCtx.exprTypes)
+		}
 	}
 
 	env := map[string]interface{}{
@@ -404,6 +407,9 @@ func processVariables(config types.Config, chainCtx *RuleChainCtx, configuration
 	if decryptSecrets != nil {
 		result[types.Secrets] = decryptSecrets
 	}
+	if exprTypes != nil {
+		result[types.ExprTypes] = exprTypes
+	}
 
 	return result, nil
 }
@@ -123,6 +123,25 @@ func TestNodeCtx(t *testing.T) {
 
 	})
 
+	t.Run("ExprTypes", func(t *testing.T) {
+		def, _ := (&JsonParser{}).DecodeRuleChain([]byte(`{
+          "ruleChain": {
+            "id": "test02",
+            "configuration": {
+              "exprTypes": {"msg.temperature": "float"}
+            }
+          },
+          "metadata": {
+            "nodes": [{"id": "s1", "type": "exprFilter", "configuration": {"expr": "msg.temperature > 'a'"}}]
+          }
+        }`))
+		_, err := InitRuleChainCtx(NewConfig(), nil, &def, nil)
+		assert.NotNil(t, err)
+
+		def.Metadata.Nodes[0].Configuration["expr"] = "msg.temperature > 10"
+		_, err = InitRuleChainCtx(NewConfig(), nil, &def, nil)
+		assert.Nil(t, err)
+	})
 }
 
 // TestNodeConcurrentAccess 测试节点并发访问和重载的安全性
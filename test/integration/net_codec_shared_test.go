@@ -0,0 +1,105 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package integration
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/yunboom/rulego/api/types"
+	"github.com/yunboom/rulego/api/types/endpoint"
+	"github.com/yunboom/rulego/components/external"
+	"github.com/yunboom/rulego/endpoint/impl"
+	"github.com/yunboom/rulego/endpoint/net"
+	"github.com/yunboom/rulego/engine"
+	"github.com/yunboom/rulego/node_pool"
+	"github.com/yunboom/rulego/test"
+	"github.com/yunboom/rulego/test/assert"
+)
+
+// 测试多个 net 节点通过 ref:// 共享同一个连接时，请求和响应一一对应
+func TestNetCodecSharedConnection(t *testing.T) {
+	config := engine.NewConfig(types.WithDefaultPool())
+	ep := &net.Net{}
+	assert.Nil(t, ep.Init(config, types.Configuration{"server": ":8902", "readTimeout": 5, "codec": "modbusTcp"}))
+	defer ep.Destroy()
+	//从站把事务ID作为寄存器值返回
+	router := impl.NewRouter().From("").Process(func(router endpoint.Router, exchange *endpoint.Exchange) bool {
+		var request map[string]interface{}
+		_ = json.Unmarshal([]byte(exchange.In.GetMsg().GetData()), &request)
+		request["values"] = []interface{}{request["transactionId"]}
+		reply, _ := json.Marshal(request)
+		exchange.Out.SetBody(reply)
+		return false
+	}).End()
+	_, err := ep.AddRouter(router)
+	assert.Nil(t, err)
+	assert.Nil(t, ep.Start())
+	time.Sleep(time.Millisecond * 200)
+
+	nodeConfig := types.Configuration{
+		"codec":        "modbusTcp",
+		"codecConfig":  types.Configuration{"role": "client"},
+		"readResponse": true,
+	}
+	pool := node_pool.NewNodePool(config)
+	sharedConfig := nodeConfig.Copy()
+	sharedConfig["server"] = "127.0.0.1:8902"
+	_, err = pool.NewFromRuleNode(types.RuleNode{Id: "modbusConn", Type: "net", Configuration: sharedConfig})
+	assert.Nil(t, err)
+	defer pool.Stop()
+	config.NodePool = pool
+
+	var nodes []*external.NetNode
+	for i := 0; i < 2; i++ {
+		node := &external.NetNode{}
+		refConfig := nodeConfig.Copy()
+		refConfig["server"] = "ref://modbusConn"
+		assert.Nil(t, node.Init(config, refConfig))
+		defer node.Destroy()
+		nodes = append(nodes, node)
+	}
+
+	var wg sync.WaitGroup
+	var lock sync.Mutex
+	mismatched := 0
+	for i := 1; i <= 40; i++ {
+		wg.Add(1)
+		transactionId := i
+		ctx := test.NewRuleContext(config, func(msg types.RuleMsg, relationType string, err error) {
+			defer wg.Done()
+			var response map[string]interface{}
+			_ = json.Unmarshal([]byte(msg.GetData()), &response)
+			values, _ := response["values"].([]interface{})
+			lock.Lock()
+			defer lock.Unlock()
+			if relationType != types.Success || len(values) != 1 || values[0] != float64(transactionId) {
+				mismatched++
+			}
+		})
+		go nodes[i%2].OnMsg(ctx, types.NewMsg(0, "READ", types.JSON, types.NewMetadata(),
+			fmt.Sprintf(`{"transactionId":%d,"unitId":1,"functionCode":3,"address":0,"quantity":1}`, transactionId)))
+	}
+	wg.Wait()
+	assert.Equal(t, 0, mismatched)
+
+	//没有编解码器不能读取响应
+	assert.NotNil(t, (&external.NetNode{}).Init(config, types.Configuration{"server": "127.0.0.1:8902", "readResponse": true}))
+}
@@ -0,0 +1,250 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package test
+
+import (
+	"net"
+	"sync"
+	"testing"
+
+	"github.com/eclipse/paho.golang/packets"
+)
+
+// Mqtt5Broker 进程内的 MQTT 5 broker 替身，用于测试
+// 只支持精确匹配的主题订阅，转发给订阅者的消息统一使用 QoS 0
+type Mqtt5Broker struct {
+	listener net.Listener
+	lock     sync.Mutex
+	//客户端连接
+	conns map[*mqtt5Conn]struct{}
+	//订阅主题和连接映射
+	subscribers map[string]map[*mqtt5Conn]struct{}
+	//保留消息
+	retained map[string]*packets.Publish
+	//收到的发布消息
+	published []*packets.Publish
+	//收到发布消息时调用，返回不为nil时作为新的发布消息转发给订阅者
+	responder func(p *packets.Publish) *packets.Publish
+	//不回复 QoS>0 发布消息的确认
+	noAck bool
+}
+
+type mqtt5Conn struct {
+	net.Conn
+	lock sync.Mutex
+}
+
+func (c *mqtt5Conn) write(cp *packets.ControlPacket) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	_, _ = cp.WriteTo(c.Conn)
+}
+
+// NewMqtt5Broker 创建并启动 broker 替身，监听随机端口
+func NewMqtt5Broker(t *testing.T) *Mqtt5Broker {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := &Mqtt5Broker{
+		listener:    listener,
+		conns:       make(map[*mqtt5Conn]struct{}),
+		subscribers: make(map[string]map[*mqtt5Conn]struct{}),
+		retained:    make(map[string]*packets.Publish),
+	}
+	go b.serve()
+	return b
+}
+
+// Addr broker 地址
+func (b *Mqtt5Broker) Addr() string {
+	return b.listener.Addr().String()
+}
+
+// Close 关闭 broker
+func (b *Mqtt5Broker) Close() {
+	_ = b.listener.Close()
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	for conn := range b.conns {
+		_ = conn.Close()
+	}
+}
+
+// SetResponder 设置响应函数，收到发布消息时调用，返回不为nil时作为新的发布消息转发给订阅者
+func (b *Mqtt5Broker) SetResponder(responder func(p *packets.Publish) *packets.Publish) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.responder = responder
+}
+
+// SetNoAck 设置是否不回复 QoS>0 发布消息的确认，用于测试发布超时
+func (b *Mqtt5Broker) SetNoAck(noAck bool) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.noAck = noAck
+}
+
+// Published 返回收到的发布消息
+func (b *Mqtt5Broker) Published() []*packets.Publish {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return append([]*packets.Publish(nil), b.published...)
+}
+
+// Subscribed 主题是否有订阅者
+func (b *Mqtt5Broker) Subscribed(topic string) bool {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return len(b.subscribers[topic]) > 0
+}
+
+func (b *Mqtt5Broker) serve() {
+	for {
+		conn, err := b.listener.Accept()
+		if err != nil {
+			return
+		}
+		c := &mqtt5Conn{Conn: conn}
+		b.lock.Lock()
+		b.conns[c] = struct{}{}
+		b.lock.Unlock()
+		go b.handle(c)
+	}
+}
+
+func (b *Mqtt5Broker) handle(conn *mqtt5Conn) {
+	defer func() {
+		b.lock.Lock()
+		delete(b.conns, conn)
+		for _, conns := range b.subscribers {
+			delete(conns, conn)
+		}
+		b.lock.Unlock()
+		_ = conn.Close()
+	}()
+	for {
+		cp, err := packets.ReadPacket(conn)
+		if err != nil {
+			return
+		}
+		switch p := cp.Content.(type) {
+		case *packets.Connect:
+			conn.write(packets.NewControlPacket(packets.CONNACK))
+		case *packets.Publish:
+			b.lock.Lock()
+			noAck := b.noAck
+			b.lock.Unlock()
+			if noAck {
+				b.onPublish(p)
+				continue
+			}
+			switch p.QoS {
+			case 1:
+				ack := packets.NewControlPacket(packets.PUBACK)
+				ack.Content.(*packets.Puback).PacketID = p.PacketID
+				conn.write(ack)
+			case 2:
+				ack := packets.NewControlPacket(packets.PUBREC)
+				ack.Content.(*packets.Pubrec).PacketID = p.PacketID
+				conn.write(ack)
+			}
+			b.onPublish(p)
+		case *packets.Pubrel:
+			ack := packets.NewControlPacket(packets.PUBCOMP)
+			ack.Content.(*packets.Pubcomp).PacketID = p.PacketID
+			conn.write(ack)
+		case *packets.Subscribe:
+			ack := packets.NewControlPacket(packets.SUBACK)
+			suback := ack.Content.(*packets.Suback)
+			suback.PacketID = p.PacketID
+			var retained []*packets.Publish
+			b.lock.Lock()
+			for _, sub := range p.Subscriptions {
+				if b.subscribers[sub.Topic] == nil {
+					b.subscribers[sub.Topic] = make(map[*mqtt5Conn]struct{})
+				}
+				b.subscribers[sub.Topic][conn] = struct{}{}
+				suback.Reasons = append(suback.Reasons, sub.QoS)
+				if r, ok := b.retained[sub.Topic]; ok {
+					retained = append(retained, r)
+				}
+			}
+			b.lock.Unlock()
+			conn.write(ack)
+			for _, r := range retained {
+				conn.write(forward(r, true))
+			}
+		case *packets.Unsubscribe:
+			ack := packets.NewControlPacket(packets.UNSUBACK)
+			unsuback := ack.Content.(*packets.Unsuback)
+			unsuback.PacketID = p.PacketID
+			b.lock.Lock()
+			for _, topic := range p.Topics {
+				delete(b.subscribers[topic], conn)
+				unsuback.Reasons = append(unsuback.Reasons, 0)
+			}
+			b.lock.Unlock()
+			conn.write(ack)
+		case *packets.Pingreq:
+			conn.write(packets.NewControlPacket(packets.PINGRESP))
+		case *packets.Disconnect:
+			return
+		}
+	}
+}
+
+func (b *Mqtt5Broker) onPublish(p *packets.Publish) {
+	b.lock.Lock()
+	b.published = append(b.published, p)
+	if p.Retain {
+		b.retained[p.Topic] = p
+	}
+	responder := b.responder
+	b.lock.Unlock()
+	b.route(p)
+	if responder != nil {
+		if reply := responder(p); reply != nil {
+			b.route(reply)
+		}
+	}
+}
+
+// route 把消息转发给主题的订阅者
+func (b *Mqtt5Broker) route(p *packets.Publish) {
+	b.lock.Lock()
+	conns := make([]*mqtt5Conn, 0, len(b.subscribers[p.Topic]))
+	for conn := range b.subscribers[p.Topic] {
+		conns = append(conns, conn)
+	}
+	b.lock.Unlock()
+	for _, conn := range conns {
+		conn.write(forward(p, false))
+	}
+}
+
+func forward(p *packets.Publish, retain bool) *packets.ControlPacket {
+	cp := packets.NewControlPacket(packets.PUBLISH)
+	out := cp.Content.(*packets.Publish)
+	out.Topic = p.Topic
+	out.Payload = p.Payload
+	out.Retain = retain
+	if p.Properties != nil {
+		out.Properties = p.Properties
+	}
+	return cp
+}
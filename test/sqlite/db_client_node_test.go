@@ -0,0 +1,383 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/yunboom/rulego/api/types"
+	"github.com/yunboom/rulego/components/external"
+	"github.com/yunboom/rulego/test"
+	"github.com/yunboom/rulego/test/assert"
+	"github.com/yunboom/rulego/utils/json"
+	"github.com/yunboom/rulego/utils/str"
+	_ "modernc.org/sqlite"
+)
+
+func TestDbClientNodeTransaction(t *testing.T) {
+	dsn := filepath.Join(t.TempDir(), "test.db")
+	db, err := sql.Open("sqlite", dsn)
+	assert.Nil(t, err)
+	defer db.Close()
+	_, err = db.Exec(`create table orders(id integer primary key autoincrement, customer text, total real);
+		create table order_items(id integer primary key autoincrement, order_id integer not null, sku text, qty integer)`)
+	assert.Nil(t, err)
+
+	config := types.NewConfig()
+	newNode := func(statements []interface{}) (*external.DbClientNode, error) {
+		node := &external.DbClientNode{}
+		err := node.Init(config, types.Configuration{
+			"driverName": "sqlite",
+			"dsn":        dsn,
+			"poolSize":   1,
+			"statements": statements,
+		})
+		return node, err
+	}
+	onMsg := func(node *external.DbClientNode, data string) (types.RuleMsg, string, error) {
+		var outMsg types.RuleMsg
+		var relationType string
+		var outErr error
+		ctx := test.NewRuleContext(config, func(msg types.RuleMsg, rt string, err error) {
+			outMsg, relationType, outErr = msg, rt, err
+		})
+		node.OnMsg(ctx, types.NewMsg(0, "ORDER", types.JSON, types.NewMetadata(), data))
+		return outMsg, relationType, outErr
+	}
+	count := func(table string) int {
+		var n int
+		assert.Nil(t, db.QueryRow("select count(*) from "+table).Scan(&n))
+		return n
+	}
+
+	t.Run("InitError", func(t *testing.T) {
+		_, err := newNode([]interface{}{
+			map[string]interface{}{"name": "a", "sql": "select 1"},
+			map[string]interface{}{"name": "a", "sql": "select 2"},
+		})
+		assert.Equal(t, "duplicate statement name: a", err.Error())
+		_, err = newNode([]interface{}{
+			map[string]interface{}{"sql": "select 1"},
+			map[string]interface{}{"sql": "drop table orders"},
+		})
+		assert.Equal(t, "statement s2: unsupported sql statement: drop table orders", err.Error())
+		_, err = newNode([]interface{}{
+			map[string]interface{}{"sql": ""},
+		})
+		assert.Equal(t, "statement s1: sql can not empty", err.Error())
+	})
+
+	t.Run("Commit", func(t *testing.T) {
+		node, err := newNode([]interface{}{
+			map[string]interface{}{
+				"name":   "header",
+				"sql":    "insert into orders(customer, total) values (?, ?)",
+				"params": []interface{}{"${msg.customer}", "${msg.total}"},
+			},
+			map[string]interface{}{
+				"sql":    "insert into order_items(order_id, sku, qty) values (?, ?, ?)",
+				"params": []interface{}{"${results.header.lastInsertId}", "${msg.sku}", 2},
+			},
+			map[string]interface{}{
+				"name":   "order",
+				"sql":    "select id, customer from orders where id = ?",
+				"params": []interface{}{"${results.header.lastInsertId}"},
+				"getOne": true,
+			},
+			map[string]interface{}{
+				"name":   "audit",
+				"sql":    "update order_items set qty = qty + 1 where order_id = ? and sku = ?",
+				"params": []interface{}{"${results.order.id}", "${msg.sku}"},
+			},
+		})
+		assert.Nil(t, err)
+		defer node.Destroy()
+
+		msg, relationType, err := onMsg(node, `{"customer":"lala","total":12.5,"sku":"A1"}`)
+		assert.Nil(t, err)
+		assert.Equal(t, types.Success, relationType)
+		var result map[string]map[string]interface{}
+		assert.Nil(t, json.Unmarshal([]byte(msg.GetData()), &result))
+		assert.Equal(t, float64(1), result["header"]["lastInsertId"])
+		assert.Equal(t, float64(1), result["s2"]["rowsAffected"])
+		assert.Equal(t, "lala", result["order"]["customer"])
+		assert.Equal(t, float64(1), result["audit"]["rowsAffected"])
+
+		var qty int
+		assert.Nil(t, db.QueryRow("select qty from order_items where order_id = 1").Scan(&qty))
+		assert.Equal(t, 3, qty)
+	})
+
+	t.Run("Rollback", func(t *testing.T) {
+		orders, items := count("orders"), count("order_items")
+		node, err := newNode([]interface{}{
+			map[string]interface{}{
+				"name":   "header",
+				"sql":    "insert into orders(customer, total) values (?, ?)",
+				"params": []interface{}{"${msg.customer}", "${msg.total}"},
+			},
+			map[string]interface{}{
+				"name":   "items",
+				"sql":    "insert into order_items(order_id, sku, qty) values (?, ?, ?)",
+				"params": []interface{}{nil, "${msg.sku}", 1},
+			},
+		})
+		assert.Nil(t, err)
+		defer node.Destroy()
+
+		msg, relationType, err := onMsg(node, `{"customer":"lala","total":1,"sku":"B1"}`)
+		assert.Equal(t, types.Failure, relationType)
+		assert.True(t, strings.HasPrefix(err.Error(), "statement items failed: "))
+		assert.Equal(t, "items", msg.Metadata.GetValue("failedStatement"))
+		assert.Equal(t, orders, count("orders"))
+		assert.Equal(t, items, count("order_items"))
+	})
+}
+
+func TestDbClientNodeBulk(t *testing.T) {
+	dsn := filepath.Join(t.TempDir(), "test.db")
+	db, err := sql.Open("sqlite", dsn)
+	assert.Nil(t, err)
+	defer db.Close()
+	_, err = db.Exec(`create table telemetry(device_id text not null, ts integer not null, temp real, tags text, primary key(device_id, ts))`)
+	assert.Nil(t, err)
+
+	config := types.NewConfig()
+	columns := []interface{}{
+		map[string]interface{}{"column": "device_id", "field": "deviceId"},
+		map[string]interface{}{"column": "ts"},
+		map[string]interface{}{"column": "temp", "field": "values.temp"},
+		map[string]interface{}{"column": "tags"},
+	}
+	newNode := func(bulk map[string]interface{}) (*external.DbClientNode, error) {
+		node := &external.DbClientNode{}
+		err := node.Init(config, types.Configuration{
+			"driverName": "sqlite",
+			"dsn":        dsn,
+			"poolSize":   1,
+			"bulk":       bulk,
+		})
+		return node, err
+	}
+	onMsg := func(node *external.DbClientNode, data string) (types.RuleMsg, string, error) {
+		var outMsg types.RuleMsg
+		var relationType string
+		var outErr error
+		ctx := test.NewRuleContext(config, func(msg types.RuleMsg, rt string, err error) {
+			outMsg, relationType, outErr = msg, rt, err
+		})
+		node.OnMsg(ctx, types.NewMsg(0, "TELEMETRY", types.JSON, types.NewMetadata(), data))
+		return outMsg, relationType, outErr
+	}
+	count := func() int {
+		var n int
+		assert.Nil(t, db.QueryRow("select count(*) from telemetry").Scan(&n))
+		return n
+	}
+
+	t.Run("Insert", func(t *testing.T) {
+		node, err := newNode(map[string]interface{}{
+			"table":     "telemetry",
+			"columns":   columns,
+			"chunkSize": 2,
+		})
+		assert.Nil(t, err)
+		defer node.Destroy()
+
+		data := `[{"deviceId":"d1","ts":1,"values":{"temp":20.5},"tags":["a"]},
+			{"deviceId":"d1","ts":2,"values":{"temp":21}},
+			{"deviceId":"d2","ts":1,"values":{"temp":22}},
+			{"deviceId":"d2","ts":2,"values":{"temp":23}},
+			{"deviceId":"d3","ts":1,"values":{"temp":24}}]`
+		msg, relationType, err := onMsg(node, data)
+		assert.Nil(t, err)
+		assert.Equal(t, types.Success, relationType)
+		assert.Equal(t, "5", msg.Metadata.GetValue("rowsAffected"))
+		assert.Equal(t, "[2,2,1]", msg.Metadata.GetValue("chunkRowsAffected"))
+		assert.Equal(t, data, msg.GetData())
+		assert.Equal(t, 5, count())
+
+		var tags string
+		assert.Nil(t, db.QueryRow("select tags from telemetry where device_id = 'd1' and ts = 1").Scan(&tags))
+		assert.Equal(t, `["a"]`, tags)
+
+		//主键冲突，整批回滚
+		msg, relationType, err = onMsg(node, `[{"deviceId":"d4","ts":1},{"deviceId":"d4","ts":2},{"deviceId":"d1","ts":1}]`)
+		assert.Equal(t, types.Failure, relationType)
+		assert.True(t, strings.HasPrefix(err.Error(), "chunk 2 failed: "))
+		assert.Equal(t, "2", msg.Metadata.GetValue("failedChunk"))
+		assert.Equal(t, 5, count())
+
+		_, relationType, err = onMsg(node, `"a"`)
+		assert.Equal(t, types.Failure, relationType)
+		assert.Equal(t, "bulk data must be a JSON array of objects", err.Error())
+	})
+
+	t.Run("Upsert", func(t *testing.T) {
+		node, err := newNode(map[string]interface{}{
+			"table":         "telemetry",
+			"columns":       columns,
+			"conflictKeys":  []string{"device_id", "ts"},
+			"updateColumns": []string{"temp"},
+		})
+		assert.Nil(t, err)
+		defer node.Destroy()
+
+		msg, relationType, err := onMsg(node, `[{"deviceId":"d1","ts":1,"values":{"temp":30},"tags":["b"]},{"deviceId":"d5","ts":1,"values":{"temp":31}}]`)
+		assert.Nil(t, err)
+		assert.Equal(t, types.Success, relationType)
+		assert.Equal(t, "2", msg.Metadata.GetValue("rowsAffected"))
+		assert.Equal(t, 6, count())
+
+		var temp float64
+		var tags string
+		assert.Nil(t, db.QueryRow("select temp, tags from telemetry where device_id = 'd1' and ts = 1").Scan(&temp, &tags))
+		assert.Equal(t, 30.0, temp)
+		assert.Equal(t, `["a"]`, tags)
+	})
+}
+
+// busyPool 每隔一次拒绝提交的任务，模拟已满的协程池
+type busyPool struct {
+	sync.Mutex
+	submits  int
+	rejected int
+}
+
+func (p *busyPool) Submit(task func()) error {
+	p.Lock()
+	p.submits++
+	if p.submits%2 == 1 {
+		p.rejected++
+		p.Unlock()
+		return errors.New("pool is full")
+	}
+	p.Unlock()
+	task()
+	return nil
+}
+
+func (p *busyPool) Release() {
+}
+
+func TestDbClientNodeStream(t *testing.T) {
+	dsn := filepath.Join(t.TempDir(), "test.db")
+	db, err := sql.Open("sqlite", dsn)
+	assert.Nil(t, err)
+	defer db.Close()
+	_, err = db.Exec(`create table devices(id integer primary key, name text);
+		insert into devices(id, name) values (1, 'a'), (2, 'b'), (3, 'c'), (4, 'd'), (5, 'e')`)
+	assert.Nil(t, err)
+
+	newNode := func(config types.Config, sqlStr string, stream map[string]interface{}) (*external.DbClientNode, error) {
+		node := &external.DbClientNode{}
+		err := node.Init(config, types.Configuration{
+			"driverName": "sqlite",
+			"dsn":        dsn,
+			"poolSize":   1,
+			"sql":        sqlStr,
+			"stream":     stream,
+		})
+		return node, err
+	}
+	type result struct {
+		msg          types.RuleMsg
+		relationType string
+		err          error
+	}
+	onMsg := func(config types.Config, node *external.DbClientNode, c context.Context) []result {
+		var results []result
+		ctx := test.NewRuleContext(config, func(msg types.RuleMsg, relationType string, err error) {
+			results = append(results, result{msg: msg, relationType: relationType, err: err})
+		})
+		ctx.SetContext(c)
+		node.OnMsg(ctx, types.NewMsg(0, "EXPORT", types.JSON, types.NewMetadata(), "{}"))
+		return results
+	}
+
+	t.Run("InitError", func(t *testing.T) {
+		_, err := newNode(types.NewConfig(), "delete from devices", map[string]interface{}{"mode": external.StreamModeRow})
+		assert.Equal(t, "stream mode only supports select statement", err.Error())
+		_, err = newNode(types.NewConfig(), "select * from devices", map[string]interface{}{"mode": "batch"})
+		assert.Equal(t, "unsupported stream mode: batch", err.Error())
+	})
+
+	t.Run("Row", func(t *testing.T) {
+		config := types.NewConfig()
+		node, err := newNode(config, "select id, name from devices order by id", map[string]interface{}{"mode": external.StreamModeRow})
+		assert.Nil(t, err)
+		defer node.Destroy()
+
+		results := onMsg(config, node, context.Background())
+		assert.Equal(t, 6, len(results))
+		for i, item := range results[:5] {
+			assert.Equal(t, types.Success, item.relationType)
+			assert.Equal(t, str.ToString(i), item.msg.Metadata.GetValue("rowIndex"))
+			assert.Equal(t, fmt.Sprintf(`{"id":%d,"name":"%c"}`, i+1, 'a'+i), item.msg.GetData())
+		}
+		last := results[5]
+		assert.Equal(t, types.Success, last.relationType)
+		assert.Equal(t, "true", last.msg.Metadata.GetValue("streamCompleted"))
+		assert.Equal(t, "5", last.msg.Metadata.GetValue("rowCount"))
+		assert.Equal(t, "{}", last.msg.GetData())
+	})
+
+	t.Run("Page", func(t *testing.T) {
+		pool := &busyPool{}
+		config := types.NewConfig(types.WithPool(pool))
+		node, err := newNode(config, "select id from devices order by id", map[string]interface{}{
+			"mode":     external.StreamModePage,
+			"pageSize": 2,
+		})
+		assert.Nil(t, err)
+		defer node.Destroy()
+
+		results := onMsg(config, node, context.Background())
+		assert.Equal(t, 4, len(results))
+		assert.Equal(t, `[{"id":1},{"id":2}]`, results[0].msg.GetData())
+		assert.Equal(t, "0", results[0].msg.Metadata.GetValue("pageIndex"))
+		assert.Equal(t, "2", results[0].msg.Metadata.GetValue("rowCount"))
+		assert.Equal(t, `[{"id":5}]`, results[2].msg.GetData())
+		assert.Equal(t, "2", results[2].msg.Metadata.GetValue("pageIndex"))
+		assert.Equal(t, "1", results[2].msg.Metadata.GetValue("rowCount"))
+		assert.Equal(t, "true", results[3].msg.Metadata.GetValue("streamCompleted"))
+		assert.Equal(t, "5", results[3].msg.Metadata.GetValue("rowCount"))
+		assert.Equal(t, "3", results[3].msg.Metadata.GetValue("pageCount"))
+		assert.Equal(t, 3, pool.rejected)
+	})
+
+	t.Run("Cancel", func(t *testing.T) {
+		config := types.NewConfig()
+		node, err := newNode(config, "select id from devices", map[string]interface{}{"mode": external.StreamModeRow})
+		assert.Nil(t, err)
+		defer node.Destroy()
+
+		c, cancel := context.WithCancel(context.Background())
+		cancel()
+		results := onMsg(config, node, c)
+		assert.Equal(t, 1, len(results))
+		assert.Equal(t, types.Failure, results[0].relationType)
+		assert.Equal(t, context.Canceled, results[0].err)
+	})
+}
@@ -0,0 +1,23 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package sqlite tests the dbClient component against an embedded SQLite database.
+// It is a separate module, so the SQLite driver is only a test dependency and is not
+// required by the users of rulego. Run the tests from this directory with go test ./...
+//
+// Package sqlite 使用内嵌的 SQLite 数据库测试 dbClient 组件。
+// 它是独立的模块，所以 SQLite 驱动只是测试依赖，rulego 的使用者不需要引入该驱动。在该目录执行 go test ./... 运行测试。
+package sqlite
@@ -0,0 +1,457 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cache
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/yunboom/rulego/api/types"
+	bolt "go.etcd.io/bbolt"
+)
+
+// boltBucket is the bucket that stores the cache items.
+var boltBucket = []byte("cache")
+
+// Value type tags of the stored items.
+const (
+	tagNil     byte = 'n'
+	tagString  byte = 's'
+	tagBool    byte = 'b'
+	tagInt     byte = 'i'
+	tagInt64   byte = 'l'
+	tagFloat64 byte = 'f'
+	tagBytes   byte = 'y'
+	tagJson    byte = 'j'
+)
+
+// BoltCache is a persistent cache implementation backed by an embedded B+tree key-value store (bbolt) in a local file.
+// Items survive restarts, expired items are skipped on read and removed periodically by the GC goroutine.
+//
+// Values of type string, bool, int, int64, float64, []byte and nil are stored with their type,
+// other values are stored as JSON and read back as the generic JSON types (map[string]interface{}, []interface{}, float64 ...).
+// Lists are stored as JSON arrays, so the list items are read back as the generic JSON types too.
+//
+// Usage example:
+//
+//	c, err := cache.NewBoltCache("./data/cache.db", time.Minute)
+//	if err != nil {
+//		return err
+//	}
+//	defer c.Close()
+//	config := rulego.NewConfig(types.WithCache(c))
+type BoltCache struct {
+	db         *bolt.DB
+	gcInterval time.Duration
+	stopGc     chan struct{}
+	closeOnce  sync.Once
+}
+
+// NewBoltCache opens or creates the cache file at path and starts the GC goroutine.
+// Parameters:
+//   - path: The cache file path
+//   - gcInterval: The interval of removing expired items, default 5 minutes
+//
+// Returns:
+//   - *BoltCache: The cache instance, call Close() to release the file
+//   - error: If the file can not be opened
+func NewBoltCache(path string, gcInterval time.Duration) (*BoltCache, error) {
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, err
+	}
+	if err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(boltBucket)
+		return err
+	}); err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+	c := &BoltCache{
+		db:         db,
+		gcInterval: time.Minute * 5,
+		stopGc:     make(chan struct{}),
+	}
+	if gcInterval > 0 {
+		c.gcInterval = gcInterval
+	}
+	go c.runGC()
+	return c, nil
+}
+
+// Set stores a value in the cache with the given key and an optional expiration duration.
+// Parameters:
+//   - key: The cache key (string)
+//   - value: The value to store (interface{})
+//   - ttl: Time-to-live duration as string (e.g. "10m", "1h")
+//
+// Returns:
+//   - error if ttl parsing, value encoding or writing fails
+func (c *BoltCache) Set(key string, value interface{}, ttl string) error {
+	expiration, err := parseExpiration(ttl)
+	if err != nil {
+		return err
+	}
+	return c.db.Update(func(tx *bolt.Tx) error {
+		return putRecord(tx.Bucket(boltBucket), key, value, expiration)
+	})
+}
+
+// Get retrieves a value from the cache by its key.
+// Returns nil if the key does not exist or has expired.
+func (c *BoltCache) Get(key string) interface{} {
+	var value interface{}
+	_ = c.db.View(func(tx *bolt.Tx) error {
+		record := tx.Bucket(boltBucket).Get([]byte(key))
+		if record != nil && !recordExpired(record, time.Now().UnixNano()) {
+			value = decodeValue(record[8:])
+		}
+		return nil
+	})
+	return value
+}
+
+// Has checks if the key exists in the cache and has not expired.
+func (c *BoltCache) Has(key string) bool {
+	var found bool
+	_ = c.db.View(func(tx *bolt.Tx) error {
+		record := tx.Bucket(boltBucket).Get([]byte(key))
+		found = record != nil && !recordExpired(record, time.Now().UnixNano())
+		return nil
+	})
+	return found
+}
+
+// Delete removes the key from the cache.
+func (c *BoltCache) Delete(key string) error {
+	return c.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltBucket).Delete([]byte(key))
+	})
+}
+
+// DeleteByPrefix removes all cache items with the given prefix.
+func (c *BoltCache) DeleteByPrefix(prefix string) error {
+	return c.db.Update(func(tx *bolt.Tx) error {
+		cursor := tx.Bucket(boltBucket).Cursor()
+		p := []byte(prefix)
+		for k, _ := cursor.Seek(p); k != nil && bytes.HasPrefix(k, p); k, _ = cursor.Seek(p) {
+			if err := cursor.Delete(); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// GetByPrefix retrieves all values with keys matching the specified prefix.
+// The keys are scanned in order from the prefix, so the cost depends on the number of matching keys.
+func (c *BoltCache) GetByPrefix(prefix string) map[string]interface{} {
+	result := make(map[string]interface{})
+	now := time.Now().UnixNano()
+	_ = c.db.View(func(tx *bolt.Tx) error {
+		cursor := tx.Bucket(boltBucket).Cursor()
+		p := []byte(prefix)
+		for k, v := cursor.Seek(p); k != nil && bytes.HasPrefix(k, p); k, v = cursor.Next() {
+			if !recordExpired(v, now) {
+				result[string(k)] = decodeValue(v[8:])
+			}
+		}
+		return nil
+	})
+	return result
+}
+
+// Incr atomically adds delta to the integer value of the key and returns the new value.
+// If the key does not exist or has expired, it is created with value delta and the given ttl,
+// otherwise the expiration of the key is kept.
+// Returns types.ErrCacheNotInteger if the stored value is not an integer.
+func (c *BoltCache) Incr(key string, delta int64, ttl string) (int64, error) {
+	expiration, err := parseExpiration(ttl)
+	if err != nil {
+		return 0, err
+	}
+	var result int64
+	err = c.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(boltBucket)
+		result = delta
+		if value, exp, found := liveValue(bucket, key); found {
+			current, ok := toInt64(value)
+			if !ok {
+				return types.ErrCacheNotInteger
+			}
+			result = current + delta
+			expiration = exp
+		}
+		return putRecord(bucket, key, result, expiration)
+	})
+	return result, err
+}
+
+// Decr atomically subtracts delta from the integer value of the key and returns the new value.
+func (c *BoltCache) Decr(key string, delta int64, ttl string) (int64, error) {
+	return c.Incr(key, -delta, ttl)
+}
+
+// CompareAndSet atomically sets the value of the key if the current value equals expected.
+// A nil expected value means the key must not exist or has expired.
+// Returns true if the value is set.
+func (c *BoltCache) CompareAndSet(key string, expected, value interface{}, ttl string) (bool, error) {
+	expiration, err := parseExpiration(ttl)
+	if err != nil {
+		return false, err
+	}
+	var swapped bool
+	err = c.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(boltBucket)
+		current, _, found := liveValue(bucket, key)
+		if (found && !valueEqual(current, expected)) || (!found && expected != nil) {
+			return nil
+		}
+		swapped = true
+		return putRecord(bucket, key, value, expiration)
+	})
+	return swapped && err == nil, err
+}
+
+// ListPush atomically appends values to the tail of the list of the key and returns the length of the list.
+// If the list does not exist or has expired, it is created with the given ttl, otherwise the expiration is kept.
+// Returns types.ErrCacheNotList if the stored value is not a list.
+func (c *BoltCache) ListPush(key string, ttl string, values ...interface{}) (int, error) {
+	expiration, err := parseExpiration(ttl)
+	if err != nil {
+		return 0, err
+	}
+	var length int
+	err = c.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(boltBucket)
+		var list []interface{}
+		if value, exp, found := liveValue(bucket, key); found {
+			var ok bool
+			if list, ok = toList(value); !ok {
+				return types.ErrCacheNotList
+			}
+			expiration = exp
+		}
+		list = appendList(list, values)
+		length = len(list)
+		return putRecord(bucket, key, list, expiration)
+	})
+	return length, err
+}
+
+// ListTrim atomically keeps only the items from start to stop (inclusive) of the list,
+// negative indexes count from the tail. The key is deleted if no items are left.
+// Returns types.ErrCacheNotList if the stored value is not a list.
+func (c *BoltCache) ListTrim(key string, start, stop int) error {
+	return c.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(boltBucket)
+		value, expiration, found := liveValue(bucket, key)
+		if !found {
+			return nil
+		}
+		list, ok := toList(value)
+		if !ok {
+			return types.ErrCacheNotList
+		}
+		lo, hi := listBounds(len(list), start, stop)
+		if lo == hi {
+			return bucket.Delete([]byte(key))
+		}
+		return putRecord(bucket, key, list[lo:hi], expiration)
+	})
+}
+
+// ListRange returns the items from start to stop (inclusive) of the list, negative indexes count from the tail.
+// Returns types.ErrCacheNotList if the stored value is not a list.
+func (c *BoltCache) ListRange(key string, start, stop int) ([]interface{}, error) {
+	result := []interface{}{}
+	err := c.db.View(func(tx *bolt.Tx) error {
+		value, _, found := liveValue(tx.Bucket(boltBucket), key)
+		if !found {
+			return nil
+		}
+		list, ok := toList(value)
+		if !ok {
+			return types.ErrCacheNotList
+		}
+		lo, hi := listBounds(len(list), start, stop)
+		result = list[lo:hi]
+		return nil
+	})
+	return result, err
+}
+
+// Persistent reports that the items are kept across restarts.
+func (c *BoltCache) Persistent() bool {
+	return true
+}
+
+// Close stops the GC goroutine and closes the cache file.
+func (c *BoltCache) Close() error {
+	var err error
+	c.closeOnce.Do(func() {
+		close(c.stopGc)
+		err = c.db.Close()
+	})
+	return err
+}
+
+// runGC periodically removes expired items until Close is called.
+func (c *BoltCache) runGC() {
+	ticker := time.NewTicker(c.gcInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			c.deleteExpired()
+		case <-c.stopGc:
+			return
+		}
+	}
+}
+
+// deleteExpired removes all expired items from the cache.
+func (c *BoltCache) deleteExpired() {
+	now := time.Now().UnixNano()
+	_ = c.db.Update(func(tx *bolt.Tx) error {
+		cursor := tx.Bucket(boltBucket).Cursor()
+		for k, v := cursor.First(); k != nil; {
+			if recordExpired(v, now) {
+				if err := cursor.Delete(); err != nil {
+					return err
+				}
+				// Delete moves the cursor to the next item
+				k, v = cursor.Seek(k)
+			} else {
+				k, v = cursor.Next()
+			}
+		}
+		return nil
+	})
+}
+
+// parseExpiration converts the ttl to the expiration Unix nano timestamp, 0 means never expire.
+func parseExpiration(ttl string) (int64, error) {
+	if ttl == "" {
+		return 0, nil
+	}
+	dur, err := time.ParseDuration(ttl)
+	if err != nil {
+		return 0, err
+	}
+	if dur > 0 {
+		return time.Now().Add(dur).UnixNano(), nil
+	}
+	return 0, nil
+}
+
+// liveValue returns the value and the expiration of the key if it exists and has not expired.
+func liveValue(bucket *bolt.Bucket, key string) (interface{}, int64, bool) {
+	record := bucket.Get([]byte(key))
+	if record == nil || recordExpired(record, time.Now().UnixNano()) {
+		return nil, 0, false
+	}
+	return decodeValue(record[8:]), int64(binary.BigEndian.Uint64(record[:8])), true
+}
+
+// putRecord encodes and stores the value of the key.
+func putRecord(bucket *bolt.Bucket, key string, value interface{}, expiration int64) error {
+	record, err := encodeRecord(value, expiration)
+	if err != nil {
+		return err
+	}
+	return bucket.Put([]byte(key), record)
+}
+
+// recordExpired checks whether the record has expired.
+func recordExpired(record []byte, now int64) bool {
+	if len(record) < 9 {
+		return true
+	}
+	expiration := int64(binary.BigEndian.Uint64(record[:8]))
+	return expiration > 0 && now > expiration
+}
+
+// encodeRecord encodes the record as [8 bytes expiration][1 byte type tag][value].
+func encodeRecord(value interface{}, expiration int64) ([]byte, error) {
+	var tag byte
+	var payload []byte
+	switch v := value.(type) {
+	case nil:
+		tag = tagNil
+	case string:
+		tag, payload = tagString, []byte(v)
+	case bool:
+		tag, payload = tagBool, []byte(strconv.FormatBool(v))
+	case int:
+		tag, payload = tagInt, []byte(strconv.Itoa(v))
+	case int64:
+		tag, payload = tagInt64, []byte(strconv.FormatInt(v, 10))
+	case float64:
+		tag, payload = tagFloat64, []byte(strconv.FormatFloat(v, 'g', -1, 64))
+	case []byte:
+		tag, payload = tagBytes, v
+	default:
+		b, err := json.Marshal(v)
+		if err != nil {
+			return nil, fmt.Errorf("can not encode cache value: %w", err)
+		}
+		tag, payload = tagJson, b
+	}
+	record := make([]byte, 9+len(payload))
+	binary.BigEndian.PutUint64(record[:8], uint64(expiration))
+	record[8] = tag
+	copy(record[9:], payload)
+	return record, nil
+}
+
+// decodeValue decodes the [1 byte type tag][value] part of the record.
+func decodeValue(b []byte) interface{} {
+	payload := string(b[1:])
+	switch b[0] {
+	case tagString:
+		return payload
+	case tagBool:
+		v, _ := strconv.ParseBool(payload)
+		return v
+	case tagInt:
+		v, _ := strconv.Atoi(payload)
+		return v
+	case tagInt64:
+		v, _ := strconv.ParseInt(payload, 10, 64)
+		return v
+	case tagFloat64:
+		v, _ := strconv.ParseFloat(payload, 64)
+		return v
+	case tagBytes:
+		return append([]byte(nil), b[1:]...)
+	case tagJson:
+		var v interface{}
+		_ = json.Unmarshal(b[1:], &v)
+		return v
+	default:
+		return nil
+	}
+}
+
+// Ensure BoltCache implements the PersistentCache interface.
+var _ types.PersistentCache = (*BoltCache)(nil)
@@ -0,0 +1,137 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cache
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/yunboom/rulego/test/assert"
+	bolt "go.etcd.io/bbolt"
+)
+
+// newTestBoltCache 在临时目录创建缓存，测试结束时关闭
+func newTestBoltCache(t *testing.T, gcInterval time.Duration) *BoltCache {
+	c, err := NewBoltCache(filepath.Join(t.TempDir(), "cache.db"), gcInterval)
+	assert.Nil(t, err)
+	t.Cleanup(func() {
+		_ = c.Close()
+	})
+	return c
+}
+
+func TestBoltCache(t *testing.T) {
+	testCache(t, newTestBoltCache(t, time.Minute))
+}
+
+func TestBoltCache_GetByPrefix(t *testing.T) {
+	testCacheGetByPrefix(t, newTestBoltCache(t, time.Minute))
+}
+
+func TestBoltCache_NamespaceCache(t *testing.T) {
+	testNamespaceCache(t, newTestBoltCache(t, time.Minute))
+}
+
+func TestBoltCache_Persistent(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.db")
+	c, err := NewBoltCache(path, time.Minute)
+	assert.Nil(t, err)
+	assert.True(t, c.Persistent())
+	assert.Nil(t, c.Set("key1", "value1", ""))
+	assert.Nil(t, c.Set("key2", "value2", "1h"))
+	assert.Nil(t, c.Set("key3", "value3", "1ms"))
+	assert.Nil(t, c.Close())
+	// 重复关闭
+	assert.Nil(t, c.Close())
+
+	time.Sleep(time.Millisecond * 10)
+	c, err = NewBoltCache(path, time.Minute)
+	assert.Nil(t, err)
+	defer c.Close()
+	assert.Equal(t, "value1", c.Get("key1"))
+	assert.Equal(t, "value2", c.Get("key2"))
+	assert.Nil(t, c.Get("key3"))
+	assert.False(t, c.Has("key3"))
+}
+
+func TestBoltCache_Types(t *testing.T) {
+	c := newTestBoltCache(t, time.Minute)
+	values := map[string]interface{}{
+		"string":  "abc",
+		"int":     1,
+		"int64":   int64(1) << 40,
+		"float64": 1.5,
+		"bool":    true,
+		"bytes":   []byte("abc"),
+	}
+	for k, v := range values {
+		assert.Nil(t, c.Set(k, v, ""))
+		assert.Equal(t, v, c.Get(k))
+	}
+
+	assert.Nil(t, c.Set("nil", nil, ""))
+	assert.True(t, c.Has("nil"))
+	assert.Nil(t, c.Get("nil"))
+
+	// 其他类型按JSON存储
+	assert.Nil(t, c.Set("map", map[string]interface{}{"name": "test", "age": 18}, ""))
+	assert.Equal(t, map[string]interface{}{"name": "test", "age": float64(18)}, c.Get("map"))
+
+	assert.NotNil(t, c.Set("func", func() {}, ""))
+}
+
+func TestBoltCache_AtomicOps(t *testing.T) {
+	testCacheAtomicOps(t, newTestBoltCache(t, time.Minute))
+}
+
+func TestBoltCache_Concurrent(t *testing.T) {
+	testCacheConcurrent(t, newTestBoltCache(t, time.Minute))
+}
+
+func TestBoltCache_ListPersistent(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.db")
+	c, err := NewBoltCache(path, time.Minute)
+	assert.Nil(t, err)
+	_, err = c.ListPush("readings", "", 1, 2.5, "a")
+	assert.Nil(t, err)
+	assert.Nil(t, c.Close())
+
+	c, err = NewBoltCache(path, time.Minute)
+	assert.Nil(t, err)
+	defer c.Close()
+	items, err := c.ListRange("readings", 0, -1)
+	assert.Nil(t, err)
+	// 列表元素按JSON存储
+	assert.Equal(t, []interface{}{float64(1), 2.5, "a"}, items)
+}
+
+func TestBoltCache_GC(t *testing.T) {
+	c := newTestBoltCache(t, time.Millisecond*20)
+	assert.Nil(t, c.Set("key1", "value1", "10ms"))
+	assert.Nil(t, c.Set("key2", "value2", "10ms"))
+	assert.Nil(t, c.Set("key3", "value3", ""))
+	time.Sleep(time.Millisecond * 100)
+
+	var count int
+	_ = c.db.View(func(tx *bolt.Tx) error {
+		count = tx.Bucket(boltBucket).Stats().KeyN
+		return nil
+	})
+	assert.Equal(t, 1, count)
+	assert.Equal(t, "value3", c.Get("key3"))
+}
@@ -0,0 +1,126 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cache
+
+import (
+	"math"
+	"reflect"
+	"strconv"
+
+	"github.com/yunboom/rulego/utils/str"
+)
+
+// toInt64 converts the integer value to int64, integral floats and numeric strings are accepted.
+func toInt64(value interface{}) (int64, bool) {
+	switch v := value.(type) {
+	case int:
+		return int64(v), true
+	case int8:
+		return int64(v), true
+	case int16:
+		return int64(v), true
+	case int32:
+		return int64(v), true
+	case int64:
+		return v, true
+	case uint:
+		return int64(v), true
+	case uint8:
+		return int64(v), true
+	case uint16:
+		return int64(v), true
+	case uint32:
+		return int64(v), true
+	case uint64:
+		if v <= math.MaxInt64 {
+			return int64(v), true
+		}
+	case float32:
+		return toInt64(float64(v))
+	case float64:
+		if v == math.Trunc(v) && math.Abs(v) < 1<<53 {
+			return int64(v), true
+		}
+	case string:
+		if i, err := strconv.ParseInt(v, 10, 64); err == nil {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// valueEqual reports whether the values are deeply equal or their string forms are equal.
+// nil is only equal to nil.
+func valueEqual(a, b interface{}) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return reflect.DeepEqual(a, b) || str.ToString(a) == str.ToString(b)
+}
+
+// toList converts the stored value to a list, slices other than []byte are accepted.
+func toList(value interface{}) ([]interface{}, bool) {
+	switch v := value.(type) {
+	case []interface{}:
+		return v, true
+	case []byte, nil:
+		return nil, false
+	}
+	rv := reflect.ValueOf(value)
+	if rv.Kind() != reflect.Slice {
+		return nil, false
+	}
+	list := make([]interface{}, rv.Len())
+	for i := range list {
+		list[i] = rv.Index(i).Interface()
+	}
+	return list, true
+}
+
+// listBounds converts the inclusive start and stop indexes to the slice bounds of a list of length n,
+// negative indexes count from the tail.
+func listBounds(n, start, stop int) (int, int) {
+	if start < 0 {
+		start += n
+	}
+	if stop < 0 {
+		stop += n
+	}
+	if start < 0 {
+		start = 0
+	}
+	if stop >= n {
+		stop = n - 1
+	}
+	if start > stop {
+		return 0, 0
+	}
+	return start, stop + 1
+}
+
+// appendList returns a new list with values appended, the old list is not modified,
+// so the lists returned by Get are never changed by later operations.
+func appendList(list []interface{}, values []interface{}) []interface{} {
+	newList := make([]interface{}, 0, len(list)+len(values))
+	newList = append(newList, list...)
+	return append(newList, values...)
+}
+
+// copyList returns a copy of the items from lo to hi of the list.
+func copyList(list []interface{}, lo, hi int) []interface{} {
+	return append([]interface{}{}, list[lo:hi]...)
+}
@@ -202,6 +202,143 @@ func (c *MemoryCache) GetByPrefix(prefix string) map[string]interface{} {
 	return result
 }
 
+// Incr atomically adds delta to the integer value of the key and returns the new value.
+// If the key does not exist or has expired, it is created with value delta and the given ttl,
+// otherwise the expiration of the key is kept.
+// Returns types.ErrCacheNotInteger if the stored value is not an integer.
+func (c *MemoryCache) Incr(key string, delta int64, ttl string) (int64, error) {
+	expiration, err := parseExpiration(ttl)
+	if err != nil {
+		return 0, err
+	}
+	c.mu.Lock()
+	result := delta
+	if it, found := c.liveItem(key); found {
+		current, ok := toInt64(it.value)
+		if !ok {
+			c.mu.Unlock()
+			return 0, types.ErrCacheNotInteger
+		}
+		result = current + delta
+		expiration = it.expiration
+	}
+	c.items[key] = item{value: result, expiration: expiration}
+	shouldStartGC := expiration > 0 && c.ticker == nil
+	c.mu.Unlock()
+
+	if shouldStartGC {
+		c.StartGC()
+	}
+	return result, nil
+}
+
+// Decr atomically subtracts delta from the integer value of the key and returns the new value.
+func (c *MemoryCache) Decr(key string, delta int64, ttl string) (int64, error) {
+	return c.Incr(key, -delta, ttl)
+}
+
+// CompareAndSet atomically sets the value of the key if the current value equals expected.
+// A nil expected value means the key must not exist or has expired.
+// Returns true if the value is set.
+func (c *MemoryCache) CompareAndSet(key string, expected, value interface{}, ttl string) (bool, error) {
+	expiration, err := parseExpiration(ttl)
+	if err != nil {
+		return false, err
+	}
+	c.mu.Lock()
+	it, found := c.liveItem(key)
+	if (found && !valueEqual(it.value, expected)) || (!found && expected != nil) {
+		c.mu.Unlock()
+		return false, nil
+	}
+	c.items[key] = item{value: value, expiration: expiration}
+	shouldStartGC := expiration > 0 && c.ticker == nil
+	c.mu.Unlock()
+
+	if shouldStartGC {
+		c.StartGC()
+	}
+	return true, nil
+}
+
+// ListPush atomically appends values to the tail of the list of the key and returns the length of the list.
+// If the list does not exist or has expired, it is created with the given ttl, otherwise the expiration is kept.
+// Returns types.ErrCacheNotList if the stored value is not a list.
+func (c *MemoryCache) ListPush(key string, ttl string, values ...interface{}) (int, error) {
+	expiration, err := parseExpiration(ttl)
+	if err != nil {
+		return 0, err
+	}
+	c.mu.Lock()
+	var list []interface{}
+	if it, found := c.liveItem(key); found {
+		var ok bool
+		if list, ok = toList(it.value); !ok {
+			c.mu.Unlock()
+			return 0, types.ErrCacheNotList
+		}
+		expiration = it.expiration
+	}
+	list = appendList(list, values)
+	c.items[key] = item{value: list, expiration: expiration}
+	shouldStartGC := expiration > 0 && c.ticker == nil
+	c.mu.Unlock()
+
+	if shouldStartGC {
+		c.StartGC()
+	}
+	return len(list), nil
+}
+
+// ListTrim atomically keeps only the items from start to stop (inclusive) of the list,
+// negative indexes count from the tail. The key is deleted if no items are left.
+// Returns types.ErrCacheNotList if the stored value is not a list.
+func (c *MemoryCache) ListTrim(key string, start, stop int) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	it, found := c.liveItem(key)
+	if !found {
+		return nil
+	}
+	list, ok := toList(it.value)
+	if !ok {
+		return types.ErrCacheNotList
+	}
+	lo, hi := listBounds(len(list), start, stop)
+	if lo == hi {
+		delete(c.items, key)
+		return nil
+	}
+	c.items[key] = item{value: copyList(list, lo, hi), expiration: it.expiration}
+	return nil
+}
+
+// ListRange returns the items from start to stop (inclusive) of the list, negative indexes count from the tail.
+// Returns types.ErrCacheNotList if the stored value is not a list.
+func (c *MemoryCache) ListRange(key string, start, stop int) ([]interface{}, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	it, found := c.liveItem(key)
+	if !found {
+		return []interface{}{}, nil
+	}
+	list, ok := toList(it.value)
+	if !ok {
+		return nil, types.ErrCacheNotList
+	}
+	lo, hi := listBounds(len(list), start, stop)
+	return copyList(list, lo, hi), nil
+}
+
+// liveItem returns the item of the key if it exists and has not expired, the caller must hold the lock.
+func (c *MemoryCache) liveItem(key string) (item, bool) {
+	it, found := c.items[key]
+	if !found || (it.expiration > 0 && time.Now().UnixNano() > it.expiration) {
+		return item{}, false
+	}
+	return it, true
+}
+
 // StartGC starts the garbage collection process if not already running and if there are expirable items.
 // It runs a goroutine that periodically checks for expired items (every c.gcInterval).
 // If GC is already running, or if there are no items with an expiration time, this is a no-op.
@@ -448,6 +585,54 @@ func (c *NamespaceCache) GetByPrefix(prefix string) map[string]interface{} {
 	return newResult
 }
 
+// Incr atomically adds delta to the integer value of the prefixed key
+func (c *NamespaceCache) Incr(key string, delta int64, ttl string) (int64, error) {
+	if c == nil || c.Cache == nil {
+		return 0, types.ErrCacheNotInitialized
+	}
+	return c.Cache.Incr(c.Namespace+key, delta, ttl)
+}
+
+// Decr atomically subtracts delta from the integer value of the prefixed key
+func (c *NamespaceCache) Decr(key string, delta int64, ttl string) (int64, error) {
+	if c == nil || c.Cache == nil {
+		return 0, types.ErrCacheNotInitialized
+	}
+	return c.Cache.Decr(c.Namespace+key, delta, ttl)
+}
+
+// CompareAndSet atomically sets the value of the prefixed key if the current value equals expected
+func (c *NamespaceCache) CompareAndSet(key string, expected, value interface{}, ttl string) (bool, error) {
+	if c == nil || c.Cache == nil {
+		return false, types.ErrCacheNotInitialized
+	}
+	return c.Cache.CompareAndSet(c.Namespace+key, expected, value, ttl)
+}
+
+// ListPush atomically appends values to the tail of the list of the prefixed key
+func (c *NamespaceCache) ListPush(key string, ttl string, values ...interface{}) (int, error) {
+	if c == nil || c.Cache == nil {
+		return 0, types.ErrCacheNotInitialized
+	}
+	return c.Cache.ListPush(c.Namespace+key, ttl, values...)
+}
+
+// ListTrim atomically keeps only the items from start to stop (inclusive) of the list of the prefixed key
+func (c *NamespaceCache) ListTrim(key string, start, stop int) error {
+	if c == nil || c.Cache == nil {
+		return types.ErrCacheNotInitialized
+	}
+	return c.Cache.ListTrim(c.Namespace+key, start, stop)
+}
+
+// ListRange returns the items from start to stop (inclusive) of the list of the prefixed key
+func (c *NamespaceCache) ListRange(key string, start, stop int) ([]interface{}, error) {
+	if c == nil || c.Cache == nil {
+		return nil, types.ErrCacheNotInitialized
+	}
+	return c.Cache.ListRange(c.Namespace+key, start, stop)
+}
+
 // Ensure NamespaceCache implements the Cache interface.
 var _ types.Cache = (*NamespaceCache)(nil)
 
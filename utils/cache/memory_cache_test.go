@@ -17,16 +17,22 @@
 package cache
 
 import (
+	"fmt"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/yunboom/rulego/api/types"
 	"github.com/yunboom/rulego/test/assert"
 )
 
 func TestMemoryCache(t *testing.T) {
-	c := NewMemoryCache(time.Minute)
+	testCache(t, NewMemoryCache(time.Minute))
+}
 
+// testCache 缓存实现的通用测试
+func testCache(t *testing.T, c types.Cache) {
 	t.Run("SetAndGet", func(t *testing.T) {
 		err := c.Set("key1", "value1", "1m")
 		assert.Equal(t, "value1", c.Get("key1"))
@@ -77,7 +83,6 @@ func TestMemoryCache(t *testing.T) {
 	})
 
 	t.Run("SetWithInvalidTTL", func(t *testing.T) {
-		c := NewMemoryCache(time.Minute)
 		err := c.Set("key_invalid_ttl", "value", "invalid-duration-string")
 		assert.NotNil(t, err)
 		assert.Nil(t, c.Get("key_invalid_ttl")) // Should not be set
@@ -166,8 +171,11 @@ func TestMemoryCache_GC_Lifecycle(t *testing.T) {
 }
 
 func TestMemoryCache_GetByPrefix(t *testing.T) {
-	c := NewMemoryCache(time.Second)
+	testCacheGetByPrefix(t, NewMemoryCache(time.Second))
+}
 
+// testCacheGetByPrefix 缓存实现的前缀查询测试，c必须是空缓存
+func testCacheGetByPrefix(t *testing.T, c types.Cache) {
 	t.Run("EmptyPrefix", func(t *testing.T) {
 		c.Set("key1", "value1", "1m")
 		c.Set("key2", "value2", "1m")
@@ -206,8 +214,12 @@ func TestMemoryCache_GetByPrefix(t *testing.T) {
 }
 
 func TestNamespaceCache(t *testing.T) {
-	// 创建底层缓存和命名空间缓存
-	baseCache := NewMemoryCache(time.Minute * 5)
+	testNamespaceCache(t, NewMemoryCache(time.Minute*5))
+}
+
+// testNamespaceCache 基于底层缓存实现的命名空间缓存测试
+func testNamespaceCache(t *testing.T, baseCache types.Cache) {
+	// 创建命名空间缓存
 	namespace := "test:"
 	cache := NewNamespaceCache(baseCache, namespace)
 
@@ -305,3 +317,161 @@ func TestNamespaceCache(t *testing.T) {
 	})
 
 }
+
+func TestMemoryCache_AtomicOps(t *testing.T) {
+	testCacheAtomicOps(t, NewMemoryCache(time.Minute))
+	testCacheAtomicOps(t, NewNamespaceCache(NewMemoryCache(time.Minute), "ns:"))
+}
+
+func TestMemoryCache_Concurrent(t *testing.T) {
+	testCacheConcurrent(t, NewMemoryCache(time.Minute))
+}
+
+// testCacheAtomicOps 缓存实现的计数器、比较设置和列表操作测试
+func testCacheAtomicOps(t *testing.T, c types.Cache) {
+	t.Run("IncrAndDecr", func(t *testing.T) {
+		v, err := c.Incr("counter", 2, "1h")
+		assert.Nil(t, err)
+		assert.Equal(t, int64(2), v)
+		v, err = c.Incr("counter", 3, "")
+		assert.Nil(t, err)
+		assert.Equal(t, int64(5), v)
+		v, err = c.Decr("counter", 6, "")
+		assert.Nil(t, err)
+		assert.Equal(t, int64(-1), v)
+		assert.Equal(t, int64(-1), c.Get("counter"))
+
+		assert.Nil(t, c.Set("number", "10", ""))
+		v, err = c.Incr("number", 1, "")
+		assert.Nil(t, err)
+		assert.Equal(t, int64(11), v)
+
+		assert.Nil(t, c.Set("name", "abc", ""))
+		_, err = c.Incr("name", 1, "")
+		assert.Equal(t, types.ErrCacheNotInteger, err)
+		assert.Equal(t, "abc", c.Get("name"))
+
+		// 过期后重新计数
+		assert.Nil(t, c.Set("expired", 100, "1ms"))
+		time.Sleep(time.Millisecond * 10)
+		v, err = c.Incr("expired", 1, "")
+		assert.Nil(t, err)
+		assert.Equal(t, int64(1), v)
+
+		_, err = c.Incr("counter", 1, "invalid")
+		assert.NotNil(t, err)
+	})
+
+	t.Run("CompareAndSet", func(t *testing.T) {
+		ok, err := c.CompareAndSet("cas", nil, "v1", "")
+		assert.Nil(t, err)
+		assert.True(t, ok)
+		ok, err = c.CompareAndSet("cas", nil, "v2", "")
+		assert.Nil(t, err)
+		assert.False(t, ok)
+		ok, err = c.CompareAndSet("cas", "v0", "v2", "")
+		assert.Nil(t, err)
+		assert.False(t, ok)
+		assert.Equal(t, "v1", c.Get("cas"))
+		ok, err = c.CompareAndSet("cas", "v1", 2, "")
+		assert.Nil(t, err)
+		assert.True(t, ok)
+		// 字符串形式相同的值相等
+		ok, err = c.CompareAndSet("cas", "2", "v3", "1h")
+		assert.Nil(t, err)
+		assert.True(t, ok)
+		assert.Equal(t, "v3", c.Get("cas"))
+
+		ok, err = c.CompareAndSet("missing", "v1", "v2", "")
+		assert.Nil(t, err)
+		assert.False(t, ok)
+		assert.False(t, c.Has("missing"))
+
+		_, err = c.CompareAndSet("cas", "v3", "v4", "invalid")
+		assert.NotNil(t, err)
+	})
+
+	t.Run("List", func(t *testing.T) {
+		items, err := c.ListRange("list", 0, -1)
+		assert.Nil(t, err)
+		assert.Equal(t, 0, len(items))
+
+		n, err := c.ListPush("list", "1h", "a", "b")
+		assert.Nil(t, err)
+		assert.Equal(t, 2, n)
+		n, err = c.ListPush("list", "", "c", "d", "e")
+		assert.Nil(t, err)
+		assert.Equal(t, 5, n)
+
+		items, err = c.ListRange("list", 0, -1)
+		assert.Nil(t, err)
+		assert.Equal(t, []interface{}{"a", "b", "c", "d", "e"}, items)
+		items, err = c.ListRange("list", 1, 2)
+		assert.Nil(t, err)
+		assert.Equal(t, []interface{}{"b", "c"}, items)
+		items, err = c.ListRange("list", -2, 100)
+		assert.Nil(t, err)
+		assert.Equal(t, []interface{}{"d", "e"}, items)
+		items, err = c.ListRange("list", 3, 1)
+		assert.Nil(t, err)
+		assert.Equal(t, 0, len(items))
+
+		// 保留最后3个
+		assert.Nil(t, c.ListTrim("list", -3, -1))
+		items, err = c.ListRange("list", 0, -1)
+		assert.Nil(t, err)
+		assert.Equal(t, []interface{}{"c", "d", "e"}, items)
+
+		// 修改读取的结果不影响缓存
+		items[0] = "x"
+		items, _ = c.ListRange("list", 0, 0)
+		assert.Equal(t, []interface{}{"c"}, items)
+
+		// 没有剩余元素时删除key
+		assert.Nil(t, c.ListTrim("list", 5, 10))
+		assert.False(t, c.Has("list"))
+		assert.Nil(t, c.ListTrim("list", 0, -1))
+
+		assert.Nil(t, c.Set("notList", "abc", ""))
+		_, err = c.ListPush("notList", "", "a")
+		assert.Equal(t, types.ErrCacheNotList, err)
+		assert.Equal(t, types.ErrCacheNotList, c.ListTrim("notList", 0, 1))
+		_, err = c.ListRange("notList", 0, 1)
+		assert.Equal(t, types.ErrCacheNotList, err)
+	})
+}
+
+// testCacheConcurrent 缓存实现的并发原子操作测试
+func testCacheConcurrent(t *testing.T, c types.Cache) {
+	const workers = 20
+	const times = 50
+	var wg sync.WaitGroup
+	var swapped int64
+	var lock sync.Mutex
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < times; j++ {
+				_, err := c.Incr("counter", 1, "")
+				assert.Nil(t, err)
+				_, err = c.ListPush("list", "", fmt.Sprintf("%d-%d", i, j))
+				assert.Nil(t, err)
+				_ = c.ListTrim("list", -10, -1)
+				ok, err := c.CompareAndSet("owner", nil, i, "")
+				assert.Nil(t, err)
+				if ok {
+					lock.Lock()
+					swapped++
+					lock.Unlock()
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+	assert.Equal(t, int64(workers*times), c.Get("counter"))
+	assert.Equal(t, int64(1), swapped)
+	items, err := c.ListRange("list", 0, -1)
+	assert.Nil(t, err)
+	assert.Equal(t, 10, len(items))
+}
@@ -0,0 +1,324 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package codec
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/yunboom/rulego/api/types"
+	"github.com/yunboom/rulego/utils/cast"
+	"github.com/yunboom/rulego/utils/maps"
+)
+
+// BinaryStruct is the registered name of the declarative binary struct codec.
+// BinaryStruct 声明式二进制结构体编解码器的注册名称。
+const BinaryStruct = "binaryStruct"
+
+// Byte orders
+// 字节序
+const (
+	EndianBig    = "big"
+	EndianLittle = "little"
+)
+
+// Field types supported by the binary struct codec
+// 二进制结构体编解码器支持的字段类型
+const (
+	FieldTypeInt8    = "int8"
+	FieldTypeUint8   = "uint8"
+	FieldTypeInt16   = "int16"
+	FieldTypeUint16  = "uint16"
+	FieldTypeInt32   = "int32"
+	FieldTypeUint32  = "uint32"
+	FieldTypeInt64   = "int64"
+	FieldTypeUint64  = "uint64"
+	FieldTypeFloat32 = "float32"
+	FieldTypeFloat64 = "float64"
+	FieldTypeBool    = "bool"
+	// FieldTypeString fixed length string, trailing zero bytes are trimmed
+	// FieldTypeString 定长字符串，末尾的 0 字节会被去掉
+	FieldTypeString = "string"
+	// FieldTypeBytes fixed length bytes, represented as a hex string
+	// FieldTypeBytes 定长字节，使用十六进制字符串表示
+	FieldTypeBytes = "bytes"
+)
+
+// BinaryField declares a field of the binary struct.
+// BinaryField 二进制结构体字段声明。
+type BinaryField struct {
+	// Name field name in the JSON message
+	// Name JSON 消息中的字段名
+	Name string `json:"name"`
+	// Offset byte offset of the field in the frame
+	// Offset 字段在帧中的字节偏移量
+	Offset int `json:"offset"`
+	// Type field type, see FieldType* constants
+	// Type 字段类型，见 FieldType* 常量
+	Type string `json:"type"`
+	// Endian big or little, defaults to the struct endianness
+	// Endian big 或 little，默认使用结构体的字节序
+	Endian string `json:"endian"`
+	// Length byte length, only for string and bytes
+	// Length 字节长度，仅用于 string 和 bytes
+	Length int `json:"length"`
+	// Scale decoded value = raw value * scale, encoded raw value = value / scale. 0 means no scaling
+	// Scale 解码值 = 原始值 * scale，编码原始值 = 值 / scale。0 表示不缩放
+	Scale float64 `json:"scale"`
+}
+
+// BinaryStructConfig is the configuration of the binary struct codec.
+// BinaryStructConfig 二进制结构体编解码器配置。
+type BinaryStructConfig struct {
+	// Endian default byte order of the fields, big or little. Default big
+	// Endian 字段默认字节序，big 或 little。默认 big
+	Endian string `json:"endian"`
+	// Size frame size used when encoding, defaults to the end of the last field
+	// Size 编码时的帧大小，默认为最后一个字段的结束位置
+	Size int `json:"size"`
+	// Fields field declarations
+	// Fields 字段声明
+	Fields []BinaryField `json:"fields"`
+}
+
+// BinaryStructCodec decodes fixed layout binary frames declared in the DSL, such as MQTT-SN-like device frames.
+// It does not split frames itself, use the fixed or length prefix packet mode of the transport.
+//
+// BinaryStructCodec 解码在 DSL 中声明的固定布局二进制帧，例如类 MQTT-SN 的设备帧。
+// 它不负责切分帧，请使用传输层的 fixed 或者 length_prefix 分包模式。
+//
+// Configuration example / 配置示例：
+//
+//	{
+//	  "codec": "binaryStruct",
+//	  "codecConfig": {
+//	    "endian": "big",
+//	    "fields": [
+//	      {"name": "msgType", "offset": 0, "type": "uint8"},
+//	      {"name": "deviceId", "offset": 1, "type": "string", "length": 8},
+//	      {"name": "temperature", "offset": 9, "type": "int16", "scale": 0.1},
+//	      {"name": "battery", "offset": 11, "type": "uint16", "endian": "little"}
+//	    ]
+//	  }
+//	}
+type BinaryStructCodec struct {
+	Config BinaryStructConfig
+	// size minimum frame size required by the fields
+	size int
+}
+
+// NewBinaryStructCodec creates a binary struct codec.
+// NewBinaryStructCodec 创建二进制结构体编解码器。
+func NewBinaryStructCodec(configuration types.Configuration) (Codec, error) {
+	c := &BinaryStructCodec{}
+	if err := maps.Map2Struct(configuration, &c.Config); err != nil {
+		return nil, err
+	}
+	if c.Config.Endian == "" {
+		c.Config.Endian = EndianBig
+	}
+	if len(c.Config.Fields) == 0 {
+		return nil, errors.New("binary struct fields can not be empty")
+	}
+	if _, err := byteOrder(c.Config.Endian); err != nil {
+		return nil, err
+	}
+	for i := range c.Config.Fields {
+		field := &c.Config.Fields[i]
+		if field.Name == "" {
+			return nil, fmt.Errorf("binary struct field %d name can not be empty", i)
+		}
+		if field.Offset < 0 {
+			return nil, fmt.Errorf("binary struct field %s offset can not be negative", field.Name)
+		}
+		if field.Endian == "" {
+			field.Endian = c.Config.Endian
+		}
+		if _, err := byteOrder(field.Endian); err != nil {
+			return nil, err
+		}
+		size, err := fieldSize(field)
+		if err != nil {
+			return nil, err
+		}
+		if end := field.Offset + size; end > c.size {
+			c.size = end
+		}
+	}
+	return c, nil
+}
+
+// Decode decodes the declared fields from the frame.
+// Decode 从帧中解码声明的字段。
+func (c *BinaryStructCodec) Decode(frame []byte) (map[string]interface{}, error) {
+	if len(frame) < c.size {
+		return nil, fmt.Errorf("binary struct frame too short: need %d bytes, got %d", c.size, len(frame))
+	}
+	result := make(map[string]interface{}, len(c.Config.Fields))
+	for _, field := range c.Config.Fields {
+		order, _ := byteOrder(field.Endian)
+		size, _ := fieldSize(&field)
+		data := frame[field.Offset : field.Offset+size]
+		var value interface{}
+		switch field.Type {
+		case FieldTypeInt8:
+			value = int8(data[0])
+		case FieldTypeUint8:
+			value = data[0]
+		case FieldTypeInt16:
+			value = int16(order.Uint16(data))
+		case FieldTypeUint16:
+			value = order.Uint16(data)
+		case FieldTypeInt32:
+			value = int32(order.Uint32(data))
+		case FieldTypeUint32:
+			value = order.Uint32(data)
+		case FieldTypeInt64:
+			value = int64(order.Uint64(data))
+		case FieldTypeUint64:
+			value = order.Uint64(data)
+		case FieldTypeFloat32:
+			value = math.Float32frombits(order.Uint32(data))
+		case FieldTypeFloat64:
+			value = math.Float64frombits(order.Uint64(data))
+		case FieldTypeBool:
+			value = data[0] != 0
+		case FieldTypeString:
+			value = string(bytes.TrimRight(data, "\x00"))
+		case FieldTypeBytes:
+			value = hex.EncodeToString(data)
+		}
+		if field.Scale != 0 && isNumericType(field.Type) {
+			value = cast.ToFloat64(value) * field.Scale
+		}
+		result[field.Name] = value
+	}
+	return result, nil
+}
+
+// Encode encodes the declared fields into a frame, missing fields are zero filled.
+// Encode 把声明的字段编码成帧，缺失的字段填充 0。
+func (c *BinaryStructCodec) Encode(data map[string]interface{}) ([]byte, error) {
+	size := c.size
+	if c.Config.Size > size {
+		size = c.Config.Size
+	}
+	frame := make([]byte, size)
+	for _, field := range c.Config.Fields {
+		v, ok := data[field.Name]
+		if !ok || v == nil {
+			continue
+		}
+		order, _ := byteOrder(field.Endian)
+		fieldLen, _ := fieldSize(&field)
+		buf := frame[field.Offset : field.Offset+fieldLen]
+		if field.Scale != 0 && isNumericType(field.Type) {
+			f, err := cast.ToFloat64E(v)
+			if err != nil {
+				return nil, fmt.Errorf("binary struct field %s: %w", field.Name, err)
+			}
+			v = math.Round(f / field.Scale)
+		}
+		switch field.Type {
+		case FieldTypeInt8, FieldTypeUint8, FieldTypeInt16, FieldTypeUint16,
+			FieldTypeInt32, FieldTypeUint32, FieldTypeInt64, FieldTypeUint64:
+			i, err := cast.ToInt64E(v)
+			if err != nil {
+				return nil, fmt.Errorf("binary struct field %s: %w", field.Name, err)
+			}
+			putInt(buf, order, uint64(i))
+		case FieldTypeFloat32:
+			f, err := cast.ToFloat64E(v)
+			if err != nil {
+				return nil, fmt.Errorf("binary struct field %s: %w", field.Name, err)
+			}
+			order.PutUint32(buf, math.Float32bits(float32(f)))
+		case FieldTypeFloat64:
+			f, err := cast.ToFloat64E(v)
+			if err != nil {
+				return nil, fmt.Errorf("binary struct field %s: %w", field.Name, err)
+			}
+			order.PutUint64(buf, math.Float64bits(f))
+		case FieldTypeBool:
+			if cast.ToBool(v) {
+				buf[0] = 1
+			}
+		case FieldTypeString:
+			copy(buf, cast.ToString(v))
+		case FieldTypeBytes:
+			raw, err := hex.DecodeString(cast.ToString(v))
+			if err != nil {
+				return nil, fmt.Errorf("binary struct field %s: %w", field.Name, err)
+			}
+			copy(buf, raw)
+		}
+	}
+	return frame, nil
+}
+
+// putInt writes the low len(buf) bytes of v in the byte order
+func putInt(buf []byte, order binary.ByteOrder, v uint64) {
+	switch len(buf) {
+	case 1:
+		buf[0] = byte(v)
+	case 2:
+		order.PutUint16(buf, uint16(v))
+	case 4:
+		order.PutUint32(buf, uint32(v))
+	case 8:
+		order.PutUint64(buf, v)
+	}
+}
+
+func byteOrder(endian string) (binary.ByteOrder, error) {
+	switch endian {
+	case EndianBig:
+		return binary.BigEndian, nil
+	case EndianLittle:
+		return binary.LittleEndian, nil
+	default:
+		return nil, fmt.Errorf("unsupported endian: %s", endian)
+	}
+}
+
+func fieldSize(field *BinaryField) (int, error) {
+	switch field.Type {
+	case FieldTypeInt8, FieldTypeUint8, FieldTypeBool:
+		return 1, nil
+	case FieldTypeInt16, FieldTypeUint16:
+		return 2, nil
+	case FieldTypeInt32, FieldTypeUint32, FieldTypeFloat32:
+		return 4, nil
+	case FieldTypeInt64, FieldTypeUint64, FieldTypeFloat64:
+		return 8, nil
+	case FieldTypeString, FieldTypeBytes:
+		if field.Length <= 0 {
+			return 0, fmt.Errorf("binary struct field %s length must be greater than 0", field.Name)
+		}
+		return field.Length, nil
+	default:
+		return 0, fmt.Errorf("unsupported binary struct field type: %s", field.Type)
+	}
+}
+
+func isNumericType(fieldType string) bool {
+	return fieldType != FieldTypeBool && fieldType != FieldTypeString && fieldType != FieldTypeBytes
+}
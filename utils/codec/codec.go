@@ -0,0 +1,131 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package codec provides pluggable protocol codecs that convert binary frames
+// into structured messages and structured replies back into binary frames.
+// It is used by the net endpoint and the net client node.
+//
+// Package codec 提供可插拔的协议编解码器，把二进制帧解码成结构化消息，
+// 并把结构化的回复编码成二进制帧。net endpoint 和 net 客户端节点使用该包。
+//
+// Built-in codecs / 内置编解码器：
+//   - modbusTcp: Modbus TCP (MBAP header + PDU) / Modbus TCP（MBAP 报文头 + PDU）
+//   - binaryStruct: declarative binary struct defined by field name, offset, type and endianness
+//     通过字段名、偏移量、类型和字节序声明的二进制结构体
+//
+// Custom codecs can be registered with Registry.Register.
+// 可以通过 Registry.Register 注册自定义编解码器。
+package codec
+
+import (
+	"bufio"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/yunboom/rulego/api/types"
+)
+
+// Codec decodes a binary frame into a structured message and encodes a structured reply into a frame.
+// Codec 把二进制帧解码成结构化消息，并把结构化回复编码成二进制帧。
+type Codec interface {
+	// Decode decodes a frame into a JSON object
+	// Decode 把一帧数据解码成 JSON 对象
+	Decode(frame []byte) (map[string]interface{}, error)
+	// Encode encodes a JSON object into a frame
+	// Encode 把 JSON 对象编码成一帧数据
+	Encode(data map[string]interface{}) ([]byte, error)
+}
+
+// Splitter is implemented by codecs that know how to split frames from a stream themselves.
+// When a codec implements it, the configured packet mode of the transport is ignored.
+//
+// Splitter 由能够自行从数据流中切分帧的编解码器实现。
+// 如果编解码器实现了该接口，传输层配置的分包模式将被忽略。
+type Splitter interface {
+	ReadPacket(reader *bufio.Reader) ([]byte, error)
+}
+
+// Factory creates a codec instance from its configuration.
+// Factory 根据配置创建编解码器实例。
+type Factory func(config types.Configuration) (Codec, error)
+
+// Registry is the global codec registry.
+// Registry 全局编解码器注册表。
+var Registry = &registry{}
+
+func init() {
+	Registry.Register(ModbusTcp, NewModbusTcpCodec)
+	Registry.Register(BinaryStruct, NewBinaryStructCodec)
+}
+
+type registry struct {
+	factories map[string]Factory
+	lock      sync.RWMutex
+}
+
+// Register registers a codec factory by name, overriding any existing one with the same name.
+// Register 按名称注册编解码器工厂，同名的会被覆盖。
+func (r *registry) Register(name string, factory Factory) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if r.factories == nil {
+		r.factories = make(map[string]Factory)
+	}
+	r.factories[name] = factory
+}
+
+// Unregister removes the codec factories with the given names.
+// Unregister 删除指定名称的编解码器工厂。
+func (r *registry) Unregister(names ...string) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	for _, name := range names {
+		delete(r.factories, name)
+	}
+}
+
+// Get returns the codec factory registered with the name.
+// Get 获取指定名称的编解码器工厂。
+func (r *registry) Get(name string) (Factory, bool) {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	f, ok := r.factories[name]
+	return f, ok
+}
+
+// Names returns the sorted names of all registered codecs.
+// Names 返回所有已注册编解码器的名称（已排序）。
+func (r *registry) Names() []string {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	var keys = make([]string, 0, len(r.factories))
+	for k := range r.factories {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// New creates a codec by the registered name and configuration.
+// New 根据注册名称和配置创建编解码器。
+func (r *registry) New(name string, config types.Configuration) (Codec, error) {
+	factory, ok := r.Get(name)
+	if !ok {
+		return nil, fmt.Errorf("codec not found: %s", name)
+	}
+	return factory(config)
+}
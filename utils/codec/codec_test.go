@@ -0,0 +1,177 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package codec
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/yunboom/rulego/api/types"
+	"github.com/yunboom/rulego/test/assert"
+)
+
+func TestRegistry(t *testing.T) {
+	assert.Equal(t, []string{BinaryStruct, ModbusTcp}, Registry.Names())
+	_, err := Registry.New("notFound", nil)
+	assert.NotNil(t, err)
+	_, err = Registry.New(ModbusTcp, types.Configuration{"role": "unknown"})
+	assert.NotNil(t, err)
+}
+
+func TestModbusTcpServer(t *testing.T) {
+	c, err := Registry.New(ModbusTcp, nil)
+	assert.Nil(t, err)
+	// 读保持寄存器请求
+	request := []byte{0x00, 0x07, 0x00, 0x00, 0x00, 0x06, 0x01, 0x03, 0x00, 0x10, 0x00, 0x02}
+	//从数据流中切分帧
+	reader := bufio.NewReader(bytes.NewReader(append(append([]byte{}, request...), request...)))
+	frame, err := c.(Splitter).ReadPacket(reader)
+	assert.Nil(t, err)
+	assert.Equal(t, request, frame)
+	frame, err = c.(Splitter).ReadPacket(reader)
+	assert.Nil(t, err)
+	assert.Equal(t, request, frame)
+
+	data, err := c.Decode(frame)
+	assert.Nil(t, err)
+	assert.Equal(t, uint16(7), data[ModbusFieldTransactionId])
+	assert.Equal(t, byte(1), data[ModbusFieldUnitId])
+	assert.Equal(t, byte(ModbusReadHoldingRegisters), data[ModbusFieldFunctionCode])
+	assert.Equal(t, uint16(0x10), data[ModbusFieldAddress])
+	assert.Equal(t, uint16(2), data[ModbusFieldQuantity])
+
+	//经过 JSON 编码的回复
+	data[ModbusFieldValues] = []int{215, 1013}
+	reply := toJSONObject(t, data)
+	response, err := c.Encode(reply)
+	assert.Nil(t, err)
+	assert.Equal(t, []byte{0x00, 0x07, 0x00, 0x00, 0x00, 0x07, 0x01, 0x03, 0x04, 0x00, 0xD7, 0x03, 0xF5}, response)
+
+	//异常响应
+	reply[ModbusFieldExceptionCode] = 2
+	response, err = c.Encode(reply)
+	assert.Nil(t, err)
+	assert.Equal(t, []byte{0x00, 0x07, 0x00, 0x00, 0x00, 0x03, 0x01, 0x83, 0x02}, response)
+
+	//写多个线圈请求
+	data, err = c.Decode([]byte{0x00, 0x01, 0x00, 0x00, 0x00, 0x09, 0x01, 0x0F, 0x00, 0x13, 0x00, 0x0A, 0x02, 0xCD, 0x01})
+	assert.Nil(t, err)
+	assert.Equal(t, []bool{true, false, true, true, false, false, true, true, true, false}, data[ModbusFieldValues])
+	response, err = c.Encode(toJSONObject(t, data))
+	assert.Nil(t, err)
+	assert.Equal(t, []byte{0x00, 0x01, 0x00, 0x00, 0x00, 0x06, 0x01, 0x0F, 0x00, 0x13, 0x00, 0x0A}, response)
+
+	//长度错误
+	_, err = c.Decode([]byte{0x00, 0x01, 0x00, 0x00, 0x00, 0x09, 0x01, 0x03, 0x00})
+	assert.NotNil(t, err)
+	_, err = c.(Splitter).ReadPacket(bufio.NewReader(bytes.NewReader([]byte{0x00, 0x01, 0x00, 0x00, 0xFF, 0xFF, 0x01})))
+	assert.NotNil(t, err)
+}
+
+func TestModbusTcpClient(t *testing.T) {
+	c, err := Registry.New(ModbusTcp, types.Configuration{"role": ModbusRoleClient})
+	assert.Nil(t, err)
+	frame, err := c.Encode(toJSONObject(t, map[string]interface{}{
+		ModbusFieldTransactionId: 9,
+		ModbusFieldUnitId:        1,
+		ModbusFieldFunctionCode:  ModbusWriteSingleCoil,
+		ModbusFieldAddress:       0xAC,
+		ModbusFieldValue:         true,
+	}))
+	assert.Nil(t, err)
+	assert.Equal(t, []byte{0x00, 0x09, 0x00, 0x00, 0x00, 0x06, 0x01, 0x05, 0x00, 0xAC, 0xFF, 0x00}, frame)
+
+	frame, err = c.Encode(toJSONObject(t, map[string]interface{}{
+		ModbusFieldTransactionId: 10,
+		ModbusFieldUnitId:        1,
+		ModbusFieldFunctionCode:  ModbusWriteMultipleRegisters,
+		ModbusFieldAddress:       1,
+		ModbusFieldValues:        []int{10, 258},
+	}))
+	assert.Nil(t, err)
+	assert.Equal(t, []byte{0x00, 0x0A, 0x00, 0x00, 0x00, 0x0B, 0x01, 0x10, 0x00, 0x01, 0x00, 0x02, 0x04, 0x00, 0x0A, 0x01, 0x02}, frame)
+
+	//读线圈响应
+	data, err := c.Decode([]byte{0x00, 0x01, 0x00, 0x00, 0x00, 0x04, 0x01, 0x01, 0x01, 0x05})
+	assert.Nil(t, err)
+	assert.Equal(t, []bool{true, false, true, false, false, false, false, false}, data[ModbusFieldValues])
+
+	//异常响应
+	data, err = c.Decode([]byte{0x00, 0x01, 0x00, 0x00, 0x00, 0x03, 0x01, 0x83, 0x02})
+	assert.Nil(t, err)
+	assert.Equal(t, true, data[ModbusFieldException])
+	assert.Equal(t, byte(ModbusReadHoldingRegisters), data[ModbusFieldFunctionCode])
+	assert.Equal(t, byte(2), data[ModbusFieldExceptionCode])
+
+	_, err = c.Encode(map[string]interface{}{})
+	assert.NotNil(t, err)
+}
+
+func TestBinaryStruct(t *testing.T) {
+	var config types.Configuration
+	assert.Nil(t, json.Unmarshal([]byte(`{
+		"fields": [
+			{"name": "msgType", "offset": 0, "type": "uint8"},
+			{"name": "deviceId", "offset": 1, "type": "string", "length": 6},
+			{"name": "temperature", "offset": 7, "type": "int16", "scale": 0.1},
+			{"name": "battery", "offset": 9, "type": "uint16", "endian": "little"},
+			{"name": "online", "offset": 11, "type": "bool"},
+			{"name": "pressure", "offset": 12, "type": "float32"},
+			{"name": "raw", "offset": 16, "type": "bytes", "length": 2}
+		]
+	}`), &config))
+	c, err := Registry.New(BinaryStruct, config)
+	assert.Nil(t, err)
+
+	frame := []byte{0x0C, 'd', 'e', 'v', '0', '1', 0x00, 0xFF, 0x38, 0x10, 0x0E, 0x01, 0x42, 0xC8, 0x00, 0x00, 0xAB, 0xCD, 0x99}
+	data, err := c.Decode(frame)
+	assert.Nil(t, err)
+	assert.Equal(t, uint8(12), data["msgType"])
+	assert.Equal(t, "dev01", data["deviceId"])
+	assert.Equal(t, -20.0, data["temperature"])
+	assert.Equal(t, uint16(3600), data["battery"])
+	assert.Equal(t, true, data["online"])
+	assert.Equal(t, float32(100), data["pressure"])
+	assert.Equal(t, "abcd", data["raw"])
+
+	encoded, err := c.Encode(toJSONObject(t, data))
+	assert.Nil(t, err)
+	assert.Equal(t, frame[:18], encoded)
+
+	_, err = c.Decode(frame[:10])
+	assert.NotNil(t, err)
+
+	_, err = Registry.New(BinaryStruct, types.Configuration{"fields": []interface{}{
+		map[string]interface{}{"name": "a", "offset": 0, "type": "int24"},
+	}})
+	assert.NotNil(t, err)
+	_, err = Registry.New(BinaryStruct, types.Configuration{"endian": "middle", "fields": []interface{}{
+		map[string]interface{}{"name": "a", "offset": 0, "type": "int8"},
+	}})
+	assert.NotNil(t, err)
+}
+
+// toJSONObject 模拟规则链中经过 JSON 序列化的消息
+func toJSONObject(t *testing.T, v interface{}) map[string]interface{} {
+	b, err := json.Marshal(v)
+	assert.Nil(t, err)
+	var result map[string]interface{}
+	assert.Nil(t, json.Unmarshal(b, &result))
+	return result
+}
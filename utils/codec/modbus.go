@@ -0,0 +1,436 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package codec
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/yunboom/rulego/api/types"
+	"github.com/yunboom/rulego/utils/cast"
+	"github.com/yunboom/rulego/utils/maps"
+)
+
+// ModbusTcp is the registered name of the Modbus TCP codec.
+// ModbusTcp Modbus TCP 编解码器的注册名称。
+const ModbusTcp = "modbusTcp"
+
+const (
+	// ModbusRoleServer decodes requests and encodes responses, used by the net endpoint
+	// ModbusRoleServer 解码请求、编码响应，用于 net endpoint
+	ModbusRoleServer = "server"
+	// ModbusRoleClient encodes requests and decodes responses, used by the net client node
+	// ModbusRoleClient 编码请求、解码响应，用于 net 客户端节点
+	ModbusRoleClient = "client"
+)
+
+// Modbus function codes
+// Modbus 功能码
+const (
+	ModbusReadCoils              = 0x01
+	ModbusReadDiscreteInputs     = 0x02
+	ModbusReadHoldingRegisters   = 0x03
+	ModbusReadInputRegisters     = 0x04
+	ModbusWriteSingleCoil        = 0x05
+	ModbusWriteSingleRegister    = 0x06
+	ModbusWriteMultipleCoils     = 0x0F
+	ModbusWriteMultipleRegisters = 0x10
+)
+
+// Field names of the decoded Modbus message
+// Modbus 解码消息的字段名
+const (
+	ModbusFieldTransactionId = "transactionId"
+	ModbusFieldProtocolId    = "protocolId"
+	ModbusFieldUnitId        = "unitId"
+	ModbusFieldFunctionCode  = "functionCode"
+	ModbusFieldAddress       = "address"
+	ModbusFieldQuantity      = "quantity"
+	ModbusFieldValue         = "value"
+	ModbusFieldValues        = "values"
+	ModbusFieldException     = "exception"
+	ModbusFieldExceptionCode = "exceptionCode"
+	ModbusFieldData          = "data"
+)
+
+const (
+	// modbusHeaderSize MBAP header size including the unit identifier
+	modbusHeaderSize = 7
+	// modbusMaxLength maximum value of the MBAP length field (unit identifier + 253 bytes PDU)
+	modbusMaxLength = 254
+	modbusCoilOn    = 0xFF00
+	modbusException = 0x80
+)
+
+// ModbusTcpConfig is the configuration of the Modbus TCP codec.
+// ModbusTcpConfig Modbus TCP 编解码器配置。
+type ModbusTcpConfig struct {
+	// Role server: decode requests and encode responses; client: encode requests and decode responses. Default server
+	// Role server：解码请求、编码响应；client：编码请求、解码响应。默认 server
+	Role string `json:"role"`
+}
+
+// ModbusTcpCodec converts Modbus TCP frames to JSON objects and back.
+// The frame is split by the MBAP length field, so the packet mode of the transport is not needed.
+//
+// ModbusTcpCodec 在 Modbus TCP 帧和 JSON 对象之间转换。
+// 帧根据 MBAP 报文头的长度字段切分，因此不需要配置传输层的分包模式。
+//
+// Decoded message / 解码后的消息：
+//
+//	{"transactionId":1,"protocolId":0,"unitId":1,"functionCode":3,"address":0,"quantity":2}
+//	{"transactionId":1,"protocolId":0,"unitId":1,"functionCode":3,"values":[215,1013]}
+//
+// Replies are encoded from the same fields, so a chain should copy transactionId,
+// unitId and functionCode from the request. Set exceptionCode to reply with an exception.
+//
+// 回复使用相同的字段编码，因此规则链需要从请求中复制 transactionId、unitId 和 functionCode。
+// 设置 exceptionCode 则回复异常响应。
+type ModbusTcpCodec struct {
+	Config ModbusTcpConfig
+}
+
+// NewModbusTcpCodec creates a Modbus TCP codec.
+// NewModbusTcpCodec 创建 Modbus TCP 编解码器。
+func NewModbusTcpCodec(configuration types.Configuration) (Codec, error) {
+	c := &ModbusTcpCodec{}
+	if err := maps.Map2Struct(configuration, &c.Config); err != nil {
+		return nil, err
+	}
+	if c.Config.Role == "" {
+		c.Config.Role = ModbusRoleServer
+	}
+	if c.Config.Role != ModbusRoleServer && c.Config.Role != ModbusRoleClient {
+		return nil, fmt.Errorf("unsupported modbus role: %s", c.Config.Role)
+	}
+	return c, nil
+}
+
+// ReadPacket reads a whole Modbus TCP frame according to the MBAP length field.
+// ReadPacket 根据 MBAP 长度字段读取完整的 Modbus TCP 帧。
+func (c *ModbusTcpCodec) ReadPacket(reader *bufio.Reader) ([]byte, error) {
+	header := make([]byte, modbusHeaderSize)
+	if _, err := io.ReadFull(reader, header); err != nil {
+		return nil, err
+	}
+	length := int(binary.BigEndian.Uint16(header[4:6]))
+	if length < 2 || length > modbusMaxLength {
+		return nil, fmt.Errorf("invalid modbus length: %d", length)
+	}
+	frame := make([]byte, modbusHeaderSize+length-1)
+	copy(frame, header)
+	if _, err := io.ReadFull(reader, frame[modbusHeaderSize:]); err != nil {
+		return nil, err
+	}
+	return frame, nil
+}
+
+// Decode decodes a Modbus TCP frame.
+// Decode 解码 Modbus TCP 帧。
+func (c *ModbusTcpCodec) Decode(frame []byte) (map[string]interface{}, error) {
+	if len(frame) < modbusHeaderSize+1 {
+		return nil, fmt.Errorf("modbus frame too short: %d", len(frame))
+	}
+	length := int(binary.BigEndian.Uint16(frame[4:6]))
+	if length != len(frame)-modbusHeaderSize+1 {
+		return nil, fmt.Errorf("modbus length mismatch: header %d, actual %d", length, len(frame)-modbusHeaderSize+1)
+	}
+	result := map[string]interface{}{
+		ModbusFieldTransactionId: binary.BigEndian.Uint16(frame[0:2]),
+		ModbusFieldProtocolId:    binary.BigEndian.Uint16(frame[2:4]),
+		ModbusFieldUnitId:        frame[6],
+	}
+	fc := frame[modbusHeaderSize]
+	pdu := frame[modbusHeaderSize+1:]
+	if fc&modbusException != 0 {
+		result[ModbusFieldFunctionCode] = fc &^ modbusException
+		result[ModbusFieldException] = true
+		if len(pdu) < 1 {
+			return nil, errors.New("modbus exception response without exception code")
+		}
+		result[ModbusFieldExceptionCode] = pdu[0]
+		return result, nil
+	}
+	result[ModbusFieldFunctionCode] = fc
+	var err error
+	if c.Config.Role == ModbusRoleServer {
+		err = decodeModbusRequest(fc, pdu, result)
+	} else {
+		err = decodeModbusResponse(fc, pdu, result)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// Encode encodes a Modbus TCP frame.
+// Encode 编码 Modbus TCP 帧。
+func (c *ModbusTcpCodec) Encode(data map[string]interface{}) ([]byte, error) {
+	fc := byte(cast.ToInt(data[ModbusFieldFunctionCode]))
+	if fc == 0 {
+		return nil, errors.New("modbus functionCode is required")
+	}
+	var pdu []byte
+	var err error
+	if exceptionCode := cast.ToInt(data[ModbusFieldExceptionCode]); exceptionCode != 0 {
+		pdu = []byte{fc | modbusException, byte(exceptionCode)}
+	} else if c.Config.Role == ModbusRoleServer {
+		pdu, err = encodeModbusResponse(fc, data)
+	} else {
+		pdu, err = encodeModbusRequest(fc, data)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(pdu) > modbusMaxLength-1 {
+		return nil, fmt.Errorf("modbus pdu too large: %d", len(pdu))
+	}
+	frame := make([]byte, modbusHeaderSize, modbusHeaderSize+len(pdu))
+	binary.BigEndian.PutUint16(frame[0:2], uint16(cast.ToInt(data[ModbusFieldTransactionId])))
+	binary.BigEndian.PutUint16(frame[2:4], uint16(cast.ToInt(data[ModbusFieldProtocolId])))
+	binary.BigEndian.PutUint16(frame[4:6], uint16(len(pdu)+1))
+	frame[6] = byte(cast.ToInt(data[ModbusFieldUnitId]))
+	return append(frame, pdu...), nil
+}
+
+func decodeModbusRequest(fc byte, pdu []byte, result map[string]interface{}) error {
+	switch fc {
+	case ModbusReadCoils, ModbusReadDiscreteInputs, ModbusReadHoldingRegisters, ModbusReadInputRegisters:
+		if len(pdu) != 4 {
+			return errModbusPduLength(fc, len(pdu))
+		}
+		result[ModbusFieldAddress] = binary.BigEndian.Uint16(pdu[0:2])
+		result[ModbusFieldQuantity] = binary.BigEndian.Uint16(pdu[2:4])
+	case ModbusWriteSingleCoil, ModbusWriteSingleRegister:
+		return decodeModbusSingleWrite(fc, pdu, result)
+	case ModbusWriteMultipleCoils, ModbusWriteMultipleRegisters:
+		if len(pdu) < 5 || len(pdu) != 5+int(pdu[4]) {
+			return errModbusPduLength(fc, len(pdu))
+		}
+		quantity := binary.BigEndian.Uint16(pdu[2:4])
+		result[ModbusFieldAddress] = binary.BigEndian.Uint16(pdu[0:2])
+		result[ModbusFieldQuantity] = quantity
+		if fc == ModbusWriteMultipleCoils {
+			if int(quantity) > len(pdu[5:])*8 {
+				return errModbusPduLength(fc, len(pdu))
+			}
+			result[ModbusFieldValues] = unpackCoils(pdu[5:], int(quantity))
+		} else {
+			if int(quantity)*2 != len(pdu[5:]) {
+				return errModbusPduLength(fc, len(pdu))
+			}
+			result[ModbusFieldValues] = unpackRegisters(pdu[5:])
+		}
+	default:
+		result[ModbusFieldData] = hex.EncodeToString(pdu)
+	}
+	return nil
+}
+
+func decodeModbusResponse(fc byte, pdu []byte, result map[string]interface{}) error {
+	switch fc {
+	case ModbusReadCoils, ModbusReadDiscreteInputs, ModbusReadHoldingRegisters, ModbusReadInputRegisters:
+		if len(pdu) < 1 || len(pdu) != 1+int(pdu[0]) {
+			return errModbusPduLength(fc, len(pdu))
+		}
+		if fc == ModbusReadCoils || fc == ModbusReadDiscreteInputs {
+			result[ModbusFieldValues] = unpackCoils(pdu[1:], len(pdu[1:])*8)
+		} else {
+			if len(pdu[1:])%2 != 0 {
+				return errModbusPduLength(fc, len(pdu))
+			}
+			result[ModbusFieldValues] = unpackRegisters(pdu[1:])
+		}
+	case ModbusWriteSingleCoil, ModbusWriteSingleRegister:
+		return decodeModbusSingleWrite(fc, pdu, result)
+	case ModbusWriteMultipleCoils, ModbusWriteMultipleRegisters:
+		if len(pdu) != 4 {
+			return errModbusPduLength(fc, len(pdu))
+		}
+		result[ModbusFieldAddress] = binary.BigEndian.Uint16(pdu[0:2])
+		result[ModbusFieldQuantity] = binary.BigEndian.Uint16(pdu[2:4])
+	default:
+		result[ModbusFieldData] = hex.EncodeToString(pdu)
+	}
+	return nil
+}
+
+// decodeModbusSingleWrite single writes have the same layout in requests and responses
+func decodeModbusSingleWrite(fc byte, pdu []byte, result map[string]interface{}) error {
+	if len(pdu) != 4 {
+		return errModbusPduLength(fc, len(pdu))
+	}
+	result[ModbusFieldAddress] = binary.BigEndian.Uint16(pdu[0:2])
+	value := binary.BigEndian.Uint16(pdu[2:4])
+	if fc == ModbusWriteSingleCoil {
+		result[ModbusFieldValue] = value == modbusCoilOn
+	} else {
+		result[ModbusFieldValue] = value
+	}
+	return nil
+}
+
+func encodeModbusRequest(fc byte, data map[string]interface{}) ([]byte, error) {
+	address := uint16(cast.ToInt(data[ModbusFieldAddress]))
+	switch fc {
+	case ModbusReadCoils, ModbusReadDiscreteInputs, ModbusReadHoldingRegisters, ModbusReadInputRegisters:
+		return appendUint16([]byte{fc}, address, uint16(cast.ToInt(data[ModbusFieldQuantity]))), nil
+	case ModbusWriteSingleCoil, ModbusWriteSingleRegister:
+		return encodeModbusSingleWrite(fc, address, data), nil
+	case ModbusWriteMultipleCoils:
+		values, err := toSlice(data[ModbusFieldValues])
+		if err != nil {
+			return nil, err
+		}
+		coils := packCoils(values)
+		pdu := appendUint16([]byte{fc}, address, uint16(len(values)))
+		pdu = append(pdu, byte(len(coils)))
+		return append(pdu, coils...), nil
+	case ModbusWriteMultipleRegisters:
+		values, err := toSlice(data[ModbusFieldValues])
+		if err != nil {
+			return nil, err
+		}
+		pdu := appendUint16([]byte{fc}, address, uint16(len(values)))
+		pdu = append(pdu, byte(len(values)*2))
+		return append(pdu, packRegisters(values)...), nil
+	default:
+		return encodeModbusRaw(fc, data)
+	}
+}
+
+func encodeModbusResponse(fc byte, data map[string]interface{}) ([]byte, error) {
+	switch fc {
+	case ModbusReadCoils, ModbusReadDiscreteInputs:
+		values, err := toSlice(data[ModbusFieldValues])
+		if err != nil {
+			return nil, err
+		}
+		coils := packCoils(values)
+		return append([]byte{fc, byte(len(coils))}, coils...), nil
+	case ModbusReadHoldingRegisters, ModbusReadInputRegisters:
+		values, err := toSlice(data[ModbusFieldValues])
+		if err != nil {
+			return nil, err
+		}
+		return append([]byte{fc, byte(len(values) * 2)}, packRegisters(values)...), nil
+	case ModbusWriteSingleCoil, ModbusWriteSingleRegister:
+		return encodeModbusSingleWrite(fc, uint16(cast.ToInt(data[ModbusFieldAddress])), data), nil
+	case ModbusWriteMultipleCoils, ModbusWriteMultipleRegisters:
+		return appendUint16([]byte{fc}, uint16(cast.ToInt(data[ModbusFieldAddress])), uint16(cast.ToInt(data[ModbusFieldQuantity]))), nil
+	default:
+		return encodeModbusRaw(fc, data)
+	}
+}
+
+func encodeModbusSingleWrite(fc byte, address uint16, data map[string]interface{}) []byte {
+	var value uint16
+	if fc == ModbusWriteSingleCoil {
+		if cast.ToBool(data[ModbusFieldValue]) {
+			value = modbusCoilOn
+		}
+	} else {
+		value = uint16(cast.ToInt(data[ModbusFieldValue]))
+	}
+	return appendUint16([]byte{fc}, address, value)
+}
+
+// encodeModbusRaw encodes an unknown function code from the hex data field
+func encodeModbusRaw(fc byte, data map[string]interface{}) ([]byte, error) {
+	raw, err := hex.DecodeString(cast.ToString(data[ModbusFieldData]))
+	if err != nil {
+		return nil, fmt.Errorf("invalid modbus data: %w", err)
+	}
+	return append([]byte{fc}, raw...), nil
+}
+
+func appendUint16(b []byte, values ...uint16) []byte {
+	for _, v := range values {
+		b = binary.BigEndian.AppendUint16(b, v)
+	}
+	return b
+}
+
+func unpackCoils(data []byte, quantity int) []bool {
+	coils := make([]bool, quantity)
+	for i := 0; i < quantity; i++ {
+		coils[i] = data[i/8]&(1<<(uint(i)%8)) != 0
+	}
+	return coils
+}
+
+func packCoils(values []interface{}) []byte {
+	coils := make([]byte, (len(values)+7)/8)
+	for i, v := range values {
+		if cast.ToBool(v) {
+			coils[i/8] |= 1 << (uint(i) % 8)
+		}
+	}
+	return coils
+}
+
+func unpackRegisters(data []byte) []uint16 {
+	registers := make([]uint16, len(data)/2)
+	for i := range registers {
+		registers[i] = binary.BigEndian.Uint16(data[i*2:])
+	}
+	return registers
+}
+
+func packRegisters(values []interface{}) []byte {
+	data := make([]byte, 0, len(values)*2)
+	for _, v := range values {
+		data = binary.BigEndian.AppendUint16(data, uint16(cast.ToInt(v)))
+	}
+	return data
+}
+
+func toSlice(v interface{}) ([]interface{}, error) {
+	switch values := v.(type) {
+	case []interface{}:
+		return values, nil
+	case []bool:
+		result := make([]interface{}, len(values))
+		for i, item := range values {
+			result[i] = item
+		}
+		return result, nil
+	case []uint16:
+		result := make([]interface{}, len(values))
+		for i, item := range values {
+			result[i] = item
+		}
+		return result, nil
+	case []int:
+		result := make([]interface{}, len(values))
+		for i, item := range values {
+			result[i] = item
+		}
+		return result, nil
+	default:
+		return nil, fmt.Errorf("modbus values must be an array, got %T", v)
+	}
+}
+
+func errModbusPduLength(fc byte, length int) error {
+	return fmt.Errorf("invalid modbus pdu length %d for function code %d", length, fc)
+}
@@ -0,0 +1,618 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dsl
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/dop251/goja/ast"
+	"github.com/dop251/goja/parser"
+	exprAst "github.com/expr-lang/expr/ast"
+	exprFile "github.com/expr-lang/expr/file"
+	exprParser "github.com/expr-lang/expr/parser"
+	"github.com/jmespath/go-jmespath"
+	"github.com/yuin/gopher-lua/parse"
+	"github.com/yunboom/rulego/api/types"
+	"github.com/yunboom/rulego/utils/el"
+	"github.com/yunboom/rulego/utils/js"
+	"github.com/yunboom/rulego/utils/str"
+)
+
+// Lint issue severities
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+const (
+	fieldJsScript   = "jsScript"
+	fieldScriptType = "scriptType"
+	fieldExpr       = "expr"
+	fieldQuery      = "query"
+	fieldMapping    = "mapping"
+)
+
+// returnShape the value the script node requires the script to return
+type returnShape int
+
+const (
+	returnAny returnShape = iota
+	returnBool
+	returnArray
+	returnMsg
+)
+
+// scriptNode the script node whose jsScript is wrapped into the function
+type scriptNode struct {
+	funcName string
+	shape    returnShape
+}
+
+// scriptNodes the script nodes, see the function templates of the components
+var scriptNodes = map[string]scriptNode{
+	"jsFilter":    {funcName: "Filter", shape: returnBool},
+	"jsSwitch":    {funcName: "Switch", shape: returnArray},
+	"jsTransform": {funcName: "Transform", shape: returnMsg},
+	"log":         {funcName: "ToString", shape: returnAny},
+}
+
+// exprNodes the expression nodes
+var exprNodes = map[string]bool{
+	"exprFilter":    true,
+	"exprTransform": true,
+}
+
+// queryNodes the JMESPath query nodes
+var queryNodes = map[string]bool{
+	"jsonPathFilter":    true,
+	"jsonPathTransform": true,
+}
+
+// exprEnvKeys the root variables of the expression environment, see types.RuleContext.GetEnv
+var exprEnvKeys = map[string]bool{
+	types.IdKey:       true,
+	types.TsKey:       true,
+	types.DataKey:     true,
+	types.MsgKey:      true,
+	types.MetadataKey: true,
+	types.MsgTypeKey:  true,
+	types.DataTypeKey: true,
+	"$env":            true,
+}
+
+// scriptVarRegex matches vars.xx and global.xx references
+var scriptVarRegex = regexp.MustCompile(`(^|[^\w$.])(` + types.Vars + `|` + types.Global + `)\.(\w+)`)
+
+// templateVarRegex matches the ${vars.xx} and ${global.xx} placeholders
+var templateVarRegex = regexp.MustCompile(`^\s*(` + types.Vars + `|` + types.Global + `)\.(\w+)\s*$`)
+
+// Issue is a problem found by Lint. Line and Column are 1-based positions in the field value, 0 if unknown.
+// Issue 是 Lint 发现的问题。Line 和 Column 是字段值中从1开始的位置，未知则为0。
+type Issue struct {
+	NodeId   string `json:"nodeId"`
+	Field    string `json:"field"`
+	Line     int    `json:"line"`
+	Column   int    `json:"column"`
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+}
+
+func (i Issue) String() string {
+	return fmt.Sprintf("%s.%s:%d:%d: %s: %s", i.NodeId, i.Field, i.Line, i.Column, i.Severity, i.Message)
+}
+
+// Issues is the list of lint issues, sorted by node id, field and position.
+// Issues 是 Lint 问题列表，按照节点ID、字段和位置排序。
+type Issues []Issue
+
+// Error implements error.
+func (issues Issues) Error() string {
+	var lines []string
+	for _, issue := range issues {
+		lines = append(lines, issue.String())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// HasError reports whether there is any issue with error severity.
+// HasError 是否有错误级别的问题。
+func (issues Issues) HasError() bool {
+	for _, issue := range issues {
+		if issue.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+// Lint statically checks the scripts and expressions of the rule chain without initializing the nodes:
+//   - compiles the JavaScript/Lua scripts of jsFilter, jsSwitch, jsTransform and log nodes
+//   - compiles the expressions of exprFilter and exprTransform nodes with the type hints of the rule chain, and reports undefined variables
+//   - compiles the JMESPath queries of jsonPathFilter and jsonPathTransform nodes
+//   - parses the ${...} templates of the other string fields
+//   - reports references to vars/global keys that are not defined in the rule chain vars or config.Properties
+//   - reports JavaScript scripts that never return the shape required by the node
+//
+// Lint 在不初始化节点的情况下静态检查规则链的脚本和表达式：
+//   - 编译 jsFilter、jsSwitch、jsTransform 和 log 节点的 JavaScript/Lua 脚本
+//   - 使用规则链的类型提示编译 exprFilter 和 exprTransform 节点的表达式，并报告未定义的变量
+//   - 编译 jsonPathFilter 和 jsonPathTransform 节点的 JMESPath 查询
+//   - 解析其他字符串字段的 ${...} 模板
+//   - 报告规则链 vars 或者 config.Properties 中未定义的 vars/global 引用
+//   - 报告没有返回节点要求格式的 JavaScript 脚本
+func Lint(config types.Config, def types.RuleChain) Issues {
+	l := &linter{
+		vars:   varKeys(def.RuleChain.Configuration[types.Vars]),
+		global: make(map[string]bool),
+		env:    GetInitNodeEnv(config, def),
+		udf:    config.Udf,
+	}
+	if exprTypes, ok := def.RuleChain.Configuration[types.ExprTypes]; ok {
+		l.exprTypes = str.ToStringMapString(exprTypes)
+	}
+	if config.Properties != nil {
+		for k := range config.Properties.Values() {
+			l.global[k] = true
+		}
+	}
+	for _, node := range def.Metadata.Nodes {
+		if node != nil {
+			l.lintNode(node)
+		}
+	}
+	sort.SliceStable(l.issues, func(i, j int) bool {
+		a, b := l.issues[i], l.issues[j]
+		if a.NodeId != b.NodeId {
+			return a.NodeId < b.NodeId
+		}
+		if a.Field != b.Field {
+			return a.Field < b.Field
+		}
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Column < b.Column
+	})
+	return l.issues
+}
+
+type linter struct {
+	vars   map[string]bool
+	global map[string]bool
+	env    map[string]interface{}
+	udf    map[string]interface{}
+	// exprTypes the type hints of the expressions
+	exprTypes map[string]string
+	issues    Issues
+	nodeId    string
+}
+
+func (l *linter) add(field string, line, column int, severity string, format string, args ...interface{}) {
+	l.issues = append(l.issues, Issue{
+		NodeId:   l.nodeId,
+		Field:    field,
+		Line:     line,
+		Column:   column,
+		Severity: severity,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+func (l *linter) lintNode(node *types.RuleNode) {
+	l.nodeId = node.Id
+	script, isScriptNode := scriptNodes[node.Type]
+	for field, value := range node.Configuration {
+		if isScriptNode && strings.EqualFold(field, fieldJsScript) {
+			continue
+		}
+		if (exprNodes[node.Type] || queryNodes[node.Type]) && strings.EqualFold(field, fieldMapping) {
+			lint := l.lintExpr
+			if queryNodes[node.Type] {
+				lint = l.lintQuery
+			}
+			if mapping, ok := value.(map[string]interface{}); ok {
+				for k, v := range mapping {
+					lint(field+"."+k, str.ToString(v))
+				}
+			} else if mapping, ok := value.(map[string]string); ok {
+				for k, v := range mapping {
+					lint(field+"."+k, v)
+				}
+			}
+			continue
+		}
+		strV, ok := value.(string)
+		if !ok {
+			continue
+		}
+		if strings.Contains(strings.ToLower(field), FieldNameScript) {
+			l.lintVarRefs(field, strV)
+		} else if exprNodes[node.Type] && strings.EqualFold(field, fieldExpr) {
+			l.lintExpr(field, strV)
+		} else if queryNodes[node.Type] && strings.EqualFold(field, fieldQuery) {
+			l.lintQuery(field, strV)
+		} else {
+			l.lintTemplate(field, strV)
+		}
+	}
+	if isScriptNode {
+		field, scriptType, source := fieldJsScript, types.Js, ""
+		for k, v := range node.Configuration {
+			if strings.EqualFold(k, fieldJsScript) {
+				field, source = k, str.ToString(v)
+			} else if strings.EqualFold(k, fieldScriptType) && str.ToString(v) != "" {
+				scriptType = str.ToString(v)
+			}
+		}
+		l.lintVarRefs(field, source)
+		if scriptType == types.Lua {
+			l.lintLua(field, script, source)
+		} else {
+			l.lintJs(field, script, source)
+		}
+	}
+}
+
+// lintVarRefs checks the vars.xx and global.xx references of the script
+func (l *linter) lintVarRefs(field, source string) {
+	for _, match := range scriptVarRegex.FindAllStringSubmatchIndex(source, -1) {
+		prefix, key := source[match[4]:match[5]], source[match[6]:match[7]]
+		line, column := position(source, match[4])
+		l.checkVarKey(field, line, column, prefix, key)
+	}
+}
+
+// checkVarKey reports the undefined vars/global key, returns false if the key is undefined
+func (l *linter) checkVarKey(field string, line, column int, prefix, key string) bool {
+	if prefix == types.Vars && !l.vars[key] {
+		l.add(field, line, column, SeverityWarning, "undefined rule chain variable: %s.%s", prefix, key)
+		return false
+	} else if prefix == types.Global && !l.global[key] {
+		l.add(field, line, column, SeverityWarning, "undefined global property: %s.%s", prefix, key)
+		return false
+	}
+	return true
+}
+
+// lintTemplate parses the ${...} placeholders of the field, returns false if any placeholder can not be resolved
+func (l *linter) lintTemplate(field, value string) bool {
+	resolved := true
+	offset := 0
+	for {
+		start := strings.Index(value[offset:], "${")
+		if start < 0 {
+			return resolved
+		}
+		start += offset + 2
+		line, column := position(value, start)
+		if el.IsQuery(value[start:]) {
+			end := el.QueryEnd(value, start)
+			if end < 0 {
+				l.add(field, line, column, SeverityError, "invalid template: unclosed query")
+				return false
+			}
+			offset = end + 1
+			if _, err := el.CompileQuery(value[start:end]); err != nil {
+				l.add(field, line, column, SeverityError, "invalid template: %s", err.Error())
+			}
+			continue
+		}
+		end := strings.Index(value[start:], "}")
+		if end < 0 {
+			return resolved
+		}
+		end += start
+		offset = end + 1
+		inner := value[start:end]
+		if match := templateVarRegex.FindStringSubmatch(inner); match != nil {
+			resolved = l.checkVarKey(field, line, column, match[1], match[2]) && resolved
+			continue
+		}
+		if _, err := exprParser.Parse(inner); err != nil {
+			errLine, errColumn, msg := exprError(err)
+			if errLine > 1 {
+				line += errLine - 1
+			} else {
+				column += errColumn - 1
+			}
+			l.add(field, line, column, SeverityError, "invalid template: %s", msg)
+		}
+	}
+}
+
+// lintExpr compiles the expression and checks the variables
+func (l *linter) lintExpr(field, source string) {
+	if !l.lintTemplate(field, source) {
+		// the expression can not be compiled before the placeholders are defined
+		return
+	}
+	// the ${vars.xx} and ${global.xx} placeholders are replaced when the node is initialized
+	source = str.ExecuteTemplate(source, l.env)
+	if strings.TrimSpace(source) == "" {
+		l.add(field, 0, 0, SeverityError, "expression can not be empty")
+		return
+	}
+	if _, err := el.CompileExpr(source, l.udf, l.exprTypes); err != nil {
+		line, column, msg := exprError(err)
+		l.add(field, line, column, SeverityError, "%s", msg)
+		return
+	}
+	tree, err := exprParser.Parse(source)
+	if err != nil {
+		return
+	}
+	v := &exprVisitor{callees: make(map[exprAst.Node]bool), declared: make(map[string]bool)}
+	exprAst.Walk(&tree.Node, v)
+	for _, ident := range v.identifiers {
+		name := ident.Value
+		if exprEnvKeys[name] || v.declared[name] || v.callees[ident] {
+			continue
+		}
+		line, column := runePosition([]rune(source), ident.Location().From)
+		if name == types.Vars || name == types.Global {
+			l.add(field, line, column, SeverityWarning, "%s is not available in expressions, use ${%s.xx} instead", name, name)
+		} else {
+			l.add(field, line, column, SeverityWarning, "undefined variable: %s", name)
+		}
+	}
+}
+
+// lintQuery compiles the JMESPath query
+func (l *linter) lintQuery(field, source string) {
+	if !l.lintTemplate(field, source) {
+		return
+	}
+	source = str.ExecuteTemplate(source, l.env)
+	if strings.TrimSpace(el.TrimQueryPrefix(source)) == "" {
+		l.add(field, 0, 0, SeverityError, "query can not be empty")
+		return
+	}
+	if _, err := el.CompileQuery(source); err != nil {
+		line, column := 0, 0
+		var syntaxErr jmespath.SyntaxError
+		if errors.As(err, &syntaxErr) {
+			// the offset is relative to the query without the prefix
+			offset := strings.Index(source, el.TrimQueryPrefix(source)) + syntaxErr.Offset
+			if offset > len(source) {
+				offset = len(source)
+			}
+			line, column = position(source, offset)
+		}
+		l.add(field, line, column, SeverityError, "%s", err.Error())
+	}
+}
+
+type exprVisitor struct {
+	identifiers []*exprAst.IdentifierNode
+	callees     map[exprAst.Node]bool
+	declared    map[string]bool
+}
+
+func (v *exprVisitor) Visit(node *exprAst.Node) {
+	switch n := (*node).(type) {
+	case *exprAst.IdentifierNode:
+		v.identifiers = append(v.identifiers, n)
+	case *exprAst.CallNode:
+		v.callees[n.Callee] = true
+	case *exprAst.VariableDeclaratorNode:
+		v.declared[n.Name] = true
+	}
+}
+
+// lintJs compiles the script wrapped by the function template of the node and checks the returned values
+func (l *linter) lintJs(field string, node scriptNode, source string) {
+	prefix := fmt.Sprintf("function %s(msg, metadata, msgType, dataType) { ", node.funcName)
+	program, err := parser.ParseFile(nil, field, prefix+js.ConvertImports(source)+" }", 0)
+	if err != nil {
+		var errList parser.ErrorList
+		if errors.As(err, &errList) && len(errList) > 0 {
+			// the following errors are usually caused by the first one
+			e := errList[0]
+			l.add(field, e.Position.Line, wrappedColumn(e.Position.Line, e.Position.Column, prefix), SeverityError, "%s", e.Message)
+		} else {
+			l.add(field, 0, 0, SeverityError, "%s", err.Error())
+		}
+		return
+	}
+	if node.shape == returnAny || len(program.Body) == 0 {
+		return
+	}
+	declaration, ok := program.Body[0].(*ast.FunctionDeclaration)
+	if !ok || declaration.Function.Body == nil {
+		return
+	}
+	var returns []*ast.ReturnStatement
+	for _, stmt := range declaration.Function.Body.List {
+		collectReturns(stmt, &returns)
+	}
+	if len(returns) == 0 {
+		l.add(field, 0, 0, SeverityError, "the script never returns a value, %s", shapeDescription(node.shape))
+		return
+	}
+	for _, ret := range returns {
+		pos := program.File.Position(int(ret.Idx0()) - program.File.Base())
+		line, column := pos.Line, wrappedColumn(pos.Line, pos.Column, prefix)
+		if ret.Argument == nil {
+			l.add(field, line, column, SeverityError, "return without a value, %s", shapeDescription(node.shape))
+		} else if severity, ok := checkShape(node.shape, ret.Argument); !ok {
+			l.add(field, line, column, severity, "invalid return value, %s", shapeDescription(node.shape))
+		}
+	}
+}
+
+// lintLua compiles the Lua script wrapped by the function template of the node
+func (l *linter) lintLua(field string, node scriptNode, source string) {
+	prefix := fmt.Sprintf("function %s(msg, metadata, msgType, dataType) ", node.funcName)
+	if _, err := parse.Parse(strings.NewReader(prefix+source+" end"), field); err != nil {
+		var parseErr *parse.Error
+		if errors.As(err, &parseErr) && parseErr.Pos.Line > 0 {
+			l.add(field, parseErr.Pos.Line, wrappedColumn(parseErr.Pos.Line, parseErr.Pos.Column, prefix), SeverityError, "%s", parseErr.Message)
+		} else {
+			l.add(field, 0, 0, SeverityError, "%s", err.Error())
+		}
+	}
+}
+
+// collectReturns collects the return statements of the function body, nested functions are skipped
+func collectReturns(stmt ast.Statement, returns *[]*ast.ReturnStatement) {
+	switch s := stmt.(type) {
+	case *ast.ReturnStatement:
+		*returns = append(*returns, s)
+	case *ast.BlockStatement:
+		for _, item := range s.List {
+			collectReturns(item, returns)
+		}
+	case *ast.IfStatement:
+		collectReturns(s.Consequent, returns)
+		if s.Alternate != nil {
+			collectReturns(s.Alternate, returns)
+		}
+	case *ast.ForStatement:
+		collectReturns(s.Body, returns)
+	case *ast.ForInStatement:
+		collectReturns(s.Body, returns)
+	case *ast.ForOfStatement:
+		collectReturns(s.Body, returns)
+	case *ast.WhileStatement:
+		collectReturns(s.Body, returns)
+	case *ast.DoWhileStatement:
+		collectReturns(s.Body, returns)
+	case *ast.WithStatement:
+		collectReturns(s.Body, returns)
+	case *ast.LabelledStatement:
+		collectReturns(s.Statement, returns)
+	case *ast.SwitchStatement:
+		for _, c := range s.Body {
+			for _, item := range c.Consequent {
+				collectReturns(item, returns)
+			}
+		}
+	case *ast.TryStatement:
+		collectReturns(s.Body, returns)
+		if s.Catch != nil {
+			collectReturns(s.Catch.Body, returns)
+		}
+		if s.Finally != nil {
+			collectReturns(s.Finally, returns)
+		}
+	}
+}
+
+// checkShape checks the literal returned value, other expressions can not be checked statically
+func checkShape(shape returnShape, value ast.Expression) (string, bool) {
+	switch v := value.(type) {
+	case *ast.ObjectLiteral:
+		if shape != returnMsg {
+			return SeverityError, false
+		}
+		for _, property := range v.Value {
+			switch p := property.(type) {
+			case *ast.PropertyShort:
+				if isMsgKey(string(p.Name.Name)) {
+					return "", true
+				}
+			case *ast.PropertyKeyed:
+				if key, ok := p.Key.(*ast.StringLiteral); !ok || p.Computed || isMsgKey(string(key.Value)) {
+					return "", true
+				}
+			default:
+				return "", true
+			}
+		}
+		// a map is accepted, but the message is not changed
+		return SeverityWarning, false
+	case *ast.ArrayLiteral:
+		return SeverityError, shape == returnArray
+	case *ast.BooleanLiteral:
+		return SeverityError, shape == returnBool
+	case *ast.StringLiteral, *ast.TemplateLiteral, *ast.NumberLiteral, *ast.NullLiteral, *ast.RegExpLiteral:
+		return SeverityError, false
+	}
+	return "", true
+}
+
+func isMsgKey(key string) bool {
+	return key == types.MsgKey || key == types.MetadataKey || key == types.MsgTypeKey || key == types.DataTypeKey
+}
+
+func shapeDescription(shape returnShape) string {
+	switch shape {
+	case returnBool:
+		return "the script must return a boolean"
+	case returnArray:
+		return "the script must return an array of relation types"
+	case returnMsg:
+		return "the script must return an object with msg, metadata, msgType or dataType"
+	}
+	return "the script must return a value"
+}
+
+// wrappedColumn converts the column of the wrapped script to the column of the source
+func wrappedColumn(line, column int, prefix string) int {
+	if line == 1 && column > len(prefix) {
+		return column - len(prefix)
+	}
+	return column
+}
+
+// exprError returns the 1-based position and the message of the expr error
+func exprError(err error) (int, int, string) {
+	var fileErr *exprFile.Error
+	if errors.As(err, &fileErr) {
+		return fileErr.Line, fileErr.Column + 1, fileErr.Message
+	}
+	return 0, 0, err.Error()
+}
+
+// position returns the 1-based line and column of the byte offset
+func position(source string, offset int) (int, int) {
+	return runePosition([]rune(source[:offset]), len([]rune(source[:offset])))
+}
+
+// runePosition returns the 1-based line and column of the rune offset
+func runePosition(source []rune, offset int) (int, int) {
+	line, column := 1, 1
+	for i := 0; i < offset && i < len(source); i++ {
+		if source[i] == '\n' {
+			line++
+			column = 1
+		} else {
+			column++
+		}
+	}
+	return line, column
+}
+
+// varKeys returns the keys of the rule chain vars
+func varKeys(vars interface{}) map[string]bool {
+	keys := make(map[string]bool)
+	switch v := vars.(type) {
+	case map[string]interface{}:
+		for k := range v {
+			keys[k] = true
+		}
+	case map[string]string:
+		for k := range v {
+			keys[k] = true
+		}
+	}
+	return keys
+}
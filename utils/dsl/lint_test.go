@@ -0,0 +1,188 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dsl
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/yunboom/rulego/api/types"
+	"github.com/yunboom/rulego/test/assert"
+)
+
+func lintChain(nodes ...*types.RuleNode) types.RuleChain {
+	return types.RuleChain{
+		RuleChain: types.RuleChainBaseInfo{
+			Configuration: types.Configuration{
+				types.Vars: map[string]interface{}{"threshold": 10},
+			},
+		},
+		Metadata: types.RuleMetadata{Nodes: nodes},
+	}
+}
+
+func TestLintValidChain(t *testing.T) {
+	config := types.NewConfig()
+	config.Properties.PutValue("url", "http://127.0.0.1")
+	def := lintChain(
+		&types.RuleNode{Id: "s1", Type: "jsFilter", Configuration: types.Configuration{
+			"jsScript": "if (msg.temperature > vars.threshold) {\n  return true;\n}\nreturn false;",
+		}},
+		&types.RuleNode{Id: "s2", Type: "jsTransform", Configuration: types.Configuration{
+			"jsScript": "function f() { return 1 }\nmsg.v = f();\nreturn {'msg':msg,'metadata':metadata,'msgType':msgType};",
+		}},
+		&types.RuleNode{Id: "s3", Type: "jsSwitch", Configuration: types.Configuration{
+			"jsScript": "switch (msgType) { case 'a': return ['a']; default: return ['b'] }",
+		}},
+		&types.RuleNode{Id: "s4", Type: "jsFilter", Configuration: types.Configuration{
+			"scriptType": types.Lua,
+			"jsScript":   "return msg.temperature > 10",
+		}},
+		&types.RuleNode{Id: "s5", Type: "exprFilter", Configuration: types.Configuration{
+			"expr": "let t = ${vars.threshold}; msg.temperature > t && upper(msgType) == 'A' && len(metadata) > 0",
+		}},
+		&types.RuleNode{Id: "s6", Type: "restApiCall", Configuration: types.Configuration{
+			"restEndpointUrlPattern": "${global.url}/api/${metadata.deviceId}",
+		}},
+	)
+	issues := Lint(config, def)
+	assert.Equal(t, 0, len(issues), issues.Error())
+	assert.False(t, issues.HasError())
+}
+
+func TestLintScripts(t *testing.T) {
+	def := lintChain(
+		&types.RuleNode{Id: "s1", Type: "jsTransform", Configuration: types.Configuration{
+			"jsScript": "msg.a = 1;\nreturn {'msg':msg;",
+		}},
+		&types.RuleNode{Id: "s2", Type: "jsFilter", Configuration: types.Configuration{
+			"jsScript": "var a = vars.unknown + global.host;\nif (a) {\n  return {'a': a};\n}\nreturn true;",
+		}},
+		&types.RuleNode{Id: "s3", Type: "jsTransform", Configuration: types.Configuration{
+			"jsScript": "msg.a = 1;",
+		}},
+		&types.RuleNode{Id: "s4", Type: "jsSwitch", Configuration: types.Configuration{
+			"jsScript": "return 'a';",
+		}},
+		&types.RuleNode{Id: "s5", Type: "jsTransform", Configuration: types.Configuration{
+			"jsScript": "return {'a': msg};",
+		}},
+		&types.RuleNode{Id: "s6", Type: "jsFilter", Configuration: types.Configuration{
+			"scriptType": types.Lua,
+			"jsScript":   "return msg.temperature >",
+		}},
+	)
+	issues := Lint(types.NewConfig(), def)
+	assert.True(t, issues.HasError())
+	assert.Equal(t, []Issue{
+		{NodeId: "s1", Field: "jsScript", Line: 2, Column: 18, Severity: SeverityError, Message: "Unexpected token ;"},
+		{NodeId: "s2", Field: "jsScript", Line: 1, Column: 9, Severity: SeverityWarning, Message: "undefined rule chain variable: vars.unknown"},
+		{NodeId: "s2", Field: "jsScript", Line: 1, Column: 24, Severity: SeverityWarning, Message: "undefined global property: global.host"},
+		{NodeId: "s2", Field: "jsScript", Line: 3, Column: 3, Severity: SeverityError, Message: "invalid return value, the script must return a boolean"},
+		{NodeId: "s3", Field: "jsScript", Severity: SeverityError, Message: "the script never returns a value, the script must return an object with msg, metadata, msgType or dataType"},
+		{NodeId: "s4", Field: "jsScript", Line: 1, Column: 1, Severity: SeverityError, Message: "invalid return value, the script must return an array of relation types"},
+		{NodeId: "s5", Field: "jsScript", Line: 1, Column: 1, Severity: SeverityWarning, Message: "invalid return value, the script must return an object with msg, metadata, msgType or dataType"},
+	}, []Issue(issues[:len(issues)-1]))
+	lua := issues[len(issues)-1]
+	assert.Equal(t, "s6", lua.NodeId)
+	assert.Equal(t, SeverityError, lua.Severity)
+	assert.Equal(t, 1, lua.Line)
+}
+
+func TestLintExpressions(t *testing.T) {
+	def := lintChain(
+		&types.RuleNode{Id: "s1", Type: "exprFilter", Configuration: types.Configuration{
+			"expr": "msg.temperature >",
+		}},
+		&types.RuleNode{Id: "s1_1", Type: "exprFilter", Configuration: types.Configuration{
+			"expr": "msg.temperature > ${vars.max}",
+		}},
+		&types.RuleNode{Id: "s2", Type: "exprFilter", Configuration: types.Configuration{
+			"expr": "msg.temperature > ${vars.threshold} &&\n temperature > vars.threshold",
+		}},
+		&types.RuleNode{Id: "s3", Type: "exprTransform", Configuration: types.Configuration{
+			"mapping": map[string]interface{}{
+				"name": "upper(msg.name)",
+				"tmp":  "msg.temperature + ",
+			},
+		}},
+		&types.RuleNode{Id: "s4", Type: "restApiCall", Configuration: types.Configuration{
+			"restEndpointUrlPattern": "http://${global.host}/api/${metadata.}",
+		}},
+	)
+	issues := Lint(types.NewConfig(), def)
+	assert.True(t, issues.HasError())
+	assert.Equal(t, 7, len(issues), issues.Error())
+
+	assert.Equal(t, Issue{NodeId: "s1", Field: "expr", Line: 1, Column: 17, Severity: SeverityError, Message: "unexpected token EOF"}, issues[0])
+	assert.Equal(t, Issue{NodeId: "s1_1", Field: "expr", Line: 1, Column: 21, Severity: SeverityWarning, Message: "undefined rule chain variable: vars.max"}, issues[1])
+	assert.Equal(t, Issue{NodeId: "s2", Field: "expr", Line: 2, Column: 2, Severity: SeverityWarning, Message: "undefined variable: temperature"}, issues[2])
+	assert.Equal(t, Issue{NodeId: "s2", Field: "expr", Line: 2, Column: 16, Severity: SeverityWarning, Message: "vars is not available in expressions, use ${vars.xx} instead"}, issues[3])
+	assert.Equal(t, "s3", issues[4].NodeId)
+	assert.Equal(t, "mapping.tmp", issues[4].Field)
+	assert.Equal(t, Issue{NodeId: "s4", Field: "restEndpointUrlPattern", Line: 1, Column: 10, Severity: SeverityWarning, Message: "undefined global property: global.host"}, issues[5])
+	assert.Equal(t, SeverityError, issues[6].Severity)
+	assert.Equal(t, 1, issues[6].Line)
+	assert.Equal(t, 37, issues[6].Column)
+}
+
+func TestLintQueries(t *testing.T) {
+	def := lintChain(
+		&types.RuleNode{Id: "s1", Type: "jsonPathFilter", Configuration: types.Configuration{
+			"query": "msg.items[?status=='error']",
+		}},
+		&types.RuleNode{Id: "s2", Type: "jsonPathFilter", Configuration: types.Configuration{
+			"query": "jq: msg.items[?",
+		}},
+		&types.RuleNode{Id: "s3", Type: "jsonPathTransform", Configuration: types.Configuration{
+			"mapping": map[string]interface{}{
+				"names": "msg.items[*].name",
+				"bad":   "msg.items[",
+			},
+		}},
+		&types.RuleNode{Id: "s4", Type: "restApiCall", Configuration: types.Configuration{
+			"restEndpointUrlPattern": "http://host/${jq: msg.items[?name=='}'] | [0].id}/${jq: msg.&&}",
+		}},
+	)
+	issues := Lint(types.NewConfig(), def)
+	assert.Equal(t, 3, len(issues), issues.Error())
+	assert.Equal(t, "s2", issues[0].NodeId)
+	assert.Equal(t, 1, issues[0].Line)
+	assert.Equal(t, 16, issues[0].Column)
+	assert.Equal(t, "mapping.bad", issues[1].Field)
+	assert.Equal(t, "s4", issues[2].NodeId)
+	assert.Equal(t, 53, issues[2].Column)
+	assert.True(t, strings.HasPrefix(issues[2].Message, "invalid template: SyntaxError"))
+}
+
+func TestLintExprTypes(t *testing.T) {
+	def := lintChain(
+		&types.RuleNode{Id: "s1", Type: "exprFilter", Configuration: types.Configuration{
+			"expr": "msg.temperature > 'a'",
+		}},
+		&types.RuleNode{Id: "s2", Type: "exprFilter", Configuration: types.Configuration{
+			"expr": "isHot(msg.temperature)",
+		}},
+	)
+	def.RuleChain.Configuration[types.ExprTypes] = map[string]interface{}{"msg.temperature": "float"}
+	config := types.NewConfig()
+	config.RegisterUdf("isHot", func(v float64) bool { return v > 50 })
+	issues := Lint(config, def)
+	assert.Equal(t, 1, len(issues), issues.Error())
+	assert.Equal(t, "s1", issues[0].NodeId)
+	assert.Equal(t, 17, issues[0].Column)
+}
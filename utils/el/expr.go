@@ -0,0 +1,300 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package el
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/expr-lang/expr"
+	"github.com/expr-lang/expr/builtin"
+	"github.com/expr-lang/expr/file"
+	"github.com/expr-lang/expr/vm"
+	"github.com/yunboom/rulego/api/types"
+	"github.com/yunboom/rulego/builtin/funcs"
+	"github.com/yunboom/rulego/utils/str"
+)
+
+// Type hint names, see CompileExpr
+// 类型提示名称，参考 CompileExpr
+const (
+	TypeString = "string"
+	TypeInt    = "int"
+	TypeFloat  = "float"
+	TypeBool   = "bool"
+	TypeArray  = "array"
+	TypeMap    = "map"
+	TypeAny    = "any"
+)
+
+var errorType = reflect.TypeOf((*error)(nil)).Elem()
+
+// structTypeRegex matches the struct types generated by TypedEnv
+var structTypeRegex = regexp.MustCompile(`(type )?struct \{[^{}]*\}`)
+
+// CompileExpr compiles the expression with expr.AllowUndefinedVariables. The expression can call
+// the functions registered in funcs.ExprFunc and the Go functions in udf (types.Config.Udf).
+// The parameter and result types of the functions are checked at compile time.
+//
+// typeHints are optional type hints of the variables, the key is the variable path and the value is the type name
+// (string, int, float, bool, array, map, any), e.g. {"msg.temperature": "float", "metadata.deviceId": "string"}.
+// If typeHints is not empty, the expression is type checked against the hints first. A variable with hinted
+// fields is checked as an object with only these fields, declare it as map to allow other fields.
+// The returned program is compiled without the hints, so the runtime behavior is not changed.
+//
+// CompileExpr 使用 expr.AllowUndefinedVariables 编译表达式。表达式可以调用 funcs.ExprFunc 注册的函数
+// 和 udf(types.Config.Udf) 中的Go函数，编译时会检查函数的参数和返回值类型。
+//
+// typeHints 是可选的变量类型提示，key为变量路径，value为类型名称(string、int、float、bool、array、map、any)，
+// 例如：{"msg.temperature": "float", "metadata.deviceId": "string"}。如果typeHints不为空，先按照类型提示进行类型检查。
+// 声明了字段类型的变量按照只包含这些字段的对象检查，如果需要访问其他字段，把该变量声明为map。
+// 返回的程序不使用类型提示编译，不改变运行时行为。
+func CompileExpr(source string, udf map[string]interface{}, typeHints map[string]string, opts ...expr.Option) (*vm.Program, error) {
+	functions, err := ExprFunctions(udf)
+	if err != nil {
+		return nil, err
+	}
+	options := append([]expr.Option{expr.AllowUndefinedVariables()}, functions...)
+	options = append(options, opts...)
+	if len(typeHints) > 0 {
+		env, err := TypedEnv(typeHints)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := expr.Compile(source, append([]expr.Option{expr.Env(env)}, options...)...); err != nil {
+			var fileErr *file.Error
+			if errors.As(err, &fileErr) {
+				// the hinted objects are checked as the generated struct types, replace the nested types from the innermost
+				for structTypeRegex.MatchString(fileErr.Message) {
+					fileErr.Message = structTypeRegex.ReplaceAllString(fileErr.Message, "object")
+				}
+			}
+			return nil, err
+		}
+	}
+	return expr.Compile(source, options...)
+}
+
+// ExprFunctions returns the expr options of the functions registered in funcs.ExprFunc and the Go functions in udf.
+// Script UDFs are ignored. A Go function of udf with the same name as a function of funcs.ExprFunc or
+// a function provided by expr itself returns an error instead of replacing it.
+// ExprFunctions 返回 funcs.ExprFunc 注册的函数和 udf 中Go函数的expr选项，忽略脚本函数。
+// udf 中的Go函数与 funcs.ExprFunc 的函数或者expr自带的函数同名时返回错误，不会替换该函数。
+func ExprFunctions(udf map[string]interface{}) ([]expr.Option, error) {
+	functions := funcs.ExprFunc.GetAll()
+	for name, fn := range udf {
+		if _, ok := fn.(types.Script); ok || strings.Contains(name, types.ScriptFuncSeparator) {
+			continue
+		}
+		_, exprFunc := functions[name]
+		if _, ok := builtin.Index[name]; ok || exprFunc {
+			return nil, fmt.Errorf("udf %s conflicts with the built-in expr function", name)
+		}
+		functions[name] = fn
+	}
+	var options []expr.Option
+	for name, fn := range functions {
+		if option, ok := exprFunction(name, fn); ok {
+			options = append(options, option)
+		}
+	}
+	return options, nil
+}
+
+// exprFunction converts the Go function to the expr function, the function type is used as the type signature
+func exprFunction(name string, fn interface{}) (expr.Option, bool) {
+	if f, ok := fn.(func(params ...any) (any, error)); ok {
+		return expr.Function(name, f), true
+	}
+	v := reflect.ValueOf(fn)
+	if v.Kind() != reflect.Func {
+		return nil, false
+	}
+	t := v.Type()
+	if t.NumOut() > 2 || (t.NumOut() == 2 && t.Out(1) != errorType) {
+		return nil, false
+	}
+	return expr.Function(name, func(params ...any) (any, error) {
+		args, err := convertArgs(t, params)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		out := v.Call(args)
+		switch {
+		case len(out) == 0:
+			return nil, nil
+		case len(out) == 1 && t.Out(0) == errorType:
+			err, _ := out[0].Interface().(error)
+			return nil, err
+		case len(out) == 2:
+			err, _ := out[1].Interface().(error)
+			return out[0].Interface(), err
+		}
+		return out[0].Interface(), nil
+	}, fn), true
+}
+
+// convertArgs converts the expr values to the parameter types of the function
+func convertArgs(t reflect.Type, params []any) ([]reflect.Value, error) {
+	numIn := t.NumIn()
+	if (!t.IsVariadic() && len(params) != numIn) || (t.IsVariadic() && len(params) < numIn-1) {
+		return nil, fmt.Errorf("expected %d arguments, got %d", numIn, len(params))
+	}
+	args := make([]reflect.Value, len(params))
+	for i, param := range params {
+		var paramType reflect.Type
+		if t.IsVariadic() && i >= numIn-1 {
+			paramType = t.In(numIn - 1).Elem()
+		} else {
+			paramType = t.In(i)
+		}
+		arg, err := convertArg(param, paramType)
+		if err != nil {
+			return nil, fmt.Errorf("argument %d: %w", i+1, err)
+		}
+		args[i] = arg
+	}
+	return args, nil
+}
+
+func convertArg(param any, t reflect.Type) (reflect.Value, error) {
+	if param == nil {
+		return reflect.Zero(t), nil
+	}
+	v := reflect.ValueOf(param)
+	switch {
+	case v.Type().AssignableTo(t):
+		return v, nil
+	case isNumber(v.Kind()) && isNumber(t.Kind()):
+		return v.Convert(t), nil
+	case t.Kind() == reflect.String:
+		return reflect.ValueOf(str.ToString(param)).Convert(t), nil
+	case t.Kind() == reflect.Slice && (v.Kind() == reflect.Slice || v.Kind() == reflect.Array):
+		slice := reflect.MakeSlice(t, v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			item, err := convertArg(v.Index(i).Interface(), t.Elem())
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			slice.Index(i).Set(item)
+		}
+		return slice, nil
+	}
+	return reflect.Value{}, fmt.Errorf("cannot use %T as %s", param, t)
+}
+
+func isNumber(kind reflect.Kind) bool {
+	return kind >= reflect.Int && kind <= reflect.Float64
+}
+
+// TypedEnv creates the typed expr environment from the type hints, see CompileExpr.
+// TypedEnv 根据类型提示创建带类型的expr环境，参考 CompileExpr。
+func TypedEnv(typeHints map[string]string) (map[string]interface{}, error) {
+	// 按路径排序，冲突总是在更长的路径上报告
+	paths := make([]string, 0, len(typeHints))
+	for path := range typeHints {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	root := &typeHintNode{}
+	for _, path := range paths {
+		if err := root.add(strings.Split(path, "."), path, typeHints[path]); err != nil {
+			return nil, err
+		}
+	}
+	env := make(map[string]interface{}, len(root.fields))
+	for name, field := range root.fields {
+		t, err := field.reflectType()
+		if err != nil {
+			return nil, err
+		}
+		env[name] = reflect.Zero(t).Interface()
+	}
+	return env, nil
+}
+
+// typeHintNode the type of a variable, either a named type or an object with fields
+type typeHintNode struct {
+	typeName string
+	fields   map[string]*typeHintNode
+}
+
+func (n *typeHintNode) add(keys []string, path, typeName string) error {
+	if n.typeName != "" {
+		return fmt.Errorf("conflicting type hint %s", path)
+	}
+	if n.fields == nil {
+		n.fields = make(map[string]*typeHintNode)
+	}
+	child, ok := n.fields[keys[0]]
+	if !ok {
+		child = &typeHintNode{}
+		n.fields[keys[0]] = child
+	}
+	if len(keys) > 1 {
+		return child.add(keys[1:], path, typeName)
+	}
+	if child.typeName != "" || child.fields != nil {
+		return fmt.Errorf("conflicting type hint %s", path)
+	}
+	child.typeName = typeName
+	return nil
+}
+
+func (n *typeHintNode) reflectType() (reflect.Type, error) {
+	if n.fields == nil {
+		switch n.typeName {
+		case TypeString:
+			return reflect.TypeOf(""), nil
+		case TypeInt:
+			return reflect.TypeOf(0), nil
+		case TypeFloat:
+			return reflect.TypeOf(float64(0)), nil
+		case TypeBool:
+			return reflect.TypeOf(false), nil
+		case TypeArray:
+			return reflect.TypeOf([]interface{}{}), nil
+		case TypeMap:
+			return reflect.TypeOf(map[string]interface{}{}), nil
+		case TypeAny:
+			return reflect.TypeOf((*interface{})(nil)).Elem(), nil
+		}
+		return nil, fmt.Errorf("unsupported type hint: %s", n.typeName)
+	}
+	names := make([]string, 0, len(n.fields))
+	for name := range n.fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	structFields := make([]reflect.StructField, len(names))
+	for i, name := range names {
+		t, err := n.fields[name].reflectType()
+		if err != nil {
+			return nil, err
+		}
+		structFields[i] = reflect.StructField{
+			Name: fmt.Sprintf("F%d", i),
+			Type: t,
+			Tag:  reflect.StructTag(fmt.Sprintf(`expr:"%s"`, name)),
+		}
+	}
+	return reflect.StructOf(structFields), nil
+}
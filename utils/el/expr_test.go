@@ -0,0 +1,117 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package el
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/expr-lang/expr/vm"
+	"github.com/yunboom/rulego/api/types"
+	"github.com/yunboom/rulego/test/assert"
+)
+
+func TestCompileExpr(t *testing.T) {
+	udf := map[string]interface{}{
+		"double": func(v int) int { return v * 2 },
+		"check": func(v string) error {
+			if v == "" {
+				return errors.New("empty")
+			}
+			return nil
+		},
+		"join2":   func(sep string, items ...string) string { return strings.Join(items, sep) },
+		"Js#skip": func() int { return 1 },
+		"script":  types.Script{Type: types.AllScript, Content: "function script() {}"},
+	}
+	env := map[string]interface{}{
+		"msg": map[string]interface{}{"a": 2.0, "name": "hello", "temperature": 10, "tags": []interface{}{"x", "y"}},
+	}
+	run := func(source string) (interface{}, error) {
+		program, err := CompileExpr(source, udf, nil)
+		if err != nil {
+			return nil, err
+		}
+		return vm.Run(program, env)
+	}
+
+	out, err := run("double(msg.a) + 1")
+	assert.Nil(t, err)
+	assert.Equal(t, 5, out)
+	out, err = run("join2('-', msg.name, 'b', msg.temperature)")
+	assert.Nil(t, err)
+	assert.Equal(t, "hello-b-10", out)
+	out, err = run("substring(msg.name, 1, 3) + padLeft('7', 3, '0')")
+	assert.Nil(t, err)
+	assert.Equal(t, "el007", out)
+	out, err = run("includes(msg.tags, 'y') && toFixed(pow(msg.a, 0.5), 2) == 1.41")
+	assert.Nil(t, err)
+	assert.Equal(t, true, out)
+	_, err = run("check('')")
+	assert.Equal(t, "empty", strings.Split(err.Error(), " (")[0])
+
+	// 编译时检查函数参数类型
+	_, err = run("double('a')")
+	assert.NotNil(t, err)
+	_, err = run("skip()")
+	assert.NotNil(t, err)
+	_, err = run("script()")
+	assert.NotNil(t, err)
+
+	// map、slice 元素
+	out, err = run("includes([{'a': 1}, [1, 2]], {'a': 1}) && includes([{'a': 1}, [1, 2]], [1, 2]) && !includes([{'a': 1}], {'a': 2})")
+	assert.Nil(t, err)
+	assert.Equal(t, true, out)
+
+	// 不能与内置函数同名
+	_, err = CompileExpr("1", map[string]interface{}{"includes": func(a, b interface{}) bool { return false }}, nil)
+	assert.NotNil(t, err)
+	_, err = CompileExpr("1", map[string]interface{}{"upper": func(s string) string { return s }}, nil)
+	assert.NotNil(t, err)
+}
+
+func TestCompileExprTypeHints(t *testing.T) {
+	hints := map[string]string{
+		"msg.temperature": TypeFloat,
+		"msg.name":        TypeString,
+		"msg.location.x":  TypeInt,
+		"metadata":        TypeMap,
+	}
+	program, err := CompileExpr("msg.temperature > 10 && msg.location.x > 0 && metadata.deviceId == 'a' && msgType == 'A'", nil, hints)
+	assert.Nil(t, err)
+	// 运行时不使用类型提示
+	out, err := vm.Run(program, map[string]interface{}{
+		"msg":      map[string]interface{}{"temperature": 20, "location": map[string]interface{}{"x": 1}},
+		"metadata": map[string]string{"deviceId": "a"},
+		"msgType":  "A",
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, true, out)
+
+	_, err = CompileExpr("msg.temperature > 'a'", nil, hints)
+	assert.NotNil(t, err)
+	_, err = CompileExpr("upper(msg.temperature)", nil, hints)
+	assert.NotNil(t, err)
+	_, err = CompileExpr("msg.humidity > 1", nil, hints)
+	assert.True(t, strings.HasPrefix(err.Error(), "object has no field humidity"))
+
+	_, err = CompileExpr("msg.a", nil, map[string]string{"msg.a": "unknown"})
+	assert.Equal(t, "unsupported type hint: unknown", err.Error())
+	_, err = CompileExpr("msg.a", nil, map[string]string{"msg": TypeMap, "msg.a": TypeInt})
+	assert.Equal(t, "conflicting type hint msg.a", err.Error())
+}
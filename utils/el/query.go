@@ -0,0 +1,252 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package el
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/jmespath/go-jmespath"
+	"github.com/yunboom/rulego/utils/json"
+)
+
+// QueryPrefix the prefix of the JMESPath query, e.g. ${jq: msg.items[?status=='error'].name}
+// QueryPrefix JMESPath 查询前缀，例如：${jq: msg.items[?status=='error'].name}
+const QueryPrefix = "jq:"
+
+// queryCache compiled queries, the key is the query string
+var queryCache sync.Map
+
+// IsQuery reports whether the expression is a JMESPath query with QueryPrefix.
+// IsQuery 判断表达式是否是带 QueryPrefix 前缀的 JMESPath 查询。
+func IsQuery(s string) bool {
+	return strings.HasPrefix(strings.TrimSpace(s), QueryPrefix)
+}
+
+// TrimQueryPrefix removes QueryPrefix and the spaces around the query.
+// TrimQueryPrefix 去掉 QueryPrefix 前缀和查询两边的空格。
+func TrimQueryPrefix(s string) string {
+	return strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(s), QueryPrefix))
+}
+
+// CompileQuery compiles the JMESPath query, QueryPrefix is optional. The compiled queries are cached.
+// CompileQuery 编译 JMESPath 查询，QueryPrefix 前缀可选。编译后的查询会被缓存。
+//
+// Query examples:
+// 查询示例：
+//
+//	msg.items[?status=='error']            // filter  过滤
+//	msg.items[0:2]                         // slice  切片
+//	msg.items[*].name                      // projection  投影
+//	msg.items[?price > `10`] | length(@)   // functions  函数
+//	{name: msg.name, type: msgType}        // multiselect  多选
+func CompileQuery(query string) (*jmespath.JMESPath, error) {
+	query = TrimQueryPrefix(query)
+	if v, ok := queryCache.Load(query); ok {
+		return v.(*jmespath.JMESPath), nil
+	}
+	compiled, err := jmespath.Compile(query)
+	if err != nil {
+		return nil, err
+	}
+	queryCache.Store(query, compiled)
+	return compiled, nil
+}
+
+// Query evaluates the JMESPath query on the data.
+// Query 在数据上执行 JMESPath 查询。
+func Query(query string, data interface{}) (interface{}, error) {
+	compiled, err := CompileQuery(query)
+	if err != nil {
+		return nil, err
+	}
+	return Search(compiled, data)
+}
+
+// Search evaluates the compiled query on the data. The data is converted to JSON types first,
+// e.g. integers to float64, so that it can be compared with the JSON literals of the query.
+// Search 在数据上执行编译后的查询。数据先转换成JSON类型，例如整数转换成float64，以便和查询中的JSON字面量比较。
+func Search(compiled *jmespath.JMESPath, data interface{}) (interface{}, error) {
+	return compiled.Search(toJsonValue(data))
+}
+
+// Truthy reports whether the query result is true according to the JMESPath truthiness:
+// false, null, empty string, empty array and empty object are false, the other values are true.
+// Truthy 按照 JMESPath 的真值规则判断查询结果：false、null、空字符串、空数组和空对象为假，其他值为真。
+func Truthy(v interface{}) bool {
+	switch t := v.(type) {
+	case nil:
+		return false
+	case bool:
+		return t
+	case string:
+		return t != ""
+	case []interface{}:
+		return len(t) > 0
+	case map[string]interface{}:
+		return len(t) > 0
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Map, reflect.Array:
+		return rv.Len() > 0
+	}
+	return true
+}
+
+// toJsonValue converts the value to the types of the decoded JSON
+func toJsonValue(v interface{}) interface{} {
+	switch t := v.(type) {
+	case nil, string, bool, float64:
+		return v
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(t))
+		for k, item := range t {
+			result[k] = toJsonValue(item)
+		}
+		return result
+	case []interface{}:
+		result := make([]interface{}, len(t))
+		for i, item := range t {
+			result[i] = toJsonValue(item)
+		}
+		return result
+	case map[string]string:
+		result := make(map[string]interface{}, len(t))
+		for k, item := range t {
+			result[k] = item
+		}
+		return result
+	case []string:
+		result := make([]interface{}, len(t))
+		for i, item := range t {
+			result[i] = item
+		}
+		return result
+	case []byte:
+		return string(t)
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint())
+	case reflect.Float32:
+		return rv.Float()
+	}
+	// other types, e.g. struct, are converted by JSON
+	var result interface{}
+	if b, err := json.Marshal(v); err == nil && json.Unmarshal(b, &result) == nil {
+		return result
+	}
+	return v
+}
+
+// QueryTemplate the template of a JMESPath query, e.g. ${jq: msg.items[?status=='error']}, the result keeps its type
+// QueryTemplate JMESPath 查询模板，例如：${jq: msg.items[?status=='error']}，结果保留原类型
+type QueryTemplate struct {
+	Tmpl  string
+	query *jmespath.JMESPath
+}
+
+// NewQueryTemplate creates the query template, the template can be ${jq: query} or jq: query.
+// NewQueryTemplate 创建查询模板，模板格式为 ${jq: query} 或者 jq: query。
+func NewQueryTemplate(tmpl string) (*QueryTemplate, error) {
+	t := &QueryTemplate{Tmpl: tmpl}
+	if err := t.Parse(); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+func (t *QueryTemplate) Parse() error {
+	query := strings.TrimSpace(t.Tmpl)
+	if strings.HasPrefix(query, "${") && strings.HasSuffix(query, "}") {
+		query = query[2 : len(query)-1]
+	}
+	compiled, err := CompileQuery(query)
+	if err != nil {
+		return err
+	}
+	t.query = compiled
+	return nil
+}
+
+func (t *QueryTemplate) Execute(data map[string]any) (interface{}, error) {
+	return Search(t.query, data)
+}
+
+func (t *QueryTemplate) ExecuteFn(loadDataFunc func() map[string]any) (interface{}, error) {
+	var data map[string]any
+	if loadDataFunc != nil {
+		data = loadDataFunc()
+	}
+	return t.Execute(data)
+}
+
+func (t *QueryTemplate) IsNotVar() bool {
+	return false
+}
+
+func (t *QueryTemplate) HasVar() bool {
+	return true
+}
+
+// hasQuery reports whether the template has a ${jq: ...} placeholder
+func hasQuery(tmpl string) bool {
+	for i := strings.Index(tmpl, "${"); i != -1; {
+		if IsQuery(tmpl[i+2:]) {
+			return true
+		}
+		next := strings.Index(tmpl[i+2:], "${")
+		if next == -1 {
+			break
+		}
+		i += next + 2
+	}
+	return false
+}
+
+// QueryEnd returns the index of the closing brace of the ${jq: ...} placeholder, the braces and
+// the quoted strings of the query are skipped. start is the index after ${, -1 if not found.
+// QueryEnd 返回 ${jq: ...} 占位符结束括号的位置，跳过查询中的括号和字符串。start 为 ${ 之后的位置，没有找到返回-1。
+func QueryEnd(tmpl string, start int) int {
+	depth := 0
+	var quote byte
+	for i := start; i < len(tmpl); i++ {
+		c := tmpl[i]
+		switch {
+		case quote != 0:
+			if c == '\\' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+		case c == '{' || c == '[' || c == '(':
+			depth++
+		case c == '}' && depth == 0:
+			return i
+		case c == '}' || c == ']' || c == ')':
+			depth--
+		}
+	}
+	return -1
+}
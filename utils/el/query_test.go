@@ -0,0 +1,101 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package el
+
+import (
+	"testing"
+
+	"github.com/yunboom/rulego/test/assert"
+)
+
+func TestQuery(t *testing.T) {
+	data := map[string]interface{}{
+		"msg": map[string]interface{}{
+			"items": []interface{}{
+				map[string]interface{}{"name": "a", "status": "ok", "price": 5},
+				map[string]interface{}{"name": "b", "status": "error", "price": 20},
+				map[string]interface{}{"name": "c", "status": "error", "price": 30},
+			},
+		},
+		"metadata": map[string]string{"deviceId": "d1"},
+	}
+	out, err := Query("jq: msg.items[?status=='error'].name", data)
+	assert.Nil(t, err)
+	assert.Equal(t, []interface{}{"b", "c"}, out)
+	out, err = Query("msg.items[?price > `10`] | length(@)", data)
+	assert.Nil(t, err)
+	assert.Equal(t, float64(2), out)
+	out, err = Query("msg.items[0:2].name", data)
+	assert.Nil(t, err)
+	assert.Equal(t, []interface{}{"a", "b"}, out)
+	out, err = Query("metadata.deviceId", data)
+	assert.Nil(t, err)
+	assert.Equal(t, "d1", out)
+
+	_, err = Query("msg.items[?", data)
+	assert.NotNil(t, err)
+
+	// 缓存
+	q1, _ := CompileQuery("jq: msg.items")
+	q2, _ := CompileQuery("msg.items ")
+	assert.True(t, q1 == q2)
+
+	assert.False(t, Truthy(nil))
+	assert.False(t, Truthy(""))
+	assert.False(t, Truthy([]interface{}{}))
+	assert.False(t, Truthy(map[string]interface{}{}))
+	assert.False(t, Truthy(false))
+	assert.True(t, Truthy(float64(0)))
+	assert.True(t, Truthy([]interface{}{"a"}))
+}
+
+func TestQueryTemplate(t *testing.T) {
+	data := map[string]interface{}{
+		"msg": map[string]interface{}{
+			"items": []interface{}{
+				map[string]interface{}{"name": "a", "status": "ok"},
+				map[string]interface{}{"name": "b", "status": "error"},
+			},
+		},
+		"metadata": map[string]string{"deviceId": "d1"},
+	}
+	tmpl, err := NewTemplate("${jq: msg.items[?status=='error'] | [0]}")
+	assert.Nil(t, err)
+	_, ok := tmpl.(*QueryTemplate)
+	assert.True(t, ok)
+	out, err := tmpl.Execute(data)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]interface{}{"name": "b", "status": "error"}, out)
+
+	tmpl, err = NewTemplate("${jq: {device: metadata.deviceId}}")
+	assert.Nil(t, err)
+	out, err = tmpl.Execute(data)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]interface{}{"device": "d1"}, out)
+
+	// 混合模板，查询中的括号和字符串中的 } 不作为结束符
+	tmpl, err = NewTemplate("${metadata.deviceId}/${jq: msg.items[?name=='}'] | length(@)}/${jq: msg.items[-1].name}/${metadata.deviceId}")
+	assert.Nil(t, err)
+	_, ok = tmpl.(*MixedTemplate)
+	assert.True(t, ok)
+	out, err = tmpl.Execute(data)
+	assert.Nil(t, err)
+	assert.Equal(t, "d1/0/b/d1", out)
+
+	_, err = NewTemplate("${jq: msg.items[}")
+	assert.NotNil(t, err)
+}
@@ -1,8 +1,9 @@
 package el
 
 import (
-	"github.com/expr-lang/expr"
+	"fmt"
 	"github.com/expr-lang/expr/vm"
+	"github.com/jmespath/go-jmespath"
 	"github.com/yunboom/rulego/utils/str"
 	"regexp"
 	"strings"
@@ -18,13 +19,59 @@ type Template interface {
 	HasVar() bool
 }
 
+// TemplateOption 模板选项，用于 NewTemplate、NewExprTemplate 和 NewMixedTemplate
+type TemplateOption func(*templateOptions)
+
+// templateOptions 编译模板中expr表达式的选项，参考 CompileExpr
+type templateOptions struct {
+	udf       map[string]interface{}
+	typeHints map[string]string
+}
+
+// WithUdf 模板中的expr表达式可以调用 udf 中的Go函数，一般使用 types.Config.Udf
+func WithUdf(udf map[string]interface{}) TemplateOption {
+	return func(o *templateOptions) {
+		o.udf = udf
+	}
+}
+
+// WithTypeHints 模板中的expr表达式按照类型提示进行类型检查，参考 CompileExpr
+func WithTypeHints(typeHints map[string]string) TemplateOption {
+	return func(o *templateOptions) {
+		o.typeHints = typeHints
+	}
+}
+
+func newTemplateOptions(opts []TemplateOption) templateOptions {
+	var o templateOptions
+	for _, opt := range opts {
+		if opt != nil {
+			opt(&o)
+		}
+	}
+	return o
+}
+
+// NewTemplate 创建模板，params 可以是 TemplateOption
 func NewTemplate(tmpl any, params ...any) (Template, error) {
+	var opts []TemplateOption
+	for _, p := range params {
+		if opt, ok := p.(TemplateOption); ok {
+			opts = append(opts, opt)
+		}
+	}
 	if v, ok := tmpl.(string); ok {
 		trimV := strings.TrimSpace(v)
 		if strings.HasPrefix(trimV, str.VarPrefix) && strings.HasSuffix(trimV, str.VarSuffix) {
-			return NewExprTemplate(v)
+			if IsQuery(trimV[len(str.VarPrefix):]) && QueryEnd(trimV, len(str.VarPrefix)) == len(trimV)-1 {
+				// 整个模板是一个JMESPath查询，结果保留原类型
+				return NewQueryTemplate(trimV)
+			} else if hasQuery(v) {
+				return NewMixedTemplate(v, opts...)
+			}
+			return NewExprTemplate(v, opts...)
 		} else if str.CheckHasVar(v) {
-			return NewMixedTemplate(v)
+			return NewMixedTemplate(v, opts...)
 		} else {
 			return &NotTemplate{Tmpl: v}, nil
 		}
@@ -38,12 +85,13 @@ func NewTemplate(tmpl any, params ...any) (Template, error) {
 type ExprTemplate struct {
 	Tmpl    string
 	Program *vm.Program
+	options templateOptions
 }
 
 // 定义正则表达式，用于匹配形如 ${...} 的占位符
 var re = regexp.MustCompile(`\$\{([^}]*)\}`)
 
-func NewExprTemplate(tmpl string) (*ExprTemplate, error) {
+func NewExprTemplate(tmpl string, opts ...TemplateOption) (*ExprTemplate, error) {
 	// 使用字符串构建器来处理模板字符串
 	var sb strings.Builder
 	inQuotes := false // 标记是否在双引号内
@@ -83,7 +131,7 @@ func NewExprTemplate(tmpl string) (*ExprTemplate, error) {
 	tmpl = sb.String()
 
 	// 创建 ExprTemplate 实例
-	t := &ExprTemplate{Tmpl: tmpl}
+	t := &ExprTemplate{Tmpl: tmpl, options: newTemplateOptions(opts)}
 
 	// 调用 Parse 方法解析模板
 	if err := t.Parse(); err != nil {
@@ -93,7 +141,7 @@ func NewExprTemplate(tmpl string) (*ExprTemplate, error) {
 }
 
 func (t *ExprTemplate) Parse() error {
-	if program, err := expr.Compile(t.Tmpl, expr.AllowUndefinedVariables()); err != nil {
+	if program, err := CompileExpr(t.Tmpl, t.options.udf, t.options.typeHints); err != nil {
 		return err
 	} else {
 		t.Program = program
@@ -167,18 +215,24 @@ func (t *AnyTemplate) HasVar() bool {
 }
 
 // MixedTemplate 支持混合字符串和变量的模板，格式如 aa/${xxx}
+// 变量也可以是JMESPath查询，格式如 aa/${jq: msg.items[0].name}
 type MixedTemplate struct {
 	Tmpl      string
-	variables []struct {
-		start int
-		end   int
-		expr  *vm.Program
-	}
-	hasVars bool // 是否包含变量
+	variables []mixedVariable
+	hasVars   bool // 是否包含变量
+	options   templateOptions
 }
 
-func NewMixedTemplate(tmpl string) (*MixedTemplate, error) {
-	t := &MixedTemplate{Tmpl: tmpl}
+// mixedVariable 模板中的变量，使用expr表达式或者JMESPath查询计算
+type mixedVariable struct {
+	start int
+	end   int
+	expr  *vm.Program
+	query *jmespath.JMESPath
+}
+
+func NewMixedTemplate(tmpl string, opts ...TemplateOption) (*MixedTemplate, error) {
+	t := &MixedTemplate{Tmpl: tmpl, options: newTemplateOptions(opts)}
 	if err := t.Parse(); err != nil {
 		return nil, err
 	}
@@ -193,35 +247,39 @@ func (t *MixedTemplate) Parse() error {
 	}
 
 	t.hasVars = true
-	parts := strings.Split(t.Tmpl, "${")
-	if len(parts) == 1 {
-		return nil
-	}
-
-	for i := 1; i < len(parts); i++ {
-		part := parts[i]
-		end := strings.Index(part, "}")
-		if end == -1 {
-			continue
+	for pos := 0; ; {
+		idx := strings.Index(t.Tmpl[pos:], "${")
+		if idx == -1 {
+			return nil
 		}
-
-		varName := part[:end]
-		program, err := expr.Compile(varName, expr.AllowUndefinedVariables())
-		if err != nil {
-			return err
+		start := pos + idx
+		v := mixedVariable{start: start}
+		if IsQuery(t.Tmpl[start+2:]) {
+			// JMESPath查询可能包含{}，跳过括号和字符串查找结束位置
+			end := QueryEnd(t.Tmpl, start+2)
+			if end == -1 {
+				return fmt.Errorf("unclosed query: %s", t.Tmpl[start:])
+			}
+			query, err := CompileQuery(t.Tmpl[start+2 : end])
+			if err != nil {
+				return err
+			}
+			v.end, v.query = end+1, query
+		} else {
+			end := strings.Index(t.Tmpl[start+2:], "}")
+			if end == -1 {
+				return nil
+			}
+			end += start + 2
+			program, err := CompileExpr(t.Tmpl[start+2:end], t.options.udf, t.options.typeHints)
+			if err != nil {
+				return err
+			}
+			v.end, v.expr = end+1, program
 		}
-
-		t.variables = append(t.variables, struct {
-			start int
-			end   int
-			expr  *vm.Program
-		}{
-			start: strings.Index(t.Tmpl, "${"+varName+"}"),
-			end:   strings.Index(t.Tmpl, "${"+varName+"}") + len("${"+varName+"}"),
-			expr:  program,
-		})
+		t.variables = append(t.variables, v)
+		pos = v.end
 	}
-	return nil
 }
 
 func (t *MixedTemplate) Execute(data map[string]any) (interface{}, error) {
@@ -239,7 +297,13 @@ func (t *MixedTemplate) Execute(data map[string]any) (interface{}, error) {
 	vm := vm.VM{}
 	for _, v := range t.variables {
 		sb.WriteString(t.Tmpl[lastPos:v.start])
-		val, err := vm.Run(v.expr, data)
+		var val interface{}
+		var err error
+		if v.query != nil {
+			val, err = Search(v.query, data)
+		} else {
+			val, err = vm.Run(v.expr, data)
+		}
 		if err != nil {
 			return nil, err
 		}
@@ -25,6 +25,8 @@ import (
 
 	"github.com/expr-lang/expr"
 	"github.com/expr-lang/expr/vm"
+	"github.com/yunboom/rulego/api/types"
+	"github.com/yunboom/rulego/test/assert"
 	"github.com/yunboom/rulego/utils/json"
 )
 
@@ -715,3 +717,37 @@ func BenchmarkMapExpression(b *testing.B) {
 		_, _ = vm.Run(mapProgram, data)
 	}
 }
+
+// 测试模板中调用Go自定义函数和类型提示
+func TestTemplateUdf(t *testing.T) {
+	config := types.NewConfig()
+	config.RegisterUdf("scale", func(v float64, factor int) float64 {
+		return v * float64(factor)
+	})
+	data := map[string]any{"msg": map[string]any{"temperature": 20.5}}
+
+	tmpl, err := NewTemplate("${scale(msg.temperature, 2)}", WithUdf(config.Udf))
+	assert.Nil(t, err)
+	result, err := tmpl.Execute(data)
+	assert.Nil(t, err)
+	assert.Equal(t, 41.0, result)
+
+	mixed, err := NewMixedTemplate("value:${scale(msg.temperature, 2)}", WithUdf(config.Udf))
+	assert.Nil(t, err)
+	result, err = mixed.Execute(data)
+	assert.Nil(t, err)
+	assert.Equal(t, "value:41", result)
+
+	exprTmpl, err := NewExprTemplate("${scale(msg.temperature, 3)}", WithUdf(config.Udf))
+	assert.Nil(t, err)
+	result, err = exprTmpl.Execute(data)
+	assert.Nil(t, err)
+	assert.Equal(t, 61.5, result)
+
+	// 参数类型在编译时检查
+	_, err = NewTemplate("${scale(msg.temperature, 'a')}", WithUdf(config.Udf))
+	assert.NotNil(t, err)
+	// 类型提示
+	_, err = NewTemplate("${msg.temperature > 'a'}", WithTypeHints(map[string]string{"msg.temperature": "float"}))
+	assert.NotNil(t, err)
+}
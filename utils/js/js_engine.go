@@ -32,6 +32,9 @@
 // - Precompilation of JavaScript code for improved performance
 // - Integration with the RuleGo configuration system
 // - Access to global variables and functions within JavaScript code
+// - An opt-in `$lib` standard library, see NewStdLib
+// - Shared script libraries referenced through `require` or `import`, see types.ScriptLibraryRegistry
+// - Per-execution resource limits, see types.ScriptLimits
 //
 // This package is crucial for components that require JavaScript execution,
 // such as the JsTransformNode and JsFilterNode in the action package.
@@ -56,31 +59,48 @@ const (
 
 // GojaJsEngine goja js engine
 type GojaJsEngine struct {
-	vmPool            sync.Pool
+	vmPool            *sync.Pool
+	poolLock          sync.RWMutex
 	config            types.Config
+	fromVars          map[string]interface{}
 	jsScript          *goja.Program
 	jsUdfProgramCache map[string]*goja.Program
+	// libraryVersion the version of config.ScriptLibraries when the VMs are checked
+	libraryVersion int64
+	// libraryDeps the libraries required by the VMs, name -> library version
+	libraryDeps sync.Map
 }
 
 // NewGojaJsEngine Create a new instance of the JavaScript engine
 func NewGojaJsEngine(config types.Config, jsScript string, fromVars map[string]interface{}) (*GojaJsEngine, error) {
-	program, err := goja.Compile("", jsScript, true)
-	if err != nil {
-		return nil, err
+	if config.ScriptLibraries != nil {
+		jsScript = ConvertImports(jsScript)
 	}
 	jsEngine := &GojaJsEngine{
 		config:   config,
-		jsScript: program,
+		fromVars: fromVars,
+	}
+	program, err := jsEngine.compile("", jsScript)
+	if err != nil {
+		return nil, err
 	}
+	jsEngine.jsScript = program
 	if err = jsEngine.PreCompileJs(config); err != nil {
 		return nil, err
 	}
-	jsEngine.vmPool = sync.Pool{
+	if config.ScriptLibraries != nil {
+		jsEngine.libraryVersion = config.ScriptLibraries.Version()
+	}
+	jsEngine.vmPool = jsEngine.newVmPool()
+	return jsEngine, nil
+}
+
+func (g *GojaJsEngine) newVmPool() *sync.Pool {
+	return &sync.Pool{
 		New: func() interface{} {
-			return jsEngine.NewVm(config, fromVars)
+			return g.NewVm(g.config, g.fromVars)
 		},
 	}
-	return jsEngine, nil
 }
 
 // PreCompileJs Precompiled UDF JavaScript file
@@ -88,7 +108,7 @@ func (g *GojaJsEngine) PreCompileJs(config types.Config) error {
 	var jsUdfProgramCache = make(map[string]*goja.Program)
 	for k, v := range config.Udf {
 		if jsFuncStr, ok := v.(string); ok {
-			if p, err := goja.Compile(k, jsFuncStr, true); err != nil {
+			if p, err := g.compile(k, jsFuncStr); err != nil {
 				return err
 			} else {
 				jsUdfProgramCache[k] = p
@@ -96,7 +116,7 @@ func (g *GojaJsEngine) PreCompileJs(config types.Config) error {
 		} else if script, scriptOk := v.(types.Script); scriptOk {
 			if script.Type == types.Js || script.Type == "" {
 				if c, ok := script.Content.(string); ok {
-					if p, err := goja.Compile(k, c, true); err != nil {
+					if p, err := g.compile(k, c); err != nil {
 						return err
 					} else {
 						jsUdfProgramCache[k] = p
@@ -115,6 +135,10 @@ func (g *GojaJsEngine) PreCompileJs(config types.Config) error {
 // NewVm new a js VM
 func (g *GojaJsEngine) NewVm(config types.Config, fromVars map[string]interface{}) *goja.Runtime {
 	vm := goja.New()
+	if config.ScriptLimits.MaxCallStackSize > 0 {
+		vm.SetMaxCallStackSize(config.ScriptLimits.MaxCallStackSize)
+	}
+	g.guardBuiltins(vm)
 	vars := make(map[string]interface{})
 	if fromVars != nil {
 		for k, v := range fromVars {
@@ -125,6 +149,14 @@ func (g *GojaJsEngine) NewVm(config types.Config, fromVars map[string]interface{
 		////Add global properties to the JavaScript runtime and call them through the global.xx method
 		vars[GlobalKey] = config.Properties.Values()
 	}
+	if config.JsStdLibEnabled {
+		//Add the standard library to the JavaScript runtime and call them through the $lib.xx method
+		vars[LibKey] = NewStdLib(config)
+	}
+	if config.ScriptLibraries != nil {
+		//Add the require function to load the shared script libraries
+		vars[RequireKey] = g.newRequire(vm)
+	}
 	//Add global custom functions to the JavaScript runtime
 	for k, v := range config.Udf {
 		var err error
@@ -162,6 +194,7 @@ func (g *GojaJsEngine) NewVm(config types.Config, fromVars map[string]interface{
 		}
 	}
 
+	g.setLoopGuard(vm)
 	state := g.setTimeout(vm)
 
 	_, err := vm.RunProgram(g.jsScript)
@@ -182,9 +215,15 @@ func (g *GojaJsEngine) Execute(ctx types.RuleContext, functionName string, argum
 		}
 	}()
 
-	vm := g.vmPool.Get().(*goja.Runtime)
+	g.checkLibraries()
+	g.poolLock.RLock()
+	vmPool := g.vmPool
+	g.poolLock.RUnlock()
+
+	vm := vmPool.Get().(*goja.Runtime)
 
 	vm.Set(CtxKey, ctx)
+	g.setLoopGuard(vm)
 
 	state := g.setTimeout(vm)
 
@@ -199,14 +238,29 @@ func (g *GojaJsEngine) Execute(ctx types.RuleContext, functionName string, argum
 	res, err := f(goja.Undefined(), params...)
 	//If there is no timeout, state=0; otherwise, state=-2
 	closeStateChan(state)
+	if err != nil {
+		if err = limitError(err); IsLimitError(err) {
+			//The VM exceeding the resource limits is discarded instead of putting it back to the pool
+			return nil, err
+		}
+	}
+	vm.ClearInterrupt()
 	//Put back to the pool
-	g.vmPool.Put(vm)
+	vmPool.Put(vm)
 	if err != nil {
 		return nil, err
 	}
 	return res.Export(), err
 }
 
+// compile compiles the script, the loops are instrumented if the loop iterations are limited
+func (g *GojaJsEngine) compile(name string, script string) (*goja.Program, error) {
+	if g.config.ScriptLimits.MaxLoopIterations > 0 {
+		script = InstrumentLoops(script)
+	}
+	return goja.Compile(name, script, true)
+}
+
 func (g *GojaJsEngine) Stop() {
 }
 
@@ -0,0 +1,196 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package js
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/dop251/goja"
+	"github.com/yunboom/rulego/api/types"
+)
+
+// RequireKey require function key, enabled by types.Config.ScriptLibraries
+const RequireKey = "require"
+
+// importRules rewrite the import statements, the regular expressions match at the import keyword
+var importRules = []statementRule{
+	{regexp.MustCompile(`^import\s+\*\s+as\s+([\w$]+)\s+from\s+['"]([^'"]+)['"]`), "var $1 = require('$2')"},
+	{regexp.MustCompile(`^import\s*\{([^}]*)\}\s*from\s*['"]([^'"]+)['"]`), ""},
+	{regexp.MustCompile(`^import\s+([\w$]+)\s+from\s+['"]([^'"]+)['"]`), "var $1 = require('$2')"},
+	{regexp.MustCompile(`^import\s+['"]([^'"]+)['"]`), "require('$1')"},
+}
+
+// exportRules rewrite the export statements, the regular expressions match at the export keyword
+var exportRules = []statementRule{
+	{regexp.MustCompile(`^export\s+function\s+([\w$]+)`), "exports.$1 = $1; function $1"},
+	{regexp.MustCompile(`^export\s+(const|let|var)\s+([\w$]+)\s*=`), "$1 $2 = exports.$2 ="},
+	{regexp.MustCompile(`^export\s+default\s+`), "module.exports = "},
+}
+
+var importAsRegex = regexp.MustCompile(`\s+as\s+`)
+
+// statementRule rewrites the statement matched by regex with the template, an empty template rewrites the named imports
+type statementRule struct {
+	regex    *regexp.Regexp
+	template string
+}
+
+// ConvertImports converts the ES module import statements to require calls.
+// Comments, strings, templates and regular expressions are left unchanged.
+//
+//	import * as utils from 'lib'    =>  var utils = require('lib')
+//	import { a, b as c } from 'lib' =>  var { a, b: c } = require('lib')
+//	import utils from 'lib'         =>  var utils = require('lib')
+//	import 'lib'                    =>  require('lib')
+func ConvertImports(script string) string {
+	return rewriteStatements(script, "import", importRules)
+}
+
+// ConvertExports converts the ES module export statements of the library to CommonJS exports.
+// Comments, strings, templates and regular expressions are left unchanged.
+//
+//	export function f() {}  =>  exports.f = f; function f() {}
+//	export const a = 1      =>  const a = exports.a = 1
+//	export default {...}    =>  module.exports = {...}
+func ConvertExports(script string) string {
+	return rewriteStatements(script, "export", exportRules)
+}
+
+// rewriteStatements rewrites the statements starting with the keyword, the keywords are found by the lexer of InstrumentLoops
+func rewriteStatements(script string, keyword string, rules []statementRule) string {
+	if !strings.Contains(script, keyword) {
+		return script
+	}
+	var b strings.Builder
+	last := 0
+	s := &loopScanner{src: script, regexAllowed: true}
+	s.onKeyword = func(word string, pos int) {
+		if word != keyword || pos < last {
+			return
+		}
+		for _, rule := range rules {
+			match := rule.regex.FindStringSubmatchIndex(script[pos:])
+			if match == nil {
+				continue
+			}
+			b.WriteString(script[last:pos])
+			if rule.template == "" {
+				// import { a, b as c } from 'lib'
+				fmt.Fprintf(&b, "var {%s} = require('%s')",
+					importAsRegex.ReplaceAllString(script[pos+match[2]:pos+match[3]], ": "), script[pos+match[4]:pos+match[5]])
+			} else {
+				b.Write(rule.regex.ExpandString(nil, rule.template, script[pos:], match))
+			}
+			last = pos + match[1]
+			return
+		}
+	}
+	s.scan()
+	if last == 0 {
+		return script
+	}
+	b.WriteString(script[last:])
+	return b.String()
+}
+
+// compileLibrary compiles the library as function(exports, module, require), the program is cached by the registry until the library is reloaded
+func compileLibrary(registry *types.ScriptLibraryRegistry, name string, loopGuard bool) (*goja.Program, int64, error) {
+	program, version, err := registry.Compile(name, loopGuard, func(lib types.ScriptLibrary) (interface{}, error) {
+		if lib.Type != types.Js {
+			return nil, fmt.Errorf("script library %s is not a JavaScript library", name)
+		}
+		script := ConvertExports(lib.Content)
+		if loopGuard {
+			script = InstrumentLoops(script)
+		}
+		return goja.Compile(name, "(function(exports, module, require) {\n"+script+"\n})", true)
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	return program.(*goja.Program), version, nil
+}
+
+// newRequire creates the require function of the VM, the modules are cached in the VM
+func (g *GojaJsEngine) newRequire(vm *goja.Runtime) func(name string) goja.Value {
+	registry := g.config.ScriptLibraries
+	modules := make(map[string]goja.Value)
+	var require func(name string) goja.Value
+	require = func(name string) goja.Value {
+		if module, ok := modules[name]; ok {
+			return module
+		}
+		program, version, err := compileLibrary(registry, name, g.config.ScriptLimits.MaxLoopIterations > 0)
+		if err != nil {
+			panic(vm.NewGoError(err))
+		}
+		g.libraryDeps.Store(name, version)
+		wrapper, err := vm.RunProgram(program)
+		if err != nil {
+			panic(vm.NewGoError(err))
+		}
+		f, _ := goja.AssertFunction(wrapper)
+		exports := vm.NewObject()
+		module := vm.NewObject()
+		_ = module.Set("exports", exports)
+		// Allow circular references
+		modules[name] = exports
+		if _, err = f(goja.Undefined(), exports, module, vm.ToValue(require)); err != nil {
+			delete(modules, name)
+			panic(vm.NewGoError(err))
+		}
+		modules[name] = module.Get("exports")
+		return modules[name]
+	}
+	return require
+}
+
+// checkLibraries rebuilds the VMs if any library the engine depends on is reloaded
+func (g *GojaJsEngine) checkLibraries() {
+	registry := g.config.ScriptLibraries
+	if registry == nil {
+		return
+	}
+	version := registry.Version()
+	g.poolLock.RLock()
+	unchanged := g.libraryVersion == version
+	g.poolLock.RUnlock()
+	if unchanged {
+		return
+	}
+	g.poolLock.Lock()
+	defer g.poolLock.Unlock()
+	if g.libraryVersion == version {
+		return
+	}
+	g.libraryVersion = version
+	changed := false
+	g.libraryDeps.Range(func(key, value interface{}) bool {
+		lib, ok := registry.Get(key.(string))
+		changed = !ok || lib.Version != value.(int64)
+		return !changed
+	})
+	if changed {
+		g.libraryDeps.Range(func(key, value interface{}) bool {
+			g.libraryDeps.Delete(key)
+			return true
+		})
+		g.vmPool = g.newVmPool()
+	}
+}
@@ -0,0 +1,154 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package js
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/yunboom/rulego/api/types"
+	"github.com/yunboom/rulego/test/assert"
+)
+
+func TestConvertImports(t *testing.T) {
+	assert.Equal(t, "var utils = require('lib');", ConvertImports("import * as utils from 'lib';"))
+	assert.Equal(t, "var { a, b: c } = require('lib')", ConvertImports(`import { a, b as c } from "lib"`))
+	assert.Equal(t, "var utils = require('lib')", ConvertImports("import utils from 'lib'"))
+	assert.Equal(t, "require('lib');", ConvertImports("import 'lib';"))
+	assert.Equal(t, "exports.f = f; function f() {}\nconst a = exports.a = 1\nmodule.exports = {}",
+		ConvertExports("export function f() {}\nexport const a = 1\nexport default {}"))
+	// 注释、字符串、模板和正则表达式中的语句不转换
+	script := "// import utils from 'lib'\nvar s = \"import 'lib'\"; var t = `export default ${1}`; var r = /import a from 'b'/\nimport 'lib'"
+	assert.Equal(t, "// import utils from 'lib'\nvar s = \"import 'lib'\"; var t = `export default ${1}`; var r = /import a from 'b'/\nrequire('lib')",
+		ConvertImports(script))
+	assert.Equal(t, script, ConvertExports(script))
+	assert.Equal(t, "/* export function f() {} */ msg.export = 1", ConvertExports("/* export function f() {} */ msg.export = 1"))
+}
+
+func TestScriptLibraryCompile(t *testing.T) {
+	libraries := types.NewScriptLibraryRegistry()
+	libraries.Register("lib", types.Js, "export const a = 1")
+	var count int
+	compile := func(lib types.ScriptLibrary) (interface{}, error) {
+		count++
+		return lib.Content, nil
+	}
+	v, version, err := libraries.Compile("lib", true, compile)
+	assert.Nil(t, err)
+	assert.Equal(t, "export const a = 1", v)
+	_, _, _ = libraries.Compile("lib", true, compile)
+	assert.Equal(t, 1, count)
+	// 不同的 key 分别缓存
+	_, _, _ = libraries.Compile("lib", false, compile)
+	assert.Equal(t, 2, count)
+
+	// 重新加载后重新编译
+	libraries.Register("lib", types.Js, "export const a = 2")
+	v, newVersion, err := libraries.Compile("lib", true, compile)
+	assert.Nil(t, err)
+	assert.Equal(t, "export const a = 2", v)
+	assert.True(t, newVersion > version)
+	assert.Equal(t, 3, count)
+
+	// 删除后缓存失效
+	libraries.Unregister("lib")
+	_, _, err = libraries.Compile("lib", true, compile)
+	assert.Equal(t, "script library lib not found", err.Error())
+	libraries.Register("lib", types.Js, "export const a = 2")
+	_, _, _ = libraries.Compile("lib", true, compile)
+	assert.Equal(t, 4, count)
+}
+
+func TestScriptLibraries(t *testing.T) {
+	libraries := types.NewScriptLibraryRegistry()
+	libraries.Register("mathUtils", types.Js, `
+		export const factor = 10
+		export function scale(v) { return v * factor }
+	`)
+	libraries.Register("format", types.Js, `
+		var math = require('mathUtils')
+		module.exports = function(v) { return 'value:' + math.scale(v) }
+	`)
+	config := types.NewConfig(types.WithScriptLibraries(libraries))
+
+	jsEngine, err := NewGojaJsEngine(config, `
+	function Transform(msg) {
+		import { scale, factor as f } from 'mathUtils';
+		import format from 'format';
+		return [scale(msg.v), f, format(msg.v)]
+	}
+	function NotFound() {
+		import 'notFound';
+		return true
+	}
+	`, nil)
+	assert.Nil(t, err)
+
+	out, err := jsEngine.Execute(nil, "Transform", map[string]interface{}{"v": 2})
+	assert.Nil(t, err)
+	assert.Equal(t, []interface{}{int64(20), int64(10), "value:20"}, out)
+
+	_, err = jsEngine.Execute(nil, "NotFound")
+	assert.NotNil(t, err)
+
+	// 重新加载依赖的库，虚拟机被重建
+	libraries.Register("mathUtils", types.Js, `
+		export const factor = 100
+		export function scale(v) { return v * factor }
+	`)
+	out, err = jsEngine.Execute(nil, "Transform", map[string]interface{}{"v": 2})
+	assert.Nil(t, err)
+	assert.Equal(t, []interface{}{int64(200), int64(100), "value:200"}, out)
+
+	// 语法错误的库
+	libraries.Register("mathUtils", types.Js, "export function scale(v) {")
+	_, err = jsEngine.Execute(nil, "Transform", map[string]interface{}{"v": 2})
+	assert.NotNil(t, err)
+}
+
+func TestScriptLibrariesLoadDir(t *testing.T) {
+	dir := t.TempDir()
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, "greet.js"), []byte("export function hello(name) { return 'hello ' + name }"), 0644))
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, "helper.lua"), []byte("return 1"), 0644))
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, "readme.md"), []byte("#"), 0644))
+
+	libraries := types.NewScriptLibraryRegistry()
+	assert.Nil(t, libraries.LoadDir(dir))
+	// 只加载 js 文件
+	assert.Equal(t, []string{"greet"}, libraries.Names())
+
+	jsEngine, err := NewGojaJsEngine(types.NewConfig(types.WithScriptLibraries(libraries)), `
+	function Hello() {
+		return require('greet').hello('rulego')
+	}
+	function Lua() {
+		return require('helper')
+	}
+	`, nil)
+	assert.Nil(t, err)
+	out, err := jsEngine.Execute(nil, "Hello")
+	assert.Nil(t, err)
+	assert.Equal(t, "hello rulego", out)
+	_, err = jsEngine.Execute(nil, "Lua")
+	assert.NotNil(t, err)
+
+	libraries.Unregister("greet")
+	_, err = jsEngine.Execute(nil, "Hello")
+	assert.NotNil(t, err)
+	assert.NotNil(t, libraries.LoadDir(filepath.Join(dir, "notFound")))
+}
@@ -0,0 +1,509 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package js
+
+import (
+	"errors"
+	"sort"
+	"strings"
+
+	"github.com/dop251/goja"
+)
+
+// LoopGuardKey the function called by the instrumented loops to count iterations
+const LoopGuardKey = "__rulegoLoopGuard"
+
+// Resource limit errors, see types.ScriptLimits
+var (
+	ErrCallStackLimit = errors.New("call stack size limit exceeded")
+	ErrLoopLimit      = errors.New("loop iteration limit exceeded")
+	ErrAllocLimit     = errors.New("allocation limit exceeded")
+)
+
+// IsLimitError reports whether the error is caused by exceeding a resource limit
+func IsLimitError(err error) bool {
+	return errors.Is(err, ErrCallStackLimit) || errors.Is(err, ErrLoopLimit) || errors.Is(err, ErrAllocLimit)
+}
+
+// limitError converts the goja error to the resource limit error
+func limitError(err error) error {
+	var stackOverflowErr *goja.StackOverflowError
+	if errors.As(err, &stackOverflowErr) {
+		return ErrCallStackLimit
+	}
+	var interruptedErr *goja.InterruptedError
+	if errors.As(err, &interruptedErr) {
+		if e, ok := interruptedErr.Value().(error); ok && IsLimitError(e) {
+			return e
+		}
+	}
+	return err
+}
+
+// setLoopGuard sets the iteration counter of the execution.
+// The guard adds its argument, or 1 without argument, to the counter and returns false once the limit is exceeded.
+func (g *GojaJsEngine) setLoopGuard(vm *goja.Runtime) {
+	maxLoopIterations := g.config.ScriptLimits.MaxLoopIterations
+	if maxLoopIterations <= 0 {
+		return
+	}
+	var count int64
+	_ = vm.Set(LoopGuardKey, func(call goja.FunctionCall) goja.Value {
+		n := int64(1)
+		if len(call.Arguments) > 0 {
+			n = call.Argument(0).ToInteger()
+		}
+		count += n
+		if count > maxLoopIterations {
+			vm.Interrupt(ErrLoopLimit)
+			return vm.ToValue(false)
+		}
+		return vm.ToValue(true)
+	})
+}
+
+// arrayIterationMethods the Array.prototype methods which visit every element
+var arrayIterationMethods = []string{"forEach", "map", "filter", "reduce", "reduceRight", "some", "every",
+	"find", "findIndex", "findLast", "findLastIndex", "flat", "flatMap", "fill", "sort", "join",
+	"indexOf", "lastIndexOf", "includes"}
+
+// builtinCheck checks a builtin call before or after it runs, returns false to stop the execution
+type builtinCheck func(call goja.FunctionCall, result goja.Value) bool
+
+// guardBuiltins wraps the Array and String builtins which iterate or grow values, so that they
+// count toward MaxLoopIterations and respect MaxArrayLength and MaxStringLength.
+// The checks before a call use the lengths of the arguments, the checks after a call
+// (Array.from of an iterable, flat, join and replace) see the result only once it has been created.
+func (g *GojaJsEngine) guardBuiltins(vm *goja.Runtime) {
+	limits := g.config.ScriptLimits
+	before := map[string][]builtinCheck{}
+	after := map[string][]builtinCheck{}
+	if max := limits.MaxArrayLength; max > 0 {
+		grow := func(extra func(call goja.FunctionCall) int64) builtinCheck {
+			return func(call goja.FunctionCall, _ goja.Value) bool {
+				return checkAlloc(vm, lengthOf(vm, call.This)+extra(call), max)
+			}
+		}
+		args := func(call goja.FunctionCall) int64 { return int64(len(call.Arguments)) }
+		before["Array.prototype.push"] = append(before["Array.prototype.push"], grow(args))
+		before["Array.prototype.unshift"] = append(before["Array.prototype.unshift"], grow(args))
+		before["Array.prototype.splice"] = append(before["Array.prototype.splice"], grow(func(call goja.FunctionCall) int64 {
+			return int64(len(call.Arguments)) - 2
+		}))
+		// fill 会为数组的每个位置分配元素
+		before["Array.prototype.fill"] = append(before["Array.prototype.fill"], grow(func(goja.FunctionCall) int64 { return 0 }))
+		before["Array.prototype.concat"] = append(before["Array.prototype.concat"], grow(func(call goja.FunctionCall) int64 {
+			var n int64
+			for _, arg := range call.Arguments {
+				if obj, ok := arg.(*goja.Object); ok && obj.ClassName() == "Array" {
+					n += lengthOf(vm, arg)
+				} else {
+					n++
+				}
+			}
+			return n
+		}))
+		before["Array.from"] = append(before["Array.from"], func(call goja.FunctionCall, _ goja.Value) bool {
+			return checkAlloc(vm, lengthOf(vm, call.Argument(0)), max)
+		})
+		resultLength := func(_ goja.FunctionCall, result goja.Value) bool {
+			return checkAlloc(vm, lengthOf(vm, result), max)
+		}
+		after["Array.from"] = append(after["Array.from"], resultLength)
+		after["Array.prototype.flat"] = append(after["Array.prototype.flat"], resultLength)
+	}
+	if max := limits.MaxStringLength; max > 0 {
+		before["String.prototype.repeat"] = append(before["String.prototype.repeat"], func(call goja.FunctionCall, _ goja.Value) bool {
+			return checkAlloc(vm, lengthOf(vm, call.This)*call.Argument(0).ToInteger(), max)
+		})
+		padTo := func(call goja.FunctionCall, _ goja.Value) bool {
+			return checkAlloc(vm, call.Argument(0).ToInteger(), max)
+		}
+		before["String.prototype.padStart"] = append(before["String.prototype.padStart"], padTo)
+		before["String.prototype.padEnd"] = append(before["String.prototype.padEnd"], padTo)
+		before["String.prototype.concat"] = append(before["String.prototype.concat"], func(call goja.FunctionCall, _ goja.Value) bool {
+			n := lengthOf(vm, call.This)
+			for _, arg := range call.Arguments {
+				n += lengthOf(vm, arg.ToString())
+			}
+			return checkAlloc(vm, n, max)
+		})
+		resultLength := func(_ goja.FunctionCall, result goja.Value) bool {
+			return checkAlloc(vm, lengthOf(vm, result), max)
+		}
+		for _, name := range []string{"Array.prototype.join", "String.prototype.replace", "String.prototype.replaceAll"} {
+			after[name] = append(after[name], resultLength)
+		}
+	}
+	// 先检查分配，再计入迭代次数
+	if limits.MaxLoopIterations > 0 {
+		countThis := func(call goja.FunctionCall, _ goja.Value) bool {
+			return loopGuard(vm, lengthOf(vm, call.This))
+		}
+		for _, name := range arrayIterationMethods {
+			before["Array.prototype."+name] = append(before["Array.prototype."+name], countThis)
+		}
+		before["Array.from"] = append(before["Array.from"], func(call goja.FunctionCall, _ goja.Value) bool {
+			return loopGuard(vm, lengthOf(vm, call.Argument(0)))
+		})
+	}
+	names := make(map[string]struct{}, len(before)+len(after))
+	for name := range before {
+		names[name] = struct{}{}
+	}
+	for name := range after {
+		names[name] = struct{}{}
+	}
+	for name := range names {
+		wrapBuiltin(vm, name, before[name], after[name])
+	}
+}
+
+// wrapBuiltin replaces the builtin, for example Array.prototype.push, with a function running the checks around it
+func wrapBuiltin(vm *goja.Runtime, name string, before, after []builtinCheck) {
+	path := strings.Split(name, ".")
+	owner := vm.GlobalObject()
+	for _, key := range path[:len(path)-1] {
+		v := owner.Get(key)
+		if v == nil || goja.IsUndefined(v) {
+			return
+		}
+		owner = v.ToObject(vm)
+	}
+	method := path[len(path)-1]
+	original, ok := goja.AssertFunction(owner.Get(method))
+	if !ok {
+		return
+	}
+	wrapped := func(call goja.FunctionCall) goja.Value {
+		for _, check := range before {
+			if !check(call, nil) {
+				return goja.Undefined()
+			}
+		}
+		result, err := original(call.This, call.Arguments...)
+		if err != nil {
+			panic(err)
+		}
+		for _, check := range after {
+			if !check(call, result) {
+				return goja.Undefined()
+			}
+		}
+		return result
+	}
+	_ = owner.DefineDataProperty(method, vm.ToValue(wrapped), goja.FLAG_TRUE, goja.FLAG_TRUE, goja.FLAG_FALSE)
+}
+
+// loopGuard adds n iterations to the counter of the execution, returns false once the limit is exceeded
+func loopGuard(vm *goja.Runtime, n int64) bool {
+	guard, ok := goja.AssertFunction(vm.Get(LoopGuardKey))
+	if !ok {
+		return true
+	}
+	res, err := guard(goja.Undefined(), vm.ToValue(n))
+	return err == nil && res.ToBoolean()
+}
+
+// checkAlloc interrupts the execution if the length exceeds the limit
+func checkAlloc(vm *goja.Runtime, length, max int64) bool {
+	if length > max {
+		vm.Interrupt(ErrAllocLimit)
+		return false
+	}
+	return true
+}
+
+// lengthOf returns the length property of the value, 0 if it has none
+func lengthOf(vm *goja.Runtime, v goja.Value) int64 {
+	if v == nil || goja.IsUndefined(v) || goja.IsNull(v) {
+		return 0
+	}
+	if l := v.ToObject(vm).Get("length"); l != nil {
+		return l.ToInteger()
+	}
+	return 0
+}
+
+// InstrumentLoops inserts the loop guard into the loops of the script to count iterations.
+//
+//	while (cond)            =>  while (__rulegoLoopGuard() && (cond))
+//	do {...} while (cond)   =>  do {...} while (__rulegoLoopGuard() && (cond))
+//	for (init; cond; step)  =>  for (init; __rulegoLoopGuard() && (cond); step)
+//	for (x of list) {...}   =>  for (x of list) {__rulegoLoopGuard();...}
+//	for (x of list) stmt    =>  for (x of list) if (__rulegoLoopGuard()) stmt
+func InstrumentLoops(script string) string {
+	s := &loopScanner{src: script, regexAllowed: true}
+	s.scan()
+	if len(s.insertions) == 0 {
+		return script
+	}
+	sort.SliceStable(s.insertions, func(i, j int) bool {
+		return s.insertions[i].pos < s.insertions[j].pos
+	})
+	var b strings.Builder
+	last := 0
+	for _, ins := range s.insertions {
+		b.WriteString(script[last:ins.pos])
+		b.WriteString(ins.text)
+		last = ins.pos
+	}
+	b.WriteString(script[last:])
+	return b.String()
+}
+
+type insertion struct {
+	pos  int
+	text string
+}
+
+// loopScanner a minimal JavaScript lexer, which skips comments, strings, templates and regular expressions
+type loopScanner struct {
+	src        string
+	insertions []insertion
+	// regexAllowed whether a slash starts a regular expression at the current position
+	regexAllowed bool
+	// afterDot whether the previous token is a dot
+	afterDot bool
+	// onKeyword if set, is called with the keywords and their positions instead of instrumenting the loops
+	onKeyword func(word string, pos int)
+}
+
+func (s *loopScanner) scan() {
+	i := 0
+	for i < len(s.src) {
+		i = s.next(i)
+	}
+}
+
+// next scans a token at i and returns the position after it
+func (s *loopScanner) next(i int) int {
+	c := s.src[i]
+	switch {
+	case c == '/' && i+1 < len(s.src) && s.src[i+1] == '/':
+		end := strings.IndexByte(s.src[i:], '\n')
+		if end < 0 {
+			return len(s.src)
+		}
+		return i + end
+	case c == '/' && i+1 < len(s.src) && s.src[i+1] == '*':
+		end := strings.Index(s.src[i+2:], "*/")
+		if end < 0 {
+			return len(s.src)
+		}
+		return i + 2 + end + 2
+	case c == '\'' || c == '"':
+		s.setToken(false)
+		return s.skipQuoted(i, c)
+	case c == '`':
+		s.setToken(false)
+		return s.skipTemplate(i)
+	case c == '/' && s.regexAllowed:
+		s.setToken(false)
+		return s.skipRegex(i)
+	case isIdentChar(c):
+		start := i
+		for i < len(s.src) && isIdentChar(s.src[i]) {
+			i++
+		}
+		word := s.src[start:i]
+		if !s.afterDot {
+			if s.onKeyword != nil {
+				s.onKeyword(word, start)
+			} else if word == "for" || word == "while" {
+				s.instrument(word, i)
+			}
+		}
+		switch word {
+		case "return", "typeof", "case", "do", "else", "in", "of", "new", "delete", "void", "throw", "instanceof", "yield", "await":
+			s.setToken(true)
+		default:
+			s.setToken(false)
+		}
+		return i
+	case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+		return i + 1
+	default:
+		s.regexAllowed = c != ')' && c != ']' && c != '}'
+		s.afterDot = c == '.'
+		return i + 1
+	}
+}
+
+func (s *loopScanner) setToken(regexAllowed bool) {
+	s.regexAllowed = regexAllowed
+	s.afterDot = false
+}
+
+// instrument instruments the loop whose keyword ends at i
+func (s *loopScanner) instrument(keyword string, i int) {
+	open := s.skipSpaces(i)
+	if open >= len(s.src) || s.src[open] != '(' {
+		return
+	}
+	// find the matching parenthesis and the top level semicolons
+	var semicolons []int
+	depth := 0
+	sub := &loopScanner{src: s.src, regexAllowed: true}
+	closePos := -1
+	for j := open; j < len(s.src) && closePos < 0; {
+		c := s.src[j]
+		switch c {
+		case '(', '[', '{':
+			depth++
+		case ')', ']', '}':
+			depth--
+			if depth == 0 {
+				closePos = j
+			}
+		case ';':
+			if depth == 1 {
+				semicolons = append(semicolons, j)
+			}
+		}
+		if c == '(' || c == '[' || c == '{' || c == ')' || c == ']' || c == '}' || c == ';' {
+			sub.setToken(c != ')' && c != ']' && c != '}')
+			j++
+		} else {
+			j = sub.nextSkipping(j)
+		}
+	}
+	if closePos < 0 {
+		return
+	}
+	guard := LoopGuardKey + "()"
+	switch {
+	case keyword == "while":
+		s.insertions = append(s.insertions, insertion{open + 1, guard + " && ("}, insertion{closePos, ")"})
+	case len(semicolons) == 2:
+		if strings.TrimSpace(s.src[semicolons[0]+1:semicolons[1]]) == "" {
+			s.insertions = append(s.insertions, insertion{semicolons[0] + 1, " " + guard})
+		} else {
+			s.insertions = append(s.insertions, insertion{semicolons[0] + 1, " " + guard + " && ("}, insertion{semicolons[1], ")"})
+		}
+	default:
+		body := s.skipSpaces(closePos + 1)
+		if body >= len(s.src) {
+			return
+		}
+		if s.src[body] == '{' {
+			s.insertions = append(s.insertions, insertion{body + 1, guard + ";"})
+		} else {
+			// 单条语句的循环体放在 if 语句中，if 没有 else，循环体中的 else 仍然属于原来的 if
+			s.insertions = append(s.insertions, insertion{body, "if (" + guard + ") "})
+		}
+	}
+}
+
+// nextSkipping scans a token without instrumenting loops
+func (s *loopScanner) nextSkipping(i int) int {
+	c := s.src[i]
+	if isIdentChar(c) {
+		for i < len(s.src) && isIdentChar(s.src[i]) {
+			i++
+		}
+		s.setToken(false)
+		return i
+	}
+	return s.next(i)
+}
+
+func (s *loopScanner) skipSpaces(i int) int {
+	for i < len(s.src) {
+		switch s.src[i] {
+		case ' ', '\t', '\n', '\r':
+			i++
+		default:
+			return i
+		}
+	}
+	return i
+}
+
+func (s *loopScanner) skipQuoted(i int, quote byte) int {
+	for i++; i < len(s.src); i++ {
+		switch s.src[i] {
+		case '\\':
+			i++
+		case quote, '\n':
+			return i + 1
+		}
+	}
+	return i
+}
+
+func (s *loopScanner) skipRegex(i int) int {
+	inClass := false
+	for i++; i < len(s.src); i++ {
+		switch s.src[i] {
+		case '\\':
+			i++
+		case '[':
+			inClass = true
+		case ']':
+			inClass = false
+		case '/':
+			if !inClass {
+				i++
+				for i < len(s.src) && isIdentChar(s.src[i]) {
+					i++
+				}
+				return i
+			}
+		case '\n':
+			return i
+		}
+	}
+	return i
+}
+
+// skipTemplate skips the template literal, loops in the ${} expressions are instrumented
+func (s *loopScanner) skipTemplate(i int) int {
+	for i++; i < len(s.src); i++ {
+		switch s.src[i] {
+		case '\\':
+			i++
+		case '`':
+			return i + 1
+		case '$':
+			if i+1 < len(s.src) && s.src[i+1] == '{' {
+				depth := 1
+				s.setToken(true)
+				for i += 2; i < len(s.src) && depth > 0; {
+					switch s.src[i] {
+					case '{':
+						depth++
+						s.setToken(true)
+						i++
+					case '}':
+						depth--
+						s.setToken(false)
+						i++
+					default:
+						i = s.next(i)
+					}
+				}
+				i--
+			}
+		}
+	}
+	return i
+}
+
+func isIdentChar(c byte) bool {
+	return c == '_' || c == '$' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c >= 0x80
+}
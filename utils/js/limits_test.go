@@ -0,0 +1,183 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package js
+
+import (
+	"testing"
+	"time"
+
+	"github.com/yunboom/rulego/api/types"
+	"github.com/yunboom/rulego/test/assert"
+)
+
+func TestInstrumentLoops(t *testing.T) {
+	assert.Equal(t, "while (__rulegoLoopGuard() && (i < 10)) i++",
+		InstrumentLoops("while (i < 10) i++"))
+	assert.Equal(t, "for (var i = 0; __rulegoLoopGuard() && ( i < n); i++) {}",
+		InstrumentLoops("for (var i = 0; i < n; i++) {}"))
+	assert.Equal(t, "for (; __rulegoLoopGuard();) {}",
+		InstrumentLoops("for (;;) {}"))
+	assert.Equal(t, "for (const x of list) {__rulegoLoopGuard(); sum += x }",
+		InstrumentLoops("for (const x of list) { sum += x }"))
+	assert.Equal(t, "do { i++ } while (__rulegoLoopGuard() && (i < 3))",
+		InstrumentLoops("do { i++ } while (i < 3)"))
+	// 字符串、注释、正则表达式和属性中的关键字不处理
+	script := "var s = 'while (true)'; // for (;;)\n/* while(1) */ var r = /for (a)/g; obj.for(1); var t = `for (x)`"
+	assert.Equal(t, script, InstrumentLoops(script))
+	// 循环体不是代码块
+	assert.Equal(t, "for (const x of list) if (__rulegoLoopGuard()) sum += x",
+		InstrumentLoops("for (const x of list) sum += x"))
+	assert.Equal(t, "for (k in obj) if (__rulegoLoopGuard()) if (k) a++; else b++",
+		InstrumentLoops("for (k in obj) if (k) a++; else b++"))
+	// 嵌套循环
+	assert.Equal(t, "while (__rulegoLoopGuard() && (a)) { while (__rulegoLoopGuard() && (b)) {} }",
+		InstrumentLoops("while (a) { while (b) {} }"))
+}
+
+func TestScriptLimits(t *testing.T) {
+	var jsScript = `
+	function Recurse(n) {
+		return Recurse(n + 1)
+	}
+	function Loop(n) {
+		var sum = 0
+		for (var i = 0; i < n; i++) {
+			sum += i
+		}
+		return sum
+	}
+	function CatchLoop() {
+		try {
+			while (true) {}
+		} catch (e) {
+			return 'caught'
+		}
+	}
+	`
+	config := types.NewConfig(types.WithScriptMaxExecutionTime(time.Second*10), types.WithScriptLimits(types.ScriptLimits{
+		MaxCallStackSize:  100,
+		MaxLoopIterations: 1000,
+	}))
+	jsEngine, err := NewGojaJsEngine(config, jsScript, nil)
+	assert.Nil(t, err)
+
+	_, err = jsEngine.Execute(nil, "Recurse", 0)
+	assert.Equal(t, ErrCallStackLimit, err)
+
+	out, err := jsEngine.Execute(nil, "Loop", 100)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(4950), out)
+
+	_, err = jsEngine.Execute(nil, "Loop", 2000)
+	assert.Equal(t, ErrLoopLimit, err)
+
+	// 不能被 try catch 捕获
+	_, err = jsEngine.Execute(nil, "CatchLoop")
+	assert.Equal(t, ErrLoopLimit, err)
+
+	// 计数按每次执行重置
+	out, err = jsEngine.Execute(nil, "Loop", 999)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(498501), out)
+}
+
+func TestScriptLimitsBuiltins(t *testing.T) {
+	var jsScript = `
+	function ForOf(n) {
+		var list = new Array(n).fill(1), sum = 0
+		for (const x of list) sum += x
+		return sum
+	}
+	function ForIn(n) {
+		var obj = {}, count = 0
+		for (var i = 0; i < n; i++) obj['k' + i] = i
+		for (const k in obj) count++
+		return count
+	}
+	function ForEach(n) {
+		var sum = 0
+		Array.from({length: n}, function (v, i) { return i }).forEach(function (x) { sum += x })
+		return sum
+	}
+	function Push(n) {
+		var list = []
+		for (var i = 0; i < n; i++) list.push(i)
+		return list.length
+	}
+	function Fill(n) {
+		return new Array(n).fill(0).length
+	}
+	function Repeat(n) {
+		return 'ab'.repeat(n).length
+	}
+	function Join(n) {
+		return new Array(n).join('abc').length
+	}
+	function CatchAlloc() {
+		try {
+			return 'x'.repeat(10000)
+		} catch (e) {
+			return 'caught'
+		}
+	}
+	`
+	config := types.NewConfig(types.WithScriptMaxExecutionTime(time.Second*10), types.WithScriptLimits(types.ScriptLimits{
+		MaxLoopIterations: 1000,
+		MaxArrayLength:    2000,
+		MaxStringLength:   1000,
+	}))
+	jsEngine, err := NewGojaJsEngine(config, jsScript, nil)
+	assert.Nil(t, err)
+
+	// 循环体不是代码块的 for-of、for-in 也计数
+	out, err := jsEngine.Execute(nil, "ForOf", 100)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(100), out)
+	_, err = jsEngine.Execute(nil, "ForOf", 600)
+	assert.Equal(t, ErrLoopLimit, err)
+	_, err = jsEngine.Execute(nil, "ForIn", 600)
+	assert.Equal(t, ErrLoopLimit, err)
+
+	// Array 内置函数访问的元素计入迭代次数
+	out, err = jsEngine.Execute(nil, "ForEach", 100)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(4950), out)
+	_, err = jsEngine.Execute(nil, "ForEach", 600)
+	assert.Equal(t, ErrLoopLimit, err)
+
+	// 数组长度限制
+	out, err = jsEngine.Execute(nil, "Push", 500)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(500), out)
+	_, err = jsEngine.Execute(nil, "Fill", 2001)
+	assert.Equal(t, ErrAllocLimit, err)
+	_, err = jsEngine.Execute(nil, "ForEach", 2001)
+	assert.Equal(t, ErrAllocLimit, err)
+
+	// 字符串长度限制
+	out, err = jsEngine.Execute(nil, "Repeat", 500)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1000), out)
+	_, err = jsEngine.Execute(nil, "Repeat", 501)
+	assert.Equal(t, ErrAllocLimit, err)
+	_, err = jsEngine.Execute(nil, "Join", 400)
+	assert.Equal(t, ErrAllocLimit, err)
+
+	// 不能被 try catch 捕获
+	_, err = jsEngine.Execute(nil, "CatchAlloc")
+	assert.Equal(t, ErrAllocLimit, err)
+}
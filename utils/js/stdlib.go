@@ -0,0 +1,229 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package js
+
+import (
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gofrs/uuid/v5"
+	"github.com/yunboom/rulego/api/types"
+	"github.com/yunboom/rulego/utils/maps"
+	"github.com/yunboom/rulego/utils/str"
+)
+
+// LibKey standard library key, enabled by types.Config.JsStdLibEnabled
+const LibKey = "$lib"
+
+// HttpMaxResponseSize the maximum response body size of $lib.http.fetch
+const HttpMaxResponseSize = 1024 * 1024
+
+// HttpDefaultTimeout the timeout of $lib.http.fetch when types.Config.ScriptMaxExecutionTime is not set
+const HttpDefaultTimeout = 10 * time.Second
+
+// ErrHostNotAllowed the request host is not in types.Config.JsHttpAllowHosts
+var ErrHostNotAllowed = errors.New("host is not allowed")
+
+// NewStdLib creates the `$lib` standard library.
+//
+//	$lib.encoding: base64Encode, base64Decode, hexEncode, hexDecode, urlEncode, urlDecode
+//	$lib.crypto: md5, sha1, sha256, sha512, hmacSha1, hmacSha256, hmacSha512, the results are hex strings
+//	$lib.time: now, format, parse, timestamps are unix milliseconds
+//	$lib.json: get
+//	$lib.uuid
+//	$lib.http: fetch, only when config.JsHttpAllowHosts is not empty
+func NewStdLib(config types.Config) map[string]interface{} {
+	lib := map[string]interface{}{
+		"encoding": map[string]interface{}{
+			"base64Encode": func(s string) string {
+				return base64.StdEncoding.EncodeToString([]byte(s))
+			},
+			"base64Decode": func(s string) (string, error) {
+				b, err := base64.StdEncoding.DecodeString(s)
+				return string(b), err
+			},
+			"hexEncode": func(s string) string {
+				return hex.EncodeToString([]byte(s))
+			},
+			"hexDecode": func(s string) (string, error) {
+				b, err := hex.DecodeString(s)
+				return string(b), err
+			},
+			"urlEncode": url.QueryEscape,
+			"urlDecode": url.QueryUnescape,
+		},
+		"crypto": map[string]interface{}{
+			"md5": func(s string) string {
+				return hashHex(md5.New(), s)
+			},
+			"sha1": func(s string) string {
+				return hashHex(sha1.New(), s)
+			},
+			"sha256": func(s string) string {
+				return hashHex(sha256.New(), s)
+			},
+			"sha512": func(s string) string {
+				return hashHex(sha512.New(), s)
+			},
+			"hmacSha1": func(key, s string) string {
+				return hashHex(hmac.New(sha1.New, []byte(key)), s)
+			},
+			"hmacSha256": func(key, s string) string {
+				return hashHex(hmac.New(sha256.New, []byte(key)), s)
+			},
+			"hmacSha512": func(key, s string) string {
+				return hashHex(hmac.New(sha512.New, []byte(key)), s)
+			},
+		},
+		"time": map[string]interface{}{
+			"now": func() int64 {
+				return time.Now().UnixMilli()
+			},
+			// format formats the timestamp in the local time zone, layout example: yyyy-MM-dd HH:mm:ss.SSS
+			"format": func(timestamp int64, layout string) string {
+				return time.UnixMilli(timestamp).Format(str.ConvertDateLayout(layout))
+			},
+			// parse parses the value in the local time zone and returns the timestamp
+			"parse": func(value string, layout string) (int64, error) {
+				t, err := time.ParseInLocation(str.ConvertDateLayout(layout), value, time.Local)
+				if err != nil {
+					return 0, err
+				}
+				return t.UnixMilli(), nil
+			},
+		},
+		"json": map[string]interface{}{
+			// get gets the value by the path, e.g. a.b.c
+			"get": func(obj interface{}, path string) interface{} {
+				return maps.Get(obj, path)
+			},
+		},
+		"uuid": func() string {
+			return uuid.Must(uuid.NewV4()).String()
+		},
+	}
+	if len(config.JsHttpAllowHosts) != 0 {
+		lib["http"] = map[string]interface{}{
+			"fetch": newHttpFetch(config),
+		}
+	}
+	return lib
+}
+
+func hashHex(h hash.Hash, s string) string {
+	h.Write([]byte(s))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// httpFetchTimeout the timeout of $lib.http.fetch, the script max execution time or HttpDefaultTimeout
+func httpFetchTimeout(config types.Config) time.Duration {
+	if config.ScriptMaxExecutionTime > 0 {
+		return config.ScriptMaxExecutionTime
+	}
+	return HttpDefaultTimeout
+}
+
+// newHttpFetch creates the fetch function, which only requests the allowed hosts.
+//
+//	$lib.http.fetch(url, {method: 'POST', headers: {'Content-Type': 'application/json'}, body: '{}'})
+//	return {status: 200, headers: {...}, body: '...'}
+func newHttpFetch(config types.Config) func(rawUrl string, options map[string]interface{}) (map[string]interface{}, error) {
+	allowHosts := config.JsHttpAllowHosts
+	client := &http.Client{
+		Timeout: httpFetchTimeout(config),
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if !isHostAllowed(allowHosts, req.URL) {
+				return fmt.Errorf("%w: %s", ErrHostNotAllowed, req.URL.Host)
+			}
+			return nil
+		},
+	}
+	return func(rawUrl string, options map[string]interface{}) (map[string]interface{}, error) {
+		u, err := url.Parse(rawUrl)
+		if err != nil {
+			return nil, err
+		}
+		if u.Scheme != "http" && u.Scheme != "https" {
+			return nil, fmt.Errorf("unsupported scheme: %s", u.Scheme)
+		}
+		if !isHostAllowed(allowHosts, u) {
+			return nil, fmt.Errorf("%w: %s", ErrHostNotAllowed, u.Host)
+		}
+		method := http.MethodGet
+		var body io.Reader
+		if v, ok := options["method"]; ok {
+			method = strings.ToUpper(str.ToString(v))
+		}
+		if v, ok := options["body"]; ok && v != nil {
+			body = strings.NewReader(str.ToString(v))
+		}
+		req, err := http.NewRequest(method, u.String(), body)
+		if err != nil {
+			return nil, err
+		}
+		if headers, ok := options["headers"].(map[string]interface{}); ok {
+			for k, v := range headers {
+				req.Header.Set(k, str.ToString(v))
+			}
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+		respBody, err := io.ReadAll(io.LimitReader(resp.Body, HttpMaxResponseSize))
+		if err != nil {
+			return nil, err
+		}
+		respHeaders := make(map[string]interface{}, len(resp.Header))
+		for k := range resp.Header {
+			respHeaders[k] = resp.Header.Get(k)
+		}
+		return map[string]interface{}{
+			"status":  resp.StatusCode,
+			"headers": respHeaders,
+			"body":    string(respBody),
+		}, nil
+	}
+}
+
+// isHostAllowed checks the host with port and the host name against the allow-list
+func isHostAllowed(allowHosts []string, u *url.URL) bool {
+	hostname := u.Hostname()
+	for _, allow := range allowHosts {
+		if allow == u.Host || allow == hostname {
+			return true
+		}
+		if strings.HasPrefix(allow, "*.") && strings.HasSuffix(hostname, allow[1:]) {
+			return true
+		}
+	}
+	return false
+}
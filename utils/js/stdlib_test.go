@@ -0,0 +1,137 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package js
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yunboom/rulego/api/types"
+	"github.com/yunboom/rulego/test/assert"
+)
+
+func TestStdLib(t *testing.T) {
+	var jsScript = `
+	function Encoding() {
+		return [$lib.encoding.base64Encode('rulego'), $lib.encoding.base64Decode('cnVsZWdv'),
+			$lib.encoding.hexEncode('ab'), $lib.encoding.hexDecode('6162'), $lib.encoding.urlEncode('a b&c')]
+	}
+	function Crypto() {
+		return [$lib.crypto.md5('rulego'), $lib.crypto.sha256('rulego'), $lib.crypto.hmacSha256('key', 'rulego')]
+	}
+	function Time() {
+		var ts = $lib.time.parse('2025-01-02 03:04:05.006', 'yyyy-MM-dd HH:mm:ss.SSS')
+		return $lib.time.format(ts, 'yyyy/MM/dd HH:mm:ss.SSS')
+	}
+	function Other(msg) {
+		return {uuid: $lib.uuid(), value: $lib.json.get(msg, 'a.b'), hasHttp: typeof $lib.http !== 'undefined'}
+	}
+	function DecodeError() {
+		return $lib.encoding.base64Decode('@@')
+	}
+	function HasLib() {
+		return typeof $lib !== 'undefined'
+	}
+	`
+	config := types.NewConfig(types.WithJsStdLib(true))
+	jsEngine, err := NewGojaJsEngine(config, jsScript, nil)
+	assert.Nil(t, err)
+	defer jsEngine.Stop()
+
+	out, err := jsEngine.Execute(nil, "Encoding")
+	assert.Nil(t, err)
+	assert.Equal(t, []interface{}{"cnVsZWdv", "rulego", "6162", "ab", "a+b%26c"}, out)
+
+	out, err = jsEngine.Execute(nil, "Crypto")
+	assert.Nil(t, err)
+	assert.Equal(t, []interface{}{
+		"54c1dd32e98582f12595c63f686f1c4e",
+		"75dc6c49f05fd2482fa7dd1f33f5fe8da056b1968354bc585b64dd4e914af31c",
+		"53a271bed47293976e76247044afa0ec8fab000fe12f8025ea3ae60286fbe3a5",
+	}, out)
+
+	out, err = jsEngine.Execute(nil, "Time")
+	assert.Nil(t, err)
+	assert.Equal(t, "2025/01/02 03:04:05.006", out)
+
+	out, err = jsEngine.Execute(nil, "Other", map[string]interface{}{"a": map[string]interface{}{"b": "c"}})
+	assert.Nil(t, err)
+	result := out.(map[string]interface{})
+	assert.Equal(t, 36, len(result["uuid"].(string)))
+	assert.Equal(t, "c", result["value"])
+	assert.Equal(t, false, result["hasHttp"])
+
+	_, err = jsEngine.Execute(nil, "DecodeError")
+	assert.NotNil(t, err)
+
+	// 默认不注入标准库
+	jsEngine2, err := NewGojaJsEngine(types.NewConfig(), jsScript, nil)
+	assert.Nil(t, err)
+	out, err = jsEngine2.Execute(nil, "HasLib")
+	assert.Nil(t, err)
+	assert.Equal(t, false, out)
+}
+
+func TestStdLibHttp(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/redirect" {
+			http.Redirect(w, r, "http://example.com/", http.StatusFound)
+			return
+		}
+		w.Header().Set("X-Method", r.Method)
+		w.Write([]byte("hello " + r.Header.Get("X-Name")))
+	}))
+	defer server.Close()
+	serverUrl, _ := url.Parse(server.URL)
+
+	var jsScript = `
+	function Fetch(url) {
+		return $lib.http.fetch(url, {method: 'post', headers: {'X-Name': 'rulego'}, body: 'data'})
+	}
+	`
+	config := types.NewConfig(types.WithJsStdLib(true), types.WithJsHttpAllowHosts(serverUrl.Host),
+		types.WithScriptMaxExecutionTime(time.Second*5))
+	jsEngine, err := NewGojaJsEngine(config, jsScript, nil)
+	assert.Nil(t, err)
+
+	out, err := jsEngine.Execute(nil, "Fetch", server.URL)
+	assert.Nil(t, err)
+	result := out.(map[string]interface{})
+	assert.Equal(t, 200, result["status"])
+	assert.Equal(t, "hello rulego", result["body"])
+	assert.Equal(t, "POST", result["headers"].(map[string]interface{})["X-Method"])
+
+	// 不在白名单中的主机
+	_, err = jsEngine.Execute(nil, "Fetch", "http://example.com/")
+	assert.True(t, strings.Contains(err.Error(), ErrHostNotAllowed.Error()))
+	_, err = jsEngine.Execute(nil, "Fetch", server.URL+"/redirect")
+	assert.True(t, strings.Contains(err.Error(), ErrHostNotAllowed.Error()))
+	_, err = jsEngine.Execute(nil, "Fetch", "file:///etc/passwd")
+	assert.NotNil(t, err)
+
+	// 未设置脚本最大执行时间时使用默认超时
+	assert.Equal(t, time.Second*5, httpFetchTimeout(config))
+	assert.Equal(t, HttpDefaultTimeout, httpFetchTimeout(types.NewConfig(types.WithScriptMaxExecutionTime(0))))
+
+	assert.True(t, isHostAllowed([]string{"*.example.com"}, &url.URL{Host: "api.example.com:8080"}))
+	assert.False(t, isHostAllowed([]string{"*.example.com"}, &url.URL{Host: "example.org"}))
+	assert.False(t, isHostAllowed([]string{"api.example.com"}, &url.URL{Host: "api.example.com.evil.org"}))
+}
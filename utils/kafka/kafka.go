@@ -0,0 +1,134 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package kafka provides the shared Kafka client configuration for the RuleGo rule engine.
+//
+// This package converts the connection settings used by the kafkaProducer component
+// and the endpoint/kafka consumer into a sarama configuration. It works with any
+// broker that speaks the Kafka protocol, such as Apache Kafka, Redpanda or AutoMQ.
+//
+// The package supports features such as:
+// - Multiple bootstrap brokers
+// - SASL/PLAIN authentication
+// - TLS/SSL connections with optional client certificates
+// - Explicit Kafka protocol version
+package kafka
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/IBM/sarama"
+	"github.com/yunboom/rulego/utils/str"
+)
+
+// SaslMechanismPlain SASL/PLAIN 认证
+const SaslMechanismPlain = "PLAIN"
+
+// Config 客户端配置
+type Config struct {
+	// Brokers kafka broker 地址列表
+	Brokers []string
+	// ClientID 客户端ID，为空则随机生成
+	ClientID string
+	// Version kafka协议版本，例如：2.8.0，为空使用默认版本
+	Version string
+	// Username SASL认证用户名，为空不认证
+	Username string
+	// Password SASL认证密码
+	Password string
+	// SaslMechanism SASL认证机制，目前支持PLAIN，默认PLAIN
+	SaslMechanism string
+	// TLS 是否使用TLS连接，配置了证书文件时自动开启
+	TLS         bool
+	CAFile      string
+	CertFile    string
+	CertKeyFile string
+}
+
+// ResourcePath 共享客户端的资源路径
+func (c Config) ResourcePath() string {
+	return strings.Join(c.Brokers, ",")
+}
+
+// NewSaramaConfig 转换成sarama客户端配置，生产者和消费者的配置由调用方继续设置
+func NewSaramaConfig(conf Config) (*sarama.Config, error) {
+	if len(conf.Brokers) == 0 {
+		return nil, fmt.Errorf("brokers can not be empty")
+	}
+	config := sarama.NewConfig()
+	if conf.ClientID == "" {
+		config.ClientID = "rulego-" + str.RandomStr(8)
+	} else {
+		config.ClientID = conf.ClientID
+	}
+	if conf.Version != "" {
+		version, err := sarama.ParseKafkaVersion(conf.Version)
+		if err != nil {
+			return nil, err
+		}
+		config.Version = version
+	}
+	if conf.Username != "" {
+		mechanism := strings.ToUpper(conf.SaslMechanism)
+		if mechanism == "" {
+			mechanism = SaslMechanismPlain
+		}
+		if mechanism != SaslMechanismPlain {
+			return nil, fmt.Errorf("unsupported sasl mechanism: %s", conf.SaslMechanism)
+		}
+		config.Net.SASL.Enable = true
+		config.Net.SASL.Mechanism = sarama.SASLTypePlaintext
+		config.Net.SASL.User = conf.Username
+		config.Net.SASL.Password = conf.Password
+	}
+	tlsConfig, err := newTLSConfig(conf.CAFile, conf.CertFile, conf.CertKeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("error loading kafka certificate files,ca_cert=%s,tls_cert=%s,tls_key=%s", conf.CAFile, conf.CertFile, conf.CertKeyFile)
+	}
+	if tlsConfig != nil || conf.TLS {
+		config.Net.TLS.Enable = true
+		config.Net.TLS.Config = tlsConfig
+	}
+	return config, nil
+}
+
+func newTLSConfig(caFile, certFile, certKeyFile string) (*tls.Config, error) {
+	if caFile == "" && certFile == "" && certKeyFile == "" {
+		return nil, nil
+	}
+	tlsConfig := &tls.Config{}
+	if caFile != "" {
+		caCert, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, err
+		}
+		certPool := x509.NewCertPool()
+		certPool.AppendCertsFromPEM(caCert)
+		tlsConfig.RootCAs = certPool
+	}
+	if certFile != "" && certKeyFile != "" {
+		kp, err := tls.LoadX509KeyPair(certFile, certKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.Certificates = []tls.Certificate{kp}
+	}
+	return tlsConfig, nil
+}
@@ -0,0 +1,363 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package lua provides Lua execution capabilities for the RuleGo rule engine.
+//
+// This package implements a pure Go Lua 5.1 engine using the gopher-lua library.
+// LuaEngine implements the types.JsEngine interface, so it can be used anywhere
+// the JavaScript engine is used, for example by the script nodes when their
+// scriptType is set to Lua.
+//
+// The package supports features such as:
+// - Pooling of Lua states for efficient reuse
+// - Precompilation of the Lua script and Lua UDF scripts
+// - ScriptMaxExecutionTime enforcement
+// - Access to global properties, rule chain variables, UDF functions and the rule context
+//
+// Values passed to Lua are converted as follows: maps become tables, slices and byte arrays
+// become 1-based array tables, numbers become Lua numbers, other values such as Go functions
+// and structs are exposed through gopher-luar. Tables returned from Lua are converted back to
+// []interface{} when they are sequences and to map[string]interface{} otherwise, integral numbers
+// are converted to int64 and other numbers to float64. Arguments of Go functions and methods called
+// from Lua are converted by gopher-luar: numbers passed as interface{} become float64, and every
+// parameter must be given, e.g. ctx:ChainCache():Set("key", "value", "").
+package lua
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/yuin/gopher-lua"
+	"github.com/yuin/gopher-lua/parse"
+	"github.com/yunboom/rulego/api/types"
+	luar "layeh.com/gopher-luar"
+)
+
+const (
+	//GlobalKey  global properties key,call them through the global.xx method
+	GlobalKey = "global"
+	// CtxKey rule context key. `$ctx` is not a valid Lua identifier, so the context is exposed as ctx
+	// CtxKey 规则上下文变量名。`$ctx` 不是合法的 Lua 标识符，所以使用 ctx
+	CtxKey = "ctx"
+)
+
+// ErrExecutionTimeout is returned when the script exceeds ScriptMaxExecutionTime
+var ErrExecutionTimeout = errors.New("execution timeout")
+
+// LuaEngine gopher-lua engine
+type LuaEngine struct {
+	vmPool         sync.Pool
+	config         types.Config
+	luaScript      *lua.FunctionProto
+	luaUdfProtoMap map[string]*lua.FunctionProto
+}
+
+// NewLuaEngine Create a new instance of the Lua engine
+func NewLuaEngine(config types.Config, luaScript string, fromVars map[string]interface{}) (*LuaEngine, error) {
+	proto, err := Compile("", luaScript)
+	if err != nil {
+		return nil, err
+	}
+	luaEngine := &LuaEngine{
+		config:    config,
+		luaScript: proto,
+	}
+	if err = luaEngine.PreCompileLua(config); err != nil {
+		return nil, err
+	}
+	luaEngine.vmPool = sync.Pool{
+		New: func() interface{} {
+			return luaEngine.NewVm(config, fromVars)
+		},
+	}
+	return luaEngine, nil
+}
+
+// Compile compiles the Lua source code
+func Compile(name string, source string) (*lua.FunctionProto, error) {
+	chunk, err := parse.Parse(strings.NewReader(source), name)
+	if err != nil {
+		return nil, err
+	}
+	return lua.Compile(chunk, name)
+}
+
+// PreCompileLua Precompiled UDF Lua scripts
+func (g *LuaEngine) PreCompileLua(config types.Config) error {
+	var luaUdfProtoMap = make(map[string]*lua.FunctionProto)
+	for k, v := range config.Udf {
+		if script, ok := v.(types.Script); ok && script.Type == types.Lua {
+			if c, ok := script.Content.(string); ok {
+				if p, err := Compile(k, c); err != nil {
+					return err
+				} else {
+					luaUdfProtoMap[k] = p
+				}
+			} else if p, ok := script.Content.(*lua.FunctionProto); ok {
+				luaUdfProtoMap[k] = p
+			}
+		}
+	}
+	g.luaUdfProtoMap = luaUdfProtoMap
+	return nil
+}
+
+// sandboxLibs the Lua libraries opened in the script sandbox, io, os, package and debug are not available
+var sandboxLibs = []struct {
+	name string
+	open lua.LGFunction
+}{
+	{lua.BaseLibName, lua.OpenBase},
+	{lua.TabLibName, lua.OpenTable},
+	{lua.StringLibName, lua.OpenString},
+	{lua.MathLibName, lua.OpenMath},
+}
+
+// newSandboxState new a Lua state that can not access files, processes or load other scripts
+func newSandboxState() *lua.LState {
+	vm := lua.NewState(lua.Options{SkipOpenLibs: true})
+	for _, lib := range sandboxLibs {
+		vm.Push(vm.NewFunction(lib.open))
+		vm.Push(lua.LString(lib.name))
+		vm.Call(1, 0)
+	}
+	// 基础库中可以读取文件或者加载模块的函数
+	for _, name := range []string{"dofile", "loadfile", "require"} {
+		vm.SetGlobal(name, lua.LNil)
+	}
+	return vm
+}
+
+// NewVm new a Lua state
+func (g *LuaEngine) NewVm(config types.Config, fromVars map[string]interface{}) *lua.LState {
+	vm := newSandboxState()
+	vars := make(map[string]interface{})
+	for k, v := range fromVars {
+		vars[k] = v
+	}
+	if len(config.Properties.Values()) != 0 {
+		//Add global properties to the Lua runtime and call them through the global.xx method
+		vars[GlobalKey] = config.Properties.Values()
+	}
+	//Add global custom functions to the Lua runtime
+	for k, v := range config.Udf {
+		if _, ok := v.(string); ok {
+			// JavaScript source code
+			continue
+		} else if script, scriptOk := v.(types.Script); scriptOk {
+			if script.Type == types.Lua || script.Type == types.AllScript {
+				if p, ok := g.luaUdfProtoMap[k]; ok {
+					if err := g.run(vm, p); err != nil {
+						config.Logger.Printf("parse lua script=" + k + " error,err:" + err.Error())
+					}
+				} else if _, ok := script.Content.(string); !ok {
+					funcName := strings.Replace(k, types.Lua+types.ScriptFuncSeparator, "", 1)
+					vars[funcName] = script.Content
+				}
+			}
+		} else {
+			// parse go func
+			vars[k] = v
+		}
+	}
+	for k, v := range vars {
+		vm.SetGlobal(k, ToLValue(vm, v))
+	}
+	if err := g.run(vm, g.luaScript); err != nil {
+		config.Logger.Printf("lua vm error,err:" + err.Error())
+	}
+	return vm
+}
+
+// Execute Execute Lua function
+func (g *LuaEngine) Execute(ctx types.RuleContext, functionName string, argumentList ...interface{}) (out interface{}, err error) {
+	defer func() {
+		if caught := recover(); caught != nil {
+			err = fmt.Errorf("%s", caught)
+		}
+	}()
+
+	vm := g.vmPool.Get().(*lua.LState)
+
+	vm.SetGlobal(CtxKey, ToLValue(vm, ctx))
+
+	f, ok := vm.GetGlobal(functionName).(*lua.LFunction)
+	if !ok {
+		g.vmPool.Put(vm)
+		return nil, errors.New(functionName + " is not a function")
+	}
+	var params []lua.LValue
+	for _, v := range argumentList {
+		params = append(params, ToLValue(vm, v))
+	}
+	timeoutCtx, cancel := g.withTimeout(vm)
+	err = vm.CallByParam(lua.P{Fn: f, NRet: 1, Protect: true}, params...)
+	cancel()
+	if err != nil {
+		vm.SetTop(0)
+		if timeoutCtx.Err() == context.DeadlineExceeded {
+			// The interrupted state is discarded instead of putting it back to the pool
+			vm.Close()
+			return nil, ErrExecutionTimeout
+		}
+		g.vmPool.Put(vm)
+		return nil, err
+	}
+	res := vm.Get(-1)
+	vm.Pop(1)
+	out = ToGoValue(res)
+	//Put back to the pool
+	g.vmPool.Put(vm)
+	return out, nil
+}
+
+func (g *LuaEngine) Stop() {
+}
+
+// run runs the compiled chunk in the Lua state
+func (g *LuaEngine) run(vm *lua.LState, proto *lua.FunctionProto) error {
+	_, cancel := g.withTimeout(vm)
+	defer cancel()
+	vm.Push(vm.NewFunctionFromProto(proto))
+	return vm.PCall(0, lua.MultRet, nil)
+}
+
+// withTimeout if timeout interrupt the Lua script execution
+func (g *LuaEngine) withTimeout(vm *lua.LState) (context.Context, context.CancelFunc) {
+	if g.config.ScriptMaxExecutionTime <= 0 {
+		vm.RemoveContext()
+		return context.Background(), func() {}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), g.config.ScriptMaxExecutionTime)
+	vm.SetContext(ctx)
+	return ctx, func() {
+		vm.RemoveContext()
+		cancel()
+	}
+}
+
+// ToLValue converts the Go value to the Lua value
+func ToLValue(vm *lua.LState, v interface{}) lua.LValue {
+	switch value := v.(type) {
+	case nil:
+		return lua.LNil
+	case lua.LValue:
+		return value
+	case bool:
+		return lua.LBool(value)
+	case string:
+		return lua.LString(value)
+	case []byte:
+		table := vm.CreateTable(len(value), 0)
+		for _, b := range value {
+			table.Append(lua.LNumber(b))
+		}
+		return table
+	case map[string]interface{}:
+		table := vm.CreateTable(0, len(value))
+		for k, item := range value {
+			table.RawSetString(k, ToLValue(vm, item))
+		}
+		return table
+	case map[string]string:
+		table := vm.CreateTable(0, len(value))
+		for k, item := range value {
+			table.RawSetString(k, lua.LString(item))
+		}
+		return table
+	case []interface{}:
+		table := vm.CreateTable(len(value), 0)
+		for _, item := range value {
+			table.Append(ToLValue(vm, item))
+		}
+		return table
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return lua.LNumber(rv.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return lua.LNumber(rv.Uint())
+	case reflect.Float32, reflect.Float64:
+		return lua.LNumber(rv.Float())
+	case reflect.String:
+		return lua.LString(rv.String())
+	case reflect.Slice, reflect.Array:
+		table := vm.CreateTable(rv.Len(), 0)
+		for i := 0; i < rv.Len(); i++ {
+			table.Append(ToLValue(vm, rv.Index(i).Interface()))
+		}
+		return table
+	case reflect.Map:
+		if rv.Type().Key().Kind() == reflect.String {
+			table := vm.CreateTable(0, rv.Len())
+			iter := rv.MapRange()
+			for iter.Next() {
+				table.RawSetString(iter.Key().String(), ToLValue(vm, iter.Value().Interface()))
+			}
+			return table
+		}
+	}
+	// functions, structs and other values
+	return luar.New(vm, v)
+}
+
+// ToGoValue converts the Lua value to the Go value
+func ToGoValue(lv lua.LValue) interface{} {
+	switch value := lv.(type) {
+	case lua.LBool:
+		return bool(value)
+	case lua.LString:
+		return string(value)
+	case lua.LNumber:
+		f := float64(value)
+		if f == math.Trunc(f) && math.Abs(f) < 1<<53 {
+			return int64(f)
+		}
+		return f
+	case *lua.LTable:
+		if n := value.MaxN(); n > 0 && n == tableLen(value) {
+			list := make([]interface{}, 0, n)
+			for i := 1; i <= n; i++ {
+				list = append(list, ToGoValue(value.RawGetInt(i)))
+			}
+			return list
+		}
+		result := make(map[string]interface{})
+		value.ForEach(func(k lua.LValue, v lua.LValue) {
+			result[k.String()] = ToGoValue(v)
+		})
+		return result
+	case *lua.LUserData:
+		return value.Value
+	default:
+		return nil
+	}
+}
+
+// tableLen returns the number of the keys of the table
+func tableLen(table *lua.LTable) int {
+	count := 0
+	table.ForEach(func(lua.LValue, lua.LValue) {
+		count++
+	})
+	return count
+}
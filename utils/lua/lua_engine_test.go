@@ -0,0 +1,183 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package lua
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/yunboom/rulego/api/types"
+	"github.com/yunboom/rulego/test/assert"
+)
+
+type userService struct {
+}
+
+func (s *userService) Query(id string) string {
+	return "result:" + id
+}
+
+func TestLuaEngine(t *testing.T) {
+	var luaScript = `
+	function Filter(msg, metadata, msgType, dataType)
+		return msg.temperature > 50
+	end
+	function Transform(msg, metadata, msgType, dataType)
+		msg.temperature = msg.temperature * 2
+		msg.tags = {"a", "b"}
+		metadata.unit = "C"
+		return {msg = msg, metadata = metadata, msgType = "NEW_" .. msgType}
+	end
+	function GetValue()
+		return global.name .. "," .. vars.ip
+	end
+	function CallGolangFunc()
+		return add(1, 5) + add2(1, 1)
+	end
+	function CallStructFunc()
+		return tool:Query("user01")
+	end
+	function Bytes(msg)
+		return #msg .. ":" .. msg[1]
+	end
+	function Loop()
+		while true do end
+	end
+	`
+	config := types.NewConfig(types.WithScriptMaxExecutionTime(time.Millisecond * 200))
+	config.Properties.PutValue("name", "lala")
+	config.RegisterUdf("add", func(a, b int) int {
+		return a + b
+	})
+	config.RegisterUdf("add2", types.Script{
+		Type:    types.Lua,
+		Content: "function add2(a, b) return a + b + 100 end",
+	})
+	config.RegisterUdf("tool", &userService{})
+	// JavaScript 的 UDF 不会加载到 Lua 中
+	config.RegisterUdf("isNumber", types.Script{
+		Type:    types.Js,
+		Content: "function isNumber(v){ return typeof v === 'number' }",
+	})
+
+	engine, err := NewLuaEngine(config, luaScript, map[string]interface{}{
+		"vars": map[string]string{"ip": "127.0.0.1"},
+	})
+	assert.Nil(t, err)
+	defer engine.Stop()
+
+	metadata := map[string]string{"productType": "test"}
+	out, err := engine.Execute(nil, "Filter", map[string]interface{}{"temperature": 60}, metadata, "TEST", "JSON")
+	assert.Nil(t, err)
+	assert.Equal(t, true, out)
+
+	out, err = engine.Execute(nil, "Transform", map[string]interface{}{"temperature": 20.5}, metadata, "TEST", "JSON")
+	assert.Nil(t, err)
+	result := out.(map[string]interface{})
+	assert.Equal(t, "NEW_TEST", result["msgType"])
+	assert.Equal(t, map[string]interface{}{"temperature": int64(41), "tags": []interface{}{"a", "b"}}, result["msg"])
+	assert.Equal(t, map[string]interface{}{"productType": "test", "unit": "C"}, result["metadata"])
+
+	out, err = engine.Execute(nil, "GetValue")
+	assert.Nil(t, err)
+	assert.Equal(t, "lala,127.0.0.1", out)
+
+	out, err = engine.Execute(nil, "CallGolangFunc")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(108), out)
+
+	out, err = engine.Execute(nil, "CallStructFunc")
+	assert.Nil(t, err)
+	assert.Equal(t, "result:user01", out)
+
+	out, err = engine.Execute(nil, "Bytes", []byte{7, 8, 9})
+	assert.Nil(t, err)
+	assert.Equal(t, "3:7", out)
+
+	_, err = engine.Execute(nil, "NotFound")
+	assert.NotNil(t, err)
+
+	// 运行时错误
+	_, err = engine.Execute(nil, "Filter", "aa", metadata, "TEST", "TEXT")
+	assert.NotNil(t, err)
+
+	// 超时
+	start := time.Now()
+	_, err = engine.Execute(nil, "Loop")
+	assert.Equal(t, ErrExecutionTimeout, err)
+	assert.True(t, time.Since(start) < time.Second)
+
+	// 超时后引擎仍然可用
+	out, err = engine.Execute(nil, "Filter", map[string]interface{}{"temperature": 10}, metadata, "TEST", "JSON")
+	assert.Nil(t, err)
+	assert.Equal(t, false, out)
+
+	// 语法错误
+	_, err = NewLuaEngine(config, "function Filter(msg) return end end", nil)
+	assert.NotNil(t, err)
+}
+
+func TestLuaEngineSandbox(t *testing.T) {
+	var luaScript = `
+	function Libs()
+		return tostring(os == nil) .. "," .. tostring(io == nil) .. "," .. tostring(package == nil) .. "," ..
+			tostring(debug == nil) .. "," .. tostring(dofile == nil) .. "," .. tostring(loadfile == nil) .. "," .. tostring(require == nil)
+	end
+	function StdLibs()
+		return string.upper("a") .. table.concat({"b", "c"}) .. math.floor(1.5)
+	end
+	function OpenFile()
+		return io.open("/etc/hostname")
+	end
+	`
+	engine, err := NewLuaEngine(types.NewConfig(), luaScript, nil)
+	assert.Nil(t, err)
+	defer engine.Stop()
+
+	// 不能访问文件和进程
+	out, err := engine.Execute(nil, "Libs")
+	assert.Nil(t, err)
+	assert.Equal(t, "true,true,true,true,true,true,true", out)
+
+	out, err = engine.Execute(nil, "StdLibs")
+	assert.Nil(t, err)
+	assert.Equal(t, "Abc1", out)
+
+	_, err = engine.Execute(nil, "OpenFile")
+	assert.NotNil(t, err)
+}
+
+func TestLuaEngineConcurrent(t *testing.T) {
+	engine, err := NewLuaEngine(types.NewConfig(), `
+	function Add(msg)
+		return msg.a + msg.b
+	end
+	`, nil)
+	assert.Nil(t, err)
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			out, err := engine.Execute(nil, "Add", map[string]interface{}{"a": i, "b": 1})
+			assert.Nil(t, err)
+			assert.Equal(t, int64(i+1), out)
+		}(i)
+	}
+	wg.Wait()
+}
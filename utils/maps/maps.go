@@ -25,6 +25,7 @@
 // Key features:
 // - Map2Struct: Converts a map to a struct using reflection
 // - Get: Retrieves nested values from maps using dot notation
+// - Set/Delete: Sets or removes nested values of maps using dot notation
 // - Support for weakly typed input when converting maps to structs
 // - Handling of time.Duration conversions from string representations
 //
@@ -111,3 +112,34 @@ func Get(input interface{}, fieldName string) interface{} {
 	}
 	return result
 }
+
+// Set 设置map中的字段，支持嵌套结构设置，例如fieldName.subFieldName.xx
+// 不存在或者不是map[string]interface{}的中间字段会被替换成新的map[string]interface{}
+func Set(input map[string]interface{}, fieldName string, value interface{}) {
+	fields := strings.Split(fieldName, ".")
+	current := input
+	for _, field := range fields[:len(fields)-1] {
+		next, ok := current[field].(map[string]interface{})
+		if !ok {
+			next = make(map[string]interface{})
+			current[field] = next
+		}
+		current = next
+	}
+	current[fields[len(fields)-1]] = value
+}
+
+// Delete 删除map中的字段，支持嵌套结构删除，例如fieldName.subFieldName.xx
+// 如果字段不存在，不做任何处理
+func Delete(input map[string]interface{}, fieldName string) {
+	fields := strings.Split(fieldName, ".")
+	current := input
+	for _, field := range fields[:len(fields)-1] {
+		next, ok := current[field].(map[string]interface{})
+		if !ok {
+			return
+		}
+		current = next
+	}
+	delete(current, fields[len(fields)-1])
+}
@@ -154,3 +154,29 @@ func TestGet(t *testing.T) {
 	}
 	assert.Nil(t, Get(mapWithIntKey, "1"))
 }
+
+func TestSetAndDelete(t *testing.T) {
+	value := map[string]interface{}{
+		"name": "Alice",
+		"address": map[string]interface{}{
+			"city": "Beijing",
+		},
+	}
+	Set(value, "age", 25)
+	Set(value, "address.zipcode", "100000")
+	Set(value, "name.first", "A")
+	Set(value, "location.point.x", 1)
+	assert.Equal(t, 25, Get(value, "age"))
+	assert.Equal(t, "100000", Get(value, "address.zipcode"))
+	assert.Equal(t, "Beijing", Get(value, "address.city"))
+	assert.Equal(t, "A", Get(value, "name.first"))
+	assert.Equal(t, 1, Get(value, "location.point.x"))
+
+	Delete(value, "address.city")
+	Delete(value, "address.city.x")
+	Delete(value, "notfound.x")
+	Delete(value, "age")
+	assert.Nil(t, Get(value, "address.city"))
+	assert.Nil(t, Get(value, "age"))
+	assert.Equal(t, "100000", Get(value, "address.zipcode"))
+}
@@ -24,6 +24,8 @@
 // - Config: Struct for configuring the MQTT client connection.
 // - Client: The main struct representing the MQTT client.
 // - Handler: Struct for defining subscription handlers.
+// - ClientV5: MQTT 5 client supporting user properties and request/response.
+// - Publisher: Common publishing interface implemented by Client and ClientV5.
 //
 // The package supports features such as:
 // - TLS/SSL connections
@@ -31,6 +33,7 @@
 // - Automatic reconnection
 // - QoS levels for publishing and subscribing
 // - Custom message handlers for subscriptions
+// - Retained messages and MQTT 5 properties
 //
 // This package is crucial for components that require MQTT communication,
 // such as the MqttNode in the external package.
@@ -62,6 +65,35 @@ type Handler struct {
 	Handle func(c paho.Client, data paho.Message)
 }
 
+// Message 发布的消息，MQTT 5 属性在 MQTT 3.x 连接中会被忽略
+type Message struct {
+	//发布主题
+	Topic string
+	//QoS 0、1、2
+	Qos byte
+	//是否保留消息
+	Retain bool
+	//消息负荷
+	Payload []byte
+	//MQTT 5 用户属性
+	UserProperties map[string]string
+	//MQTT 5 响应主题
+	ResponseTopic string
+	//MQTT 5 对比数据，用于关联请求和响应
+	CorrelationData []byte
+}
+
+// DefaultPublishTimeout ctx 没有截止时间时，等待发布完成的默认超时
+const DefaultPublishTimeout = 5 * time.Second
+
+// Publisher 发布消息的客户端，Client 和 ClientV5 都实现了该接口
+type Publisher interface {
+	//PublishMessage 发布消息，QoS>0时等待broker确认，ctx 没有截止时间时最多等待 DefaultPublishTimeout
+	PublishMessage(ctx context.Context, msg Message) error
+	//Close 关闭客户端
+	Close() error
+}
+
 // Config 客户端配置
 type Config struct {
 	//mqtt broker 地址
@@ -75,10 +107,12 @@ type Config struct {
 	QOS                  uint8
 	CleanSession         bool
 	//client Id
-	ClientID    string
-	CAFile      string
-	CertFile    string
-	CertKeyFile string
+	ClientID string
+	//协议版本 3:MQTT 3.1 4:MQTT 3.1.1 5:MQTT 5.0，默认4。5需要使用 NewClientV5 创建客户端
+	ProtocolVersion uint
+	CAFile          string
+	CertFile        string
+	CertKeyFile     string
 }
 
 // Client mqtt客户端
@@ -96,6 +130,9 @@ type Client struct {
 // 支持自动重连和指数退避重试策略
 func NewClient(ctx context.Context, conf Config) (*Client, error) {
 	var err error
+	if conf.ProtocolVersion == ProtocolVersion5 {
+		return nil, errors.New("mqtt 5 is not supported by this client, use NewClientV5")
+	}
 
 	b := Client{
 		msgHandlerMap: make(map[string]Handler),
@@ -113,6 +150,9 @@ func NewClient(ctx context.Context, conf Config) (*Client, error) {
 	} else {
 		opts.SetClientID(conf.ClientID)
 	}
+	if conf.ProtocolVersion != 0 {
+		opts.SetProtocolVersion(conf.ProtocolVersion)
+	}
 
 	// 设置回调函数
 	opts.SetOnConnectHandler(b.onConnected)
@@ -219,6 +259,12 @@ func (b *Client) Close() error {
 	return nil
 }
 
+// ClientID 返回客户端ID
+func (b *Client) ClientID() string {
+	r := b.client.OptionsReader()
+	return r.ClientID()
+}
+
 // IsConnected 检查MQTT客户端是否已连接
 func (b *Client) IsConnected() bool {
 	return atomic.LoadInt32(&b.isConnected) == 1
@@ -244,6 +290,33 @@ func (b *Client) Publish(topic string, qos byte, data []byte) error {
 	return nil
 }
 
+// PublishMessage 发布消息，支持保留消息，MQTT 5 属性会被忽略
+func (b *Client) PublishMessage(ctx context.Context, msg Message) error {
+	if !b.IsConnected() {
+		return errors.New("MQTT client is not connected")
+	}
+	ctx, cancel := publishContext(ctx)
+	defer cancel()
+	token := b.client.Publish(msg.Topic, msg.Qos, msg.Retain, msg.Payload)
+	select {
+	case <-token.Done():
+		return token.Error()
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// publishContext ctx 没有截止时间时，使用 DefaultPublishTimeout 作为发布超时
+func publishContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if _, ok := ctx.Deadline(); ok {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, DefaultPublishTimeout)
+}
+
 // onConnected MQTT连接成功回调
 func (b *Client) onConnected(c paho.Client) {
 	atomic.StoreInt32(&b.isConnected, 1)
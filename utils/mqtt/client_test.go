@@ -158,6 +158,23 @@ func TestClient_Publish_NotConnected(t *testing.T) {
 }
 
 // TestClient_RegisterHandler 测试注册处理器 - 跳过因为需要真实MQTT客户端
+// 测试 ctx 没有截止时间时使用默认发布超时
+func TestPublishContext(t *testing.T) {
+	ctx, cancel := publishContext(context.Background())
+	defer cancel()
+	deadline, ok := ctx.Deadline()
+	assert.True(t, ok)
+	assert.True(t, time.Until(deadline) <= DefaultPublishTimeout)
+
+	parent, parentCancel := context.WithTimeout(context.Background(), time.Second)
+	defer parentCancel()
+	parentDeadline, _ := parent.Deadline()
+	ctx, cancel = publishContext(parent)
+	defer cancel()
+	deadline, _ = ctx.Deadline()
+	assert.Equal(t, parentDeadline, deadline)
+}
+
 func TestClient_RegisterHandler(t *testing.T) {
 	t.Skip("RegisterHandler requires a real MQTT client connection")
 }
@@ -0,0 +1,364 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mqtt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/eclipse/paho.golang/autopaho"
+	paho5 "github.com/eclipse/paho.golang/paho"
+	string2 "github.com/yunboom/rulego/utils/str"
+)
+
+// MQTT 协议版本
+const (
+	ProtocolVersion31  = 3
+	ProtocolVersion311 = 4
+	ProtocolVersion5   = 5
+)
+
+// ErrRequestTimeout 等待响应超时
+var ErrRequestTimeout = errors.New("mqtt request timeout waiting for response")
+
+// ClientV5 MQTT 5 客户端，支持用户属性以及基于响应主题和对比数据的请求/响应模式
+type ClientV5 struct {
+	cm       *autopaho.ConnectionManager
+	cancel   context.CancelFunc
+	clientId string
+	lock     sync.Mutex
+	//对比数据和等待响应的请求映射
+	pending map[string]chan Message
+	//响应主题和订阅状态映射
+	responseTopics map[string]*responseTopic
+}
+
+// responseTopic 响应主题的订阅状态
+type responseTopic struct {
+	//引用计数，为0表示正在取消订阅
+	refs int
+	//向broker订阅完成后关闭，err 为订阅结果
+	ready chan struct{}
+	err   error
+	//取消订阅完成后关闭
+	closed chan struct{}
+}
+
+// NewClientV5 创建一个MQTT 5客户端实例，ctx 用于控制首次连接的等待时间
+// 连接断开后会在后台自动重连，并重新订阅响应主题
+func NewClientV5(ctx context.Context, conf Config) (*ClientV5, error) {
+	serverUrl, err := parseServerUrl(conf.Server)
+	if err != nil {
+		return nil, err
+	}
+	tlsconfig, err := newTLSConfig(conf.CAFile, conf.CertFile, conf.CertKeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("error loading mqtt certificate files,ca_cert=%s,tls_cert=%s,tls_key=%s", conf.CAFile, conf.CertFile, conf.CertKeyFile)
+	}
+	clientId := conf.ClientID
+	if clientId == "" {
+		//随机clientId
+		clientId = "rulego/" + string2.RandomStr(8)
+	}
+	b := &ClientV5{
+		clientId:       clientId,
+		pending:        make(map[string]chan Message),
+		responseTopics: make(map[string]*responseTopic),
+	}
+	cfg := autopaho.ClientConfig{
+		ServerUrls:                    []*url.URL{serverUrl},
+		TlsCfg:                        tlsconfig,
+		KeepAlive:                     30,
+		CleanStartOnInitialConnection: conf.CleanSession,
+		ConnectRetryDelay:             2 * time.Second,
+		ConnectUsername:               conf.Username,
+		ConnectPassword:               []byte(conf.Password),
+		OnConnectionUp: func(cm *autopaho.ConnectionManager, _ *paho5.Connack) {
+			b.resubscribe(cm)
+		},
+		ClientConfig: paho5.ClientConfig{
+			ClientID:          clientId,
+			OnPublishReceived: []func(paho5.PublishReceived) (bool, error){b.onPublishReceived},
+		},
+	}
+	connCtx, cancel := context.WithCancel(context.Background())
+	cm, err := autopaho.NewConnection(connCtx, cfg)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	b.cm = cm
+	b.cancel = cancel
+	if err := cm.AwaitConnection(ctx); err != nil {
+		cancel()
+		<-cm.Done()
+		return nil, fmt.Errorf("failed to connect mqtt server %s: %w", conf.Server, err)
+	}
+	return b, nil
+}
+
+// PublishMessage 发布消息，QoS>0时等待broker确认
+func (b *ClientV5) PublishMessage(ctx context.Context, msg Message) error {
+	ctx, cancel := publishContext(ctx)
+	defer cancel()
+	_, err := b.cm.Publish(ctx, toPublish(msg))
+	return err
+}
+
+// Request 请求/响应模式：订阅消息的响应主题后发布请求，等待携带相同对比数据的响应或者ctx超时
+// 如果消息没有对比数据，则随机生成一个
+func (b *ClientV5) Request(ctx context.Context, msg Message) (Message, error) {
+	if msg.ResponseTopic == "" {
+		return Message{}, errors.New("response topic can not be empty")
+	}
+	if len(msg.CorrelationData) == 0 {
+		msg.CorrelationData = []byte(string2.RandomStr(16))
+	}
+	correlationId := string(msg.CorrelationData)
+	replyCh := make(chan Message, 1)
+
+	b.lock.Lock()
+	if _, ok := b.pending[correlationId]; ok {
+		b.lock.Unlock()
+		return Message{}, fmt.Errorf("duplicate correlation data: %s", correlationId)
+	}
+	b.pending[correlationId] = replyCh
+	b.lock.Unlock()
+	defer func() {
+		b.lock.Lock()
+		delete(b.pending, correlationId)
+		b.lock.Unlock()
+	}()
+
+	if err := b.SubscribeResponseTopic(ctx, msg.ResponseTopic); err != nil {
+		return Message{}, err
+	}
+	defer b.UnsubscribeResponseTopic(msg.ResponseTopic)
+
+	if err := b.PublishMessage(ctx, msg); err != nil {
+		return Message{}, err
+	}
+	select {
+	case reply := <-replyCh:
+		return reply, nil
+	case <-ctx.Done():
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return Message{}, ErrRequestTimeout
+		}
+		return Message{}, ctx.Err()
+	}
+}
+
+// SubscribeResponseTopic 订阅响应主题，同一主题多次订阅只会向broker订阅一次
+// 每次调用需要对应一次 UnsubscribeResponseTopic，订阅失败不需要调用
+// 向broker订阅期间不持有锁，不会阻塞响应的分发和其他请求
+func (b *ClientV5) SubscribeResponseTopic(ctx context.Context, topic string) error {
+	for {
+		b.lock.Lock()
+		t, ok := b.responseTopics[topic]
+		if !ok {
+			t = &responseTopic{refs: 1, ready: make(chan struct{}), closed: make(chan struct{})}
+			b.responseTopics[topic] = t
+			b.lock.Unlock()
+			t.err = subscribe(ctx, b.cm, topic)
+			close(t.ready)
+			if t.err != nil {
+				//回滚引用计数
+				b.lock.Lock()
+				b.releaseResponseTopic(topic, t)
+			}
+			return t.err
+		}
+		if t.refs == 0 {
+			//正在取消订阅，完成后重新订阅
+			b.lock.Unlock()
+			select {
+			case <-t.closed:
+				continue
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+		t.refs++
+		b.lock.Unlock()
+		//等待其他请求发起的订阅完成
+		select {
+		case <-t.ready:
+			if t.err == nil {
+				return nil
+			}
+			b.lock.Lock()
+			b.releaseResponseTopic(topic, t)
+			return t.err
+		case <-ctx.Done():
+			b.lock.Lock()
+			b.releaseResponseTopic(topic, t)
+			return ctx.Err()
+		}
+	}
+}
+
+// UnsubscribeResponseTopic 释放响应主题，引用计数为0时取消订阅
+func (b *ClientV5) UnsubscribeResponseTopic(topic string) {
+	b.lock.Lock()
+	t, ok := b.responseTopics[topic]
+	if !ok || t.refs == 0 {
+		b.lock.Unlock()
+		return
+	}
+	b.releaseResponseTopic(topic, t)
+}
+
+// releaseResponseTopic 减少响应主题的引用计数，为0时向broker取消订阅
+// 调用前需要持有 b.lock，返回前释放
+func (b *ClientV5) releaseResponseTopic(topic string, t *responseTopic) {
+	t.refs--
+	if t.refs > 0 {
+		b.lock.Unlock()
+		return
+	}
+	b.lock.Unlock()
+	<-t.ready
+	if t.err == nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		_, _ = b.cm.Unsubscribe(ctx, &paho5.Unsubscribe{Topics: []string{topic}})
+		cancel()
+	}
+	b.lock.Lock()
+	if b.responseTopics[topic] == t {
+		delete(b.responseTopics, topic)
+	}
+	b.lock.Unlock()
+	close(t.closed)
+}
+
+// ClientID 返回客户端ID
+func (b *ClientV5) ClientID() string {
+	return b.clientId
+}
+
+// Close 断开连接
+func (b *ClientV5) Close() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+	_ = b.cm.Disconnect(ctx)
+	b.cancel()
+	return nil
+}
+
+// onPublishReceived 把响应分发给等待的请求
+func (b *ClientV5) onPublishReceived(pr paho5.PublishReceived) (bool, error) {
+	p := pr.Packet
+	if p.Properties == nil || len(p.Properties.CorrelationData) == 0 {
+		return false, nil
+	}
+	b.lock.Lock()
+	replyCh, ok := b.pending[string(p.Properties.CorrelationData)]
+	b.lock.Unlock()
+	if !ok {
+		return false, nil
+	}
+	select {
+	case replyCh <- fromPublish(p):
+	default:
+		//已经收到过响应，丢弃重复的响应
+	}
+	return true, nil
+}
+
+// resubscribe 重连后重新订阅响应主题
+func (b *ClientV5) resubscribe(cm *autopaho.ConnectionManager) {
+	b.lock.Lock()
+	topics := make([]string, 0, len(b.responseTopics))
+	for topic, t := range b.responseTopics {
+		if t.refs > 0 {
+			topics = append(topics, topic)
+		}
+	}
+	b.lock.Unlock()
+	for _, topic := range topics {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		_ = subscribe(ctx, cm, topic)
+		cancel()
+	}
+}
+
+func subscribe(ctx context.Context, cm *autopaho.ConnectionManager, topic string) error {
+	suback, err := cm.Subscribe(ctx, &paho5.Subscribe{
+		Subscriptions: []paho5.SubscribeOptions{{Topic: topic, QoS: 1}},
+	})
+	if err != nil {
+		return err
+	}
+	if len(suback.Reasons) > 0 && suback.Reasons[0] >= 0x80 {
+		return fmt.Errorf("subscribe topic %s failed, reason code: %d", topic, suback.Reasons[0])
+	}
+	return nil
+}
+
+func toPublish(msg Message) *paho5.Publish {
+	p := &paho5.Publish{
+		Topic:   msg.Topic,
+		QoS:     msg.Qos,
+		Retain:  msg.Retain,
+		Payload: msg.Payload,
+		Properties: &paho5.PublishProperties{
+			ResponseTopic:   msg.ResponseTopic,
+			CorrelationData: msg.CorrelationData,
+		},
+	}
+	for k, v := range msg.UserProperties {
+		p.Properties.User.Add(k, v)
+	}
+	return p
+}
+
+func fromPublish(p *paho5.Publish) Message {
+	msg := Message{
+		Topic:   p.Topic,
+		Qos:     p.QoS,
+		Retain:  p.Retain,
+		Payload: p.Payload,
+	}
+	if p.Properties != nil {
+		msg.ResponseTopic = p.Properties.ResponseTopic
+		msg.CorrelationData = p.Properties.CorrelationData
+		if len(p.Properties.User) > 0 {
+			msg.UserProperties = make(map[string]string, len(p.Properties.User))
+			for _, item := range p.Properties.User {
+				msg.UserProperties[item.Key] = item.Value
+			}
+		}
+	}
+	return msg
+}
+
+// parseServerUrl 解析broker地址，没有协议头时默认使用 mqtt://
+func parseServerUrl(server string) (*url.URL, error) {
+	if server == "" {
+		return nil, errors.New("server can not be empty")
+	}
+	if !strings.Contains(server, "://") {
+		server = "mqtt://" + server
+	}
+	return url.Parse(server)
+}
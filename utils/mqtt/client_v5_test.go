@@ -0,0 +1,106 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mqtt
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/eclipse/paho.golang/packets"
+	"github.com/yunboom/rulego/test"
+	"github.com/yunboom/rulego/test/assert"
+)
+
+func TestClientV5(t *testing.T) {
+	broker := test.NewMqtt5Broker(t)
+	defer broker.Close()
+	//请求主题原样回复到响应主题
+	broker.SetResponder(func(p *packets.Publish) *packets.Publish {
+		if p.Topic != "rpc/req" {
+			return nil
+		}
+		return &packets.Publish{Topic: p.Properties.ResponseTopic, Payload: append([]byte("re:"), p.Payload...), Properties: &packets.Properties{
+			CorrelationData: p.Properties.CorrelationData,
+			User:            []packets.User{{Key: "status", Value: "ok"}},
+		}}
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	client, err := NewClientV5(ctx, Config{Server: broker.Addr()})
+	assert.Nil(t, err)
+	defer client.Close()
+
+	t.Run("Publish", func(t *testing.T) {
+		for _, qos := range []byte{0, 1, 2} {
+			err := client.PublishMessage(ctx, Message{Topic: "device/msg", Qos: qos, Retain: qos == 2, Payload: []byte("hello"),
+				UserProperties: map[string]string{"deviceId": "d1"}})
+			assert.Nil(t, err)
+		}
+		time.Sleep(100 * time.Millisecond)
+		published := broker.Published()
+		assert.Equal(t, 3, len(published))
+		assert.Equal(t, byte(2), published[2].QoS)
+		assert.True(t, published[2].Retain)
+		assert.Equal(t, "d1", published[2].Properties.User[0].Value)
+	})
+
+	t.Run("Request", func(t *testing.T) {
+		reply, err := client.Request(ctx, Message{Topic: "rpc/req", Qos: 1, Payload: []byte("ping"), ResponseTopic: "rpc/resp", CorrelationData: []byte("c1")})
+		assert.Nil(t, err)
+		assert.Equal(t, "re:ping", string(reply.Payload))
+		assert.Equal(t, "c1", string(reply.CorrelationData))
+		assert.Equal(t, "ok", reply.UserProperties["status"])
+		//请求结束后取消订阅
+		time.Sleep(100 * time.Millisecond)
+		assert.False(t, broker.Subscribed("rpc/resp"))
+
+		//随机生成对比数据
+		reply, err = client.Request(ctx, Message{Topic: "rpc/req", Payload: []byte("ping"), ResponseTopic: "rpc/resp"})
+		assert.Nil(t, err)
+		assert.Equal(t, 16, len(reply.CorrelationData))
+
+		//常驻订阅的响应主题在请求结束后保留
+		assert.Nil(t, client.SubscribeResponseTopic(ctx, "rpc/resp"))
+		_, err = client.Request(ctx, Message{Topic: "rpc/req", Payload: []byte("ping"), ResponseTopic: "rpc/resp"})
+		assert.Nil(t, err)
+		assert.True(t, broker.Subscribed("rpc/resp"))
+		client.UnsubscribeResponseTopic("rpc/resp")
+
+		_, err = client.Request(ctx, Message{Topic: "rpc/req"})
+		assert.NotNil(t, err)
+	})
+
+	t.Run("RequestTimeout", func(t *testing.T) {
+		timeoutCtx, timeoutCancel := context.WithTimeout(ctx, 200*time.Millisecond)
+		defer timeoutCancel()
+		_, err := client.Request(timeoutCtx, Message{Topic: "rpc/none", Payload: []byte("ping"), ResponseTopic: "rpc/resp"})
+		assert.Equal(t, ErrRequestTimeout, err)
+	})
+
+	t.Run("ConnectError", func(t *testing.T) {
+		connCtx, connCancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+		defer connCancel()
+		_, err := NewClientV5(connCtx, Config{Server: "127.0.0.1:1"})
+		assert.NotNil(t, err)
+		_, err = NewClientV5(connCtx, Config{})
+		assert.NotNil(t, err)
+		_, err = NewClient(connCtx, Config{Server: broker.Addr(), ProtocolVersion: ProtocolVersion5})
+		assert.NotNil(t, err)
+	})
+}
@@ -17,7 +17,55 @@
 package schema
 
 import (
+	"encoding/json"
 	"fmt"
+	"math"
+	"net"
+	"net/mail"
+	"net/url"
+	"os"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// 校验规则名称
+const (
+	RuleRequired  = "required"
+	RuleType      = "type"
+	RuleEnum      = "enum"
+	RuleFormat    = "format"
+	RulePattern   = "pattern"
+	RuleMinimum   = "minimum"
+	RuleMaximum   = "maximum"
+	RuleMinLength = "minLength"
+	RuleMaxLength = "maxLength"
+	RuleMinItems  = "minItems"
+	RuleMaxItems  = "maxItems"
+)
+
+// 支持的字符串格式
+const (
+	FormatEmail    = "email"
+	FormatUri      = "uri"
+	FormatDateTime = "date-time"
+	FormatDate     = "date"
+	FormatTime     = "time"
+	FormatIpv4     = "ipv4"
+	FormatIpv6     = "ipv6"
+	FormatUuid     = "uuid"
+	FormatHostname = "hostname"
+)
+
+var (
+	uuidRegex     = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+	hostnameRegex = regexp.MustCompile(`^([a-zA-Z0-9]([a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?)(\.[a-zA-Z0-9]([a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?)*$`)
+	// patternCache 缓存已编译的 pattern
+	patternCache sync.Map
 )
 
 // JSONSchema 定义了 JSON Schema 的结构
@@ -25,6 +73,8 @@ type JSONSchema struct {
 	Type       string                 `json:"type"`
 	Properties map[string]FieldSchema `json:"properties"`
 	Required   []string               `json:"required"`
+	// Items 数组元素的 Schema，Type 为 array 时有效
+	Items *FieldSchema `json:"items,omitempty"`
 }
 
 // CheckFieldIsRequired 检查字段是否在 Required 列表中
@@ -39,13 +89,23 @@ func (s JSONSchema) CheckFieldIsRequired(fieldName string) bool {
 
 // FieldSchema 定义了单个字段的 Schema
 type FieldSchema struct {
-	Type        string                 `json:"type"`        //类型
-	Title       string                 `json:"title"`       //标题
-	Description string                 `json:"description"` //描述
-	Default     interface{}            `json:"default"`     //默认值
-	Properties  map[string]FieldSchema `json:"properties"`  // 嵌套字段
-	Required    []string               `json:"required"`    // 嵌套字段的必填列表
-	Component   map[string]interface{} `json:"component"`   //前端表单组件配置
+	Type        string                 `json:"type"`                //类型
+	Title       string                 `json:"title"`               //标题
+	Description string                 `json:"description"`         //描述
+	Default     interface{}            `json:"default"`             //默认值
+	Properties  map[string]FieldSchema `json:"properties"`          // 嵌套字段
+	Required    []string               `json:"required"`            // 嵌套字段的必填列表
+	Component   map[string]interface{} `json:"component"`           //前端表单组件配置
+	Enum        []interface{}          `json:"enum,omitempty"`      //枚举值
+	Format      string                 `json:"format,omitempty"`    //字符串格式，例如：email、uri、date-time、ipv4、uuid
+	Pattern     string                 `json:"pattern,omitempty"`   //字符串正则表达式
+	Minimum     *float64               `json:"minimum,omitempty"`   //最小值
+	Maximum     *float64               `json:"maximum,omitempty"`   //最大值
+	MinLength   *int                   `json:"minLength,omitempty"` //字符串最小长度
+	MaxLength   *int                   `json:"maxLength,omitempty"` //字符串最大长度
+	MinItems    *int                   `json:"minItems,omitempty"`  //数组最少元素个数
+	MaxItems    *int                   `json:"maxItems,omitempty"`  //数组最多元素个数
+	Items       *FieldSchema           `json:"items,omitempty"`     //数组元素的 Schema
 }
 
 // Data 定义了 JSON 数据的结构
@@ -53,25 +113,194 @@ type Data struct {
 	Properties map[string]interface{} `json:"properties"`
 }
 
+// FieldError 单个字段的校验错误
+type FieldError struct {
+	// Field 字段路径，例如：address.street、items[0].id
+	Field string `json:"field"`
+	// Rule 违反的规则，例如：required、type、enum
+	Rule string `json:"rule"`
+	// Message 错误描述
+	Message string `json:"message"`
+}
+
+func (e *FieldError) Error() string {
+	if e.Rule == RuleRequired {
+		return "missing required field: " + e.Field
+	}
+	if e.Field == "" {
+		return e.Message
+	}
+	return fmt.Sprintf("field %s: %s", e.Field, e.Message)
+}
+
+// ValidationErrors 校验错误列表，包含所有违反规则的字段
+type ValidationErrors []*FieldError
+
+func (e ValidationErrors) Error() string {
+	var items = make([]string, 0, len(e))
+	for _, item := range e {
+		items = append(items, item.Error())
+	}
+	return strings.Join(items, "; ")
+}
+
+// Validate 验证 JSON 数据是否符合该 Schema，失败返回 ValidationErrors
+func (s JSONSchema) Validate(data map[string]interface{}) error {
+	return validateData(data, s)
+}
+
+// ValidateValue 验证任意 JSON 值是否符合该 Schema，失败返回 ValidationErrors
+func (s JSONSchema) ValidateValue(value interface{}) error {
+	var errs ValidationErrors
+	validateValue("", value, s.fieldSchema(), &errs)
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// fieldSchema 把顶层 Schema 转换成 FieldSchema
+func (s JSONSchema) fieldSchema() FieldSchema {
+	return FieldSchema{
+		Type:       s.Type,
+		Properties: s.Properties,
+		Required:   s.Required,
+		Items:      s.Items,
+	}
+}
+
+// Parse 把 JSON 字符串、字节数组或者 map 解析成 JSONSchema
+func Parse(v interface{}) (JSONSchema, error) {
+	var s JSONSchema
+	var b []byte
+	switch value := v.(type) {
+	case JSONSchema:
+		return value, nil
+	case *JSONSchema:
+		if value == nil {
+			return s, fmt.Errorf("schema is nil")
+		}
+		return *value, nil
+	case string:
+		b = []byte(value)
+	case []byte:
+		b = value
+	default:
+		var err error
+		if b, err = json.Marshal(value); err != nil {
+			return s, err
+		}
+	}
+	err := json.Unmarshal(b, &s)
+	return s, err
+}
+
+// ParseFile 从文件加载 JSONSchema
+func ParseFile(path string) (JSONSchema, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return JSONSchema{}, err
+	}
+	return Parse(b)
+}
+
 // validateData 验证 JSON 数据是否符合 JSON Schema
 func validateData(data map[string]interface{}, schema JSONSchema) error {
+	var errs ValidationErrors
+	validateObject("", data, schema.Properties, schema.Required, &errs)
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// validateObject 验证对象的必填字段和每个字段的值
+func validateObject(path string, data map[string]interface{}, properties map[string]FieldSchema, required []string, errs *ValidationErrors) {
 	// 检查 required 字段
-	for _, field := range schema.Required {
+	for _, field := range required {
 		if _, ok := data[field]; !ok {
-			return fmt.Errorf("missing required field: %s", field)
+			*errs = append(*errs, &FieldError{Field: joinPath(path, field), Rule: RuleRequired, Message: "missing required field"})
 		}
 	}
-
-	// 检查每个字段的类型
-	for fieldName, fieldSchema := range schema.Properties {
+	// 按字段名顺序检查每个字段，保证错误顺序稳定
+	var fieldNames = make([]string, 0, len(properties))
+	for fieldName := range properties {
+		fieldNames = append(fieldNames, fieldName)
+	}
+	sort.Strings(fieldNames)
+	for _, fieldName := range fieldNames {
 		if value, ok := data[fieldName]; ok {
-			if err := validateFieldType(value, fieldSchema.Type); err != nil {
-				return fmt.Errorf("field %s: %v", fieldName, err)
-			}
+			validateValue(joinPath(path, fieldName), value, properties[fieldName], errs)
 		}
 	}
+}
 
-	return nil
+// validateValue 验证单个值，包括类型、枚举、格式、范围以及嵌套对象和数组
+func validateValue(path string, value interface{}, schema FieldSchema, errs *ValidationErrors) {
+	if schema.Type != "" {
+		if err := validateFieldType(value, schema.Type); err != nil {
+			*errs = append(*errs, &FieldError{Field: path, Rule: RuleType, Message: err.Error()})
+			return
+		}
+	}
+	if len(schema.Enum) > 0 && !inEnum(value, schema.Enum) {
+		*errs = append(*errs, &FieldError{Field: path, Rule: RuleEnum, Message: fmt.Sprintf("value %v is not one of %v", value, schema.Enum)})
+	}
+	switch v := value.(type) {
+	case string:
+		validateString(path, v, schema, errs)
+	case map[string]interface{}:
+		validateObject(path, v, schema.Properties, schema.Required, errs)
+	case []interface{}:
+		validateArray(path, v, schema, errs)
+	default:
+		if f, ok := toFloat64(value); ok {
+			validateNumber(path, f, schema, errs)
+		}
+	}
+}
+
+func validateString(path string, v string, schema FieldSchema, errs *ValidationErrors) {
+	length := utf8.RuneCountInString(v)
+	if schema.MinLength != nil && length < *schema.MinLength {
+		*errs = append(*errs, &FieldError{Field: path, Rule: RuleMinLength, Message: fmt.Sprintf("length %d is less than %d", length, *schema.MinLength)})
+	}
+	if schema.MaxLength != nil && length > *schema.MaxLength {
+		*errs = append(*errs, &FieldError{Field: path, Rule: RuleMaxLength, Message: fmt.Sprintf("length %d is greater than %d", length, *schema.MaxLength)})
+	}
+	if schema.Pattern != "" {
+		if re, err := compilePattern(schema.Pattern); err != nil {
+			*errs = append(*errs, &FieldError{Field: path, Rule: RulePattern, Message: fmt.Sprintf("invalid pattern %s: %v", schema.Pattern, err)})
+		} else if !re.MatchString(v) {
+			*errs = append(*errs, &FieldError{Field: path, Rule: RulePattern, Message: fmt.Sprintf("value does not match pattern %s", schema.Pattern)})
+		}
+	}
+	if schema.Format != "" && !checkFormat(v, schema.Format) {
+		*errs = append(*errs, &FieldError{Field: path, Rule: RuleFormat, Message: fmt.Sprintf("value is not a valid %s", schema.Format)})
+	}
+}
+
+func validateNumber(path string, v float64, schema FieldSchema, errs *ValidationErrors) {
+	if schema.Minimum != nil && v < *schema.Minimum {
+		*errs = append(*errs, &FieldError{Field: path, Rule: RuleMinimum, Message: fmt.Sprintf("value %v is less than %v", v, *schema.Minimum)})
+	}
+	if schema.Maximum != nil && v > *schema.Maximum {
+		*errs = append(*errs, &FieldError{Field: path, Rule: RuleMaximum, Message: fmt.Sprintf("value %v is greater than %v", v, *schema.Maximum)})
+	}
+}
+
+func validateArray(path string, v []interface{}, schema FieldSchema, errs *ValidationErrors) {
+	if schema.MinItems != nil && len(v) < *schema.MinItems {
+		*errs = append(*errs, &FieldError{Field: path, Rule: RuleMinItems, Message: fmt.Sprintf("items count %d is less than %d", len(v), *schema.MinItems)})
+	}
+	if schema.MaxItems != nil && len(v) > *schema.MaxItems {
+		*errs = append(*errs, &FieldError{Field: path, Rule: RuleMaxItems, Message: fmt.Sprintf("items count %d is greater than %d", len(v), *schema.MaxItems)})
+	}
+	if schema.Items != nil {
+		for i, item := range v {
+			validateValue(fmt.Sprintf("%s[%d]", path, i), item, *schema.Items, errs)
+		}
+	}
 }
 
 // validateFieldType 验证字段的类型是否符合 Schema 定义
@@ -82,9 +311,14 @@ func validateFieldType(value interface{}, fieldType string) error {
 			return fmt.Errorf("expected string, got %T", value)
 		}
 	case "integer":
-		if _, ok := value.(float64); !ok { // JSON 中的整数通常被解析为 float64
+		// JSON 中的整数通常被解析为 float64
+		if f, ok := toFloat64(value); !ok || f != math.Trunc(f) {
 			return fmt.Errorf("expected integer, got %T", value)
 		}
+	case "number":
+		if _, ok := toFloat64(value); !ok {
+			return fmt.Errorf("expected number, got %T", value)
+		}
 	case "boolean":
 		if _, ok := value.(bool); !ok {
 			return fmt.Errorf("expected boolean, got %T", value)
@@ -97,8 +331,112 @@ func validateFieldType(value interface{}, fieldType string) error {
 		if _, ok := value.(map[string]interface{}); !ok {
 			return fmt.Errorf("expected object, got %T", value)
 		}
+	case "null":
+		if value != nil {
+			return fmt.Errorf("expected null, got %T", value)
+		}
 	default:
 		return fmt.Errorf("unsupported type: %s", fieldType)
 	}
 	return nil
 }
+
+// checkFormat 检查字符串格式，未知的格式不做校验
+func checkFormat(v string, format string) bool {
+	switch format {
+	case FormatEmail:
+		addr, err := mail.ParseAddress(v)
+		return err == nil && addr.Address == v
+	case FormatUri:
+		u, err := url.Parse(v)
+		return err == nil && u.Scheme != ""
+	case FormatDateTime:
+		_, err := time.Parse(time.RFC3339, v)
+		return err == nil
+	case FormatDate:
+		_, err := time.Parse("2006-01-02", v)
+		return err == nil
+	case FormatTime:
+		_, err := time.Parse("15:04:05", v)
+		return err == nil
+	case FormatIpv4:
+		ip := net.ParseIP(v)
+		return ip != nil && ip.To4() != nil && strings.Contains(v, ".")
+	case FormatIpv6:
+		ip := net.ParseIP(v)
+		return ip != nil && strings.Contains(v, ":")
+	case FormatUuid:
+		return uuidRegex.MatchString(v)
+	case FormatHostname:
+		return len(v) <= 253 && hostnameRegex.MatchString(v)
+	default:
+		return true
+	}
+}
+
+func compilePattern(pattern string) (*regexp.Regexp, error) {
+	if v, ok := patternCache.Load(pattern); ok {
+		return v.(*regexp.Regexp), nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	patternCache.Store(pattern, re)
+	return re, nil
+}
+
+func inEnum(value interface{}, enum []interface{}) bool {
+	for _, item := range enum {
+		if reflect.DeepEqual(value, item) {
+			return true
+		}
+		if a, ok := toFloat64(value); ok {
+			if b, ok := toFloat64(item); ok && a == b {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func toFloat64(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case int8:
+		return float64(v), true
+	case int16:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case uint:
+		return float64(v), true
+	case uint8:
+		return float64(v), true
+	case uint16:
+		return float64(v), true
+	case uint32:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	case json.Number:
+		f, err := v.Float64()
+		return f, err == nil
+	default:
+		return 0, false
+	}
+}
+
+func joinPath(parent, field string) string {
+	if parent == "" {
+		return field
+	}
+	return parent + "." + field
+}
@@ -17,6 +17,9 @@
 package schema
 
 import (
+	"encoding/json"
+	"errors"
+	"strings"
 	"testing"
 )
 
@@ -178,12 +181,8 @@ func TestValidateData(t *testing.T) {
 			schema:  schema,
 			wantErr: false,
 		},
-		// Note: Current validateData doesn't deeply validate nested object fields' types or their required fields.
-		// It only checks the type of the 'address' field itself (should be 'object').
-		// Adding a test for missing required field in nested object to highlight this.
-		// This test will pass because `validateData` doesn't check nested `Required` fields.
 		{
-			name: "Nested object missing required field (current validateData won't catch this)",
+			name: "Nested object missing required field",
 			data: map[string]interface{}{
 				"name": "Jane Doe",
 				"age":  float64(25),
@@ -191,8 +190,22 @@ func TestValidateData(t *testing.T) {
 					"city": "Anytown", // "street" is missing
 				},
 			},
-			schema:  schema,
-			wantErr: false, // This will pass as current implementation doesn't check nested required
+			schema:    schema,
+			wantErr:   true,
+			errString: "missing required field: address.street",
+		},
+		{
+			name: "Nested object invalid field type",
+			data: map[string]interface{}{
+				"name": "Jane Doe",
+				"age":  float64(25),
+				"address": map[string]interface{}{
+					"street": 123,
+				},
+			},
+			schema:    schema,
+			wantErr:   true,
+			errString: "field address.street: expected string, got int",
 		},
 	}
 	for _, tt := range tests {
@@ -209,6 +222,95 @@ func TestValidateData(t *testing.T) {
 	}
 }
 
+func TestValidateRules(t *testing.T) {
+	var s JSONSchema
+	err := json.Unmarshal([]byte(`{
+		"type": "object",
+		"properties": {
+			"email": {"type": "string", "format": "email"},
+			"level": {"type": "string", "enum": ["info", "warn", "error"]},
+			"code": {"type": "string", "pattern": "^[A-Z]{3}[0-9]{2}$", "minLength": 5, "maxLength": 5},
+			"temperature": {"type": "number", "minimum": -40, "maximum": 125},
+			"count": {"type": "integer"},
+			"ts": {"type": "string", "format": "date-time"},
+			"ip": {"type": "string", "format": "ipv4"},
+			"id": {"type": "string", "format": "uuid"},
+			"items": {
+				"type": "array",
+				"minItems": 1,
+				"maxItems": 2,
+				"items": {
+					"type": "object",
+					"properties": {"sku": {"type": "string"}, "qty": {"type": "integer", "minimum": 1}},
+					"required": ["sku"]
+				}
+			}
+		},
+		"required": ["email"]
+	}`), &s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	valid := map[string]interface{}{
+		"email":       "a@b.com",
+		"level":       "warn",
+		"code":        "ABC12",
+		"temperature": float64(20.5),
+		"count":       float64(3),
+		"ts":          "2025-01-02T15:04:05Z",
+		"ip":          "192.168.1.1",
+		"id":          "6ba7b810-9dad-11d1-80b4-00c04fd430c8",
+		"items":       []interface{}{map[string]interface{}{"sku": "a", "qty": float64(1)}},
+	}
+	if err := s.Validate(valid); err != nil {
+		t.Errorf("Validate() error = %v", err)
+	}
+
+	invalid := map[string]interface{}{
+		"email":       "not-an-email",
+		"level":       "debug",
+		"code":        "abc",
+		"temperature": float64(200),
+		"count":       float64(1.5),
+		"ts":          "2025-01-02",
+		"ip":          "::1",
+		"id":          "123",
+		"items": []interface{}{
+			map[string]interface{}{"qty": float64(0)},
+			map[string]interface{}{"sku": "b"},
+			map[string]interface{}{"sku": "c"},
+		},
+	}
+	err = s.Validate(invalid)
+	var errs ValidationErrors
+	if !errors.As(err, &errs) {
+		t.Fatalf("Validate() error = %v, want ValidationErrors", err)
+	}
+	var got []string
+	for _, item := range errs {
+		got = append(got, item.Field+":"+item.Rule)
+	}
+	want := []string{
+		"code:minLength", "code:pattern", "count:type", "email:format", "id:format", "ip:format",
+		"items:maxItems", "items[0].sku:required", "items[0].qty:minimum", "level:enum", "temperature:maximum", "ts:format",
+	}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("Validate() errors = %v, want %v", got, want)
+	}
+
+	// 顶层数组
+	arraySchema, err := Parse(`{"type":"array","items":{"type":"string","enum":["a","b"]}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := arraySchema.ValidateValue([]interface{}{"a", "b"}); err != nil {
+		t.Errorf("ValidateValue() error = %v", err)
+	}
+	if err := arraySchema.ValidateValue([]interface{}{"a", "c"}); err == nil || !strings.Contains(err.Error(), "[1]") {
+		t.Errorf("ValidateValue() error = %v, want enum error of [1]", err)
+	}
+}
+
 // Example for Data struct (though it's simple, just to ensure it's used)
 func TestDataStruct(t *testing.T) {
 	data := Data{
@@ -266,6 +266,17 @@ func RemoveBraces(s string) string {
 	return result
 }
 
+// dateLayoutReplacer 把 yyyy-MM-dd HH:mm:ss.SSS 格式转换成go格式
+var dateLayoutReplacer = strings.NewReplacer(
+	"yyyy", "2006", "yy", "06", "MM", "01", "dd", "02",
+	"HH", "15", "mm", "04", "ss", "05", ".SSS", ".000",
+)
+
+// ConvertDateLayout 把 yyyy-MM-dd HH:mm:ss.SSS 格式的时间格式转换成go的时间格式，例如：yyyy-MM-dd -> 2006-01-02
+func ConvertDateLayout(layout string) string {
+	return dateLayoutReplacer.Replace(layout)
+}
+
 // ToLowerFirst 首字母转小写
 func ToLowerFirst(s string) string {
 	if s == "" {